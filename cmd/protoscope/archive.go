@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/archive"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// archiveRun keeps the report, exports and JSON results of a daemon run in
+// the config's archive directory, then prunes older runs. Failures are
+// logged, not fatal.
+func archiveRun(config *models.Config, started time.Time, results []*models.TestResult, info *models.RunInfo) {
+	if !config.Archive.Enabled() {
+		return
+	}
+
+	// The archive gets the files uploads get, and the results even when
+	// they are not signed
+	files := map[string][]byte{}
+	for _, file := range uploadFiles(config, results, info) {
+		files[file.name] = file.data
+	}
+	if _, ok := files["results.json"]; !ok {
+		if data, err := encodeResults(results, info); err == nil {
+			files["results.json"] = data
+		}
+	}
+
+	a := archive.New(config.Archive.Dir, archive.Policy{
+		Keep:   config.Archive.Keep,
+		Daily:  config.Archive.Daily,
+		Weekly: config.Archive.Weekly,
+	})
+	path, err := a.Save(started, files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to archive run: %v\n", err)
+		return
+	}
+	fmt.Printf("🗄 Run archived to %s\n", path)
+
+	removed, err := a.Prune()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to prune archive: %v\n", err)
+	}
+	if removed > 0 {
+		fmt.Printf("🗑 Removed %d archived run(s) past retention\n", removed)
+	}
+}
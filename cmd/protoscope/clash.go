@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/VenoMexx/ProtoScope/internal/clashapi"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// clashCommand tests the proxies of a running Clash/mihomo instance through
+// its external controller and optionally selects the best one in a group.
+func clashCommand(args []string) {
+	controller := flag.String("controller", "http://127.0.0.1:9090", "Clash external controller address")
+	secret := flag.String("secret", "", "Clash external controller secret")
+	group := flag.String("group", "", "Only test members of this proxy group")
+	selectBest := flag.Bool("select", false, "Select the best node in -group (must be a selector group)")
+	flag.CommandLine.Parse(args)
+
+	if *selectBest && *group == "" {
+		fmt.Fprintln(os.Stderr, "❌ Error: -select needs -group")
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	config := createConfig()
+	client := clashapi.NewClient(*controller, *secret)
+
+	nodes, err := client.Nodes(ctx, *group)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: failed to list Clash proxies: %v\n", err)
+		os.Exit(1)
+	}
+	if len(nodes) == 0 {
+		fmt.Println("No proxies found")
+		os.Exit(0)
+	}
+	fmt.Printf("🔍 Testing %d Clash proxies via %s...\n\n", len(nodes), *controller)
+
+	testURLs := config.APIEndpoints.ConnectivityEndpoints(config.TestConfig.Country)
+	results := make([]*models.TestResult, len(nodes))
+
+	concurrency := config.TestConfig.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var printMu sync.Mutex
+
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node clashapi.Proxy) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			timeout := config.TestConfig.TimeoutFor(node.ProtocolType())
+			result := client.Test(ctx, node, testURLs, timeout)
+			results[i] = result
+
+			printMu.Lock()
+			defer printMu.Unlock()
+			if result.Success {
+				fmt.Printf("✓ %s [%s] %dms\n", node.Name, node.Type, result.Connectivity.ResponseTime.Milliseconds())
+			} else {
+				fmt.Printf("✗ %s [%s] %s\n", node.Name, node.Type, result.Error)
+			}
+		}(i, node)
+	}
+	wg.Wait()
+
+	// Clash does the proxying, so no backend of ours is involved
+	info := newRunInfo(ctx, config, config.TestConfig.Country)
+	info.Backend = "clash"
+	info.BackendVersions = nil
+
+	fmt.Println()
+	if !outputToFile(config, results, info) {
+		switch *outputFormat {
+		case "json":
+			outputJSON(results, info)
+		case "markdown":
+			outputMarkdown(results, info)
+		case "html":
+			outputHTML(results, info)
+		case "csv":
+			outputCSV(results)
+		default:
+			outputConsole(results, info)
+		}
+	}
+
+	ranked := models.RankResults(results)
+	if len(ranked) == 0 {
+		fmt.Fprintln(os.Stderr, "✗ No working proxies")
+		os.Exit(1)
+	}
+	best := ranked[0]
+	fmt.Fprintf(os.Stderr, "🏆 Best proxy: %s (%dms)\n", best.Protocol.Name, best.Connectivity.ResponseTime.Milliseconds())
+
+	if *selectBest {
+		if err := client.Select(ctx, *group, best.Protocol.Name); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: failed to select %s in %s: %v\n", best.Protocol.Name, *group, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "✓ Selected %s in group %s\n", best.Protocol.Name, *group)
+	}
+}
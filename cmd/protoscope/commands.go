@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command is a protoscope subcommand
+type command struct {
+	description string
+	run         func(args []string)
+}
+
+// commands lists all subcommands by name
+var commands map[string]command
+
+func init() {
+	// Registered in init because commands refer back to printCommands
+	commands = map[string]command{
+		"clash": {
+			description: "Test the proxies of a running Clash/mihomo via its external controller",
+			run:         clashCommand,
+		},
+		"daemon": {
+			description: "Re-test nodes periodically and deliver reports (email)",
+			run:         daemonCommand,
+		},
+		"doctor": {
+			description: "Check backends, network and test endpoints before a run",
+			run:         doctorCommand,
+		},
+		"echo-server": {
+			description: "Serve the test endpoints yourself instead of using third-party services",
+			run:         echoServerCommand,
+		},
+		"healthcheck": {
+			description: "Exit non-zero unless a running daemon reports healthy (Docker HEALTHCHECK)",
+			run:         healthcheckCommand,
+		},
+		"history": {
+			description: "Show latency, speed and availability trends of nodes from runs recorded with -history",
+			run:         historyCommand,
+		},
+		"install-backend": {
+			description: "Download xray or sing-box built for this device (ARM, MIPS routers included)",
+			run:         installBackendCommand,
+		},
+		"notes": {
+			description: "Attach persistent notes and labels to nodes",
+			run:         notesCommand,
+		},
+		"publish": {
+			description: "Generate a static HTML site of the runs recorded with -history (GitHub Pages)",
+			run:         publishCommand,
+		},
+		"run-best": {
+			description: "Test nodes and keep a local proxy running through the best one",
+			run:         runBestCommand,
+		},
+		"sandbox-exec": {
+			description: "Internal: confine and run a proxy backend (see backend_sandbox)",
+			run:         sandboxExecCommand,
+		},
+		"secrets": {
+			description: "Keep notifier and API credentials in an encrypted file referenced from the config",
+			run:         secretsCommand,
+		},
+		"serve": {
+			description: "Serve an API to start test runs for subscription URLs and fetch their results",
+			run:         serveCommand,
+		},
+		"service": {
+			description: "Install or uninstall daemon mode as a systemd unit or Windows service",
+			run:         serviceCommand,
+		},
+		"update": {
+			description: "Update protoscope to the latest GitHub release",
+			run:         updateCommand,
+		},
+		"verify": {
+			description: "Check the signature of a signed JSON result file",
+			run:         verifyCommand,
+		},
+	}
+}
+
+// runCommand dispatches to a subcommand
+func runCommand(name string, args []string) {
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "❌ Unknown command: %s\n\n", name)
+		printCommands()
+		os.Exit(1)
+	}
+	cmd.run(args)
+}
+
+// printCommands prints the list of subcommands
+func printCommands() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("Commands:")
+	for _, name := range names {
+		fmt.Printf("  %-16s %s\n", name, commands[name].description)
+	}
+}
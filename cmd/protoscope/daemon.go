@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/logfile"
+	"github.com/VenoMexx/ProtoScope/internal/notify"
+	"github.com/VenoMexx/ProtoScope/internal/parser"
+	"github.com/VenoMexx/ProtoScope/internal/report"
+	"github.com/VenoMexx/ProtoScope/internal/server"
+	"github.com/VenoMexx/ProtoScope/internal/tester"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// daemonCommand re-tests all nodes periodically and delivers the report of
+// each run to the notifiers and upload targets configured in the -config file.
+// With -listen the latest results are also served over HTTP.
+func daemonCommand(args []string) {
+	interval := flag.Duration("interval", time.Hour, "Time between test runs")
+	once := flag.Bool("once", false, "Run a single test and deliver the report, then exit")
+	listen := flag.String("listen", "", "Serve the latest results over HTTP on this address, e.g. 127.0.0.1:8080 (GET /sub/working, /metrics)")
+	dnsListen := flag.String("dns-listen", "", "Answer DNS queries for -dns-name on this UDP address, e.g. 0.0.0.0:53")
+	dnsName := flag.String("dns-name", "best.protoscope.lan", "Hostname that resolves to the best node's server IP")
+	dnsTTL := flag.Duration("dns-ttl", 30*time.Second, "TTL of DNS answers")
+	stuckAfter := flag.Duration("stuck-after", 2*time.Hour, "Report unhealthy on /healthz when a run takes longer than this")
+	logFile := flag.String("log-file", "", "Write output to this file instead of the console, rotated by size")
+	logMaxSize := flag.Int("log-max-size", 10, "Rotate the log file when it reaches this many MB")
+	logKeep := flag.Int("log-keep", 5, "Number of rotated log files to keep")
+	flag.CommandLine.Parse(args)
+
+	if *logFile != "" {
+		closeLog, err := redirectOutput(*logFile, int64(*logMaxSize)<<20, *logKeep)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer closeLog()
+	}
+
+	if *interval <= 0 {
+		fmt.Fprintln(os.Stderr, "❌ Error: -interval must be positive")
+		os.Exit(1)
+	}
+
+	ctx, stop := daemonContext()
+	defer stop()
+
+	config := createConfig()
+
+	// Subscriptions from the config are re-fetched before every run so
+	// rotating tokens are followed; -url/-file are fetched once
+	var sources *sourceSet
+	var protocols []*models.Protocol
+	if len(config.Subscriptions) > 0 && *subscriptionURL == "" && *subscriptionFile == "" {
+		sources = newSourceSet(config.Subscriptions)
+	} else {
+		protocols = loadProtocols()
+	}
+
+	runner := newTestRunner(config)
+	// latest holds the nodes of the latest scheduled run for runs started
+	// through the server
+	var latest atomic.Pointer[[]*models.Protocol]
+
+	var srv *server.Server
+	if *listen != "" {
+		var base string
+		srv, base = startServer(ctx, config, *listen, &latest)
+		srv.SetInterval(*interval)
+		fmt.Printf("🌐 Serving subscription on %s/sub/working\n", base)
+	}
+	heartbeat := func(d time.Duration) {
+		if srv != nil {
+			srv.Heartbeat(d)
+		}
+	}
+
+	dns := startDNSResponder(ctx, *dnsListen, *dnsName, *dnsTTL)
+
+	notifying := config.Notify.Email.Enabled() || config.Notify.MQTT.Enabled() ||
+		len(config.Notify.UptimeKuma) > 0 || config.Notify.HomeAssistant.Enabled()
+	if !notifying && !config.Upload.Enabled() && !config.Archive.Enabled() && srv == nil && dns == nil {
+		fmt.Println("⚠ No notifiers or upload targets configured, reports are only printed as a summary")
+	}
+
+	for {
+		heartbeat(*stuckAfter)
+		if sources != nil {
+			protocols = sources.load(ctx)
+		}
+
+		latest.Store(&protocols)
+
+		started := time.Now()
+		if len(protocols) == 0 {
+			fmt.Fprintln(os.Stderr, "⚠ No nodes to test")
+		} else if results := runScheduled(ctx, srv, runner, protocols); results != nil {
+			if srv != nil {
+				srv.Update(results)
+			}
+			if dns != nil {
+				updateBestNodeDNS(ctx, dns, *dnsName, results)
+			}
+			info := newRunInfo(ctx, config, runner.Country())
+			deliverReports(ctx, config, results, info)
+			uploadResults(ctx, config, results, info)
+			archiveRun(config, started, results, info)
+			if *recordHistory {
+				saveHistory(started, results)
+			}
+		}
+
+		if *once {
+			break
+		}
+		// The interval can be changed through the server's /settings
+		wait := *interval
+		if srv != nil {
+			wait = srv.Interval()
+		}
+		// The loop checks in again right after sleeping; allow some slack
+		heartbeat(wait + 5*time.Minute)
+		sleepContext(ctx, wait)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	fmt.Println("👋 Stopped")
+}
+
+// runScheduled runs the scheduled tests, tracked by the server when there
+// is one
+func runScheduled(ctx context.Context, srv *server.Server, runner *tester.TestRunner, protocols []*models.Protocol) []*models.TestResult {
+	if srv == nil {
+		return runOnce(ctx, runner, protocols)
+	}
+
+	run := srv.StartRun("schedule")
+	run.SetNodes(len(protocols))
+	runner.SetProgress(run.Progress)
+	results := runOnce(ctx, runner, protocols)
+	if results == nil {
+		run.Finish(nil, errors.New("run failed or was interrupted"))
+	} else {
+		run.Finish(results, nil)
+	}
+	return results
+}
+
+// runOnce tests all nodes once. It returns nil when the run failed or was
+// interrupted.
+func runOnce(ctx context.Context, runner *tester.TestRunner, protocols []*models.Protocol) []*models.TestResult {
+	started := time.Now()
+	fmt.Printf("🔍 [%s] Testing %d nodes...\n", started.Format(time.DateTime), len(protocols))
+
+	results, err := runner.RunTests(ctx, protocols)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error running tests: %v\n", err)
+		return nil
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if err := runner.SaveResultCache(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to save result cache: %v\n", err)
+	}
+
+	summary := report.Summarize(results)
+	fmt.Printf("✓ Run finished in %s: %d working, %d failed\n", time.Since(started).Round(time.Second), summary.Working, summary.Failed)
+
+	return results
+}
+
+// deliverReports sends the report of one run to every configured notifier.
+// Delivery errors are logged so a flaky mail server doesn't stop the daemon.
+func deliverReports(ctx context.Context, config *models.Config, results []*models.TestResult, info *models.RunInfo) {
+	publishMQTT(ctx, config.Notify.MQTT, results)
+	pushStatus(ctx, config.Notify, results)
+
+	email := config.Notify.Email
+	if !email.Enabled() {
+		return
+	}
+
+	body, err := report.Render(email.Format, results, info)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to render email report: %v\n", err)
+		return
+	}
+
+	summary := report.Summarize(results)
+	subject := fmt.Sprintf("%s: %d/%d working", email.Subject, summary.Working, summary.Total)
+
+	sendCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	if err := notify.NewEmailNotifier(email).Send(sendCtx, subject, body, email.Format); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to email report: %v\n", err)
+		return
+	}
+	fmt.Printf("📧 Report emailed to %d recipient(s)\n", len(email.To))
+}
+
+// publishMQTT publishes the run's summary and node results to the
+// configured MQTT broker
+func publishMQTT(ctx context.Context, config models.MQTTConfig, results []*models.TestResult) {
+	if !config.Enabled() {
+		return
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	if err := notify.NewMQTTNotifier(config).Publish(publishCtx, results); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to publish results over MQTT: %v\n", err)
+		return
+	}
+	fmt.Printf("📡 Results of %d node(s) published to %s\n", len(results), config.Broker)
+}
+
+// pushStatus reports node status to the configured Uptime Kuma monitors
+// and Home Assistant webhook
+func pushStatus(ctx context.Context, config models.NotifyConfig, results []*models.TestResult) {
+	pushCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	if len(config.UptimeKuma) > 0 {
+		if err := notify.PushUptimeKuma(pushCtx, config.UptimeKuma, results); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Failed to push to Uptime Kuma: %v\n", err)
+		} else {
+			fmt.Printf("💓 Status pushed to %d Uptime Kuma monitor(s)\n", len(config.UptimeKuma))
+		}
+	}
+	if config.HomeAssistant.Enabled() {
+		if err := notify.PushHomeAssistant(pushCtx, config.HomeAssistant, results); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Failed to push to Home Assistant: %v\n", err)
+		} else {
+			fmt.Println("🏠 Status pushed to Home Assistant")
+		}
+	}
+}
+
+// sourceSet fetches the subscriptions configured in the config file
+type sourceSet struct {
+	sources []models.SubscriptionSource
+	last    [][]*models.Protocol // nodes of the last successful fetch per source
+}
+
+func newSourceSet(sources []models.SubscriptionSource) *sourceSet {
+	return &sourceSet{
+		sources: sources,
+		last:    make([][]*models.Protocol, len(sources)),
+	}
+}
+
+// load runs each source's pre-fetch hook, fetches it and returns the nodes of
+// all sources. A source that fails keeps the nodes of its last good fetch.
+func (s *sourceSet) load(ctx context.Context) []*models.Protocol {
+	decoder := parser.NewDecoder()
+	var protocols []*models.Protocol
+
+	for i, source := range s.sources {
+		name := source.Name
+		if name == "" {
+			name = fmt.Sprintf("subscription %d", i+1)
+		}
+
+		url, err := parser.ResolveSource(ctx, source, time.Now())
+		if err == nil {
+			var subscription *models.Subscription
+			subscription, err = decoder.DecodeSubscription(url)
+			if err == nil {
+				s.last[i] = filterProtocols(subscription.Protocols)
+				for _, protocol := range s.last[i] {
+					protocol.Subscription = name
+				}
+				fmt.Printf("📡 %s: %d nodes\n", name, len(s.last[i]))
+				printParseReport(subscription.Report)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %s: %v (keeping %d nodes from the last fetch)\n", name, err, len(s.last[i]))
+		}
+
+		protocols = append(protocols, s.last[i]...)
+	}
+
+	return protocols
+}
+
+// redirectOutput sends everything written to stdout and stderr to a rotated
+// log file. The returned function flushes and closes it.
+func redirectOutput(path string, maxSize int64, keep int) (func(), error) {
+	file, err := logfile.Open(path, maxSize, keep)
+	if err != nil {
+		return nil, fmt.Errorf("opening log file: %w", err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	os.Stdout = w
+	os.Stderr = w
+
+	done := make(chan struct{})
+	go func() {
+		io.Copy(file, r)
+		close(done)
+	}()
+
+	return func() {
+		w.Close()
+		<-done
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,46 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// daemonContext returns a context that is cancelled on Ctrl+C, or, when
+// running as a Windows service, when the service manager stops the service
+func daemonContext() (context.Context, context.CancelFunc) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return signal.NotifyContext(context.Background(), os.Interrupt)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go svc.Run("", serviceHandler{cancel: cancel})
+	return ctx, cancel
+}
+
+// serviceHandler reports the daemon as running and stops it on request
+type serviceHandler struct {
+	cancel context.CancelFunc
+}
+
+// Execute implements svc.Handler
+func (h serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for request := range requests {
+		switch request.Cmd {
+		case svc.Interrogate:
+			status <- request.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			status <- svc.Status{State: svc.StopPending}
+			h.cancel()
+			return false, 0
+		}
+	}
+	return false, 0
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/dnsresponder"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// startDNSResponder starts answering queries for name on listen, or returns
+// nil when listen is empty. A failure to bind exits the process.
+func startDNSResponder(ctx context.Context, listen, name string, ttl time.Duration) *dnsresponder.Responder {
+	if listen == "" {
+		return nil
+	}
+
+	responder := dnsresponder.New(name, ttl)
+	go func() {
+		if err := responder.ListenAndServe(ctx, listen); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: DNS responder: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+	fmt.Printf("🌐 Answering DNS for %s on %s\n", name, listen)
+
+	return responder
+}
+
+// updateBestNodeDNS points the DNS name at the server of the best working
+// node. Without a working node the previous answer is kept.
+func updateBestNodeDNS(ctx context.Context, responder *dnsresponder.Responder, name string, results []*models.TestResult) {
+	ranked := models.RankResults(results)
+	for _, best := range ranked {
+		addrs, err := serverAddresses(ctx, best)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Failed to resolve %s: %v\n", best.Protocol.Server, err)
+			continue
+		}
+		responder.SetAddresses(addrs)
+		fmt.Printf("🌐 %s → %s (%s)\n", name, addrs[0], best.Protocol.Name)
+		return
+	}
+}
+
+// serverAddresses returns the IPs of a node's server, reusing the addresses
+// resolved during the test when there are any
+func serverAddresses(ctx context.Context, result *models.TestResult) ([]net.IP, error) {
+	var addrs []net.IP
+	for _, resolved := range result.ResolvedIPs {
+		if ip := net.ParseIP(resolved); ip != nil {
+			addrs = append(addrs, ip)
+		}
+	}
+	if len(addrs) > 0 {
+		return addrs, nil
+	}
+
+	if ip := net.ParseIP(result.Protocol.Server); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	ipAddrs, err := net.DefaultResolver.LookupIPAddr(ctx, result.Protocol.Server)
+	if err != nil {
+		return nil, err
+	}
+	for _, ipAddr := range ipAddrs {
+		addrs = append(addrs, ipAddr.IP)
+	}
+	return addrs, nil
+}
+
+// localAddress returns the IP clients should use to reach a proxy listening
+// on host: host itself, or this machine's primary address when host is a
+// wildcard
+func localAddress(host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+		return ip, nil
+	}
+
+	// Connecting a UDP socket sends nothing but picks the outgoing interface
+	conn, err := net.Dial("udp", "192.0.2.1:53")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/checks"
+	"github.com/VenoMexx/ProtoScope/internal/tester"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// diagnosis is the outcome of one doctor check
+type diagnosis struct {
+	name   string
+	status string // ok, warn, fail
+	detail string
+	fix    string // what to do about a warning or failure
+}
+
+const (
+	diagOK   = "ok"
+	diagWarn = "warn"
+	diagFail = "fail"
+)
+
+// doctorCommand checks the environment for the usual causes of every node
+// failing and prints how to fix them
+func doctorCommand(args []string) {
+	checkTimeout := flag.Duration("check-timeout", 5*time.Second, "Timeout of each network check")
+	flag.CommandLine.Parse(args)
+
+	config := createConfig()
+
+	checks := []func(context.Context, *models.Config, time.Duration) []diagnosis{
+		diagnoseBackends,
+		diagnoseUDP,
+		diagnoseIPv6,
+		diagnoseDNS,
+		diagnoseClock,
+		diagnoseFileLimit,
+		diagnoseEndpoints,
+	}
+
+	ctx := context.Background()
+	failed := 0
+	for _, check := range checks {
+		for _, d := range check(ctx, config, *checkTimeout) {
+			printDiagnosis(d)
+			if d.status == diagFail {
+				failed++
+			}
+		}
+	}
+
+	fmt.Println()
+	if failed > 0 {
+		fmt.Printf("✗ %d problem(s) found\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("✓ Environment looks good")
+}
+
+func printDiagnosis(d diagnosis) {
+	icon := "✓"
+	switch d.status {
+	case diagWarn:
+		icon = "⚠"
+	case diagFail:
+		icon = "✗"
+	}
+	fmt.Printf("%s %s: %s\n", icon, d.name, d.detail)
+	if d.status != diagOK && d.fix != "" {
+		fmt.Printf("  → %s\n", d.fix)
+	}
+}
+
+// diagnoseBackends looks for the proxy backends and their versions. The
+// configured backend, or sing-box by default, is required.
+func diagnoseBackends(ctx context.Context, config *models.Config, timeout time.Duration) []diagnosis {
+	required := tester.ProxyBackend(config.TestConfig.Backend)
+	if required == "" {
+		required = tester.BackendSingbox
+	}
+	if required == tester.BackendMock {
+		return []diagnosis{{name: "Backend", status: diagOK, detail: "mock backend, no binary needed"}}
+	}
+
+	var results []diagnosis
+	for _, backend := range []tester.ProxyBackend{tester.BackendSingbox, tester.BackendXray} {
+		binary := tester.GetBackendBinary(backend)
+		d := diagnosis{name: "Backend " + binary}
+
+		path, err := tester.FindBackend(backend)
+		if err != nil {
+			d.status = diagWarn
+			d.detail = "not found in PATH or next to protoscope"
+			if backend == required {
+				d.status = diagFail
+				d.fix = fmt.Sprintf("run protoscope install-backend -backend %s, or install it (see README, Installing Sing-box) and put it on PATH", binary)
+			}
+			results = append(results, d)
+			continue
+		}
+
+		versionCtx, cancel := context.WithTimeout(ctx, timeout)
+		out, err := exec.CommandContext(versionCtx, path, "version").Output()
+		cancel()
+		if err != nil {
+			d.status = diagFail
+			d.detail = fmt.Sprintf("%s does not run: %v", path, err)
+			d.fix = "reinstall it for this OS and architecture"
+			results = append(results, d)
+			continue
+		}
+
+		d.status = diagOK
+		d.detail = fmt.Sprintf("%s (%s)", strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0]), path)
+		results = append(results, d)
+	}
+	return results
+}
+
+// diagnoseUDP sends a DNS query to public resolvers over UDP. Hysteria2 and
+// TUIC need outbound UDP.
+func diagnoseUDP(ctx context.Context, config *models.Config, timeout time.Duration) []diagnosis {
+	d := diagnosis{name: "UDP"}
+	var lastErr error
+	for _, server := range []string{"1.1.1.1:53", "8.8.8.8:53"} {
+		if lastErr = udpDNSQuery(server, timeout); lastErr == nil {
+			d.status = diagOK
+			d.detail = "outbound UDP works (DNS to " + server + ")"
+			return []diagnosis{d}
+		}
+	}
+	d.status = diagWarn
+	d.detail = fmt.Sprintf("no UDP reply from public resolvers: %v", lastErr)
+	d.fix = "hysteria2 and tuic nodes will fail; allow outbound UDP in your firewall or network"
+	return []diagnosis{d}
+}
+
+// udpDNSQuery sends an A query for example.com and waits for any reply
+func udpDNSQuery(server string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	id := make([]byte, 2)
+	rand.Read(id)
+	query := append(id, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)
+	for _, label := range []string{"example", "com"} {
+		query = append(query, byte(len(label)))
+		query = append(query, label...)
+	}
+	query = append(query, 0x00, 0x00, 0x01, 0x00, 0x01)
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write(query); err != nil {
+		return err
+	}
+	reply := make([]byte, 512)
+	n, err := conn.Read(reply)
+	if err != nil {
+		return err
+	}
+	if n < 2 || binary.BigEndian.Uint16(reply) != binary.BigEndian.Uint16(id) {
+		return fmt.Errorf("unexpected reply from %s", server)
+	}
+	return nil
+}
+
+// diagnoseIPv6 checks for IPv6 connectivity; without it IPv6 leak tests
+// can't find leaks and IPv6-only nodes fail
+func diagnoseIPv6(ctx context.Context, config *models.Config, timeout time.Duration) []diagnosis {
+	d := diagnosis{name: "IPv6"}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp6", "[2606:4700:4700::1111]:443")
+	if err != nil {
+		d.status = diagWarn
+		d.detail = "no IPv6 connectivity"
+		d.fix = "IPv6-only nodes will fail and IPv6 leak tests have nothing to leak; fine if you don't use IPv6"
+		return []diagnosis{d}
+	}
+	conn.Close()
+	d.status = diagOK
+	d.detail = "available"
+	return []diagnosis{d}
+}
+
+// diagnoseDNS checks that the system resolver works and doesn't rewrite
+// failed lookups, which breaks DNS blocking and leak results
+func diagnoseDNS(ctx context.Context, config *models.Config, timeout time.Duration) []diagnosis {
+	d := diagnosis{name: "DNS"}
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(lookupCtx, "www.google.com")
+	if err != nil {
+		d.status = diagFail
+		d.detail = "system resolver failed: " + err.Error()
+		d.fix = "check /etc/resolv.conf or your network's DNS settings"
+		return []diagnosis{d}
+	}
+	for _, addr := range addrs {
+		if addr.IP.IsPrivate() || addr.IP.IsLoopback() || addr.IP.IsUnspecified() {
+			d.status = diagWarn
+			d.detail = fmt.Sprintf("www.google.com resolves to %s", addr.IP)
+			d.fix = "your resolver filters or hijacks names; DNS blocking results will be unreliable"
+			return []diagnosis{d}
+		}
+	}
+
+	label := make([]byte, 8)
+	rand.Read(label)
+	bogus := "protoscope-" + hex.EncodeToString(label) + ".example.com"
+	if addrs, err := net.DefaultResolver.LookupIPAddr(lookupCtx, bogus); err == nil && len(addrs) > 0 {
+		d.status = diagWarn
+		d.detail = fmt.Sprintf("non-existent names resolve (to %s)", addrs[0].IP)
+		d.fix = "your ISP redirects failed lookups; switch to a public resolver so DNS checks are meaningful"
+		return []diagnosis{d}
+	}
+
+	d.status = diagOK
+	d.detail = "system resolver works"
+	return []diagnosis{d}
+}
+
+// diagnoseClock compares the local clock with NTP, or with a server's Date
+// header where UDP is blocked. VMess rejects clients more than 90 seconds
+// off, Shadowsocks 2022 more than 30, and TLS needs a roughly correct clock.
+func diagnoseClock(ctx context.Context, config *models.Config, timeout time.Duration) []diagnosis {
+	d := diagnosis{name: "Clock"}
+	client := &http.Client{Timeout: timeout}
+
+	checkCtx, cancel := context.WithTimeout(ctx, 2*timeout)
+	defer cancel()
+	// Connectivity endpoints send a Date header too and are reachable
+	// wherever testing works at all
+	httpURLs := append(slices.Clone(config.APIEndpoints.TimeHTTP), config.APIEndpoints.Connectivity...)
+	skew, err := checks.MeasureClockSkew(checkCtx, client, config.APIEndpoints.NTP, httpURLs)
+	if err != nil {
+		d.status = diagWarn
+		d.detail = fmt.Sprintf("could not compare: %v", err)
+		return []diagnosis{d}
+	}
+
+	switch {
+	case skew.Abs() > 90*time.Second:
+		d.status = diagFail
+		d.fix = "VMess nodes reject clocks off by more than 90s; enable NTP (e.g. timedatectl set-ntp true)"
+	case skew.Abs() > 30*time.Second:
+		d.status = diagFail
+		d.fix = "Shadowsocks 2022 nodes reject clocks off by more than 30s; enable NTP (e.g. timedatectl set-ntp true)"
+	case skew.Abs() > 10*time.Second:
+		d.status = diagWarn
+		d.fix = "enable NTP time sync"
+	default:
+		d.status = diagOK
+	}
+	d.detail = skew.String()
+	return []diagnosis{d}
+}
+
+// diagnoseFileLimit checks the open-file limit against the concurrency;
+// every node under test holds a backend process and several sockets
+func diagnoseFileLimit(ctx context.Context, config *models.Config, timeout time.Duration) []diagnosis {
+	d := diagnosis{name: "Open files"}
+	limit, ok := openFileLimit()
+	if !ok {
+		d.status = diagOK
+		d.detail = "no limit to check on this OS"
+		return []diagnosis{d}
+	}
+
+	needed := uint64(max(config.TestConfig.Concurrency, 1))*64 + 256
+	d.detail = fmt.Sprintf("limit %d, concurrency %d needs about %d", limit, config.TestConfig.Concurrency, needed)
+	if limit < needed {
+		d.status = diagWarn
+		d.fix = "raise it with ulimit -n 65535 (or LimitNOFILE= in the systemd unit), or lower -concurrency"
+		return []diagnosis{d}
+	}
+	d.status = diagOK
+	return []diagnosis{d}
+}
+
+// diagnoseEndpoints checks that the public test endpoints are reachable
+// directly; checks through nodes can't pass if they are down or blocked
+func diagnoseEndpoints(ctx context.Context, config *models.Config, timeout time.Duration) []diagnosis {
+	groups := []struct {
+		name     string
+		urls     []string
+		required bool
+	}{
+		{"Connectivity endpoints", config.APIEndpoints.Connectivity, true},
+		{"IP-check endpoints", config.APIEndpoints.IPCheck, true},
+		{"Geolocation endpoints", config.APIEndpoints.GeoLocation, false},
+		{"Speed test endpoints", config.APIEndpoints.SpeedTest, false},
+	}
+
+	client := &http.Client{Timeout: timeout}
+	var results []diagnosis
+	for _, group := range groups {
+		if len(group.urls) == 0 {
+			continue
+		}
+
+		d := diagnosis{name: group.name}
+		var failed []string
+		for _, url := range group.urls {
+			req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+			if err != nil {
+				failed = append(failed, url)
+				continue
+			}
+			// Headers are enough; speed test bodies are large
+			resp, err := client.Do(req)
+			if err != nil || resp.StatusCode >= 500 {
+				failed = append(failed, url)
+			}
+			if err == nil {
+				resp.Body.Close()
+			}
+		}
+
+		reachable := len(group.urls) - len(failed)
+		d.detail = fmt.Sprintf("%d/%d reachable", reachable, len(group.urls))
+		switch {
+		case len(failed) == 0:
+			d.status = diagOK
+		case reachable == 0 && group.required:
+			d.status = diagFail
+			d.detail += " (" + strings.Join(failed, ", ") + ")"
+			d.fix = "your network blocks them; set reachable alternatives under api_endpoints in the config file"
+		default:
+			d.status = diagWarn
+			d.detail += " (unreachable: " + strings.Join(failed, ", ") + ")"
+			d.fix = "set reachable alternatives under api_endpoints in the config file"
+		}
+		results = append(results, d)
+	}
+	return results
+}
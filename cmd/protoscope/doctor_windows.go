@@ -0,0 +1,7 @@
+package main
+
+// openFileLimit reports no limit; Windows has no per-process descriptor cap
+// worth checking
+func openFileLimit() (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/VenoMexx/ProtoScope/internal/echoserver"
+)
+
+// echoServerCommand runs the test endpoints on this machine, so checks can
+// be pointed at it via api_endpoints instead of third-party services
+func echoServerCommand(args []string) {
+	listen := flag.String("listen", ":8080", "HTTP address of the connectivity, IP, speed and canary endpoints")
+	dnsListen := flag.String("dns-listen", "", "UDP address of the DNS canary, e.g. :53 (disabled if empty)")
+	dnsZone := flag.String("dns-zone", "", "Zone delegated to -dns-listen, e.g. canary.example.com")
+	dnsAnswer := flag.String("dns-answer", "", "Address canary names resolve to, normally this server's public IP")
+	egressPorts := flag.String("egress-ports", "", "Extra TCP ports answering HTTP for egress checks, e.g. 25,465,587,22,3389")
+	flag.CommandLine.Parse(args)
+
+	var answer net.IP
+	if *dnsListen != "" {
+		if *dnsZone == "" {
+			fmt.Fprintln(os.Stderr, "❌ Error: -dns-listen needs -dns-zone")
+			os.Exit(1)
+		}
+		if answer = net.ParseIP(*dnsAnswer); answer == nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: invalid -dns-answer address %q\n", *dnsAnswer)
+			os.Exit(1)
+		}
+	}
+
+	var ports []int
+	for _, item := range splitList(*egressPorts) {
+		port, err := strconv.Atoi(item)
+		if err != nil || port < 1 || port > 65535 {
+			fmt.Fprintf(os.Stderr, "❌ Error: invalid -egress-ports entry %q\n", item)
+			os.Exit(1)
+		}
+		ports = append(ports, port)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	zone := ""
+	if *dnsListen != "" {
+		zone = *dnsZone
+	}
+	server := echoserver.New(zone, answer)
+
+	if *dnsListen != "" {
+		go func() {
+			if err := server.ServeDNS(ctx, *dnsListen); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Error: DNS canary: %v\n", err)
+				os.Exit(1)
+			}
+		}()
+		fmt.Printf("🌐 DNS canary for *.%s on %s → %s\n", *dnsZone, *dnsListen, answer)
+	}
+
+	for _, port := range ports {
+		addr := net.JoinHostPort("", strconv.Itoa(port))
+		go func() {
+			if err := server.ListenAndServe(ctx, addr); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Error: egress port %s: %v\n", addr, err)
+				os.Exit(1)
+			}
+		}()
+	}
+	if len(ports) > 0 {
+		fmt.Printf("🚪 Answering egress probes on ports %s\n", *egressPorts)
+	}
+
+	fmt.Printf("🛰  Echo server on %s\n", *listen)
+	if err := server.ListenAndServe(ctx, *listen); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+}
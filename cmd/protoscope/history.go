@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/store"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+const historyUsage = `Usage:
+  protoscope history [-days 7] [-history-db <file>]          trends of every node: last N days vs the N before
+  protoscope history [-days 7] [-history-db <file>] <node>   every run of one node (name or fingerprint prefix)
+
+Runs are recorded with -history.`
+
+// saveHistory records a run in the history database (-history)
+func saveHistory(started time.Time, results []*models.TestResult) {
+	history, err := store.Open(*historyDB)
+	if err == nil {
+		err = history.SaveRun(started, results)
+		history.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to record history: %v\n", err)
+	}
+}
+
+// historyCommand shows latency, speed and availability trends from the
+// history database
+func historyCommand(args []string) {
+	days := flag.Int("days", 7, "Length of the period compared with the one before it")
+	flag.CommandLine.Parse(args)
+
+	if *days <= 0 || flag.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, historyUsage)
+		os.Exit(1)
+	}
+	if _, err := os.Stat(*historyDB); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "❌ Error: no history at %s; record runs with -history\n", *historyDB)
+		os.Exit(1)
+	}
+
+	history, err := store.Open(*historyDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer history.Close()
+
+	period := time.Duration(*days) * 24 * time.Hour
+	split := time.Now().Add(-period)
+
+	if flag.NArg() == 1 {
+		points, err := history.Points(split, flag.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		printNodeHistory(flag.Arg(0), points, *days)
+		return
+	}
+
+	points, err := history.Points(split.Add(-period), "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	printTrends(store.Trends(points, split), *days)
+}
+
+// printTrends prints each node's availability, median latency and median
+// speed in the previous period and the last one
+func printTrends(trends []store.Trend, days int) {
+	if len(trends) == 0 {
+		fmt.Printf("No runs in the last %d days\n", 2*days)
+		return
+	}
+
+	fmt.Printf("📈 Last %d days compared with the %d days before (previous → last)\n", days, days)
+	for _, trend := range trends {
+		fmt.Printf("\n%s  %s [%s]\n", trend.Fingerprint[:12], trend.Name, trend.Type)
+		fmt.Printf("   availability %s   latency %s   speed %s\n",
+			trendValues(trend.Previous, trend.Current, availabilityLabel),
+			trendValues(trend.Previous, trend.Current, latencyLabel),
+			trendValues(trend.Previous, trend.Current, speedLabel))
+	}
+}
+
+// trendValues renders a value of both periods, "-" for a period without
+// runs or measurements
+func trendValues(previous, current store.Period, label func(store.Period) string) string {
+	return label(previous) + " → " + label(current)
+}
+
+func availabilityLabel(p store.Period) string {
+	if p.Runs == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", p.Availability())
+}
+
+func latencyLabel(p store.Period) string {
+	if p.Latency == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dms", p.Latency.Milliseconds())
+}
+
+func speedLabel(p store.Period) string {
+	if p.Speed == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f Mbps", p.Speed)
+}
+
+// printNodeHistory prints every recorded run of the nodes matching query
+func printNodeHistory(query string, points []store.Point, days int) {
+	if len(points) == 0 {
+		fmt.Fprintf(os.Stderr, "❌ Error: no runs of %q in the last %d days\n", query, days)
+		os.Exit(1)
+	}
+
+	fingerprint := ""
+	for _, point := range points {
+		if point.Fingerprint != fingerprint {
+			fingerprint = point.Fingerprint
+			fmt.Printf("\n%s  %s [%s]\n", fingerprint[:12], point.Name, point.Type)
+		}
+		status := "✗ failed"
+		if point.Success {
+			status = "✓ working"
+		}
+		line := fmt.Sprintf("   %s  %-10s", point.Started.Local().Format("2006-01-02 15:04"), status)
+		if point.Latency > 0 {
+			line += fmt.Sprintf("  %5dms", point.Latency.Milliseconds())
+		}
+		if point.Download > 0 {
+			line += fmt.Sprintf("  ↓ %.1f Mbps", point.Download)
+		}
+		if point.Upload > 0 {
+			line += fmt.Sprintf("  ↑ %.1f Mbps", point.Upload)
+		}
+		fmt.Println(line)
+	}
+}
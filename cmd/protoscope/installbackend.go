@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/update"
+)
+
+// installBackendCommand downloads the latest xray or sing-box release built
+// for this platform, by default next to the protoscope binary where the
+// tester finds it
+func installBackendCommand(args []string) {
+	platform := update.CurrentPlatform()
+	backend := flag.String("backend", "sing-box", "Backend to install: sing-box, xray")
+	dir := flag.String("dir", "", "Directory to install into (default: next to protoscope)")
+	flag.StringVar(&platform.OS, "os", platform.OS, "Target OS, to install for another device")
+	flag.StringVar(&platform.Arch, "arch", platform.Arch, "Target architecture (GOARCH), e.g. arm, mipsle")
+	flag.IntVar(&platform.ARM, "arm", platform.ARM, "ARM version for -arch arm: 5, 6 or 7")
+	flag.StringVar(&platform.MIPSFloat, "mips-float", platform.MIPSFloat, "Float ABI for -arch mips/mipsle: hardfloat, softfloat (routers without an FPU)")
+	insecure := flag.Bool("insecure", false, "Install a build the release publishes no checksum for")
+	flag.CommandLine.Parse(args)
+
+	repo, ok := update.BackendRepos[*backend]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "❌ Error: unknown backend %q (want sing-box or xray)\n", *backend)
+		os.Exit(1)
+	}
+	if *dir == "" {
+		executable, err := os.Executable()
+		if err == nil {
+			executable, err = filepath.EvalSymlinks(executable)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: cannot locate the protoscope binary, pass -dir: %v\n", err)
+			os.Exit(1)
+		}
+		*dir = filepath.Dir(executable)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	updater := update.New(repo)
+	release, err := updater.Latest(ctx, update.ChannelStable)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("⬇ Downloading %s %s for %s\n", *backend, release.Tag, platform)
+
+	binary, verified, err := updater.DownloadBackend(ctx, release, *backend, platform)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !verified {
+		if !*insecure {
+			fmt.Fprintf(os.Stderr, "❌ Error: %s %s publishes no checksum for this build; pass -insecure to install it anyway\n", *backend, release.Tag)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "⚠ %s publishes no checksum for this build; relying on HTTPS from GitHub\n", *backend)
+	}
+
+	name := *backend
+	if platform.OS == "windows" {
+		name += ".exe"
+	}
+	path := filepath.Join(*dir, name)
+	if err := update.Install(path, binary); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: installing %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Installed %s %s to %s\n", *backend, release.Tag, path)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"runtime/debug"
+	"sort"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// lowMemoryHeap is the heap size the garbage collector works to stay under
+// with -low-memory; the backends need the rest of a small router's RAM
+const lowMemoryHeap = 48 << 20
+
+// applyLowMemory makes the garbage collector run more often and aim for
+// lowMemoryHeap, unless GOGC or GOMEMLIMIT already tune it
+func applyLowMemory() {
+	if os.Getenv("GOGC") == "" {
+		debug.SetGCPercent(50)
+	}
+	if os.Getenv("GOMEMLIMIT") == "" {
+		debug.SetMemoryLimit(lowMemoryHeap)
+	}
+}
+
+// resultSpool keeps the results of a -low-memory run on disk, a JSON line
+// each written as the node finishes, rather than in memory while the
+// backends run. The reports are built from it once they have exited.
+type resultSpool struct {
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// spooledResult is a line of the spool; results finish out of order
+type spooledResult struct {
+	Index  int                `json:"index"`
+	Result *models.TestResult `json:"result"`
+}
+
+// newResultSpool creates the spool in the temporary directory
+func newResultSpool() (*resultSpool, error) {
+	file, err := os.CreateTemp("", "protoscope-results-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	writer := bufio.NewWriter(file)
+	return &resultSpool{file: file, writer: writer, encoder: json.NewEncoder(writer)}, nil
+}
+
+// add writes the result of the node at index to disk
+func (s *resultSpool) add(index int, result *models.TestResult) error {
+	if err := s.encoder.Encode(spooledResult{Index: index, Result: result}); err != nil {
+		return err
+	}
+	return s.writer.Flush()
+}
+
+// results reads the results back in node order and removes the spool
+func (s *resultSpool) results() ([]*models.TestResult, error) {
+	defer os.Remove(s.file.Name())
+	defer s.file.Close()
+
+	if err := s.writer.Flush(); err != nil {
+		return nil, err
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var spooled []spooledResult
+	decoder := json.NewDecoder(bufio.NewReader(s.file))
+	for {
+		var line spooledResult
+		if err := decoder.Decode(&line); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		spooled = append(spooled, line)
+	}
+
+	sort.Slice(spooled, func(i, j int) bool { return spooled[i].Index < spooled[j].Index })
+	results := make([]*models.TestResult, len(spooled))
+	for i, line := range spooled {
+		results[i] = line.Result
+	}
+	return results, nil
+}
@@ -1,41 +1,300 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/VenoMexx/ProtoScope/internal/cache"
+	"github.com/VenoMexx/ProtoScope/internal/checks"
+	"github.com/VenoMexx/ProtoScope/internal/export"
+	"github.com/VenoMexx/ProtoScope/internal/i18n"
 	"github.com/VenoMexx/ProtoScope/internal/parser"
+	"github.com/VenoMexx/ProtoScope/internal/report"
+	"github.com/VenoMexx/ProtoScope/internal/store"
 	"github.com/VenoMexx/ProtoScope/internal/tester"
 	"github.com/VenoMexx/ProtoScope/pkg/models"
 )
 
+// version is the release version, set at build time with
+// -ldflags "-X main.version=..."
+var version = "v0.2.0"
+
 var (
 	subscriptionURL  = flag.String("url", "", "Subscription URL to test")
 	subscriptionFile = flag.String("file", "", "Subscription file to test (alternative to -url)")
-	outputFormat     = flag.String("format", "console", "Output format (console, json, markdown)")
+	configFile       = flag.String("config", "", "YAML config file (flags given on the command line override it)")
+	outputFormat     = flag.String("format", "console", "Output format (console, json, markdown, html, csv)")
+	outputPath       = flag.String("output", "", "Write the report to this file instead of stdout (format by extension with -format console; CSV gets its run info in a .runinfo.json beside it)")
 	timeout          = flag.Duration("timeout", 30*time.Second, "Timeout for each test")
 	concurrency      = flag.Int("concurrent", 3, "Number of concurrent tests")
 	quickMode        = flag.Bool("quick", false, "Quick mode (connectivity only)")
 	verbose          = flag.Bool("verbose", false, "Verbose output")
+	language         = flag.String("lang", "", "Language of console and markdown output: en, zh-CN, fa-IR, ru-RU (default: from LANG/LC_ALL)")
 	noSpeedTest      = flag.Bool("no-speed", false, "Disable speed tests")
+	lowMemory        = flag.Bool("low-memory", false, "Fit into 128-256 MB of RAM (routers): test one node at a time with small connection pools")
+	meteredMode      = flag.Bool("metered", false, "Cap speed test data for testing over mobile data (1 MB per node, 50 MB per run by default) and skip sustained tests")
 	noGeoTest        = flag.Bool("no-geo", false, "Disable geo-access tests")
 	noDNSTest        = flag.Bool("no-dns", false, "Disable DNS tests")
+	blocklistSample  = flag.Int("blocklist-sample", 0, "Also test this many domains from the start of each ad, tracker and malware blocklist in the DNS blocking test (e.g. 100)")
 	noPrivacyTest    = flag.Bool("no-privacy", false, "Disable privacy tests")
-	protocolsFilter  = flag.String("protocols", "", "Filter protocols (comma-separated: vmess,vless,trojan,shadowsocks,hysteria2,tuic)")
+	protocolsFilter  = flag.String("protocols", "", "Filter protocols (comma-separated: vmess,vless,trojan,shadowsocks,hysteria2,tuic,wireguard,ssh)")
+	pingServers      = flag.Bool("ping", false, "ICMP ping each server directly (raw sockets need root, falls back to unprivileged ICMP)")
+	traceServers     = flag.Bool("trace", false, "Traceroute to each server directly, reporting hop count and worst hop (needs root or CAP_NET_RAW)")
+	probeMTU         = flag.Bool("mtu", false, "Probe the path MTU of hysteria2/tuic servers to find fragmentation issues (Linux, needs root or CAP_NET_RAW)")
+	probeEgress      = flag.Bool("egress", false, "Probe which outbound ports (SMTP, SSH, RDP) each exit blocks")
+	sensitiveSites   = flag.Bool("sensitive", false, "Check which banking and government sites (test_config.sensitive_sites) each exit reaches without fraud blocks")
+	gamingTest       = flag.Bool("gaming", false, "Measure latency and jitter to game service regions (test_config.gaming_endpoints) and rate each node for online games")
+	streamingCheck   = flag.Bool("streaming", false, "Check which streaming and AI services (Netflix, Disney+, YouTube Premium, ChatGPT) each exit unlocks, and in which region")
+	edgeTest         = flag.Bool("cdn-edge", false, "Compare downloads from the CDN edge near each exit and the one near you, separating node bandwidth from the path to it")
+	ooklaTest        = flag.Bool("ookla", false, "Also run the speedtest.net TCP test against a server near each exit, for numbers comparable with speedtest.net")
+	tlsFingerprint   = flag.Bool("tls-fingerprint", false, "Record the TLS ClientHello (JA3) the backend sends to each TLS node")
+	obfsCheck        = flag.Bool("obfs-check", false, "Check that REALITY and salamander nodes don't send recognisable proxy traffic")
+	activeProbe      = flag.Bool("active-probe", false, "Probe each server directly with invalid handshakes and wrong credentials and rate its resistance to active probing")
+	testAllIPs       = flag.Bool("all-ips", false, "Test every resolved IP of multi-IP/anycast servers separately")
+	exportFailover   = flag.String("export-failover", "", "Write a failover group of working nodes ordered by score to this file")
+	failoverFormat   = flag.String("failover-format", "", "Failover export format: singbox, clash (default: by file extension)")
+	exportProfiles   = flag.String("export-profiles", "", "Write working nodes as client profiles grouped by grade (A-D) to this file")
+	profilesFormat   = flag.String("profiles-format", "v2rayn", "Profile export format: v2rayn, nekobox")
+	scorecardFile    = flag.String("scorecard", "", "Write a one-page provider scorecard grading the subscription by category to this file (.html for HTML, markdown otherwise)")
+	scorecardName    = flag.String("scorecard-name", "", "Provider name on the scorecard (default: the subscription's name, or the host of -url)")
+	rawDir           = flag.String("raw-dir", "", "Write every latency sample, per-second throughput and per-domain timing of each node to a JSON file per node in this directory")
+	jsonOut          = flag.String("json-out", "", "Also write the JSON results to this file, signed as <file>.minisig when signing is configured")
+	slowThreshold    = flag.Duration("slow-threshold", 5*time.Second, "Skip privacy and streaming checks on nodes slower than this (0 = never skip)")
+	shuffle          = flag.Bool("shuffle", false, "Test nodes in random order (avoids rate-limit patterns on test endpoints)")
+	shuffleSeed      = flag.Int64("seed", 0, "Seed for -shuffle to reproduce a previous order (default: random, printed at start)")
+	rateLimit        = flag.Float64("rate-limit", 5, "Max requests per second to each test endpoint host across all workers (0 = unlimited)")
+	connectivityURLs = flag.String("connectivity-url", "", "Connectivity endpoints tried as fallbacks, any success counts (comma-separated, default: gstatic, cp.cloudflare.com, captive.apple.com)")
+	userCountry      = flag.String("country", "", "Your country code for regional connectivity endpoints (default: detected from your IP)")
+	ipCheckEndpoints = flag.String("ip-check", "", "IP-check endpoints to rotate among (comma-separated URLs, default: ipify, ifconfig.me, icanhazip)")
+	retestFailed     = flag.String("retest-failed", "", "Re-test only failed nodes from a previous -format json report and merge the new results into it")
+	retestBelow      = flag.Int("retest-below", 0, "With -retest-failed, also re-test working nodes scoring below this (0-100)")
+	skipUnchanged    = flag.Bool("skip-unchanged", false, "Reuse recent results of nodes whose settings did not change instead of re-testing them")
+	cacheTTL         = flag.Duration("cache-ttl", time.Hour, "How long results are reused with -skip-unchanged")
+	cacheFile        = flag.String("cache-file", cache.DefaultPath(), "Result cache file for -skip-unchanged")
+	recordHistory    = flag.Bool("history", false, "Record the results in the history database, see protoscope history")
+	historyDB        = flag.String("history-db", store.DefaultPath(), "History database (SQLite) for -history and protoscope history")
+	mockMode         = flag.Bool("mock", false, "Simulate nodes with canned responses (offline development and demos)")
+	mockReplayFile   = flag.String("mock-replay", "", "JSON file with canned responses for -mock")
+	backendSandbox   = flag.Bool("sandbox", false, "Run xray/sing-box confined to reading their config, with dangerous syscalls blocked (Linux 5.13+)")
+	chaosLatency     = flag.Duration("chaos-latency", 0, "Developer: add this latency to every proxied request")
+	chaosTimeoutRate = flag.Float64("chaos-timeout-rate", 0, "Developer: fraction of proxied requests that fail with a timeout (0-1)")
+	chaosCrashRate   = flag.Float64("chaos-crash-rate", 0, "Developer: fraction of nodes whose backend crashes after start (0-1)")
+	chaosSeed        = flag.Int64("chaos-seed", 1, "Developer: seed for chaos faults")
 )
 
 func main() {
+	// Subcommands take the first argument; plain flags run a one-shot test
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	flag.Parse()
+	applyLanguage()
+
+	ctx := context.Background()
+
+	// Create test configuration
+	config := createConfig()
+
+	var retest *retestPlan
+	var filteredProtocols []*models.Protocol
+	switch {
+	case *retestFailed != "":
+		retest = loadRetest()
+		filteredProtocols = retest.protocols
+	case len(config.Subscriptions) > 0 && *subscriptionURL == "" && *subscriptionFile == "":
+		// Several providers can be tested, and compared, in one run
+		filteredProtocols = loadSources(ctx, config.Subscriptions)
+	default:
+		filteredProtocols = loadProtocols()
+	}
+
+	// Create test runner
+	runner := newTestRunner(config)
+	warnClockSkew(ctx, runner, filteredProtocols)
+
+	var results []*models.TestResult
+
+	started := time.Now()
+	if *quickMode {
+		fmt.Println("🚀 " + i18n.T("Running quick connectivity tests..."))
+		fmt.Println()
+		results = runQuickTests(ctx, runner, filteredProtocols)
+	} else {
+		fmt.Println("🔍 " + i18n.T("Running comprehensive tests..."))
+		fmt.Println()
+		results = runFullTests(ctx, runner, filteredProtocols, config.TestConfig.LowMemory)
+		if err := runner.SaveResultCache(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Failed to save result cache: %v\n", err)
+		}
+	}
+
+	if *recordHistory {
+		saveHistory(started, results)
+	}
+
+	// Re-tested nodes replace their entries in the previous report
+	if retest != nil {
+		results = retest.merge(results)
+	}
+
+	info := newRunInfo(ctx, config, runner.Country())
+
+	// Output results
+	fmt.Println()
+	if !outputToFile(config, results, info) {
+		switch *outputFormat {
+		case "json":
+			outputJSON(results, info)
+		case "markdown":
+			outputMarkdown(results, info)
+		case "html":
+			outputHTML(results, info)
+		case "csv":
+			outputCSV(results)
+		default:
+			outputConsole(results, info)
+			if *verbose {
+				printEndpointStats(runner.IPCheckStats())
+			}
+		}
+	}
 
+	if *exportFailover != "" {
+		writeFailoverExport(results)
+	}
+	if *exportProfiles != "" {
+		writeProfilesExport(results)
+	}
+	if *scorecardFile != "" {
+		writeScorecard(results, info)
+	}
+	if *jsonOut != "" {
+		writeJSONResults(*jsonOut, config, results, info)
+	}
+
+	uploadResults(ctx, config, results, info)
+}
+
+// writeFailoverExport writes the -export-failover file
+func writeFailoverExport(results []*models.TestResult) {
+	format := *failoverFormat
+	if format == "" {
+		format = "singbox"
+		if ext := strings.ToLower(filepath.Ext(*exportFailover)); ext == ".yaml" || ext == ".yml" {
+			format = "clash"
+		}
+	}
+
+	var data []byte
+	var err error
+	switch format {
+	case "singbox", "sing-box":
+		data, err = export.SingboxFailover(results, export.DefaultFailoverOptions())
+	case "clash":
+		data, err = export.ClashFailover(results, export.DefaultFailoverOptions())
+	default:
+		err = fmt.Errorf("unknown failover format: %s", format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error exporting failover group: %v\n", err)
+		return
+	}
+
+	if err := os.WriteFile(*exportFailover, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing %s: %v\n", *exportFailover, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "💾 Failover group (%s) written to %s\n", format, *exportFailover)
+}
+
+// writeProfilesExport writes the -export-profiles file
+func writeProfilesExport(results []*models.TestResult) {
+	var data []byte
+	var err error
+	switch *profilesFormat {
+	case "v2rayn":
+		data, err = export.V2RayNProfiles(results)
+	case "nekobox":
+		data, err = export.NekoBoxProfiles(results, export.DefaultFailoverOptions())
+	default:
+		err = fmt.Errorf("unknown profiles format: %s", *profilesFormat)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error exporting profiles: %v\n", err)
+		return
+	}
+
+	if err := os.WriteFile(*exportProfiles, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing %s: %v\n", *exportProfiles, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "💾 Profiles (%s) written to %s\n", *profilesFormat, *exportProfiles)
+}
+
+// writeScorecard writes the -scorecard file, in HTML when its name ends in
+// .html or .htm and in markdown otherwise
+func writeScorecard(results []*models.TestResult, info *models.RunInfo) {
+	cards := report.Scorecards(results, scorecardProvider())
+	if len(cards) == 1 && *scorecardName != "" {
+		cards[0].Provider = *scorecardName
+	}
+
+	var buf bytes.Buffer
+	switch strings.ToLower(filepath.Ext(*scorecardFile)) {
+	case ".html", ".htm":
+		if err := report.ScorecardHTML(&buf, cards, info); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error rendering scorecard: %v\n", err)
+			return
+		}
+	default:
+		report.ScorecardMarkdown(&buf, cards, info)
+	}
+
+	if err := os.WriteFile(*scorecardFile, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing %s: %v\n", *scorecardFile, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "💾 Scorecard written to %s\n", *scorecardFile)
+}
+
+// scorecardProvider names the provider of a -url or -file subscription:
+// -scorecard-name, else the URL's host or the file's base name
+func scorecardProvider() string {
+	if *scorecardName != "" {
+		return *scorecardName
+	}
+	if u, err := url.Parse(*subscriptionURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	if *subscriptionFile != "" {
+		base := filepath.Base(*subscriptionFile)
+		return strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return "subscription"
+}
+
+// loadProtocols decodes the subscription given by -url or -file and applies
+// the -protocols filter. It exits the process on any error.
+func loadProtocols() []*models.Protocol {
 	if *subscriptionURL == "" && *subscriptionFile == "" {
 		fmt.Println("ProtoScope - Protocol Security Tester")
 		fmt.Println("Usage: protoscope -url <subscription-url> OR -file <subscription-file>")
+		fmt.Println("       protoscope -config <config-with-subscriptions>")
+		fmt.Println("       protoscope <command> [flags]")
+		fmt.Println()
+		printCommands()
 		fmt.Println()
 		flag.PrintDefaults()
 		os.Exit(1)
@@ -46,10 +305,8 @@ func main() {
 		os.Exit(1)
 	}
 
-	ctx := context.Background()
-
 	// Parse subscription
-	fmt.Println("ProtoScope v0.2.0 - Protocol Security Tester")
+	fmt.Printf("ProtoScope %s - %s\n", version, i18n.T("Protocol Security Tester"))
 	fmt.Println("===========================================")
 	fmt.Println()
 
@@ -58,21 +315,33 @@ func main() {
 	var err error
 
 	if *subscriptionFile != "" {
-		fmt.Printf("📁 Reading subscription from file: %s\n", *subscriptionFile)
+		fmt.Printf("📁 "+i18n.T("Reading subscription from file: %s")+"\n", *subscriptionFile)
 		subscription, err = decoder.DecodeFromFile(*subscriptionFile)
 	} else {
-		fmt.Printf("📡 Fetching subscription from: %s\n", *subscriptionURL)
+		fmt.Printf("📡 "+i18n.T("Fetching subscription from: %s")+"\n", *subscriptionURL)
 		subscription, err = decoder.DecodeSubscription(*subscriptionURL)
 	}
 
+	var providerErr *parser.ProviderError
+	if errors.As(err, &providerErr) {
+		// The provider answered, so retrying or checking the network won't help
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", providerErr)
+		fmt.Fprintln(os.Stderr, "   Check the subscription token and plan with your provider")
+		os.Exit(1)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Error: Failed to decode subscription: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✓ Found %d protocols\n", len(subscription.Protocols))
+	fmt.Printf("✓ "+i18n.T("Found %d protocols")+"\n", len(subscription.Protocols))
+	printParseReport(subscription.Report)
+	if unique, dropped := parser.Dedupe(subscription.Protocols); dropped > 0 {
+		subscription.Protocols = unique
+		fmt.Printf("🧹 "+i18n.T("Removed %d duplicate nodes")+"\n", dropped)
+	}
 	if len(subscription.Protocols) == 0 {
-		fmt.Println("No protocols found in subscription")
+		fmt.Println(i18n.T("No protocols found in subscription"))
 		os.Exit(0)
 	}
 
@@ -83,37 +352,111 @@ func main() {
 		os.Exit(1)
 	}
 	if *protocolsFilter != "" {
-		fmt.Printf("🔍 Filtered to %d protocols: %s\n", len(filteredProtocols), *protocolsFilter)
+		fmt.Printf("🔍 "+i18n.T("Filtered to %d protocols: %s")+"\n", len(filteredProtocols), *protocolsFilter)
 	}
-	fmt.Println()
 
-	// Create test configuration
-	config := createConfig()
+	filteredProtocols = shuffleProtocols(filteredProtocols)
+	fmt.Println()
 
-	// Create test runner
-	runner := tester.NewTestRunner(config)
+	return filteredProtocols
+}
 
-	var results []*models.TestResult
+// loadSources fetches the subscriptions listed in the config, each node
+// tagged with its subscription's name. It exits the process when none of
+// them has a node.
+func loadSources(ctx context.Context, sources []models.SubscriptionSource) []*models.Protocol {
+	fmt.Printf("ProtoScope %s - %s\n", version, i18n.T("Protocol Security Tester"))
+	fmt.Println("===========================================")
+	fmt.Println()
 
-	if *quickMode {
-		fmt.Println("🚀 Running quick connectivity tests...")
-		fmt.Println()
-		results = runQuickTests(ctx, runner, filteredProtocols)
-	} else {
-		fmt.Println("🔍 Running comprehensive tests...")
-		fmt.Println()
-		results = runFullTests(ctx, runner, filteredProtocols)
+	protocols := newSourceSet(sources).load(ctx)
+	if len(protocols) == 0 {
+		fmt.Println(i18n.T("No protocols found in subscription"))
+		os.Exit(0)
 	}
 
-	// Output results
+	protocols = shuffleProtocols(protocols)
 	fmt.Println()
-	switch *outputFormat {
-	case "json":
-		outputJSON(results)
-	case "markdown":
-		outputMarkdown(results)
+
+	return protocols
+}
+
+// shuffleProtocols puts the nodes in random order with -shuffle
+func shuffleProtocols(protocols []*models.Protocol) []*models.Protocol {
+	if !*shuffle {
+		return protocols
+	}
+	seed := *shuffleSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	fmt.Printf("🔀 Shuffled test order (reproduce with -shuffle -seed %d)\n", seed)
+	return tester.ShuffleProtocols(protocols, seed)
+}
+
+// printParseReport lists the links that failed to parse: as JSON on stderr
+// with -format json, keeping stdout to the results, one per line with
+// -verbose, otherwise just their number
+func printParseReport(report *models.ParseReport) {
+	if report == nil || len(report.Skipped) == 0 {
+		return
+	}
+
+	switch {
+	case *outputFormat == "json":
+		encoder := json.NewEncoder(os.Stderr)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(map[string]*models.ParseReport{"parse_report": report})
+	case *verbose:
+		fmt.Printf("⚠️  "+i18n.T("Skipped %d links that failed to parse:")+"\n", len(report.Skipped))
+		for _, issue := range report.Skipped {
+			fmt.Printf("   "+i18n.T("line %d")+" [%s] %s\n", issue.Line, issue.Scheme, issue.Error)
+			fmt.Printf("      %s\n", issue.Snippet)
+		}
 	default:
-		outputConsole(results)
+		fmt.Printf("⚠️  "+i18n.T("Skipped %d links that failed to parse (-verbose lists them)")+"\n", len(report.Skipped))
+	}
+	if features := unsupportedFeatures(report); features != "" && *outputFormat != "json" {
+		fmt.Printf("   "+i18n.T("Nodes ProtoScope can't test: %s")+"\n", features)
+	}
+}
+
+// unsupportedFeatures lists the features skipped nodes need, with how many
+// need each, e.g. "shadow-tls (2), obfs plugin (1)"
+func unsupportedFeatures(report *models.ParseReport) string {
+	counts := make(map[string]int)
+	var features []string
+	for _, issue := range report.Skipped {
+		if issue.Unsupported == "" {
+			continue
+		}
+		if counts[issue.Unsupported] == 0 {
+			features = append(features, issue.Unsupported)
+		}
+		counts[issue.Unsupported]++
+	}
+	parts := make([]string, len(features))
+	for i, feature := range features {
+		parts[i] = fmt.Sprintf("%s (%d)", feature, counts[feature])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// warnClockSkew checks the local clock when some nodes authenticate with
+// timestamps and warns if it is off by more than they tolerate
+func warnClockSkew(ctx context.Context, runner *tester.TestRunner, protocols []*models.Protocol) {
+	var tolerance time.Duration
+	for _, protocol := range protocols {
+		if t := tester.ClockTolerance(protocol); t > 0 && (tolerance == 0 || t < tolerance) {
+			tolerance = t
+		}
+	}
+	if tolerance == 0 {
+		return
+	}
+	if skew := runner.ClockSkew(ctx); skew != nil && skew.Abs() > tolerance {
+		fmt.Printf("⚠️  "+i18n.T("Clock is %s; VMess and Shadowsocks 2022 nodes need it synced")+"\n", skew)
+		fmt.Println()
 	}
 }
 
@@ -142,47 +485,253 @@ func filterProtocols(protocols []*models.Protocol) []*models.Protocol {
 	return filtered
 }
 
-// createConfig creates test configuration from flags
+// applyLanguage switches output to the -lang language. Without -lang the
+// language detected from the locale is kept.
+func applyLanguage() {
+	if *language == "" {
+		return
+	}
+	if err := i18n.SetLanguage(*language); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// createConfig creates test configuration from flags. With -config, the
+// file is the base and only flags set on the command line override it.
 func createConfig() *models.Config {
+	// Subcommands parse their flags late, so -lang is applied here too
+	applyLanguage()
+
 	config := models.DefaultConfig()
+	if *configFile != "" {
+		loaded, err := models.LoadConfig(*configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		config = loaded
+		resolveSecrets(config)
+	}
+
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	override := func(name string) bool {
+		return *configFile == "" || setFlags[name]
+	}
 
-	config.TestConfig.Timeout = *timeout
-	config.TestConfig.Concurrency = *concurrency
-	config.TestConfig.EnableSpeedTest = !*noSpeedTest && !*quickMode
-	config.TestConfig.EnableGeoTest = !*noGeoTest && !*quickMode
-	config.TestConfig.EnableDNSTest = !*noDNSTest && !*quickMode
-	config.TestConfig.EnablePrivacyTest = !*noPrivacyTest && !*quickMode
+	if override("output") {
+		config.OutputConfig.OutputPath = *outputPath
+	}
+	if config.OutputConfig.OutputPath != "" {
+		if _, err := outputFileFormat(config.OutputConfig.OutputPath); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if override("timeout") {
+		config.TestConfig.Timeout = *timeout
+	}
+	if override("concurrent") {
+		config.TestConfig.Concurrency = *concurrency
+	}
+	if override("no-speed") {
+		config.TestConfig.EnableSpeedTest = !*noSpeedTest
+	}
+	if override("metered") {
+		config.TestConfig.Metered = *meteredMode
+	}
+	if override("low-memory") {
+		config.TestConfig.LowMemory = *lowMemory
+	}
+	if config.TestConfig.LowMemory {
+		config.TestConfig.Concurrency = 1
+		applyLowMemory()
+	}
+	if override("no-geo") {
+		config.TestConfig.EnableGeoTest = !*noGeoTest
+	}
+	if override("no-dns") {
+		config.TestConfig.EnableDNSTest = !*noDNSTest
+	}
+	if override("blocklist-sample") {
+		config.TestConfig.BlocklistSample = *blocklistSample
+	}
+	if override("no-privacy") {
+		config.TestConfig.EnablePrivacyTest = !*noPrivacyTest
+	}
+	if *quickMode {
+		config.TestConfig.EnableSpeedTest = false
+		config.TestConfig.EnableGeoTest = false
+		config.TestConfig.EnableDNSTest = false
+		config.TestConfig.EnablePrivacyTest = false
+	}
+	if override("ping") {
+		config.TestConfig.EnablePing = *pingServers
+	}
+	if override("trace") {
+		config.TestConfig.EnableTrace = *traceServers
+	}
+	if override("mtu") {
+		config.TestConfig.EnableMTUProbe = *probeMTU
+	}
+	if override("egress") {
+		config.TestConfig.EnableEgressCheck = *probeEgress
+	}
+	if override("sensitive") {
+		config.TestConfig.EnableSensitiveCheck = *sensitiveSites
+	}
+	if override("gaming") {
+		config.TestConfig.EnableGamingTest = *gamingTest
+	}
+	if override("streaming") {
+		config.TestConfig.EnableStreamingCheck = *streamingCheck
+	}
+	if override("cdn-edge") {
+		config.TestConfig.EnableEdgeTest = *edgeTest
+	}
+	if override("ookla") {
+		config.TestConfig.EnableOokla = *ooklaTest
+	}
+	if override("tls-fingerprint") {
+		config.TestConfig.EnableTLSFingerprint = *tlsFingerprint
+	}
+	if override("obfs-check") {
+		config.TestConfig.EnableObfuscationCheck = *obfsCheck
+	}
+	if override("active-probe") {
+		config.TestConfig.EnableActiveProbing = *activeProbe
+	}
+	if override("all-ips") {
+		config.TestConfig.TestAllIPs = *testAllIPs
+	}
+	if override("slow-threshold") {
+		config.TestConfig.SlowNodeThreshold = *slowThreshold
+	}
+	if override("rate-limit") {
+		config.TestConfig.EndpointRateLimit = *rateLimit
+	}
+	if override("sandbox") {
+		config.TestConfig.BackendSandbox = *backendSandbox
+	}
+	if *userCountry != "" {
+		config.TestConfig.Country = strings.ToUpper(*userCountry)
+	}
+	if *connectivityURLs != "" {
+		config.APIEndpoints.Connectivity = splitList(*connectivityURLs)
+	}
+	if *ipCheckEndpoints != "" {
+		config.APIEndpoints.IPCheck = splitList(*ipCheckEndpoints)
+	}
+	if *mockMode || *mockReplayFile != "" {
+		config.TestConfig.Backend = string(tester.BackendMock)
+	}
 
 	return config
 }
 
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// printEndpointStats prints IP-check endpoint health after a run
+func printEndpointStats(stats []checks.EndpointStats) {
+	if len(stats) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("🌐 IP-check endpoints:")
+	for _, stat := range stats {
+		state := "✓"
+		if stat.Quarantined {
+			state = "⛔ quarantined"
+		}
+		fmt.Printf("   %s: %d/%d failed (%.0f%%) %s\n", stat.URL, stat.Failures, stat.Attempts, stat.FailureRate*100, state)
+	}
+}
+
+// newTestRunner creates the test runner and applies runner-level flags
+func newTestRunner(config *models.Config) *tester.TestRunner {
+	runner := tester.NewTestRunner(config)
+
+	if *mockReplayFile != "" {
+		responses, err := tester.LoadMockReplay(*mockReplayFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		runner.SetMockReplay(responses)
+	}
+
+	if *skipUnchanged {
+		resultCache, err := cache.Load(*cacheFile, *cacheTTL, cacheScope(config))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Ignoring result cache: %v\n", err)
+		} else {
+			runner.SetResultCache(resultCache)
+		}
+	}
+
+	// Notes live in the history database; it is not created just to find
+	// there are none
+	if _, err := os.Stat(*historyDB); err == nil {
+		if err := loadNotes(runner); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Ignoring node notes: %v\n", err)
+		}
+	}
+
+	runner.SetChaos(tester.ChaosOptions{
+		Latency:     *chaosLatency,
+		TimeoutRate: *chaosTimeoutRate,
+		CrashRate:   *chaosCrashRate,
+		Seed:        *chaosSeed,
+	})
+
+	return runner
+}
+
 // runQuickTests runs quick connectivity tests
 func runQuickTests(ctx context.Context, runner *tester.TestRunner, protocols []*models.Protocol) []*models.TestResult {
 	results := make([]*models.TestResult, 0, len(protocols))
 
 	for i, protocol := range protocols {
-		fmt.Printf("[%d/%d] Testing: %s [%s]\n", i+1, len(protocols), protocol.Name, protocol.Type)
-		fmt.Printf("       Server: %s:%d\n", protocol.Server, protocol.Port)
+		fmt.Printf("[%d/%d] "+i18n.T("Testing: %s [%s]")+"\n", i+1, len(protocols), protocol.Name, protocol.Type)
+		fmt.Printf("       "+i18n.T("Server: %s:%d")+"\n", protocol.Server, protocol.Port)
 
 		result, err := runner.QuickTest(ctx, protocol)
 		if err != nil {
-			fmt.Printf("       ❌ Error: %v\n\n", err)
+			fmt.Printf("       ❌ "+i18n.T("Error: %v")+"\n\n", err)
 			continue
 		}
 
 		if result.Success {
-			fmt.Printf("       ✓ Connected (%dms)\n\n", result.Connectivity.ResponseTime.Milliseconds())
+			fmt.Printf("       ✓ "+i18n.T("Connected (%dms)")+"\n", result.Connectivity.ResponseTime.Milliseconds())
+			printPing(result)
+			printTrace(result)
+			printMTU(result)
+			printActiveProbing(result)
+			printIPResults(result)
+			fmt.Println()
 		} else {
 			// Check if it's an unsupported protocol error
 			if strings.Contains(result.Error, "not yet supported") {
-				fmt.Printf("       ⚠ Skipped: %s\n\n", result.Error)
+				fmt.Printf("       ⚠ "+i18n.T("Skipped: %s")+"\n\n", result.Error)
 			} else {
-				fmt.Printf("       ✗ Failed: %s\n", result.Error)
+				fmt.Printf("       ✗ "+i18n.T("Failed: %s")+"\n", result.Error)
 
 				// Show detailed error analysis if available
 				if result.ErrorDetails != nil {
-					fmt.Printf("       📋 Type: %s\n", result.ErrorDetails.Type)
-					fmt.Printf("       💡 Suggestion: %s\n", result.ErrorDetails.Suggestion)
+					fmt.Printf("       📋 "+i18n.T("Type: %s")+"\n", result.ErrorDetails.Type)
+					fmt.Printf("       💡 "+i18n.T("Suggestion: %s")+"\n", result.ErrorDetails.Suggestion)
 				}
 				fmt.Println()
 			}
@@ -195,10 +744,21 @@ func runQuickTests(ctx context.Context, runner *tester.TestRunner, protocols []*
 }
 
 // runFullTests runs comprehensive tests
-func runFullTests(ctx context.Context, runner *tester.TestRunner, protocols []*models.Protocol) []*models.TestResult {
+func runFullTests(ctx context.Context, runner *tester.TestRunner, protocols []*models.Protocol, lowMemory bool) []*models.TestResult {
 	total := len(protocols)
 	var printMu sync.Mutex
 
+	// Low-memory runs keep the results on disk until the backends are done
+	var spool *resultSpool
+	if lowMemory {
+		var err error
+		if spool, err = newResultSpool(); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		runner.SetStreamOnly(true)
+	}
+
 	results, err := runner.RunTestsStream(ctx, protocols, func(idx int, result *models.TestResult) {
 		if result == nil || result.Protocol == nil {
 			return
@@ -208,34 +768,55 @@ func runFullTests(ctx context.Context, runner *tester.TestRunner, protocols []*m
 		defer printMu.Unlock()
 
 		printFullTestResult(result, idx, total)
+		if *rawDir != "" {
+			writeRawMeasurements(*rawDir, idx, result)
+		}
+		if spool != nil {
+			if err := spool.add(idx, result); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Error spooling results: %v\n", err)
+				os.Exit(1)
+			}
+		}
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Error running tests: %v\n", err)
 		os.Exit(1)
 	}
 
+	if spool != nil {
+		if results, err = spool.results(); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error reading spooled results: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	return results
 }
 
 func printFullTestResult(result *models.TestResult, idx, total int) {
 	fmt.Printf("[%d/%d] %s [%s]\n", idx+1, total, result.Protocol.Name, result.Protocol.Type)
-	fmt.Printf("       Server: %s:%d\n", result.Protocol.Server, result.Protocol.Port)
+	fmt.Printf("       "+i18n.T("Server: %s:%d")+"\n", result.Protocol.Server, result.Protocol.Port)
+	if result.Note != nil {
+		fmt.Printf("       📌%s\n", noteSuffix(result.Note))
+	}
+	if result.Cached {
+		fmt.Printf("       ♻ "+i18n.T("Unchanged, result from %s")+"\n", result.Timestamp.Format("2006-01-02 15:04"))
+	}
 
 	if !result.Success {
 		// Check if it's an unsupported protocol error
 		if strings.Contains(result.Error, "not yet supported") {
-			fmt.Printf("       ⚠ Skipped: %s\n\n", result.Error)
+			fmt.Printf("       ⚠ "+i18n.T("Skipped: %s")+"\n\n", result.Error)
 		} else {
-			fmt.Printf("       ✗ Failed: %s\n", result.Error)
+			fmt.Printf("       ✗ "+i18n.T("Failed: %s")+"\n", result.Error)
 
 			// Show detailed error analysis if available
 			if result.ErrorDetails != nil {
-				fmt.Printf("       📋 Type: %s\n", result.ErrorDetails.Type)
+				fmt.Printf("       📋 "+i18n.T("Type: %s")+"\n", result.ErrorDetails.Type)
 				if result.ErrorDetails.Details != "" {
-					fmt.Printf("       📝 Details: %s\n", result.ErrorDetails.Details)
+					fmt.Printf("       📝 "+i18n.T("Details: %s")+"\n", result.ErrorDetails.Details)
 				}
 				if *verbose && result.ErrorDetails.BackendLog != "" {
-					fmt.Printf("       🔍 Backend Log:\n")
+					fmt.Printf("       🔍 %s\n", i18n.T("Backend Log:"))
 					logLines := strings.Split(result.ErrorDetails.BackendLog, "\n")
 					for _, line := range logLines {
 						if strings.TrimSpace(line) != "" {
@@ -243,25 +824,58 @@ func printFullTestResult(result *models.TestResult, idx, total int) {
 						}
 					}
 				}
-				fmt.Printf("       💡 Suggestion: %s\n", result.ErrorDetails.Suggestion)
+				fmt.Printf("       💡 "+i18n.T("Suggestion: %s")+"\n", result.ErrorDetails.Suggestion)
 			}
 			fmt.Println()
 		}
 		return
 	}
 
-	fmt.Printf("       ✓ Connected (%dms)\n", result.Connectivity.ResponseTime.Milliseconds())
+	fmt.Printf("       ✓ "+i18n.T("Connected (%dms)")+"\n", result.Connectivity.ResponseTime.Milliseconds())
+	printPing(result)
+	printTrace(result)
+	printMTU(result)
+	printActiveProbing(result)
+	printIPResults(result)
+	if result.PartialSuccess {
+		fmt.Printf("       ⚠ %s\n", i18n.T("Partial: node deadline reached, showing completed checks only"))
+	}
 
 	if result.Performance != nil {
-		fmt.Printf("       📊 Speed: ↓%.1f Mbps\n", result.Performance.DownloadSpeed)
-		fmt.Printf("       ⏱  Latency: %dms\n", result.Performance.Latency.Milliseconds())
+		fmt.Printf("       📊 "+i18n.T("Speed: ↓%.1f Mbps")+"\n", result.Performance.DownloadSpeed)
+		if metered := result.Performance.Metered; metered != nil {
+			fmt.Printf("          %s\n", report.MeteredLabel(metered))
+		}
+		if result.Performance.UploadSpeed > 0 {
+			fmt.Printf("       📤 "+i18n.T("Upload: ↑%.1f Mbps")+"\n", result.Performance.UploadSpeed)
+		}
+		if result.Performance.SpeedTestServer != "" && *verbose {
+			fmt.Printf("       🖥  "+i18n.T("Speed test server: %s")+"\n", report.SpeedTestServerLabel(result.Performance))
+		}
+		if traffic := result.Performance.BackendTraffic; traffic != nil {
+			fmt.Printf("       📈 "+i18n.T("Backend counted: ↓%.1f Mbps (%.1f MB down, %.1f KB up)")+"\n",
+				traffic.DownloadSpeed, float64(traffic.DownlinkBytes)/1e6, float64(traffic.UplinkBytes)/1e3)
+		}
+		fmt.Printf("       ⏱  "+i18n.T("Latency: %dms")+"\n", result.Performance.Latency.Milliseconds())
+	}
+	if result.Ookla != nil {
+		fmt.Printf("       🏁 Speedtest.net: %s\n", report.OoklaLabel(result.Ookla))
+	}
+	if result.EdgeSpeed != nil {
+		fmt.Printf("       🛰  %s: %s\n", i18n.T("CDN Edge"), report.EdgeLabel(result.EdgeSpeed))
+	}
+	for _, name := range report.PluginNames(result) {
+		fmt.Printf("       🔌 %s: %s\n", name, report.PluginLabel(result.Plugins[name]))
 	}
 
 	if result.GeoAccess != nil && *verbose {
-		fmt.Printf("       🌍 Geo: %d/%d accessible (%.0f%%)\n",
+		fmt.Printf("       🌍 "+i18n.T("Geo: %d/%d accessible (%.0f%%)")+"\n",
 			result.GeoAccess.Summary.TotalAccessible,
 			result.GeoAccess.Summary.TotalTested,
 			result.GeoAccess.Summary.AccessPercentage)
+		if blockpages := result.GeoAccess.Summary.TotalBlockpages; blockpages > 0 {
+			fmt.Printf("       🚧 "+i18n.T("Blockpage detected: %d domains")+"\n", blockpages)
+		}
 	}
 
 	if result.DNS != nil && *verbose {
@@ -269,122 +883,301 @@ func printFullTestResult(result *models.TestResult, idx, total int) {
 		if result.DNS.LeakDetection != nil && result.DNS.LeakDetection.IsLeaking {
 			leak = "⚠"
 		}
-		fmt.Printf("       🔒 DNS Leak: %s\n", leak)
+		fmt.Printf("       🔒 "+i18n.T("DNS Leak: %s")+"\n", leak)
 
 		if result.DNS.Blocking != nil {
-			fmt.Printf("       🛡  Blocked: %d/%d domains\n",
+			fmt.Printf("       🛡  "+i18n.T("Blocked: %d/%d domains")+"\n",
 				result.DNS.Blocking.Summary.TotalBlocked,
 				result.DNS.Blocking.Summary.TotalTested)
+			fmt.Printf("       🛡  "+i18n.T("Ad-blocking: %.0f%% effective")+"\n", result.DNS.Blocking.Summary.Effectiveness)
+			if result.DNS.Blocking.BlocklistError != "" {
+				fmt.Printf("          "+i18n.T("Blocklists left out: %s")+"\n", result.DNS.Blocking.BlocklistError)
+			}
+		}
+
+		if result.DNS.DNSSEC != nil {
+			fmt.Printf("       🔏 "+i18n.T("DNSSEC: %s")+"\n", report.DNSSECLabel(result.DNS.DNSSEC.Status))
 		}
 	}
 
+	// Shown without -verbose: streaming services block whole providers
+	if result.Privacy != nil && result.Privacy.CountryMismatch {
+		fmt.Printf("       ⚠️  "+i18n.T("Country mismatch: advertised as %s, exits in %s")+"\n", result.Protocol.AdvertisedCountry, result.Privacy.ExitCountry)
+	}
+	if result.Privacy != nil && result.Privacy.Hosting != nil {
+		fmt.Printf("       🏢 "+i18n.T("Hosting: %s")+"\n", result.Privacy.Hosting)
+	}
+	if result.Privacy != nil && result.Privacy.ProxyDetection != nil {
+		printProxyDetection(result.Privacy.ProxyDetection)
+	}
+	if result.Egress != nil {
+		printEgress(result.Egress)
+	}
+	if result.SensitiveSites != nil {
+		printSensitiveSites(result.SensitiveSites)
+	}
+	if result.Gaming != nil {
+		fmt.Printf("       🎮 "+i18n.T("Gaming: %s")+"\n", report.GamingLabel(result.Gaming))
+	}
+	if result.Streaming != nil && *verbose {
+		fmt.Printf("       📺 "+i18n.T("Streaming: %s")+"\n", report.StreamingLabel(result.Streaming))
+	}
+	if result.TLSFingerprint != nil {
+		printTLSFingerprint(result.TLSFingerprint)
+	}
+	if result.Obfuscation != nil {
+		printObfuscation(result.Obfuscation)
+	}
+
 	if result.Privacy != nil && *verbose {
-		fmt.Printf("       🔐 Security Score: %d/100\n", result.Privacy.Score)
+		fmt.Printf("       🔐 "+i18n.T("Security Score: %d/100")+"\n", result.Privacy.Score)
+	}
+
+	// Checks cut short by the deadline are always listed for partial nodes
+	if *verbose || result.PartialSuccess {
+		for _, skipped := range result.SkippedChecks {
+			fmt.Printf("       ⏭  "+i18n.T("Skipped %s: %s")+"\n", skipped.Name, skipped.Reason)
+		}
 	}
 
 	fmt.Println()
 }
 
-func outputJSON(results []*models.TestResult) {
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(results); err != nil {
-		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+// printComparison prints a line per subscription with its working nodes,
+// medians and unlock rate per geo region
+func printComparison(comparison *report.Comparison) {
+	fmt.Println("📦 " + i18n.T("Subscription comparison:"))
+	for _, stats := range comparison.Subscriptions {
+		line := fmt.Sprintf(i18n.T("%s: %d/%d working (%.1f%%), median %s, %s"),
+			stats.Name, stats.Working, stats.Total, stats.WorkingPercent(), stats.LatencyLabel(), stats.SpeedLabel())
+		var unlock []string
+		for _, region := range comparison.Regions {
+			unlock = append(unlock, region+" "+stats.UnlockLabel(region))
+		}
+		if len(unlock) > 0 {
+			line += "; " + strings.Join(unlock, ", ")
+		}
+		fmt.Println("   " + line)
 	}
 }
 
-func outputMarkdown(results []*models.TestResult) {
-	fmt.Println("# ProtoScope Test Results")
-	fmt.Println()
-	fmt.Printf("**Generated**: %s\n\n", time.Now().Format(time.RFC1123))
-	fmt.Printf("**Total Protocols**: %d\n\n", len(results))
+// printEgress prints which probed outbound ports the exit blocks
+func printEgress(egress *models.EgressResult) {
+	if len(egress.Blocked) == 0 {
+		fmt.Printf("       🚪 "+i18n.T("Egress: all %d probed ports open")+"\n", len(egress.Ports))
+		return
+	}
+	fmt.Printf("       🚪 "+i18n.T("Egress: blocked %s")+"\n", report.EgressBlocked(egress))
+}
 
-	fmt.Println("## Summary")
-	fmt.Println()
+// printSensitiveSites prints how many sensitive sites the exit reaches and
+// which ones turned it away
+func printSensitiveSites(sensitive *models.SensitiveSitesResult) {
+	if sensitive.Reachable == len(sensitive.Sites) {
+		fmt.Printf("       🏦 "+i18n.T("Sensitive sites: all %d reachable")+"\n", len(sensitive.Sites))
+		return
+	}
+	fmt.Printf("       🏦 "+i18n.T("Sensitive sites: %d/%d reachable, blocked %s")+"\n",
+		sensitive.Reachable, len(sensitive.Sites), report.SensitiveBlocked(sensitive))
+}
 
-	working := 0
-	failed := 0
-	avgLatency := time.Duration(0)
-	latencyCount := 0
+// printTLSFingerprint prints the ClientHello the backend sent and warns when
+// the requested uTLS fingerprint was not applied
+func printTLSFingerprint(fingerprint *models.TLSFingerprintResult) {
+	if fingerprint.Error != "" {
+		fmt.Printf("       🔑 "+i18n.T("TLS fingerprint: %s")+"\n", fingerprint.Error)
+		return
+	}
 
-	for _, result := range results {
-		if result == nil {
-			continue
+	fmt.Printf("       🔑 "+i18n.T("TLS fingerprint: JA3 %s (%s)")+"\n", fingerprint.JA3Hash, fingerprint.Profile)
+	if fingerprint.Mismatch {
+		fmt.Printf("          ⚠ "+i18n.T("fp=%s requested, but the backend sent a plain Go TLS hello")+"\n", fingerprint.Requested)
+	}
+	if *verbose {
+		fmt.Printf("          JA3: %s\n", fingerprint.JA3)
+		fmt.Printf("          JA3N: %s\n", fingerprint.JA3NHash)
+	}
+}
+
+// printObfuscation prints whether the node's obfuscation hides the proxy
+// protocol and what gives it away otherwise
+func printObfuscation(obfuscation *models.ObfuscationResult) {
+	switch {
+	case obfuscation.Error != "":
+		fmt.Printf("       🎭 "+i18n.T("Obfuscation (%s): %s")+"\n", obfuscation.Method, obfuscation.Error)
+	case obfuscation.Effective:
+		fmt.Printf("       🎭 "+i18n.T("Obfuscation (%s): effective, looks like %s")+"\n", obfuscation.Method, obfuscation.Observed)
+	default:
+		fmt.Printf("       🎭 "+i18n.T("Obfuscation (%s): misconfigured")+"\n", obfuscation.Method)
+		for _, issue := range obfuscation.Issues {
+			fmt.Printf("          ⚠ %s\n", issue)
 		}
-		if result.Success {
-			working++
-			if result.Connectivity != nil {
-				avgLatency += result.Connectivity.ResponseTime
-				latencyCount++
+	}
+}
+
+// printProxyDetection prints whether sites will likely see the exit IP as a
+// proxy, and why
+func printProxyDetection(detection *models.ProxyDetectionResult) {
+	if !detection.LikelyFlagged {
+		fmt.Printf("       🕵  "+i18n.T("Proxy lists: not listed (%d checked)")+"\n", detection.ListsChecked)
+		return
+	}
+
+	reasons := detection.Lists
+	if len(reasons) == 0 {
+		reasons = detection.Categories
+	}
+	fmt.Printf("       🕵  "+i18n.T("Proxy lists: likely flagged as a proxy (%s)")+"\n", strings.Join(reasons, ", "))
+}
+
+// printIPResults prints per-IP connectivity for servers with several addresses
+func printIPResults(result *models.TestResult) {
+	if len(result.IPResults) == 0 {
+		if *verbose && len(result.ResolvedIPs) > 1 {
+			fmt.Printf("       🌐 "+i18n.T("Resolved IPs: %s")+"\n", strings.Join(result.ResolvedIPs, ", "))
+			if result.DialedIP != "" {
+				fmt.Printf("          "+i18n.T("Tested through %s")+"\n", result.DialedIP)
 			}
+		}
+		return
+	}
+
+	for _, ipResult := range result.IPResults {
+		if ipResult.Connected {
+			fmt.Printf("       🌐 %s: ✓ %dms\n", ipResult.IP, ipResult.ResponseTime.Milliseconds())
 		} else {
-			failed++
+			fmt.Printf("       🌐 %s: ✗ %s\n", ipResult.IP, ipResult.Error)
 		}
 	}
+}
 
-	if latencyCount > 0 {
-		avgLatency = avgLatency / time.Duration(latencyCount)
+// printTrace prints the hop count and slowest hop of the route to the server
+func printTrace(result *models.TestResult) {
+	if result.Trace == nil {
+		return
 	}
 
-	fmt.Printf("- **Working**: %d (%.1f%%)\n", working, float64(working)/float64(len(results))*100)
-	fmt.Printf("- **Failed**: %d (%.1f%%)\n", failed, float64(failed)/float64(len(results))*100)
-	if latencyCount > 0 {
-		fmt.Printf("- **Average Latency**: %dms\n", avgLatency.Milliseconds())
+	trace := result.Trace
+	if !trace.Reached {
+		fmt.Printf("       🛰  "+i18n.T("Trace: %s")+"\n", trace.Error)
+		return
 	}
-	fmt.Println()
 
-	fmt.Println("## Detailed Results")
+	fmt.Printf("       🛰  "+i18n.T("Trace: %d hops"), trace.HopCount)
+	if trace.WorstHop > 0 {
+		fmt.Printf(i18n.T(", worst hop #%d %dms"), trace.WorstHop, trace.WorstHopRTT.Milliseconds())
+	}
 	fmt.Println()
 
-	for i, result := range results {
-		if result == nil {
-			continue
+	if *verbose {
+		for _, hop := range trace.Hops {
+			address := hop.Address
+			if address == "" {
+				address = "*"
+			}
+			fmt.Printf("          %2d. %-39s %4dms "+i18n.T("%3.0f%% loss")+"\n", hop.TTL, address, hop.AvgRTT.Milliseconds(), hop.Loss)
 		}
+	}
+}
 
-		status := "✗ Failed"
-		if result.Success {
-			status = "✓ Working"
-		}
+// printMTU prints the path MTU of UDP-based nodes and flags likely stalls
+func printMTU(result *models.TestResult) {
+	if result.MTU == nil {
+		return
+	}
 
-		fmt.Printf("### %d. %s - %s\n", i+1, result.Protocol.Name, status)
-		fmt.Println()
-		fmt.Printf("- **Type**: %s\n", result.Protocol.Type)
-		fmt.Printf("- **Server**: %s:%d\n", result.Protocol.Server, result.Protocol.Port)
+	mtu := result.MTU
+	if mtu.PathMTU == 0 {
+		fmt.Printf("       📦 MTU: %s\n", mtu.Error)
+		return
+	}
 
-		if result.Success {
-			if result.Connectivity != nil {
-				fmt.Printf("- **Response Time**: %dms\n", result.Connectivity.ResponseTime.Milliseconds())
-			}
+	fmt.Printf("       📦 MTU: %d", mtu.PathMTU)
+	if mtu.Blackhole {
+		fmt.Printf(" ⚠ %s", i18n.T("PMTU black hole: larger packets are dropped silently"))
+	} else if mtu.FragmentationIssue {
+		fmt.Printf(" ⚠ %s", i18n.T("too small for full-size QUIC packets"))
+	}
+	fmt.Println()
+}
 
-			if result.Performance != nil {
-				fmt.Printf("- **Download Speed**: %.1f Mbps\n", result.Performance.DownloadSpeed)
-				fmt.Printf("- **Latency**: %dms\n", result.Performance.Latency.Milliseconds())
-			}
+// printActiveProbing prints the server's active-probing resistance and, with
+// -verbose, how it reacted to each probe
+func printActiveProbing(result *models.TestResult) {
+	if result.ActiveProbing == nil {
+		return
+	}
 
-			if result.GeoAccess != nil {
-				fmt.Printf("- **Geo Access**: %d/%d (%.0f%%)\n",
-					result.GeoAccess.Summary.TotalAccessible,
-					result.GeoAccess.Summary.TotalTested,
-					result.GeoAccess.Summary.AccessPercentage)
-			}
+	probing := result.ActiveProbing
+	if probing.Error != "" {
+		fmt.Printf("       🧪 "+i18n.T("Active probing: %s")+"\n", probing.Error)
+		return
+	}
 
-			if result.Privacy != nil {
-				fmt.Printf("- **Security Score**: %d/100\n", result.Privacy.Score)
-			}
-		} else {
-			fmt.Printf("- **Error**: %s\n", result.Error)
+	fmt.Printf("       🧪 "+i18n.T("Active probing: %s (%d/100)")+"\n", i18n.T(probing.Verdict), probing.Score)
+	if *verbose {
+		for _, probe := range probing.Probes {
+			fmt.Printf("          %-10s → %s %s\n", probe.Name, probe.Response, probe.Detail)
 		}
+	}
+}
 
-		fmt.Println()
+// printPing prints the direct ICMP RTT next to the proxied response time
+func printPing(result *models.TestResult) {
+	if result.Ping == nil {
+		return
+	}
+
+	if result.Ping.Received == 0 {
+		fmt.Printf("       🏓 "+i18n.T("Ping: no reply (%s)")+"\n", result.Ping.Error)
+		return
 	}
+
+	fmt.Printf("       🏓 "+i18n.T("Ping: %dms (%s, %.0f%% loss)"), result.Ping.AvgRTT.Milliseconds(), result.Ping.Method, result.Ping.PacketLoss)
+	if result.Connectivity != nil {
+		overhead := result.Connectivity.ResponseTime - result.Ping.AvgRTT
+		if overhead > 0 {
+			fmt.Printf(i18n.T(" - proxy overhead: %dms"), overhead.Milliseconds())
+		}
+	}
+	fmt.Println()
 }
 
-func outputConsole(results []*models.TestResult) {
+// outputJSON prints the run info as an object of its own, then the results
+// array, which -retest-failed reads back
+func outputJSON(results []*models.TestResult, info *models.RunInfo) {
+	if err := writeResults(os.Stdout, results, info); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+	}
+}
+
+func outputMarkdown(results []*models.TestResult, info *models.RunInfo) {
+	report.Markdown(os.Stdout, results, info)
+}
+
+// outputCSV prints a row per node for spreadsheets
+func outputCSV(results []*models.TestResult) {
+	if err := report.CSV(os.Stdout, results); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing CSV: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// outputHTML prints the standalone HTML report
+func outputHTML(results []*models.TestResult, info *models.RunInfo) {
+	if err := report.HTML(os.Stdout, results, info); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error rendering HTML report: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func outputConsole(results []*models.TestResult, info *models.RunInfo) {
 	fmt.Println("===========================================")
-	fmt.Println("📊 Test Summary")
+	fmt.Println("📊 " + i18n.T("Test Summary"))
 	fmt.Println("===========================================")
 
 	working := 0
+	partial := 0
 	failed := 0
 	avgLatency := time.Duration(0)
 	latencyCount := 0
@@ -395,6 +1188,9 @@ func outputConsole(results []*models.TestResult) {
 		if result == nil {
 			continue
 		}
+		if result.PartialSuccess {
+			partial++
+		}
 		if result.Success {
 			working++
 			if result.Connectivity != nil {
@@ -417,20 +1213,46 @@ func outputConsole(results []*models.TestResult) {
 		avgSpeed = avgSpeed / float64(speedCount)
 	}
 
-	fmt.Printf("Total Protocols: %d\n", len(results))
-	fmt.Printf("✓ Working: %d (%.1f%%)\n", working, float64(working)/float64(len(results))*100)
-	fmt.Printf("✗ Failed: %d (%.1f%%)\n", failed, float64(failed)/float64(len(results))*100)
+	fmt.Printf(i18n.T("Total Protocols: %d")+"\n", len(results))
+	fmt.Printf("✓ "+i18n.T("Working: %d (%.1f%%)")+"\n", working, float64(working)/float64(len(results))*100)
+	if partial > 0 {
+		fmt.Printf("⚠ "+i18n.T("Partial: %d (working, some checks hit the deadline)")+"\n", partial)
+	}
+	fmt.Printf("✗ "+i18n.T("Failed: %d (%.1f%%)")+"\n", failed, float64(failed)/float64(len(results))*100)
 
 	if latencyCount > 0 {
-		fmt.Printf("⏱  Average Latency: %dms\n", avgLatency.Milliseconds())
+		fmt.Printf("⏱  "+i18n.T("Average Latency: %dms")+"\n", avgLatency.Milliseconds())
 	}
 	if speedCount > 0 {
-		fmt.Printf("📊 Average Speed: %.1f Mbps\n", avgSpeed)
+		fmt.Printf("📊 "+i18n.T("Average Speed: %.1f Mbps")+"\n", avgSpeed)
+	}
+	summary := report.Summarize(results)
+	if summary.Metered != nil {
+		fmt.Printf("📶 %s: %s\n", i18n.T("Metered"), summary.Metered.Label())
+	}
+	if summary.Mismatched > 0 {
+		fmt.Printf("⚠️  "+i18n.T("%d nodes exit in another country than their name advertises")+"\n", summary.Mismatched)
+	}
+	if countries := summary.Countries; len(countries) > 0 {
+		groups := make([]string, 0, len(countries))
+		for _, count := range countries {
+			groups = append(groups, fmt.Sprintf("%s %d/%d", count.Country, count.Working, count.Total))
+		}
+		fmt.Printf("🌍 "+i18n.T("By advertised country (working/total): %s")+"\n", strings.Join(groups, ", "))
+	}
+	if comparison := summary.Comparison; comparison != nil {
+		printComparison(comparison)
 	}
 
 	fmt.Println()
+	platform := fmt.Sprintf("%s/%s", info.OS, info.Arch)
+	if info.VantageCountry != "" {
+		platform += ", " + info.VantageCountry
+	}
+	fmt.Printf("🧾 ProtoScope %s · %s · %s · config %.12s\n", info.Version, report.BackendLabel(info), platform, info.ConfigHash)
+
 	fmt.Println("===========================================")
-	fmt.Println("💡 Tip: Use -format json or -format markdown for detailed output")
-	fmt.Println("💡 Use -verbose for more details in console mode")
+	fmt.Println("💡 " + i18n.T("Tip: Use -format json or -format markdown for detailed output"))
+	fmt.Println("💡 " + i18n.T("Use -verbose for more details in console mode"))
 	fmt.Println("===========================================")
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/internal/notes"
+	"github.com/VenoMexx/ProtoScope/internal/store"
+	"github.com/VenoMexx/ProtoScope/internal/tester"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+const notesUsage = `Usage:
+  protoscope notes list
+  protoscope notes set -url|-file <subscription> [-labels a,b] <node> [note text]
+  protoscope notes rm <node name or fingerprint prefix>`
+
+// notesCommand lists, sets and removes notes attached to nodes
+func notesCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, notesUsage)
+		os.Exit(1)
+	}
+	action := args[0]
+
+	labels := flag.String("labels", "", "Comma-separated labels, e.g. paid,home")
+	flag.CommandLine.Parse(args[1:])
+
+	history, err := store.Open(*historyDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer history.Close()
+	nodeNotes, err := notes.Load(history)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch action {
+	case "list":
+		printNotes(nodeNotes.All())
+		return
+
+	case "set":
+		if flag.NArg() < 1 {
+			fmt.Fprintln(os.Stderr, notesUsage)
+			os.Exit(1)
+		}
+		createConfig()
+		protocol := findNode(loadProtocols(), flag.Arg(0))
+		text := strings.Join(flag.Args()[1:], " ")
+		nodeNotes.Set(protocol, text, splitList(*labels))
+		fmt.Printf("✓ Note set on %s\n", protocol.Name)
+
+	case "rm":
+		if flag.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, notesUsage)
+			os.Exit(1)
+		}
+		if nodeNotes.Remove(flag.Arg(0)) == 0 {
+			fmt.Fprintf(os.Stderr, "❌ Error: no note matches %q\n", flag.Arg(0))
+			os.Exit(1)
+		}
+		fmt.Println("✓ Note removed")
+
+	default:
+		fmt.Fprintln(os.Stderr, notesUsage)
+		os.Exit(1)
+	}
+
+	if err := nodeNotes.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadNotes attaches the notes in the history database to the runner's
+// results
+func loadNotes(runner *tester.TestRunner) error {
+	history, err := store.Open(*historyDB)
+	if err != nil {
+		return err
+	}
+	defer history.Close()
+	nodeNotes, err := notes.Load(history)
+	if err != nil {
+		return err
+	}
+	runner.SetNotes(nodeNotes)
+	return nil
+}
+
+// findNode picks the node named query, or whose fingerprint starts with it
+func findNode(protocols []*models.Protocol, query string) *models.Protocol {
+	var matches []*models.Protocol
+	for _, protocol := range protocols {
+		if protocol.Name == query || (len(query) >= 8 && strings.HasPrefix(protocol.Fingerprint(), query)) {
+			matches = append(matches, protocol)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0]
+	case 0:
+		fmt.Fprintf(os.Stderr, "❌ Error: no node matches %q\n", query)
+	default:
+		fmt.Fprintf(os.Stderr, "❌ Error: %d nodes match %q; use a fingerprint prefix instead:\n", len(matches), query)
+		for _, protocol := range matches {
+			fmt.Fprintf(os.Stderr, "   %s  %s:%d\n", protocol.Fingerprint()[:12], protocol.Server, protocol.Port)
+		}
+	}
+	os.Exit(1)
+	return nil
+}
+
+// printNotes prints stored notes with the fingerprint prefix that selects them
+func printNotes(entries []notes.Entry) {
+	if len(entries) == 0 {
+		fmt.Println("No notes")
+		return
+	}
+	for _, entry := range entries {
+		fmt.Printf("%s  %s%s\n", entry.Fingerprint[:12], entry.Name, noteSuffix(entry.NodeNote))
+	}
+}
+
+// noteSuffix renders a note after a node name: labels, then the text
+func noteSuffix(note *models.NodeNote) string {
+	var b strings.Builder
+	if len(note.Labels) > 0 {
+		fmt.Fprintf(&b, " [%s]", strings.Join(note.Labels, ", "))
+	}
+	if note.Text != "" {
+		fmt.Fprintf(&b, " — %s", note.Text)
+	}
+	return b.String()
+}
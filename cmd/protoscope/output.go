@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/internal/report"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// outputExtensions picks the report format of an -output file by its
+// extension when -format is left at console
+var outputExtensions = map[string]string{
+	".json":     "json",
+	".md":       "markdown",
+	".markdown": "markdown",
+	".html":     "html",
+	".htm":      "html",
+	".csv":      "csv",
+}
+
+// outputFileFormat returns the format the report is written to path in
+func outputFileFormat(path string) (string, error) {
+	if *outputFormat != "console" {
+		return *outputFormat, nil
+	}
+	format, ok := outputExtensions[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return "", fmt.Errorf("can't tell the format of %s from its extension, set -format json, markdown, html or csv", path)
+	}
+	return format, nil
+}
+
+// renderReport writes the results to w in a file format: json, markdown,
+// html or csv
+func renderReport(w io.Writer, format string, results []*models.TestResult, info *models.RunInfo) error {
+	switch format {
+	case "json":
+		return writeResults(w, results, info)
+	case "markdown":
+		report.Markdown(w, results, info)
+		return nil
+	case "html":
+		return report.HTML(w, results, info)
+	case "csv":
+		return report.CSV(w, results)
+	}
+	return fmt.Errorf("unknown output format: %s", format)
+}
+
+// outputToFile writes the report to the output path (-output) when one is
+// set, and reports whether it was. The file is replaced atomically, so a
+// dashboard reading it never sees half a report.
+func outputToFile(config *models.Config, results []*models.TestResult, info *models.RunInfo) bool {
+	path := config.OutputConfig.OutputPath
+	if path == "" {
+		return false
+	}
+
+	format, err := outputFileFormat(path)
+	if err == nil {
+		err = writeFileAtomic(path, func(w io.Writer) error {
+			return renderReport(w, format, results, info)
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "💾 Report written to %s\n", path)
+
+	// A CSV row per node has no place for the run information, so it goes
+	// to a file beside the report
+	if format == "csv" {
+		infoPath := runInfoPath(path)
+		err := writeFileAtomic(infoPath, func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(info)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error writing %s: %v\n", infoPath, err)
+			os.Exit(1)
+		}
+	}
+	return true
+}
+
+// runInfoPath is the file beside a CSV report holding its run information,
+// e.g. results.runinfo.json for results.csv
+func runInfoPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".runinfo.json"
+}
+
+// writeFileAtomic creates path's directory and replaces path with what
+// write produces, through a temporary file in the same directory
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly after the rename
+
+	err = write(tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/site"
+	"github.com/VenoMexx/ProtoScope/internal/store"
+)
+
+// publishCommand generates a static site of the runs recorded with
+// -history, e.g. to publish daily subscription health on GitHub Pages
+func publishCommand(args []string) {
+	db := flag.String("db", "", "History database to publish (default: -history-db)")
+	out := flag.String("out", "site", "Directory to write the site to")
+	days := flag.Int("days", 30, "Publish the runs of this many days")
+	title := flag.String("title", "ProtoScope Node Health", "Title of the site")
+	flag.CommandLine.Parse(args)
+
+	if *db == "" {
+		*db = *historyDB
+	}
+	if *days <= 0 || flag.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: protoscope publish [-db <file>] [-out <dir>] [-days 30] [-title <title>]")
+		os.Exit(1)
+	}
+	if _, err := os.Stat(*db); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "❌ Error: no history at %s; record runs with -history\n", *db)
+		os.Exit(1)
+	}
+
+	history, err := store.Open(*db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer history.Close()
+
+	points, err := history.Points(time.Now().AddDate(0, 0, -*days), "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	runs, err := site.Generate(*out, *title, points)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: failed to publish: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "💾 Site with %d runs written to %s\n", runs, *out)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// maxRawNameLength caps the node name part of raw measurement file names
+const maxRawNameLength = 60
+
+// writeRawMeasurements writes the samples of a tested node to its file in
+// the -raw-dir directory, named by its position in the run and its name,
+// e.g. 003-Germany_01.json. Failures are logged, not fatal.
+func writeRawMeasurements(dir string, idx int, result *models.TestResult) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to create %s: %v\n", dir, err)
+		return
+	}
+
+	data, err := json.MarshalIndent(result.RawMeasurements(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to encode raw measurements of %s: %v\n", result.Protocol.Name, err)
+		return
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%03d-%s.json", idx+1, rawFileName(result.Protocol.Name)))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to write %s: %v\n", path, err)
+	}
+}
+
+// rawFileName makes a node name safe for a file name, keeping letters and
+// digits and replacing everything else, emoji flags included, with _
+func rawFileName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if b.Len() >= maxRawNameLength {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '.' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	if name := strings.Trim(b.String(), "_."); name != "" {
+		return name
+	}
+	return "node"
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/VenoMexx/ProtoScope/internal/i18n"
+	"github.com/VenoMexx/ProtoScope/internal/parser"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// retestPlan is a previous report and the nodes in it that are tested again
+type retestPlan struct {
+	previous  []*models.TestResult
+	protocols []*models.Protocol
+	indices   []int // position in previous of each entry in protocols
+}
+
+// loadRetest reads the -retest-failed report and selects the nodes to test
+// again: failed and partial nodes, and with -retest-below also nodes scoring
+// under the threshold. It exits the process on any error.
+func loadRetest() *retestPlan {
+	fmt.Printf("ProtoScope %s - %s\n", version, i18n.T("Protocol Security Tester"))
+	fmt.Println("===========================================")
+	fmt.Println()
+	fmt.Printf("📁 Reading previous results from: %s\n", *retestFailed)
+
+	previous, err := loadResultsFile(*retestFailed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	plan := &retestPlan{previous: previous}
+	allowed := make(map[*models.Protocol]bool)
+
+	decoder := parser.NewDecoder()
+	for idx, result := range previous {
+		if result == nil || result.Protocol == nil {
+			continue
+		}
+		if result.Success && !result.PartialSuccess && result.Score() >= *retestBelow {
+			continue
+		}
+
+		// Re-parse the original link so Extra values get their parser types
+		// back; JSON turns every number into float64
+		protocol := result.Protocol
+		if protocol.Raw != "" {
+			if reparsed, err := decoder.ParseProtocol(protocol.Raw); err == nil {
+				reparsed.Name = protocol.Name
+				protocol = reparsed
+			}
+		}
+		plan.protocols = append(plan.protocols, protocol)
+		plan.indices = append(plan.indices, idx)
+	}
+
+	// Apply the -protocols filter without losing track of positions
+	for _, protocol := range filterProtocols(plan.protocols) {
+		allowed[protocol] = true
+	}
+	protocols, indices := plan.protocols[:0], plan.indices[:0]
+	for i, protocol := range plan.protocols {
+		if allowed[protocol] {
+			protocols = append(protocols, protocol)
+			indices = append(indices, plan.indices[i])
+		}
+	}
+	plan.protocols, plan.indices = protocols, indices
+
+	fmt.Printf("✓ %d of %d nodes need a re-test\n", len(plan.protocols), len(previous))
+	if len(plan.protocols) == 0 {
+		os.Exit(0)
+	}
+	fmt.Println()
+
+	return plan
+}
+
+// loadResultsFile reads results written with -format json. Console lines
+// printed before the JSON array (when stdout was redirected) are skipped.
+func loadResultsFile(path string) ([]*models.TestResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results: %w", err)
+	}
+
+	// The indented array opens on a line of its own; progress lines like
+	// "[1/2] name" also start with a bracket
+	start := 0
+	if !bytes.HasPrefix(data, []byte("[")) {
+		start = bytes.Index(data, []byte("\n[\n"))
+		if start < 0 {
+			return nil, fmt.Errorf("no JSON results found in %s", path)
+		}
+	}
+
+	var results []*models.TestResult
+	if err := json.NewDecoder(bytes.NewReader(data[start:])).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to parse results %s: %w", path, err)
+	}
+
+	return results, nil
+}
+
+// merge replaces the previous results of re-tested nodes, keeping the order
+// of the previous report
+func (plan *retestPlan) merge(retested []*models.TestResult) []*models.TestResult {
+	merged := make([]*models.TestResult, len(plan.previous))
+	copy(merged, plan.previous)
+
+	for i, result := range retested {
+		if result != nil && i < len(plan.indices) {
+			merged[plan.indices[i]] = result
+		}
+	}
+
+	return merged
+}
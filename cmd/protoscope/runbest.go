@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/checks"
+	"github.com/VenoMexx/ProtoScope/internal/tester"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// reasonRetest is returned by serveThrough when the periodic re-test is due
+const reasonRetest = "re-test due"
+
+// runBestCommand tests all nodes, starts a local SOCKS/HTTP proxy through the
+// best one and fails over to the next best node when health checks fail.
+func runBestCommand(args []string) {
+	listen := flag.String("listen", "127.0.0.1:1080", "Local SOCKS5/HTTP proxy address")
+	checkInterval := flag.Duration("check-interval", 30*time.Second, "Health check interval for the active node")
+	maxFailures := flag.Int("max-failures", 3, "Consecutive failed health checks before failing over")
+	retestInterval := flag.Duration("retest-interval", 0, "Re-test all nodes periodically and switch if a better one appears (0 = only on failure)")
+	dnsListen := flag.String("dns-listen", "", "Answer DNS queries for -dns-name with the local proxy address on this UDP address")
+	dnsName := flag.String("dns-name", "proxy.protoscope.lan", "Hostname that resolves to the local proxy address")
+	dnsTTL := flag.Duration("dns-ttl", 30*time.Second, "TTL of DNS answers")
+	flag.CommandLine.Parse(args)
+
+	host, portStr, err := net.SplitHostPort(*listen)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: invalid -listen address: %v\n", err)
+		os.Exit(1)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: invalid -listen port: %v\n", err)
+		os.Exit(1)
+	}
+	if tester.PortReserved(port) {
+		fmt.Fprintf(os.Stderr, "❌ Error: Windows reserves port %d (see netsh interface ipv4 show excludedportrange protocol=tcp), pick another -listen port\n", port)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if dns := startDNSResponder(ctx, *dnsListen, *dnsName, *dnsTTL); dns != nil {
+		ip, err := localAddress(host)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: cannot determine the local proxy address: %v\n", err)
+			os.Exit(1)
+		}
+		dns.SetAddresses([]net.IP{ip})
+		fmt.Printf("🌐 %s → %s\n", *dnsName, ip)
+	}
+
+	config := createConfig()
+	protocols := loadProtocols()
+	runner := newTestRunner(config)
+
+	for ctx.Err() == nil {
+		fmt.Println("🔍 Testing nodes to find the best one...")
+		results, err := runner.RunTests(ctx, protocols)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error running tests: %v\n", err)
+			os.Exit(1)
+		}
+		if err := runner.SaveResultCache(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Failed to save result cache: %v\n", err)
+		}
+
+		ranked := models.RankResults(results)
+		if len(ranked) == 0 {
+			fmt.Println("✗ No working nodes, retrying in", *checkInterval)
+			sleepContext(ctx, *checkInterval)
+			continue
+		}
+
+		// Walk down the ranking until a node comes up on the local address
+		for _, best := range ranked {
+			if ctx.Err() != nil {
+				break
+			}
+
+			fmt.Printf("🏆 Best node: %s [%s] (score %d)\n", best.Protocol.Name, best.Protocol.Type, best.Score())
+
+			reason := serveThrough(ctx, best.Protocol, &config.TestConfig, host, port, runner.ConnectivityURLs(), *checkInterval, *maxFailures, *retestInterval)
+			if reason == "" || reason == reasonRetest {
+				break
+			}
+			fmt.Printf("⚠ %s, failing over to the next node\n", reason)
+		}
+	}
+
+	fmt.Println("👋 Stopped")
+}
+
+// serveThrough keeps a local proxy running through the given node until it
+// becomes unhealthy, a re-test is due or ctx is cancelled. It returns the
+// reason for leaving, or "" on cancellation.
+func serveThrough(ctx context.Context, protocol *models.Protocol, testConfig *models.TestConfig, host string, port int, connectivityURLs []string, checkInterval time.Duration, maxFailures int, retestInterval time.Duration) string {
+	proxyMgr := tester.NewProxyManager(protocol, port)
+	proxyMgr.SetListenAddress(host)
+	proxyMgr.SetMixedInbound(true)
+	proxyMgr.SetVerbose(*verbose)
+	proxyMgr.SetSandbox(tester.SandboxFromConfig(testConfig))
+	proxyMgr.SetConfigStdin(testConfig.BackendConfigStdin)
+
+	// The backend must outlive the start timeout, so it only gets ctx
+	if err := proxyMgr.Start(ctx); err != nil {
+		return fmt.Sprintf("failed to start proxy: %v", err)
+	}
+	defer proxyMgr.Stop()
+
+	if http := proxyMgr.HTTPAddress(); http != proxyMgr.SOCKSAddress() {
+		fmt.Printf("✓ Proxy running on %s (SOCKS5), %s (HTTP)\n", proxyMgr.SOCKSAddress(), http)
+	} else {
+		fmt.Printf("✓ Proxy running on %s:%d (SOCKS5/HTTP)\n", host, port)
+	}
+
+	client, err := proxyMgr.GetHTTPClient(10 * time.Second)
+	if err != nil {
+		return fmt.Sprintf("failed to create HTTP client: %v", err)
+	}
+
+	var retestAt <-chan time.Time
+	if retestInterval > 0 {
+		retestAt = time.After(retestInterval)
+	}
+
+	connectivityChecker := checks.NewConnectivityChecker(10 * time.Second)
+	failures := 0
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ""
+		case <-retestAt:
+			return reasonRetest
+		case <-ticker.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		result, _ := connectivityChecker.CheckAny(checkCtx, connectivityURLs, client)
+		cancel()
+
+		if result != nil && result.Connected {
+			failures = 0
+			if *verbose {
+				fmt.Printf("       ✓ Health check OK (%dms)\n", result.ResponseTime.Milliseconds())
+			}
+			continue
+		}
+
+		failures++
+		fmt.Printf("       ✗ Health check failed (%d/%d)\n", failures, maxFailures)
+		if failures >= maxFailures {
+			return "node became unhealthy"
+		}
+	}
+}
+
+// sleepContext sleeps for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/tester"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// newRunInfo describes a run made with config from country
+func newRunInfo(ctx context.Context, config *models.Config, country string) *models.RunInfo {
+	info := &models.RunInfo{
+		Version:        version,
+		Args:           redactArgs(os.Args[1:]),
+		Backend:        config.TestConfig.Backend,
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		VantageCountry: country,
+	}
+	if info.Backend == "" {
+		info.Backend = "auto"
+	}
+
+	info.ConfigHash = hashJSON(config)
+
+	if tester.ProxyBackend(config.TestConfig.Backend) != tester.BackendMock {
+		versionCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		for _, backend := range []tester.ProxyBackend{tester.BackendSingbox, tester.BackendXray} {
+			if v, err := tester.BackendVersion(versionCtx, backend); err == nil {
+				if info.BackendVersions == nil {
+					info.BackendVersions = make(map[string]string)
+				}
+				info.BackendVersions[tester.GetBackendBinary(backend)] = v
+			}
+		}
+	}
+	return info
+}
+
+// hashJSON returns the SHA-256 of v's JSON encoding, or "" if it has none
+func hashJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheScope identifies the settings a node's result depends on besides
+// the node itself: what is tested and against which endpoints
+func cacheScope(config *models.Config) string {
+	return hashJSON([]interface{}{config.TestConfig, config.DomainLists, config.APIEndpoints})
+}
+
+// redactArgs reduces subscription URLs on the command line to their host,
+// since the path or query usually carries the subscription token
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i := 0; i < len(redacted); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(redacted[i], "-"), "=")
+		if !strings.HasPrefix(redacted[i], "-") || name != "url" {
+			continue
+		}
+		if hasValue {
+			redacted[i] = redacted[i][:len(redacted[i])-len(value)] + redactURL(value)
+		} else if i+1 < len(redacted) {
+			i++
+			redacted[i] = redactURL(redacted[i])
+		}
+	}
+	return redacted
+}
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+	return u.Scheme + "://" + u.Host + "/***"
+}
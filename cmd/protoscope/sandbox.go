@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/VenoMexx/ProtoScope/internal/sandbox"
+)
+
+// sandboxExecCommand confines itself and executes a proxy backend in its
+// place. ProtoScope starts backends through it when backend_sandbox,
+// backend_user or backend_apparmor_profile is set.
+func sandboxExecCommand(args []string) {
+	if err := sandbox.Exec(args); err != nil {
+		// The backend log is where this ends up
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/internal/secrets"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+const secretsUsage = `Usage:
+  protoscope secrets keygen [identity file]
+  protoscope secrets set [-config <file>] <alias>   (value read from stdin)
+  protoscope secrets list [-config <file>]
+  protoscope secrets rm [-config <file>] <alias>
+
+Config values written as secret:<alias> are replaced with the alias's value.`
+
+// secretsCommand manages the encrypted secrets file
+func secretsCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, secretsUsage)
+		os.Exit(1)
+	}
+	action := args[0]
+	flag.CommandLine.Parse(args[1:])
+
+	if action == "keygen" {
+		path := secrets.DefaultIdentityPath()
+		if flag.NArg() > 0 {
+			path = flag.Arg(0)
+		}
+		generateSecretsKey(path)
+		return
+	}
+
+	// The config is loaded without resolving its secrets, so a missing
+	// alias can still be set
+	config := models.DefaultConfig()
+	if *configFile != "" {
+		loaded, err := models.LoadConfig(*configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		config = loaded
+	}
+	store, err := openSecrets(config.Secrets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch action {
+	case "list":
+		aliases := store.Aliases()
+		if len(aliases) == 0 {
+			fmt.Println("No secrets")
+		}
+		for _, alias := range aliases {
+			fmt.Println(alias)
+		}
+		return
+
+	case "set":
+		if flag.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, secretsUsage)
+			os.Exit(1)
+		}
+		value, err := readSecretValue(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		store.Set(flag.Arg(0), value)
+		fmt.Printf("✓ Secret %s set; use it as %s%s\n", flag.Arg(0), secrets.Prefix, flag.Arg(0))
+
+	case "rm":
+		if flag.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, secretsUsage)
+			os.Exit(1)
+		}
+		if !store.Remove(flag.Arg(0)) {
+			fmt.Fprintf(os.Stderr, "❌ Error: no secret named %q\n", flag.Arg(0))
+			os.Exit(1)
+		}
+		fmt.Println("✓ Secret removed")
+
+	default:
+		fmt.Fprintln(os.Stderr, secretsUsage)
+		os.Exit(1)
+	}
+
+	if err := store.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// generateSecretsKey writes a new age identity to path, never over an
+// existing one
+func generateSecretsKey(path string) {
+	identity, err := secrets.GenerateX25519Identity()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	recipient := identity.Recipient()
+	fmt.Fprintf(f, "# public key: %s\n%s\n", recipient, identity)
+	if err := f.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Key written to %s\n", path)
+	fmt.Printf("Public key: %s\n", recipient)
+}
+
+// readSecretValue reads a secret's value from stdin, without the final
+// line break
+func readSecretValue(alias string) (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintf(os.Stderr, "Value of %s (input is shown; pipe it in to keep it off the screen): ", alias)
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// openSecrets opens the secrets file with the configured identity, the
+// default identity file when it exists, or the passphrase
+func openSecrets(config models.SecretsConfig) (*secrets.Store, error) {
+	path := config.File
+	if path == "" {
+		path = secrets.DefaultPath()
+	}
+
+	identityPath := config.Identity
+	if identityPath == "" {
+		if _, err := os.Stat(secrets.DefaultIdentityPath()); err == nil {
+			identityPath = secrets.DefaultIdentityPath()
+		}
+	}
+	if identityPath != "" {
+		identity, err := secrets.LoadX25519Identity(identityPath)
+		if err != nil {
+			return nil, err
+		}
+		return secrets.Open(path, identity, identity.Recipient())
+	}
+
+	passphrase := os.Getenv(config.PassphraseEnv)
+	if passphrase == "" {
+		return nil, errors.New("no key for the secrets file: run protoscope secrets keygen, set secrets.identity, or set " + config.PassphraseEnv)
+	}
+	identity, err := secrets.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := secrets.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return secrets.Open(path, identity, recipient)
+}
+
+// resolveSecrets replaces the secret:<alias> values of the config with
+// the aliases' values; an unknown alias is fatal
+func resolveSecrets(config *models.Config) {
+	if !secrets.HasReferences(config) {
+		return
+	}
+	store, err := openSecrets(config.Secrets)
+	if err == nil {
+		err = store.Resolve(config)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/VenoMexx/ProtoScope/internal/server"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// serveCommand serves the API without a schedule: scripts and dashboards
+// start runs with POST /runs, usually for a subscription URL, follow them
+// on /runs/{id} or its event stream and fetch the results as JSON
+func serveCommand(args []string) {
+	listen := flag.String("listen", "127.0.0.1:8080", "Address to serve the API on, e.g. :8080")
+	flag.CommandLine.Parse(args)
+
+	ctx, stop := daemonContext()
+	defer stop()
+
+	config := createConfig()
+	if config.Server.Runs.MaxConcurrent <= 0 {
+		fmt.Fprintln(os.Stderr, "❌ Error: server.runs.max_concurrent must be positive to serve runs")
+		os.Exit(1)
+	}
+
+	// Nodes given with -url, -file or the config are tested by requests
+	// without a url; they are fetched once
+	var latest atomic.Pointer[[]*models.Protocol]
+	var protocols []*models.Protocol
+	switch {
+	case *subscriptionURL != "" || *subscriptionFile != "":
+		protocols = loadProtocols()
+	case len(config.Subscriptions) > 0:
+		protocols = newSourceSet(config.Subscriptions).load(ctx)
+	}
+	latest.Store(&protocols)
+
+	_, base := startServer(ctx, config, *listen, &latest)
+	fmt.Printf("🌐 Serving the API on %s (POST /runs)\n", base)
+	if !hasAdminToken(config.Server.Tokens) {
+		fmt.Fprintln(os.Stderr, "⚠ No admin token in server.tokens, so POST /runs is refused")
+	}
+
+	<-ctx.Done()
+	fmt.Println("👋 Stopped")
+}
+
+// startServer serves config's server settings on listen until ctx is done,
+// with runs requested through it testing latest. It returns the server
+// and its base URL.
+func startServer(ctx context.Context, config *models.Config, listen string, latest *atomic.Pointer[[]*models.Protocol]) (*server.Server, string) {
+	srv := server.New()
+	srv.SetTokens(config.Server.Tokens)
+	srv.SetCORSOrigins(config.Server.CORSOrigins)
+	if len(config.Server.Tokens) == 0 {
+		fmt.Fprintln(os.Stderr, "⚠ No tokens in server.tokens, so the subscription, results and events are refused")
+	}
+	scheme := "http"
+	if config.Server.TLSCert != "" {
+		tlsConfig, err := server.LoadTLS(config.Server.TLSCert, config.Server.TLSKey, config.Server.ClientCA)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		srv.SetTLS(tlsConfig)
+		scheme = "https"
+	}
+	if err := serveRuns(ctx, srv, config, latest); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	go func() {
+		if err := srv.ListenAndServe(ctx, listen); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: HTTP server: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+	return srv, scheme + "://" + listen
+}
+
+// hasAdminToken reports whether any of tokens may start runs
+func hasAdminToken(tokens []models.APIToken) bool {
+	for _, token := range tokens {
+		if token.Scope == models.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/VenoMexx/ProtoScope/internal/parser"
+	"github.com/VenoMexx/ProtoScope/internal/server"
+	"github.com/VenoMexx/ProtoScope/internal/tester"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// serveRuns lets admins request runs through the server. Each runs beside
+// the scheduled one with its own runner, progress stream and share of the
+// port range,
+// within the quota in config.Server.Runs, and tests the latest nodes or
+// the subscription at the requested URL. The results stay with the run
+// (GET /runs/{id}/results); the served subscription and the notifiers
+// only see scheduled runs.
+func serveRuns(ctx context.Context, srv *server.Server, config *models.Config, latest *atomic.Pointer[[]*models.Protocol]) error {
+	quota := config.Server.Runs
+	if quota.MaxConcurrent <= 0 {
+		return nil
+	}
+
+	// Each run in flight takes a share of the range and gives it back when
+	// it ends; there are never more runs than shares
+	var pools chan *tester.PortPool
+	if quota.Ports != "" {
+		pool, err := tester.ParsePortPool(quota.Ports)
+		if err != nil {
+			return fmt.Errorf("server.runs.ports: %w", err)
+		}
+		shares, err := pool.Split(quota.MaxConcurrent)
+		if err != nil {
+			return fmt.Errorf("server.runs.ports: %w", err)
+		}
+		pools = make(chan *tester.PortPool, len(shares))
+		for _, share := range shares {
+			pools <- share
+		}
+	}
+
+	// The runs share the config but test fewer nodes at once
+	runConfig := *config
+	if quota.Concurrency > 0 {
+		runConfig.TestConfig.Concurrency = quota.Concurrency
+	}
+
+	srv.SetRunHandler(func(run *server.Run) {
+		var protocols []*models.Protocol
+		if url := run.Request().URL; url != "" {
+			subscription, err := parser.NewDecoder().DecodeSubscription(url)
+			if err != nil {
+				run.Finish(nil, err)
+				return
+			}
+			protocols = filterProtocols(subscription.Protocols)
+		} else if nodes := latest.Load(); nodes != nil {
+			protocols = *nodes
+		}
+		if len(protocols) == 0 {
+			run.Finish(nil, errors.New("no nodes to test: none loaded yet and no subscription url requested"))
+			return
+		}
+		if requested := run.Request().Nodes; len(requested) > 0 {
+			if protocols = matchNodes(protocols, requested); len(protocols) == 0 {
+				run.Finish(nil, errors.New("no node matches the requested nodes"))
+				return
+			}
+		}
+		if quota.MaxNodes > 0 && len(protocols) > quota.MaxNodes {
+			protocols = protocols[:quota.MaxNodes]
+		}
+		run.SetNodes(len(protocols))
+
+		runCtx := ctx
+		if quota.Timeout > 0 {
+			var cancel context.CancelFunc
+			runCtx, cancel = context.WithTimeout(ctx, quota.Timeout)
+			defer cancel()
+		}
+
+		var pool *tester.PortPool
+		if pools != nil {
+			pool = <-pools
+			defer func() { pools <- pool }()
+		}
+
+		runner := newTestRunner(&runConfig)
+		runner.SetPortPool(pool)
+		runner.SetProgress(run.Progress)
+
+		fmt.Printf("▶ Run %s started through the API: %d nodes\n", run.ID(), len(protocols))
+		results, err := runner.RunTests(runCtx, protocols)
+		if err == nil {
+			err = runCtx.Err()
+		}
+		run.Finish(results, err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Run %s failed: %v\n", run.ID(), err)
+			return
+		}
+		fmt.Printf("✓ Run %s finished\n", run.ID())
+	}, quota.MaxConcurrent, quota.MaxQueued)
+	return nil
+}
+
+// matchNodes returns the nodes named in queries or whose fingerprint
+// starts with one of them, as findNode matches a single node
+func matchNodes(protocols []*models.Protocol, queries []string) []*models.Protocol {
+	var matched []*models.Protocol
+	for _, protocol := range protocols {
+		for _, query := range queries {
+			if protocol.Name == query || (len(query) >= 8 && strings.HasPrefix(protocol.Fingerprint(), query)) {
+				matched = append(matched, protocol)
+				break
+			}
+		}
+	}
+	return matched
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/VenoMexx/ProtoScope/internal/service"
+)
+
+// serviceCommand installs or removes a system service running daemon mode
+func serviceCommand(args []string) {
+	if len(args) == 0 || (args[0] != "install" && args[0] != "uninstall") {
+		fmt.Fprintln(os.Stderr, "Usage: protoscope service install|uninstall [flags] [-- daemon flags]")
+		os.Exit(1)
+	}
+	action := args[0]
+
+	name := flag.String("name", "protoscope", "Service name")
+	logFile := flag.String("log-file", "", "Write the daemon's output to this file, rotated by size (default: journal on Linux, protoscope.log next to the binary on Windows)")
+	logMaxSize := flag.Int("log-max-size", 10, "Rotate the log file when it reaches this many MB")
+	logKeep := flag.Int("log-keep", 5, "Number of rotated log files to keep")
+	flag.CommandLine.Parse(args[1:])
+
+	if action == "uninstall" {
+		if err := service.Uninstall(*name); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Service %s removed\n", *name)
+		return
+	}
+
+	if *configFile == "" && *subscriptionURL == "" && *subscriptionFile == "" {
+		fmt.Fprintln(os.Stderr, "❌ Error: -config, -url or -file is required")
+		os.Exit(1)
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: cannot locate the protoscope binary: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *logFile == "" && runtime.GOOS == "windows" {
+		// Windows services have no console to write to
+		*logFile = filepath.Join(filepath.Dir(executable), "protoscope.log")
+	}
+
+	// Services don't start in the current directory, so paths are made
+	// absolute
+	daemonArgs := []string{"daemon"}
+	for _, path := range []struct{ flag, value string }{
+		{"-config", *configFile},
+		{"-file", *subscriptionFile},
+		{"-log-file", *logFile},
+	} {
+		if path.value == "" {
+			continue
+		}
+		abs, err := filepath.Abs(path.value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %s: %v\n", path.flag, err)
+			os.Exit(1)
+		}
+		daemonArgs = append(daemonArgs, path.flag, abs)
+	}
+	if *subscriptionURL != "" {
+		daemonArgs = append(daemonArgs, "-url", *subscriptionURL)
+	}
+	if *logFile != "" {
+		daemonArgs = append(daemonArgs,
+			"-log-max-size", strconv.Itoa(*logMaxSize),
+			"-log-keep", strconv.Itoa(*logKeep))
+	}
+	daemonArgs = append(daemonArgs, flag.Args()...)
+
+	cfg := service.Config{
+		Name:       *name,
+		Executable: executable,
+		Args:       daemonArgs,
+		LogFile:    *logFile,
+	}
+	if err := service.Install(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Service %s installed and started: %s %v\n", *name, executable, daemonArgs)
+	if *logFile != "" {
+		fmt.Printf("📄 Logs: %s (rotated at %d MB, %d kept)\n", *logFile, *logMaxSize, *logKeep)
+	} else {
+		fmt.Printf("📄 Logs: journalctl -u %s\n", *name)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/signing"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// writeResults renders results the way -format json prints them: the run
+// info object, then the results array. Each result is encoded straight to
+// w, so the whole report is never held in memory.
+func writeResults(w io.Writer, results []*models.TestResult, info *models.RunInfo) error {
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(map[string]*models.RunInfo{"run_info": info}); err != nil {
+		return err
+	}
+
+	// The array is written one element at a time, laid out as Encode would
+	var element bytes.Buffer
+	elementEncoder := json.NewEncoder(&element)
+	elementEncoder.SetIndent("  ", "  ")
+	bw.WriteString("[")
+	for i, result := range results {
+		if i > 0 {
+			bw.WriteString(",")
+		}
+		element.Reset()
+		if err := elementEncoder.Encode(result); err != nil {
+			return err
+		}
+		bw.WriteString("\n  ")
+		bw.Write(bytes.TrimSuffix(element.Bytes(), []byte("\n")))
+	}
+	if len(results) > 0 {
+		bw.WriteString("\n")
+	}
+	bw.WriteString("]\n")
+	return bw.Flush()
+}
+
+// encodeResults renders results like writeResults, into memory
+func encodeResults(results []*models.TestResult, info *models.RunInfo) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeResults(&buf, results, info); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// signingKey loads the secret key from the signing config; it returns nil
+// when signing is not configured
+func signingKey(config *models.Config) (*signing.SecretKey, error) {
+	if !config.Signing.Enabled() {
+		return nil, nil
+	}
+	data, err := os.ReadFile(config.Signing.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	password := ""
+	if config.Signing.PasswordEnv != "" {
+		password = os.Getenv(config.Signing.PasswordEnv)
+	}
+	return signing.ParseSecretKey(string(data), password)
+}
+
+// signResults signs a result file named name
+func signResults(key *signing.SecretKey, name string, data []byte) []byte {
+	return signing.Sign(key, data, resultsComment(name))
+}
+
+// resultsComment is the trusted comment of a result file's signature: when,
+// which file and which version
+func resultsComment(name string) string {
+	return fmt.Sprintf("timestamp:%d\tfile:%s\tprotoscope %s", time.Now().Unix(), name, version)
+}
+
+// writeJSONResults writes the -json-out file, and its .minisig signature
+// when signing is configured
+func writeJSONResults(path string, config *models.Config, results []*models.TestResult, info *models.RunInfo) {
+	file, err := os.Create(path)
+	if err == nil {
+		err = writeResults(file, results, info)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing %s: %v\n", path, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "💾 Results written to %s\n", path)
+
+	key, err := signingKey(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error loading signing key: %v\n", err)
+		return
+	}
+	if key == nil {
+		return
+	}
+	// The file is hashed as it is read back, not loaded whole
+	file, err = os.Open(path)
+	var sig []byte
+	if err == nil {
+		sig, err = signing.SignReader(key, file, resultsComment(filepath.Base(path)))
+		file.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading %s back to sign it: %v\n", path, err)
+		return
+	}
+	if err := os.WriteFile(path+".minisig", sig, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing %s.minisig: %v\n", path, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "🔏 Signed as %s.minisig\n", path)
+}
+
+// verifyCommand checks the signature of a result file
+func verifyCommand(args []string) {
+	pubKey := flag.String("pubkey", "", "Public key: a minisign .pub file or its base64 line (required)")
+	sigFile := flag.String("sig", "", "Signature file (default: the result file with .minisig appended)")
+	flag.CommandLine.Parse(args)
+
+	if *pubKey == "" || flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: protoscope verify -pubkey <key> [-sig <file>] <results.json>")
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+	if *sigFile == "" {
+		*sigFile = path + ".minisig"
+	}
+
+	keyText := *pubKey
+	if data, err := os.ReadFile(*pubKey); err == nil {
+		keyText = string(data)
+	}
+	key, err := signing.ParsePublicKey(keyText)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	sig, err := os.ReadFile(*sigFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	comment, err := signing.Verify(key, data, sig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ %s is signed by key %s and unmodified\n", path, key.KeyID())
+	fmt.Printf("  Trusted comment: %s\n", comment)
+}
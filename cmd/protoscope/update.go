@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/update"
+)
+
+// releaseRepo is the GitHub repository releases are published to
+const releaseRepo = "VenoMexx/ProtoScope"
+
+// releaseKeyFile holds the base64 ed25519 key release checksums are signed
+// with
+//
+//go:embed release.pub
+var releaseKeyFile string
+
+// releasePublicKey is the release key, release.pub unless overridden at
+// build time with -ldflags "-X main.releasePublicKey=...". Without a key
+// update warns and trusts the release's checksums alone.
+var releasePublicKey = strings.TrimSpace(releaseKeyFile)
+
+// updateCommand replaces the running binary with the latest release of a
+// channel
+func updateCommand(args []string) {
+	channel := flag.String("channel", update.ChannelStable, "Release channel: stable, nightly")
+	checkOnly := flag.Bool("check", false, "Only report whether an update is available")
+	force := flag.Bool("force", false, "Install the latest release even if it is not newer")
+	insecure := flag.Bool("insecure", false, "Verify the release against its checksums only, ignoring the release key")
+	flag.CommandLine.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	updater := update.New(releaseRepo)
+	if releasePublicKey != "" && !*insecure {
+		if err := updater.SetPublicKey(releasePublicKey); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	release, err := updater.Latest(ctx, *channel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !*force && !update.Newer(release.Tag, version) {
+		fmt.Printf("✓ Up to date (%s, latest %s release is %s)\n", version, *channel, release.Tag)
+		return
+	}
+	fmt.Printf("⬆ Update available: %s → %s\n", version, release.Tag)
+	if *checkOnly {
+		return
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: cannot locate the protoscope binary: %v\n", err)
+		os.Exit(1)
+	}
+
+	// A checksum file from the same release proves nothing about who
+	// published it, only that the download arrived intact
+	switch {
+	case *insecure:
+		fmt.Fprintln(os.Stderr, "⚠ Warning: -insecure, verifying the checksum only; this does not prove who published the release")
+	case !updater.Signed():
+		fmt.Fprintln(os.Stderr, "⚠ Warning: this build has no release key, verifying the checksum only; this does not prove who published the release")
+	}
+	binary, err := updater.Download(ctx, release)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := update.Replace(executable, binary); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: replacing %s: %v\n", executable, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Updated %s to %s\n", executable, release.Tag)
+}
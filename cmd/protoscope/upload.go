@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/export"
+	"github.com/VenoMexx/ProtoScope/internal/report"
+	"github.com/VenoMexx/ProtoScope/internal/upload"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// uploadFile is one generated file published after a run
+type uploadFile struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
+// uploadResults publishes the report and exported subscriptions of a run to
+// the upload targets in the config. Failures are logged, not fatal.
+func uploadResults(ctx context.Context, config *models.Config, results []*models.TestResult, info *models.RunInfo) {
+	uploaders := upload.FromConfig(config.Upload)
+	if len(uploaders) == 0 {
+		return
+	}
+
+	files := uploadFiles(config, results, info)
+
+	uploadCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	for _, uploader := range uploaders {
+		uploaded := 0
+		for _, file := range files {
+			if err := uploader.Upload(uploadCtx, file.name, file.data, file.contentType); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠ Failed to upload %s to %s: %v\n", file.name, uploader.Name(), err)
+				continue
+			}
+			uploaded++
+		}
+		fmt.Fprintf(os.Stderr, "📤 Uploaded %d/%d files to %s\n", uploaded, len(files), uploader.Name())
+	}
+}
+
+// uploadFiles renders the report and subscription exports. Exports are
+// left out when no node works, so the last good subscription stays online.
+// With signing configured the JSON results and their signature are added.
+func uploadFiles(config *models.Config, results []*models.TestResult, info *models.RunInfo) []uploadFile {
+	var files []uploadFile
+
+	reportFormat := config.Upload.ReportFormat
+	reportName, reportType := "report.md", "text/markdown; charset=utf-8"
+	if reportFormat == "html" {
+		reportName, reportType = "report.html", "text/html; charset=utf-8"
+	}
+	if data, err := report.Render(reportFormat, results, info); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to render report for upload: %v\n", err)
+	} else {
+		files = append(files, uploadFile{reportName, reportType, data})
+	}
+
+	if data, err := export.Subscription(results); err == nil {
+		files = append(files, uploadFile{"subscription.txt", "text/plain; charset=utf-8", data})
+	}
+
+	opts := export.DefaultFailoverOptions()
+	if data, err := export.ClashFailover(results, opts); err == nil {
+		files = append(files, uploadFile{"clash.yaml", "application/yaml", data})
+	}
+	if data, err := export.SingboxFailover(results, opts); err == nil {
+		files = append(files, uploadFile{"singbox.json", "application/json", data})
+	}
+
+	if key, err := signingKey(config); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to load signing key: %v\n", err)
+	} else if key != nil {
+		if data, err := encodeResults(results, info); err == nil {
+			files = append(files,
+				uploadFile{"results.json", "application/json", data},
+				uploadFile{"results.json.minisig", "text/plain; charset=utf-8", signResults(key, "results.json", data)})
+		}
+	}
+
+	return files
+}
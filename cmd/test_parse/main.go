@@ -19,7 +19,6 @@ func main() {
 	lineNum := 0
 	skippedCount := 0
 	supportedCount := 0
-	unsupportedCount := 0
 	
 	for scanner.Scan() {
 		lineNum++
@@ -44,10 +43,10 @@ func main() {
 			protocol, err = parser.ParseHysteria2(line)
 		case strings.HasPrefix(line, "tuic://"):
 			protocol, err = parser.ParseTUIC(line)
-		case strings.HasPrefix(line, "wg://"), strings.HasPrefix(line, "ssh://"):
-			unsupportedCount++
-			fmt.Printf("[Line %d] UNSUPPORTED: %s\n", lineNum, strings.Split(line, "://")[0])
-			continue
+		case strings.HasPrefix(line, "wg://"), strings.HasPrefix(line, "wireguard://"):
+			protocol, err = parser.ParseWireGuard(line)
+		case strings.HasPrefix(line, "ssh://"):
+			protocol, err = parser.ParseSSH(line)
 		default:
 			continue
 		}
@@ -68,6 +67,5 @@ func main() {
 	fmt.Printf("\n=== SUMMARY ===\n")
 	fmt.Printf("✓ Successfully parsed: %d\n", supportedCount)
 	fmt.Printf("✗ Parse errors: %d\n", skippedCount)
-	fmt.Printf("⊘ Unsupported (wg/ssh): %d\n", unsupportedCount)
 	fmt.Printf("Total lines: %d\n", lineNum)
 }
@@ -0,0 +1,136 @@
+// Package archive keeps the reports and exports of daemon runs on disk, a
+// timestamped directory per run, pruned by a retention policy so
+// long-running instances don't fill the disk
+package archive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// dirLayout names run directories by their UTC start time; names sort in
+// time order
+const dirLayout = "2006-01-02T150405Z"
+
+// Policy decides which runs are kept. Keep is the number of latest runs
+// kept; Daily and Weekly keep the latest run of each of that many days and
+// ISO weeks with runs, going back from the newest, as rollups of older
+// history. A zero Policy keeps everything.
+type Policy struct {
+	Keep   int
+	Daily  int
+	Weekly int
+}
+
+// Archive is a directory of run directories
+type Archive struct {
+	dir    string
+	policy Policy
+}
+
+// New returns the archive in dir
+func New(dir string, policy Policy) *Archive {
+	return &Archive{dir: dir, policy: policy}
+}
+
+// Save writes the files of the run started at started into a new directory
+// and returns its path
+func (a *Archive) Save(started time.Time, files map[string][]byte) (string, error) {
+	path := filepath.Join(a.dir, started.UTC().Format(dirLayout))
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return "", err
+	}
+
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(path, name), data, 0644); err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// Prune removes the run directories the policy doesn't keep and returns
+// how many were removed. Other files in the archive are left alone.
+func (a *Archive) Prune() (int, error) {
+	if a.policy == (Policy{}) {
+		return 0, nil
+	}
+
+	runs, err := a.runs()
+	if err != nil {
+		return 0, err
+	}
+	keep := a.policy.retain(runs)
+
+	removed := 0
+	for _, run := range runs {
+		if keep[run] {
+			continue
+		}
+		path := filepath.Join(a.dir, run.Format(dirLayout))
+		if err := os.RemoveAll(path); err != nil {
+			return removed, fmt.Errorf("removing %s: %w", path, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// runs lists the start times of the archived runs, newest first
+func (a *Archive) runs() ([]time.Time, error) {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var runs []time.Time
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if started, err := time.Parse(dirLayout, entry.Name()); err == nil {
+			runs = append(runs, started)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].After(runs[j]) })
+	return runs, nil
+}
+
+// retain returns the runs to keep out of runs, sorted newest first
+func (p Policy) retain(runs []time.Time) map[time.Time]bool {
+	keep := map[time.Time]bool{}
+	for i := 0; i < p.Keep && i < len(runs); i++ {
+		keep[runs[i]] = true
+	}
+
+	// The newest run of each period stands for the period
+	rollup := func(periods int, period func(time.Time) string) {
+		seen := map[string]bool{}
+		for _, run := range runs {
+			key := period(run)
+			if seen[key] {
+				continue
+			}
+			if len(seen) == periods {
+				break
+			}
+			seen[key] = true
+			keep[run] = true
+		}
+	}
+	rollup(p.Daily, func(t time.Time) string {
+		return t.Format(time.DateOnly)
+	})
+	rollup(p.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+
+	return keep
+}
@@ -0,0 +1,65 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	a := New(dir, Policy{Keep: 2, Daily: 3, Weekly: 2})
+
+	// Runs every 12 hours over three weeks, the newest on Wed 2025-03-19
+	newest := time.Date(2025, 3, 19, 18, 0, 0, 0, time.UTC)
+	for i := 0; i < 42; i++ {
+		if _, err := a.Save(newest.Add(-time.Duration(i)*12*time.Hour), map[string][]byte{"report.md": []byte("# report")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644)
+
+	if _, err := a.Prune(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	var kept []string
+	for _, entry := range entries {
+		kept = append(kept, entry.Name())
+	}
+	want := []string{
+		"2025-03-16T180000Z", // week 11
+		"2025-03-17T180000Z", // day 3
+		"2025-03-18T180000Z", // day 2
+		"2025-03-19T060000Z", // keep 2
+		"2025-03-19T180000Z", // keep 1, day 1, week 12
+		"notes.txt",
+	}
+	if len(kept) != len(want) {
+		t.Fatalf("kept %v, want %v", kept, want)
+	}
+	for i := range want {
+		if kept[i] != want[i] {
+			t.Errorf("kept %v, want %v", kept, want)
+			break
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "2025-03-19T180000Z", "report.md"))
+	if err != nil || string(data) != "# report" {
+		t.Errorf("report = %q, %v", data, err)
+	}
+}
+
+func TestPruneZeroPolicy(t *testing.T) {
+	dir := t.TempDir()
+	a := New(dir, Policy{})
+	for i := 0; i < 3; i++ {
+		a.Save(time.Date(2025, 3, 1, i, 0, 0, 0, time.UTC), nil)
+	}
+	if removed, err := a.Prune(); err != nil || removed != 0 {
+		t.Errorf("zero policy removed %d runs (%v), want none", removed, err)
+	}
+}
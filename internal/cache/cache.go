@@ -0,0 +1,122 @@
+// Package cache persists test results keyed by node fingerprint and test
+// configuration so unchanged nodes can be skipped on later runs.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// ResultCache stores the last result of each node
+type ResultCache struct {
+	path  string
+	ttl   time.Duration
+	scope string // hash of the configuration results were tested with
+
+	mu      sync.Mutex
+	entries map[string]*models.TestResult
+}
+
+// DefaultPath returns the cache file in the user's cache directory
+func DefaultPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "protoscope", "results.json")
+}
+
+// Load reads the cache file. A missing file yields an empty cache. scope
+// identifies the test configuration: results tested with another one are
+// not reused, as they ran other checks.
+func Load(path string, ttl time.Duration, scope string) (*ResultCache, error) {
+	c := &ResultCache{
+		path:    path,
+		ttl:     ttl,
+		scope:   scope,
+		entries: make(map[string]*models.TestResult),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse result cache %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// Get returns a copy of the cached result for the node if it was tested
+// within the TTL with the same connection settings and configuration
+func (c *ResultCache) Get(protocol *models.Protocol) (*models.TestResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.entries[c.key(protocol)]
+	if !ok || time.Since(cached.Timestamp) > c.ttl {
+		return nil, false
+	}
+
+	result := *cached
+	result.Protocol = protocol
+	result.Cached = true
+	return &result, true
+}
+
+// Put stores a fresh result
+func (c *ResultCache) Put(result *models.TestResult) {
+	if result == nil || result.Protocol == nil || result.Cached {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[c.key(result.Protocol)] = result
+}
+
+// key is the entry of a node tested with the cache's configuration
+func (c *ResultCache) key(protocol *models.Protocol) string {
+	return c.scope + ":" + protocol.Fingerprint()
+}
+
+// Save writes the cache file, dropping entries older than the TTL
+func (c *ResultCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, result := range c.entries {
+		if time.Since(result.Timestamp) > c.ttl {
+			delete(c.entries, key)
+		}
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Results include node credentials, so the file is private. It is written
+	// atomically so an interrupted run can't leave a truncated cache.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write result cache: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
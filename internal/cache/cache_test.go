@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestResultCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	protocol := &models.Protocol{Type: models.ProtocolTrojan, Name: "DE-01", Server: "de.example.com", Port: 443, Password: "pw"}
+
+	c, err := Load(path, time.Hour, "a")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	c.Put(&models.TestResult{Protocol: protocol, Timestamp: time.Now(), Success: true})
+	c.Put(&models.TestResult{
+		Protocol:  &models.Protocol{Type: models.ProtocolTrojan, Server: "old.example.com", Port: 443},
+		Timestamp: time.Now().Add(-2 * time.Hour),
+	})
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	c, err = Load(path, time.Hour, "a")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(c.entries) != 1 {
+		t.Fatalf("expected expired entry to be dropped on save, got %d entries", len(c.entries))
+	}
+
+	renamed := protocol.Clone()
+	renamed.Name = "Germany 1"
+	result, ok := c.Get(renamed)
+	if !ok || !result.Success || !result.Cached {
+		t.Fatalf("expected cached success for unchanged node, got %+v", result)
+	}
+	if result.Protocol.Name != "Germany 1" {
+		t.Fatal("expected cached result to carry the current protocol")
+	}
+
+	changed := protocol.Clone()
+	changed.Password = "new"
+	if _, ok := c.Get(changed); ok {
+		t.Fatal("expected changed node to miss the cache")
+	}
+
+	c, err = Load(path, time.Hour, "b")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if _, ok := c.Get(protocol); ok {
+		t.Fatal("expected a result tested with another configuration to miss the cache")
+	}
+}
@@ -0,0 +1,253 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Server reactions to a probe
+const (
+	ProbeTimeout  = "timeout"  // kept the connection open without answering
+	ProbeReset    = "reset"    // TCP reset
+	ProbeClose    = "close"    // orderly close without data
+	ProbeHTTP     = "http"     // HTTP response, e.g. a decoy page
+	ProbeTLS      = "tls"      // TLS record, e.g. an alert
+	ProbeData     = "data"     // other bytes
+	ProbeRefused  = "refused"  // connection refused
+	ProbeTLSError = "tls-fail" // TLS handshake failed
+	ProbeAccepted = "accepted" // proxied traffic with wrong credentials
+	ProbeRejected = "rejected" // refused to proxy with wrong credentials
+)
+
+// CredentialProbeName names the probe made with wrong credentials
+const CredentialProbeName = "wrong-credentials"
+
+// Active-probing resistance verdicts
+const (
+	ProbingResistant       = "resistant"
+	ProbingPartial         = "partial"
+	ProbingFingerprintable = "fingerprintable"
+)
+
+// ActiveProbeChecker connects to a node server directly with traffic that
+// isn't a valid client handshake, as censors do to confirm suspected
+// proxies, and scores how much the reactions give away
+type ActiveProbeChecker struct {
+	timeout time.Duration // per probe
+	// credentialProbe runs the node's own protocol with wrong credentials;
+	// it returns nil when it couldn't tell
+	credentialProbe func(ctx context.Context) *models.ActiveProbe
+}
+
+// NewActiveProbeChecker creates a new active-probing checker
+func NewActiveProbeChecker(timeout time.Duration) *ActiveProbeChecker {
+	return &ActiveProbeChecker{
+		timeout: timeout,
+	}
+}
+
+// SetCredentialProbe adds a probe made with the node's protocol and
+// deliberately wrong credentials. A server that proxies for them accepts
+// anyone, which a prober confirms with a single request.
+func (a *ActiveProbeChecker) SetCredentialProbe(probe func(ctx context.Context) *models.ActiveProbe) {
+	a.credentialProbe = probe
+}
+
+// Check probes host:port. sni is set for TLS nodes, which additionally get
+// a TLS handshake followed by a plain HTTP request in place of the proxy
+// protocol; servers with a fallback answer it like a web server.
+func (a *ActiveProbeChecker) Check(ctx context.Context, host string, port int, sni string) (*models.ActiveProbingResult, error) {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	result := &models.ActiveProbingResult{}
+
+	httpRequest := []byte("GET / HTTP/1.1\r\nHost: " + hostHeader(host, sni) + "\r\nUser-Agent: Mozilla/5.0\r\nAccept: */*\r\n\r\n")
+
+	probes := []struct {
+		name    string
+		payload []byte
+	}{
+		{"empty", nil},
+		{"http", httpRequest},
+		// Two lengths: servers that wait for a fixed-size header before
+		// closing react differently to them, as Shadowsocks servers did
+		{"random-50", randomBytes(50)},
+		{"random-221", randomBytes(221)},
+	}
+
+	for _, probe := range probes {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		result.Probes = append(result.Probes, a.probe(ctx, address, probe.name, probe.payload))
+	}
+
+	if sni != "" && ctx.Err() == nil {
+		result.Probes = append(result.Probes, a.probeTLS(ctx, address, sni, httpRequest))
+	}
+
+	refused := 0
+	for _, probe := range result.Probes {
+		if probe.Response == ProbeRefused {
+			refused++
+		}
+	}
+	if refused == len(result.Probes) {
+		result.Error = "server refused every probe"
+		return result, fmt.Errorf("%s", result.Error)
+	}
+
+	if a.credentialProbe != nil && ctx.Err() == nil {
+		if probe := a.credentialProbe(ctx); probe != nil {
+			result.Probes = append(result.Probes, *probe)
+		}
+	}
+
+	result.Score, result.Verdict = scoreProbing(result.Probes)
+	return result, nil
+}
+
+// probe sends payload on a fresh connection and records the reaction
+func (a *ActiveProbeChecker) probe(ctx context.Context, address, name string, payload []byte) models.ActiveProbe {
+	start := time.Now()
+	dialer := &net.Dialer{Timeout: a.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return models.ActiveProbe{Name: name, Response: ProbeRefused, Detail: err.Error()}
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(a.timeout))
+	if len(payload) > 0 {
+		if _, err := conn.Write(payload); err != nil {
+			return models.ActiveProbe{Name: name, Response: classifyProbeError(err), Detail: err.Error(), Elapsed: time.Since(start)}
+		}
+	}
+	return a.readReaction(conn, name, start)
+}
+
+// probeTLS completes a TLS handshake and sends payload inside it
+func (a *ActiveProbeChecker) probeTLS(ctx context.Context, address, sni string, payload []byte) models.ActiveProbe {
+	const name = "tls-http"
+	start := time.Now()
+	dialer := &net.Dialer{Timeout: a.timeout}
+	rawConn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return models.ActiveProbe{Name: name, Response: ProbeRefused, Detail: err.Error()}
+	}
+	defer rawConn.Close()
+
+	rawConn.SetDeadline(time.Now().Add(a.timeout))
+	conn := tls.Client(rawConn, &tls.Config{ServerName: sni, InsecureSkipVerify: true})
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return models.ActiveProbe{Name: name, Response: ProbeTLSError, Detail: err.Error(), Elapsed: time.Since(start)}
+	}
+	if _, err := conn.Write(payload); err != nil {
+		return models.ActiveProbe{Name: name, Response: classifyProbeError(err), Detail: err.Error(), Elapsed: time.Since(start)}
+	}
+	return a.readReaction(conn, name, start)
+}
+
+// readReaction waits for the server's first answer, or its absence
+func (a *ActiveProbeChecker) readReaction(conn net.Conn, name string, start time.Time) models.ActiveProbe {
+	buf := make([]byte, 512)
+	n, err := conn.Read(buf)
+	probe := models.ActiveProbe{Name: name, Elapsed: time.Since(start)}
+
+	switch {
+	case n > 0 && bytes.HasPrefix(buf[:n], []byte("HTTP/")):
+		probe.Response = ProbeHTTP
+		probe.Detail = string(bytes.SplitN(buf[:n], []byte("\r\n"), 2)[0])
+	case n > 0 && (buf[0] == 0x15 || buf[0] == 0x16) && n >= 3 && buf[1] == 0x03:
+		probe.Response = ProbeTLS
+	case n > 0:
+		probe.Response = ProbeData
+		probe.Detail = fmt.Sprintf("%d bytes", n)
+	default:
+		probe.Response = classifyProbeError(err)
+		if probe.Response == ProbeData {
+			probe.Detail = err.Error()
+		}
+	}
+	return probe
+}
+
+// classifyProbeError maps a read or write error to a reaction
+func classifyProbeError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ProbeTimeout
+	case errors.Is(err, syscall.ECONNRESET):
+		return ProbeReset
+	case errors.Is(err, io.EOF):
+		return ProbeClose
+	default:
+		return ProbeData
+	}
+}
+
+// scoreProbing rates the reactions. Answering like a web server or staying
+// silent reveals nothing; closing or resetting on garbage, odd replies and
+// reactions that depend on the payload length are what probers look for.
+func scoreProbing(probes []models.ActiveProbe) (int, string) {
+	score := 100
+	reactions := map[string]string{}
+	for _, probe := range probes {
+		reactions[probe.Name] = probe.Response
+		switch probe.Response {
+		case ProbeReset, ProbeClose:
+			score -= 20
+		case ProbeData:
+			score -= 25
+		case ProbeTLSError:
+			score -= 10
+		}
+	}
+
+	if reactions["random-50"] != reactions["random-221"] {
+		score -= 20
+	}
+	// A TLS node that falls back to a web server passes; dropping a valid
+	// handshake followed by HTTP shows there is no fallback
+	if response, ok := reactions["tls-http"]; ok && response != ProbeHTTP {
+		score -= 15
+	}
+	// Hiding the protocol is moot when any client may use it
+	if reactions[CredentialProbeName] == ProbeAccepted {
+		score -= 50
+	}
+
+	score = max(score, 0)
+	switch {
+	case score >= 75:
+		return score, ProbingResistant
+	case score >= 50:
+		return score, ProbingPartial
+	default:
+		return score, ProbingFingerprintable
+	}
+}
+
+func hostHeader(host, sni string) string {
+	if sni != "" {
+		return sni
+	}
+	return host
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
@@ -0,0 +1,95 @@
+package checks
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestActiveProbeWebServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	}))
+	defer server.Close()
+
+	host, port := splitAddr(t, server.Listener.Addr())
+	result, err := NewActiveProbeChecker(300*time.Millisecond).Check(context.Background(), host, port, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Verdict != ProbingResistant {
+		t.Errorf("web server rated %s (%d): %+v", result.Verdict, result.Score, result.Probes)
+	}
+}
+
+func TestActiveProbeOpenProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	}))
+	defer server.Close()
+
+	checker := NewActiveProbeChecker(300 * time.Millisecond)
+	checker.SetCredentialProbe(func(ctx context.Context) *models.ActiveProbe {
+		return &models.ActiveProbe{Name: CredentialProbeName, Response: ProbeAccepted}
+	})
+	host, port := splitAddr(t, server.Listener.Addr())
+	result, err := checker.Check(context.Background(), host, port, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := result.Probes[len(result.Probes)-1]; last.Name != CredentialProbeName {
+		t.Errorf("last probe %+v, want the credential probe", last)
+	}
+	if result.Verdict == ProbingResistant {
+		t.Errorf("server accepting any credentials rated resistant (%d)", result.Score)
+	}
+}
+
+func TestActiveProbeLengthDependentClose(t *testing.T) {
+	// Waits for a 100-byte header and drops the connection when it doesn't
+	// authenticate, the reaction that gave Shadowsocks servers away
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(time.Second))
+				io.ReadFull(conn, make([]byte, 100))
+			}()
+		}
+	}()
+
+	host, port := splitAddr(t, listener.Addr())
+	result, err := NewActiveProbeChecker(300*time.Millisecond).Check(context.Background(), host, port, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Verdict == ProbingResistant {
+		t.Errorf("length-dependent server rated resistant: %+v", result.Probes)
+	}
+}
+
+func splitAddr(t *testing.T, addr net.Addr) (string, int) {
+	t.Helper()
+	host, portText, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(portText)
+	return host, port
+}
@@ -0,0 +1,96 @@
+package checks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Blocklists holds the domains sampled from ad, tracker and malware
+// blocklists for the DNS blocking test
+type Blocklists struct {
+	Ads      []string
+	Tracking []string
+	Malware  []string
+}
+
+// LoadBlocklists fetches the blocklists and keeps the first sample domains
+// of each. Lists that fail, or have an unknown category, are reported and
+// left out.
+func LoadBlocklists(ctx context.Context, client *http.Client, sources []models.BlocklistSource, sample int) (*Blocklists, []error) {
+	b := &Blocklists{}
+	var errs []error
+
+	for _, source := range sources {
+		var list *[]string
+		switch source.Category {
+		case "ads":
+			list = &b.Ads
+		case "tracking":
+			list = &b.Tracking
+		case "malware":
+			list = &b.Malware
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown category %q", source.Name, source.Category))
+			continue
+		}
+
+		body, err := fetchFeed(ctx, client, source.URL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name, err))
+			continue
+		}
+
+		domains := parseBlocklist(body, sample)
+		if len(domains) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no domains in list", source.Name))
+			continue
+		}
+		*list = append(*list, domains...)
+	}
+
+	return b, errs
+}
+
+// parseBlocklist returns up to limit domains of a hosts file or a list with
+// one domain per line. Comments, addresses and the localhost entries hosts
+// files start with are skipped.
+func parseBlocklist(body []byte, limit int) []string {
+	var domains []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() && len(domains) < limit {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		domain := fields[0]
+		if _, err := netip.ParseAddr(domain); err == nil {
+			if len(fields) < 2 {
+				continue
+			}
+			domain = fields[1]
+		}
+		domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+		if !strings.Contains(domain, ".") || strings.HasPrefix(domain, "localhost") || seen[domain] {
+			continue
+		}
+		if _, err := netip.ParseAddr(domain); err == nil {
+			continue
+		}
+
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+
+	return domains
+}
@@ -0,0 +1,56 @@
+package checks
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestParseBlocklist(t *testing.T) {
+	hosts := []byte(`# Title: test hosts
+127.0.0.1 localhost
+127.0.0.1 localhost.localdomain
+::1 localhost
+0.0.0.0 0.0.0.0
+0.0.0.0 ads.example.com # banner
+0.0.0.0 ADS.example.com
+0.0.0.0 tracker.example.net.
+pixel.example.org
+0.0.0.0 one.too.many
+`)
+	got := parseBlocklist(hosts, 3)
+	want := []string{"ads.example.com", "tracker.example.net", "pixel.example.org"}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseBlocklist = %v, want %v", got, want)
+	}
+}
+
+func TestBlockingEffectiveness(t *testing.T) {
+	status := func(blocked bool) models.BlockStatus {
+		return models.BlockStatus{IsBlocked: blocked}
+	}
+	result := &models.DNSBlockingResult{
+		Ads:      map[string]models.BlockStatus{"a": status(true), "b": status(false)},
+		Tracking: map[string]models.BlockStatus{"c": status(false)},
+		Malware:  map[string]models.BlockStatus{"d": status(true)},
+	}
+
+	tests := []struct {
+		name    string
+		weights models.BlockingWeights
+		want    float64
+	}{
+		{"unweighted", models.BlockingWeights{}, 50},
+		{"malware first", models.BlockingWeights{Ads: 1, Tracking: 1, Malware: 2}, 62.5},
+		{"ads only", models.BlockingWeights{Ads: 1}, 50},
+	}
+	for _, tt := range tests {
+		checker := NewDNSChecker(0)
+		checker.SetWeights(tt.weights)
+		if got := checker.blockingEffectiveness(result); math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("%s: effectiveness = %.2f, want %.2f", tt.name, got, tt.want)
+		}
+	}
+}
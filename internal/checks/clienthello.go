@@ -0,0 +1,255 @@
+package checks
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TLS extension types used when describing a ClientHello
+const (
+	extServerName      = 0
+	extSupportedGroups = 10
+	extPointFormats    = 11
+	extALPN            = 16
+	extRecordSizeLimit = 28
+)
+
+// ClientHello is the part of a TLS ClientHello that identifies the client
+// implementation
+type ClientHello struct {
+	Version      uint16
+	CipherSuites []uint16
+	Extensions   []uint16 // in the order sent
+	Groups       []uint16
+	PointFormats []uint8
+	ServerName   string
+	ALPN         []string
+	GREASE       bool // RFC 8701 placeholder values, sent by Chromium and Safari
+}
+
+// ParseClientHello parses a ClientHello from the first bytes a client sent
+// on a connection: a TLS handshake record holding the hello
+func ParseClientHello(data []byte) (*ClientHello, error) {
+	if len(data) < 5 || data[0] != 0x16 {
+		return nil, fmt.Errorf("not a TLS handshake record")
+	}
+	recordLen := int(binary.BigEndian.Uint16(data[3:5]))
+	if len(data) < 5+recordLen {
+		return nil, fmt.Errorf("truncated TLS record: have %d of %d bytes", len(data)-5, recordLen)
+	}
+
+	r := helloReader(data[5 : 5+recordLen])
+	msgType, ok := r.uint8()
+	if !ok || msgType != 1 {
+		return nil, fmt.Errorf("not a ClientHello")
+	}
+	body, ok := r.bytes(3)
+	if !ok {
+		return nil, fmt.Errorf("ClientHello spans several records")
+	}
+
+	hello := &ClientHello{}
+	r = helloReader(body)
+	if hello.Version, ok = r.uint16(); !ok {
+		return nil, fmt.Errorf("truncated ClientHello")
+	}
+	if _, ok = r.skip(32); !ok { // random
+		return nil, fmt.Errorf("truncated ClientHello")
+	}
+	if _, ok = r.bytes(1); !ok { // session id
+		return nil, fmt.Errorf("truncated ClientHello")
+	}
+
+	suites, ok := r.bytes(2)
+	if !ok {
+		return nil, fmt.Errorf("truncated cipher suites")
+	}
+	for s := helloReader(suites); len(s) >= 2; {
+		suite, _ := s.uint16()
+		hello.add(&hello.CipherSuites, suite)
+	}
+
+	if _, ok = r.bytes(1); !ok { // compression methods
+		return nil, fmt.Errorf("truncated compression methods")
+	}
+
+	extensions, ok := r.bytes(2)
+	if !ok {
+		// Extensions are optional in TLS 1.2
+		return hello, nil
+	}
+	for e := helloReader(extensions); len(e) > 0; {
+		extType, ok1 := e.uint16()
+		extData, ok2 := e.bytes(2)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("truncated extension")
+		}
+		hello.add(&hello.Extensions, extType)
+		hello.parseExtension(extType, extData)
+	}
+
+	return hello, nil
+}
+
+func (h *ClientHello) parseExtension(extType uint16, data []byte) {
+	r := helloReader(data)
+	switch extType {
+	case extServerName:
+		list, _ := r.bytes(2)
+		for l := helloReader(list); len(l) > 0; {
+			nameType, _ := l.uint8()
+			name, ok := l.bytes(2)
+			if !ok {
+				return
+			}
+			if nameType == 0 {
+				h.ServerName = string(name)
+			}
+		}
+	case extSupportedGroups:
+		list, _ := r.bytes(2)
+		for l := helloReader(list); len(l) >= 2; {
+			group, _ := l.uint16()
+			h.add(&h.Groups, group)
+		}
+	case extPointFormats:
+		list, _ := r.bytes(1)
+		h.PointFormats = append(h.PointFormats, list...)
+	case extALPN:
+		list, _ := r.bytes(2)
+		for l := helloReader(list); len(l) > 0; {
+			proto, ok := l.bytes(1)
+			if !ok {
+				return
+			}
+			h.ALPN = append(h.ALPN, string(proto))
+		}
+	}
+}
+
+// add appends a value to a list, leaving out GREASE placeholders as JA3 does
+func (h *ClientHello) add(list *[]uint16, value uint16) {
+	if isGREASE(value) {
+		h.GREASE = true
+		return
+	}
+	*list = append(*list, value)
+}
+
+// isGREASE reports whether v is one of the 0x?a?a values reserved by RFC 8701
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// JA3 returns the JA3 string: version, ciphers, extensions, groups and
+// point formats
+func (h *ClientHello) JA3() string {
+	return h.ja3(h.Extensions)
+}
+
+// JA3Hash returns the MD5 of the JA3 string, the usual fingerprint form
+func (h *ClientHello) JA3Hash() string {
+	return md5Hex(h.JA3())
+}
+
+// JA3NHash is JA3 with extensions sorted. Chromium shuffles its extensions
+// on every connection, so only the sorted form is stable.
+func (h *ClientHello) JA3NHash() string {
+	return md5Hex(h.ja3(slices.Sorted(slices.Values(h.Extensions))))
+}
+
+func (h *ClientHello) ja3(extensions []uint16) string {
+	points := make([]uint16, len(h.PointFormats))
+	for i, p := range h.PointFormats {
+		points[i] = uint16(p)
+	}
+	return strings.Join([]string{
+		strconv.Itoa(int(h.Version)),
+		joinUint16(h.CipherSuites),
+		joinUint16(extensions),
+		joinUint16(h.Groups),
+		joinUint16(points),
+	}, ",")
+}
+
+// Client TLS implementations told apart by Profile
+const (
+	ProfileBrowser = "browser"   // Chromium or Safari
+	ProfileFirefox = "firefox"   // no GREASE, but record_size_limit
+	ProfileGo      = "go-stdlib" // Go crypto/tls: no browser mimicry
+)
+
+// Profile guesses which client implementation sent the hello. Browsers and
+// uTLS browser profiles send GREASE, except Firefox, which is recognised by
+// record_size_limit; Go's crypto/tls sends neither.
+func (h *ClientHello) Profile() string {
+	switch {
+	case h.GREASE:
+		return ProfileBrowser
+	case slices.Contains(h.Extensions, extRecordSizeLimit):
+		return ProfileFirefox
+	default:
+		return ProfileGo
+	}
+}
+
+func joinUint16(values []uint16) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(int(v))
+	}
+	return strings.Join(parts, "-")
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// helloReader reads big-endian fields off a byte slice
+type helloReader []byte
+
+func (r *helloReader) uint8() (uint8, bool) {
+	if len(*r) < 1 {
+		return 0, false
+	}
+	v := (*r)[0]
+	*r = (*r)[1:]
+	return v, true
+}
+
+func (r *helloReader) uint16() (uint16, bool) {
+	if len(*r) < 2 {
+		return 0, false
+	}
+	v := binary.BigEndian.Uint16(*r)
+	*r = (*r)[2:]
+	return v, true
+}
+
+func (r *helloReader) skip(n int) ([]byte, bool) {
+	if len(*r) < n {
+		return nil, false
+	}
+	v := (*r)[:n]
+	*r = (*r)[n:]
+	return v, true
+}
+
+// bytes reads a field prefixed by a lengthBytes-long length
+func (r *helloReader) bytes(lengthBytes int) ([]byte, bool) {
+	prefix, ok := r.skip(lengthBytes)
+	if !ok {
+		return nil, false
+	}
+	n := 0
+	for _, b := range prefix {
+		n = n<<8 | int(b)
+	}
+	return r.skip(n)
+}
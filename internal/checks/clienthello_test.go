@@ -0,0 +1,78 @@
+package checks
+
+import (
+	"crypto/tls"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// goClientHello captures the ClientHello Go's crypto/tls sends
+func goClientHello(t *testing.T) []byte {
+	t.Helper()
+
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go func() {
+		conn := tls.Client(client, &tls.Config{ServerName: "example.com", NextProtos: []string{"h2", "http/1.1"}})
+		conn.Handshake()
+		conn.Close()
+	}()
+
+	server.SetDeadline(time.Now().Add(5 * time.Second))
+	header := make([]byte, 5)
+	if _, err := server.Read(header); err != nil {
+		t.Fatal(err)
+	}
+	record := make([]byte, int(header[3])<<8|int(header[4]))
+	for read := 0; read < len(record); {
+		n, err := server.Read(record[read:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		read += n
+	}
+	return append(header, record...)
+}
+
+func TestParseClientHello(t *testing.T) {
+	hello, err := ParseClientHello(goClientHello(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hello.ServerName != "example.com" {
+		t.Errorf("server name = %q", hello.ServerName)
+	}
+	if strings.Join(hello.ALPN, ",") != "h2,http/1.1" {
+		t.Errorf("ALPN = %v", hello.ALPN)
+	}
+	if hello.Profile() != ProfileGo {
+		t.Errorf("profile = %s, want %s", hello.Profile(), ProfileGo)
+	}
+	if fields := strings.Split(hello.JA3(), ","); len(fields) != 5 || fields[0] != "771" {
+		t.Errorf("unexpected JA3 %q", hello.JA3())
+	}
+	if len(hello.JA3Hash()) != 32 || len(hello.JA3NHash()) != 32 {
+		t.Error("expected MD5 hex hashes")
+	}
+
+	if _, err := ParseClientHello([]byte("GET / HTTP/1.1\r\n")); err == nil {
+		t.Error("expected an error for non-TLS bytes")
+	}
+}
+
+func TestIsGREASE(t *testing.T) {
+	for _, v := range []uint16{0x0a0a, 0x1a1a, 0xfafa} {
+		if !isGREASE(v) {
+			t.Errorf("%#04x should be GREASE", v)
+		}
+	}
+	for _, v := range []uint16{0x0a1a, 0x1301, 0x002f} {
+		if isGREASE(v) {
+			t.Errorf("%#04x should not be GREASE", v)
+		}
+	}
+}
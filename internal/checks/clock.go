@@ -0,0 +1,136 @@
+package checks
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ClockSkew is the difference between a reference clock and the local one
+type ClockSkew struct {
+	// Offset is reference time minus local time: positive when the local
+	// clock is behind
+	Offset time.Duration
+	Source string // e.g. "ntp time.cloudflare.com:123"
+}
+
+// Abs returns the size of the skew
+func (s *ClockSkew) Abs() time.Duration {
+	if s.Offset < 0 {
+		return -s.Offset
+	}
+	return s.Offset
+}
+
+func (s *ClockSkew) String() string {
+	direction := "behind"
+	if s.Offset < 0 {
+		direction = "ahead"
+	}
+	return fmt.Sprintf("%s %s (%s)", s.Abs().Round(time.Second), direction, s.Source)
+}
+
+// ntpEpochOffset is the number of seconds from 1900 (NTP) to 1970 (Unix)
+const ntpEpochOffset = 2208988800
+
+// MeasureClockSkew compares the local clock with the first NTP server that
+// answers, falling back to the Date header of HTTP endpoints where UDP is
+// blocked. The Date header has one-second resolution, which is plenty for
+// protocol tolerances of half a minute and more.
+func MeasureClockSkew(ctx context.Context, client *http.Client, ntpServers, httpURLs []string) (*ClockSkew, error) {
+	var lastErr error
+	for _, server := range ntpServers {
+		offset, err := queryNTP(ctx, server)
+		if err == nil {
+			return &ClockSkew{Offset: offset, Source: "ntp " + server}, nil
+		}
+		lastErr = err
+	}
+	for _, url := range httpURLs {
+		offset, err := httpDateOffset(ctx, client, url)
+		if err == nil {
+			return &ClockSkew{Offset: offset, Source: "http " + url}, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no time sources configured")
+	}
+	return nil, lastErr
+}
+
+// queryNTP sends an SNTP request and returns the clock offset
+func queryNTP(ctx context.Context, server string) (time.Duration, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(3 * time.Second)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	// LI 0, version 4, mode 3 (client)
+	request := make([]byte, 48)
+	request[0] = 0x23
+	sent := time.Now()
+	if _, err := conn.Write(request); err != nil {
+		return 0, err
+	}
+
+	reply := make([]byte, 48)
+	n, err := conn.Read(reply)
+	received := time.Now()
+	if err != nil {
+		return 0, err
+	}
+	if n < 48 || reply[0]&0x07 != 4 {
+		return 0, fmt.Errorf("invalid NTP reply from %s", server)
+	}
+	// Stratum 0 is a kiss-of-death packet, e.g. rate limiting
+	if reply[1] == 0 {
+		return 0, fmt.Errorf("NTP server %s refused the request (%s)", server, reply[12:16])
+	}
+
+	serverReceived := ntpTime(reply[32:40])
+	serverSent := ntpTime(reply[40:48])
+	return (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2, nil
+}
+
+// ntpTime decodes a 64-bit NTP timestamp
+func ntpTime(b []byte) time.Time {
+	seconds := int64(binary.BigEndian.Uint32(b[:4])) - ntpEpochOffset
+	fraction := int64(binary.BigEndian.Uint32(b[4:])) * int64(time.Second) >> 32
+	return time.Unix(seconds, fraction)
+}
+
+// httpDateOffset compares the Date header of a HEAD response with the
+// local time halfway through the request
+func httpDateOffset(ctx context.Context, client *http.Client, url string) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	sent := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	received := time.Now()
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("no usable Date header from %s", url)
+	}
+	// The header is truncated to the second; its mean error is half of one
+	midpoint := sent.Add(received.Sub(sent) / 2)
+	return date.Add(500 * time.Millisecond).Sub(midpoint), nil
+}
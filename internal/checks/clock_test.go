@@ -0,0 +1,63 @@
+package checks
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMeasureClockSkewHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(5*time.Minute).UTC().Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	skew, err := MeasureClockSkew(context.Background(), server.Client(), nil, []string{server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skew.Offset < 5*time.Minute-2*time.Second || skew.Offset > 5*time.Minute+2*time.Second {
+		t.Errorf("offset %s, want about 5m (local clock behind)", skew.Offset)
+	}
+}
+
+func TestMeasureClockSkewNTP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no UDP: %v", err)
+	}
+	defer conn.Close()
+
+	// Answers with a clock two minutes ahead
+	go func() {
+		buf := make([]byte, 48)
+		_, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		reply := make([]byte, 48)
+		reply[0] = 0x24 // version 4, mode 4 (server)
+		reply[1] = 2
+		now := time.Now().Add(-2 * time.Minute)
+		putNTPTime(reply[32:40], now)
+		putNTPTime(reply[40:48], now)
+		conn.WriteTo(reply, from)
+	}()
+
+	skew, err := MeasureClockSkew(context.Background(), http.DefaultClient, []string{conn.LocalAddr().String()}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skew.Offset > -2*time.Minute+time.Second || skew.Offset < -2*time.Minute-time.Second {
+		t.Errorf("offset %s, want about -2m (local clock ahead)", skew.Offset)
+	}
+}
+
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b[:4], uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:], uint32((int64(t.Nanosecond())<<32)/int64(time.Second)))
+}
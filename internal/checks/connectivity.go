@@ -6,6 +6,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"golang.org/x/net/proxy"
@@ -160,3 +161,71 @@ func (c *ConnectivityChecker) Ping(ctx context.Context, address string) (time.Du
 
 	return time.Since(start), nil
 }
+
+// fallbackDelay is how long CheckAny waits on an endpoint before also
+// trying the next one
+const fallbackDelay = 500 * time.Millisecond
+
+// CheckAny tests connectivity against several endpoints and succeeds if any
+// of them answers. Endpoints are started one after another, each after the
+// previous one failed or fallbackDelay passed, so the happy path costs a
+// single request while a blocked or poisoned endpoint doesn't cause a false
+// negative.
+func (c *ConnectivityChecker) CheckAny(ctx context.Context, urls []string, client *http.Client) (*models.ConnectivityResult, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no connectivity endpoints configured")
+	}
+
+	start := time.Now()
+	checkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type attempt struct {
+		url    string
+		result *models.ConnectivityResult
+	}
+	attempts := make(chan attempt, len(urls))
+
+	launch := func(url string) {
+		go func() {
+			result, _ := c.CheckHTTP(checkCtx, url, client)
+			attempts <- attempt{url: url, result: result}
+		}()
+	}
+
+	next := 0
+	launch(urls[next])
+	next++
+
+	var errs []string
+	pending := 1
+	for pending > 0 {
+		var timer <-chan time.Time
+		if next < len(urls) {
+			timer = time.After(fallbackDelay)
+		}
+
+		select {
+		case a := <-attempts:
+			pending--
+			if a.result.Connected {
+				a.result.Endpoint = a.url
+				return a.result, nil
+			}
+			errs = append(errs, fmt.Sprintf("%s: %s", a.url, a.result.Error))
+		case <-timer:
+		}
+
+		if next < len(urls) {
+			launch(urls[next])
+			next++
+			pending++
+		}
+	}
+
+	return &models.ConnectivityResult{
+		Connected:    false,
+		ResponseTime: time.Since(start),
+		Error:        "all connectivity endpoints failed: " + strings.Join(errs, "; "),
+	}, nil
+}
@@ -0,0 +1,89 @@
+package checks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GeoInfo is what a geolocation endpoint knows about an IP
+type GeoInfo struct {
+	CountryCode string
+	City        string
+	AS          string // e.g. "AS16509 Amazon.com, Inc."; may be empty
+}
+
+// LookupGeo looks up an IP using a geolocation endpoint such as ip-api.com.
+// The IP is appended to the endpoint URL; an empty IP asks the endpoint
+// about the caller's own address.
+func LookupGeo(ctx context.Context, client *http.Client, endpoint, ip string) (*GeoInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+ip, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	// ip-api.com uses countryCode and as; ipapi.co and similar use
+	// country_code, asn and org
+	var geo struct {
+		Status      string `json:"status"`
+		Message     string `json:"message"`
+		CountryCode string `json:"countryCode"`
+		Code        string `json:"country_code"`
+		City        string `json:"city"`
+		AS          string `json:"as"`
+		ASN         string `json:"asn"`
+		Org         string `json:"org"`
+	}
+	if err := json.Unmarshal(body, &geo); err != nil {
+		return nil, fmt.Errorf("invalid geolocation response: %w", err)
+	}
+	if geo.Status == "fail" {
+		return nil, fmt.Errorf("geolocation failed: %s", geo.Message)
+	}
+
+	code := geo.CountryCode
+	if code == "" {
+		code = geo.Code
+	}
+	if len(code) != 2 {
+		return nil, fmt.Errorf("no country code in geolocation response")
+	}
+
+	as := geo.AS
+	if as == "" && geo.ASN != "" {
+		as = strings.TrimSpace(geo.ASN + " " + geo.Org)
+	}
+
+	return &GeoInfo{
+		CountryCode: strings.ToUpper(code),
+		City:        geo.City,
+		AS:          as,
+	}, nil
+}
+
+// GetCountry looks up the ISO country code of an IP, see LookupGeo
+func GetCountry(ctx context.Context, client *http.Client, endpoint, ip string) (string, error) {
+	geo, err := LookupGeo(ctx, client, endpoint, ip)
+	if err != nil {
+		return "", err
+	}
+	return geo.CountryCode, nil
+}
@@ -3,12 +3,17 @@ package checks
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"golang.org/x/net/proxy"
+
 	"github.com/VenoMexx/ProtoScope/pkg/domains"
 	"github.com/VenoMexx/ProtoScope/pkg/models"
 )
@@ -16,6 +21,11 @@ import (
 // DNSChecker tests DNS leak and blocking
 type DNSChecker struct {
 	timeout time.Duration
+	canary  string // base URL of a self-hosted echo-server DNS canary
+	dialer  proxy.Dialer
+	weights models.BlockingWeights
+	// blocklists are sampled domains tested along with the built-in ones
+	blocklists *Blocklists
 }
 
 // NewDNSChecker creates a new DNS checker
@@ -25,6 +35,22 @@ func NewDNSChecker(timeout time.Duration) *DNSChecker {
 	}
 }
 
+// SetCanary makes leak detection look up a fresh name under the DNS canary
+// of a protoscope echo-server at baseURL and ask it which resolvers did
+func (d *DNSChecker) SetCanary(baseURL string) {
+	d.canary = strings.TrimSuffix(baseURL, "/")
+}
+
+// SetWeights sets the category weights of the blocking effectiveness score
+func (d *DNSChecker) SetWeights(weights models.BlockingWeights) {
+	d.weights = weights
+}
+
+// SetBlocklists adds domains sampled from blocklists to the blocking test
+func (d *DNSChecker) SetBlocklists(blocklists *Blocklists) {
+	d.blocklists = blocklists
+}
+
 // Check performs complete DNS tests
 func (d *DNSChecker) Check(ctx context.Context, client *http.Client, expectedCountry string) (*models.DNSResult, error) {
 	result := &models.DNSResult{}
@@ -40,6 +66,14 @@ func (d *DNSChecker) Check(ctx context.Context, client *http.Client, expectedCou
 		}
 	}
 	result.LeakDetection = leakResult
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+
+	result.DNSSEC = d.CheckDNSSEC(ctx, client)
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
 
 	// Check DNS blocking
 	blockingResult, err := d.CheckDNSBlocking(ctx, client)
@@ -52,7 +86,7 @@ func (d *DNSChecker) Check(ctx context.Context, client *http.Client, expectedCou
 	}
 	result.Blocking = blockingResult
 
-	return result, nil
+	return result, ctx.Err()
 }
 
 // CheckDNSLeak checks for DNS leaks
@@ -86,12 +120,25 @@ func (d *DNSChecker) CheckDNSLeak(ctx context.Context, client *http.Client, expe
 
 // detectDNSServers tries to detect which DNS servers are being used
 func (d *DNSChecker) detectDNSServers(ctx context.Context, client *http.Client) ([]string, error) {
+	if d.canary != "" {
+		if servers, err := d.detectViaCanary(ctx, client); err == nil {
+			return servers, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
 	// Try to use DNS leak test API
 	urls := []string{
 		"https://www.dnsleaktest.com/api/servers",
 	}
 
 	for _, url := range urls {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
 		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
 			continue
@@ -120,10 +167,61 @@ func (d *DNSChecker) detectDNSServers(ctx context.Context, client *http.Client)
 		}
 	}
 
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
 	// Fallback: try to detect via whoami
 	return d.detectViaDNSQuery(ctx)
 }
 
+// detectViaCanary requests a canary name through the proxy, so the exit's
+// resolver looks it up at the echo-server, then fetches who did
+func (d *DNSChecker) detectViaCanary(ctx context.Context, client *http.Client) ([]string, error) {
+	var canary struct {
+		Token string `json:"token"`
+		Host  string `json:"host"`
+	}
+	if err := d.getJSON(ctx, client, d.canary+"/dns-canary/new", &canary); err != nil {
+		return nil, err
+	}
+
+	// The response doesn't matter, only that the name was resolved
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://"+canary.Host+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp, err := client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+
+	var servers []string
+	if err := d.getJSON(ctx, client, d.canary+"/dns-canary/"+canary.Token, &servers); err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("canary %s was not looked up", canary.Host)
+	}
+	return servers, nil
+}
+
+// getJSON fetches url and decodes the JSON response into v
+func (d *DNSChecker) getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
 // parseAlternativeDNS parses DNS servers from alternative formats
 func (d *DNSChecker) parseAlternativeDNS(body string) []string {
 	// This is a simple parser, in production you'd want more robust parsing
@@ -167,7 +265,13 @@ func (d *DNSChecker) checkIfLeaking(dnsServers []string, expectedCountry string)
 	return false
 }
 
-// CheckDNSBlocking checks if DNS is blocking ads/tracking
+// blockingWorkers domains are checked at a time, so sampled blocklists
+// don't hold up a node for minutes
+const blockingWorkers = 8
+
+// CheckDNSBlocking checks if DNS is blocking ads/tracking, and malware when
+// blocklists were sampled. If ctx is done before all domains were tried,
+// the domains tested so far are returned with ctx's error.
 func (d *DNSChecker) CheckDNSBlocking(ctx context.Context, client *http.Client) (*models.DNSBlockingResult, error) {
 	result := &models.DNSBlockingResult{
 		Ads:      make(map[string]models.BlockStatus),
@@ -175,22 +279,49 @@ func (d *DNSChecker) CheckDNSBlocking(ctx context.Context, client *http.Client)
 		Malware:  make(map[string]models.BlockStatus),
 	}
 
-	// Test ad domains
-	for _, domain := range domains.GetAllAdDomains() {
-		status := d.checkDomainBlocking(ctx, client, domain)
-		result.Ads[domain] = status
+	ads := domains.GetAllAdDomains()
+	tracking := domains.GetAllTrackingDomains()
+	var malware []string
+	if d.blocklists != nil {
+		ads = append(slices.Clone(ads), d.blocklists.Ads...)
+		tracking = append(slices.Clone(tracking), d.blocklists.Tracking...)
+		malware = d.blocklists.Malware
 	}
 
-	// Test tracking domains
-	for _, domain := range domains.GetAllTrackingDomains() {
-		status := d.checkDomainBlocking(ctx, client, domain)
-		result.Tracking[domain] = status
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, blockingWorkers)
+	check := func(category map[string]models.BlockStatus, list []string) {
+		for _, domain := range list {
+			select {
+			case workers <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-workers }()
+				status := d.checkDomainBlocking(ctx, client, domain)
+				if ctx.Err() != nil {
+					// Cut short, not blocked
+					return
+				}
+				mu.Lock()
+				category[domain] = status
+				mu.Unlock()
+			}()
+		}
 	}
+	check(result.Ads, ads)
+	check(result.Tracking, tracking)
+	check(result.Malware, malware)
+	wg.Wait()
 
 	// Calculate summary
 	result.Summary = d.calculateBlockingSummary(result)
 
-	return result, nil
+	return result, ctx.Err()
 }
 
 // checkDomainBlocking checks if a domain is blocked
@@ -202,8 +333,9 @@ func (d *DNSChecker) checkDomainBlocking(ctx context.Context, client *http.Clien
 	}
 
 	// First, try DNS resolution
+	host := domainHost(domain)
 	resolver := &net.Resolver{}
-	addrs, err := resolver.LookupHost(ctx, domain)
+	addrs, err := resolver.LookupHost(ctx, host)
 	if err != nil {
 		// DNS resolution failed - might be blocked
 		status.IsBlocked = true
@@ -213,6 +345,15 @@ func (d *DNSChecker) checkDomainBlocking(ctx context.Context, client *http.Clien
 	}
 	status.DNSResponse = strings.Join(addrs, ", ")
 
+	if d.dialer != nil {
+		if blockType, err := d.probeSNI(ctx, host, addrs); blockType != "" {
+			status.IsBlocked = true
+			status.BlockType = blockType
+			status.TLSError = err.Error()
+			return status
+		}
+	}
+
 	// DNS works, try HTTP
 	url := "http://" + domain
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -277,5 +418,45 @@ func (d *DNSChecker) calculateBlockingSummary(result *models.DNSBlockingResult)
 		TotalTested:     total,
 		TotalBlocked:    blocked,
 		BlockPercentage: percentage,
+		Effectiveness:   d.blockingEffectiveness(result),
+	}
+}
+
+// blockingEffectiveness averages the percentage of domains blocked in each
+// category tested, weighted by d.weights. Without weights every category
+// counts the same.
+func (d *DNSChecker) blockingEffectiveness(result *models.DNSBlockingResult) float64 {
+	weights := d.weights
+	if weights == (models.BlockingWeights{}) {
+		weights = models.BlockingWeights{Ads: 1, Tracking: 1, Malware: 1}
+	}
+
+	categories := []struct {
+		statuses map[string]models.BlockStatus
+		weight   float64
+	}{
+		{result.Ads, weights.Ads},
+		{result.Tracking, weights.Tracking},
+		{result.Malware, weights.Malware},
+	}
+
+	var total, weightSum float64
+	for _, category := range categories {
+		if len(category.statuses) == 0 || category.weight <= 0 {
+			continue
+		}
+		blocked := 0
+		for _, status := range category.statuses {
+			if status.IsBlocked {
+				blocked++
+			}
+		}
+		total += category.weight * float64(blocked) / float64(len(category.statuses))
+		weightSum += category.weight
+	}
+
+	if weightSum == 0 {
+		return 0
 	}
+	return total / weightSum * 100.0
 }
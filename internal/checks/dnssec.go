@@ -0,0 +1,105 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// dnssecTestZones are pairs of a correctly signed name and one whose
+// signatures are deliberately broken, both serving HTTP. They are tried in
+// order until one gives a conclusive answer.
+var dnssecTestZones = []struct{ signed, broken string }{
+	{"sigok.verteiltesysteme.net", "sigfail.verteiltesysteme.net"},
+	{"www.internetsociety.org", "www.dnssec-failed.org"},
+}
+
+// CheckDNSSEC tests whether names are resolved by a DNSSEC-validating
+// resolver. Requests go through the proxy, so the node's resolver looks the
+// names up; a validating resolver returns SERVFAIL for the broken zone.
+func (d *DNSChecker) CheckDNSSEC(ctx context.Context, client *http.Client) *models.DNSSECResult {
+	result := &models.DNSSECResult{Status: models.DNSSECInconclusive}
+
+	for _, zone := range dnssecTestZones {
+		if ctx.Err() != nil {
+			break
+		}
+
+		result.SignedDomain = zone.signed
+		result.BrokenDomain = zone.broken
+		result.SignedReachable = d.resolvesThrough(ctx, client, zone.signed) == nil
+		if !result.SignedReachable {
+			// Without the control name nothing can be concluded
+			result.Error = fmt.Sprintf("signed test name %s unreachable", zone.signed)
+			continue
+		}
+
+		err := d.resolvesThrough(ctx, client, zone.broken)
+		result.BrokenReachable = err == nil
+		switch {
+		case err == nil:
+			result.Status = models.DNSSECNotValidating
+		case !resolutionFailed(err):
+			// A timeout or a server that is down says nothing about
+			// validation
+			result.Status = models.DNSSECInconclusive
+			result.Error = fmt.Sprintf("broken test name %s failed without a lookup error: %v", zone.broken, err)
+			continue
+		default:
+			result.Status = models.DNSSECValidating
+		}
+		result.Error = ""
+		return result
+	}
+
+	return result
+}
+
+// resolutionFailed reports whether a request through the proxy failed
+// because the name didn't resolve. Backends answer a SOCKS request for a
+// name they can't resolve with "host unreachable" or a general failure;
+// direct lookups fail with a *net.DNSError.
+func resolutionFailed(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	message := err.Error()
+	for _, failure := range []string{"lookup ", "host unreachable", "general SOCKS server failure"} {
+		if strings.Contains(message, failure) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvesThrough fetches http://host/ through the proxy. Any HTTP response
+// means the name resolved.
+func (d *DNSChecker) resolvesThrough(ctx context.Context, client *http.Client, host string) error {
+	timeout := d.timeout
+	if timeout <= 0 || timeout > 10*time.Second {
+		timeout = 10 * time.Second
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, "GET", "http://"+host+"/", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,89 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// resolverTransport answers requests for hosts it knows and fails the rest
+// like an unresolvable name
+type resolverTransport map[string]bool
+
+func (t resolverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t[req.URL.Hostname()] {
+		return nil, errors.New("dial tcp: lookup " + req.URL.Hostname() + ": server misbehaving")
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok")), Request: req}, nil
+}
+
+func TestCheckDNSSEC(t *testing.T) {
+	tests := []struct {
+		name      string
+		resolves  resolverTransport
+		status    string
+		signedFor string
+	}{
+		{
+			name:      "validating",
+			resolves:  resolverTransport{"sigok.verteiltesysteme.net": true},
+			status:    models.DNSSECValidating,
+			signedFor: "sigok.verteiltesysteme.net",
+		},
+		{
+			name:      "not validating",
+			resolves:  resolverTransport{"sigok.verteiltesysteme.net": true, "sigfail.verteiltesysteme.net": true},
+			status:    models.DNSSECNotValidating,
+			signedFor: "sigok.verteiltesysteme.net",
+		},
+		{
+			name:      "falls back to the next zone",
+			resolves:  resolverTransport{"www.internetsociety.org": true},
+			status:    models.DNSSECValidating,
+			signedFor: "www.internetsociety.org",
+		},
+		{
+			name:     "no control name",
+			resolves: resolverTransport{},
+			status:   models.DNSSECInconclusive,
+		},
+	}
+
+	checker := NewDNSChecker(time.Second)
+	for _, tt := range tests {
+		result := checker.CheckDNSSEC(context.Background(), &http.Client{Transport: tt.resolves})
+		if result.Status != tt.status {
+			t.Errorf("%s: status %s, want %s", tt.name, result.Status, tt.status)
+		}
+		if tt.signedFor != "" && result.SignedDomain != tt.signedFor {
+			t.Errorf("%s: concluded from %s, want %s", tt.name, result.SignedDomain, tt.signedFor)
+		}
+	}
+}
+
+// timeoutTransport times out requests for the hosts it lists and answers
+// the rest
+type timeoutTransport map[string]bool
+
+func (t timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t[req.URL.Hostname()] {
+		return nil, context.DeadlineExceeded
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok")), Request: req}, nil
+}
+
+// TestCheckDNSSECTimeout doesn't take a broken name that timed out for
+// one the resolver refused
+func TestCheckDNSSECTimeout(t *testing.T) {
+	transport := timeoutTransport{"sigfail.verteiltesysteme.net": true, "www.dnssec-failed.org": true}
+	result := NewDNSChecker(time.Second).CheckDNSSEC(context.Background(), &http.Client{Transport: transport})
+	if result.Status != models.DNSSECInconclusive || result.Error == "" {
+		t.Errorf("status %s (%s), want inconclusive", result.Status, result.Error)
+	}
+}
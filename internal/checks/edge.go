@@ -0,0 +1,207 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// EdgeChecker downloads from a CDN twice through the proxy: once from the
+// edge the exit's resolver picks, near the exit, and once from the edge
+// the user's resolver picks, near the user. The first reflects the node's
+// own bandwidth; the second also crosses the path between node and user
+// twice, as traffic to CDN-hosted sites does when clients resolve locally.
+//
+// Only CDNs that steer by DNS give each edge its own addresses. An anycast
+// CDN such as Cloudflare announces the same address everywhere, so traffic
+// to it from the exit lands near the exit wherever it was resolved. The
+// edge reached directly tells: a download through the node that ends
+// elsewhere is reported as failed. A fixed server near the user can be
+// given instead.
+type EdgeChecker struct {
+	timeout time.Duration // per download
+	// lookup resolves the CDN host directly; nil uses the system resolver
+	lookup func(ctx context.Context, host string) ([]string, error)
+	// direct reaches the CDN without the proxy to find the user's edge
+	direct *http.Client
+	// nearUserURL is a download from a unicast server near the user
+	nearUserURL string
+}
+
+// NewEdgeChecker creates a new CDN edge checker
+func NewEdgeChecker(timeout time.Duration) *EdgeChecker {
+	return &EdgeChecker{
+		timeout: timeout,
+	}
+}
+
+// SetDirectClient sets the client that finds the user's edge; without it
+// the edge reached through the node is not checked
+func (e *EdgeChecker) SetDirectClient(client *http.Client) {
+	e.direct = client
+}
+
+// SetNearUserURL downloads from a fixed server near the user instead of
+// the CDN edge the local resolver picks
+func (e *EdgeChecker) SetNearUserURL(nearUserURL string) {
+	e.nearUserURL = nearUserURL
+}
+
+// Check runs both downloads of downloadURL. client must send host names to
+// the proxy unresolved; dialer connects through the proxy to an address.
+func (e *EdgeChecker) Check(ctx context.Context, client *http.Client, dialer proxy.Dialer, downloadURL string) (*models.EdgeSpeedResult, error) {
+	u, err := url.Parse(downloadURL)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.EdgeSpeedResult{URL: downloadURL}
+
+	result.NearExit = e.measure(ctx, client, downloadURL)
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+
+	if e.nearUserURL != "" {
+		// A unicast server is the same wherever its name is resolved
+		result.NearUser = e.measure(ctx, client, e.nearUserURL)
+		return result, ctx.Err()
+	}
+
+	if e.direct != nil {
+		result.UserEdge = e.directEdge(ctx, downloadURL)
+	}
+	ip, err := e.resolve(ctx, u.Hostname())
+	if err != nil {
+		result.NearUser.Error = err.Error()
+		return result, nil
+	}
+	result.NearUser = e.measure(ctx, pinnedClient(dialer, ip, e.timeout), downloadURL)
+	result.NearUser.IP = ip
+	if near := &result.NearUser; near.Error == "" && near.Edge != "" && result.UserEdge != "" && near.Edge != result.UserEdge {
+		near.Error = fmt.Sprintf("reached edge %s, not yours (%s): the CDN routes by anycast; set api_endpoints.cdn_edge_near_user", near.Edge, result.UserEdge)
+	}
+	return result, ctx.Err()
+}
+
+// directEdge returns the edge downloadURL is served from without the
+// proxy, or "" when it can't be told. Only the headers are read.
+func (e *EdgeChecker) directEdge(ctx context.Context, downloadURL string) string {
+	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
+	if err != nil {
+		return ""
+	}
+	resp, err := e.direct.Do(req)
+	if err != nil {
+		return ""
+	}
+	resp.Body.Close()
+	return EdgeLocation(resp.Header)
+}
+
+// resolve looks host up with the local resolver, outside the proxy
+func (e *EdgeChecker) resolve(ctx context.Context, host string) (string, error) {
+	lookup := e.lookup
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupHost
+	}
+	addrs, err := lookup(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		// The SOCKS path of most nodes carries IPv4 only
+		if ip := net.ParseIP(addr); ip != nil && ip.To4() != nil {
+			return addr, nil
+		}
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("no addresses for %s", host)
+	}
+	return addrs[0], nil
+}
+
+// pinnedClient connects to ip through dialer whatever host a request
+// names, keeping the name for TLS and the Host header
+func pinnedClient(dialer proxy.Dialer, ip string, timeout time.Duration) *http.Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			addr = net.JoinHostPort(ip, port)
+			if contextDialer, ok := dialer.(proxy.ContextDialer); ok {
+				return contextDialer.DialContext(ctx, network, addr)
+			}
+			return dialer.Dial(network, addr)
+		},
+		TLSHandshakeTimeout: 10 * time.Second,
+		DisableKeepAlives:   true,
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// measure times one download and reads the edge location from the
+// response headers
+func (e *EdgeChecker) measure(ctx context.Context, client *http.Client, downloadURL string) models.EdgeSpeed {
+	var speed models.EdgeSpeed
+
+	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
+	if err != nil {
+		speed.Error = err.Error()
+		return speed
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		speed.Error = err.Error()
+		return speed
+	}
+	defer resp.Body.Close()
+	speed.Edge = EdgeLocation(resp.Header)
+	if resp.StatusCode != http.StatusOK {
+		speed.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		return speed
+	}
+
+	written, err := io.Copy(io.Discard, resp.Body)
+	if err != nil {
+		speed.Error = err.Error()
+		return speed
+	}
+	speed.DownloadSpeed = float64(written) * 8 / time.Since(start).Seconds() / 1_000_000
+	return speed
+}
+
+// EdgeLocation returns the point of presence a CDN response came from,
+// usually an airport code, or "" when the CDN is not recognized.
+// Cloudflare appends it to CF-Ray, CloudFront names it in X-Amz-Cf-Pop
+// and Fastly ends X-Served-By with it.
+func EdgeLocation(header http.Header) string {
+	if ray := header.Get("CF-Ray"); ray != "" {
+		if _, colo, ok := strings.Cut(ray, "-"); ok {
+			return colo
+		}
+	}
+	if pop := header.Get("X-Amz-Cf-Pop"); len(pop) >= 3 {
+		return pop[:3]
+	}
+	if servedBy := header.Get("X-Served-By"); servedBy != "" {
+		// The last cache of a shielded request is the edge
+		nodes := strings.Split(servedBy, ",")
+		last := strings.TrimSpace(nodes[len(nodes)-1])
+		if i := strings.LastIndex(last, "-"); i >= 0 {
+			return last[i+1:]
+		}
+	}
+	return ""
+}
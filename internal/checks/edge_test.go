@@ -0,0 +1,125 @@
+package checks
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+func TestEdgeChecker(t *testing.T) {
+	var port string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Both downloads keep the CDN host name, pinned or not
+		if r.Host != "cdn.test:"+port {
+			t.Errorf("request for host %s, want cdn.test", r.Host)
+		}
+		w.Header().Set("CF-Ray", "8c1b2d3e4f5a6b7c-AMS")
+		w.Write(make([]byte, 100_000))
+	}))
+	defer server.Close()
+	_, port, _ = net.SplitHostPort(server.Listener.Addr().String())
+
+	// The "exit" resolves cdn.test to the server, as does the local lookup
+	exit := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return net.Dial(network, server.Listener.Addr().String())
+		},
+	}}
+	e := NewEdgeChecker(5 * time.Second)
+	e.lookup = func(ctx context.Context, host string) ([]string, error) {
+		if host != "cdn.test" {
+			t.Errorf("resolved %s, want cdn.test", host)
+		}
+		return []string{"::1", "127.0.0.1"}, nil
+	}
+
+	downloadURL := (&url.URL{Scheme: "http", Host: net.JoinHostPort("cdn.test", port), Path: "/__down"}).String()
+	result, err := e.Check(context.Background(), exit, proxy.Direct, downloadURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NearExit.DownloadSpeed <= 0 || result.NearExit.Edge == "" {
+		t.Errorf("near exit %+v, want a speed and edge", result.NearExit)
+	}
+	if result.NearUser.DownloadSpeed <= 0 || result.NearUser.IP != "127.0.0.1" {
+		t.Errorf("near user %+v, want a speed from the IPv4 address", result.NearUser)
+	}
+}
+
+func TestEdgeLocation(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		edge   string
+	}{
+		{http.Header{"Cf-Ray": {"8c1b2d3e4f5a6b7c-AMS"}}, "AMS"},
+		{http.Header{"X-Amz-Cf-Pop": {"FRA56-P3"}}, "FRA"},
+		{http.Header{"X-Served-By": {"cache-iad-kiad7000025-IAD, cache-ams21080-AMS"}}, "AMS"},
+		{http.Header{"Server": {"nginx"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := EdgeLocation(tt.header); got != tt.edge {
+			t.Errorf("EdgeLocation(%v) = %q, want %q", tt.header, got, tt.edge)
+		}
+	}
+}
+
+// TestEdgeCheckerAnycast fails the near-user download when it reaches the
+// exit's edge instead of the one the user reaches directly
+func TestEdgeCheckerAnycast(t *testing.T) {
+	edge := func(colo string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("CF-Ray", "8c1b2d3e4f5a6b7c-"+colo)
+			w.Write(make([]byte, 1000))
+		}))
+	}
+	nearExit, nearUser := edge("AMS"), edge("FRA")
+	defer nearExit.Close()
+	defer nearUser.Close()
+	via := func(server *httptest.Server) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial(network, server.Listener.Addr().String())
+			},
+		}}
+	}
+
+	// The node reaches the exit's edge at any address
+	e := NewEdgeChecker(5 * time.Second)
+	e.lookup = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	}
+	e.SetDirectClient(via(nearUser))
+	toExitEdge := dialerFunc(func(network, addr string) (net.Conn, error) {
+		return net.Dial(network, nearExit.Listener.Addr().String())
+	})
+
+	result, err := e.Check(context.Background(), via(nearExit), toExitEdge, "http://cdn.test/__down")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.UserEdge != "FRA" || result.NearUser.Edge != "AMS" || result.NearUser.Error == "" {
+		t.Errorf("result %+v, want the near-user download to fail at AMS", result)
+	}
+
+	// A unicast server near the user is downloaded from through the node
+	e.SetNearUserURL("http://near-user.test/100kb")
+	result, err = e.Check(context.Background(), via(nearUser), proxy.Direct, "http://cdn.test/__down")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NearUser.Error != "" || result.NearUser.Edge != "FRA" {
+		t.Errorf("near user %+v, want the unicast server", result.NearUser)
+	}
+}
+
+type dialerFunc func(network, addr string) (net.Conn, error)
+
+func (f dialerFunc) Dial(network, addr string) (net.Conn, error) {
+	return f(network, addr)
+}
@@ -0,0 +1,110 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// egressServices names the ports commonly blocked by exits
+var egressServices = map[int]string{
+	22:   "ssh",
+	25:   "smtp",
+	465:  "smtps",
+	587:  "submission",
+	3389: "rdp",
+}
+
+// EgressChecker tests which outbound ports the exit lets through
+type EgressChecker struct {
+	timeout time.Duration // per port
+}
+
+// NewEgressChecker creates a new egress policy checker
+func NewEgressChecker(timeout time.Duration) *EgressChecker {
+	return &EgressChecker{
+		timeout: timeout,
+	}
+}
+
+// Check connects to probeHost on each port through the proxy. The local
+// SOCKS inbound of most backends accepts a connection before the exit has
+// connected, so a port only counts as open once the probe host answered an
+// HTTP request on it; probe hosts such as portquiz.net serve HTTP on every
+// port.
+func (e *EgressChecker) Check(ctx context.Context, dialer proxy.Dialer, probeHost string, ports []int) (*models.EgressResult, error) {
+	if probeHost == "" {
+		return nil, fmt.Errorf("no egress probe host configured")
+	}
+
+	result := &models.EgressResult{
+		Probe: probeHost,
+		Ports: make([]models.EgressPort, len(ports)),
+	}
+
+	var wg sync.WaitGroup
+	for i, port := range ports {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry := models.EgressPort{Port: port, Service: egressServices[port]}
+			if err := e.probe(ctx, dialer, probeHost, port); err != nil {
+				entry.Error = err.Error()
+			} else {
+				entry.Open = true
+			}
+			result.Ports[i] = entry
+		}()
+	}
+	wg.Wait()
+
+	for _, port := range result.Ports {
+		if !port.Open {
+			result.Blocked = append(result.Blocked, port.Port)
+		}
+	}
+
+	return result, ctx.Err()
+}
+
+// probe sends a minimal HTTP request to host:port and waits for any reply
+func (e *EgressChecker) probe(ctx context.Context, dialer proxy.Dialer, host string, port int) error {
+	probeCtx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	var conn net.Conn
+	var err error
+	if contextDialer, ok := dialer.(proxy.ContextDialer); ok {
+		conn, err = contextDialer.DialContext(probeCtx, "tcp", address)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, _ := probeCtx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	request := fmt.Sprintf("GET / HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", address)
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 1)
+	if _, err := conn.Read(buf); err != nil {
+		return fmt.Errorf("no answer on port %d: %w", port, err)
+	}
+	return nil
+}
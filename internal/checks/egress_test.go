@@ -0,0 +1,62 @@
+package checks
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// portDialer simulates an exit: open ports answer HTTP, refused ports fail
+// to dial and silent ports accept the connection but never answer, as a
+// local SOCKS inbound does when the exit drops the connection
+type portDialer struct {
+	refused map[string]bool
+	silent  map[string]bool
+}
+
+func (d *portDialer) Dial(network, addr string) (net.Conn, error) {
+	_, port, _ := net.SplitHostPort(addr)
+	if d.refused[port] {
+		return nil, errors.New("connection refused")
+	}
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		if d.silent[port] {
+			return
+		}
+		if _, err := http.ReadRequest(bufio.NewReader(server)); err != nil {
+			return
+		}
+		server.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n"))
+	}()
+	return client, nil
+}
+
+func TestEgressCheck(t *testing.T) {
+	dialer := &portDialer{
+		refused: map[string]bool{"25": true},
+		silent:  map[string]bool{"3389": true},
+	}
+
+	checker := NewEgressChecker(time.Second)
+	result, err := checker.Check(context.Background(), dialer, "probe.test", []int{25, 465, 22, 3389})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Blocked) != 2 || result.Blocked[0] != 25 || result.Blocked[1] != 3389 {
+		t.Fatalf("blocked = %v, want [25 3389]", result.Blocked)
+	}
+	if !result.Ports[1].Open || result.Ports[1].Service != "smtps" {
+		t.Errorf("port 465 = %+v, want open smtps", result.Ports[1])
+	}
+	if result.Ports[0].Error == "" {
+		t.Error("expected an error for the refused port")
+	}
+}
@@ -0,0 +1,134 @@
+package checks
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// endpointQuarantine is how long a failing endpoint is left out of rotation
+	endpointQuarantine = 5 * time.Minute
+	// endpointMaxConsecutiveFailures quarantines an endpoint after this many failures in a row
+	endpointMaxConsecutiveFailures = 3
+	// endpointMaxFailureRate quarantines an endpoint once enough attempts fail at this rate
+	endpointMaxFailureRate = 0.5
+	endpointMinAttempts    = 4
+)
+
+// EndpointStats reports how an endpoint behaved during a run
+type EndpointStats struct {
+	URL         string  `json:"url"`
+	Attempts    int     `json:"attempts"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+	Quarantined bool    `json:"quarantined"`
+}
+
+// endpointState tracks the health of one endpoint
+type endpointState struct {
+	url              string
+	attempts         int
+	failures         int
+	consecutive      int
+	quarantinedUntil time.Time
+}
+
+// EndpointPool rotates requests among equivalent endpoints (e.g. IP-check
+// services), tracks their failure rates and quarantines endpoints that start
+// failing. It is safe for concurrent use by all workers of a run.
+type EndpointPool struct {
+	mu        sync.Mutex
+	endpoints []*endpointState
+	next      int
+	now       func() time.Time
+}
+
+// NewEndpointPool creates a pool for the given endpoint URLs
+func NewEndpointPool(urls []string) *EndpointPool {
+	pool := &EndpointPool{now: time.Now}
+	for _, url := range urls {
+		pool.endpoints = append(pool.endpoints, &endpointState{url: url})
+	}
+	return pool
+}
+
+// Endpoints returns the endpoints to try, in order. Rotation starts at a
+// different endpoint on every call to spread load; quarantined endpoints are
+// moved to the end so they are only used when everything else failed.
+func (p *EndpointPool) Endpoints() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.endpoints)
+	if n == 0 {
+		return nil
+	}
+
+	now := p.now()
+	healthy := make([]string, 0, n)
+	quarantined := make([]string, 0)
+
+	for i := 0; i < n; i++ {
+		endpoint := p.endpoints[(p.next+i)%n]
+		if now.Before(endpoint.quarantinedUntil) {
+			quarantined = append(quarantined, endpoint.url)
+		} else {
+			healthy = append(healthy, endpoint.url)
+		}
+	}
+	p.next = (p.next + 1) % n
+
+	return append(healthy, quarantined...)
+}
+
+// Report records the outcome of a request to an endpoint
+func (p *EndpointPool) Report(url string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, endpoint := range p.endpoints {
+		if endpoint.url != url {
+			continue
+		}
+
+		endpoint.attempts++
+		if err == nil {
+			endpoint.consecutive = 0
+			return
+		}
+
+		endpoint.failures++
+		endpoint.consecutive++
+
+		rate := float64(endpoint.failures) / float64(endpoint.attempts)
+		if endpoint.consecutive >= endpointMaxConsecutiveFailures ||
+			(endpoint.attempts >= endpointMinAttempts && rate >= endpointMaxFailureRate) {
+			endpoint.quarantinedUntil = p.now().Add(endpointQuarantine)
+			endpoint.consecutive = 0
+		}
+		return
+	}
+}
+
+// Stats returns per-endpoint statistics
+func (p *EndpointPool) Stats() []EndpointStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	stats := make([]EndpointStats, 0, len(p.endpoints))
+	for _, endpoint := range p.endpoints {
+		rate := 0.0
+		if endpoint.attempts > 0 {
+			rate = float64(endpoint.failures) / float64(endpoint.attempts)
+		}
+		stats = append(stats, EndpointStats{
+			URL:         endpoint.url,
+			Attempts:    endpoint.attempts,
+			Failures:    endpoint.failures,
+			FailureRate: rate,
+			Quarantined: now.Before(endpoint.quarantinedUntil),
+		})
+	}
+	return stats
+}
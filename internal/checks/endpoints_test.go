@@ -0,0 +1,38 @@
+package checks
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEndpointPoolRotates(t *testing.T) {
+	pool := NewEndpointPool([]string{"a", "b", "c"})
+
+	first := pool.Endpoints()
+	second := pool.Endpoints()
+	if first[0] != "a" || second[0] != "b" {
+		t.Fatalf("expected rotation a then b, got %v then %v", first, second)
+	}
+}
+
+func TestEndpointPoolQuarantinesFailingEndpoint(t *testing.T) {
+	now := time.Now()
+	pool := NewEndpointPool([]string{"a", "b"})
+	pool.now = func() time.Time { return now }
+
+	for i := 0; i < endpointMaxConsecutiveFailures; i++ {
+		pool.Report("a", errors.New("timeout"))
+	}
+
+	endpoints := pool.Endpoints()
+	if endpoints[0] != "b" || endpoints[1] != "a" {
+		t.Fatalf("expected quarantined endpoint last, got %v", endpoints)
+	}
+
+	now = now.Add(endpointQuarantine + time.Second)
+	stats := pool.Stats()
+	if stats[0].Quarantined {
+		t.Fatal("expected quarantine to expire")
+	}
+}
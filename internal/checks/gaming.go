@@ -0,0 +1,162 @@
+package checks
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Gaming ratings, by the median latency and jitter of a region
+const (
+	// GamingGood is fine for competitive shooters and fighting games
+	GamingGood = "good"
+	// GamingPlayable is fine for most games but noticeable in fast ones
+	GamingPlayable = "playable"
+	// GamingPoor lags or rubber-bands
+	GamingPoor = "poor"
+)
+
+// Rating thresholds
+const (
+	gamingGoodLatency     = 80 * time.Millisecond
+	gamingGoodJitter      = 15 * time.Millisecond
+	gamingPlayableLatency = 150 * time.Millisecond
+	gamingPlayableJitter  = 30 * time.Millisecond
+)
+
+// GamingChecker measures latency and jitter to the regional servers of game
+// services, which say more about a node for games than download speed
+type GamingChecker struct {
+	timeout  time.Duration // per request
+	samples  int
+	interval time.Duration
+}
+
+// NewGamingChecker creates a new gaming latency checker
+func NewGamingChecker(timeout time.Duration) *GamingChecker {
+	return &GamingChecker{
+		timeout: timeout,
+	}
+}
+
+// SetSampling sets how many requests are sent to each endpoint and how long
+// to wait between them; zero values keep the jitter sampler's defaults
+func (g *GamingChecker) SetSampling(samples int, interval time.Duration) {
+	g.samples = samples
+	g.interval = interval
+}
+
+// Check samples every endpoint through client at once. client should keep
+// connections alive, so the samples time round trips rather than handshakes
+// as game traffic would.
+func (g *GamingChecker) Check(ctx context.Context, client *http.Client, endpoints []models.GamingEndpoint) (*models.GamingResult, error) {
+	result := &models.GamingResult{
+		Endpoints: make([]models.GamingLatency, len(endpoints)),
+	}
+
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result.Endpoints[i] = g.checkEndpoint(ctx, client, endpoint)
+		}()
+	}
+	wg.Wait()
+
+	for i := range result.Endpoints {
+		endpoint := &result.Endpoints[i]
+		if endpoint.Error != "" {
+			continue
+		}
+		if result.Best == nil || endpoint.Latency < result.Best.Latency {
+			result.Best = endpoint
+		}
+	}
+	if result.Best != nil {
+		result.Rating = result.Best.Rating
+	}
+
+	return result, ctx.Err()
+}
+
+// checkEndpoint samples a single endpoint. The first request opens the
+// connection and is not counted.
+func (g *GamingChecker) checkEndpoint(ctx context.Context, client *http.Client, endpoint models.GamingEndpoint) models.GamingLatency {
+	latency := models.GamingLatency{
+		Service: endpoint.Service,
+		Region:  endpoint.Region,
+		URL:     endpoint.URL,
+	}
+
+	samples := g.samples
+	if samples <= 0 {
+		samples = defaultJitterSamples
+	}
+	samples = max(samples, 2)
+	interval := g.interval
+	if interval <= 0 {
+		interval = defaultJitterInterval
+	}
+
+	if _, err := g.timeSample(ctx, client, endpoint.URL); err != nil {
+		latency.Error = err.Error()
+		return latency
+	}
+
+	var times []time.Duration
+	failed := 0
+	for i := 0; i < samples; i++ {
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		d, err := g.timeSample(ctx, client, endpoint.URL)
+		if err != nil {
+			failed++
+			continue
+		}
+		times = append(times, d)
+	}
+	if len(times) == 0 {
+		latency.Error = "all samples failed"
+		return latency
+	}
+
+	latency.Times = slices.Clone(times)
+	slices.Sort(times)
+	latency.Latency = times[len(times)/2]
+	latency.MinLatency = times[0]
+	latency.Jitter = stdDev(times)
+	latency.Samples = len(times)
+	latency.Loss = float64(failed) / float64(len(times)+failed)
+	latency.Rating = gamingRating(latency.Latency, latency.Jitter)
+	return latency
+}
+
+// timeSample times one request to url within the checker's timeout
+func (g *GamingChecker) timeSample(ctx context.Context, client *http.Client, url string) (time.Duration, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	return timeRequest(reqCtx, client, url)
+}
+
+// gamingRating rates a median latency and jitter
+func gamingRating(latency, jitter time.Duration) string {
+	switch {
+	case latency <= gamingGoodLatency && jitter <= gamingGoodJitter:
+		return GamingGood
+	case latency <= gamingPlayableLatency && jitter <= gamingPlayableJitter:
+		return GamingPlayable
+	default:
+		return GamingPoor
+	}
+}
@@ -0,0 +1,66 @@
+package checks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestGamingCheck(t *testing.T) {
+	var heads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads++
+		}
+		w.WriteHeader(http.StatusUnauthorized) // API servers turn away keyless requests
+	}))
+	defer server.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	checker := NewGamingChecker(time.Second)
+	checker.SetSampling(4, time.Millisecond)
+	result, err := checker.Check(context.Background(), server.Client(), []models.GamingEndpoint{
+		{Service: "Riot", Region: "EU West", URL: server.URL},
+		{Service: "Steam", URL: down.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	riot := result.Endpoints[0]
+	if riot.Error != "" || riot.Samples != 4 || riot.Loss != 0 || riot.Rating != GamingGood {
+		t.Errorf("riot = %+v, want 4 samples rated good", riot)
+	}
+	if heads != 5 {
+		t.Errorf("%d HEAD requests, want 4 samples after a warm-up", heads)
+	}
+	if result.Endpoints[1].Error == "" {
+		t.Error("closed server did not fail")
+	}
+	if result.Best == nil || result.Best.Service != "Riot" || result.Rating != GamingGood {
+		t.Errorf("best = %+v, rating %q", result.Best, result.Rating)
+	}
+}
+
+func TestGamingRating(t *testing.T) {
+	tests := []struct {
+		latency, jitter time.Duration
+		want            string
+	}{
+		{40 * time.Millisecond, 5 * time.Millisecond, GamingGood},
+		{40 * time.Millisecond, 20 * time.Millisecond, GamingPlayable},
+		{120 * time.Millisecond, 10 * time.Millisecond, GamingPlayable},
+		{120 * time.Millisecond, 40 * time.Millisecond, GamingPoor},
+		{200 * time.Millisecond, time.Millisecond, GamingPoor},
+	}
+	for _, tt := range tests {
+		if got := gamingRating(tt.latency, tt.jitter); got != tt.want {
+			t.Errorf("gamingRating(%v, %v) = %q, want %q", tt.latency, tt.jitter, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package checks
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/VenoMexx/ProtoScope/pkg/domains"
@@ -12,48 +17,80 @@ import (
 
 // GeoAccessChecker tests access to geo-specific domains
 type GeoAccessChecker struct {
-	timeout time.Duration
+	timeout  time.Duration
+	progress func(domain string, done, total int)
+	expect   map[string]models.GeoExpectation
+	regions  map[string][]string
 }
 
+// maxGeoBody is how much of a page is read to verify it
+const maxGeoBody = 1 << 20
+
 // NewGeoAccessChecker creates a new geo-access checker
 func NewGeoAccessChecker(timeout time.Duration) *GeoAccessChecker {
 	return &GeoAccessChecker{
 		timeout: timeout,
+		regions: domains.GeoRegions,
 	}
 }
 
-// Check performs geo-access tests for all regions
-func (g *GeoAccessChecker) Check(ctx context.Context, client *http.Client) (*models.GeoAccessResult, error) {
-	result := &models.GeoAccessResult{
-		RU:     make(map[string]models.AccessStatus),
-		CN:     make(map[string]models.AccessStatus),
-		IR:     make(map[string]models.AccessStatus),
-		US:     make(map[string]models.AccessStatus),
-		Custom: make(map[string]models.AccessStatus),
-	}
+// SetProgress makes Check call progress after each domain with the number
+// of domains tested so far and in total
+func (g *GeoAccessChecker) SetProgress(progress func(domain string, done, total int)) {
+	g.progress = progress
+}
 
-	// Test RU domains
-	for _, domain := range domains.GeoDomainsRU {
-		status := g.checkDomain(ctx, client, domain)
-		result.RU[domain] = status
-	}
+// SetExpectations makes pages of the given domains count as accessible only
+// when they meet their expectation, and as block pages otherwise
+func (g *GeoAccessChecker) SetExpectations(expect map[string]models.GeoExpectation) {
+	g.expect = expect
+}
 
-	// Test CN domains
-	for _, domain := range domains.GeoDomainsCN {
-		status := g.checkDomain(ctx, client, domain)
-		result.CN[domain] = status
+// SetRegions sets the domains of regions by name on top of the built-in
+// ones: a region replaces the built-in region of the same name, and an
+// empty one removes it
+func (g *GeoAccessChecker) SetRegions(regions map[string][]string) {
+	merged := maps.Clone(domains.GeoRegions)
+	for name, domainList := range regions {
+		if len(domainList) == 0 {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = domainList
 	}
+	g.regions = merged
+}
 
-	// Test IR domains
-	for _, domain := range domains.GeoDomainsIR {
-		status := g.checkDomain(ctx, client, domain)
-		result.IR[domain] = status
+// Check performs geo-access tests for all regions, in order of their names.
+// If ctx is done before all domains were tried, the domains tested so far
+// are returned with ctx's error.
+func (g *GeoAccessChecker) Check(ctx context.Context, client *http.Client) (*models.GeoAccessResult, error) {
+	result := &models.GeoAccessResult{
+		Regions: make(map[string]map[string]models.AccessStatus, len(g.regions)),
+	}
+	total := 0
+	for _, domainList := range g.regions {
+		total += len(domainList)
+	}
+	done := 0
+	checked := func(domain string) {
+		done++
+		if g.progress != nil {
+			g.progress(domain, done, total)
+		}
 	}
 
-	// Test US domains
-	for _, domain := range domains.GeoDomainsUS {
-		status := g.checkDomain(ctx, client, domain)
-		result.US[domain] = status
+	for _, region := range slices.Sorted(maps.Keys(g.regions)) {
+		statuses := make(map[string]models.AccessStatus, len(g.regions[region]))
+		result.Regions[region] = statuses
+		for _, domain := range g.regions[region] {
+			if ctx.Err() != nil {
+				result.Summary = g.calculateSummary(result)
+				return result, ctx.Err()
+			}
+			statuses[domain] = g.checkDomain(ctx, client, domain)
+			checked(domain)
+		}
 	}
 
 	// Calculate summary
@@ -62,15 +99,22 @@ func (g *GeoAccessChecker) Check(ctx context.Context, client *http.Client) (*mod
 	return result, nil
 }
 
-// CheckCountry tests access to a specific country's domains
+// CheckCountry tests access to a single region's domains, looking country
+// codes up case-insensitively
 func (g *GeoAccessChecker) CheckCountry(ctx context.Context, client *http.Client, country string) (map[string]models.AccessStatus, error) {
-	domainList := domains.GetGeoDomainsForCountry(country)
+	domainList, ok := g.regions[country]
+	if !ok {
+		domainList = g.regions[strings.ToUpper(country)]
+	}
 	if len(domainList) == 0 {
-		return nil, fmt.Errorf("unknown country code: %s", country)
+		return nil, fmt.Errorf("unknown region: %s", country)
 	}
 
 	results := make(map[string]models.AccessStatus)
 	for _, domain := range domainList {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
 		status := g.checkDomain(ctx, client, domain)
 		results[domain] = status
 	}
@@ -81,24 +125,30 @@ func (g *GeoAccessChecker) CheckCountry(ctx context.Context, client *http.Client
 // checkDomain checks access to a single domain
 func (g *GeoAccessChecker) checkDomain(ctx context.Context, client *http.Client, domain string) models.AccessStatus {
 	start := time.Now()
+	var expect *models.GeoExpectation
+	if e, ok := g.expect[domain]; ok {
+		expect = &e
+	}
 
 	// Try HTTPS first
 	url := "https://" + domain
-	status := g.tryURL(ctx, client, url)
-	if status.Accessible {
+	status := g.tryURL(ctx, client, url, expect)
+	if status.Accessible || status.Blockpage || ctx.Err() != nil {
 		return status
 	}
 
 	// Try HTTP as fallback
 	url = "http://" + domain
-	status = g.tryURL(ctx, client, url)
+	status = g.tryURL(ctx, client, url, expect)
 	status.Latency = time.Since(start)
 
 	return status
 }
 
-// tryURL attempts to access a URL
-func (g *GeoAccessChecker) tryURL(ctx context.Context, client *http.Client, url string) models.AccessStatus {
+// tryURL attempts to access a URL. Redirects to block pages count as
+// blocked; with an expectation the page is read and checked against it,
+// unless it is a consent page standing in for the site.
+func (g *GeoAccessChecker) tryURL(ctx context.Context, client *http.Client, url string, expect *models.GeoExpectation) models.AccessStatus {
 	start := time.Now()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -131,55 +181,58 @@ func (g *GeoAccessChecker) tryURL(ctx context.Context, client *http.Client, url
 	// (4xx means we connected, just not authorized/not found)
 	accessible := resp.StatusCode < 500
 
-	return models.AccessStatus{
+	status := models.AccessStatus{
 		Accessible: accessible,
 		StatusCode: resp.StatusCode,
 		Latency:    latency,
 	}
+	if status.Redirect = classifyRedirect(req.URL, resp.Request.URL); status.Redirect != "" {
+		status.FinalURL = resp.Request.URL.String()
+	}
+	switch {
+	case status.Redirect == RedirectBlockpage:
+		status.Accessible = false
+		status.Blockpage = true
+		status.Error = "redirected to a block page"
+	case status.Redirect == RedirectOffsite:
+		status.Accessible = false
+		status.Error = "redirected to another site: " + resp.Request.URL.Hostname()
+	case status.Redirect == RedirectConsent:
+		// The consent page stands in for the site, which is reachable
+	case accessible && expect != nil:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxGeoBody))
+		if err == nil && isBlockpage(body, *expect) {
+			status.Accessible = false
+			status.Blockpage = true
+			status.Error = "blockpage detected"
+		}
+	}
+	return status
+}
+
+// isBlockpage reports whether a page fails its expectation
+func isBlockpage(body []byte, expect models.GeoExpectation) bool {
+	if expect.MinBodyBytes > 0 && len(body) < expect.MinBodyBytes {
+		return true
+	}
+	return expect.Keyword != "" && !bytes.Contains(bytes.ToLower(body), bytes.ToLower([]byte(expect.Keyword)))
 }
 
 // calculateSummary calculates summary statistics
 func (g *GeoAccessChecker) calculateSummary(result *models.GeoAccessResult) models.GeoAccessSummary {
 	total := 0
 	accessible := 0
-
-	// Count RU
-	for _, status := range result.RU {
-		total++
-		if status.Accessible {
-			accessible++
-		}
-	}
-
-	// Count CN
-	for _, status := range result.CN {
-		total++
-		if status.Accessible {
-			accessible++
-		}
-	}
-
-	// Count IR
-	for _, status := range result.IR {
-		total++
-		if status.Accessible {
-			accessible++
-		}
-	}
-
-	// Count US
-	for _, status := range result.US {
-		total++
-		if status.Accessible {
-			accessible++
-		}
-	}
-
-	// Count Custom
-	for _, status := range result.Custom {
-		total++
-		if status.Accessible {
-			accessible++
+	blockpages := 0
+
+	for _, region := range result.Regions {
+		for _, status := range region {
+			total++
+			if status.Accessible {
+				accessible++
+			}
+			if status.Blockpage {
+				blockpages++
+			}
 		}
 	}
 
@@ -194,5 +247,6 @@ func (g *GeoAccessChecker) calculateSummary(result *models.GeoAccessResult) mode
 		TotalAccessible:  accessible,
 		TotalBlocked:     blocked,
 		AccessPercentage: percentage,
+		TotalBlockpages:  blockpages,
 	}
 }
@@ -0,0 +1,125 @@
+package checks
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/domains"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestTryURLBlockpage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/blocked" {
+			w.Write([]byte("<html>Access to this resource is restricted</html>"))
+			return
+		}
+		w.Write([]byte("<html><title>Example Video</title>...</html>"))
+	}))
+	defer server.Close()
+
+	checker := NewGeoAccessChecker(time.Second)
+	tests := []struct {
+		path   string
+		expect *models.GeoExpectation
+		want   bool // accessible
+	}{
+		{"/", nil, true},
+		{"/blocked", nil, true},
+		{"/", &models.GeoExpectation{Keyword: "example video"}, true},
+		{"/blocked", &models.GeoExpectation{Keyword: "example video"}, false},
+		{"/", &models.GeoExpectation{MinBodyBytes: 4096}, false},
+	}
+	for _, tt := range tests {
+		status := checker.tryURL(context.Background(), server.Client(), server.URL+tt.path, tt.expect)
+		if status.Accessible != tt.want || status.Blockpage == tt.want {
+			t.Errorf("%s with %+v: accessible = %v, blockpage = %v, want accessible %v", tt.path, tt.expect, status.Accessible, status.Blockpage, tt.want)
+		}
+	}
+}
+
+func TestClassifyRedirect(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"https://google.com", "https://google.com", ""},
+		{"https://google.com", "https://www.google.com/", ""},
+		{"https://google.com", "https://www.google.ru/", RedirectRegional},
+		{"https://amazon.com", "https://www.amazon.co.uk/", RedirectRegional},
+		{"https://twitter.com", "https://x.com/", ""},
+		{"https://rutracker.org", "http://warning.rt.ru/", RedirectOffsite},
+		{"https://example.com", "https://blocked.provider.net/?site=example.com", RedirectOffsite},
+		{"https://youtube.com", "https://consent.youtube.com/m?continue=x", RedirectConsent},
+		{"https://google.com", "https://consent.google.de/ml", RedirectConsent},
+		{"https://example.com", "https://example.com/gdpr/", RedirectConsent},
+		{"https://netflix.com", "https://www.netflix.com/tudum", RedirectBlockpage},
+		{"https://example.com", "https://example.com/en/unavailable", RedirectBlockpage},
+	}
+	for _, tt := range tests {
+		from, _ := url.Parse(tt.from)
+		to, _ := url.Parse(tt.to)
+		if got := classifyRedirect(from, to); got != tt.want {
+			t.Errorf("classifyRedirect(%s, %s) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestTryURLRedirectBlockpage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/tudum", http.StatusFound)
+			return
+		}
+		w.Write([]byte("<html>Netflix is not available in your country</html>"))
+	}))
+	defer server.Close()
+
+	checker := NewGeoAccessChecker(time.Second)
+	status := checker.tryURL(context.Background(), server.Client(), server.URL, nil)
+	if status.Accessible || !status.Blockpage || status.Redirect != RedirectBlockpage {
+		t.Errorf("status = %+v, want a block page redirect", status)
+	}
+	if status.FinalURL != server.URL+"/tudum" {
+		t.Errorf("final URL = %q, want %s/tudum", status.FinalURL, server.URL)
+	}
+}
+
+func TestCheckCustomRegions(t *testing.T) {
+	checker := NewGeoAccessChecker(time.Second)
+	checker.SetRegions(map[string][]string{
+		"streaming": {"netflix.com", "hulu.com"},
+		"US":        {"google.com"},
+		"CN":        {},
+	})
+
+	client := &http.Client{Transport: resolverTransport{"netflix.com": true, "google.com": true}}
+	result, err := checker.Check(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := slices.Sorted(maps.Keys(result.Regions)); !slices.Equal(got, []string{"IR", "RU", "US", "streaming"}) {
+		t.Errorf("regions = %v", got)
+	}
+	if len(result.Regions["US"]) != 1 || !result.Regions["US"]["google.com"].Accessible {
+		t.Errorf("US = %v, want google.com only", result.Regions["US"])
+	}
+	streaming := result.Regions["streaming"]
+	if !streaming["netflix.com"].Accessible || streaming["hulu.com"].Accessible {
+		t.Errorf("streaming = %v", streaming)
+	}
+	if result.Summary.TotalTested != len(domains.GeoDomainsRU)+len(domains.GeoDomainsIR)+3 {
+		t.Errorf("tested %d domains", result.Summary.TotalTested)
+	}
+
+	if _, err := checker.CheckCountry(context.Background(), client, "cn"); err == nil {
+		t.Error("removed region CN is still checked")
+	}
+}
@@ -0,0 +1,121 @@
+package checks
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Kinds of redirect a geo-access domain can answer with
+const (
+	// RedirectRegional is the same site under another country's domain,
+	// e.g. google.com to google.ru
+	RedirectRegional = "regional"
+	// RedirectConsent is a cookie or age consent page, shown to visitors
+	// from the EU and a few other regions
+	RedirectConsent = "consent"
+	// RedirectBlockpage is a page saying the site is not available in the
+	// visitor's region
+	RedirectBlockpage = "blockpage"
+	// RedirectOffsite is an unrelated site, such as the page an ISP or a
+	// censor shows in place of a blocked one
+	RedirectOffsite = "offsite"
+)
+
+// siteMoves are sites that moved to a domain of another name and redirect
+// there
+var siteMoves = map[string][]string{
+	"twitter.com": {"x.com"},
+}
+
+// consentHosts are consent pages on hosts of their own
+var consentHosts = []string{
+	"consent.google.",
+	"consent.youtube.com",
+	"consent.yahoo.com",
+	"guce.yahoo.com",
+}
+
+// consentPaths are path segments of consent pages served by the site itself
+var consentPaths = []string{
+	"consent",
+	"cookie-consent",
+	"gdpr",
+}
+
+// blockPaths are path segments of regional block pages. Netflix sends
+// visitors from countries it does not serve to its /tudum fan site.
+var blockPaths = []string{
+	"unavailable",
+	"not-available",
+	"notavailable",
+	"unsupported",
+	"geoblock",
+	"geo-blocked",
+	"geo-restricted",
+	"region-blocked",
+	"blocked",
+	"tudum",
+}
+
+// classifyRedirect tells what kind of page a request for from ended up on
+// at to, or "" for none of the kinds above, including no redirect at all.
+// Any redirect to another site, whatever its path, is offsite: block pages
+// often sit at the root of the blocker's host.
+func classifyRedirect(from, to *url.URL) string {
+	if to == nil || (to.Host == from.Host && to.Path == from.Path) {
+		return ""
+	}
+
+	host := strings.ToLower(to.Hostname())
+	for _, consent := range consentHosts {
+		if strings.HasPrefix(host, consent) {
+			return RedirectConsent
+		}
+	}
+	segments := strings.Split(strings.ToLower(to.Path), "/")
+	for _, segment := range segments {
+		for _, consent := range consentPaths {
+			if segment == consent {
+				return RedirectConsent
+			}
+		}
+	}
+	for _, segment := range segments {
+		for _, block := range blockPaths {
+			if segment == block {
+				return RedirectBlockpage
+			}
+		}
+	}
+
+	fromSite, fromSuffix := siteName(from.Hostname())
+	toSite, toSuffix := siteName(host)
+	if fromSite == "" || toSite == "" {
+		return ""
+	}
+	if fromSite == toSite {
+		if fromSuffix != toSuffix {
+			return RedirectRegional
+		}
+		return ""
+	}
+	for _, moved := range siteMoves[fromSite+"."+fromSuffix] {
+		if moved == toSite+"."+toSuffix {
+			return ""
+		}
+	}
+	return RedirectOffsite
+}
+
+// siteName splits a host's registrable domain into its name and public
+// suffix, e.g. www.google.co.uk into google and co.uk
+func siteName(host string) (name, suffix string) {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(host))
+	if err != nil {
+		return "", ""
+	}
+	name, suffix, _ = strings.Cut(domain, ".")
+	return name, suffix
+}
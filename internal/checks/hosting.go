@@ -0,0 +1,281 @@
+package checks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// hostingASNs maps the ASNs of major clouds and hosters to provider names.
+// They catch addresses missing from the range feeds and providers that
+// publish none, such as Hetzner.
+var hostingASNs = map[int]string{
+	16509:  "AWS",
+	14618:  "AWS",
+	15169:  "GCP",
+	396982: "GCP",
+	8075:   "Azure",
+	31898:  "Oracle",
+	24940:  "Hetzner",
+	213230: "Hetzner",
+	14061:  "DigitalOcean",
+	63949:  "Linode",
+	20473:  "Vultr",
+	16276:  "OVH",
+}
+
+// hostingRange is one prefix of a provider's range feed
+type hostingRange struct {
+	prefix   netip.Prefix
+	provider string
+	region   string
+}
+
+// HostingRanges matches IPs against the published IP ranges of cloud
+// providers
+type HostingRanges struct {
+	ranges []hostingRange
+}
+
+// LoadHostingRanges fetches and parses the range feeds. Feeds that fail are
+// reported but don't prevent the others from being used.
+func LoadHostingRanges(ctx context.Context, client *http.Client, sources []models.HostingRangeSource) (*HostingRanges, []error) {
+	h := &HostingRanges{}
+	var errs []error
+
+	for _, source := range sources {
+		ranges, err := fetchHostingRanges(ctx, client, source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s ranges: %w", source.Provider, err))
+			continue
+		}
+		h.ranges = append(h.ranges, ranges...)
+	}
+
+	return h, errs
+}
+
+func fetchHostingRanges(ctx context.Context, client *http.Client, source models.HostingRangeSource) ([]hostingRange, error) {
+	body, err := fetchFeed(ctx, client, source.URL)
+	if err != nil {
+		return nil, err
+	}
+	return parseHostingRanges(source.Format, source.Provider, body)
+}
+
+// fetchFeed downloads an IP list or range feed
+func fetchFeed(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	// Azure's Service Tags file is the largest at around 5 MB
+	return io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+}
+
+// parsePrefixLines parses a plain-text list with one prefix or address per
+// line, as published by most blocklists. Text after the prefix is returned
+// as the line's note; blank lines and # or ; comments are skipped.
+func parsePrefixLines(body []byte, add func(prefix netip.Prefix, note string)) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(fields[0])
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(fields[0])
+			if addrErr != nil {
+				continue
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+
+		note := ""
+		if len(fields) > 1 && !strings.HasPrefix(fields[1], "#") {
+			note = fields[1]
+		}
+		add(prefix.Masked(), note)
+	}
+}
+
+// parseHostingRanges parses a range feed in one of the supported formats
+func parseHostingRanges(format, provider string, body []byte) ([]hostingRange, error) {
+	var ranges []hostingRange
+	add := func(cidr, region string) {
+		if prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr)); err == nil {
+			ranges = append(ranges, hostingRange{prefix: prefix.Masked(), provider: provider, region: region})
+		}
+	}
+
+	switch format {
+	case "aws":
+		var feed struct {
+			Prefixes []struct {
+				IPPrefix string `json:"ip_prefix"`
+				Region   string `json:"region"`
+			} `json:"prefixes"`
+			IPv6Prefixes []struct {
+				IPv6Prefix string `json:"ipv6_prefix"`
+				Region     string `json:"region"`
+			} `json:"ipv6_prefixes"`
+		}
+		if err := json.Unmarshal(body, &feed); err != nil {
+			return nil, err
+		}
+		for _, p := range feed.Prefixes {
+			add(p.IPPrefix, awsRegion(p.Region))
+		}
+		for _, p := range feed.IPv6Prefixes {
+			add(p.IPv6Prefix, awsRegion(p.Region))
+		}
+
+	case "gcp":
+		var feed struct {
+			Prefixes []struct {
+				IPv4Prefix string `json:"ipv4Prefix"`
+				IPv6Prefix string `json:"ipv6Prefix"`
+				Scope      string `json:"scope"`
+			} `json:"prefixes"`
+		}
+		if err := json.Unmarshal(body, &feed); err != nil {
+			return nil, err
+		}
+		for _, p := range feed.Prefixes {
+			add(p.IPv4Prefix+p.IPv6Prefix, p.Scope)
+		}
+
+	case "oracle":
+		var feed struct {
+			Regions []struct {
+				Region string `json:"region"`
+				CIDRs  []struct {
+					CIDR string `json:"cidr"`
+				} `json:"cidrs"`
+			} `json:"regions"`
+		}
+		if err := json.Unmarshal(body, &feed); err != nil {
+			return nil, err
+		}
+		for _, r := range feed.Regions {
+			for _, c := range r.CIDRs {
+				add(c.CIDR, r.Region)
+			}
+		}
+
+	case "azure":
+		var feed struct {
+			Values []struct {
+				Properties struct {
+					Region          string   `json:"region"`
+					AddressPrefixes []string `json:"addressPrefixes"`
+				} `json:"properties"`
+			} `json:"values"`
+		}
+		if err := json.Unmarshal(body, &feed); err != nil {
+			return nil, err
+		}
+		for _, v := range feed.Values {
+			for _, cidr := range v.Properties.AddressPrefixes {
+				add(cidr, v.Properties.Region)
+			}
+		}
+
+	case "cidr", "":
+		parsePrefixLines(body, func(prefix netip.Prefix, region string) {
+			ranges = append(ranges, hostingRange{prefix: prefix, provider: provider, region: region})
+		})
+
+	default:
+		return nil, fmt.Errorf("unknown range format %q", format)
+	}
+
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no prefixes in feed")
+	}
+	return ranges, nil
+}
+
+// awsRegion drops the "GLOBAL" pseudo-region of AWS edge services
+func awsRegion(region string) string {
+	if region == "GLOBAL" {
+		return ""
+	}
+	return region
+}
+
+// Lookup returns the provider whose most specific prefix contains ip, or nil
+func (h *HostingRanges) Lookup(ip string) *models.HostingInfo {
+	if h == nil {
+		return nil
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	addr = addr.Unmap()
+
+	var best *hostingRange
+	for i := range h.ranges {
+		r := &h.ranges[i]
+		if !r.prefix.Contains(addr) {
+			continue
+		}
+		// Prefer the most specific prefix, and one with a region on ties
+		if best == nil || r.prefix.Bits() > best.prefix.Bits() ||
+			(r.prefix.Bits() == best.prefix.Bits() && best.region == "" && r.region != "") {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	return &models.HostingInfo{
+		Provider: best.provider,
+		Region:   best.region,
+		Network:  best.prefix.String(),
+		Source:   "ip-ranges",
+	}
+}
+
+// HostingFromAS identifies a hosting provider from an AS description such
+// as "AS24940 Hetzner Online GmbH", or returns nil
+func HostingFromAS(as string) *models.HostingInfo {
+	number, _, _ := strings.Cut(strings.TrimSpace(as), " ")
+	asn, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(number), "AS"))
+	if err != nil {
+		return nil
+	}
+	provider, ok := hostingASNs[asn]
+	if !ok {
+		return nil
+	}
+	return &models.HostingInfo{
+		Provider: provider,
+		Network:  fmt.Sprintf("AS%d", asn),
+		Source:   "asn",
+	}
+}
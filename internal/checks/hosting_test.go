@@ -0,0 +1,87 @@
+package checks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestHostingRanges(t *testing.T) {
+	feeds := map[string]string{
+		"/aws": `{"prefixes":[
+			{"ip_prefix":"3.0.0.0/8","region":"GLOBAL","service":"AMAZON"},
+			{"ip_prefix":"3.5.140.0/22","region":"ap-northeast-2","service":"EC2"}],
+			"ipv6_prefixes":[{"ipv6_prefix":"2600:1f14::/35","region":"us-west-2"}]}`,
+		"/gcp":    `{"prefixes":[{"ipv4Prefix":"34.1.208.0/20","scope":"africa-south1"},{"ipv6Prefix":"2600:1900:8000::/44","scope":"us-central1"}]}`,
+		"/oracle": `{"regions":[{"region":"eu-frankfurt-1","cidrs":[{"cidr":"130.61.0.0/16","tags":["OCI"]}]}]}`,
+		"/azure":  `{"values":[{"name":"AzureCloud.westeurope","properties":{"region":"westeurope","addressPrefixes":["13.69.0.0/17"]}}]}`,
+		"/cidr":   "# Hetzner\n5.9.0.0/16 fsn1\n",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		feed, ok := feeds[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(feed))
+	}))
+	defer srv.Close()
+
+	ranges, errs := LoadHostingRanges(context.Background(), srv.Client(), []models.HostingRangeSource{
+		{Provider: "AWS", URL: srv.URL + "/aws", Format: "aws"},
+		{Provider: "GCP", URL: srv.URL + "/gcp", Format: "gcp"},
+		{Provider: "Oracle", URL: srv.URL + "/oracle", Format: "oracle"},
+		{Provider: "Azure", URL: srv.URL + "/azure", Format: "azure"},
+		{Provider: "Hetzner", URL: srv.URL + "/cidr", Format: "cidr"},
+		{Provider: "Gone", URL: srv.URL + "/missing", Format: "cidr"},
+	})
+	if len(errs) != 1 {
+		t.Errorf("got %d feed errors, want 1 for the missing feed: %v", len(errs), errs)
+	}
+
+	tests := []struct {
+		ip       string
+		provider string
+		region   string
+	}{
+		{"3.5.141.7", "AWS", "ap-northeast-2"}, // most specific prefix wins
+		{"3.200.0.1", "AWS", ""},
+		{"2600:1f14::1", "AWS", "us-west-2"},
+		{"34.1.210.1", "GCP", "africa-south1"},
+		{"130.61.5.5", "Oracle", "eu-frankfurt-1"},
+		{"13.69.1.1", "Azure", "westeurope"},
+		{"5.9.1.1", "Hetzner", "fsn1"},
+		{"::ffff:5.9.1.1", "Hetzner", "fsn1"},
+		{"192.0.2.1", "", ""},
+	}
+	for _, tt := range tests {
+		info := ranges.Lookup(tt.ip)
+		if tt.provider == "" {
+			if info != nil {
+				t.Errorf("%s: got %+v, want no provider", tt.ip, info)
+			}
+			continue
+		}
+		if info == nil || info.Provider != tt.provider || info.Region != tt.region {
+			t.Errorf("%s: got %+v, want %s %s", tt.ip, info, tt.provider, tt.region)
+		}
+	}
+}
+
+func TestHostingFromAS(t *testing.T) {
+	if info := HostingFromAS("AS24940 Hetzner Online GmbH"); info == nil || info.Provider != "Hetzner" || info.Network != "AS24940" {
+		t.Errorf("Hetzner AS: got %+v", info)
+	}
+	if info := HostingFromAS("as16509"); info == nil || info.Provider != "AWS" {
+		t.Errorf("bare ASN: got %+v", info)
+	}
+	if info := HostingFromAS("AS3320 Deutsche Telekom AG"); info != nil {
+		t.Errorf("residential AS: got %+v, want nil", info)
+	}
+	if info := HostingFromAS(""); info != nil {
+		t.Errorf("empty AS: got %+v, want nil", info)
+	}
+}
@@ -0,0 +1,111 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// libreSpeedScheme prefixes speed test endpoints that are LibreSpeed
+// instances, e.g. "librespeed+https://speed.example.com/backend"; the URL
+// is the directory holding garbage.php and empty.php
+const libreSpeedScheme = "librespeed+"
+
+// LibreSpeed test sizes. garbage.php sends ckSize chunks of 1 MiB.
+const (
+	libreSpeedChunk   = 1 << 20
+	libreSpeedUpload  = 5_000_000
+	libreSpeedPings   = 3
+	libreSpeedMaxSize = 1024 // garbage.php's ckSize limit
+)
+
+// libreSpeedBase returns the backend URL of a LibreSpeed endpoint
+func libreSpeedBase(endpoint string) (string, bool) {
+	base, ok := strings.CutPrefix(endpoint, libreSpeedScheme)
+	return strings.TrimSuffix(base, "/"), ok
+}
+
+// libreSpeedDownloadURL asks garbage.php for about the full download size,
+// or the metered cap rounded up to whole chunks
+func (p *PerformanceChecker) libreSpeedDownloadURL(base string) string {
+	size := int64(fullDownloadBytes)
+	if p.metered {
+		size = p.downloadCap
+	}
+	chunks := min(max((size+libreSpeedChunk-1)/libreSpeedChunk, 1), libreSpeedMaxSize)
+	return fmt.Sprintf("%s/garbage.php?ckSize=%d", base, chunks)
+}
+
+// libreSpeedExtras pings the LibreSpeed instance that ran the download and,
+// unless metered, measures upload speed to it. Failures leave the fields
+// empty.
+func (p *PerformanceChecker) libreSpeedExtras(ctx context.Context, client *http.Client, base string, result *models.PerformanceResult) {
+	if ping, err := p.libreSpeedPing(ctx, client, base); err == nil {
+		result.ServerPing = ping
+	}
+	if p.metered {
+		return
+	}
+	if upload, throughput, err := p.libreSpeedUpload(ctx, client, base); err == nil {
+		result.UploadSpeed = upload
+		result.UploadThroughput = throughput
+	}
+}
+
+// libreSpeedPing returns the fastest of a few requests to empty.php, like
+// LibreSpeed's own client, so a cold connection doesn't count
+func (p *PerformanceChecker) libreSpeedPing(ctx context.Context, client *http.Client, base string) (time.Duration, error) {
+	var best time.Duration
+	var lastErr error
+	for range libreSpeedPings {
+		latency, err := timeRequest(ctx, client, base+"/empty.php")
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if best == 0 || latency < best {
+			best = latency
+		}
+	}
+	if best == 0 {
+		return 0, lastErr
+	}
+	return best, nil
+}
+
+// libreSpeedUpload POSTs random data, which proxies and servers can't
+// compress, to empty.php and times it. It also returns the Mbps of every
+// second, as far as the transport had taken the data.
+func (p *PerformanceChecker) libreSpeedUpload(ctx context.Context, client *http.Client, base string) (float64, []float64, error) {
+	data := make([]byte, libreSpeedUpload)
+	rand.Read(data)
+
+	start := time.Now()
+	meter := &throughputMeter{start: start}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/empty.php", io.TeeReader(bytes.NewReader(data), meter))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	elapsed := time.Since(start)
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, nil, fmt.Errorf("upload to %s: HTTP %d", base, resp.StatusCode)
+	}
+	return float64(len(data)) * 8 / elapsed.Seconds() / 1_000_000, meter.samples(elapsed), nil
+}
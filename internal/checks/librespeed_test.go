@@ -0,0 +1,55 @@
+package checks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLibreSpeed(t *testing.T) {
+	var uploaded atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/backend/garbage.php", func(w http.ResponseWriter, r *http.Request) {
+		chunks, _ := strconv.Atoi(r.URL.Query().Get("ckSize"))
+		for range chunks {
+			w.Write(make([]byte, libreSpeedChunk))
+		}
+	})
+	mux.HandleFunc("/backend/empty.php", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		uploaded.Add(n)
+	})
+	mux.HandleFunc("/generate_204", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	endpoint := "librespeed+" + server.URL + "/backend/"
+	p := NewPerformanceChecker(5 * time.Second)
+	p.SetEndpoints([]string{server.URL + "/generate_204"}, []string{endpoint})
+	p.SetJitterSampling(2, time.Millisecond)
+	result, err := p.Check(context.Background(), server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DownloadSpeed <= 0 || result.SpeedTestServer != endpoint {
+		t.Errorf("download %.1f Mbps from %q, want a speed from %q", result.DownloadSpeed, result.SpeedTestServer, endpoint)
+	}
+	if result.UploadSpeed <= 0 || uploaded.Load() != libreSpeedUpload {
+		t.Errorf("upload %.1f Mbps after %d bytes, want a speed over %d bytes", result.UploadSpeed, uploaded.Load(), libreSpeedUpload)
+	}
+	if result.ServerPing <= 0 {
+		t.Error("expected a ping to the LibreSpeed server")
+	}
+
+	p.SetDataCap(1_500_000)
+	if got := p.libreSpeedDownloadURL(server.URL); got != server.URL+"/garbage.php?ckSize=2" {
+		t.Errorf("metered download URL %s, want 2 chunks", got)
+	}
+}
@@ -0,0 +1,228 @@
+package checks
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// mtuProbeSizes are the IP packet sizes tried, largest first: Ethernet,
+// PPPoE, common tunnel overheads, the IPv6 minimum and the QUIC minimum
+var mtuProbeSizes = []int{1500, 1492, 1480, 1460, 1440, 1420, 1400, 1380, 1350, 1300, 1280, 1250, 1228}
+
+// quicMinPacket is the smallest IP packet carrying a full-size QUIC initial
+// (1200 bytes of UDP payload); paths below it break QUIC handshakes
+func quicMinPacket(isIPv4 bool) int {
+	if isIPv4 {
+		return 1200 + 8 + 20
+	}
+	return 1200 + 8 + 40
+}
+
+// MTUChecker probes the path MTU to a node server with ICMP echoes that
+// must not be fragmented
+type MTUChecker struct {
+	timeout  time.Duration // per probe
+	attempts int           // probes per size before giving up on it
+	resolver *PingChecker
+}
+
+// NewMTUChecker creates a new MTU checker
+func NewMTUChecker(timeout time.Duration) *MTUChecker {
+	return &MTUChecker{
+		timeout:  timeout,
+		attempts: 2,
+		resolver: NewPingChecker(timeout, 1),
+	}
+}
+
+// mtuOutcome is the result of probing one packet size
+type mtuOutcome int
+
+const (
+	mtuFits   mtuOutcome = iota // echo reply received
+	mtuTooBig                   // rejected locally or "fragmentation needed" received
+	mtuLost                     // no answer at all
+)
+
+// Check probes decreasing packet sizes with the Don't Fragment bit set until
+// one gets through. Sizes that vanish without a "fragmentation needed"
+// message while smaller ones pass indicate a PMTU black hole, the classic
+// cause of QUIC nodes that connect but stall. Needs root or CAP_NET_RAW.
+func (m *MTUChecker) Check(ctx context.Context, host string) (*models.MTUResult, error) {
+	result := &models.MTUResult{}
+
+	ip, err := m.resolver.resolve(ctx, host)
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.Address = ip.String()
+	isIPv4 := ip.To4() != nil
+
+	network := "ip4:icmp"
+	if !isIPv4 {
+		network = "ip6:ipv6-icmp"
+	}
+	conn, err := net.ListenPacket(network, "")
+	if err != nil {
+		err = fmt.Errorf("MTU probing needs a raw ICMP socket (root or CAP_NET_RAW): %w", err)
+		result.Error = err.Error()
+		return result, err
+	}
+	defer conn.Close()
+
+	if err := setDontFragment(conn, isIPv4); err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+
+	id := rand.Intn(0xffff)
+	seq := 0
+	m.search(ctx, result, func(size int) (mtuOutcome, int) {
+		seq++
+		return m.probe(ctx, conn, ip, id, seq, size)
+	})
+
+	if result.PathMTU == 0 {
+		if ctx.Err() != nil {
+			result.Error = ctx.Err().Error()
+		} else {
+			result.Error = "no probe size got through (ICMP may be filtered)"
+		}
+		return result, fmt.Errorf("%s", result.Error)
+	}
+
+	result.FragmentationIssue = result.Blackhole || result.PathMTU < quicMinPacket(isIPv4)
+
+	return result, nil
+}
+
+// search tries mtuProbeSizes largest first, each up to m.attempts times,
+// until one fits. Sizes that were lost are then probed again from the
+// largest down: one that fits now only lost packets, and the path MTU is
+// at least that. Sizes lost in both passes are the black hole.
+func (m *MTUChecker) search(ctx context.Context, result *models.MTUResult, probe func(size int) (mtuOutcome, int)) {
+	try := func(size int) mtuOutcome {
+		outcome := mtuLost
+		for attempt := 0; attempt < m.attempts && outcome == mtuLost && ctx.Err() == nil; attempt++ {
+			var hint int
+			outcome, hint = probe(size)
+			if hint > 0 && (result.ReportedMTU == 0 || hint < result.ReportedMTU) {
+				result.ReportedMTU = hint
+			}
+		}
+		return outcome
+	}
+
+	var lost []int
+	for _, size := range mtuProbeSizes {
+		if ctx.Err() != nil {
+			return
+		}
+		outcome := try(size)
+		if outcome == mtuFits {
+			result.PathMTU = size
+			break
+		}
+		if outcome == mtuLost {
+			lost = append(lost, size)
+		}
+	}
+	if result.PathMTU == 0 {
+		return
+	}
+
+	for _, size := range lost {
+		if ctx.Err() != nil {
+			return
+		}
+		switch try(size) {
+		case mtuFits:
+			result.PathMTU = size
+			return
+		case mtuLost:
+			result.Blackhole = true
+		}
+	}
+}
+
+// probe sends one DF echo request of the given IP packet size. A
+// "fragmentation needed" / "packet too big" reply also returns the MTU it
+// advertises.
+func (m *MTUChecker) probe(ctx context.Context, conn net.PacketConn, ip net.IP, id, seq, size int) (mtuOutcome, int) {
+	isIPv4 := ip.To4() != nil
+	var msgType icmp.Type = ipv4.ICMPTypeEcho
+	headerLen, proto := 20, protocolICMP
+	if !isIPv4 {
+		msgType, headerLen, proto = ipv6.ICMPTypeEchoRequest, 40, protocolICMPIPv6
+	}
+
+	// IP header + 8 bytes of ICMP echo header + padding
+	payload := size - headerLen - 8
+	msg := icmp.Message{
+		Type: msgType,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: make([]byte, payload)},
+	}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return mtuLost, 0
+	}
+
+	deadline := time.Now().Add(m.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return mtuLost, 0
+	}
+
+	if _, err := conn.WriteTo(data, &net.IPAddr{IP: ip}); err != nil {
+		// The kernel already knows the packet exceeds the path MTU
+		if errors.Is(err, syscall.EMSGSIZE) {
+			return mtuTooBig, 0
+		}
+		return mtuLost, 0
+	}
+
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return mtuLost, 0
+		}
+
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil {
+			continue
+		}
+
+		switch body := reply.Body.(type) {
+		case *icmp.Echo:
+			if (reply.Type == ipv4.ICMPTypeEchoReply || reply.Type == ipv6.ICMPTypeEchoReply) && body.ID == id && body.Seq == seq {
+				return mtuFits, 0
+			}
+		case *icmp.DstUnreach:
+			// IPv4 "fragmentation needed" (code 4) carries the next-hop MTU
+			// in bytes 6-7 of the ICMP header
+			if reply.Code == 4 && quotedEchoMatches(body.Data, isIPv4, id, seq) {
+				return mtuTooBig, int(binary.BigEndian.Uint16(buf[6:8]))
+			}
+		case *icmp.PacketTooBig:
+			if quotedEchoMatches(body.Data, isIPv4, id, seq) {
+				return mtuTooBig, body.MTU
+			}
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package checks
+
+import (
+	"fmt"
+	"net"
+)
+
+// setDontFragment is only implemented on Linux
+func setDontFragment(conn net.PacketConn, isIPv4 bool) error {
+	return fmt.Errorf("MTU probing is only supported on Linux")
+}
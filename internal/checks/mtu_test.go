@@ -0,0 +1,121 @@
+package checks
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestMTUSearch(t *testing.T) {
+	tests := map[string]struct {
+		probe     func(size, attempt int) (mtuOutcome, int)
+		want      int
+		reported  int
+		blackhole bool
+	}{
+		"black hole": {
+			probe: func(size, _ int) (mtuOutcome, int) {
+				if size > 1400 {
+					return mtuLost, 0
+				}
+				return mtuFits, 0
+			},
+			want: 1400, blackhole: true,
+		},
+		"fragmentation needed": {
+			probe: func(size, _ int) (mtuOutcome, int) {
+				if size > 1420 {
+					return mtuTooBig, 1420
+				}
+				return mtuFits, 0
+			},
+			want: 1420, reported: 1420,
+		},
+		"one probe lost": {
+			probe: func(size, attempt int) (mtuOutcome, int) {
+				if attempt == 1 {
+					return mtuLost, 0
+				}
+				return mtuFits, 0
+			},
+			want: 1500,
+		},
+		"lost until the second pass": {
+			probe: func(size, attempt int) (mtuOutcome, int) {
+				if size == 1500 && attempt <= 2 {
+					return mtuLost, 0
+				}
+				return mtuFits, 0
+			},
+			want: 1500,
+		},
+	}
+	for name, test := range tests {
+		attempts := map[int]int{}
+		result := &models.MTUResult{}
+		NewMTUChecker(time.Second).search(context.Background(), result, func(size int) (mtuOutcome, int) {
+			attempts[size]++
+			return test.probe(size, attempts[size])
+		})
+		if result.PathMTU != test.want || result.ReportedMTU != test.reported || result.Blackhole != test.blackhole {
+			t.Errorf("%s: got %+v", name, result)
+		}
+	}
+}
+
+// TestMTUProbeIgnoresStrayPacketTooBig only trusts "packet too big" replies
+// quoting the probe itself
+func TestMTUProbeIgnoresStrayPacketTooBig(t *testing.T) {
+	tooBig := func(id, seq int) []byte {
+		echo, _ := (&icmp.Message{
+			Type: ipv6.ICMPTypeEchoRequest,
+			Body: &icmp.Echo{ID: id, Seq: seq},
+		}).Marshal(nil)
+		quoted := append(make([]byte, 40), echo[:8]...)
+		data, _ := (&icmp.Message{
+			Type: ipv6.ICMPTypePacketTooBig,
+			Body: &icmp.PacketTooBig{MTU: 1280, Data: quoted},
+		}).Marshal(nil)
+		return data
+	}
+	reply, _ := (&icmp.Message{
+		Type: ipv6.ICMPTypeEchoReply,
+		Body: &icmp.Echo{ID: 7, Seq: 1},
+	}).Marshal(nil)
+	m := NewMTUChecker(time.Second)
+	ip := net.ParseIP("2001:db8::1")
+
+	conn := &fakePacketConn{replies: [][]byte{tooBig(8, 1), reply}}
+	if outcome, _ := m.probe(context.Background(), conn, ip, 7, 1, 1500); outcome != mtuFits {
+		t.Errorf("stray packet too big: outcome %d, want it ignored", outcome)
+	}
+	conn = &fakePacketConn{replies: [][]byte{tooBig(7, 1)}}
+	if outcome, mtu := m.probe(context.Background(), conn, ip, 7, 1, 1500); outcome != mtuTooBig || mtu != 1280 {
+		t.Errorf("packet too big: outcome %d, MTU %d", outcome, mtu)
+	}
+}
+
+// fakePacketConn returns canned replies, then times out
+type fakePacketConn struct {
+	net.PacketConn
+	replies [][]byte
+}
+
+func (c *fakePacketConn) WriteTo(b []byte, _ net.Addr) (int, error) { return len(b), nil }
+func (c *fakePacketConn) SetDeadline(time.Time) error               { return nil }
+
+func (c *fakePacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	if len(c.replies) == 0 {
+		return 0, nil, os.ErrDeadlineExceeded
+	}
+	n := copy(b, c.replies[0])
+	c.replies = c.replies[1:]
+	return n, &net.IPAddr{}, nil
+}
@@ -0,0 +1,121 @@
+package checks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Wire forms a client's first flight can take
+const (
+	WireTLS    = "tls"    // TLS ClientHello
+	WireQUIC   = "quic"   // QUIC long-header packet of a known version
+	WireHTTP   = "http"   // plain HTTP request
+	WireTrojan = "trojan" // bare trojan header: hex password hash and CRLF
+	WireVLESS  = "vless"  // bare VLESS header: version 0 and a UUID
+	WireOpaque = "opaque" // nothing recognisable
+)
+
+// Obfuscation methods checked by CheckObfuscation
+const (
+	ObfsReality    = "reality"
+	ObfsSalamander = "salamander"
+)
+
+var httpMethods = [][]byte{
+	[]byte("GET "), []byte("POST "), []byte("HEAD "), []byte("PUT "),
+	[]byte("CONNECT "), []byte("OPTIONS "), []byte("DELETE "), []byte("PATCH "),
+}
+
+// ClassifyWire tells what the first bytes a client sent look like to an
+// observer on the path
+func ClassifyWire(data []byte) string {
+	switch {
+	case len(data) >= 6 && data[0] == 0x16 && data[1] == 0x03 && data[5] == 0x01:
+		return WireTLS
+	case isQUICLongHeader(data):
+		return WireQUIC
+	case isTrojanHeader(data):
+		return WireTrojan
+	case len(data) >= 18 && data[0] == 0x00:
+		return WireVLESS
+	}
+	for _, method := range httpMethods {
+		if bytes.HasPrefix(data, method) {
+			return WireHTTP
+		}
+	}
+	return WireOpaque
+}
+
+// isQUICLongHeader matches QUIC v1 and v2 long-header packets, the form of
+// every Initial a client opens with
+func isQUICLongHeader(data []byte) bool {
+	if len(data) < 5 || data[0]&0xc0 != 0xc0 {
+		return false
+	}
+	switch binary.BigEndian.Uint32(data[1:5]) {
+	case 0x00000001, 0x6b3343cf:
+		return true
+	}
+	return false
+}
+
+// isTrojanHeader matches the 56 hex characters of a SHA-224 password hash
+// followed by CRLF
+func isTrojanHeader(data []byte) bool {
+	if len(data) < 58 || data[56] != '\r' || data[57] != '\n' {
+		return false
+	}
+	for _, c := range data[:56] {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckObfuscation judges whether a node's first flight hides the proxy
+// protocol as the obfuscation method intends. sni is the camouflage server
+// name the node is configured with.
+func CheckObfuscation(method, sni string, data []byte) *models.ObfuscationResult {
+	result := &models.ObfuscationResult{
+		Method:   method,
+		Observed: ClassifyWire(data),
+	}
+
+	switch method {
+	case ObfsReality:
+		// REALITY must look like a browser visiting the camouflage site
+		if result.Observed != WireTLS {
+			result.Issues = append(result.Issues, fmt.Sprintf("first flight is %s, not a TLS ClientHello", result.Observed))
+			break
+		}
+		hello, err := ParseClientHello(data)
+		if err != nil {
+			result.Issues = append(result.Issues, err.Error())
+			break
+		}
+		if hello.ServerName == "" {
+			result.Issues = append(result.Issues, "ClientHello carries no server name")
+		} else if sni != "" && hello.ServerName != sni {
+			result.Issues = append(result.Issues, fmt.Sprintf("server name %s differs from the camouflage name %s", hello.ServerName, sni))
+		}
+		if hello.Profile() == ProfileGo {
+			result.Issues = append(result.Issues, "Go TLS fingerprint instead of a browser's")
+		}
+	case ObfsSalamander:
+		// Salamander scrambles every datagram; a readable QUIC header means
+		// the obfuscation is off
+		if result.Observed != WireOpaque {
+			result.Issues = append(result.Issues, fmt.Sprintf("first datagram is readable as %s", result.Observed))
+		}
+	default:
+		result.Issues = append(result.Issues, fmt.Sprintf("unknown obfuscation method %q", method))
+	}
+
+	result.Effective = len(result.Issues) == 0
+	return result
+}
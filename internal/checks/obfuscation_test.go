@@ -0,0 +1,45 @@
+package checks
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyWire(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte{0x16, 0x03, 0x01, 0x02, 0x00, 0x01, 0x00}, WireTLS},
+		{[]byte{0xc3, 0x00, 0x00, 0x00, 0x01, 0x08}, WireQUIC},
+		{[]byte(strings.Repeat("ab12", 14) + "\r\n\x01\x03"), WireTrojan},
+		{append([]byte{0x00}, make([]byte, 20)...), WireVLESS},
+		{[]byte("CONNECT example.com:443 HTTP/1.1\r\n"), WireHTTP},
+		{[]byte{0x5e, 0x91, 0x07, 0xd2, 0x3a, 0xc8, 0x11}, WireOpaque},
+	}
+	for _, tt := range tests {
+		if got := ClassifyWire(tt.data); got != tt.want {
+			t.Errorf("ClassifyWire(% x) = %s, want %s", tt.data[:6], got, tt.want)
+		}
+	}
+}
+
+func TestCheckObfuscation(t *testing.T) {
+	quic := []byte{0xc3, 0x00, 0x00, 0x00, 0x01, 0x08, 0x00}
+	if result := CheckObfuscation(ObfsSalamander, "", quic); result.Effective || result.Observed != WireQUIC {
+		t.Errorf("plain QUIC passed as salamander: %+v", result)
+	}
+	if result := CheckObfuscation(ObfsSalamander, "", []byte{0x5e, 0x91, 0x07, 0xd2, 0x3a}); !result.Effective {
+		t.Errorf("scrambled datagram rejected: %+v", result)
+	}
+
+	// Go's own hello carries the right name but no browser fingerprint
+	result := CheckObfuscation(ObfsReality, "example.com", goClientHello(t))
+	if result.Effective || len(result.Issues) != 1 || !strings.Contains(result.Issues[0], "fingerprint") {
+		t.Errorf("unexpected REALITY verdict: %+v", result)
+	}
+	result = CheckObfuscation(ObfsReality, "www.microsoft.com", goClientHello(t))
+	if len(result.Issues) != 2 {
+		t.Errorf("expected a server name mismatch too: %+v", result)
+	}
+}
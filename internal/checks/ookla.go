@@ -0,0 +1,289 @@
+package checks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Ookla test settings. The official clients split each direction over
+// several connections; the sizes add up to the plain download test's.
+const (
+	ooklaCandidates  = 5
+	ooklaPings       = 3
+	ooklaConnections = 4
+	ooklaStreamBytes = fullDownloadBytes / ooklaConnections
+	// Transfers get longer than single commands, like the plain download
+	ooklaTransferTimeout = 60 * time.Second
+)
+
+// OoklaChecker runs the speedtest.net TCP test against a server near the
+// exit, for numbers comparable with speedtest.net's own
+type OoklaChecker struct {
+	timeout time.Duration // per server command
+}
+
+// NewOoklaChecker creates a new Ookla speed test checker
+func NewOoklaChecker(timeout time.Duration) *OoklaChecker {
+	return &OoklaChecker{
+		timeout: timeout,
+	}
+}
+
+// ooklaServer is an entry of the speedtest.net server list
+type ooklaServer struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"` // city
+	Country  string  `json:"country"`
+	CC       string  `json:"cc"`
+	Sponsor  string  `json:"sponsor"`
+	Host     string  `json:"host"` // host:port of the TCP test
+	Distance float64 `json:"distance"`
+}
+
+// Check fetches the server list through the proxy, which speedtest.net
+// sorts by distance from the exit IP, picks the nearest servers' one with
+// the lowest ping and measures download and upload speed to it through
+// dialer
+func (o *OoklaChecker) Check(ctx context.Context, client *http.Client, dialer proxy.Dialer, serversURL string) (*models.OoklaResult, error) {
+	servers, err := fetchOoklaServers(ctx, client, serversURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *ooklaServer
+	var bestPing time.Duration
+	for i := range servers[:min(len(servers), ooklaCandidates)] {
+		ping, err := o.ping(ctx, dialer, servers[i].Host)
+		if err != nil {
+			continue
+		}
+		if best == nil || ping < bestPing {
+			best, bestPing = &servers[i], ping
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("none of the %d nearest speedtest.net servers answered", min(len(servers), ooklaCandidates))
+	}
+
+	result := &models.OoklaResult{
+		Server: models.OoklaServer{
+			ID:       best.ID,
+			Name:     best.Name,
+			Sponsor:  best.Sponsor,
+			Country:  best.CC,
+			Host:     best.Host,
+			Distance: best.Distance,
+		},
+		Latency: bestPing,
+	}
+
+	if result.DownloadSpeed, err = o.parallel(ctx, dialer, best.Host, o.download); err != nil {
+		return result, fmt.Errorf("download from %s: %w", best.Host, err)
+	}
+	if result.UploadSpeed, err = o.parallel(ctx, dialer, best.Host, o.upload); err != nil {
+		return result, fmt.Errorf("upload to %s: %w", best.Host, err)
+	}
+	return result, nil
+}
+
+// fetchOoklaServers downloads the server list
+func fetchOoklaServers(ctx context.Context, client *http.Client, url string) ([]ooklaServer, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("speedtest.net server list: HTTP %d", resp.StatusCode)
+	}
+
+	var servers []ooklaServer
+	if err := json.NewDecoder(resp.Body).Decode(&servers); err != nil {
+		return nil, fmt.Errorf("speedtest.net server list: %w", err)
+	}
+	servers = slices.DeleteFunc(servers, func(s ooklaServer) bool { return s.Host == "" })
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("speedtest.net server list is empty")
+	}
+	return servers, nil
+}
+
+// ooklaConn is a connection speaking the speedtest.net line protocol
+type ooklaConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// dial connects to a server through the proxy and greets it
+func (o *OoklaChecker) dial(ctx context.Context, dialer proxy.Dialer, host string) (*ooklaConn, error) {
+	var conn net.Conn
+	var err error
+	if contextDialer, ok := dialer.(proxy.ContextDialer); ok {
+		conn, err = contextDialer.DialContext(ctx, "tcp", host)
+	} else {
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ooklaConn{Conn: conn, reader: bufio.NewReader(conn)}
+	reply, err := o.command(ctx, c, "HI")
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(reply, "HELLO") {
+		conn.Close()
+		return nil, fmt.Errorf("%s is not a speedtest.net server: %q", host, reply)
+	}
+	return c, nil
+}
+
+// command sends a command line and reads the reply line
+func (o *OoklaChecker) command(ctx context.Context, c *ooklaConn, line string) (string, error) {
+	c.SetDeadline(ooklaDeadline(ctx, o.timeout))
+	if _, err := io.WriteString(c, line+"\n"); err != nil {
+		return "", err
+	}
+	reply, err := c.reader.ReadString('\n')
+	return strings.TrimSpace(reply), err
+}
+
+// ooklaDeadline is timeout from now or the context deadline, if sooner
+func ooklaDeadline(ctx context.Context, timeout time.Duration) time.Time {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
+
+// ping returns the fastest of a few PING round trips to host
+func (o *OoklaChecker) ping(ctx context.Context, dialer proxy.Dialer, host string) (time.Duration, error) {
+	c, err := o.dial(ctx, dialer, host)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	var best time.Duration
+	for range ooklaPings {
+		start := time.Now()
+		reply, err := o.command(ctx, c, "PING "+strconv.FormatInt(start.UnixMilli(), 10))
+		if err != nil {
+			return 0, err
+		}
+		if !strings.HasPrefix(reply, "PONG") {
+			return 0, fmt.Errorf("unexpected reply to PING: %q", reply)
+		}
+		if rtt := time.Since(start); best == 0 || rtt < best {
+			best = rtt
+		}
+	}
+	return best, nil
+}
+
+// download asks for ooklaStreamBytes, which the server sends as "DOWNLOAD "
+// followed by random data and a newline
+func (o *OoklaChecker) download(ctx context.Context, c *ooklaConn) (int64, error) {
+	c.SetDeadline(ooklaDeadline(ctx, ooklaTransferTimeout))
+	if _, err := fmt.Fprintf(c, "DOWNLOAD %d\n", ooklaStreamBytes); err != nil {
+		return 0, err
+	}
+	return io.CopyN(io.Discard, c.reader, ooklaStreamBytes)
+}
+
+// upload sends ooklaStreamBytes, the command line included and ending in
+// a newline, which the server acknowledges with "OK <size> <ms>"
+func (o *OoklaChecker) upload(ctx context.Context, c *ooklaConn) (int64, error) {
+	header := fmt.Sprintf("UPLOAD %d 0\n", ooklaStreamBytes)
+	payload := make([]byte, ooklaStreamBytes-len(header))
+	for i := range payload {
+		payload[i] = 'A' + byte(rand.IntN(26))
+	}
+	payload[len(payload)-1] = '\n'
+
+	c.SetDeadline(ooklaDeadline(ctx, ooklaTransferTimeout))
+	if _, err := io.WriteString(c, header); err != nil {
+		return 0, err
+	}
+	if _, err := c.Write(payload); err != nil {
+		return 0, err
+	}
+	reply, err := c.reader.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	if !strings.HasPrefix(reply, "OK") {
+		return 0, fmt.Errorf("unexpected reply to UPLOAD: %q", strings.TrimSpace(reply))
+	}
+	return ooklaStreamBytes, nil
+}
+
+// parallel runs transfer on ooklaConnections connections at once and
+// returns the combined speed in Mbps. Connections are set up before the
+// clock starts, so handshakes don't count against the speed.
+func (o *OoklaChecker) parallel(ctx context.Context, dialer proxy.Dialer, host string, transfer func(context.Context, *ooklaConn) (int64, error)) (float64, error) {
+	conns := make([]*ooklaConn, 0, ooklaConnections)
+	defer func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	}()
+	for range ooklaConnections {
+		c, err := o.dial(ctx, dialer, host)
+		if err != nil {
+			return 0, err
+		}
+		conns = append(conns, c)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var total int64
+	var firstErr error
+	start := time.Now()
+	for _, c := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := transfer(ctx, c)
+			mu.Lock()
+			defer mu.Unlock()
+			total += n
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return float64(total) * 8 / elapsed.Seconds() / 1_000_000, nil
+}
@@ -0,0 +1,80 @@
+package checks
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// serveOokla answers the speedtest.net line protocol on listener
+func serveOokla(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				fields := strings.Fields(line)
+				switch fields[0] {
+				case "HI":
+					fmt.Fprint(conn, "HELLO 2.9 (2.9.0) test\n")
+				case "PING":
+					fmt.Fprintf(conn, "PONG %d\n", time.Now().UnixMilli())
+				case "DOWNLOAD":
+					var size int
+					fmt.Sscan(fields[1], &size)
+					data := "DOWNLOAD " + strings.Repeat("x", size-len("DOWNLOAD ")-1) + "\n"
+					io.WriteString(conn, data)
+				case "UPLOAD":
+					var size int
+					fmt.Sscan(fields[1], &size)
+					io.CopyN(io.Discard, reader, int64(size-len(line)))
+					fmt.Fprintf(conn, "OK %d 10\n", size)
+				}
+			}
+		}()
+	}
+}
+
+func TestOokla(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go serveOokla(listener)
+
+	servers := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id":"1","name":"Nowhere","cc":"NL","sponsor":"Down","host":"127.0.0.1:1","distance":3},
+			{"id":"2","name":"Amsterdam","cc":"NL","sponsor":"Test ISP","host":"%s","distance":12}]`, listener.Addr())
+	}))
+	defer servers.Close()
+
+	o := NewOoklaChecker(2 * time.Second)
+	result, err := o.Check(context.Background(), servers.Client(), proxy.Direct, servers.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Server.ID != "2" || result.Server.Sponsor != "Test ISP" {
+		t.Errorf("server %+v, want the one that answers", result.Server)
+	}
+	if result.Latency <= 0 || result.DownloadSpeed <= 0 || result.UploadSpeed <= 0 {
+		t.Errorf("result %+v, want latency and both speeds", result)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/VenoMexx/ProtoScope/pkg/models"
@@ -13,8 +16,26 @@ import (
 // PerformanceChecker tests latency and speed
 type PerformanceChecker struct {
 	timeout time.Duration
+	traffic TrafficCounter
+	// download runs the download test when set, so it can use its own
+	// timeout and connections
+	download *http.Client
+	// Overrides of the built-in latency and download test URLs
+	latencyURLs  []string
+	downloadURLs []string
+	// Jitter sampler settings; zero uses the defaults
+	jitterSamples  int
+	jitterInterval time.Duration
+	// Metered mode caps the download at downloadCap bytes and skips the
+	// jitter sampler
+	metered     bool
+	downloadCap int64
 }
 
+// TrafficCounter reads the proxy backend's cumulative uplink and downlink
+// byte counters for the tested node
+type TrafficCounter func(ctx context.Context) (uplink, downlink int64, err error)
+
 // NewPerformanceChecker creates a new performance checker
 func NewPerformanceChecker(timeout time.Duration) *PerformanceChecker {
 	return &PerformanceChecker{
@@ -22,6 +43,36 @@ func NewPerformanceChecker(timeout time.Duration) *PerformanceChecker {
 	}
 }
 
+// SetTrafficCounter makes the download test also record the backend's own
+// traffic counters
+func (p *PerformanceChecker) SetTrafficCounter(counter TrafficCounter) {
+	p.traffic = counter
+}
+
+// SetDownloadClient makes the download test use client instead of the one
+// passed to Check
+func (p *PerformanceChecker) SetDownloadClient(client *http.Client) {
+	p.download = client
+}
+
+// SetEndpoints replaces the URLs the latency and download tests use; an
+// empty list keeps the built-in ones
+func (p *PerformanceChecker) SetEndpoints(latencyURLs, downloadURLs []string) {
+	p.latencyURLs = latencyURLs
+	p.downloadURLs = downloadURLs
+}
+
+// fullDownloadBytes is the size of the unmetered download test
+const fullDownloadBytes = 10_000_000
+
+// SetDataCap turns on metered mode: the download test stops after
+// capBytes, or is skipped when capBytes is 0, and the jitter sampler, which
+// keeps the connection busy for seconds, is left out
+func (p *PerformanceChecker) SetDataCap(capBytes int64) {
+	p.metered = true
+	p.downloadCap = max(capBytes, 0)
+}
+
 // Check performs complete performance test
 func (p *PerformanceChecker) Check(ctx context.Context, client *http.Client) (*models.PerformanceResult, error) {
 	result := &models.PerformanceResult{}
@@ -32,100 +83,204 @@ func (p *PerformanceChecker) Check(ctx context.Context, client *http.Client) (*m
 		return nil, fmt.Errorf("latency test failed: %w", err)
 	}
 	result.Latency = latency
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
 
 	// Measure download speed
-	downloadSpeed, err := p.MeasureDownloadSpeed(ctx, client)
-	if err != nil {
-		// Don't fail completely, just log
-		downloadSpeed = 0
+	var downloadSpeed float64
+	var downloaded int64
+	var test *SpeedTest
+	downloadClient := client
+	if p.download != nil {
+		downloadClient = p.download
+	}
+	if !p.metered || p.downloadCap > 0 {
+		result.BackendTraffic = p.countTraffic(ctx, func() {
+			test, err = p.MeasureDownloadSpeed(ctx, downloadClient)
+		})
+	}
+	if err == nil && test != nil {
+		// Don't fail completely on download errors, just report no speed
+		downloadSpeed = test.Speed
+		downloaded = test.Bytes
+		result.SpeedTestServer = test.Endpoint
+		result.DownloadThroughput = test.Throughput
 	}
 	result.DownloadSpeed = downloadSpeed
+	if test != nil && ctx.Err() == nil {
+		if base, ok := libreSpeedBase(test.Endpoint); ok {
+			p.libreSpeedExtras(ctx, downloadClient, base, result)
+		}
+	}
+	if p.metered {
+		result.Metered = &models.MeteredSpeed{
+			CapBytes:     p.downloadCap,
+			Bytes:        downloaded,
+			AccuracyLoss: AccuracyLoss(downloaded, downloadSpeed, latency),
+		}
+	}
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	if p.metered {
+		return result, nil
+	}
 
 	// Measure jitter (optional)
-	jitter, _ := p.MeasureJitter(ctx, client, 3)
+	jitter, samples, _ := p.MeasureJitter(ctx, client)
 	result.Jitter = jitter
+	result.JitterSamples = samples
 
 	return result, nil
 }
 
-// MeasureLatency measures latency to a test endpoint
-func (p *PerformanceChecker) MeasureLatency(ctx context.Context, client *http.Client) (time.Duration, error) {
-	testURLs := []string{
-		"https://www.google.com",
-		"https://www.cloudflare.com",
-		"http://www.gstatic.com/generate_204",
+// countTraffic runs test and returns the backend traffic counted meanwhile,
+// or nil without a traffic counter or when the counters can't be read
+func (p *PerformanceChecker) countTraffic(ctx context.Context, test func()) *models.BackendTraffic {
+	if p.traffic == nil {
+		test()
+		return nil
+	}
+
+	upBefore, downBefore, beforeErr := p.traffic(ctx)
+	start := time.Now()
+	test()
+	elapsed := time.Since(start)
+	upAfter, downAfter, afterErr := p.traffic(ctx)
+	if beforeErr != nil || afterErr != nil {
+		return nil
 	}
 
-	var totalLatency time.Duration
-	successCount := 0
+	traffic := &models.BackendTraffic{
+		UplinkBytes:   upAfter - upBefore,
+		DownlinkBytes: downAfter - downBefore,
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		traffic.DownloadSpeed = float64(traffic.DownlinkBytes) * 8 / seconds / 1_000_000
+	}
+	return traffic
+}
+
+// defaultLatencyURLs answer with an empty 204, so a request times the
+// round trip through the proxy rather than a page download
+var defaultLatencyURLs = []string{
+	"http://www.gstatic.com/generate_204",
+	"http://cp.cloudflare.com/generate_204",
+}
+
+// MeasureLatency measures the time to the response headers of a HEAD
+// request to the first latency endpoint that answers. The body is never
+// read, so endpoints serving content only add their server time.
+func (p *PerformanceChecker) MeasureLatency(ctx context.Context, client *http.Client) (time.Duration, error) {
+	testURLs := defaultLatencyURLs
+	if len(p.latencyURLs) > 0 {
+		testURLs = p.latencyURLs
+	}
 
 	for _, url := range testURLs {
-		start := time.Now()
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if latency, err := timeRequest(ctx, client, url); err == nil {
+			return latency, nil
+		}
+	}
 
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	return 0, fmt.Errorf("all latency tests failed")
+}
+
+// timeRequest times a HEAD request to url up to the response headers,
+// retrying with GET for servers that reject HEAD
+func timeRequest(ctx context.Context, client *http.Client, url string) (time.Duration, error) {
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
 		if err != nil {
-			continue
+			return 0, err
 		}
 
+		start := time.Now()
 		resp, err := client.Do(req)
 		if err != nil {
-			continue
+			return 0, err
 		}
-		resp.Body.Close()
-
 		latency := time.Since(start)
-		totalLatency += latency
-		successCount++
+		resp.Body.Close()
 
-		if successCount >= 1 {
-			break
+		if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+			continue
 		}
+		return latency, nil
 	}
+	return 0, fmt.Errorf("%s rejects HEAD and GET", url)
+}
 
-	if successCount == 0 {
-		return 0, fmt.Errorf("all latency tests failed")
-	}
-
-	return totalLatency / time.Duration(successCount), nil
+// SpeedTest is the outcome of a download test
+type SpeedTest struct {
+	Endpoint   string    // the configured endpoint that answered
+	Speed      float64   // Mbps
+	Bytes      int64     // downloaded
+	Throughput []float64 // Mbps of every second of the download
 }
 
-// MeasureDownloadSpeed measures download speed
-func (p *PerformanceChecker) MeasureDownloadSpeed(ctx context.Context, client *http.Client) (float64, error) {
+// MeasureDownloadSpeed measures download speed against the first endpoint
+// that answers
+func (p *PerformanceChecker) MeasureDownloadSpeed(ctx context.Context, client *http.Client) (*SpeedTest, error) {
 	// Test file URLs (approximately 10MB)
 	testURLs := []string{
 		"https://speed.cloudflare.com/__down?bytes=10000000",
 		"http://ipv4.download.thinkbroadband.com/10MB.zip",
 	}
+	if len(p.downloadURLs) > 0 {
+		testURLs = p.downloadURLs
+	}
 
-	for _, url := range testURLs {
-		speed, err := p.downloadTest(ctx, client, url, 10*1024*1024)
+	for _, endpoint := range testURLs {
+		url := endpoint
+		if base, ok := libreSpeedBase(endpoint); ok {
+			url = p.libreSpeedDownloadURL(base)
+		}
+		test, err := p.downloadTest(ctx, client, url)
 		if err == nil {
-			return speed, nil
+			test.Endpoint = endpoint
+			return test, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
 	}
 
-	return 0, fmt.Errorf("all download tests failed")
+	return nil, fmt.Errorf("all download tests failed")
 }
 
 // downloadTest performs a single download test
-func (p *PerformanceChecker) downloadTest(ctx context.Context, client *http.Client, url string, expectedSize int64) (float64, error) {
+func (p *PerformanceChecker) downloadTest(ctx context.Context, client *http.Client, url string) (*SpeedTest, error) {
+	if p.metered {
+		url = capDownloadURL(url, p.downloadCap)
+	}
 	start := time.Now()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// Read and discard the body
-	written, err := io.Copy(io.Discard, resp.Body)
+	// Read and discard the body; closing it early drops the connection, so
+	// a capped download of a fixed-size file stops there
+	var body io.Reader = resp.Body
+	if p.metered {
+		body = io.LimitReader(resp.Body, p.downloadCap)
+	}
+	meter := &throughputMeter{start: start}
+	written, err := io.Copy(meter, body)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	elapsed := time.Since(start)
@@ -136,47 +291,190 @@ func (p *PerformanceChecker) downloadTest(ctx context.Context, client *http.Clie
 	bitsPerSecond := (bytes * 8) / seconds
 	mbps := bitsPerSecond / 1_000_000
 
-	return mbps, nil
+	return &SpeedTest{Speed: mbps, Bytes: written, Throughput: meter.samples(elapsed)}, nil
+}
+
+// throughputMeter counts the bytes written to it in each second since start
+type throughputMeter struct {
+	start   time.Time
+	seconds []int64
+}
+
+func (m *throughputMeter) Write(b []byte) (int, error) {
+	second := int(time.Since(m.start) / time.Second)
+	for len(m.seconds) <= second {
+		m.seconds = append(m.seconds, 0)
+	}
+	m.seconds[second] += int64(len(b))
+	return len(b), nil
+}
+
+// samples returns the throughput of every second in Mbps, the last one
+// over the part of it that had passed after elapsed
+func (m *throughputMeter) samples(elapsed time.Duration) []float64 {
+	samples := make([]float64, len(m.seconds))
+	for i, bytes := range m.seconds {
+		seconds := 1.0
+		if i == len(m.seconds)-1 {
+			seconds = (elapsed - time.Duration(i)*time.Second).Seconds()
+		}
+		if seconds > 0 {
+			samples[i] = float64(bytes) * 8 / seconds / 1_000_000
+		}
+	}
+	return samples
+}
+
+// capDownloadURL asks endpoints that take the download size as a "bytes"
+// parameter, like speed.cloudflare.com, for no more than capBytes
+func capDownloadURL(rawURL string, capBytes int64) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	size, err := strconv.ParseInt(query.Get("bytes"), 10, 64)
+	if err != nil || size <= capBytes {
+		return rawURL
+	}
+	query.Set("bytes", strconv.FormatInt(capBytes, 10))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// initialWindow is the usual initial TCP congestion window, 10 segments
+const initialWindow = 10 * 1460
+
+// rampFraction estimates the share of a download of size bytes at mbps
+// spent ramping up: one round trip for the request, then TCP slow start
+// doubling the congestion window every round trip until it holds the
+// bandwidth-delay product. Speed is measured over the whole download, so
+// it reads low by about this fraction.
+func rampFraction(bytes int64, mbps float64, rtt time.Duration) float64 {
+	if bytes <= 0 || mbps <= 0 || rtt <= 0 {
+		return 0
+	}
+	bytesPerSecond := mbps * 1_000_000 / 8
+	rounds := 1.0
+	if bdp := bytesPerSecond * rtt.Seconds(); bdp > initialWindow {
+		rounds += math.Log2(bdp / initialWindow)
+	}
+	ramp := rounds * rtt.Seconds()
+	return ramp / (ramp + float64(bytes)/bytesPerSecond)
+}
+
+// AccuracyLoss estimates how much lower a speed measured over a download
+// of size bytes reads than one measured over the full-size download, as a
+// fraction of the speed. It is 0 when nothing was downloaded.
+func AccuracyLoss(bytes int64, mbps float64, rtt time.Duration) float64 {
+	if bytes >= fullDownloadBytes {
+		return 0
+	}
+	return max(rampFraction(bytes, mbps, rtt)-rampFraction(fullDownloadBytes, mbps, rtt), 0)
+}
+
+// Jitter sampling defaults
+const (
+	defaultJitterSamples  = 10
+	defaultJitterInterval = 200 * time.Millisecond
+)
+
+// SetJitterSampling sets how many requests the jitter sampler sends and how
+// long it waits between them; zero values keep the defaults
+func (p *PerformanceChecker) SetJitterSampling(samples int, interval time.Duration) {
+	p.jitterSamples = samples
+	p.jitterInterval = interval
 }
 
-// MeasureJitter measures connection jitter
-func (p *PerformanceChecker) MeasureJitter(ctx context.Context, client *http.Client, samples int) (time.Duration, error) {
-	if samples < 2 {
-		samples = 2
+// MeasureJitter times repeated requests to one latency endpoint, the first
+// that answers, and returns the standard deviation of the samples along
+// with the samples themselves. Sticking to one endpoint keeps differences
+// between endpoints out of the jitter.
+func (p *PerformanceChecker) MeasureJitter(ctx context.Context, client *http.Client) (time.Duration, []time.Duration, error) {
+	samples := p.jitterSamples
+	if samples <= 0 {
+		samples = defaultJitterSamples
+	}
+	samples = max(samples, 2)
+	interval := p.jitterInterval
+	if interval <= 0 {
+		interval = defaultJitterInterval
 	}
 
-	latencies := make([]time.Duration, 0, samples)
+	testURLs := defaultLatencyURLs
+	if len(p.latencyURLs) > 0 {
+		testURLs = p.latencyURLs
+	}
 
-	for i := 0; i < samples; i++ {
-		latency, err := p.MeasureLatency(ctx, client)
+	for _, url := range testURLs {
+		if ctx.Err() != nil {
+			return 0, nil, ctx.Err()
+		}
+		first, err := timeRequest(ctx, client, url)
 		if err != nil {
 			continue
 		}
+		latencies := p.sampleLatency(ctx, client, url, first, samples, interval)
+		if len(latencies) < 2 {
+			return 0, latencies, fmt.Errorf("insufficient samples for jitter calculation")
+		}
+		return stdDev(latencies), latencies, nil
+	}
+
+	return 0, nil, fmt.Errorf("all latency endpoints failed")
+}
+
+// sampleLatency collects up to samples request times to url, starting with
+// first. Failed requests are skipped rather than counted as outliers.
+func (p *PerformanceChecker) sampleLatency(ctx context.Context, client *http.Client, url string, first time.Duration, samples int, interval time.Duration) []time.Duration {
+	latencies := []time.Duration{first}
+	for len(latencies) < samples {
+		select {
+		case <-ctx.Done():
+			return latencies
+		case <-time.After(interval):
+		}
+
+		latency, err := timeRequest(ctx, client, url)
+		if err != nil {
+			if ctx.Err() != nil {
+				return latencies
+			}
+			samples-- // give up on this sample
+			continue
+		}
 		latencies = append(latencies, latency)
-		time.Sleep(100 * time.Millisecond)
 	}
+	return latencies
+}
 
-	if len(latencies) < 2 {
-		return 0, fmt.Errorf("insufficient samples for jitter calculation")
+// stdDev returns the population standard deviation of durations
+func stdDev(durations []time.Duration) time.Duration {
+	var sum float64
+	for _, d := range durations {
+		sum += float64(d)
 	}
+	mean := sum / float64(len(durations))
 
-	// Calculate jitter as average deviation
-	var totalDiff time.Duration
-	for i := 1; i < len(latencies); i++ {
-		diff := latencies[i] - latencies[i-1]
-		if diff < 0 {
-			diff = -diff
-		}
-		totalDiff += diff
+	var variance float64
+	for _, d := range durations {
+		diff := float64(d) - mean
+		variance += diff * diff
 	}
+	variance /= float64(len(durations))
 
-	jitter := totalDiff / time.Duration(len(latencies)-1)
-	return jitter, nil
+	return time.Duration(math.Sqrt(variance))
 }
 
-// MeasureUploadSpeed measures upload speed (simplified)
+// MeasureUploadSpeed measures upload speed against the first LibreSpeed
+// instance among the speed test endpoints; plain download URLs can't take
+// uploads
 func (p *PerformanceChecker) MeasureUploadSpeed(ctx context.Context, client *http.Client) (float64, error) {
-	// This is a placeholder for upload speed testing
-	// In a real implementation, you would upload data to a test server
-	return 0, fmt.Errorf("upload speed test not implemented")
+	for _, endpoint := range p.downloadURLs {
+		if base, ok := libreSpeedBase(endpoint); ok {
+			speed, _, err := p.libreSpeedUpload(ctx, client, base)
+			return speed, err
+		}
+	}
+	return 0, fmt.Errorf("no LibreSpeed endpoint configured for the upload test")
 }
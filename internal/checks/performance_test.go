@@ -0,0 +1,123 @@
+package checks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMeasureLatencyUsesHEAD(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.URL.Path == "/no-head" && r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	p := NewPerformanceChecker(5 * time.Second)
+	for _, tt := range []struct {
+		path    string
+		methods []string
+	}{
+		{"/generate_204", []string{"HEAD"}},
+		{"/no-head", []string{"HEAD", "GET"}},
+	} {
+		methods = nil
+		p.SetEndpoints([]string{server.URL + tt.path}, nil)
+		latency, err := p.MeasureLatency(context.Background(), server.Client())
+		if err != nil || latency <= 0 {
+			t.Fatalf("%s: latency %v, err %v", tt.path, latency, err)
+		}
+		if len(methods) != len(tt.methods) || methods[0] != tt.methods[0] || methods[len(methods)-1] != tt.methods[len(tt.methods)-1] {
+			t.Errorf("%s: methods %v, want %v", tt.path, methods, tt.methods)
+		}
+	}
+}
+
+func TestMeasureJitter(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	p := NewPerformanceChecker(5 * time.Second)
+	p.SetEndpoints([]string{"http://127.0.0.1:1/generate_204", server.URL + "/generate_204"}, nil)
+	p.SetJitterSampling(5, time.Millisecond)
+
+	jitter, samples, err := p.MeasureJitter(context.Background(), server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 5 || requests != 5 {
+		t.Errorf("%d samples from %d requests, want 5 from one endpoint", len(samples), requests)
+	}
+	if jitter != stdDev(samples) {
+		t.Errorf("jitter %v is not the samples' standard deviation %v", jitter, stdDev(samples))
+	}
+}
+
+func TestStdDev(t *testing.T) {
+	samples := []time.Duration{2, 4, 4, 4, 5, 5, 7, 9}
+	if got := stdDev(samples); got != 2 {
+		t.Errorf("stdDev = %v, want 2", got)
+	}
+	if got := stdDev([]time.Duration{10, 10}); got != 0 {
+		t.Errorf("stdDev of equal samples = %v, want 0", got)
+	}
+}
+
+func TestDataCap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 100_000))
+	}))
+	defer server.Close()
+
+	p := NewPerformanceChecker(5 * time.Second)
+	p.SetEndpoints([]string{server.URL}, []string{server.URL + "/10MB.zip"})
+	p.SetDataCap(20_000)
+	result, err := p.Check(context.Background(), server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Metered == nil || result.Metered.Bytes != 20_000 {
+		t.Fatalf("metered download %+v, want 20000 bytes", result.Metered)
+	}
+	if result.JitterSamples != nil {
+		t.Error("jitter sampled in metered mode")
+	}
+
+	if got := capDownloadURL("https://speed.cloudflare.com/__down?bytes=10000000", 20_000); got != "https://speed.cloudflare.com/__down?bytes=20000" {
+		t.Errorf("capDownloadURL = %s", got)
+	}
+}
+
+func TestAccuracyLoss(t *testing.T) {
+	rtt := 50 * time.Millisecond
+	small := AccuracyLoss(500_000, 50, rtt)
+	large := AccuracyLoss(2_000_000, 50, rtt)
+	if !(small > large && large > 0) {
+		t.Errorf("accuracy loss %.2f for 500 kB and %.2f for 2 MB, want smaller downloads to lose more", small, large)
+	}
+	if got := AccuracyLoss(fullDownloadBytes, 50, rtt); got != 0 {
+		t.Errorf("full-size download loses %.2f, want 0", got)
+	}
+}
+
+func TestThroughputMeter(t *testing.T) {
+	meter := &throughputMeter{start: time.Now().Add(-1500 * time.Millisecond)}
+	meter.seconds = []int64{250_000}
+	meter.Write(make([]byte, 125_000))
+
+	samples := meter.samples(1500 * time.Millisecond)
+	if len(samples) != 2 || samples[0] != 2 || samples[1] != 2 {
+		t.Errorf("samples = %v, want 2 Mbps over the full and the half second", samples)
+	}
+}
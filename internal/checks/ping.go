@@ -0,0 +1,241 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+const (
+	protocolICMP     = 1
+	protocolICMPIPv6 = 58
+)
+
+// PingChecker measures ICMP echo round-trip time to a node server
+type PingChecker struct {
+	timeout time.Duration
+	count   int
+}
+
+// NewPingChecker creates a new ping checker
+func NewPingChecker(timeout time.Duration, count int) *PingChecker {
+	if count < 1 {
+		count = 1
+	}
+	return &PingChecker{
+		timeout: timeout,
+		count:   count,
+	}
+}
+
+// Check pings the given host directly (not through the proxy).
+// A raw ICMP socket is tried first; it requires root or CAP_NET_RAW, so an
+// unprivileged datagram ICMP socket is used as a fallback.
+func (p *PingChecker) Check(ctx context.Context, host string) (*models.PingResult, error) {
+	result := &models.PingResult{}
+
+	ip, err := p.resolve(ctx, host)
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.Address = ip.String()
+
+	conn, method, err := p.listen(ip)
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	defer conn.Close()
+	result.Method = method
+
+	var dst net.Addr = &net.IPAddr{IP: ip}
+	if method == "udp" {
+		dst = &net.UDPAddr{IP: ip}
+	}
+
+	id := rand.Intn(0xffff)
+	rtts := make([]time.Duration, 0, p.count)
+
+	for seq := 0; seq < p.count; seq++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		result.Sent++
+		rtt, err := p.echo(ctx, conn, dst, ip, id, seq)
+		if err != nil {
+			continue
+		}
+		rtts = append(rtts, rtt)
+		result.Received++
+
+		if seq < p.count-1 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+	}
+
+	if result.Sent > 0 {
+		result.PacketLoss = float64(result.Sent-result.Received) / float64(result.Sent) * 100.0
+	}
+
+	if len(rtts) == 0 {
+		result.Error = "no echo replies received"
+		return result, fmt.Errorf("no echo replies received from %s", ip)
+	}
+
+	var total time.Duration
+	result.MinRTT = rtts[0]
+	for _, rtt := range rtts {
+		total += rtt
+		if rtt < result.MinRTT {
+			result.MinRTT = rtt
+		}
+		if rtt > result.MaxRTT {
+			result.MaxRTT = rtt
+		}
+	}
+	result.AvgRTT = total / time.Duration(len(rtts))
+
+	return result, nil
+}
+
+// resolve resolves the host to a single IP, preferring IPv4
+func (p *PingChecker) resolve(ctx context.Context, host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses for %s", host)
+	}
+
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			return addr.IP, nil
+		}
+	}
+	return addrs[0].IP, nil
+}
+
+// listen opens a privileged ICMP socket, falling back to an unprivileged one
+func (p *PingChecker) listen(ip net.IP) (*icmp.PacketConn, string, error) {
+	rawNetwork, udpNetwork, address := "ip4:icmp", "udp4", "0.0.0.0"
+	if ip.To4() == nil {
+		rawNetwork, udpNetwork, address = "ip6:ipv6-icmp", "udp6", "::"
+	}
+
+	conn, err := icmp.ListenPacket(rawNetwork, address)
+	if err == nil {
+		return conn, "icmp", nil
+	}
+
+	conn, udpErr := icmp.ListenPacket(udpNetwork, address)
+	if udpErr == nil {
+		return conn, "udp", nil
+	}
+
+	return nil, "", fmt.Errorf("icmp unavailable (raw: %v; unprivileged: %v)", err, udpErr)
+}
+
+// echo sends a single echo request and waits for the matching reply
+func (p *PingChecker) echo(ctx context.Context, conn *icmp.PacketConn, dst net.Addr, ip net.IP, id, seq int) (time.Duration, error) {
+	var msgType icmp.Type = ipv4.ICMPTypeEcho
+	proto := protocolICMP
+	if ip.To4() == nil {
+		msgType = ipv6.ICMPTypeEchoRequest
+		proto = protocolICMPIPv6
+	}
+
+	payload := []byte(fmt.Sprintf("protoscope-%d", os.Getpid()))
+	msg := icmp.Message{
+		Type: msgType,
+		Code: 0,
+		Body: &icmp.Echo{
+			ID:   id,
+			Seq:  seq,
+			Data: payload,
+		},
+	}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	deadline := time.Now().Add(p.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if _, err := conn.WriteTo(data, dst); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil {
+			continue
+		}
+
+		echo, ok := reply.Body.(*icmp.Echo)
+		if !ok {
+			continue
+		}
+		if reply.Type != ipv4.ICMPTypeEchoReply && reply.Type != ipv6.ICMPTypeEchoReply {
+			continue
+		}
+		// Unprivileged sockets rewrite the ID to the local port, so only
+		// the sequence number can be matched there.
+		if echo.Seq != seq {
+			continue
+		}
+		if _, isUDP := dst.(*net.UDPAddr); !isUDP && echo.ID != id {
+			continue
+		}
+		// A raw socket sees every echo reply on the host, so another
+		// pinger's reply can carry the same ID and sequence number
+		if !peerIP(peer).Equal(ip) || !bytes.Equal(echo.Data, payload) {
+			continue
+		}
+
+		return time.Since(start), nil
+	}
+}
+
+// peerIP is the address a reply was read from
+func peerIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// defaultPluginTimeout bounds a plugin without a configured timeout
+const defaultPluginTimeout = 60 * time.Second
+
+// PluginChecker runs an external check, so checks can be written in any
+// language without touching ProtoScope
+type PluginChecker struct {
+	plugin models.PluginConfig
+}
+
+// NewPluginChecker creates a checker for a configured plugin
+func NewPluginChecker(plugin models.PluginConfig) *PluginChecker {
+	return &PluginChecker{
+		plugin: plugin,
+	}
+}
+
+// Check runs the plugin against a node reachable through the SOCKS5 proxy
+// at socksAddr. A plugin that exits non-zero or prints something other
+// than JSON fails the check; the result carries the error either way.
+func (p *PluginChecker) Check(ctx context.Context, socksAddr string, protocol *models.Protocol) (*models.PluginResult, error) {
+	start := time.Now()
+	output, err := p.run(ctx, socksAddr, protocol)
+	result := &models.PluginResult{Duration: time.Since(start)}
+	if err == nil && !json.Valid(output) {
+		err = fmt.Errorf("plugin %s printed invalid JSON", p.plugin.Name)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.Output = json.RawMessage(output)
+	return result, nil
+}
+
+// run executes the command through the system shell and returns its
+// trimmed standard output
+func (p *PluginChecker) run(ctx context.Context, socksAddr string, protocol *models.Protocol) ([]byte, error) {
+	input, err := json.Marshal(protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := p.plugin.Timeout
+	if timeout <= 0 {
+		timeout = defaultPluginTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", p.plugin.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", p.plugin.Command)
+	}
+	cmd.Env = append(os.Environ(),
+		"PROTOSCOPE_SOCKS="+socksAddr,
+		"PROTOSCOPE_PLUGIN="+p.plugin.Name,
+		"PROTOSCOPE_NODE="+protocol.Name,
+	)
+	cmd.Stdin = bytes.NewReader(input)
+	// Children of the shell may keep the output open after it is killed
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("plugin %s timed out after %s", p.plugin.Name, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("plugin %s failed: %w: %s", p.plugin.Name, err, msg)
+		}
+		return nil, fmt.Errorf("plugin %s failed: %w", p.plugin.Name, err)
+	}
+
+	return bytes.TrimSpace(stdout.Bytes()), nil
+}
@@ -0,0 +1,55 @@
+package checks
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestPluginChecker(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin commands below need a POSIX shell")
+	}
+	protocol := &models.Protocol{Type: models.ProtocolVLESS, Name: "NL-01", Server: "nl.example.com", Port: 443}
+
+	// The plugin sees the SOCKS address and the node on stdin
+	echo := NewPluginChecker(models.PluginConfig{
+		Name:    "echo",
+		Command: `printf '{"socks":"%s","node":' "$PROTOSCOPE_SOCKS"; cat; printf '}'`,
+	})
+	result, err := echo.Check(context.Background(), "127.0.0.1:1080", protocol)
+	if err != nil {
+		t.Fatalf("Check returned %v", err)
+	}
+	var output struct {
+		SOCKS string          `json:"socks"`
+		Node  models.Protocol `json:"node"`
+	}
+	if err := json.Unmarshal(result.Output, &output); err != nil {
+		t.Fatalf("plugin output %s: %v", result.Output, err)
+	}
+	if output.SOCKS != "127.0.0.1:1080" || output.Node.Server != "nl.example.com" {
+		t.Errorf("plugin saw %+v", output)
+	}
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"echo 'geo blocked' >&2; exit 3", "geo blocked"},
+		{"echo not json", "invalid JSON"},
+		{"sleep 5", "timed out"},
+	}
+	for _, tt := range tests {
+		checker := NewPluginChecker(models.PluginConfig{Name: "broken", Command: tt.command, Timeout: 200 * time.Millisecond})
+		result, err := checker.Check(context.Background(), "127.0.0.1:1080", protocol)
+		if err == nil || !strings.Contains(result.Error, tt.want) {
+			t.Errorf("%q: error %v, want %q", tt.command, err, tt.want)
+		}
+	}
+}
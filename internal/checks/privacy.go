@@ -5,15 +5,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
 
 	"github.com/VenoMexx/ProtoScope/pkg/models"
 )
 
+// defaultIPCheckEndpoints are used when no endpoint pool is configured
+var defaultIPCheckEndpoints = []string{
+	"https://api.ipify.org",
+	"https://ifconfig.me/ip",
+	"https://icanhazip.com",
+	"https://api.myip.com",
+}
+
 // PrivacyChecker tests privacy and security
 type PrivacyChecker struct {
 	realIP string
+	ipPool *EndpointPool
 }
 
 // NewPrivacyChecker creates a new privacy checker
@@ -23,7 +33,14 @@ func NewPrivacyChecker(realIP string) *PrivacyChecker {
 	}
 }
 
-// Check performs complete privacy tests
+// SetIPCheckPool makes the checker rotate among the pool's IP-check
+// endpoints and report their health back to it
+func (p *PrivacyChecker) SetIPCheckPool(pool *EndpointPool) {
+	p.ipPool = pool
+}
+
+// Check performs complete privacy tests. When ctx ends first, the leaks
+// tested so far are returned with its error, without a score.
 func (p *PrivacyChecker) Check(ctx context.Context, client *http.Client) (*models.PrivacyResult, error) {
 	result := &models.PrivacyResult{
 		Exposed: []string{},
@@ -35,6 +52,9 @@ func (p *PrivacyChecker) Check(ctx context.Context, client *http.Client) (*model
 		return nil, fmt.Errorf("failed to get proxy IP: %w", err)
 	}
 	result.ProxyIP = proxyIP
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
 
 	// Store real IP if provided
 	if p.realIP != "" {
@@ -55,6 +75,10 @@ func (p *PrivacyChecker) Check(ctx context.Context, client *http.Client) (*model
 		result.Exposed = append(result.Exposed, "WebRTC")
 	}
 
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+
 	// Check IPv6 leak
 	ipv6Leak := p.CheckIPv6Leak(ctx, client)
 	result.IPv6Leak = ipv6Leak
@@ -62,6 +86,10 @@ func (p *PrivacyChecker) Check(ctx context.Context, client *http.Client) (*model
 		result.Exposed = append(result.Exposed, "IPv6")
 	}
 
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+
 	// Calculate security score
 	result.Score = p.calculateSecurityScore(result)
 
@@ -70,17 +98,35 @@ func (p *PrivacyChecker) Check(ctx context.Context, client *http.Client) (*model
 
 // GetPublicIP gets the public IP address through the proxy
 func (p *PrivacyChecker) GetPublicIP(ctx context.Context, client *http.Client) (string, error) {
-	endpoints := []string{
-		"https://api.ipify.org",
-		"https://ifconfig.me/ip",
-		"https://icanhazip.com",
-		"https://api.myip.com",
+	endpoints := defaultIPCheckEndpoints
+	if p.ipPool != nil {
+		endpoints = p.ipPool.Endpoints()
 	}
 
+	// A dead node fails every endpoint, so failures are only charged to
+	// the pool once another endpoint answered through the same node
+	type failure struct {
+		endpoint string
+		err      error
+	}
+	var failures []failure
 	for _, endpoint := range endpoints {
 		ip, err := p.fetchIP(ctx, client, endpoint)
-		if err == nil && ip != "" {
-			return strings.TrimSpace(ip), nil
+		if err == nil && net.ParseIP(ip) == nil {
+			err = fmt.Errorf("invalid IP in response: %q", ip)
+		}
+		if err == nil {
+			if p.ipPool != nil {
+				for _, f := range failures {
+					p.ipPool.Report(f.endpoint, f.err)
+				}
+				p.ipPool.Report(endpoint, nil)
+			}
+			return ip, nil
+		}
+		failures = append(failures, failure{endpoint, err})
+		if ctx.Err() != nil {
+			break
 		}
 	}
 
@@ -114,11 +160,11 @@ func (p *PrivacyChecker) fetchIP(ctx context.Context, client *http.Client, endpo
 		IP string `json:"ip"`
 	}
 	if err := json.Unmarshal(body, &jsonResp); err == nil && jsonResp.IP != "" {
-		return jsonResp.IP, nil
+		return strings.TrimSpace(jsonResp.IP), nil
 	}
 
 	// Otherwise return as plain text
-	return string(body), nil
+	return strings.TrimSpace(string(body)), nil
 }
 
 // CheckDNSLeak checks for DNS leaks
@@ -154,6 +200,9 @@ func (p *PrivacyChecker) CheckWebRTCLeak(ctx context.Context, client *http.Clien
 
 		resp, err := client.Do(req)
 		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
 			continue
 		}
 		defer resp.Body.Close()
@@ -188,6 +237,9 @@ func (p *PrivacyChecker) CheckIPv6Leak(ctx context.Context, client *http.Client)
 
 		resp, err := client.Do(req)
 		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
 			continue
 		}
 		defer resp.Body.Close()
@@ -232,35 +284,14 @@ func (p *PrivacyChecker) calculateSecurityScore(result *models.PrivacyResult) in
 	return score
 }
 
-// GetRealIP gets the real IP (without proxy)
-func GetRealIP(ctx context.Context) (string, error) {
-	client := &http.Client{}
-
-	endpoints := []string{
-		"https://api.ipify.org",
-		"https://ifconfig.me/ip",
-	}
-
-	for _, endpoint := range endpoints {
-		req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
-		if err != nil {
-			continue
-		}
-
-		resp, err := client.Do(req)
-		if err != nil {
-			continue
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode == http.StatusOK {
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				continue
-			}
-			return strings.TrimSpace(string(body)), nil
-		}
+// GetRealIP gets the real IP (without proxy) using a direct client.
+// When pool is non-nil its endpoints are used and their health is tracked.
+func GetRealIP(ctx context.Context, client *http.Client, pool *EndpointPool) (string, error) {
+	checker := &PrivacyChecker{ipPool: pool}
+	ip, err := checker.GetPublicIP(ctx, client)
+	if err != nil {
+		return "", fmt.Errorf("failed to get real IP")
 	}
 
-	return "", fmt.Errorf("failed to get real IP")
+	return ip, nil
 }
@@ -0,0 +1,75 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// TestPrivacyCheckKeepsPartialResult returns the exit IP found before the
+// deadline along with the error
+func TestPrivacyCheckKeepsPartialResult(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		// The deadline passes once the exit IP is known
+		cancel()
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("203.0.113.7")), Request: req}, nil
+	})}
+
+	result, err := NewPrivacyChecker("198.51.100.1").Check(ctx, client)
+	if err != context.Canceled {
+		t.Fatalf("error %v, want context.Canceled", err)
+	}
+	if result == nil || result.ProxyIP != "203.0.113.7" {
+		t.Fatalf("result %+v, want the exit IP measured before the deadline", result)
+	}
+}
+
+// TestGetPublicIPChargesEndpointsOfLiveNodes leaves the endpoints alone
+// when the node itself is dead
+func TestGetPublicIPChargesEndpointsOfLiveNodes(t *testing.T) {
+	pool := NewEndpointPool([]string{"https://a.test", "https://b.test"})
+	checker := NewPrivacyChecker("")
+	checker.SetIPCheckPool(pool)
+
+	dead := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})}
+	for i := 0; i < endpointMaxConsecutiveFailures; i++ {
+		if _, err := checker.GetPublicIP(context.Background(), dead); err == nil {
+			t.Fatal("dead node returned an IP")
+		}
+	}
+	for _, stats := range pool.Stats() {
+		if stats.Attempts != 0 || stats.Quarantined {
+			t.Errorf("dead node charged to %+v", stats)
+		}
+	}
+
+	// a.test is down while b.test answers through the same node
+	live := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "a.test" {
+			return nil, errors.New("timeout")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("203.0.113.7")), Request: req}, nil
+	})}
+	for i := 0; i < 2; i++ {
+		if _, err := checker.GetPublicIP(context.Background(), live); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := pool.Stats()
+	if stats[0].Failures != 1 || stats[1].Failures != 0 || stats[1].Attempts != 2 {
+		t.Errorf("stats %+v, want a.test's one failure and two answers from b.test", stats)
+	}
+}
@@ -0,0 +1,92 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sort"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// proxyList is one loaded detection list
+type proxyList struct {
+	name     string
+	category string
+	prefixes []netip.Prefix
+}
+
+// ProxyLists matches IPs against public Tor, VPN, proxy and datacenter
+// lists, the kind of data sites use to decide a visitor is behind a proxy
+type ProxyLists struct {
+	lists []proxyList
+}
+
+// LoadProxyLists fetches the detection lists. Lists that fail are reported
+// and left out.
+func LoadProxyLists(ctx context.Context, client *http.Client, sources []models.ProxyListSource) (*ProxyLists, []error) {
+	p := &ProxyLists{}
+	var errs []error
+
+	for _, source := range sources {
+		body, err := fetchFeed(ctx, client, source.URL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name, err))
+			continue
+		}
+
+		list := proxyList{name: source.Name, category: source.Category}
+		parsePrefixLines(body, func(prefix netip.Prefix, _ string) {
+			list.prefixes = append(list.prefixes, prefix)
+		})
+		if len(list.prefixes) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no addresses in list", source.Name))
+			continue
+		}
+		p.lists = append(p.lists, list)
+	}
+
+	return p, errs
+}
+
+// Check reports which lists contain ip. Being on any list, or hosted by a
+// known provider, makes the IP likely to be flagged as a proxy.
+func (p *ProxyLists) Check(ip string, hosting *models.HostingInfo) *models.ProxyDetectionResult {
+	result := &models.ProxyDetectionResult{}
+	if p != nil {
+		result.ListsChecked = len(p.lists)
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return result
+	}
+	addr = addr.Unmap()
+
+	categories := make(map[string]bool)
+	if p != nil {
+		for _, list := range p.lists {
+			for _, prefix := range list.prefixes {
+				if prefix.Contains(addr) {
+					result.Lists = append(result.Lists, list.name)
+					if list.category != "" {
+						categories[list.category] = true
+					}
+					break
+				}
+			}
+		}
+	}
+	if hosting != nil {
+		categories["datacenter"] = true
+	}
+
+	for category := range categories {
+		result.Categories = append(result.Categories, category)
+	}
+	sort.Strings(result.Categories)
+
+	result.LikelyFlagged = len(result.Lists) > 0 || hosting != nil
+	return result
+}
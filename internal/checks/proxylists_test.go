@@ -0,0 +1,61 @@
+package checks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestProxyLists(t *testing.T) {
+	lists := map[string]string{
+		"/tor":     "185.220.101.1\n185.220.101.2\n",
+		"/vpn":     "# X4BNet VPN ranges\n198.51.100.0/24\n",
+		"/proxies": "; FireHOL\n198.51.100.128/25 # open proxies\n2001:db8::/32\n",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		list, ok := lists[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(list))
+	}))
+	defer srv.Close()
+
+	p, errs := LoadProxyLists(context.Background(), srv.Client(), []models.ProxyListSource{
+		{Name: "tor-exits", URL: srv.URL + "/tor", Category: "tor"},
+		{Name: "vpn", URL: srv.URL + "/vpn", Category: "vpn"},
+		{Name: "proxies", URL: srv.URL + "/proxies", Category: "proxy"},
+		{Name: "gone", URL: srv.URL + "/gone", Category: "proxy"},
+	})
+	if len(errs) != 1 {
+		t.Errorf("got %d list errors, want 1 for the missing list: %v", len(errs), errs)
+	}
+
+	tests := []struct {
+		ip         string
+		hosting    *models.HostingInfo
+		lists      []string
+		categories []string
+		flagged    bool
+	}{
+		{"185.220.101.2", nil, []string{"tor-exits"}, []string{"tor"}, true},
+		{"198.51.100.200", nil, []string{"vpn", "proxies"}, []string{"proxy", "vpn"}, true},
+		{"2001:db8::1", nil, []string{"proxies"}, []string{"proxy"}, true},
+		{"192.0.2.10", &models.HostingInfo{Provider: "AWS"}, nil, []string{"datacenter"}, true},
+		{"192.0.2.10", nil, nil, nil, false},
+	}
+	for _, tt := range tests {
+		result := p.Check(tt.ip, tt.hosting)
+		if !slices.Equal(result.Lists, tt.lists) || !slices.Equal(result.Categories, tt.categories) || result.LikelyFlagged != tt.flagged {
+			t.Errorf("%s: got lists %v categories %v flagged %v", tt.ip, result.Lists, result.Categories, result.LikelyFlagged)
+		}
+		if result.ListsChecked != 3 {
+			t.Errorf("%s: lists checked = %d, want 3", tt.ip, result.ListsChecked)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// What gave a fraud block away, besides its status code
+const (
+	// FraudChallenge is a bot challenge page, such as Cloudflare's
+	FraudChallenge = "challenge"
+	// FraudCaptcha is a page asking to solve a captcha before going on
+	FraudCaptcha = "captcha"
+	// FraudDenied is an access denied page of the site's firewall
+	FraudDenied = "denied"
+)
+
+// fraudMarker is text of a fraud block page, matched case-insensitively.
+// Login pages embed captcha and bot protection scripts too, so pages that
+// answer with a success status only count as blocked on strong markers.
+type fraudMarker struct {
+	text   string
+	reason string
+	strong bool
+}
+
+// fraudMarkers give away the block pages of common bot and fraud
+// protections: Cloudflare, Akamai, Imperva and PerimeterX
+var fraudMarkers = []fraudMarker{
+	{"just a moment...", FraudChallenge, true},
+	{"_cf_chl_opt", FraudChallenge, true},
+	{"pardon our interruption", FraudChallenge, true},
+	{"request unsuccessful. incapsula", FraudDenied, true},
+	{"you have been blocked", FraudDenied, true},
+	{"px-captcha", FraudCaptcha, false},
+	{"are you a robot", FraudCaptcha, false},
+	{"captcha", FraudCaptcha, false},
+	{"access denied", FraudDenied, false},
+	{"unusual activity", FraudDenied, false},
+}
+
+// maxSensitiveBody is how much of a page is read to look for fraud markers
+const maxSensitiveBody = 256 << 10
+
+// SensitiveChecker tests access to banking and government sites, which
+// often block proxy and datacenter exits
+type SensitiveChecker struct {
+	timeout time.Duration // per site
+}
+
+// NewSensitiveChecker creates a new sensitive sites checker
+func NewSensitiveChecker(timeout time.Duration) *SensitiveChecker {
+	return &SensitiveChecker{
+		timeout: timeout,
+	}
+}
+
+// Check requests every site through client at once. A site is reachable
+// when it answers without a fraud block: a bot challenge, captcha or access
+// denied page, or a 403, 429 or 451 status.
+func (s *SensitiveChecker) Check(ctx context.Context, client *http.Client, sites []models.SensitiveSite) (*models.SensitiveSitesResult, error) {
+	result := &models.SensitiveSitesResult{
+		Sites: make([]models.SensitiveSiteAccess, len(sites)),
+	}
+
+	var wg sync.WaitGroup
+	for i, site := range sites {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result.Sites[i] = s.checkSite(ctx, client, site)
+		}()
+	}
+	wg.Wait()
+
+	for _, site := range result.Sites {
+		if site.Reachable {
+			result.Reachable++
+		}
+		if site.FraudBlock != "" {
+			result.FraudBlocked++
+		}
+	}
+
+	return result, ctx.Err()
+}
+
+// checkSite requests a single site
+func (s *SensitiveChecker) checkSite(ctx context.Context, client *http.Client, site models.SensitiveSite) models.SensitiveSiteAccess {
+	access := models.SensitiveSiteAccess{
+		Name:     site.Name,
+		Category: site.Category,
+		URL:      site.URL,
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, "GET", site.URL, nil)
+	if err != nil {
+		access.Error = err.Error()
+		return access
+	}
+	// Bot protections challenge clients that don't look like a browser
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		access.Latency = time.Since(start)
+		access.Error = err.Error()
+		return access
+	}
+	defer resp.Body.Close()
+	access.Latency = time.Since(start)
+	access.StatusCode = resp.StatusCode
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxSensitiveBody))
+	access.FraudBlock = fraudBlock(resp, body)
+	switch {
+	case access.FraudBlock != "":
+	case resp.StatusCode >= 500:
+		access.Error = fmt.Sprintf("http %d", resp.StatusCode)
+	default:
+		access.Reachable = true
+	}
+	return access
+}
+
+// fraudBlock tells what gives a response away as a fraud block, or ""
+func fraudBlock(resp *http.Response, body []byte) string {
+	if resp.Header.Get("Cf-Mitigated") == "challenge" {
+		return FraudChallenge
+	}
+
+	blockStatus := resp.StatusCode == http.StatusForbidden ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusUnavailableForLegalReasons
+	success := !blockStatus && resp.StatusCode < 500
+
+	body = bytes.ToLower(body)
+	for _, marker := range fraudMarkers {
+		if (marker.strong || !success) && bytes.Contains(body, []byte(marker.text)) {
+			return marker.reason
+		}
+	}
+	if blockStatus {
+		return fmt.Sprintf("http %d", resp.StatusCode)
+	}
+	return ""
+}
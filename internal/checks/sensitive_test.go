@@ -0,0 +1,66 @@
+package checks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestSensitiveSites(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			// A login page loading a captcha widget is not a block
+			w.Write([]byte(`<html><title>Log in</title><script src="/recaptcha/api.js"></script></html>`))
+		case "/challenge":
+			w.Header().Set("Cf-Mitigated", "challenge")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<title>Just a moment...</title>"))
+		case "/akamai":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<H1>Access Denied</H1> Reference #18.1234"))
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/imperva":
+			w.Write([]byte("<html>Request unsuccessful. Incapsula incident ID: 1234</html>"))
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path      string
+		reachable bool
+		block     string
+	}{
+		{"/login", true, ""},
+		{"/challenge", false, FraudChallenge},
+		{"/akamai", false, FraudDenied},
+		{"/forbidden", false, "http 403"},
+		{"/imperva", false, FraudDenied},
+		{"/down", false, ""},
+	}
+	sites := make([]models.SensitiveSite, len(tests))
+	for i, tt := range tests {
+		sites[i] = models.SensitiveSite{Name: tt.path, URL: server.URL + tt.path}
+	}
+
+	result, err := NewSensitiveChecker(time.Second).Check(context.Background(), server.Client(), sites)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		site := result.Sites[i]
+		if site.Reachable != tt.reachable || site.FraudBlock != tt.block {
+			t.Errorf("%s: reachable = %v, fraud block = %q, want %v, %q", tt.path, site.Reachable, site.FraudBlock, tt.reachable, tt.block)
+		}
+	}
+	if result.Reachable != 1 || result.FraudBlocked != 4 {
+		t.Errorf("reachable = %d, fraud blocked = %d, want 1, 4", result.Reachable, result.FraudBlocked)
+	}
+}
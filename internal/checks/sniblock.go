@@ -0,0 +1,102 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// sniProbeTimeout bounds each TLS handshake of an SNI probe
+const sniProbeTimeout = 5 * time.Second
+
+// SetDialer makes blocking checks also probe each domain with TLS
+// handshakes through dialer, the node's proxy, telling the node's DNS
+// blocking apart from filtering by SNI or by IP
+func (d *DNSChecker) SetDialer(dialer proxy.Dialer) {
+	d.dialer = dialer
+}
+
+// probeSNI returns how the node blocks host, or "" when a handshake by
+// name gets through. addrs are host's addresses resolved locally, so they
+// reach the exit as IP literals that its DNS cannot block. An IPv4 address
+// is probed where host has one:
+//   - by name blocked, IP with SNI answers: the node's DNS blocks the name
+//   - IP with SNI blocked, IP without SNI answers: filtered by SNI
+//   - IP blocked either way: filtered by IP
+//
+// The local SOCKS inbound accepts connections before the exit connects, so
+// only a completed handshake counts as reachable. The error of the failed
+// handshake is returned with the block type.
+func (d *DNSChecker) probeSNI(ctx context.Context, host string, addrs []string) (string, error) {
+	err := d.handshake(ctx, host, host)
+	if err == nil || ctx.Err() != nil {
+		return "", nil
+	}
+	if len(addrs) == 0 {
+		return "DNS", err
+	}
+
+	addr, ipv4 := probeAddress(addrs)
+	sniErr := d.handshake(ctx, addr, host)
+	if sniErr == nil {
+		return "DNS", err
+	}
+	if !ipv4 {
+		// Many exits have no IPv6 at all, so an IPv6 address failing says
+		// nothing about how the name is blocked
+		return "", nil
+	}
+	if d.handshake(ctx, addr, "") == nil {
+		return "SNI", sniErr
+	}
+	return "IP", sniErr
+}
+
+// probeAddress picks the address to probe by IP: the first IPv4 one, as
+// every exit has IPv4, else the first one. ipv4 reports which it is.
+func probeAddress(addrs []string) (addr string, ipv4 bool) {
+	for _, a := range addrs {
+		if ip := net.ParseIP(a); ip != nil && ip.To4() != nil {
+			return a, true
+		}
+	}
+	return addrs[0], false
+}
+
+// handshake dials target:443 through the node and completes a TLS
+// handshake sending serverName, or no SNI when it is empty. Certificates
+// are not verified: only whether the server answers matters.
+func (d *DNSChecker) handshake(ctx context.Context, target, serverName string) error {
+	probeCtx, cancel := context.WithTimeout(ctx, sniProbeTimeout)
+	defer cancel()
+
+	address := net.JoinHostPort(target, "443")
+	var conn net.Conn
+	var err error
+	if contextDialer, ok := d.dialer.(proxy.ContextDialer); ok {
+		conn, err = contextDialer.DialContext(probeCtx, "tcp", address)
+	} else {
+		conn, err = d.dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	return tlsConn.HandshakeContext(probeCtx)
+}
+
+// domainHost strips the path some blocking test entries carry, as in
+// facebook.com/tr
+func domainHost(domain string) string {
+	host, _, _ := strings.Cut(domain, "/")
+	return host
+}
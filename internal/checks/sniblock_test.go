@@ -0,0 +1,91 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// exitDialer simulates an exit in front of one TLS server: refused hosts
+// fail to dial, as when the exit's DNS has no answer or drops the IP
+type exitDialer struct {
+	server  string
+	refused map[string]bool
+}
+
+func (d *exitDialer) Dial(network, addr string) (net.Conn, error) {
+	host, _, _ := net.SplitHostPort(addr)
+	if d.refused[host] {
+		return nil, errors.New("connection refused")
+	}
+	return net.Dial(network, d.server)
+}
+
+func TestProbeSNI(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			if hello.ServerName == "sni.test" {
+				return nil, errors.New("filtered")
+			}
+			return nil, nil
+		},
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	address := server.Listener.Addr().String()
+
+	tests := []struct {
+		name    string
+		host    string
+		refused map[string]bool
+		want    string
+	}{
+		{"reachable", "ok.test", nil, ""},
+		{"node DNS", "dns.test", map[string]bool{"dns.test": true}, "DNS"},
+		{"SNI filter", "sni.test", nil, "SNI"},
+		{"IP filter", "ip.test", map[string]bool{"ip.test": true, "192.0.2.1": true}, "IP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewDNSChecker(time.Second)
+			checker.SetDialer(&exitDialer{server: address, refused: tt.refused})
+			blockType, err := checker.probeSNI(context.Background(), tt.host, []string{"192.0.2.1"})
+			if blockType != tt.want {
+				t.Fatalf("block type = %q (%v), want %q", blockType, err, tt.want)
+			}
+			if blockType != "" && err == nil {
+				t.Error("expected the handshake error with the block type")
+			}
+		})
+	}
+}
+
+// TestProbeSNIPrefersIPv4 probes by an IPv4 address and doesn't call an
+// IPv6-only host IP-blocked on an exit that may lack IPv6
+func TestProbeSNIPrefersIPv4(t *testing.T) {
+	checker := NewDNSChecker(time.Second)
+	refused := map[string]bool{"ip.test": true, "2001:db8::1": true, "192.0.2.1": true}
+	checker.SetDialer(&exitDialer{server: "127.0.0.1:1", refused: refused})
+
+	if blockType, _ := checker.probeSNI(context.Background(), "ip.test", []string{"2001:db8::1", "192.0.2.1"}); blockType != "IP" {
+		t.Errorf("block type = %q, want IP from the IPv4 address", blockType)
+	}
+	if blockType, _ := checker.probeSNI(context.Background(), "ip.test", []string{"2001:db8::1"}); blockType != "" {
+		t.Errorf("block type = %q for an IPv6-only host, want none", blockType)
+	}
+}
+
+func TestDomainHost(t *testing.T) {
+	if got := domainHost("facebook.com/tr"); got != "facebook.com" {
+		t.Errorf("domainHost = %q, want facebook.com", got)
+	}
+}
@@ -0,0 +1,287 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Streaming and AI services the streaming check probes
+const (
+	ServiceNetflix        = "Netflix"
+	ServiceDisneyPlus     = "Disney+"
+	ServiceYouTubePremium = "YouTube Premium"
+	ServiceChatGPT        = "ChatGPT"
+)
+
+// streamingURLs are the region detection endpoints of the services
+type streamingURLs struct {
+	// netflixTitle is licensed, not a Netflix original, so only full
+	// catalogues have it; netflixOriginal is in every catalogue
+	netflixTitle    string
+	netflixOriginal string
+	disney          string
+	youtubePremium  string
+	chatGPTTrace    string // Cloudflare trace of chatgpt.com, for the region
+	chatGPTCountry  string // OpenAI's cookie requirements, which name unsupported countries
+	chatGPTApp      string // the iOS app's endpoint, which turns VPNs away
+}
+
+var defaultStreamingURLs = streamingURLs{
+	netflixTitle:    "https://www.netflix.com/title/70143836",
+	netflixOriginal: "https://www.netflix.com/title/80018499",
+	disney:          "https://www.disneyplus.com/",
+	youtubePremium:  "https://www.youtube.com/premium",
+	chatGPTTrace:    "https://chatgpt.com/cdn-cgi/trace",
+	chatGPTCountry:  "https://api.openai.com/compliance/cookie_requirements",
+	chatGPTApp:      "https://ios.chat.openai.com/",
+}
+
+// maxStreamingBody is how much of a page is read to find the region
+const maxStreamingBody = 1 << 20
+
+var (
+	// netflixLocale is the country of a regional Netflix URL such as
+	// /jp/title/... or /de-en/title/...
+	netflixLocale = regexp.MustCompile(`^/([a-z]{2})(-[a-z]{2})?/`)
+	netflixRegion = regexp.MustCompile(`"requestCountry":\{[^}]*"id":"([A-Z]{2})"`)
+	disneyRegion  = regexp.MustCompile(`Region: ([A-Z]{2})`)
+	// disneyLocale is the country of a regional Disney+ URL such as /en-gb/
+	disneyLocale  = regexp.MustCompile(`^/[a-z]{2}-([a-z]{2})(/|$)`)
+	youtubeRegion = regexp.MustCompile(`"INNERTUBE_CONTEXT_GL"\s*:\s*"([A-Z]{2})"`)
+	traceLocation = regexp.MustCompile(`(?m)^loc=([A-Z]{2})$`)
+)
+
+// StreamingChecker tells which streaming and AI services serve the exit,
+// and which region they detected, from the pages and APIs the services use
+// to pick a catalogue or turn unsupported countries away
+type StreamingChecker struct {
+	timeout time.Duration // per request
+	urls    streamingURLs
+}
+
+// NewStreamingChecker creates a new streaming unlock checker
+func NewStreamingChecker(timeout time.Duration) *StreamingChecker {
+	return &StreamingChecker{
+		timeout: timeout,
+		urls:    defaultStreamingURLs,
+	}
+}
+
+// Check probes every service through client at once
+func (s *StreamingChecker) Check(ctx context.Context, client *http.Client) (*models.StreamingResult, error) {
+	checks := []func(context.Context, *http.Client) models.StreamingService{
+		s.checkNetflix,
+		s.checkDisney,
+		s.checkYouTubePremium,
+		s.checkChatGPT,
+	}
+	result := &models.StreamingResult{
+		Services: make([]models.StreamingService, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result.Services[i] = check(ctx, client)
+		}()
+	}
+	wg.Wait()
+
+	for _, service := range result.Services {
+		if service.Unlocked {
+			result.Unlocked++
+		}
+	}
+	return result, ctx.Err()
+}
+
+// checkNetflix tells a full catalogue from one of Netflix originals only:
+// licensed titles are missing from the latter
+func (s *StreamingChecker) checkNetflix(ctx context.Context, client *http.Client) models.StreamingService {
+	service := models.StreamingService{Name: ServiceNetflix}
+
+	resp, body, err := s.get(ctx, client, s.urls.netflixTitle)
+	if err != nil {
+		service.Error = err.Error()
+		return service
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		service.Unlocked = true
+		service.Region = netflixCountry(resp, body)
+		return service
+	case http.StatusForbidden:
+		service.Detail = "blocked"
+		return service
+	case http.StatusNotFound:
+	default:
+		service.Error = fmt.Sprintf("http %d", resp.StatusCode)
+		return service
+	}
+
+	resp, body, err = s.get(ctx, client, s.urls.netflixOriginal)
+	if err != nil {
+		service.Error = err.Error()
+		return service
+	}
+	if resp.StatusCode == http.StatusOK {
+		service.Detail = "originals only"
+		service.Region = netflixCountry(resp, body)
+	} else {
+		service.Detail = "blocked"
+	}
+	return service
+}
+
+// netflixCountry reads the country from the page, or from the regional URL
+// Netflix redirected to. US pages have no regional URL, so without either
+// the region is unknown and "" is returned.
+func netflixCountry(resp *http.Response, body []byte) string {
+	if m := netflixRegion.FindSubmatch(body); m != nil {
+		return string(m[1])
+	}
+	if m := netflixLocale.FindStringSubmatch(resp.Request.URL.Path); m != nil {
+		return strings.ToUpper(m[1])
+	}
+	return ""
+}
+
+// checkDisney follows the home page to the region's site; unsupported
+// regions land on an unavailable page
+func (s *StreamingChecker) checkDisney(ctx context.Context, client *http.Client) models.StreamingService {
+	service := models.StreamingService{Name: ServiceDisneyPlus}
+
+	resp, body, err := s.get(ctx, client, s.urls.disney)
+	if err != nil {
+		service.Error = err.Error()
+		return service
+	}
+	final := resp.Request.URL
+	switch {
+	case strings.Contains(final.Host+final.Path, "unavailable") || strings.Contains(final.Path, "unsupported"):
+		service.Detail = "not available in region"
+	case resp.StatusCode == http.StatusForbidden:
+		service.Detail = "blocked"
+	case resp.StatusCode != http.StatusOK:
+		service.Error = fmt.Sprintf("http %d", resp.StatusCode)
+		return service
+	default:
+		service.Unlocked = true
+	}
+
+	if m := disneyRegion.FindSubmatch(body); m != nil {
+		service.Region = string(m[1])
+	} else if m := disneyLocale.FindStringSubmatch(final.Path); m != nil {
+		service.Region = strings.ToUpper(m[1])
+	}
+	return service
+}
+
+// checkYouTubePremium reads the Premium page, which says so when Premium
+// is not sold in the region
+func (s *StreamingChecker) checkYouTubePremium(ctx context.Context, client *http.Client) models.StreamingService {
+	service := models.StreamingService{Name: ServiceYouTubePremium}
+
+	resp, body, err := s.get(ctx, client, s.urls.youtubePremium)
+	if err != nil {
+		service.Error = err.Error()
+		return service
+	}
+	if resp.StatusCode != http.StatusOK {
+		service.Error = fmt.Sprintf("http %d", resp.StatusCode)
+		return service
+	}
+
+	if m := youtubeRegion.FindSubmatch(body); m != nil {
+		service.Region = string(m[1])
+	}
+	page := strings.ToLower(string(body))
+	switch {
+	case strings.Contains(page, "www.google.cn"):
+		service.Region = "CN"
+		service.Detail = "not available in region"
+	case strings.Contains(page, "premium is not available in your country"):
+		service.Detail = "not available in region"
+	case strings.Contains(page, "ad-free"):
+		service.Unlocked = true
+	default:
+		service.Error = "unexpected page"
+	}
+	return service
+}
+
+// checkChatGPT takes the region from chatgpt.com's Cloudflare trace, asks
+// OpenAI whether the country is supported and whether the app accepts the
+// exit, as it turns known VPN ranges away
+func (s *StreamingChecker) checkChatGPT(ctx context.Context, client *http.Client) models.StreamingService {
+	service := models.StreamingService{Name: ServiceChatGPT}
+
+	if _, body, err := s.get(ctx, client, s.urls.chatGPTTrace); err == nil {
+		if m := traceLocation.FindSubmatch(body); m != nil {
+			service.Region = string(m[1])
+		}
+	}
+
+	resp, body, err := s.get(ctx, client, s.urls.chatGPTCountry)
+	if err != nil {
+		service.Error = err.Error()
+		return service
+	}
+	if strings.Contains(string(body), "unsupported_country") {
+		service.Detail = "not available in region"
+		return service
+	}
+	// The endpoint wants a token; without one it still names unsupported
+	// countries
+	if resp.StatusCode >= 500 {
+		service.Error = fmt.Sprintf("http %d", resp.StatusCode)
+		return service
+	}
+
+	_, body, err = s.get(ctx, client, s.urls.chatGPTApp)
+	if err != nil {
+		service.Error = err.Error()
+		return service
+	}
+	if strings.Contains(string(body), "VPN") {
+		service.Detail = "VPN detected"
+		return service
+	}
+	service.Unlocked = true
+	return service
+}
+
+// get requests url like a browser and reads the start of the page
+func (s *StreamingChecker) get(ctx context.Context, client *http.Client, url string) (*http.Response, []byte, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, "GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The services serve other pages to clients that don't look like a
+	// browser, and the region's language otherwise
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36")
+	req.Header.Set("Accept-Language", "en")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxStreamingBody))
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
@@ -0,0 +1,116 @@
+package checks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestStreaming(t *testing.T) {
+	// locked switches the services to answering an exit they turn away
+	locked := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/netflix/title/70143836":
+			if locked {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			http.Redirect(w, r, "/jp/title/70143836", http.StatusFound)
+		case "/netflix/title/80018499":
+			w.Write([]byte(`{"requestCountry":{"supportedLocales":[],"id":"BR"}}`))
+		case "/jp/title/70143836":
+			w.Write([]byte("<html>Breaking Bad</html>"))
+		case "/disney/":
+			if locked {
+				http.Redirect(w, r, "/unavailable/", http.StatusFound)
+				return
+			}
+			http.Redirect(w, r, "/en-jp/home", http.StatusFound)
+		case "/unavailable/", "/en-jp/home":
+			w.Write([]byte("<html>Disney+</html>"))
+		case "/youtube/premium":
+			if locked {
+				w.Write([]byte(`"INNERTUBE_CONTEXT_GL":"BR" YouTube Premium is not available in your country`))
+				return
+			}
+			w.Write([]byte(`"INNERTUBE_CONTEXT_GL": "JP" Ad-free videos`))
+		case "/chatgpt/trace":
+			w.Write([]byte("fl=1\nloc=JP\ntls=TLSv1.3\n"))
+		case "/chatgpt/cookies":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":"invalid_api_key"}}`))
+		case "/chatgpt/app":
+			if locked {
+				w.Write([]byte("Something went wrong. You may be connected to a disallowed ISP. If you are using VPN, try disabling it."))
+				return
+			}
+			w.Write([]byte("<html>ChatGPT</html>"))
+		}
+	}))
+	defer server.Close()
+
+	checker := NewStreamingChecker(time.Second)
+	checker.urls = streamingURLs{
+		netflixTitle:    server.URL + "/netflix/title/70143836",
+		netflixOriginal: server.URL + "/netflix/title/80018499",
+		disney:          server.URL + "/disney/",
+		youtubePremium:  server.URL + "/youtube/premium",
+		chatGPTTrace:    server.URL + "/chatgpt/trace",
+		chatGPTCountry:  server.URL + "/chatgpt/cookies",
+		chatGPTApp:      server.URL + "/chatgpt/app",
+	}
+
+	tests := []struct {
+		locked bool
+		want   []models.StreamingService
+	}{
+		{false, []models.StreamingService{
+			{Name: ServiceNetflix, Unlocked: true, Region: "JP"},
+			{Name: ServiceDisneyPlus, Unlocked: true, Region: "JP"},
+			{Name: ServiceYouTubePremium, Unlocked: true, Region: "JP"},
+			{Name: ServiceChatGPT, Unlocked: true, Region: "JP"},
+		}},
+		{true, []models.StreamingService{
+			{Name: ServiceNetflix, Region: "BR", Detail: "originals only"},
+			{Name: ServiceDisneyPlus, Detail: "not available in region"},
+			{Name: ServiceYouTubePremium, Region: "BR", Detail: "not available in region"},
+			{Name: ServiceChatGPT, Region: "JP", Detail: "VPN detected"},
+		}},
+	}
+	for _, tt := range tests {
+		locked = tt.locked
+		result, err := checker.Check(context.Background(), server.Client())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, want := range tt.want {
+			if got := result.Services[i]; got != want {
+				t.Errorf("locked=%v: %+v, want %+v", tt.locked, got, want)
+			}
+		}
+		if want := map[bool]int{false: 4, true: 0}[tt.locked]; result.Unlocked != want {
+			t.Errorf("locked=%v: %d unlocked, want %d", tt.locked, result.Unlocked, want)
+		}
+	}
+}
+
+func TestNetflixCountry(t *testing.T) {
+	tests := []struct {
+		path, body, want string
+	}{
+		{"/title/80018499", `"requestCountry":{"supportedLocales":[],"id":"DE"}`, "DE"},
+		{"/jp-en/title/80018499", "", "JP"},
+		{"/title/80018499", "", ""},
+	}
+	for _, tt := range tests {
+		request := httptest.NewRequest(http.MethodGet, "https://www.netflix.com"+tt.path, nil)
+		if got := netflixCountry(&http.Response{Request: request}, []byte(tt.body)); got != tt.want {
+			t.Errorf("%s %q: got %q, want %q", tt.path, tt.body, got, tt.want)
+		}
+	}
+}
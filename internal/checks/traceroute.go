@@ -0,0 +1,249 @@
+package checks
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// TraceChecker traces the path to a node server with ICMP echo probes of
+// increasing TTL, MTR style: each hop is probed several times so loss and
+// latency can be reported per hop
+type TraceChecker struct {
+	timeout  time.Duration // per probe
+	maxHops  int
+	probes   int
+	resolver *PingChecker
+}
+
+// NewTraceChecker creates a new traceroute checker
+func NewTraceChecker(timeout time.Duration, maxHops, probes int) *TraceChecker {
+	if maxHops < 1 {
+		maxHops = 30
+	}
+	if probes < 1 {
+		probes = 1
+	}
+	return &TraceChecker{
+		timeout:  timeout,
+		maxHops:  maxHops,
+		probes:   probes,
+		resolver: NewPingChecker(timeout, 1),
+	}
+}
+
+// maxSilentHops stops a trace after this many consecutive hops without any
+// answer; firewalls that drop ICMP would otherwise cost maxHops timeouts
+const maxSilentHops = 5
+
+// traceReply is one ICMP message matched to a probe
+type traceReply struct {
+	from    net.IP
+	reached bool // echo reply from the target rather than time exceeded
+}
+
+// Check traces the path to host directly (not through the proxy).
+// Setting the TTL of outgoing probes needs a raw ICMP socket, so this
+// requires root or CAP_NET_RAW.
+func (t *TraceChecker) Check(ctx context.Context, host string) (*models.TraceResult, error) {
+	result := &models.TraceResult{}
+
+	ip, err := t.resolver.resolve(ctx, host)
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.Address = ip.String()
+
+	network, address := "ip4:icmp", "0.0.0.0"
+	if ip.To4() == nil {
+		network, address = "ip6:ipv6-icmp", "::"
+	}
+	conn, err := icmp.ListenPacket(network, address)
+	if err != nil {
+		err = fmt.Errorf("traceroute needs a raw ICMP socket (root or CAP_NET_RAW): %w", err)
+		result.Error = err.Error()
+		return result, err
+	}
+	defer conn.Close()
+
+	id := rand.Intn(0xffff)
+	seq := 0
+	silent := 0
+
+	for ttl := 1; ttl <= t.maxHops; ttl++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		hop := models.TraceHop{TTL: ttl}
+		var rtts []time.Duration
+		reached := false
+
+		for probe := 0; probe < t.probes; probe++ {
+			seq++
+			hop.Sent++
+
+			reply, rtt, err := t.probe(ctx, conn, ip, id, seq, ttl)
+			if err != nil {
+				continue
+			}
+			hop.Received++
+			rtts = append(rtts, rtt)
+			if hop.Address == "" {
+				hop.Address = reply.from.String()
+			}
+			if reply.reached {
+				reached = true
+			}
+		}
+
+		if len(rtts) > 0 {
+			var total time.Duration
+			for _, rtt := range rtts {
+				total += rtt
+			}
+			hop.AvgRTT = total / time.Duration(len(rtts))
+			hop.Loss = float64(hop.Sent-hop.Received) / float64(hop.Sent) * 100.0
+
+			if hop.AvgRTT > result.WorstHopRTT {
+				result.WorstHopRTT = hop.AvgRTT
+				result.WorstHop = ttl
+			}
+			silent = 0
+		} else {
+			hop.Loss = 100
+			silent++
+		}
+
+		result.Hops = append(result.Hops, hop)
+
+		if reached {
+			result.Reached = true
+			result.HopCount = ttl
+			break
+		}
+		if silent >= maxSilentHops {
+			break
+		}
+	}
+
+	if !result.Reached {
+		result.Error = fmt.Sprintf("target not reached within %d hops", len(result.Hops))
+		return result, fmt.Errorf("%s", result.Error)
+	}
+
+	return result, nil
+}
+
+// probe sends one echo request with the given TTL and waits for the echo
+// reply or the time-exceeded message it triggers
+func (t *TraceChecker) probe(ctx context.Context, conn *icmp.PacketConn, ip net.IP, id, seq, ttl int) (*traceReply, time.Duration, error) {
+	var msgType icmp.Type = ipv4.ICMPTypeEcho
+	proto := protocolICMP
+	if ip.To4() == nil {
+		msgType = ipv6.ICMPTypeEchoRequest
+		proto = protocolICMPIPv6
+	}
+
+	msg := icmp.Message{
+		Type: msgType,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("protoscope-trace")},
+	}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	deadline := time.Now().Add(t.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, 0, err
+	}
+
+	dst := &net.IPAddr{IP: ip}
+	start := time.Now()
+	if ip.To4() != nil {
+		pc := conn.IPv4PacketConn()
+		if err := pc.SetTTL(ttl); err != nil {
+			return nil, 0, err
+		}
+		if _, err := pc.WriteTo(data, nil, dst); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		pc := conn.IPv6PacketConn()
+		if err := pc.SetHopLimit(ttl); err != nil {
+			return nil, 0, err
+		}
+		if _, err := pc.WriteTo(data, nil, dst); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, src, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil, 0, err
+		}
+		from := addrIP(src)
+
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil {
+			continue
+		}
+
+		switch body := reply.Body.(type) {
+		case *icmp.Echo:
+			if (reply.Type == ipv4.ICMPTypeEchoReply || reply.Type == ipv6.ICMPTypeEchoReply) && body.ID == id && body.Seq == seq {
+				return &traceReply{from: from, reached: true}, time.Since(start), nil
+			}
+		case *icmp.TimeExceeded:
+			if quotedEchoMatches(body.Data, ip.To4() != nil, id, seq) {
+				return &traceReply{from: from}, time.Since(start), nil
+			}
+		case *icmp.DstUnreach:
+			if quotedEchoMatches(body.Data, ip.To4() != nil, id, seq) {
+				return &traceReply{from: from, reached: from.Equal(ip)}, time.Since(start), nil
+			}
+		}
+	}
+}
+
+func addrIP(addr net.Addr) net.IP {
+	if ipAddr, ok := addr.(*net.IPAddr); ok {
+		return ipAddr.IP
+	}
+	return nil
+}
+
+// quotedEchoMatches checks whether the original datagram quoted in an ICMP
+// error is our echo request: IP header followed by the first 8 bytes of
+// the echo (type, code, checksum, id, seq)
+func quotedEchoMatches(quoted []byte, isIPv4 bool, id, seq int) bool {
+	headerLen := 40
+	if isIPv4 {
+		if len(quoted) < 1 {
+			return false
+		}
+		headerLen = int(quoted[0]&0x0f) * 4
+	}
+	if len(quoted) < headerLen+8 {
+		return false
+	}
+	echo := quoted[headerLen:]
+	return int(binary.BigEndian.Uint16(echo[4:6])) == id&0xffff &&
+		int(binary.BigEndian.Uint16(echo[6:8])) == seq&0xffff
+}
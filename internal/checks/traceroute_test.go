@@ -0,0 +1,32 @@
+package checks
+
+import (
+	"testing"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+func TestQuotedEchoMatches(t *testing.T) {
+	echo, err := (&icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{ID: 0x1234, Seq: 7, Data: []byte("x")},
+	}).Marshal(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 20-byte IPv4 header (IHL=5) followed by the echo header
+	quoted := append([]byte{0x45}, make([]byte, 19)...)
+	quoted = append(quoted, echo[:8]...)
+
+	if !quotedEchoMatches(quoted, true, 0x1234, 7) {
+		t.Fatal("expected quoted echo to match")
+	}
+	if quotedEchoMatches(quoted, true, 0x1234, 8) {
+		t.Fatal("expected other sequence number not to match")
+	}
+	if quotedEchoMatches(quoted[:24], true, 0x1234, 7) {
+		t.Fatal("expected truncated quote not to match")
+	}
+}
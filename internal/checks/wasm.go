@@ -0,0 +1,169 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// wasmMemoryPages caps a WASM plugin's memory at 256 MiB
+const wasmMemoryPages = 4096
+
+// wasmCache keeps compiled plugins, so a module is compiled once per run
+// rather than once per node
+var wasmCache = wazero.NewCompilationCache()
+
+// WASMPluginChecker runs a check compiled to WebAssembly in a sandbox. The
+// module is a WASI command with the same contract as an exec plugin: the
+// node's protocol as JSON on stdin and the result as JSON on stdout. It
+// sees no files, sockets or host environment; its only way out is the
+// http_get function imported from the "protoscope" module, which fetches a
+// URL through the node:
+//
+//	http_get(url_ptr, url_len, buf_ptr, buf_cap i32) i64
+//
+// writes up to buf_cap bytes of the response body to buf_ptr and returns
+// the HTTP status shifted left by 32 bits ORed with the bytes written, or
+// -1 when the request failed.
+type WASMPluginChecker struct {
+	plugin models.PluginConfig
+}
+
+// NewWASMPluginChecker creates a checker for a configured WASM plugin
+func NewWASMPluginChecker(plugin models.PluginConfig) *WASMPluginChecker {
+	return &WASMPluginChecker{
+		plugin: plugin,
+	}
+}
+
+// Check runs the plugin's module with client as its way to the network. A
+// module that exits non-zero, traps or prints something other than JSON
+// fails the check; the result carries the error either way.
+func (w *WASMPluginChecker) Check(ctx context.Context, client *http.Client, protocol *models.Protocol) (*models.PluginResult, error) {
+	start := time.Now()
+	output, err := w.run(ctx, client, protocol)
+	result := &models.PluginResult{Duration: time.Since(start)}
+	if err == nil && !json.Valid(output) {
+		err = fmt.Errorf("plugin %s printed invalid JSON", w.plugin.Name)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.Output = json.RawMessage(output)
+	return result, nil
+}
+
+// run instantiates the module in a fresh runtime, which runs its _start
+// function, and returns its trimmed standard output
+func (w *WASMPluginChecker) run(ctx context.Context, client *http.Client, protocol *models.Protocol) ([]byte, error) {
+	input, err := json.Marshal(protocol)
+	if err != nil {
+		return nil, err
+	}
+	wasm, err := os.ReadFile(w.plugin.WASM)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", w.plugin.Name, err)
+	}
+
+	timeout := w.plugin.Timeout
+	if timeout <= 0 {
+		timeout = defaultPluginTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCompilationCache(wasmCache).
+		WithMemoryLimitPages(wasmMemoryPages).
+		WithCloseOnContextDone(true))
+	defer rt.Close(context.Background())
+
+	wasi_snapshot_preview1.MustInstantiate(ctx, rt)
+	_, err = rt.NewHostModuleBuilder("protoscope").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, mod api.Module, urlPtr, urlLen, bufPtr, bufCap uint32) int64 {
+			return wasmHTTPGet(ctx, client, mod.Memory(), urlPtr, urlLen, bufPtr, bufCap)
+		}).
+		Export("http_get").
+		Instantiate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err := rt.CompileModule(ctx, wasm)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", w.plugin.Name, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	config := wazero.NewModuleConfig().
+		WithName(w.plugin.Name).
+		WithArgs(w.plugin.Name).
+		WithEnv("PROTOSCOPE_PLUGIN", w.plugin.Name).
+		WithEnv("PROTOSCOPE_NODE", protocol.Name).
+		WithStdin(bytes.NewReader(input)).
+		WithStdout(&stdout).
+		WithStderr(&stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader)
+
+	if _, err := rt.InstantiateModule(ctx, compiled, config); err != nil {
+		var exitErr *sys.ExitError
+		if ctx.Err() == context.DeadlineExceeded || (errors.As(err, &exitErr) && exitErr.ExitCode() == sys.ExitCodeDeadlineExceeded) {
+			return nil, fmt.Errorf("plugin %s timed out after %s", w.plugin.Name, timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("plugin %s failed: %w: %s", w.plugin.Name, err, msg)
+		}
+		return nil, fmt.Errorf("plugin %s failed: %w", w.plugin.Name, err)
+	}
+
+	return bytes.TrimSpace(stdout.Bytes()), nil
+}
+
+// wasmHTTPGet implements the http_get import
+func wasmHTTPGet(ctx context.Context, client *http.Client, memory api.Memory, urlPtr, urlLen, bufPtr, bufCap uint32) int64 {
+	url, ok := memory.Read(urlPtr, urlLen)
+	if !ok {
+		return -1
+	}
+	buf, ok := memory.Read(bufPtr, bufCap)
+	if !ok {
+		return -1
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", string(url), nil)
+	if err != nil {
+		return -1
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return -1
+	}
+	defer resp.Body.Close()
+
+	// memory.Read returns a view, so the body lands in the module's memory
+	n, err := io.ReadFull(resp.Body, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return -1
+	}
+	return int64(resp.StatusCode)<<32 | int64(n)
+}
@@ -0,0 +1,232 @@
+package checks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestWASMPluginChecker(t *testing.T) {
+	dir := t.TempDir()
+	wasm := filepath.Join(dir, "plugin.wasm")
+	failing := filepath.Join(dir, "failing.wasm")
+	if err := os.WriteFile(wasm, wasmTestModule(false), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(failing, wasmTestModule(true), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "plugin.test" {
+			t.Errorf("request for host %s, want plugin.test", r.Host)
+		}
+		w.Write([]byte("hello from the exit"))
+	}))
+	defer server.Close()
+	// Stands in for the node's proxy
+	proxyURL, _ := url.Parse(server.URL)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	checker := NewWASMPluginChecker(models.PluginConfig{Name: "hello", WASM: wasm, Timeout: 30 * time.Second})
+	result, err := checker.Check(context.Background(), client, &models.Protocol{Name: "NL-01"})
+	if err != nil {
+		t.Fatalf("Check returned %v", err)
+	}
+	var output struct {
+		Node models.Protocol `json:"node"`
+		Body string          `json:"body"`
+	}
+	if err := json.Unmarshal(result.Output, &output); err != nil {
+		t.Fatalf("plugin output %s: %v", result.Output, err)
+	}
+	if output.Node.Name != "NL-01" || output.Body != "hello from the exit" {
+		t.Errorf("plugin saw %+v", output)
+	}
+
+	checker = NewWASMPluginChecker(models.PluginConfig{Name: "failing", WASM: failing, Timeout: 30 * time.Second})
+	result, err = checker.Check(context.Background(), client, &models.Protocol{Name: "NL-01"})
+	if err == nil || !strings.Contains(result.Error, "node refused") {
+		t.Errorf("failing plugin: error %v, want its stderr", err)
+	}
+}
+
+// wasmTestModule assembles a tiny WASI command, as a plugin built from Go
+// takes long to compile under the race detector. It prints
+// {"node":<stdin>,"body":"<body of http://plugin.test/hello>"} or, when
+// failing, writes "node refused" to stderr and exits 2.
+func wasmTestModule(failing bool) []byte {
+	const (
+		typeHTTPGet  = 0 // (i32 i32 i32 i32) -> i64
+		typeFD       = 1 // (i32 i32 i32 i32) -> i32
+		typeProcExit = 2 // (i32) -> ()
+		typeStart    = 3 // () -> ()
+
+		funcHTTPGet  = 0
+		funcFDRead   = 1
+		funcFDWrite  = 2
+		funcProcExit = 3
+		funcStart    = 4
+	)
+	// Memory layout: the URL at 0, the iovec of fd_read at 32, the byte
+	// count at 40, the iovecs of the output at 48, its fixed parts from
+	// 128, stdin at 256 and the body at 1024
+	const url = "http://plugin.test/hello"
+	data := map[uint32][]byte{
+		0:   []byte(url),
+		32:  wasmLE32(256, 512),
+		48:  wasmLE32(128, 8, 256, 0, 160, 9, 1024, 0, 192, 2),
+		128: []byte(`{"node":`),
+		160: []byte(`,"body":"`),
+		192: []byte(`"}`),
+		208: []byte("node refused\n"),
+		224: wasmLE32(208, 13),
+	}
+
+	var code []byte
+	i32 := func(v int64) { code = append(append(code, 0x41), wasmSLEB(v)...) }
+	call := func(f byte) { code = append(code, 0x10, f) }
+	if failing {
+		i32(2)
+		i32(224)
+		i32(1)
+		i32(40)
+		call(funcFDWrite)
+		code = append(code, 0x1a) // drop
+		i32(2)
+		call(funcProcExit)
+	} else {
+		// fd_read(0, 32, 1, 40), then the stdin iovec's length = read
+		i32(0)
+		i32(32)
+		i32(1)
+		i32(40)
+		call(funcFDRead)
+		code = append(code, 0x1a)
+		i32(60)
+		i32(40)
+		code = append(code, 0x28, 0x02, 0x00) // i32.load
+		code = append(code, 0x36, 0x02, 0x00) // i32.store
+		// r = http_get(url, 24, 1024, 64); exit 1 when r < 0
+		i32(0)
+		i32(int64(len(url)))
+		i32(1024)
+		i32(64)
+		call(funcHTTPGet)
+		code = append(code, 0x21, 0x00)             // local.set 0
+		code = append(code, 0x20, 0x00, 0x42, 0x00) // local.get 0, i64.const 0
+		code = append(code, 0x53, 0x04, 0x40)       // i64.lt_s, if
+		i32(1)
+		call(funcProcExit)
+		code = append(code, 0x0b) // end
+		// the body iovec's length = the low 32 bits of r
+		i32(76)
+		code = append(code, 0x20, 0x00, 0xa7) // local.get 0, i32.wrap_i64
+		code = append(code, 0x36, 0x02, 0x00)
+		// fd_write(1, 48, 5, 40)
+		i32(1)
+		i32(48)
+		i32(5)
+		i32(40)
+		call(funcFDWrite)
+		code = append(code, 0x1a)
+	}
+	code = append(code, 0x0b)
+	// One i64 local
+	body := append([]byte{0x01, 0x01, 0x7e}, code...)
+
+	i32x4 := []byte{0x04, 0x7f, 0x7f, 0x7f, 0x7f}
+	types := wasmVec(
+		append(append([]byte{0x60}, i32x4...), 0x01, 0x7e),
+		append(append([]byte{0x60}, i32x4...), 0x01, 0x7f),
+		[]byte{0x60, 0x01, 0x7f, 0x00},
+		[]byte{0x60, 0x00, 0x00},
+	)
+	imports := wasmVec(
+		wasmImport("protoscope", "http_get", typeHTTPGet),
+		wasmImport("wasi_snapshot_preview1", "fd_read", typeFD),
+		wasmImport("wasi_snapshot_preview1", "fd_write", typeFD),
+		wasmImport("wasi_snapshot_preview1", "proc_exit", typeProcExit),
+	)
+	exports := wasmVec(
+		append(wasmName("memory"), 0x02, 0x00),
+		append(wasmName("_start"), 0x00, funcStart),
+	)
+	var segments [][]byte
+	for offset, bytes := range data {
+		segment := append([]byte{0x00, 0x41}, wasmSLEB(int64(offset))...)
+		segment = append(segment, 0x0b)
+		segments = append(segments, append(append(segment, wasmULEB(uint32(len(bytes)))...), bytes...))
+	}
+
+	module := []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+	module = append(module, wasmSection(1, types)...)
+	module = append(module, wasmSection(2, imports)...)
+	module = append(module, wasmSection(3, wasmVec([]byte{typeStart}))...)
+	module = append(module, wasmSection(5, wasmVec([]byte{0x00, 0x01}))...) // one page
+	module = append(module, wasmSection(7, exports)...)
+	module = append(module, wasmSection(10, wasmVec(append(wasmULEB(uint32(len(body))), body...)))...)
+	module = append(module, wasmSection(11, wasmVec(segments...))...)
+	return module
+}
+
+func wasmSection(id byte, content []byte) []byte {
+	return append(append([]byte{id}, wasmULEB(uint32(len(content)))...), content...)
+}
+
+func wasmVec(items ...[]byte) []byte {
+	out := wasmULEB(uint32(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func wasmName(s string) []byte {
+	return append(wasmULEB(uint32(len(s))), s...)
+}
+
+func wasmImport(module, field string, typeIndex byte) []byte {
+	return append(append(wasmName(module), wasmName(field)...), 0x00, typeIndex)
+}
+
+func wasmLE32(values ...uint32) []byte {
+	var out []byte
+	for _, v := range values {
+		out = append(out, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+	}
+	return out
+}
+
+func wasmULEB(v uint32) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func wasmSLEB(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
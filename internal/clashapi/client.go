@@ -0,0 +1,218 @@
+// Package clashapi talks to the REST API of a running Clash or mihomo
+// instance (its "external controller")
+package clashapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Proxy is a proxy or proxy group as listed by GET /proxies
+type Proxy struct {
+	Name string   `json:"name"`
+	Type string   `json:"type"`
+	UDP  bool     `json:"udp"`
+	Now  string   `json:"now,omitempty"` // selected member of a group
+	All  []string `json:"all,omitempty"` // members of a group
+}
+
+// groupTypes are proxy types that select among other proxies
+var groupTypes = map[string]bool{
+	"selector":    true,
+	"urltest":     true,
+	"fallback":    true,
+	"loadbalance": true,
+	"relay":       true,
+}
+
+// builtinTypes are pseudo proxies every Clash config has
+var builtinTypes = map[string]bool{
+	"direct":     true,
+	"reject":     true,
+	"rejectdrop": true,
+	"pass":       true,
+	"compatible": true,
+}
+
+// IsGroup reports whether the proxy is a group of other proxies
+func (p Proxy) IsGroup() bool {
+	return groupTypes[strings.ToLower(p.Type)]
+}
+
+// IsNode reports whether the proxy is an actual remote node
+func (p Proxy) IsNode() bool {
+	t := strings.ToLower(p.Type)
+	return !groupTypes[t] && !builtinTypes[t]
+}
+
+// ProtocolType maps the Clash proxy type to a ProtoScope protocol type
+func (p Proxy) ProtocolType() models.ProtocolType {
+	switch strings.ToLower(p.Type) {
+	case "vmess":
+		return models.ProtocolVMess
+	case "vless":
+		return models.ProtocolVLESS
+	case "trojan":
+		return models.ProtocolTrojan
+	case "shadowsocks", "ss":
+		return models.ProtocolShadowsocks
+	case "hysteria2":
+		return models.ProtocolHysteria2
+	case "tuic":
+		return models.ProtocolTUIC
+	case "wireguard":
+		return models.ProtocolWireGuard
+	case "ssh":
+		return models.ProtocolSSH
+	default:
+		return models.ProtocolType(strings.ToLower(p.Type))
+	}
+}
+
+// Client is a Clash external controller client
+type Client struct {
+	baseURL string
+	secret  string
+	client  *http.Client
+}
+
+// NewClient creates a client for the controller at baseURL, e.g.
+// http://127.0.0.1:9090. secret is the controller's bearer token, if any.
+func NewClient(baseURL, secret string) *Client {
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  secret,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Proxies returns all proxies and groups keyed by name
+func (c *Client) Proxies(ctx context.Context) (map[string]Proxy, error) {
+	var body struct {
+		Proxies map[string]Proxy `json:"proxies"`
+	}
+	if err := c.do(ctx, "GET", "/proxies", nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Proxies, nil
+}
+
+// Nodes returns the remote nodes sorted by name. With a group name, only
+// members of that group are returned.
+func (c *Client) Nodes(ctx context.Context, group string) ([]Proxy, error) {
+	proxies, err := c.Proxies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(proxies))
+	if group != "" {
+		g, ok := proxies[group]
+		if !ok || !g.IsGroup() {
+			return nil, fmt.Errorf("no proxy group named %q", group)
+		}
+		names = append(names, g.All...)
+	} else {
+		for name := range proxies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	nodes := make([]Proxy, 0, len(names))
+	for _, name := range names {
+		if proxy, ok := proxies[name]; ok && proxy.IsNode() {
+			nodes = append(nodes, proxy)
+		}
+	}
+	return nodes, nil
+}
+
+// Delay asks Clash to measure the delay of a proxy by fetching testURL
+// through it
+func (c *Client) Delay(ctx context.Context, name, testURL string, timeout time.Duration) (time.Duration, error) {
+	query := url.Values{}
+	query.Set("url", testURL)
+	query.Set("timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+
+	var body struct {
+		Delay int `json:"delay"`
+	}
+	path := "/proxies/" + url.PathEscape(name) + "/delay?" + query.Encode()
+	if err := c.do(ctx, "GET", path, nil, &body); err != nil {
+		return 0, err
+	}
+	return time.Duration(body.Delay) * time.Millisecond, nil
+}
+
+// Select selects a member of a selector group
+func (c *Client) Select(ctx context.Context, group, name string) error {
+	return c.do(ctx, "PUT", "/proxies/"+url.PathEscape(group), map[string]string{"name": name}, nil)
+}
+
+// do sends a request and decodes the JSON response into out, if given
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.secret != "" {
+		req.Header.Set("Authorization", "Bearer "+c.secret)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024*1024))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Clash reports errors as {"message": "..."}
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("clash api: %s (status %d)", apiErr.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("clash api: unexpected status: %d", resp.StatusCode)
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("clash api: invalid response: %w", err)
+	}
+	return nil
+}
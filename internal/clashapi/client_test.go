@@ -0,0 +1,90 @@
+package clashapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeController serves a minimal Clash API with two nodes in a selector
+func fakeController(t *testing.T, selected *string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /proxies", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Unauthorized"})
+			return
+		}
+		w.Write([]byte(`{"proxies": {
+			"DIRECT": {"name": "DIRECT", "type": "Direct"},
+			"Proxy": {"name": "Proxy", "type": "Selector", "now": "jp", "all": ["jp", "us", "DIRECT"]},
+			"jp": {"name": "jp", "type": "Vmess"},
+			"us": {"name": "us", "type": "Trojan"},
+			"de": {"name": "de", "type": "Shadowsocks"}
+		}}`))
+	})
+	mux.HandleFunc("GET /proxies/{name}/delay", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("name") {
+		case "jp":
+			w.WriteHeader(http.StatusRequestTimeout)
+			w.Write([]byte(`{"message": "Timeout"}`))
+		default:
+			w.Write([]byte(`{"delay": 120}`))
+		}
+	})
+	mux.HandleFunc("PUT /proxies/{group}", func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Name string }
+		json.NewDecoder(r.Body).Decode(&body)
+		*selected = r.PathValue("group") + "=" + body.Name
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestClient(t *testing.T) {
+	var selected string
+	srv := fakeController(t, &selected)
+	defer srv.Close()
+	ctx := context.Background()
+
+	if _, err := NewClient(srv.URL, "wrong").Proxies(ctx); err == nil {
+		t.Error("expected an error with a wrong secret")
+	}
+
+	client := NewClient(srv.URL, "s3cret")
+
+	all, err := client.Nodes(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("got %d nodes, want 3 (groups and builtins excluded)", len(all))
+	}
+
+	nodes, err := client.Nodes(ctx, "Proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "jp" || nodes[1].Name != "us" {
+		t.Fatalf("group members = %+v", nodes)
+	}
+
+	jp := client.Test(ctx, nodes[0], []string{"http://a/generate_204"}, time.Second)
+	if jp.Success || jp.Protocol.Type != "vmess" {
+		t.Errorf("jp: success=%v type=%s, want failed vmess", jp.Success, jp.Protocol.Type)
+	}
+	us := client.Test(ctx, nodes[1], []string{"http://a/generate_204"}, time.Second)
+	if !us.Success || us.Connectivity.ResponseTime != 120*time.Millisecond {
+		t.Errorf("us: %+v", us.Connectivity)
+	}
+
+	if err := client.Select(ctx, "Proxy", "us"); err != nil {
+		t.Fatal(err)
+	}
+	if selected != "Proxy=us" {
+		t.Errorf("selected = %q", selected)
+	}
+}
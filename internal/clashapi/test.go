@@ -0,0 +1,50 @@
+package clashapi
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Test measures a node through the controller's delay API. Test URLs are
+// tried in order until one answers, like the connectivity check of a
+// regular run; the node never leaves Clash, so only connectivity is tested.
+func (c *Client) Test(ctx context.Context, node Proxy, testURLs []string, timeout time.Duration) *models.TestResult {
+	result := &models.TestResult{
+		Protocol: &models.Protocol{
+			Type: node.ProtocolType(),
+			Name: node.Name,
+		},
+		Timestamp: time.Now(),
+	}
+
+	var errs []string
+	for _, testURL := range testURLs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		delay, err := c.Delay(ctx, node.Name, testURL, timeout)
+		if err != nil {
+			errs = append(errs, testURL+": "+err.Error())
+			continue
+		}
+
+		result.Success = true
+		result.Connectivity = &models.ConnectivityResult{
+			Connected:    true,
+			ResponseTime: delay,
+			Endpoint:     testURL,
+		}
+		return result
+	}
+
+	result.Error = "all connectivity endpoints failed: " + strings.Join(errs, "; ")
+	if ctx.Err() != nil && len(errs) == 0 {
+		result.Error = "Not tested: " + ctx.Err().Error()
+	}
+	result.Connectivity = &models.ConnectivityResult{Error: result.Error}
+	return result
+}
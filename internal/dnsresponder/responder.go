@@ -0,0 +1,149 @@
+// Package dnsresponder answers DNS queries for a single hostname with
+// addresses that change at runtime, e.g. the server of the current best node
+package dnsresponder
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Responder answers A/AAAA queries for one name
+type Responder struct {
+	name string // lowercase FQDN with trailing dot
+	ttl  uint32
+
+	mu    sync.RWMutex
+	addrs []net.IP
+}
+
+// New creates a responder for hostname. Answers carry the given TTL, which
+// should be short so clients follow changes quickly.
+func New(hostname string, ttl time.Duration) *Responder {
+	name := strings.ToLower(strings.TrimSuffix(hostname, ".")) + "."
+	return &Responder{
+		name: name,
+		ttl:  uint32(ttl.Seconds()),
+	}
+}
+
+// SetAddresses replaces the addresses the name resolves to
+func (r *Responder) SetAddresses(addrs []net.IP) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addrs = addrs
+}
+
+// ListenAndServe answers queries over UDP on addr until ctx is cancelled
+func (r *Responder) ListenAndServe(ctx context.Context, addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 512)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		reply, err := r.handle(buf[:n])
+		if err != nil {
+			continue // not a DNS query, drop it
+		}
+		conn.WriteTo(reply, from)
+	}
+}
+
+// handle builds the reply to one query message
+func (r *Responder) handle(query []byte) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	reply := dnsmessage.Header{
+		ID:               header.ID,
+		Response:         true,
+		Authoritative:    true,
+		RecursionDesired: header.RecursionDesired,
+		OpCode:           header.OpCode,
+		RCode:            dnsmessage.RCodeSuccess,
+	}
+
+	var answers []net.IP
+	switch {
+	case header.OpCode != 0:
+		reply.RCode = dnsmessage.RCodeNotImplemented
+	case strings.ToLower(question.Name.String()) != r.name:
+		// Not a recursive resolver: only the configured name is answered
+		reply.RCode = dnsmessage.RCodeRefused
+	default:
+		r.mu.RLock()
+		addrs := r.addrs
+		r.mu.RUnlock()
+
+		if len(addrs) == 0 {
+			reply.RCode = dnsmessage.RCodeServerFailure
+			break
+		}
+		for _, addr := range addrs {
+			isV4 := addr.To4() != nil
+			if (question.Type == dnsmessage.TypeA && isV4) || (question.Type == dnsmessage.TypeAAAA && !isV4) {
+				answers = append(answers, addr)
+			}
+		}
+	}
+
+	builder := dnsmessage.NewBuilder(nil, reply)
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(question); err != nil {
+		return nil, err
+	}
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+
+	resource := dnsmessage.ResourceHeader{
+		Name:  question.Name,
+		Class: dnsmessage.ClassINET,
+		TTL:   r.ttl,
+	}
+	for _, addr := range answers {
+		if ip4 := addr.To4(); ip4 != nil {
+			var a dnsmessage.AResource
+			copy(a.A[:], ip4)
+			err = builder.AResource(resource, a)
+		} else {
+			var aaaa dnsmessage.AAAAResource
+			copy(aaaa.AAAA[:], addr.To16())
+			err = builder.AAAAResource(resource, aaaa)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.Finish()
+}
@@ -0,0 +1,66 @@
+package dnsresponder
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestResponder(t *testing.T) {
+	responder := New("best.proxy.lan", 30*time.Second)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().String()
+	conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go responder.ListenAndServe(ctx, addr)
+	time.Sleep(50 * time.Millisecond)
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", addr)
+		},
+	}
+	lookup := func(host string) ([]string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		return resolver.LookupHost(ctx, host)
+	}
+
+	// No best node yet
+	if _, err := lookup("best.proxy.lan"); err == nil {
+		t.Error("expected a lookup failure before addresses are set")
+	}
+
+	responder.SetAddresses([]net.IP{net.ParseIP("203.0.113.7"), net.ParseIP("2001:db8::7")})
+	hosts, err := lookup("BEST.proxy.lan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, h := range hosts {
+		found[h] = true
+	}
+	if !found["203.0.113.7"] || !found["2001:db8::7"] {
+		t.Errorf("lookup = %v, want both addresses", hosts)
+	}
+
+	// Failover: the answer follows the new best node
+	responder.SetAddresses([]net.IP{net.ParseIP("198.51.100.1")})
+	hosts, err = lookup("best.proxy.lan")
+	if err != nil || len(hosts) != 1 || hosts[0] != "198.51.100.1" {
+		t.Errorf("after update: %v, %v", hosts, err)
+	}
+
+	if _, err := lookup("example.com"); err == nil {
+		t.Error("expected other names to be refused")
+	}
+}
@@ -0,0 +1,126 @@
+package echoserver
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// canaryTTLSeconds keeps resolvers from caching canary answers
+const canaryTTLSeconds = 0
+
+// ServeDNS answers queries for the canary zone over UDP on addr until ctx is
+// cancelled. The zone must be delegated to this listener (an NS record
+// pointing at this server) so recursive resolvers query it directly; the
+// source address of each query is the resolver a client used.
+func (s *Server) ServeDNS(ctx context.Context, addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 512)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		resolver := from.String()
+		if udpAddr, ok := from.(*net.UDPAddr); ok {
+			resolver = udpAddr.IP.String()
+		}
+		reply, err := s.handleDNS(buf[:n], resolver)
+		if err != nil {
+			continue // not a DNS query, drop it
+		}
+		conn.WriteTo(reply, from)
+	}
+}
+
+// handleDNS records the resolver of a canary lookup and builds the reply
+func (s *Server) handleDNS(query []byte, resolver string) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	reply := dnsmessage.Header{
+		ID:            header.ID,
+		Response:      true,
+		Authoritative: true,
+		OpCode:        header.OpCode,
+		RCode:         dnsmessage.RCodeSuccess,
+	}
+
+	name := strings.ToLower(question.Name.String())
+	var answer net.IP
+	switch {
+	case header.OpCode != 0:
+		reply.RCode = dnsmessage.RCodeNotImplemented
+	case s.zone == "" || !strings.HasSuffix(name, "."+s.zone):
+		reply.RCode = dnsmessage.RCodeRefused
+	default:
+		// Resolvers may randomise the case of the name; the token is the
+		// leftmost label in any case
+		token := strings.SplitN(name, ".", 2)[0]
+		if !s.recordLookup(token, resolver) {
+			reply.RCode = dnsmessage.RCodeNameError
+			break
+		}
+		if question.Type == dnsmessage.TypeA && s.answer.To4() != nil {
+			answer = s.answer.To4()
+		} else if question.Type == dnsmessage.TypeAAAA && s.answer != nil && s.answer.To4() == nil {
+			answer = s.answer
+		}
+	}
+
+	builder := dnsmessage.NewBuilder(nil, reply)
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(question); err != nil {
+		return nil, err
+	}
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+
+	if answer != nil {
+		resource := dnsmessage.ResourceHeader{
+			Name:  question.Name,
+			Class: dnsmessage.ClassINET,
+			TTL:   canaryTTLSeconds,
+		}
+		if ip4 := answer.To4(); ip4 != nil {
+			var a dnsmessage.AResource
+			copy(a.A[:], ip4)
+			err = builder.AResource(resource, a)
+		} else {
+			var aaaa dnsmessage.AAAAResource
+			copy(aaaa.AAAA[:], answer.To16())
+			err = builder.AAAAResource(resource, aaaa)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.Finish()
+}
@@ -0,0 +1,245 @@
+// Package echoserver serves the endpoints ProtoScope's checks talk to, so
+// privacy-conscious users can run them against a server of their own instead
+// of third-party services
+package echoserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxDownload caps /__down so the server can't be used to burn bandwidth
+	maxDownload = 100 * 1000 * 1000
+	// defaultDownload matches the size the speed test expects
+	defaultDownload = 10 * 1000 * 1000
+	// canaryTTL is how long a DNS canary token collects resolvers
+	canaryTTL = 10 * time.Minute
+	// maxCanaries bounds the tokens alive at once, and maxResolvers the
+	// resolvers kept per token, so the server's memory doesn't grow with
+	// whatever anyone sends it
+	maxCanaries  = 10000
+	maxResolvers = 32
+)
+
+// Server answers connectivity, IP, speed and DNS canary requests
+type Server struct {
+	zone   string // lowercase DNS canary zone with trailing dot
+	answer net.IP // address canary names resolve to
+
+	mu       sync.Mutex
+	canaries map[string]*canary
+}
+
+// canary collects the resolvers that looked up one token
+type canary struct {
+	created   time.Time
+	resolvers []string
+}
+
+// New creates a server. zone is the domain delegated to this server's DNS
+// listener and answer the address canary names resolve to, normally this
+// server's own. With an empty zone the DNS canary is disabled.
+func New(zone string, answer net.IP) *Server {
+	if zone != "" {
+		zone = strings.ToLower(strings.Trim(zone, ".")) + "."
+	}
+	return &Server{
+		zone:     zone,
+		answer:   answer,
+		canaries: make(map[string]*canary),
+	}
+}
+
+// Handler returns the HTTP handler with all routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /generate_204", s.handleGenerate204)
+	mux.HandleFunc("GET /ip", s.handleIP)
+	mux.HandleFunc("GET /__down", s.handleDown)
+	mux.HandleFunc("POST /__up", s.handleUp)
+	mux.HandleFunc("GET /dns-canary/new", s.handleCanaryNew)
+	mux.HandleFunc("GET /dns-canary/{token}", s.handleCanaryResult)
+	// Everything else gets a plain answer: canary lookups are followed by a
+	// request to the canary name, and egress probes may send any path
+	mux.HandleFunc("/", s.handleRoot)
+	return mux
+}
+
+// ListenAndServe serves HTTP on addr until ctx is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	httpServer := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleGenerate204(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleIP returns the client address as plain text, or as {"ip": ...}
+// with ?format=json
+func (s *Server) handleIP(w http.ResponseWriter, r *http.Request) {
+	ip := clientIP(r)
+	if r.URL.Query().Get("format") == "json" {
+		writeJSON(w, http.StatusOK, map[string]string{"ip": ip})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	io.WriteString(w, ip+"\n")
+}
+
+// handleDown sends ?bytes= zero bytes, as speed.cloudflare.com does
+func (s *Server) handleDown(w http.ResponseWriter, r *http.Request) {
+	size := int64(defaultDownload)
+	if raw := r.URL.Query().Get("bytes"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid bytes", http.StatusBadRequest)
+			return
+		}
+		size = min(n, maxDownload)
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	chunk := make([]byte, 64*1024)
+	for size > 0 {
+		n := min(size, int64(len(chunk)))
+		if _, err := w.Write(chunk[:n]); err != nil {
+			return
+		}
+		size -= n
+	}
+}
+
+// handleUp discards the request body and reports its size
+func (s *Server) handleUp(w http.ResponseWriter, r *http.Request) {
+	n, err := io.Copy(io.Discard, io.LimitReader(r.Body, maxDownload))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"bytes": n})
+}
+
+// handleCanaryNew hands out a fresh name under the canary zone
+func (s *Server) handleCanaryNew(w http.ResponseWriter, r *http.Request) {
+	if s.zone == "" {
+		http.Error(w, "DNS canary not configured", http.StatusNotFound)
+		return
+	}
+
+	b := make([]byte, 8)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	for key, c := range s.canaries {
+		if time.Since(c.created) > canaryTTL {
+			delete(s.canaries, key)
+		}
+	}
+	if len(s.canaries) >= maxCanaries {
+		s.mu.Unlock()
+		http.Error(w, "too many DNS canaries in flight", http.StatusServiceUnavailable)
+		return
+	}
+	s.canaries[token] = &canary{created: time.Now()}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"token": token,
+		"host":  token + "." + strings.TrimSuffix(s.zone, "."),
+	})
+}
+
+// handleCanaryResult returns the resolvers that looked up a token, as a
+// JSON list of IPs
+func (s *Server) handleCanaryResult(w http.ResponseWriter, r *http.Request) {
+	resolvers, ok := s.Resolvers(r.PathValue("token"))
+	if !ok {
+		http.Error(w, "unknown token", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, resolvers)
+}
+
+func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	io.WriteString(w, "protoscope echo-server\n")
+}
+
+// Resolvers returns the distinct resolver addresses seen for token
+func (s *Server) Resolvers(token string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.canaries[token]
+	if !ok {
+		return nil, false
+	}
+	return append([]string{}, c.resolvers...), true
+}
+
+// recordLookup notes that resolver looked up token
+func (s *Server) recordLookup(token, resolver string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.canaries[token]
+	if !ok {
+		return false
+	}
+	for _, seen := range c.resolvers {
+		if seen == resolver {
+			return true
+		}
+	}
+	if len(c.resolvers) < maxResolvers {
+		c.resolvers = append(c.resolvers, resolver)
+	}
+	return true
+}
+
+// clientIP is the address the request came from
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
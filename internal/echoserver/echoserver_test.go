@@ -0,0 +1,139 @@
+package echoserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestEndpoints(t *testing.T) {
+	s := New("", nil)
+
+	tests := []struct {
+		target string
+		code   int
+		body   string
+		size   int
+	}{
+		{"/generate_204", http.StatusNoContent, "", 0},
+		{"/ip", http.StatusOK, "192.0.2.1\n", -1},
+		{"/ip?format=json", http.StatusOK, "{\"ip\":\"192.0.2.1\"}\n", -1},
+		{"/__down?bytes=100000", http.StatusOK, "", 100000},
+		{"/__down?bytes=-1", http.StatusBadRequest, "", -1},
+		{"/dns-canary/new", http.StatusNotFound, "", -1},
+		{"/anything", http.StatusOK, "protoscope echo-server\n", -1},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", tt.target, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.target, rec.Code, tt.code)
+			continue
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%s: body %q, want %q", tt.target, rec.Body.String(), tt.body)
+		}
+		if tt.size >= 0 && rec.Body.Len() != tt.size {
+			t.Errorf("%s: %d bytes, want %d", tt.target, rec.Body.Len(), tt.size)
+		}
+	}
+}
+
+func TestDNSCanary(t *testing.T) {
+	s := New("Canary.Example.com.", net.ParseIP("198.51.100.7"))
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/dns-canary/new", nil))
+	var canary struct {
+		Token string `json:"token"`
+		Host  string `json:"host"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &canary); err != nil {
+		t.Fatalf("new canary: %v (%s)", err, rec.Body.String())
+	}
+	if canary.Host != canary.Token+".canary.example.com" {
+		t.Fatalf("host %q does not belong to the zone", canary.Host)
+	}
+
+	// Resolvers may randomise the case of the queried name
+	answer := query(t, s, "0x20"+canary.Host[4:]+".", "203.0.113.53")
+	if answer != nil {
+		t.Errorf("unknown token answered with %s", answer)
+	}
+	answer = query(t, s, canary.Token+".CANARY.example.com.", "203.0.113.53")
+	if !answer.Equal(net.ParseIP("198.51.100.7")) {
+		t.Errorf("answer %v, want 198.51.100.7", answer)
+	}
+	query(t, s, canary.Host+".", "203.0.113.53")
+	query(t, s, canary.Host+".", "203.0.113.54")
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/dns-canary/"+canary.Token, nil))
+	var resolvers []string
+	if err := json.Unmarshal(rec.Body.Bytes(), &resolvers); err != nil {
+		t.Fatalf("canary result: %v (%s)", err, rec.Body.String())
+	}
+	if len(resolvers) != 2 || resolvers[0] != "203.0.113.53" || resolvers[1] != "203.0.113.54" {
+		t.Errorf("resolvers %v, want the two distinct resolvers", resolvers)
+	}
+
+	if query(t, s, "www.example.org.", "203.0.113.53") != nil {
+		t.Error("answered a name outside the zone")
+	}
+}
+
+func TestDNSCanaryLimits(t *testing.T) {
+	s := New("canary.example.com", net.ParseIP("198.51.100.7"))
+	for i := 0; i < maxCanaries; i++ {
+		s.canaries[fmt.Sprint(i)] = &canary{created: time.Now()}
+	}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/dns-canary/new", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status %d with %d canaries in flight, want 503", rec.Code, maxCanaries)
+	}
+
+	for i := 0; i < maxResolvers+10; i++ {
+		s.recordLookup("0", fmt.Sprintf("203.0.113.%d", i))
+	}
+	if resolvers, _ := s.Resolvers("0"); len(resolvers) != maxResolvers {
+		t.Errorf("kept %d resolvers, want %d", len(resolvers), maxResolvers)
+	}
+}
+
+// query sends an A query for name from resolver and returns the answer
+func query(t *testing.T, s *Server, name, resolver string) net.IP {
+	t.Helper()
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 7, RecursionDesired: true})
+	builder.StartQuestions()
+	builder.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  dnsmessage.TypeA,
+		Class: dnsmessage.ClassINET,
+	})
+	msg, err := builder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := s.handleDNS(msg, resolver)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	var parsed dnsmessage.Message
+	if err := parsed.Unpack(reply); err != nil {
+		t.Fatalf("%s: bad reply: %v", name, err)
+	}
+	for _, answer := range parsed.Answers {
+		if a, ok := answer.Body.(*dnsmessage.AResource); ok {
+			return net.IP(a.A[:])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// ClashProxy converts a protocol into a Clash/Clash.Meta proxy entry
+func ClashProxy(protocol *models.Protocol, name string) (map[string]interface{}, error) {
+	proxy := map[string]interface{}{
+		"name":   name,
+		"server": protocol.Server,
+		"port":   protocol.Port,
+	}
+
+	switch protocol.Type {
+	case models.ProtocolVMess:
+		proxy["type"] = "vmess"
+		proxy["uuid"] = protocol.UUID
+		proxy["alterId"] = extraInt(protocol, "aid")
+		proxy["cipher"] = "auto"
+		addClashTLS(proxy, protocol)
+		addClashTransport(proxy, protocol)
+
+	case models.ProtocolVLESS:
+		proxy["type"] = "vless"
+		proxy["uuid"] = protocol.UUID
+		if flow := extraString(protocol, "flow"); flow != "" {
+			proxy["flow"] = flow
+		}
+		addClashTLS(proxy, protocol)
+		if extraString(protocol, "security") == "reality" {
+			reality := map[string]interface{}{}
+			if pbk := extraString(protocol, "pbk"); pbk != "" {
+				reality["public-key"] = pbk
+			}
+			if sid := extraString(protocol, "sid"); sid != "" {
+				reality["short-id"] = sid
+			}
+			proxy["reality-opts"] = reality
+			if _, ok := proxy["client-fingerprint"]; !ok {
+				proxy["client-fingerprint"] = "chrome"
+			}
+		}
+		addClashTransport(proxy, protocol)
+
+	case models.ProtocolTrojan:
+		proxy["type"] = "trojan"
+		proxy["password"] = protocol.Password
+		if protocol.SNI != "" {
+			proxy["sni"] = protocol.SNI
+		}
+		addClashInsecure(proxy, protocol)
+		addClashFingerprint(proxy, protocol)
+		addClashTransport(proxy, protocol)
+
+	case models.ProtocolShadowsocks:
+		method := extraString(protocol, "method")
+		if method == "" {
+			method = "aes-256-gcm"
+		}
+		proxy["type"] = "ss"
+		proxy["cipher"] = method
+		proxy["password"] = protocol.Password
+
+	case models.ProtocolHysteria2:
+		proxy["type"] = "hysteria2"
+		proxy["password"] = protocol.Password
+		if protocol.SNI != "" {
+			proxy["sni"] = protocol.SNI
+		}
+		if obfs := extraString(protocol, "obfs"); obfs != "" {
+			proxy["obfs"] = obfs
+			proxy["obfs-password"] = extraString(protocol, "obfs-password")
+		}
+		addClashInsecure(proxy, protocol)
+
+	case models.ProtocolTUIC:
+		proxy["type"] = "tuic"
+		proxy["uuid"] = protocol.UUID
+		proxy["password"] = protocol.Password
+		if protocol.SNI != "" {
+			proxy["sni"] = protocol.SNI
+		}
+		if alpn := extraString(protocol, "alpn"); alpn != "" {
+			proxy["alpn"] = []string{alpn}
+		}
+		if cc := extraString(protocol, "congestion_control"); cc != "" {
+			proxy["congestion-controller"] = cc
+		}
+		addClashInsecure(proxy, protocol)
+
+	case models.ProtocolWireGuard:
+		proxy["type"] = "wireguard"
+		proxy["private-key"] = protocol.Password
+		proxy["public-key"] = extraString(protocol, "publickey")
+		proxy["udp"] = true
+		if psk := extraString(protocol, "presharedkey"); psk != "" {
+			proxy["pre-shared-key"] = psk
+		}
+		for _, address := range strings.Split(extraString(protocol, "address"), ",") {
+			ip, _, _ := strings.Cut(address, "/")
+			if strings.Contains(ip, ":") {
+				proxy["ipv6"] = ip
+			} else if ip != "" {
+				proxy["ip"] = ip
+			}
+		}
+		if mtu, err := strconv.Atoi(extraString(protocol, "mtu")); err == nil {
+			proxy["mtu"] = mtu
+		}
+		// Clash.Meta takes the reserved bytes as a list or as base64
+		if reserved := extraString(protocol, "reserved"); strings.Contains(reserved, ",") {
+			var values []int
+			for _, field := range strings.Split(reserved, ",") {
+				if value, err := strconv.Atoi(field); err == nil {
+					values = append(values, value)
+				}
+			}
+			proxy["reserved"] = values
+		} else if reserved != "" {
+			proxy["reserved"] = reserved
+		}
+
+	case models.ProtocolSSH:
+		proxy["type"] = "ssh"
+		proxy["username"] = extraString(protocol, "user")
+		if protocol.Password != "" {
+			proxy["password"] = protocol.Password
+		}
+		if key := extraString(protocol, "privatekey"); key != "" {
+			proxy["private-key"] = key
+			if passphrase := extraString(protocol, "passphrase"); passphrase != "" {
+				proxy["private-key-passphrase"] = passphrase
+			}
+		}
+		if hostKeys := extraString(protocol, "hostkey"); hostKeys != "" {
+			proxy["host-key"] = strings.Split(hostKeys, ",")
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported protocol for clash: %s", protocol.Type)
+	}
+
+	return proxy, nil
+}
+
+// addClashTLS adds TLS fields used by vmess/vless entries
+func addClashTLS(proxy map[string]interface{}, protocol *models.Protocol) {
+	if !protocol.TLS {
+		return
+	}
+	proxy["tls"] = true
+	if protocol.SNI != "" {
+		proxy["servername"] = protocol.SNI
+	}
+	addClashInsecure(proxy, protocol)
+	addClashFingerprint(proxy, protocol)
+}
+
+// addClashInsecure turns certificate verification off when the node's
+// config does
+func addClashInsecure(proxy map[string]interface{}, protocol *models.Protocol) {
+	if extraString(protocol, "insecure") == "1" {
+		proxy["skip-cert-verify"] = true
+	}
+}
+
+// addClashFingerprint adds the uTLS client fingerprint if the link has one
+func addClashFingerprint(proxy map[string]interface{}, protocol *models.Protocol) {
+	if fp := extraString(protocol, "fp"); fp != "" {
+		proxy["client-fingerprint"] = fp
+	} else if fp := extraString(protocol, "fingerprint"); fp != "" {
+		proxy["client-fingerprint"] = fp
+	}
+}
+
+// addClashTransport adds ws/grpc/h2 transport options
+func addClashTransport(proxy map[string]interface{}, protocol *models.Protocol) {
+	switch protocol.Network {
+	case "ws":
+		proxy["network"] = "ws"
+		opts := map[string]interface{}{}
+		if path := extraString(protocol, "path"); path != "" {
+			opts["path"] = path
+		}
+		if host := extraString(protocol, "host"); host != "" {
+			opts["headers"] = map[string]interface{}{"Host": host}
+		}
+		proxy["ws-opts"] = opts
+	case "grpc":
+		proxy["network"] = "grpc"
+		if serviceName := extraString(protocol, "serviceName"); serviceName != "" {
+			proxy["grpc-opts"] = map[string]interface{}{"grpc-service-name": serviceName}
+		}
+	case "h2", "http":
+		proxy["network"] = "h2"
+		opts := map[string]interface{}{}
+		if path := extraString(protocol, "path"); path != "" {
+			opts["path"] = path
+		}
+		if host := extraString(protocol, "host"); host != "" {
+			opts["host"] = []string{host}
+		}
+		proxy["h2-opts"] = opts
+	}
+}
+
+// extraString returns a string value from Protocol.Extra
+func extraString(protocol *models.Protocol, key string) string {
+	if value, ok := protocol.Extra[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// extraInt returns an integer value from Protocol.Extra, accepting numeric strings
+func extraInt(protocol *models.Protocol, key string) int {
+	switch value := protocol.Extra[key].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	case string:
+		n, _ := strconv.Atoi(value)
+		return n
+	}
+	return 0
+}
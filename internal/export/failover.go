@@ -0,0 +1,154 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/VenoMexx/ProtoScope/internal/tester"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// FailoverGroupName is the name of the exported failover group
+const FailoverGroupName = "ProtoScope"
+
+// FailoverOptions controls the generated failover group
+type FailoverOptions struct {
+	TestURL  string // URL the client uses to probe nodes
+	Interval string // Probe interval, e.g. "3m"
+}
+
+// DefaultFailoverOptions returns the default failover group options
+func DefaultFailoverOptions() FailoverOptions {
+	return FailoverOptions{
+		TestURL:  "http://www.gstatic.com/generate_204",
+		Interval: "3m",
+	}
+}
+
+// SingboxFailover builds a sing-box config fragment with all working nodes
+// as outbounds and a urltest group listing them in ProtoScope's ranking order
+func SingboxFailover(results []*models.TestResult, opts FailoverOptions) ([]byte, error) {
+	ranked := models.RankResults(results)
+	if len(ranked) == 0 {
+		return nil, fmt.Errorf("no working nodes to export")
+	}
+
+	names := UniqueNames(ranked)
+	outbounds := make([]map[string]interface{}, 0, len(ranked)+1)
+	outbounds = append(outbounds, map[string]interface{}{
+		"type":      "urltest",
+		"tag":       FailoverGroupName,
+		"outbounds": names,
+		"url":       opts.TestURL,
+		"interval":  opts.Interval,
+	})
+
+	var endpoints []map[string]interface{}
+	for i, result := range ranked {
+		outbound, err := tester.SingboxOutbound(result.Protocol, names[i])
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", result.Protocol.Name, err)
+		}
+		if tester.IsSingboxEndpoint(outbound) {
+			endpoints = append(endpoints, outbound)
+			continue
+		}
+		outbounds = append(outbounds, outbound)
+	}
+
+	return json.MarshalIndent(singboxFragment(outbounds, endpoints), "", "  ")
+}
+
+// singboxFragment is a sing-box config holding outbounds and, when there
+// are any, endpoints such as WireGuard nodes
+func singboxFragment(outbounds, endpoints []map[string]interface{}) map[string]interface{} {
+	fragment := map[string]interface{}{
+		"outbounds": outbounds,
+	}
+	if len(endpoints) > 0 {
+		fragment["endpoints"] = endpoints
+	}
+	return fragment
+}
+
+// ClashFailover builds a Clash config fragment with all working nodes as
+// proxies and a fallback group ordered by ProtoScope's ranking
+func ClashFailover(results []*models.TestResult, opts FailoverOptions) ([]byte, error) {
+	ranked := models.RankResults(results)
+	if len(ranked) == 0 {
+		return nil, fmt.Errorf("no working nodes to export")
+	}
+
+	names := UniqueNames(ranked)
+	proxies := make([]map[string]interface{}, 0, len(ranked))
+	for i, result := range ranked {
+		proxy, err := ClashProxy(result.Protocol, names[i])
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", result.Protocol.Name, err)
+		}
+		proxies = append(proxies, proxy)
+	}
+
+	interval := 180
+	if d, err := parseIntervalSeconds(opts.Interval); err == nil {
+		interval = d
+	}
+
+	return yaml.Marshal(map[string]interface{}{
+		"proxies": proxies,
+		"proxy-groups": []map[string]interface{}{
+			{
+				"name":     FailoverGroupName,
+				"type":     "fallback",
+				"proxies":  names,
+				"url":      opts.TestURL,
+				"interval": interval,
+			},
+		},
+	})
+}
+
+// UniqueNames returns node names with duplicates suffixed, in result order.
+// Notes are left out: clients remember the selected node by name, which
+// must not change when a label does.
+func UniqueNames(results []*models.TestResult) []string {
+	names := make([]string, len(results))
+	taken := make(map[string]bool, len(results))
+	for i, result := range results {
+		names[i] = result.Protocol.Name
+		if names[i] == "" {
+			names[i] = fmt.Sprintf("%s:%d", result.Protocol.Server, result.Protocol.Port)
+		}
+		taken[names[i]] = true
+	}
+
+	// A node keeps its own name over a suffixed duplicate of another, so
+	// "x", "x", "x (2)" become "x", "x (3)", "x (2)"
+	first := make(map[string]bool, len(results))
+	for i, name := range names {
+		if !first[name] {
+			first[name] = true
+			continue
+		}
+		suffixed := name
+		for n := 2; taken[suffixed]; n++ {
+			suffixed = fmt.Sprintf("%s (%d)", name, n)
+		}
+		taken[suffixed] = true
+		names[i] = suffixed
+	}
+
+	return names
+}
+
+// parseIntervalSeconds converts a duration string like "3m" into seconds
+func parseIntervalSeconds(interval string) (int, error) {
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, err
+	}
+	return int(d.Seconds()), nil
+}
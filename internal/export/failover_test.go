@@ -0,0 +1,104 @@
+package export
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// exportResult is a working node scoring speed/50 of 100
+func exportResult(name string, speed float64) *models.TestResult {
+	return &models.TestResult{
+		Protocol: &models.Protocol{
+			Name: name, Type: models.ProtocolTrojan, Server: "node.example", Port: 443,
+			Password: "secret", TLS: true, SNI: "node.example",
+		},
+		Success:     true,
+		Performance: &models.PerformanceResult{DownloadSpeed: speed},
+	}
+}
+
+func TestUniqueNames(t *testing.T) {
+	results := []*models.TestResult{
+		exportResult("x", 50), exportResult("x", 50), exportResult("x (2)", 50),
+		exportResult("", 50), exportResult("x", 50),
+	}
+	want := []string{"x", "x (3)", "x (2)", "node.example:443", "x (4)"}
+	if got := UniqueNames(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("UniqueNames = %q, want %q", got, want)
+	}
+}
+
+func TestSingboxFailover(t *testing.T) {
+	failed := exportResult("down", 50)
+	failed.Success = false
+	data, err := SingboxFailover([]*models.TestResult{exportResult("slow", 10), failed, exportResult("fast", 50)}, DefaultFailoverOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fragment struct {
+		Outbounds []struct {
+			Type      string   `json:"type"`
+			Tag       string   `json:"tag"`
+			Outbounds []string `json:"outbounds"`
+		} `json:"outbounds"`
+	}
+	if err := json.Unmarshal(data, &fragment); err != nil {
+		t.Fatal(err)
+	}
+	if len(fragment.Outbounds) != 3 {
+		t.Fatalf("outbounds = %+v, want the group and two nodes", fragment.Outbounds)
+	}
+	group := fragment.Outbounds[0]
+	if group.Type != "urltest" || group.Tag != FailoverGroupName || !reflect.DeepEqual(group.Outbounds, []string{"fast", "slow"}) {
+		t.Errorf("group = %+v, want a urltest over fast, slow", group)
+	}
+	if fragment.Outbounds[1].Tag != "fast" || fragment.Outbounds[1].Type != "trojan" {
+		t.Errorf("first node = %+v", fragment.Outbounds[1])
+	}
+
+	if _, err := SingboxFailover([]*models.TestResult{failed}, DefaultFailoverOptions()); err == nil {
+		t.Error("expected an error without working nodes")
+	}
+}
+
+func TestClashFailover(t *testing.T) {
+	data, err := ClashFailover([]*models.TestResult{exportResult("slow", 10), exportResult("fast", 50)}, FailoverOptions{
+		TestURL:  "http://probe.example/204",
+		Interval: "5m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config struct {
+		Proxies []struct {
+			Name string `yaml:"name"`
+			Type string `yaml:"type"`
+		} `yaml:"proxies"`
+		Groups []struct {
+			Name     string   `yaml:"name"`
+			Type     string   `yaml:"type"`
+			Proxies  []string `yaml:"proxies"`
+			URL      string   `yaml:"url"`
+			Interval int      `yaml:"interval"`
+		} `yaml:"proxy-groups"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Proxies) != 2 || config.Proxies[0].Name != "fast" || config.Proxies[0].Type != "trojan" {
+		t.Errorf("proxies = %+v", config.Proxies)
+	}
+	if len(config.Groups) != 1 {
+		t.Fatalf("groups = %+v", config.Groups)
+	}
+	group := config.Groups[0]
+	if group.Type != "fallback" || !reflect.DeepEqual(group.Proxies, []string{"fast", "slow"}) ||
+		group.URL != "http://probe.example/204" || group.Interval != 300 {
+		t.Errorf("group = %+v", group)
+	}
+}
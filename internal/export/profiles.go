@@ -0,0 +1,249 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/internal/tester"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// grades lists profile groups from best to worst
+var grades = []string{"A", "B", "C", "D"}
+
+// ProfileGroupName returns the profile group name for a grade
+func ProfileGroupName(grade string) string {
+	return FailoverGroupName + " " + grade
+}
+
+// v2rayNConfigTypes maps protocol types to v2rayN's EConfigType
+var v2rayNConfigTypes = map[models.ProtocolType]int{
+	models.ProtocolVMess:       1,
+	models.ProtocolShadowsocks: 3,
+	models.ProtocolVLESS:       5,
+	models.ProtocolTrojan:      6,
+	models.ProtocolHysteria2:   7,
+	models.ProtocolTUIC:        8,
+	models.ProtocolWireGuard:   9,
+}
+
+// v2rayNSubItem is a v2rayN subscription group
+type v2rayNSubItem struct {
+	ID      string `json:"id"`
+	Remarks string `json:"remarks"`
+	URL     string `json:"url"`
+	Enabled bool   `json:"enabled"`
+	Sort    int    `json:"sort"`
+}
+
+// v2rayNProfile is a v2rayN ProfileItem
+type v2rayNProfile struct {
+	IndexID        string `json:"indexId"`
+	ConfigType     int    `json:"configType"`
+	ConfigVersion  int    `json:"configVersion"`
+	Address        string `json:"address"`
+	Port           int    `json:"port"`
+	ID             string `json:"id"`
+	AlterID        int    `json:"alterId"`
+	Security       string `json:"security"`
+	Network        string `json:"network"`
+	Remarks        string `json:"remarks"`
+	HeaderType     string `json:"headerType"`
+	RequestHost    string `json:"requestHost"`
+	Path           string `json:"path"`
+	StreamSecurity string `json:"streamSecurity"`
+	AllowInsecure  string `json:"allowInsecure"`
+	Subid          string `json:"subid"`
+	Flow           string `json:"flow"`
+	SNI            string `json:"sni"`
+	ALPN           string `json:"alpn"`
+	Fingerprint    string `json:"fingerprint"`
+	PublicKey      string `json:"publicKey"`
+	ShortID        string `json:"shortId"`
+	Sort           int    `json:"sort"`
+}
+
+// V2RayNProfiles builds a v2rayN guiNConfig-style JSON with working nodes
+// as profiles, grouped into subscription groups by grade ("ProtoScope A",
+// "ProtoScope B", ...) and sorted by ProtoScope's ranking
+func V2RayNProfiles(results []*models.TestResult) ([]byte, error) {
+	ranked := models.RankResults(results)
+	if len(ranked) == 0 {
+		return nil, fmt.Errorf("no working nodes to export")
+	}
+
+	names := UniqueNames(ranked)
+	used := make(map[string]bool)
+	profiles := make([]v2rayNProfile, 0, len(ranked))
+
+	for i, result := range ranked {
+		// v2rayN has no SSH profiles
+		if _, ok := v2rayNConfigTypes[result.Protocol.Type]; !ok {
+			continue
+		}
+		grade := result.Grade()
+		used[grade] = true
+
+		profile, err := v2rayNProfileFor(result.Protocol)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", result.Protocol.Name, err)
+		}
+		profile.IndexID = fmt.Sprintf("protoscope-%d", i+1)
+		profile.Remarks = names[i]
+		profile.Subid = "protoscope-" + strings.ToLower(grade)
+		profile.Sort = i + 1
+		profiles = append(profiles, profile)
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no working nodes of a protocol v2rayN supports")
+	}
+
+	var subItems []v2rayNSubItem
+	for i, grade := range grades {
+		if used[grade] {
+			subItems = append(subItems, v2rayNSubItem{
+				ID:      "protoscope-" + strings.ToLower(grade),
+				Remarks: ProfileGroupName(grade),
+				Enabled: true,
+				Sort:    i + 1,
+			})
+		}
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"subItem": subItems,
+		"vmess":   profiles, // v2rayN keeps profiles of all types under "vmess"
+	}, "", "  ")
+}
+
+// v2rayNProfileFor converts a protocol into a v2rayN profile
+func v2rayNProfileFor(protocol *models.Protocol) (v2rayNProfile, error) {
+	configType, ok := v2rayNConfigTypes[protocol.Type]
+	if !ok {
+		return v2rayNProfile{}, fmt.Errorf("unsupported protocol for v2rayN: %s", protocol.Type)
+	}
+
+	profile := v2rayNProfile{
+		ConfigType:    configType,
+		ConfigVersion: 2,
+		Address:       protocol.Server,
+		Port:          protocol.Port,
+		Network:       protocol.Network,
+		HeaderType:    "none",
+		RequestHost:   extraString(protocol, "host"),
+		Path:          extraString(protocol, "path"),
+		SNI:           protocol.SNI,
+		ALPN:          extraString(protocol, "alpn"),
+		Fingerprint:   extraString(protocol, "fp"),
+		Flow:          extraString(protocol, "flow"),
+	}
+	if profile.Network == "" {
+		profile.Network = "tcp"
+	}
+	if profile.Network == "grpc" {
+		profile.Path = extraString(protocol, "serviceName")
+	}
+	if protocol.TLS {
+		profile.StreamSecurity = "tls"
+	}
+	if extraString(protocol, "security") == "reality" {
+		profile.StreamSecurity = "reality"
+		profile.PublicKey = extraString(protocol, "pbk")
+		profile.ShortID = extraString(protocol, "sid")
+	}
+	if extraString(protocol, "insecure") == "1" {
+		profile.AllowInsecure = "true"
+	}
+
+	switch protocol.Type {
+	case models.ProtocolVMess:
+		profile.ID = protocol.UUID
+		profile.AlterID = extraInt(protocol, "aid")
+		profile.Security = "auto"
+	case models.ProtocolVLESS:
+		profile.ID = protocol.UUID
+		profile.Security = "none"
+	case models.ProtocolTrojan, models.ProtocolHysteria2:
+		profile.ID = protocol.Password
+	case models.ProtocolShadowsocks:
+		profile.ID = protocol.Password
+		profile.Security = extraString(protocol, "method")
+		if profile.Security == "" {
+			profile.Security = "aes-256-gcm"
+		}
+	case models.ProtocolTUIC:
+		profile.ID = protocol.UUID
+		profile.Security = protocol.Password
+		profile.HeaderType = extraString(protocol, "congestion_control")
+	case models.ProtocolWireGuard:
+		// v2rayN keeps the local address, reserved bytes and MTU in
+		// fields named for other protocols
+		profile.ID = protocol.Password
+		profile.PublicKey = extraString(protocol, "publickey")
+		profile.RequestHost = extraString(protocol, "address")
+		profile.Path = extraString(protocol, "reserved")
+		profile.ShortID = extraString(protocol, "mtu")
+	}
+
+	return profile, nil
+}
+
+// NekoBoxProfiles builds a sing-box config fragment for NekoBox (and other
+// sing-box based clients) with working nodes as outbounds, a urltest group
+// per grade and a selector over the grade groups, best grade first
+func NekoBoxProfiles(results []*models.TestResult, opts FailoverOptions) ([]byte, error) {
+	ranked := models.RankResults(results)
+	if len(ranked) == 0 {
+		return nil, fmt.Errorf("no working nodes to export")
+	}
+
+	names := UniqueNames(ranked)
+	members := make(map[string][]string)
+	nodes := make([]map[string]interface{}, 0, len(ranked))
+	var endpoints []map[string]interface{}
+
+	for i, result := range ranked {
+		outbound, err := tester.SingboxOutbound(result.Protocol, names[i])
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", result.Protocol.Name, err)
+		}
+		if tester.IsSingboxEndpoint(outbound) {
+			endpoints = append(endpoints, outbound)
+		} else {
+			nodes = append(nodes, outbound)
+		}
+
+		grade := result.Grade()
+		members[grade] = append(members[grade], names[i])
+	}
+
+	var groupNames []string
+	var groups []map[string]interface{}
+	for _, grade := range grades {
+		if len(members[grade]) == 0 {
+			continue
+		}
+		name := ProfileGroupName(grade)
+		groupNames = append(groupNames, name)
+		groups = append(groups, map[string]interface{}{
+			"type":      "urltest",
+			"tag":       name,
+			"outbounds": members[grade],
+			"url":       opts.TestURL,
+			"interval":  opts.Interval,
+		})
+	}
+
+	outbounds := make([]map[string]interface{}, 0, len(groups)+len(nodes)+1)
+	outbounds = append(outbounds, map[string]interface{}{
+		"type":      "selector",
+		"tag":       FailoverGroupName,
+		"outbounds": groupNames,
+		"default":   groupNames[0],
+	})
+	outbounds = append(outbounds, groups...)
+	outbounds = append(outbounds, nodes...)
+
+	return json.MarshalIndent(singboxFragment(outbounds, endpoints), "", "  ")
+}
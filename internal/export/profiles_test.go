@@ -0,0 +1,79 @@
+package export
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestV2RayNProfiles(t *testing.T) {
+	ssh := exportResult("ssh", 50)
+	ssh.Protocol.Type = models.ProtocolSSH
+	data, err := V2RayNProfiles([]*models.TestResult{exportResult("b", 35), ssh, exportResult("a", 50)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config struct {
+		SubItem []v2rayNSubItem `json:"subItem"`
+		VMess   []v2rayNProfile `json:"vmess"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(config.SubItem) != 2 || config.SubItem[0].Remarks != "ProtoScope A" || config.SubItem[1].Remarks != "ProtoScope B" {
+		t.Errorf("groups = %+v, want ProtoScope A and B", config.SubItem)
+	}
+	if len(config.VMess) != 2 {
+		t.Fatalf("profiles = %+v, want the two trojan nodes", config.VMess)
+	}
+	first := config.VMess[0]
+	if first.Remarks != "a" || first.Subid != "protoscope-a" || first.ConfigType != 6 ||
+		first.ID != "secret" || first.StreamSecurity != "tls" || first.SNI != "node.example" {
+		t.Errorf("first profile = %+v", first)
+	}
+	if config.VMess[1].Remarks != "b" || config.VMess[1].Subid != "protoscope-b" {
+		t.Errorf("second profile = %+v", config.VMess[1])
+	}
+
+	if _, err := V2RayNProfiles([]*models.TestResult{ssh}); err == nil {
+		t.Error("expected an error with only nodes v2rayN can't hold")
+	}
+}
+
+func TestNekoBoxProfiles(t *testing.T) {
+	results := []*models.TestResult{exportResult("d", 10), exportResult("a1", 50), exportResult("a2", 45)}
+	data, err := NekoBoxProfiles(results, DefaultFailoverOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fragment struct {
+		Outbounds []struct {
+			Type      string   `json:"type"`
+			Tag       string   `json:"tag"`
+			Outbounds []string `json:"outbounds"`
+			Default   string   `json:"default"`
+		} `json:"outbounds"`
+	}
+	if err := json.Unmarshal(data, &fragment); err != nil {
+		t.Fatal(err)
+	}
+	if len(fragment.Outbounds) != 6 {
+		t.Fatalf("outbounds = %+v, want a selector, two grade groups and three nodes", fragment.Outbounds)
+	}
+
+	selector := fragment.Outbounds[0]
+	if selector.Type != "selector" || selector.Default != "ProtoScope A" ||
+		!reflect.DeepEqual(selector.Outbounds, []string{"ProtoScope A", "ProtoScope D"}) {
+		t.Errorf("selector = %+v", selector)
+	}
+	groupA := fragment.Outbounds[1]
+	if groupA.Type != "urltest" || !reflect.DeepEqual(groupA.Outbounds, []string{"a1", "a2"}) {
+		t.Errorf("group A = %+v", groupA)
+	}
+	if groupD := fragment.Outbounds[2]; !reflect.DeepEqual(groupD.Outbounds, []string{"d"}) {
+		t.Errorf("group D = %+v", groupD)
+	}
+}
@@ -0,0 +1,34 @@
+package export
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/internal/parser"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Subscription builds a base64 subscription of the working nodes' original
+// share links, ordered by ProtoScope's ranking, as served by subscription
+// providers and understood by v2rayN, Shadowrocket and similar clients.
+// Nodes without an original link, e.g. from a Clash config, get their
+// canonical one.
+func Subscription(results []*models.TestResult) ([]byte, error) {
+	ranked := models.RankResults(results)
+
+	links := make([]string, 0, len(ranked))
+	for _, result := range ranked {
+		if result.Protocol.Raw != "" {
+			links = append(links, result.Protocol.Raw)
+		} else if link, err := parser.Canonical(result.Protocol); err == nil {
+			links = append(links, link)
+		}
+	}
+	if len(links) == 0 {
+		return nil, fmt.Errorf("no working nodes to export")
+	}
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(strings.Join(links, "\n")))
+	return []byte(encoded), nil
+}
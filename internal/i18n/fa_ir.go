@@ -0,0 +1,195 @@
+package i18n
+
+// faIR is the Persian catalog
+var faIR = map[string]string{
+	// Console
+	"Protocol Security Tester":                                    "آزمایشگر امنیت پروتکل",
+	"Reading subscription from file: %s":                          "خواندن اشتراک از فایل: %s",
+	"Fetching subscription from: %s":                              "دریافت اشتراک از: %s",
+	"Found %d protocols":                                          "%d پروتکل یافت شد",
+	"Removed %d duplicate nodes":                                  "%d گره تکراری حذف شد",
+	"Skipped %d links that failed to parse (-verbose lists them)": "%d پیوند که تجزیه نشد نادیده گرفته شد (فهرست با -verbose)",
+	"Skipped %d links that failed to parse:":                      "%d پیوند که تجزیه نشد نادیده گرفته شد:",
+	"Nodes ProtoScope can't test: %s":                             "گره‌هایی که ProtoScope نمی‌تواند آزمایش کند: %s",
+	"line %d":                                                     "خط %d",
+	"No protocols found in subscription":                          "هیچ پروتکلی در اشتراک یافت نشد",
+	"Filtered to %d protocols: %s":                                "فیلتر شد به %d پروتکل: %s",
+	"Running quick connectivity tests...":                         "در حال اجرای آزمون سریع اتصال...",
+	"Running comprehensive tests...":                              "در حال اجرای آزمون‌های کامل...",
+	"Testing: %s [%s]":                                            "در حال آزمون: %s [%s]",
+	"Server: %s:%d":                                               "سرور: %s:%d",
+	"Error: %v":                                                   "خطا: %v",
+	"Connected (%dms)":                                            "متصل شد (%dms)",
+	"Skipped: %s":                                                 "رد شد: %s",
+	"Failed: %s":                                                  "ناموفق: %s",
+	"Type: %s":                                                    "نوع: %s",
+	"Suggestion: %s":                                              "پیشنهاد: %s",
+	"Details: %s":                                                 "جزئیات: %s",
+	"Backend Log:":                                                "گزارش بک‌اند:",
+	"Unchanged, result from %s":                                   "بدون تغییر، نتیجه از %s",
+	"Partial: node deadline reached, showing completed checks only": "ناقص: مهلت گره به پایان رسید، فقط بررسی‌های کامل‌شده نمایش داده می‌شوند",
+	"Speed: ↓%.1f Mbps": "سرعت: ↓%.1f Mbps",
+	"Backend counted: ↓%.1f Mbps (%.1f MB down, %.1f KB up)": "شمارش بک‌اند: ↓%.1f Mbps (%.1f MB دریافت، %.1f KB ارسال)",
+	"Blockpage detected: %d domains":                         "صفحه مسدودسازی شناسایی شد: %d دامنه",
+	"Sensitive sites: all %d reachable":                      "سایت‌های حساس: همه %d در دسترس",
+	"Sensitive sites: %d/%d reachable, blocked %s":           "سایت‌های حساس: %d/%d در دسترس، مسدود %s",
+	"Country mismatch: advertised as %s, exits in %s":        "عدم تطابق کشور: اعلام‌شده %s، خروج از %s",
+	"Gaming: %s":                                  "بازی: %s",
+	"Streaming: %s":                               "پخش: %s",
+	"Latency: %dms":                               "تأخیر: %dms",
+	"Geo: %d/%d accessible (%.0f%%)":              "جغرافیایی: %d/%d در دسترس (%.0f%%)",
+	"DNS Leak: %s":                                "نشت DNS: %s",
+	"Blocked: %d/%d domains":                      "مسدود: %d/%d دامنه",
+	"Ad-blocking: %.0f%% effective":               "مسدودسازی تبلیغات: %.0f%% مؤثر",
+	"Blocklists left out: %s":                     "فهرست‌های کنار گذاشته‌شده: %s",
+	"DNSSEC: %s":                                  "DNSSEC: %s",
+	"Security Score: %d/100":                      "امتیاز امنیت: %d/100",
+	"Hosting: %s":                                 "میزبان: %s",
+	"Proxy lists: not listed (%d checked)":        "فهرست‌های پراکسی: فهرست نشده (%d بررسی شد)",
+	"Proxy lists: likely flagged as a proxy (%s)": "فهرست‌های پراکسی: احتمالاً به‌عنوان پراکسی شناسایی می‌شود (%s)",
+	"Egress: all %d probed ports open":            "خروجی: همه %d پورت بررسی‌شده باز است",
+	"Egress: blocked %s":                          "خروجی: مسدود %s",
+	"TLS fingerprint: %s":                         "اثر انگشت TLS: %s",
+	"TLS fingerprint: JA3 %s (%s)":                "اثر انگشت TLS: JA3 %s (%s)",
+	"fp=%s requested, but the backend sent a plain Go TLS hello": "fp=%s درخواست شده، اما بک‌اند یک TLS hello ساده Go فرستاد",
+	"Obfuscation (%s): %s":                       "مبهم‌سازی (%s): %s",
+	"Obfuscation (%s): effective, looks like %s": "مبهم‌سازی (%s): مؤثر، شبیه %s است",
+	"Obfuscation (%s): misconfigured":            "مبهم‌سازی (%s): پیکربندی نادرست",
+	"Active probing: %s":                         "کاوش فعال: %s",
+	"Active probing: %s (%d/100)":                "کاوش فعال: %s (%d/100)",
+	"Skipped %s: %s":                             "رد شد %s: %s",
+	"Resolved IPs: %s":                           "IPهای یافت‌شده: %s",
+	"Tested through %s":                          "آزموده از طریق %s",
+	"Trace: %s":                                  "ردیابی مسیر: %s",
+	"Trace: %d hops":                             "ردیابی مسیر: %d گام",
+	", worst hop #%d %dms":                       "، کندترین گام #%d %dms",
+	"%3.0f%% loss":                               "اتلاف %3.0f%%",
+	"PMTU black hole: larger packets are dropped silently":          "سیاه‌چاله PMTU: بسته‌های بزرگ‌تر بی‌صدا حذف می‌شوند",
+	"too small for full-size QUIC packets":                          "برای بسته‌های QUIC با اندازه کامل خیلی کوچک است",
+	"Ping: no reply (%s)":                                           "پینگ: بدون پاسخ (%s)",
+	"Ping: %dms (%s, %.0f%% loss)":                                  "پینگ: %dms (%s، اتلاف %.0f%%)",
+	" - proxy overhead: %dms":                                       " - سربار پراکسی: %dms",
+	"Test Summary":                                                  "خلاصه آزمون",
+	"Total Protocols: %d":                                           "کل پروتکل‌ها: %d",
+	"Working: %d (%.1f%%)":                                          "فعال: %d (%.1f%%)",
+	"Partial: %d (working, some checks hit the deadline)":           "ناقص: %d (فعال، برخی بررسی‌ها به مهلت رسیدند)",
+	"Failed: %d (%.1f%%)":                                           "ناموفق: %d (%.1f%%)",
+	"Average Latency: %dms":                                         "میانگین تأخیر: %dms",
+	"↓%.1f / ↑%.1f Mbps, ping %dms, server %s":                      "↓%.1f / ↑%.1f Mbps، پینگ %dms، سرور %s",
+	"%.1f MB downloaded, speeds may read ~%.0f%% low":               "%.1f مگابایت دانلود شد، سرعت‌ها ممکن است حدود %.0f%% کمتر نشان داده شوند",
+	"%d speed tests skipped, run data cap reached":                  "%d تست سرعت رد شد، سقف داده این اجرا پر شد",
+	"metered, %.1f MB; may read ~%.0f%% low":                        "حجمی، %.1f مگابایت؛ ممکن است حدود %.0f%% کمتر باشد",
+	"%d nodes exit in another country than their name advertises":   "%d گره از کشوری غیر از کشور اعلام‌شده در نام خارج می‌شوند",
+	"Clock is %s; VMess and Shadowsocks 2022 nodes need it synced":  "ساعت %s است؛ گره‌های VMess و Shadowsocks 2022 به ساعت همگام نیاز دارند",
+	"By advertised country (working/total): %s":                     "بر اساس کشور اعلام‌شده (فعال/کل): %s",
+	"Subscription comparison:":                                      "مقایسه اشتراک‌ها:",
+	"Provider Scorecard: %s":                                        "کارنامه ارائه‌دهنده: %s",
+	"Overall Grade":                                                 "نمره کلی",
+	"%d working":                                                    "%d فعال",
+	"Countries":                                                     "کشورها",
+	"Protocols":                                                     "پروتکل‌ها",
+	"Category":                                                      "دسته",
+	"Grade":                                                         "نمره",
+	"%s: %d/%d working (%.1f%%), median %s, %s":                     "%s: %d/%d فعال (%.1f%%)، میانه %s، %s",
+	"Average Speed: %.1f Mbps":                                      "میانگین سرعت: %.1f Mbps",
+	"Tip: Use -format json or -format markdown for detailed output": "نکته: برای خروجی کامل از -format json یا -format markdown استفاده کنید",
+	"%d/%d nodes exit in the advertised country":                    "%d/%d گره از کشور اعلام‌شده خارج می‌شوند",
+	"Tested with ProtoScope %s":                                     "آزمایش‌شده با ProtoScope %s",
+	"from %s":                                                       "از %s",
+	"Use -verbose for more details in console mode":                 "برای جزئیات بیشتر در حالت کنسول از -verbose استفاده کنید",
+
+	// Markdown report
+	"ProtoScope Test Results":               "نتایج آزمون ProtoScope",
+	"Score":                                 "امتیاز",
+	"Details":                               "جزئیات",
+	"Reliability":                           "پایداری",
+	"Speed":                                 "سرعت",
+	"Unlock":                                "رفع محدودیت",
+	"Privacy":                               "حریم خصوصی",
+	"Advertised Locations":                  "مکان‌های اعلام‌شده",
+	"%d/%d nodes working":                   "%d/%d گره فعال",
+	"median %dms":                           "میانه %d میلی‌ثانیه",
+	"median %.1f Mbps":                      "میانه %.1f مگابیت بر ثانیه",
+	"%d/%d geo-restricted sites reached":    "%d/%d سایت دارای محدودیت جغرافیایی در دسترس",
+	"average %d/100, %d nodes leaking":      "میانگین %d/100، نشت در %d گره",
+	"Generated":                             "زمان تولید",
+	"Total Protocols":                       "کل پروتکل‌ها",
+	"Summary":                               "خلاصه",
+	"Working":                               "فعال",
+	"Partial":                               "ناقص",
+	"working, some checks hit the deadline": "فعال، برخی بررسی‌ها به مهلت رسیدند",
+	"Failed":                                "ناموفق",
+	"Average Latency":                       "میانگین تأخیر",
+	"Nodes":                                 "گره‌ها",
+	"Country":                               "کشور",
+	"By Advertised Country":                 "بر اساس کشور اعلام‌شده",
+	"Subscription Comparison":               "مقایسه اشتراک‌ها",
+	"Subscription":                          "اشتراک",
+	"Median Latency":                        "میانه تأخیر",
+	"Median Speed":                          "میانه سرعت",
+	"Unlock %s":                             "دسترسی %s",
+	"Detailed Results":                      "نتایج تفصیلی",
+	"Type":                                  "نوع",
+	"Server":                                "سرور",
+	"Tested":                                "زمان آزمون",
+	"unchanged, cached":                     "بدون تغییر، از حافظه نهان",
+	"Response Time":                         "زمان پاسخ",
+	"Ping (%s)":                             "پینگ (%s)",
+	"%dms, %.0f%% loss":                     "%dms، اتلاف %.0f%%",
+	"Trace":                                 "ردیابی مسیر",
+	"%d hops, worst hop #%d %dms":           "%d گام، کندترین گام #%d %dms",
+	"Path MTU":                              "MTU مسیر",
+	"fragmentation issue":                   "مشکل قطعه‌بندی",
+	"Download Speed":                        "سرعت دانلود",
+	"Backend Counted Speed":                 "سرعت شمارش‌شده بک‌اند",
+	"Latency":                               "تأخیر",
+	"Geo Access":                            "دسترسی جغرافیایی",
+	"Security Score":                        "امتیاز امنیت",
+	"Hosting":                               "میزبان",
+	"advertised as %s, exits in %s":         "اعلام‌شده %s، خروج از %s",
+	"Country Mismatch":                      "عدم تطابق کشور",
+	"CDN Edge":                              "لبه CDN",
+	"%s near the exit, %s near you":         "%s نزدیک خروجی، %s نزدیک شما",
+	"Upload: ↑%.1f Mbps":                    "آپلود: ↑%.1f Mbps",
+	"Speed test server: %s":                 "سرور تست سرعت: %s",
+	"Upload Speed":                          "سرعت آپلود",
+	"Speed Test Server":                     "سرور تست سرعت",
+	"ping %dms":                             "پینگ %dms",
+	"Metered":                               "اینترنت حجمی",
+	"skipped, run data cap reached":         "رد شد، سقف داده این اجرا پر شد",
+	"Proxy Detection":                       "شناسایی پراکسی",
+	"validating":                            "اعتبارسنجی می‌شود",
+	"not validating":                        "اعتبارسنجی نمی‌شود",
+	"inconclusive":                          "نامشخص",
+	"not listed":                            "فهرست نشده",
+	"likely flagged":                        "احتمالاً شناسایی می‌شود",
+	"Blocked Ports":                         "پورت‌های مسدود",
+	"none":                                  "هیچ",
+	"Sensitive Sites":                       "سایت‌های حساس",
+	"blocked":                               "مسدود",
+	"unreachable":                           "در دسترس نیست",
+	"Gaming":                                "بازی",
+	"Streaming":                             "پخش",
+	"locked":                                "قفل",
+	"good":                                  "خوب",
+	"playable":                              "قابل بازی",
+	"poor":                                  "ضعیف",
+	"%s, best %dms to %s (jitter %dms)":     "%s، بهترین %dms تا %s (جیتر %dms)",
+	"TLS Fingerprint":                       "اثر انگشت TLS",
+	"Obfuscation (%s)":                      "مبهم‌سازی (%s)",
+	"effective":                             "مؤثر",
+	"Active Probing":                        "کاوش فعال",
+	"Note":                                  "یادداشت",
+	"resistant":                             "مقاوم",
+	"partial":                               "تا حدی مقاوم",
+	"fingerprintable":                       "قابل شناسایی",
+	"Skipped %s":                            "رد شد %s",
+	"Error":                                 "خطا",
+	"Vantage Country":                       "کشور محل آزمایش",
+	"Platform":                              "پلتفرم",
+	"Backend":                               "بک‌اند",
+	"Config Hash":                           "هش پیکربندی",
+	"Command Line":                          "خط فرمان",
+	"Version":                               "نسخه",
+	"Run Information":                       "اطلاعات اجرا",
+}
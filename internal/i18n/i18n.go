@@ -0,0 +1,110 @@
+// Package i18n translates console and report strings. Messages are looked up
+// by their English text, so untranslated strings fall back to English and
+// call sites stay readable.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// English is the source language of all messages
+const English = "en"
+
+// catalogs maps a language tag to its translations, keyed by English text
+var catalogs = map[string]map[string]string{
+	"zh-CN": zhCN,
+	"fa-IR": faIR,
+	"ru-RU": ruRU,
+}
+
+// current is the active catalog; nil means English
+var (
+	current     map[string]string
+	currentLang = English
+)
+
+func init() {
+	if lang, ok := Match(Detect()); ok {
+		setLanguage(lang)
+	}
+}
+
+// Supported returns the supported language tags
+func Supported() []string {
+	return []string{English, "fa-IR", "ru-RU", "zh-CN"}
+}
+
+// SetLanguage switches all output to a language tag such as "zh-CN", "ru"
+// or "fa_IR.UTF-8"
+func SetLanguage(tag string) error {
+	lang, ok := Match(tag)
+	if !ok {
+		return fmt.Errorf("unsupported language %q (supported: %s)", tag, strings.Join(Supported(), ", "))
+	}
+	setLanguage(lang)
+	return nil
+}
+
+func setLanguage(lang string) {
+	currentLang = lang
+	current = catalogs[lang]
+}
+
+// Language returns the active language tag
+func Language() string {
+	return currentLang
+}
+
+// T returns the translation of an English message, or the message itself
+// when the active language has none
+func T(message string) string {
+	if translated, ok := current[message]; ok {
+		return translated
+	}
+	return message
+}
+
+// Match maps a language tag or POSIX locale to a supported language:
+// an exact match first, then by the primary language ("ru" → "ru-RU")
+func Match(tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i] // zh_CN.UTF-8, sr_RS@latin
+	}
+	tag = strings.ReplaceAll(tag, "_", "-")
+	if tag == "" {
+		return "", false
+	}
+
+	primary, _, _ := strings.Cut(tag, "-")
+	for _, lang := range Supported() {
+		if strings.EqualFold(lang, tag) {
+			return lang, true
+		}
+	}
+	for _, lang := range Supported() {
+		langPrimary, _, _ := strings.Cut(lang, "-")
+		if strings.EqualFold(langPrimary, primary) {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// Detect returns the user's locale from the environment, in POSIX priority
+// order, or "" when none is set
+func Detect() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG", "LANGUAGE"} {
+		value := os.Getenv(name)
+		if name == "LANGUAGE" {
+			// A colon-separated preference list
+			value, _, _ = strings.Cut(value, ":")
+		}
+		if value != "" && value != "C" && value != "POSIX" {
+			return value
+		}
+	}
+	return ""
+}
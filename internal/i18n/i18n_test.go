@@ -0,0 +1,73 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"zh_CN.UTF-8", "zh-CN"},
+		{"zh-cn", "zh-CN"},
+		{"zh_TW", "zh-CN"},
+		{"ru", "ru-RU"},
+		{"fa_IR", "fa-IR"},
+		{"en_US.UTF-8", "en"},
+		{"de_DE", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got, _ := Match(tt.tag); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "C")
+	t.Setenv("LANG", "fa_IR.UTF-8")
+	if got := Detect(); got != "fa_IR.UTF-8" {
+		t.Errorf("Detect() = %q, want LANG", got)
+	}
+}
+
+var verbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// TestCatalogs checks that every catalog translates the same messages and
+// keeps their format verbs, so Printf arguments still line up
+func TestCatalogs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for message, translated := range catalog {
+			if !slices.Equal(verbs.FindAllString(message, -1), verbs.FindAllString(translated, -1)) {
+				t.Errorf("%s: %q changes the format verbs of %q", lang, translated, message)
+			}
+			for other, otherCatalog := range catalogs {
+				if _, ok := otherCatalog[message]; !ok {
+					t.Errorf("%s has no translation of %q (present in %s)", other, message, lang)
+				}
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	defer setLanguage(currentLang)
+
+	if err := SetLanguage("ru"); err != nil {
+		t.Fatal(err)
+	}
+	if got := T("Summary"); got != "Сводка" {
+		t.Errorf("T(Summary) = %q", got)
+	}
+	if got := T("not a catalog message"); got != "not a catalog message" {
+		t.Errorf("untranslated message changed: %q", got)
+	}
+	if err := SetLanguage("xx"); err == nil {
+		t.Error("expected an error for an unsupported language")
+	}
+}
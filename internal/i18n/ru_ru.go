@@ -0,0 +1,195 @@
+package i18n
+
+// ruRU is the Russian catalog
+var ruRU = map[string]string{
+	// Console
+	"Protocol Security Tester":                                    "Тестер безопасности протоколов",
+	"Reading subscription from file: %s":                          "Чтение подписки из файла: %s",
+	"Fetching subscription from: %s":                              "Загрузка подписки: %s",
+	"Found %d protocols":                                          "Найдено протоколов: %d",
+	"Removed %d duplicate nodes":                                  "Удалено дубликатов: %d",
+	"Skipped %d links that failed to parse (-verbose lists them)": "Пропущено ссылок с ошибками разбора: %d (список: -verbose)",
+	"Skipped %d links that failed to parse:":                      "Пропущено ссылок с ошибками разбора: %d:",
+	"Nodes ProtoScope can't test: %s":                             "Узлы, которые ProtoScope не может проверить: %s",
+	"line %d":                                                     "строка %d",
+	"No protocols found in subscription":                          "В подписке не найдено протоколов",
+	"Filtered to %d protocols: %s":                                "После фильтра осталось протоколов: %d (%s)",
+	"Running quick connectivity tests...":                         "Быстрая проверка подключения...",
+	"Running comprehensive tests...":                              "Полная проверка...",
+	"Testing: %s [%s]":                                            "Проверка: %s [%s]",
+	"Server: %s:%d":                                               "Сервер: %s:%d",
+	"Error: %v":                                                   "Ошибка: %v",
+	"Connected (%dms)":                                            "Подключено (%d мс)",
+	"Skipped: %s":                                                 "Пропущено: %s",
+	"Failed: %s":                                                  "Сбой: %s",
+	"Type: %s":                                                    "Тип: %s",
+	"Suggestion: %s":                                              "Совет: %s",
+	"Details: %s":                                                 "Подробности: %s",
+	"Backend Log:":                                                "Журнал бэкенда:",
+	"Unchanged, result from %s":                                   "Без изменений, результат от %s",
+	"Partial: node deadline reached, showing completed checks only": "Частично: истёк лимит времени узла, показаны только завершённые проверки",
+	"Speed: ↓%.1f Mbps": "Скорость: ↓%.1f Мбит/с",
+	"Backend counted: ↓%.1f Mbps (%.1f MB down, %.1f KB up)": "По счётчикам бэкенда: ↓%.1f Мбит/с (принято %.1f МБ, отправлено %.1f КБ)",
+	"Blockpage detected: %d domains":                         "Обнаружена страница блокировки: доменов %d",
+	"Sensitive sites: all %d reachable":                      "Чувствительные сайты: все %d доступны",
+	"Sensitive sites: %d/%d reachable, blocked %s":           "Чувствительные сайты: доступно %d/%d, заблокированы %s",
+	"Country mismatch: advertised as %s, exits in %s":        "Несовпадение страны: заявлено %s, выход в %s",
+	"Gaming: %s":                                  "Игры: %s",
+	"Streaming: %s":                               "Стриминг: %s",
+	"Latency: %dms":                               "Задержка: %d мс",
+	"Geo: %d/%d accessible (%.0f%%)":              "Гео: доступно %d/%d (%.0f%%)",
+	"DNS Leak: %s":                                "Утечка DNS: %s",
+	"Blocked: %d/%d domains":                      "Заблокировано доменов: %d/%d",
+	"Ad-blocking: %.0f%% effective":               "Блокировка рекламы: эффективность %.0f%%",
+	"Blocklists left out: %s":                     "Списки не загружены: %s",
+	"DNSSEC: %s":                                  "DNSSEC: %s",
+	"Security Score: %d/100":                      "Оценка безопасности: %d/100",
+	"Hosting: %s":                                 "Хостинг: %s",
+	"Proxy lists: not listed (%d checked)":        "Списки прокси: не найден (проверено %d)",
+	"Proxy lists: likely flagged as a proxy (%s)": "Списки прокси: вероятно, будет распознан как прокси (%s)",
+	"Egress: all %d probed ports open":            "Исходящие: все %d проверенных портов открыты",
+	"Egress: blocked %s":                          "Исходящие: заблокированы %s",
+	"TLS fingerprint: %s":                         "TLS-отпечаток: %s",
+	"TLS fingerprint: JA3 %s (%s)":                "TLS-отпечаток: JA3 %s (%s)",
+	"fp=%s requested, but the backend sent a plain Go TLS hello": "запрошен fp=%s, но бэкенд отправил обычный Go TLS hello",
+	"Obfuscation (%s): %s":                       "Обфускация (%s): %s",
+	"Obfuscation (%s): effective, looks like %s": "Обфускация (%s): работает, выглядит как %s",
+	"Obfuscation (%s): misconfigured":            "Обфускация (%s): настроена неверно",
+	"Active probing: %s":                         "Активное зондирование: %s",
+	"Active probing: %s (%d/100)":                "Активное зондирование: %s (%d/100)",
+	"Skipped %s: %s":                             "Пропущено %s: %s",
+	"Resolved IPs: %s":                           "IP-адреса: %s",
+	"Tested through %s":                          "Проверен через %s",
+	"Trace: %s":                                  "Трассировка: %s",
+	"Trace: %d hops":                             "Трассировка: хопов %d",
+	", worst hop #%d %dms":                       ", худший хоп #%d %d мс",
+	"%3.0f%% loss":                               "потери %3.0f%%",
+	"PMTU black hole: larger packets are dropped silently":          "PMTU black hole: большие пакеты молча отбрасываются",
+	"too small for full-size QUIC packets":                          "слишком мало для полноразмерных пакетов QUIC",
+	"Ping: no reply (%s)":                                           "Пинг: нет ответа (%s)",
+	"Ping: %dms (%s, %.0f%% loss)":                                  "Пинг: %d мс (%s, потери %.0f%%)",
+	" - proxy overhead: %dms":                                       " - накладные расходы прокси: %d мс",
+	"Test Summary":                                                  "Итоги проверки",
+	"Total Protocols: %d":                                           "Всего протоколов: %d",
+	"Working: %d (%.1f%%)":                                          "Работают: %d (%.1f%%)",
+	"Partial: %d (working, some checks hit the deadline)":           "Частично: %d (работают, часть проверок не уложилась в срок)",
+	"Failed: %d (%.1f%%)":                                           "Сбой: %d (%.1f%%)",
+	"Average Latency: %dms":                                         "Средняя задержка: %d мс",
+	"↓%.1f / ↑%.1f Mbps, ping %dms, server %s":                      "↓%.1f / ↑%.1f Мбит/с, пинг %d мс, сервер %s",
+	"%.1f MB downloaded, speeds may read ~%.0f%% low":               "загружено %.1f МБ, скорость может быть занижена на ~%.0f%%",
+	"%d speed tests skipped, run data cap reached":                  "пропущено замеров скорости: %d, достигнут лимит трафика",
+	"metered, %.1f MB; may read ~%.0f%% low":                        "лимит трафика, %.1f МБ; может быть занижена на ~%.0f%%",
+	"%d nodes exit in another country than their name advertises":   "Узлов с выходом не в заявленной стране: %d",
+	"Clock is %s; VMess and Shadowsocks 2022 nodes need it synced":  "Часы сбиты: %s; узлам VMess и Shadowsocks 2022 нужны точные часы",
+	"By advertised country (working/total): %s":                     "По заявленной стране (работают/всего): %s",
+	"Subscription comparison:":                                      "Сравнение подписок:",
+	"Provider Scorecard: %s":                                        "Карточка провайдера: %s",
+	"Overall Grade":                                                 "Итоговая оценка",
+	"%d working":                                                    "%d работают",
+	"Countries":                                                     "Страны",
+	"Protocols":                                                     "Протоколы",
+	"Category":                                                      "Категория",
+	"Grade":                                                         "Оценка",
+	"%s: %d/%d working (%.1f%%), median %s, %s":                     "%s: %d/%d работают (%.1f%%), медиана %s, %s",
+	"Average Speed: %.1f Mbps":                                      "Средняя скорость: %.1f Мбит/с",
+	"Tip: Use -format json or -format markdown for detailed output": "Совет: -format json или -format markdown дают подробный вывод",
+	"%d/%d nodes exit in the advertised country":                    "%d/%d узлов выходят в заявленной стране",
+	"Tested with ProtoScope %s":                                     "Проверено ProtoScope %s",
+	"from %s":                                                       "из %s",
+	"Use -verbose for more details in console mode":                 "-verbose покажет больше подробностей в консоли",
+
+	// Markdown report
+	"ProtoScope Test Results":               "Результаты проверки ProtoScope",
+	"Score":                                 "Баллы",
+	"Details":                               "Подробности",
+	"Reliability":                           "Надёжность",
+	"Speed":                                 "Скорость",
+	"Unlock":                                "Разблокировка",
+	"Privacy":                               "Приватность",
+	"Advertised Locations":                  "Заявленные локации",
+	"%d/%d nodes working":                   "%d/%d узлов работают",
+	"median %dms":                           "медиана %d мс",
+	"median %.1f Mbps":                      "медиана %.1f Мбит/с",
+	"%d/%d geo-restricted sites reached":    "доступно %d/%d сайтов с геоограничениями",
+	"average %d/100, %d nodes leaking":      "в среднем %d/100, утечки у %d узлов",
+	"Generated":                             "Создан",
+	"Total Protocols":                       "Всего протоколов",
+	"Summary":                               "Сводка",
+	"Working":                               "Работает",
+	"Partial":                               "Частично",
+	"working, some checks hit the deadline": "работают, часть проверок не уложилась в срок",
+	"Failed":                                "Сбой",
+	"Average Latency":                       "Средняя задержка",
+	"Nodes":                                 "Узлы",
+	"Country":                               "Страна",
+	"By Advertised Country":                 "По заявленной стране",
+	"Subscription Comparison":               "Сравнение подписок",
+	"Subscription":                          "Подписка",
+	"Median Latency":                        "Медианная задержка",
+	"Median Speed":                          "Медианная скорость",
+	"Unlock %s":                             "Доступ %s",
+	"Detailed Results":                      "Подробные результаты",
+	"Type":                                  "Тип",
+	"Server":                                "Сервер",
+	"Tested":                                "Проверен",
+	"unchanged, cached":                     "без изменений, из кэша",
+	"Response Time":                         "Время ответа",
+	"Ping (%s)":                             "Пинг (%s)",
+	"%dms, %.0f%% loss":                     "%d мс, потери %.0f%%",
+	"Trace":                                 "Трассировка",
+	"%d hops, worst hop #%d %dms":           "хопов %d, худший хоп #%d %d мс",
+	"Path MTU":                              "MTU пути",
+	"fragmentation issue":                   "проблема фрагментации",
+	"Download Speed":                        "Скорость загрузки",
+	"Backend Counted Speed":                 "Скорость по счётчикам бэкенда",
+	"Latency":                               "Задержка",
+	"Geo Access":                            "Гео-доступ",
+	"Security Score":                        "Оценка безопасности",
+	"Hosting":                               "Хостинг",
+	"advertised as %s, exits in %s":         "заявлено %s, выход в %s",
+	"Country Mismatch":                      "Несовпадение страны",
+	"CDN Edge":                              "Узел CDN",
+	"%s near the exit, %s near you":         "%s у выхода, %s рядом с вами",
+	"Upload: ↑%.1f Mbps":                    "Отдача: ↑%.1f Мбит/с",
+	"Speed test server: %s":                 "Сервер замера скорости: %s",
+	"Upload Speed":                          "Скорость отдачи",
+	"Speed Test Server":                     "Сервер замера скорости",
+	"ping %dms":                             "пинг %d мс",
+	"Metered":                               "Лимит трафика",
+	"skipped, run data cap reached":         "пропущено, достигнут лимит трафика",
+	"Proxy Detection":                       "Распознавание прокси",
+	"validating":                            "проверяется",
+	"not validating":                        "не проверяется",
+	"inconclusive":                          "не определено",
+	"not listed":                            "не найден",
+	"likely flagged":                        "вероятно, распознаётся",
+	"Blocked Ports":                         "Заблокированные порты",
+	"none":                                  "нет",
+	"Sensitive Sites":                       "Чувствительные сайты",
+	"blocked":                               "заблокированы",
+	"unreachable":                           "недоступен",
+	"Gaming":                                "Игры",
+	"Streaming":                             "Стриминг",
+	"locked":                                "недоступны",
+	"good":                                  "хорошо",
+	"playable":                              "играбельно",
+	"poor":                                  "плохо",
+	"%s, best %dms to %s (jitter %dms)":     "%s, лучший %dмс до %s (джиттер %dмс)",
+	"TLS Fingerprint":                       "TLS-отпечаток",
+	"Obfuscation (%s)":                      "Обфускация (%s)",
+	"effective":                             "работает",
+	"Active Probing":                        "Активное зондирование",
+	"Note":                                  "Заметка",
+	"resistant":                             "устойчив",
+	"partial":                               "частично устойчив",
+	"fingerprintable":                       "легко распознаётся",
+	"Skipped %s":                            "Пропущено %s",
+	"Error":                                 "Ошибка",
+	"Vantage Country":                       "Страна проверки",
+	"Platform":                              "Платформа",
+	"Backend":                               "Бэкенд",
+	"Config Hash":                           "Хеш конфигурации",
+	"Command Line":                          "Командная строка",
+	"Version":                               "Версия",
+	"Run Information":                       "Сведения о запуске",
+}
@@ -0,0 +1,195 @@
+package i18n
+
+// zhCN is the Simplified Chinese catalog
+var zhCN = map[string]string{
+	// Console
+	"Protocol Security Tester":                                    "协议安全测试工具",
+	"Reading subscription from file: %s":                          "从文件读取订阅：%s",
+	"Fetching subscription from: %s":                              "正在获取订阅：%s",
+	"Found %d protocols":                                          "找到 %d 个节点",
+	"Removed %d duplicate nodes":                                  "已移除 %d 个重复节点",
+	"Skipped %d links that failed to parse (-verbose lists them)": "已跳过 %d 个无法解析的链接（使用 -verbose 查看）",
+	"Skipped %d links that failed to parse:":                      "已跳过 %d 个无法解析的链接：",
+	"Nodes ProtoScope can't test: %s":                             "ProtoScope 无法测试的节点：%s",
+	"line %d":                                                     "第 %d 行",
+	"No protocols found in subscription":                          "订阅中没有找到节点",
+	"Filtered to %d protocols: %s":                                "筛选后剩余 %d 个节点：%s",
+	"Running quick connectivity tests...":                         "正在进行快速连通性测试...",
+	"Running comprehensive tests...":                              "正在进行全面测试...",
+	"Testing: %s [%s]":                                            "测试：%s [%s]",
+	"Server: %s:%d":                                               "服务器：%s:%d",
+	"Error: %v":                                                   "错误：%v",
+	"Connected (%dms)":                                            "已连接（%dms）",
+	"Skipped: %s":                                                 "已跳过：%s",
+	"Failed: %s":                                                  "失败：%s",
+	"Type: %s":                                                    "类型：%s",
+	"Suggestion: %s":                                              "建议：%s",
+	"Details: %s":                                                 "详情：%s",
+	"Backend Log:":                                                "后端日志：",
+	"Unchanged, result from %s":                                   "未变化，沿用 %s 的结果",
+	"Partial: node deadline reached, showing completed checks only": "部分完成：已达到节点时限，仅显示已完成的检查",
+	"Speed: ↓%.1f Mbps": "速度：↓%.1f Mbps",
+	"Backend counted: ↓%.1f Mbps (%.1f MB down, %.1f KB up)": "后端统计：↓%.1f Mbps（下行 %.1f MB，上行 %.1f KB）",
+	"Blockpage detected: %d domains":                         "检测到封锁页面：%d 个域名",
+	"Sensitive sites: all %d reachable":                      "敏感网站：%d 个全部可访问",
+	"Sensitive sites: %d/%d reachable, blocked %s":           "敏感网站：可访问 %d/%d，被封锁 %s",
+	"Country mismatch: advertised as %s, exits in %s":        "国家不符：宣称 %s，实际出口 %s",
+	"Gaming: %s":                                  "游戏：%s",
+	"Streaming: %s":                               "流媒体：%s",
+	"Latency: %dms":                               "延迟：%dms",
+	"Geo: %d/%d accessible (%.0f%%)":              "地区访问：%d/%d 可访问（%.0f%%）",
+	"DNS Leak: %s":                                "DNS 泄漏：%s",
+	"Blocked: %d/%d domains":                      "已拦截：%d/%d 个域名",
+	"Ad-blocking: %.0f%% effective":               "广告拦截：有效率 %.0f%%",
+	"Blocklists left out: %s":                     "未加载的拦截列表：%s",
+	"DNSSEC: %s":                                  "DNSSEC：%s",
+	"Security Score: %d/100":                      "安全评分：%d/100",
+	"Hosting: %s":                                 "托管商：%s",
+	"Proxy lists: not listed (%d checked)":        "代理名单：未列入（已检查 %d 个）",
+	"Proxy lists: likely flagged as a proxy (%s)": "代理名单：可能被识别为代理（%s）",
+	"Egress: all %d probed ports open":            "出站：%d 个探测端口全部开放",
+	"Egress: blocked %s":                          "出站：已封锁 %s",
+	"TLS fingerprint: %s":                         "TLS 指纹：%s",
+	"TLS fingerprint: JA3 %s (%s)":                "TLS 指纹：JA3 %s（%s）",
+	"fp=%s requested, but the backend sent a plain Go TLS hello": "已请求 fp=%s，但后端发送的是普通 Go TLS 握手",
+	"Obfuscation (%s): %s":                       "混淆（%s）：%s",
+	"Obfuscation (%s): effective, looks like %s": "混淆（%s）：有效，看起来像 %s",
+	"Obfuscation (%s): misconfigured":            "混淆（%s）：配置错误",
+	"Active probing: %s":                         "主动探测：%s",
+	"Active probing: %s (%d/100)":                "主动探测：%s（%d/100）",
+	"Skipped %s: %s":                             "已跳过 %s：%s",
+	"Resolved IPs: %s":                           "解析到的 IP：%s",
+	"Tested through %s":                          "测试使用 %s",
+	"Trace: %s":                                  "路由追踪：%s",
+	"Trace: %d hops":                             "路由追踪：%d 跳",
+	", worst hop #%d %dms":                       "，最慢一跳 #%d %dms",
+	"%3.0f%% loss":                               "丢包 %3.0f%%",
+	"PMTU black hole: larger packets are dropped silently":          "PMTU 黑洞：较大的数据包被静默丢弃",
+	"too small for full-size QUIC packets":                          "太小，无法容纳完整大小的 QUIC 数据包",
+	"Ping: no reply (%s)":                                           "Ping：无响应（%s）",
+	"Ping: %dms (%s, %.0f%% loss)":                                  "Ping：%dms（%s，丢包 %.0f%%）",
+	" - proxy overhead: %dms":                                       " - 代理开销：%dms",
+	"Test Summary":                                                  "测试摘要",
+	"Total Protocols: %d":                                           "节点总数：%d",
+	"Working: %d (%.1f%%)":                                          "可用：%d（%.1f%%）",
+	"Partial: %d (working, some checks hit the deadline)":           "部分完成：%d（可用，部分检查超时）",
+	"Failed: %d (%.1f%%)":                                           "失败：%d（%.1f%%）",
+	"Average Latency: %dms":                                         "平均延迟：%dms",
+	"↓%.1f / ↑%.1f Mbps, ping %dms, server %s":                      "↓%.1f / ↑%.1f Mbps，延迟 %dms，服务器 %s",
+	"%.1f MB downloaded, speeds may read ~%.0f%% low":               "已下载 %.1f MB，速度可能偏低约 %.0f%%",
+	"%d speed tests skipped, run data cap reached":                  "%d 个测速因达到本次流量上限而跳过",
+	"metered, %.1f MB; may read ~%.0f%% low":                        "按流量计费，%.1f MB；可能偏低约 %.0f%%",
+	"%d nodes exit in another country than their name advertises":   "%d 个节点的出口国家与名称宣称的不符",
+	"Clock is %s; VMess and Shadowsocks 2022 nodes need it synced":  "时钟偏差 %s；VMess 和 Shadowsocks 2022 节点需要同步时钟",
+	"By advertised country (working/total): %s":                     "按宣称国家（可用/总数）：%s",
+	"Subscription comparison:":                                      "订阅对比：",
+	"Provider Scorecard: %s":                                        "服务商评分卡：%s",
+	"Overall Grade":                                                 "总评",
+	"%d working":                                                    "%d 个可用",
+	"Countries":                                                     "国家",
+	"Protocols":                                                     "协议",
+	"Category":                                                      "类别",
+	"Grade":                                                         "等级",
+	"%s: %d/%d working (%.1f%%), median %s, %s":                     "%s：%d/%d 可用 (%.1f%%)，中位数 %s，%s",
+	"Average Speed: %.1f Mbps":                                      "平均速度：%.1f Mbps",
+	"Tip: Use -format json or -format markdown for detailed output": "提示：使用 -format json 或 -format markdown 获取详细输出",
+	"%d/%d nodes exit in the advertised country":                    "%d/%d 个节点的出口位于宣称的国家",
+	"Tested with ProtoScope %s":                                     "使用 ProtoScope %s 测试",
+	"from %s":                                                       "测试地点 %s",
+	"Use -verbose for more details in console mode":                 "在控制台模式下使用 -verbose 查看更多详情",
+
+	// Markdown report
+	"ProtoScope Test Results":               "ProtoScope 测试结果",
+	"Score":                                 "分数",
+	"Details":                               "详情",
+	"Reliability":                           "可靠性",
+	"Speed":                                 "速度",
+	"Unlock":                                "解锁",
+	"Privacy":                               "隐私",
+	"Advertised Locations":                  "宣称位置",
+	"%d/%d nodes working":                   "%d/%d 个节点可用",
+	"median %dms":                           "中位数 %dms",
+	"median %.1f Mbps":                      "中位数 %.1f Mbps",
+	"%d/%d geo-restricted sites reached":    "可访问 %d/%d 个地区限制网站",
+	"average %d/100, %d nodes leaking":      "平均 %d/100，%d 个节点泄漏",
+	"Generated":                             "生成时间",
+	"Total Protocols":                       "节点总数",
+	"Summary":                               "摘要",
+	"Working":                               "可用",
+	"Partial":                               "部分完成",
+	"working, some checks hit the deadline": "可用，部分检查超时",
+	"Failed":                                "失败",
+	"Average Latency":                       "平均延迟",
+	"Nodes":                                 "节点",
+	"Country":                               "国家",
+	"By Advertised Country":                 "按宣称国家",
+	"Subscription Comparison":               "订阅对比",
+	"Subscription":                          "订阅",
+	"Median Latency":                        "延迟中位数",
+	"Median Speed":                          "速度中位数",
+	"Unlock %s":                             "解锁 %s",
+	"Detailed Results":                      "详细结果",
+	"Type":                                  "类型",
+	"Server":                                "服务器",
+	"Tested":                                "测试时间",
+	"unchanged, cached":                     "未变化，使用缓存",
+	"Response Time":                         "响应时间",
+	"Ping (%s)":                             "Ping（%s）",
+	"%dms, %.0f%% loss":                     "%dms，丢包 %.0f%%",
+	"Trace":                                 "路由追踪",
+	"%d hops, worst hop #%d %dms":           "%d 跳，最慢一跳 #%d %dms",
+	"Path MTU":                              "路径 MTU",
+	"fragmentation issue":                   "分片问题",
+	"Download Speed":                        "下载速度",
+	"Backend Counted Speed":                 "后端统计速度",
+	"Latency":                               "延迟",
+	"Geo Access":                            "地区访问",
+	"Security Score":                        "安全评分",
+	"Hosting":                               "托管商",
+	"advertised as %s, exits in %s":         "宣称 %s，实际出口 %s",
+	"Country Mismatch":                      "国家不符",
+	"CDN Edge":                              "CDN 边缘节点",
+	"%s near the exit, %s near you":         "出口附近 %s，你附近 %s",
+	"Upload: ↑%.1f Mbps":                    "上传：↑%.1f Mbps",
+	"Speed test server: %s":                 "测速服务器：%s",
+	"Upload Speed":                          "上传速度",
+	"Speed Test Server":                     "测速服务器",
+	"ping %dms":                             "延迟 %dms",
+	"Metered":                               "按流量计费",
+	"skipped, run data cap reached":         "已跳过，达到本次流量上限",
+	"Proxy Detection":                       "代理识别",
+	"validating":                            "正在验证",
+	"not validating":                        "未验证",
+	"inconclusive":                          "无法判断",
+	"not listed":                            "未列入",
+	"likely flagged":                        "可能被识别",
+	"Blocked Ports":                         "封锁端口",
+	"none":                                  "无",
+	"Sensitive Sites":                       "敏感网站",
+	"blocked":                               "被封锁",
+	"unreachable":                           "无法访问",
+	"Gaming":                                "游戏",
+	"Streaming":                             "流媒体",
+	"locked":                                "未解锁",
+	"good":                                  "良好",
+	"playable":                              "可玩",
+	"poor":                                  "较差",
+	"%s, best %dms to %s (jitter %dms)":     "%s，最佳 %dms 至 %s（抖动 %dms）",
+	"TLS Fingerprint":                       "TLS 指纹",
+	"Obfuscation (%s)":                      "混淆（%s）",
+	"effective":                             "有效",
+	"Active Probing":                        "主动探测",
+	"Note":                                  "备注",
+	"resistant":                             "抗探测",
+	"partial":                               "部分抗探测",
+	"fingerprintable":                       "易被识别",
+	"Skipped %s":                            "已跳过 %s",
+	"Error":                                 "错误",
+	"Vantage Country":                       "测试所在国家",
+	"Platform":                              "平台",
+	"Backend":                               "后端",
+	"Config Hash":                           "配置哈希",
+	"Command Line":                          "命令行",
+	"Version":                               "版本",
+	"Run Information":                       "运行信息",
+}
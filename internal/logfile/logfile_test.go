@@ -0,0 +1,53 @@
+package logfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "protoscope.log")
+
+	f, err := Open(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Close()
+
+	want := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(name), data, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("more rotated files kept than configured")
+	}
+
+	// Reopening appends and counts the existing size
+	f, err = Open(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("fifth\n"))
+	f.Close()
+	data, _ := os.ReadFile(path + ".1")
+	if !strings.HasPrefix(string(data), "fourth") {
+		t.Errorf("reopened file not rotated by its existing size: .1 = %q", data)
+	}
+}
@@ -0,0 +1,119 @@
+// Package notes persists user notes and labels attached to nodes in the
+// history database. Notes are keyed by node fingerprint, so they follow a
+// node across renames and subscription reorders but not across changed
+// connection settings.
+package notes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/store"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Store holds the notes of all nodes
+type Store struct {
+	history *store.Store
+
+	mu      sync.Mutex
+	entries map[string]*models.NodeNote
+}
+
+// Entry is a stored note with the fingerprint of its node
+type Entry struct {
+	Fingerprint string
+	*models.NodeNote
+}
+
+// Load reads the notes from the history database. Save writes them back
+// to it, so history must stay open until then.
+func Load(history *store.Store) (*Store, error) {
+	entries, err := history.Notes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notes: %w", err)
+	}
+	return &Store{history: history, entries: entries}, nil
+}
+
+// Get returns a copy of the node's note, or nil
+func (s *Store) Get(protocol *models.Protocol) *models.NodeNote {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	note, ok := s.entries[protocol.Fingerprint()]
+	if !ok {
+		return nil
+	}
+	copied := *note
+	return &copied
+}
+
+// Set attaches a note and labels to a node, replacing any earlier note
+func (s *Store) Set(protocol *models.Protocol, text string, labels []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[protocol.Fingerprint()] = &models.NodeNote{
+		Text:    text,
+		Labels:  labels,
+		Name:    protocol.Name,
+		Updated: time.Now(),
+	}
+}
+
+// Remove deletes the notes matched by Find and returns how many there were
+func (s *Store) Remove(query string) int {
+	matches := s.Find(query)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range matches {
+		delete(s.entries, entry.Fingerprint)
+	}
+	return len(matches)
+}
+
+// Find returns notes whose node name equals query or whose fingerprint
+// starts with it (at least 8 characters)
+func (s *Store) Find(query string) []Entry {
+	var matches []Entry
+	for _, entry := range s.All() {
+		if entry.Name == query || (len(query) >= 8 && strings.HasPrefix(entry.Fingerprint, query)) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+// All returns every note, sorted by node name
+func (s *Store) All() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]Entry, 0, len(s.entries))
+	for fingerprint, note := range s.entries {
+		entries = append(entries, Entry{Fingerprint: fingerprint, NodeNote: note})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].Fingerprint < entries[j].Fingerprint
+	})
+	return entries
+}
+
+// Save writes the notes to the history database
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.history.SaveNotes(s.entries); err != nil {
+		return fmt.Errorf("failed to write notes: %w", err)
+	}
+	return nil
+}
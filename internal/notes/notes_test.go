@@ -0,0 +1,57 @@
+//go:build !nohistory && !mips && !mipsle && !mips64 && !mips64le
+
+package notes
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/internal/store"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestNotesRoundTrip(t *testing.T) {
+	history, err := store.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Close()
+	protocol := &models.Protocol{Type: models.ProtocolTrojan, Name: "DE-01", Server: "de.example.com", Port: 443, Password: "pw"}
+
+	s, err := Load(history)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	s.Set(protocol, "friend's node", []string{"home"})
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	s, err = Load(history)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	renamed := protocol.Clone()
+	renamed.Name = "Germany 1"
+	note := s.Get(renamed)
+	if note == nil || note.Text != "friend's node" || len(note.Labels) != 1 {
+		t.Fatalf("expected the note to follow the renamed node, got %+v", note)
+	}
+
+	changed := protocol.Clone()
+	changed.Password = "new"
+	if s.Get(changed) != nil {
+		t.Fatal("expected no note for changed settings")
+	}
+
+	if len(s.Find(protocol.Fingerprint()[:8])) != 1 || len(s.Find("DE-01")) != 1 {
+		t.Fatal("expected the note to be found by fingerprint prefix and name")
+	}
+	if len(s.Find("DE")) != 0 {
+		t.Fatal("short queries must not match fingerprints")
+	}
+	if s.Remove("DE-01") != 1 || len(s.All()) != 0 {
+		t.Fatal("expected the note to be removed")
+	}
+}
@@ -0,0 +1,203 @@
+// Package notify delivers reports after daemon-mode runs
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// EmailNotifier sends reports over SMTP
+type EmailNotifier struct {
+	config models.EmailConfig
+}
+
+// NewEmailNotifier creates a new SMTP notifier
+func NewEmailNotifier(config models.EmailConfig) *EmailNotifier {
+	return &EmailNotifier{config: config}
+}
+
+// Send emails a rendered report, as the message body or as an attachment
+// depending on the config. format is the report format (html or markdown).
+func (n *EmailNotifier) Send(ctx context.Context, subject string, report []byte, format string) error {
+	msg, err := n.buildMessage(subject, report, format, time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := n.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if n.config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server %s does not support authentication", n.config.Host)
+		}
+		auth := smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.config.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	for _, to := range n.config.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s failed: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp server rejected message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// dial connects to the SMTP server and sets up TLS as configured
+func (n *EmailNotifier) dial(ctx context.Context) (*smtp.Client, error) {
+	port := n.config.Port
+	if port == 0 {
+		port = 587
+	}
+	address := net.JoinHostPort(n.config.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{
+		ServerName:         n.config.Host,
+		InsecureSkipVerify: n.config.InsecureSkipVerify,
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	tlsMode := strings.ToLower(n.config.TLS)
+	if tlsMode == "tls" {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, n.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp handshake failed: %w", err)
+	}
+
+	switch tlsMode {
+	case "tls", "none":
+	case "starttls", "":
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("smtp server %s does not support STARTTLS", n.config.Host)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp STARTTLS failed: %w", err)
+		}
+	default:
+		client.Close()
+		return nil, fmt.Errorf("unknown smtp tls mode: %s", n.config.TLS)
+	}
+
+	return client, nil
+}
+
+// buildMessage builds the MIME message for a report
+func (n *EmailNotifier) buildMessage(subject string, report []byte, format string, now time.Time) ([]byte, error) {
+	contentType, filename := "text/markdown", "report.md"
+	if format == "html" {
+		contentType, filename = "text/html", "report.html"
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+
+	writeHeader("From", n.config.From)
+	writeHeader("To", strings.Join(n.config.To, ", "))
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", subject))
+	writeHeader("Date", now.Format(time.RFC1123Z))
+	writeHeader("MIME-Version", "1.0")
+
+	if !n.config.Attach {
+		if format != "html" {
+			// Mail clients render text/markdown poorly; plain text reads fine
+			contentType = "text/plain"
+		}
+		writeHeader("Content-Type", contentType+"; charset=utf-8")
+		writeHeader("Content-Transfer-Encoding", "base64")
+		buf.WriteString("\r\n")
+		writeBase64(&buf, report)
+		return buf.Bytes(), nil
+	}
+
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	writeHeader("Content-Type", fmt.Sprintf("multipart/mixed; boundary=%q", boundary))
+	buf.WriteString("\r\n")
+
+	fmt.Fprintf(&buf, "--%s\r\n", boundary)
+	writeHeader("Content-Type", "text/plain; charset=utf-8")
+	buf.WriteString("\r\n")
+	fmt.Fprintf(&buf, "ProtoScope report attached (%s).\r\n", filename)
+
+	fmt.Fprintf(&buf, "--%s\r\n", boundary)
+	writeHeader("Content-Type", contentType+"; charset=utf-8")
+	writeHeader("Content-Transfer-Encoding", "base64")
+	writeHeader("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	buf.WriteString("\r\n")
+	writeBase64(&buf, report)
+
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+// writeBase64 writes data base64-encoded in 76-character lines
+func writeBase64(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteString("\r\n")
+}
+
+// randomBoundary returns a MIME multipart boundary
+func randomBoundary() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("protoscope-%x", b[:]), nil
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestBuildMessageAttachment(t *testing.T) {
+	n := NewEmailNotifier(models.EmailConfig{
+		From:   "scope@example.com",
+		To:     []string{"a@example.com", "b@example.com"},
+		Attach: true,
+	})
+	report := []byte("<html><body>" + strings.Repeat("report ", 50) + "</body></html>")
+
+	raw, err := n.buildMessage("ProtoScope report: 3/5 working", report, "html", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	if got := msg.Header.Get("To"); got != "a@example.com, b@example.com" {
+		t.Errorf("To = %q", got)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, %v", mediaType, err)
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	if _, err := reader.NextPart(); err != nil {
+		t.Fatalf("missing text part: %v", err)
+	}
+	part, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("missing attachment: %v", err)
+	}
+	if part.FileName() != "report.html" {
+		t.Errorf("attachment filename = %q", part.FileName())
+	}
+	attached, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+	if !bytes.Equal(attached, report) {
+		t.Errorf("attachment does not round-trip")
+	}
+}
+
+func TestBuildMessageBody(t *testing.T) {
+	n := NewEmailNotifier(models.EmailConfig{
+		From: "scope@example.com",
+		To:   []string{"a@example.com"},
+	})
+	report := []byte("# ProtoScope Test Results\n")
+
+	raw, err := n.buildMessage("report", report, "markdown", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	if got := msg.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	body, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, msg.Body))
+	if !bytes.Equal(body, report) {
+		t.Errorf("body = %q", body)
+	}
+}
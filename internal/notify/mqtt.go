@@ -0,0 +1,361 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/report"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// MQTT 3.1.1 control packet types, shifted into the fixed header
+const (
+	mqttConnect    = 1 << 4
+	mqttConnack    = 2 << 4
+	mqttPublish    = 3 << 4
+	mqttPuback     = 4 << 4
+	mqttDisconnect = 14 << 4
+)
+
+// mqttKeepAlive is announced to the broker; a run's messages go out well
+// within it, so no pings are sent
+const mqttKeepAlive = 60
+
+// MQTTNotifier publishes run results to an MQTT broker
+type MQTTNotifier struct {
+	config models.MQTTConfig
+}
+
+// NewMQTTNotifier creates a new MQTT notifier
+func NewMQTTNotifier(config models.MQTTConfig) *MQTTNotifier {
+	return &MQTTNotifier{config: config}
+}
+
+// MQTTSummary is the message published to the summary topic
+type MQTTSummary struct {
+	Total      int       `json:"total"`
+	Working    int       `json:"working"`
+	Partial    int       `json:"partial"`
+	Failed     int       `json:"failed"`
+	AvgLatency int64     `json:"avg_latency_ms"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// MQTTNode is the message published to a node's topic
+type MQTTNode struct {
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	Server       string    `json:"server"`
+	Status       string    `json:"status"` // working, partial or failed
+	Latency      int64     `json:"latency_ms,omitempty"`
+	DownloadMbps float64   `json:"download_mbps,omitempty"`
+	ExitCountry  string    `json:"exit_country,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Publish sends the summary of a run and the result of every node
+func (n *MQTTNotifier) Publish(ctx context.Context, results []*models.TestResult) error {
+	// QoS 2 needs the PUBREC/PUBREL/PUBCOMP exchange this client doesn't speak
+	if n.config.QoS != 0 && n.config.QoS != 1 {
+		return fmt.Errorf("mqtt qos %d is not supported, use 0 or 1", n.config.QoS)
+	}
+
+	messages, err := n.messages(results, time.Now())
+	if err != nil {
+		return err
+	}
+
+	conn, err := n.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	session := &mqttSession{conn: conn, reader: bufio.NewReader(conn), qos: n.config.QoS, retain: n.config.Retain}
+	if err := session.connect(n.config); err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		if err := session.publish(msg.topic, msg.payload); err != nil {
+			return fmt.Errorf("mqtt publish to %s failed: %w", msg.topic, err)
+		}
+	}
+	return session.disconnect()
+}
+
+// mqttMessage is a payload for a topic
+type mqttMessage struct {
+	topic   string
+	payload []byte
+}
+
+// messages builds the summary message followed by one per node
+func (n *MQTTNotifier) messages(results []*models.TestResult, now time.Time) ([]mqttMessage, error) {
+	summary := report.Summarize(results)
+	payload, err := json.Marshal(MQTTSummary{
+		Total:      summary.Total,
+		Working:    summary.Working,
+		Partial:    summary.Partial,
+		Failed:     summary.Failed,
+		AvgLatency: summary.AvgLatency.Milliseconds(),
+		Timestamp:  now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	messages := []mqttMessage{{topic: n.config.SummaryTopic, payload: payload}}
+
+	names := nodeNames(results)
+	for i, result := range results {
+		node := MQTTNode{
+			Name:      result.Protocol.Name,
+			Type:      string(result.Protocol.Type),
+			Server:    result.Protocol.Server,
+			Status:    result.Status(),
+			Error:     result.Error,
+			Timestamp: now,
+		}
+		if result.Connectivity != nil && result.Success {
+			node.Latency = result.Connectivity.ResponseTime.Milliseconds()
+		}
+		if result.Performance != nil {
+			node.DownloadMbps = result.Performance.DownloadSpeed
+		}
+		if result.Privacy != nil {
+			node.ExitCountry = result.Privacy.ExitCountry
+		}
+
+		payload, err := json.Marshal(node)
+		if err != nil {
+			return nil, err
+		}
+		topic := strings.ReplaceAll(n.config.NodeTopic, "{node}", names[i])
+		messages = append(messages, mqttMessage{topic: topic, payload: payload})
+	}
+	return messages, nil
+}
+
+// TopicName makes a node name usable as one MQTT topic level, and as a
+// Home Assistant entity ID: lower case letters, digits and underscores
+func TopicName(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	topic := strings.TrimSuffix(b.String(), "_")
+	if topic == "" {
+		return "node"
+	}
+	return topic
+}
+
+// nodeNames returns the TopicName of every result's node. Nodes of the
+// same name are numbered rather than overwriting each other.
+func nodeNames(results []*models.TestResult) []string {
+	names := make([]string, len(results))
+	used := make(map[string]int, len(results))
+	for i, result := range results {
+		name := TopicName(result.Protocol.Name)
+		used[name]++
+		if used[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, used[name])
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// dial connects to the broker, with TLS for mqtts:// URLs
+func (n *MQTTNotifier) dial(ctx context.Context) (net.Conn, error) {
+	broker, err := url.Parse(n.config.Broker)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mqtt broker URL: %w", err)
+	}
+	var useTLS bool
+	port := "1883"
+	switch broker.Scheme {
+	case "mqtt", "tcp":
+	case "mqtts", "ssl", "tls":
+		useTLS, port = true, "8883"
+	default:
+		return nil, fmt.Errorf("unknown mqtt broker scheme: %s", broker.Scheme)
+	}
+	if broker.Port() != "" {
+		port = broker.Port()
+	}
+	address := net.JoinHostPort(broker.Hostname(), port)
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mqtt broker: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if useTLS {
+		conn = tls.Client(conn, &tls.Config{
+			ServerName:         broker.Hostname(),
+			InsecureSkipVerify: n.config.InsecureSkipVerify,
+		})
+	}
+	return conn, nil
+}
+
+// mqttSession speaks just enough MQTT 3.1.1 to publish
+type mqttSession struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	qos      int
+	retain   bool
+	packetID uint16
+}
+
+// connect sends CONNECT and waits for the broker to accept it
+func (s *mqttSession) connect(config models.MQTTConfig) error {
+	var body bytes.Buffer
+	writeMQTTString(&body, "MQTT")
+	body.WriteByte(4) // protocol level 3.1.1
+
+	flags := byte(0x02) // clean session
+	if config.Username != "" {
+		flags |= 0x80
+		if config.Password != "" {
+			flags |= 0x40
+		}
+	}
+	body.WriteByte(flags)
+	binary.Write(&body, binary.BigEndian, uint16(mqttKeepAlive))
+
+	writeMQTTString(&body, config.ClientID)
+	if config.Username != "" {
+		writeMQTTString(&body, config.Username)
+		if config.Password != "" {
+			writeMQTTString(&body, config.Password)
+		}
+	}
+
+	if err := s.send(mqttConnect, body.Bytes()); err != nil {
+		return fmt.Errorf("mqtt CONNECT failed: %w", err)
+	}
+	packetType, reply, err := s.receive()
+	if err != nil {
+		return fmt.Errorf("mqtt CONNACK failed: %w", err)
+	}
+	if packetType != mqttConnack || len(reply) != 2 {
+		return fmt.Errorf("mqtt broker answered CONNECT with packet type %d", packetType>>4)
+	}
+	if code := reply[1]; code != 0 {
+		return fmt.Errorf("mqtt broker refused connection: %s", mqttConnackReason(code))
+	}
+	return nil
+}
+
+// publish sends a message and, at QoS 1, waits for its acknowledgement
+func (s *mqttSession) publish(topic string, payload []byte) error {
+	header := byte(mqttPublish)
+	if s.retain {
+		header |= 0x01
+	}
+
+	var body bytes.Buffer
+	writeMQTTString(&body, topic)
+	if s.qos > 0 {
+		header |= 1 << 1
+		s.packetID++
+		binary.Write(&body, binary.BigEndian, s.packetID)
+	}
+	body.Write(payload)
+
+	if err := s.send(header, body.Bytes()); err != nil {
+		return err
+	}
+	if s.qos == 0 {
+		return nil
+	}
+
+	packetType, reply, err := s.receive()
+	if err != nil {
+		return err
+	}
+	if packetType != mqttPuback || len(reply) != 2 || binary.BigEndian.Uint16(reply) != s.packetID {
+		return fmt.Errorf("unexpected reply to PUBLISH: packet type %d", packetType>>4)
+	}
+	return nil
+}
+
+// disconnect tells the broker the session ends cleanly
+func (s *mqttSession) disconnect() error {
+	return s.send(mqttDisconnect, nil)
+}
+
+// send writes a packet with the given fixed header byte
+func (s *mqttSession) send(header byte, body []byte) error {
+	packet := []byte{header}
+	packet = binary.AppendUvarint(packet, uint64(len(body)))
+	packet = append(packet, body...)
+	_, err := s.conn.Write(packet)
+	return err
+}
+
+// receive reads a packet and returns its type and body
+func (s *mqttSession) receive() (byte, []byte, error) {
+	header, err := s.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, err := binary.ReadUvarint(s.reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length > 1<<16 {
+		return 0, nil, errors.New("mqtt packet too large")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header & 0xf0, body, nil
+}
+
+// writeMQTTString writes a length-prefixed UTF-8 string
+func writeMQTTString(buf *bytes.Buffer, s string) {
+	binary.Write(buf, binary.BigEndian, uint16(len(s)))
+	buf.WriteString(s)
+}
+
+// mqttConnackReason explains a CONNACK return code
+func mqttConnackReason(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "client ID rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad username or password"
+	case 5:
+		return "not authorized"
+	}
+	return fmt.Sprintf("return code %d", code)
+}
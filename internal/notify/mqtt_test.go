@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// fakeBroker accepts one MQTT connection, acknowledges CONNECT and QoS 1
+// publishes and returns the published messages by topic
+func fakeBroker(t *testing.T) (string, <-chan map[string][]byte) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	published := make(chan map[string][]byte, 1)
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s := &mqttSession{conn: conn, reader: bufio.NewReader(conn)}
+		messages := make(map[string][]byte)
+		defer func() { published <- messages }()
+		for {
+			header, err := s.reader.ReadByte()
+			if err != nil {
+				return
+			}
+			length, _ := binary.ReadUvarint(s.reader)
+			body := make([]byte, length)
+			io.ReadFull(s.reader, body)
+
+			switch header & 0xf0 {
+			case mqttConnect:
+				s.send(mqttConnack, []byte{0, 0})
+			case mqttPublish:
+				topicLen := int(binary.BigEndian.Uint16(body))
+				topic, rest := string(body[2:2+topicLen]), body[2+topicLen:]
+				if header&0x06 != 0 {
+					s.send(mqttPuback, rest[:2])
+					rest = rest[2:]
+				}
+				if header&0x01 == 0 {
+					t.Errorf("message to %s not retained", topic)
+				}
+				messages[topic] = rest
+			case mqttDisconnect:
+				return
+			}
+		}
+	}()
+	return "mqtt://" + listener.Addr().String(), published
+}
+
+func TestMQTTPublish(t *testing.T) {
+	broker, published := fakeBroker(t)
+	config := models.DefaultConfig().Notify.MQTT
+	config.Broker = broker
+	config.QoS = 1
+
+	results := []*models.TestResult{
+		{
+			Protocol:     &models.Protocol{Type: models.ProtocolVLESS, Name: "🇳🇱 NL-01", Server: "nl.example.com"},
+			Success:      true,
+			Connectivity: &models.ConnectivityResult{Connected: true, ResponseTime: 120 * time.Millisecond},
+		},
+		{Protocol: &models.Protocol{Type: models.ProtocolTrojan, Name: "NL 01", Server: "nl2.example.com"}, Error: "timeout"},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := NewMQTTNotifier(config).Publish(ctx, results); err != nil {
+		t.Fatalf("Publish returned %v", err)
+	}
+
+	messages := <-published
+	var summary MQTTSummary
+	json.Unmarshal(messages["protoscope/summary"], &summary)
+	if summary.Total != 2 || summary.Working != 1 {
+		t.Errorf("summary %+v, want 1 of 2 working", summary)
+	}
+	var working, failed MQTTNode
+	json.Unmarshal(messages["protoscope/nodes/nl_01"], &working)
+	json.Unmarshal(messages["protoscope/nodes/nl_01_2"], &failed)
+	if working.Status != "working" || working.Latency != 120 {
+		t.Errorf("working node %+v", working)
+	}
+	if failed.Status != "failed" || failed.Error != "timeout" {
+		t.Errorf("failed node %+v", failed)
+	}
+}
+
+func TestTopicName(t *testing.T) {
+	tests := map[string]string{
+		"🇺🇸 US-West #3": "us_west_3",
+		"node/a+b":      "node_a_b",
+		"香港":            "node",
+	}
+	for name, want := range tests {
+		if got := TopicName(name); got != want {
+			t.Errorf("TopicName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestMQTTRejectsQoS2(t *testing.T) {
+	config := models.DefaultConfig().Notify.MQTT
+	config.Broker = "mqtt://127.0.0.1:1"
+	config.QoS = 2
+	err := NewMQTTNotifier(config).Publish(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "qos 2") {
+		t.Errorf("Publish with qos 2 returned %v, want an unsupported qos error", err)
+	}
+}
@@ -0,0 +1,183 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/report"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// pushTimeout bounds each push request
+const pushTimeout = 30 * time.Second
+
+// PushUptimeKuma reports every monitor's node as up or down, with its
+// latency, to the monitor's push URL. All monitors are tried; the errors
+// of those that failed are joined.
+func PushUptimeKuma(ctx context.Context, monitors []models.UptimeKumaMonitor, results []*models.TestResult) error {
+	client := &http.Client{Timeout: pushTimeout}
+	var errs []error
+	for _, monitor := range monitors {
+		pushURL, err := uptimeKumaURL(monitor, results)
+		if err == nil {
+			err = pushGet(ctx, client, pushURL)
+		}
+		if err != nil {
+			name := monitor.Node
+			if name == "" {
+				name = "run"
+			}
+			errs = append(errs, fmt.Errorf("uptime kuma monitor %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// uptimeKumaURL sets status, msg and ping on a monitor's push URL, replacing
+// the defaults Uptime Kuma shows with it
+func uptimeKumaURL(monitor models.UptimeKumaMonitor, results []*models.TestResult) (string, error) {
+	u, err := url.Parse(monitor.URL)
+	if err != nil {
+		return "", err
+	}
+
+	up, msg, latency := false, "", time.Duration(0)
+	if monitor.Node == "" {
+		summary := report.Summarize(results)
+		up = summary.Working > 0
+		msg = fmt.Sprintf("%d/%d working", summary.Working, summary.Total)
+		for _, result := range results {
+			if result.Success && result.Connectivity != nil {
+				if rt := result.Connectivity.ResponseTime; latency == 0 || rt < latency {
+					latency = rt
+				}
+			}
+		}
+	} else {
+		msg = "not in the tested subscription"
+		for _, result := range results {
+			if result.Protocol.Name != monitor.Node {
+				continue
+			}
+			up, msg = result.Success, result.Status()
+			if result.Error != "" {
+				msg = result.Error
+			}
+			if result.Success && result.Connectivity != nil {
+				latency = result.Connectivity.ResponseTime
+			}
+			break
+		}
+	}
+
+	query := u.Query()
+	query.Set("status", "down")
+	if up {
+		query.Set("status", "up")
+	}
+	query.Set("msg", msg)
+	query.Del("ping")
+	if latency > 0 {
+		query.Set("ping", strconv.FormatInt(latency.Milliseconds(), 10))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// HomeAssistantPayload is the JSON posted to the Home Assistant webhook.
+// Nodes are keyed by TopicName, so templates can address them as
+// trigger.json.nodes.<name>.
+type HomeAssistantPayload struct {
+	Total      int                          `json:"total"`
+	Working    int                          `json:"working"`
+	AvgLatency int64                        `json:"avg_latency_ms"`
+	Nodes      map[string]HomeAssistantNode `json:"nodes"`
+	Timestamp  time.Time                    `json:"timestamp"`
+}
+
+// HomeAssistantNode is one node of a HomeAssistantPayload
+type HomeAssistantNode struct {
+	Name    string `json:"name"`
+	Up      bool   `json:"up"`
+	Status  string `json:"status"` // working, partial or failed
+	Latency int64  `json:"latency_ms"`
+	Error   string `json:"error,omitempty"`
+}
+
+// PushHomeAssistant posts the run's node results to a Home Assistant
+// webhook
+func PushHomeAssistant(ctx context.Context, config models.HomeAssistantConfig, results []*models.TestResult) error {
+	body, err := json.Marshal(homeAssistantPayload(results, time.Now()))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return pushDo(&http.Client{Timeout: pushTimeout}, req)
+}
+
+// homeAssistantPayload builds the webhook body
+func homeAssistantPayload(results []*models.TestResult, now time.Time) HomeAssistantPayload {
+	summary := report.Summarize(results)
+	payload := HomeAssistantPayload{
+		Total:      summary.Total,
+		Working:    summary.Working,
+		AvgLatency: summary.AvgLatency.Milliseconds(),
+		Nodes:      make(map[string]HomeAssistantNode, len(results)),
+		Timestamp:  now,
+	}
+
+	names := nodeNames(results)
+	for i, result := range results {
+		node := HomeAssistantNode{
+			Name:   result.Protocol.Name,
+			Up:     result.Success,
+			Status: result.Status(),
+			Error:  result.Error,
+		}
+		if result.Success && result.Connectivity != nil {
+			node.Latency = result.Connectivity.ResponseTime.Milliseconds()
+		}
+		payload.Nodes[names[i]] = node
+	}
+	return payload
+}
+
+// pushGet requests a push URL
+func pushGet(ctx context.Context, client *http.Client, pushURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pushURL, nil)
+	if err != nil {
+		return err
+	}
+	return pushDo(client, req)
+}
+
+// pushDo sends a push request and fails on any status but 2xx
+func pushDo(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		// Push URLs carry tokens; keep them out of the logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
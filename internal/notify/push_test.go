@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func pushResults() []*models.TestResult {
+	return []*models.TestResult{
+		{
+			Protocol:     &models.Protocol{Name: "NL-01"},
+			Success:      true,
+			Connectivity: &models.ConnectivityResult{Connected: true, ResponseTime: 80 * time.Millisecond},
+		},
+		{Protocol: &models.Protocol{Name: "US-01"}, Error: "connection refused"},
+	}
+}
+
+func TestPushUptimeKuma(t *testing.T) {
+	var mu sync.Mutex
+	pushes := make(map[string]url.Values)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		pushes[r.URL.Path] = r.URL.Query()
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	monitors := []models.UptimeKumaMonitor{
+		{Node: "NL-01", URL: server.URL + "/api/push/nl?status=up&msg=OK&ping="},
+		{Node: "US-01", URL: server.URL + "/api/push/us"},
+		{URL: server.URL + "/api/push/run"},
+	}
+	if err := PushUptimeKuma(context.Background(), monitors, pushResults()); err != nil {
+		t.Fatalf("PushUptimeKuma returned %v", err)
+	}
+
+	tests := []struct {
+		path, status, msg, ping string
+	}{
+		{"/api/push/nl", "up", "working", "80"},
+		{"/api/push/us", "down", "connection refused", ""},
+		{"/api/push/run", "up", "1/2 working", "80"},
+	}
+	for _, tt := range tests {
+		query := pushes[tt.path]
+		if query.Get("status") != tt.status || query.Get("msg") != tt.msg || query.Get("ping") != tt.ping {
+			t.Errorf("%s pushed %v, want status=%s msg=%q ping=%s", tt.path, query, tt.status, tt.msg, tt.ping)
+		}
+	}
+}
+
+func TestPushHomeAssistant(t *testing.T) {
+	var payload HomeAssistantPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s with Content-Type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	config := models.HomeAssistantConfig{Webhook: server.URL + "/api/webhook/protoscope"}
+	if err := PushHomeAssistant(context.Background(), config, pushResults()); err != nil {
+		t.Fatalf("PushHomeAssistant returned %v", err)
+	}
+	if payload.Total != 2 || payload.Working != 1 {
+		t.Errorf("payload totals %+v", payload)
+	}
+	if nl := payload.Nodes["nl_01"]; !nl.Up || nl.Latency != 80 {
+		t.Errorf("nl_01 = %+v, want up with 80ms", nl)
+	}
+	if us := payload.Nodes["us_01"]; us.Up || us.Status != "failed" {
+		t.Errorf("us_01 = %+v, want down", us)
+	}
+}
@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Canonical re-serializes a parsed protocol into a normalized share URL:
+// lowercase server, query parameters sorted by name, empty parameters
+// dropped and the name decoded and re-escaped consistently. Parsing the
+// result yields the same connection settings and name, so two links that
+// differ only in encoding have the same canonical form.
+func Canonical(p *models.Protocol) (string, error) {
+	switch p.Type {
+	case models.ProtocolVLESS:
+		params := extraParams(p)
+		params.Set("type", p.Network)
+		if params.Get("security") == "" && p.TLS {
+			params.Set("security", "tls")
+		}
+		setParam(params, "sni", p.SNI)
+		return canonicalURL("vless", url.User(p.UUID), p, params), nil
+
+	case models.ProtocolTrojan:
+		params := extraParams(p)
+		params.Set("type", p.Network)
+		setParam(params, "sni", p.SNI)
+		return canonicalURL("trojan", url.User(p.Password), p, params), nil
+
+	case models.ProtocolHysteria2:
+		params := extraParams(p)
+		setParam(params, "sni", p.SNI)
+		return canonicalURL("hysteria2", url.User(p.Password), p, params), nil
+
+	case models.ProtocolTUIC:
+		user := url.User(p.UUID)
+		if p.Password != "" {
+			user = url.UserPassword(p.UUID, p.Password)
+		}
+		params := extraParams(p)
+		setParam(params, "sni", p.SNI)
+		return canonicalURL("tuic", user, p, params), nil
+
+	case models.ProtocolWireGuard:
+		return canonicalURL("wireguard", url.User(p.Password), p, extraParams(p)), nil
+
+	case models.ProtocolSSH:
+		user := url.User(extraString(p.Extra["user"]))
+		if p.Password != "" {
+			user = url.UserPassword(extraString(p.Extra["user"]), p.Password)
+		}
+		params := extraParams(p)
+		params.Del("user")
+		return canonicalURL("ssh", user, p, params), nil
+
+	case models.ProtocolShadowsocks:
+		method := extraString(p.Extra["method"])
+		if method == "" {
+			return "", fmt.Errorf("shadowsocks node %s has no method", p.Name)
+		}
+		// The legacy form is the one ParseShadowsocks reads; the port is
+		// appended without brackets because the parser splits at the last colon
+		userInfo := fmt.Sprintf("%s:%s@%s:%d", method, p.Password, strings.ToLower(p.Server), p.Port)
+		link := "ss://" + base64.StdEncoding.EncodeToString([]byte(userInfo))
+		if p.Name != "" {
+			link += "#" + url.QueryEscape(p.Name)
+		}
+		return link, nil
+
+	case models.ProtocolVMess:
+		// Fields in the order of the v2rayN format, so the JSON is stable
+		config := struct {
+			V    string `json:"v"`
+			PS   string `json:"ps"`
+			Add  string `json:"add"`
+			Port string `json:"port"`
+			ID   string `json:"id"`
+			AID  string `json:"aid"`
+			Net  string `json:"net"`
+			Type string `json:"type"`
+			Host string `json:"host"`
+			Path string `json:"path"`
+			TLS  string `json:"tls"`
+			SNI  string `json:"sni"`
+		}{
+			V:    "2",
+			PS:   p.Name,
+			Add:  strings.ToLower(p.Server),
+			Port: strconv.Itoa(p.Port),
+			ID:   p.UUID,
+			AID:  extraString(p.Extra["aid"]),
+			Net:  p.Network,
+			Type: extraString(p.Extra["type"]),
+			Host: extraString(p.Extra["host"]),
+			Path: extraString(p.Extra["path"]),
+			SNI:  p.SNI,
+		}
+		if p.TLS {
+			config.TLS = "tls"
+		}
+		data, err := json.Marshal(config)
+		if err != nil {
+			return "", err
+		}
+		return "vmess://" + base64.StdEncoding.EncodeToString(data), nil
+
+	default:
+		return "", fmt.Errorf("no share URL format for %s nodes", p.Type)
+	}
+}
+
+// Dedupe drops nodes whose connection settings equal an earlier node's,
+// ignoring names, and returns the remaining nodes in order with the number
+// dropped
+func Dedupe(protocols []*models.Protocol) ([]*models.Protocol, int) {
+	seen := make(map[string]bool, len(protocols))
+	unique := make([]*models.Protocol, 0, len(protocols))
+	for _, p := range protocols {
+		key := p.Raw
+		unnamed := p.Clone()
+		unnamed.Name = ""
+		if canonical, err := Canonical(unnamed); err == nil {
+			key = canonical
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, p)
+	}
+	return unique, len(protocols) - len(unique)
+}
+
+// canonicalURL builds scheme://user@server:port?params#name
+func canonicalURL(scheme string, user *url.Userinfo, p *models.Protocol, params url.Values) string {
+	u := url.URL{
+		Scheme:   scheme,
+		User:     user,
+		Host:     net.JoinHostPort(strings.ToLower(p.Server), strconv.Itoa(p.Port)),
+		RawQuery: params.Encode(), // sorted by key
+		Fragment: p.Name,
+	}
+	return u.String()
+}
+
+// extraParams returns the non-empty Extra values as query parameters
+func extraParams(p *models.Protocol) url.Values {
+	params := url.Values{}
+	for key, value := range p.Extra {
+		setParam(params, key, extraString(value))
+	}
+	return params
+}
+
+func setParam(params url.Values, key, value string) {
+	if value != "" {
+		params.Set(key, value)
+	}
+}
+
+// extraString renders an Extra value as a query parameter; lists become
+// comma-separated, nested objects can't be represented and are dropped
+func extraString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool, int, int64, float64:
+		return fmt.Sprint(v)
+	case []string:
+		return strings.Join(v, ",")
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, extraString(item))
+		}
+		return strings.Join(items, ",")
+	default:
+		return ""
+	}
+}
+
+// keepUnknownParams copies query parameters a parser does not map to a
+// field into extra, so settings like REALITY keys reach the backend and
+// Canonical can re-emit them. consumed lists the parameters already handled.
+func keepUnknownParams(extra map[string]interface{}, query url.Values, consumed ...string) {
+	skip := make(map[string]bool, len(consumed))
+	for _, key := range consumed {
+		skip[key] = true
+	}
+	for key := range query {
+		if _, ok := extra[key]; ok || skip[key] || query.Get(key) == "" {
+			continue
+		}
+		extra[key] = query.Get(key)
+	}
+}
@@ -0,0 +1,291 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// clashProxiesKey finds the top-level proxies list of a Clash config
+var clashProxiesKey = regexp.MustCompile(`(?m)^proxies:`)
+
+// clashConfig is the part of a Clash or Clash.Meta (mihomo) config that
+// lists the nodes. Entries are kept as nodes for their line numbers.
+type clashConfig struct {
+	Proxies []yaml.Node `yaml:"proxies"`
+}
+
+// clashProxy is a proxy entry of a Clash config
+type clashProxy map[string]interface{}
+
+// isClashYAML reports whether content is a Clash config rather than a list
+// of share links
+func isClashYAML(content string) bool {
+	return clashProxiesKey.MatchString(content)
+}
+
+// parseClashProxies parses the proxies of a Clash config. Like links, each
+// entry is validated, and entries that fail to convert are recorded in the
+// report with their line.
+func (d *Decoder) parseClashProxies(content string) ([]*models.Protocol, *models.ParseReport, error) {
+	report := &models.ParseReport{Lines: strings.Count(content, "\n") + 1}
+
+	var config clashConfig
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return nil, report, fmt.Errorf("failed to parse clash config: %w", err)
+	}
+
+	var protocols []*models.Protocol
+	for _, node := range config.Proxies {
+		var proxy clashProxy
+		err := node.Decode(&proxy)
+		var protocol *models.Protocol
+		if err == nil {
+			protocol, err = proxy.protocol()
+		}
+		if err != nil {
+			report.Skipped = append(report.Skipped, models.ParseIssue{
+				Line:        node.Line,
+				Scheme:      proxy.str("type"),
+				Error:       err.Error(),
+				Snippet:     truncate(proxy.str("name"), 40),
+				Unsupported: unsupportedFeature(err),
+			})
+			continue
+		}
+
+		finishStructured(protocol)
+		protocols = append(protocols, protocol)
+	}
+	report.Parsed = len(protocols)
+
+	if len(protocols) == 0 {
+		if len(report.Skipped) > 0 {
+			first := report.Skipped[0]
+			return nil, report, fmt.Errorf("no valid protocols found; %d clash proxies failed to parse, first on line %d: %s", len(report.Skipped), first.Line, first.Error)
+		}
+		return nil, report, fmt.Errorf("no valid protocols found")
+	}
+
+	return protocols, report, nil
+}
+
+// finishStructured completes a node read from a structured format, Clash
+// or sing-box, as the share link parsers complete theirs. Its share link
+// stands in for the original, so the node can be re-tested and exported
+// like a parsed link.
+func finishStructured(protocol *models.Protocol) {
+	if link, err := Canonical(protocol); err == nil {
+		protocol.Raw = link
+	}
+	protocol.Warnings = Validate(protocol)
+	protocol.AdvertisedCountry = CountryHint(protocol.Name)
+}
+
+// protocol converts the entry, mapping its options to the Extra keys of the
+// share link parsers
+func (c clashProxy) protocol() (*models.Protocol, error) {
+	server := c.str("server")
+	if server == "" {
+		return nil, fmt.Errorf("missing server in clash proxy")
+	}
+	port, err := parsePort(c.str("port"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid port: %w", err)
+	}
+	name := c.str("name")
+	if name == "" {
+		name = fmt.Sprintf("%s:%d", server, port)
+	}
+
+	protocol := &models.Protocol{
+		Name:   name,
+		Server: server,
+		Port:   port,
+		Extra:  map[string]interface{}{},
+	}
+
+	switch typ := c.str("type"); typ {
+	case "ss":
+		if plugin := c.str("plugin"); plugin != "" {
+			return nil, &UnsupportedError{Feature: shadowsocksPlugin(plugin)}
+		}
+		protocol.Type = models.ProtocolShadowsocks
+		protocol.Password = c.str("password")
+		protocol.Network = "tcp"
+		protocol.Extra["method"] = c.str("cipher")
+
+	case "vmess":
+		protocol.Type = models.ProtocolVMess
+		protocol.UUID = c.str("uuid")
+		protocol.TLS = c.bool("tls")
+		protocol.SNI = c.str("servername")
+		protocol.Extra["aid"] = c.str("alterId")
+		c.transport(protocol)
+
+	case "vless":
+		protocol.Type = models.ProtocolVLESS
+		protocol.UUID = c.str("uuid")
+		protocol.TLS = c.bool("tls")
+		protocol.SNI = c.str("servername")
+		setExtra(protocol.Extra, "flow", c.str("flow"))
+		if protocol.TLS {
+			protocol.Extra["security"] = "tls"
+		}
+		if reality := c.sub("reality-opts"); reality != nil {
+			protocol.TLS = true
+			protocol.Extra["security"] = "reality"
+			setExtra(protocol.Extra, "pbk", reality.str("public-key"))
+			setExtra(protocol.Extra, "sid", reality.str("short-id"))
+		}
+		setExtra(protocol.Extra, "fp", c.str("client-fingerprint"))
+		c.transport(protocol)
+
+	case "trojan":
+		protocol.Type = models.ProtocolTrojan
+		protocol.Password = c.str("password")
+		protocol.TLS = true
+		protocol.SNI = c.str("sni")
+		if protocol.SNI == "" {
+			protocol.SNI = server
+		}
+		setExtra(protocol.Extra, "fp", c.str("client-fingerprint"))
+		c.transport(protocol)
+
+	case "hysteria2":
+		protocol.Type = models.ProtocolHysteria2
+		protocol.Password = c.str("password")
+		if protocol.Password == "" {
+			protocol.Password = c.str("auth")
+		}
+		protocol.Network = "udp"
+		protocol.TLS = true
+		protocol.SNI = c.str("sni")
+		if protocol.SNI == "" {
+			protocol.SNI = server
+		}
+		setExtra(protocol.Extra, "obfs", c.str("obfs"))
+		setExtra(protocol.Extra, "obfs-password", c.str("obfs-password"))
+		if c.bool("skip-cert-verify") {
+			protocol.Extra["insecure"] = "1"
+		}
+
+	case "tuic":
+		protocol.Type = models.ProtocolTUIC
+		protocol.UUID = c.str("uuid")
+		protocol.Password = c.str("password")
+		protocol.Network = "udp"
+		protocol.TLS = true
+		protocol.SNI = c.str("sni")
+		if protocol.SNI == "" {
+			protocol.SNI = server
+		}
+		setExtra(protocol.Extra, "congestion_control", c.str("congestion-controller"))
+		setExtra(protocol.Extra, "alpn", c.str("alpn"))
+
+	case "wireguard":
+		protocol.Type = models.ProtocolWireGuard
+		protocol.Password = c.str("private-key")
+		protocol.Network = "udp"
+		var address []string
+		if ip := c.str("ip"); ip != "" {
+			address = append(address, ip+"/32")
+		}
+		if ip := c.str("ipv6"); ip != "" {
+			address = append(address, ip+"/128")
+		}
+		setExtra(protocol.Extra, "publickey", c.str("public-key"))
+		setExtra(protocol.Extra, "presharedkey", c.str("pre-shared-key"))
+		setExtra(protocol.Extra, "address", strings.Join(address, ","))
+		setExtra(protocol.Extra, "reserved", c.str("reserved"))
+		setExtra(protocol.Extra, "mtu", c.str("mtu"))
+
+	case "ssh":
+		protocol.Type = models.ProtocolSSH
+		protocol.Password = c.str("password")
+		protocol.Network = "tcp"
+		setExtra(protocol.Extra, "user", c.str("username"))
+		setExtra(protocol.Extra, "privatekey", c.str("private-key"))
+		setExtra(protocol.Extra, "passphrase", c.str("private-key-passphrase"))
+		setExtra(protocol.Extra, "hostkey", c.str("host-key"))
+
+	default:
+		return nil, fmt.Errorf("unsupported clash proxy type %q", typ)
+	}
+
+	return protocol, nil
+}
+
+// transport maps the ws, grpc and h2 options of V2Ray-based proxies
+func (c clashProxy) transport(protocol *models.Protocol) {
+	protocol.Network = c.str("network")
+	switch protocol.Network {
+	case "":
+		protocol.Network = "tcp"
+	case "ws":
+		if opts := c.sub("ws-opts"); opts != nil {
+			setExtra(protocol.Extra, "path", opts.str("path"))
+			if headers := opts.sub("headers"); headers != nil {
+				setExtra(protocol.Extra, "host", headers.str("Host"))
+			}
+		}
+	case "grpc":
+		if opts := c.sub("grpc-opts"); opts != nil {
+			setExtra(protocol.Extra, "serviceName", opts.str("grpc-service-name"))
+		}
+	case "h2":
+		if opts := c.sub("h2-opts"); opts != nil {
+			setExtra(protocol.Extra, "path", opts.str("path"))
+			setExtra(protocol.Extra, "host", opts.str("host"))
+		}
+	}
+}
+
+// str returns an option as a string; numbers and booleans are formatted and
+// lists joined with commas, as the share links write them
+func (c clashProxy) str(key string) string {
+	switch value := c[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// bool returns a boolean option, which some configs write as a string
+func (c clashProxy) bool(key string) bool {
+	value, _ := strconv.ParseBool(c.str(key))
+	return value
+}
+
+// sub returns nested options such as ws-opts, or nil. YAML decodes nested
+// maps into the type of the outer one.
+func (c clashProxy) sub(key string) clashProxy {
+	switch value := c[key].(type) {
+	case clashProxy:
+		return value
+	case map[string]interface{}:
+		return value
+	}
+	return nil
+}
+
+// setExtra sets an Extra key unless the value is empty
+func setExtra(extra map[string]interface{}, key, value string) {
+	if value != "" {
+		extra[key] = value
+	}
+}
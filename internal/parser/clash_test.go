@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+const clashSubscription = `mixed-port: 7890
+proxies:
+  - {name: "🇩🇪 SS", type: ss, server: ss.example.com, port: 8388, cipher: aes-256-gcm, password: pw}
+  - name: VMess WS
+    type: vmess
+    server: vmess.example.com
+    port: 443
+    uuid: 2b5c6a6e-7d4f-4f3a-9a3e-1c2d3e4f5a6b
+    alterId: 0
+    cipher: auto
+    tls: true
+    servername: cdn.example.com
+    network: ws
+    ws-opts:
+      path: /ws
+      headers:
+        Host: cdn.example.com
+  - name: VLESS Reality
+    type: vless
+    server: vless.example.com
+    port: 443
+    uuid: 2b5c6a6e-7d4f-4f3a-9a3e-1c2d3e4f5a6b
+    flow: xtls-rprx-vision
+    servername: www.microsoft.com
+    client-fingerprint: chrome
+    reality-opts: {public-key: pbk123, short-id: ab12}
+  - {name: Trojan gRPC, type: trojan, server: trojan.example.com, port: "443", password: pw, network: grpc, grpc-opts: {grpc-service-name: svc}}
+  - {name: HY2, type: hysteria2, server: hy2.example.com, port: 443, password: pw, obfs: salamander, obfs-password: x, skip-cert-verify: true}
+  - {name: TUIC, type: tuic, server: tuic.example.com, port: 443, uuid: 2b5c6a6e-7d4f-4f3a-9a3e-1c2d3e4f5a6b, password: pw, alpn: [h3], congestion-controller: bbr}
+  - {name: Plugin, type: ss, server: p.example.com, port: 8388, cipher: aes-256-gcm, password: pw, plugin: obfs}
+  - {name: ShadowTLS, type: ss, server: stls.example.com, port: 443, cipher: aes-256-gcm, password: pw, plugin: shadow-tls, plugin-opts: {host: www.bing.com, password: x, version: 3}}
+  - {name: Snell, type: snell, server: s.example.com, port: 443, psk: x}
+proxy-groups:
+  - {name: Auto, type: url-test, proxies: [VMess WS]}
+`
+
+func TestParseClashYAML(t *testing.T) {
+	protocols, report, err := NewDecoder().parseProtocols(clashSubscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(protocols) != 6 || report.Parsed != 6 {
+		t.Fatalf("parsed %d nodes (report %d), want 6", len(protocols), report.Parsed)
+	}
+	skipped := report.Skipped
+	if len(skipped) != 3 || skipped[0].Line != 30 || skipped[0].Unsupported != "obfs plugin" ||
+		skipped[1].Unsupported != "shadow-tls" || skipped[2].Scheme != "snell" || skipped[2].Unsupported != "" {
+		t.Errorf("skipped = %+v, want the obfs node on line 30 and the shadow-tls node as unsupported, and the snell node", skipped)
+	}
+
+	want := []struct {
+		typ   models.ProtocolType
+		extra map[string]string
+	}{
+		{models.ProtocolShadowsocks, map[string]string{"method": "aes-256-gcm"}},
+		{models.ProtocolVMess, map[string]string{"path": "/ws", "host": "cdn.example.com", "aid": "0"}},
+		{models.ProtocolVLESS, map[string]string{"security": "reality", "pbk": "pbk123", "sid": "ab12", "fp": "chrome", "flow": "xtls-rprx-vision"}},
+		{models.ProtocolTrojan, map[string]string{"serviceName": "svc"}},
+		{models.ProtocolHysteria2, map[string]string{"obfs": "salamander", "insecure": "1"}},
+		{models.ProtocolTUIC, map[string]string{"alpn": "h3", "congestion_control": "bbr"}},
+	}
+	for i, w := range want {
+		p := protocols[i]
+		if p.Type != w.typ {
+			t.Errorf("%s: type %s, want %s", p.Name, p.Type, w.typ)
+		}
+		for key, value := range w.extra {
+			if p.Extra[key] != value {
+				t.Errorf("%s: extra %s = %v, want %s", p.Name, key, p.Extra[key], value)
+			}
+		}
+		if len(p.Warnings) != 0 {
+			t.Errorf("%s: warnings %v", p.Name, p.Warnings)
+		}
+
+		// The share link stands in for the entry and parses the same
+		reparsed, err := NewDecoder().ParseProtocol(p.Raw)
+		if err != nil {
+			t.Errorf("%s: share link %q: %v", p.Name, p.Raw, err)
+			continue
+		}
+		if a, _ := Canonical(reparsed); a != p.Raw {
+			t.Errorf("%s: share link %q reparses as %q", p.Name, p.Raw, a)
+		}
+	}
+	if protocols[0].AdvertisedCountry != "DE" {
+		t.Errorf("country = %q, want DE", protocols[0].AdvertisedCountry)
+	}
+	if protocols[1].Network != "ws" || !protocols[1].TLS || protocols[1].SNI != "cdn.example.com" {
+		t.Errorf("vmess = %+v", protocols[1])
+	}
+}
@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// linkSchemes are the share link schemes parseProtocolLine understands
+var linkSchemes = []string{"vmess://", "vless://", "trojan://", "ss://", "hysteria2://", "hy2://", "tuic://", "wg://", "wireguard://", "ssh://"}
+
+// linkPattern finds share links embedded in other text, e.g. an HTML page
+var linkPattern = regexp.MustCompile("(?:vmess|vless|trojan|ss|hysteria2|hy2|tuic|wg|wireguard|ssh)://[^\\s\"'<>`]+")
+
+// base64Block matches a line that is entirely base64, as some providers
+// interleave encoded blocks with plain lines
+var base64Block = regexp.MustCompile(`^[A-Za-z0-9+/_-]{16,}={0,2}$`)
+
+var (
+	htmlTitle   = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlHeading = regexp.MustCompile(`(?is)<h1[^>]*>(.*?)</h1>`)
+	htmlTag     = regexp.MustCompile(`<[^>]+>`)
+)
+
+// ProviderError is a subscription response that carries an error from the
+// provider, such as an expired-plan page, instead of a node list
+type ProviderError struct {
+	Format  string // html or json
+	Message string
+}
+
+func (e *ProviderError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("subscription provider returned an error page (%s) instead of nodes", e.Format)
+	}
+	return fmt.Sprintf("subscription provider returned an error: %s", e.Message)
+}
+
+// detectProviderError recognises HTML pages and JSON error objects. It is
+// only consulted when no links were found, since some providers wrap valid
+// links in HTML.
+func detectProviderError(content string) *ProviderError {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(content, "\ufeff"))
+	lower := strings.ToLower(trimmed)
+
+	switch {
+	case strings.HasPrefix(lower, "<!doctype html"), strings.HasPrefix(lower, "<html"), strings.Contains(lower, "<body"):
+		perr := &ProviderError{Format: "html"}
+		for _, pattern := range []*regexp.Regexp{htmlTitle, htmlHeading} {
+			if match := pattern.FindStringSubmatch(trimmed); match != nil {
+				perr.Message = cleanText(match[1])
+				if perr.Message != "" {
+					break
+				}
+			}
+		}
+		return perr
+
+	case strings.HasPrefix(trimmed, "{"):
+		var body map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &body); err != nil {
+			return nil
+		}
+		perr := &ProviderError{Format: "json"}
+		for _, key := range []string{"error", "message", "msg", "detail", "reason"} {
+			if message := jsonMessage(body[key]); message != "" {
+				perr.Message = message
+				break
+			}
+		}
+		return perr
+	}
+	return nil
+}
+
+// jsonMessage extracts an error text from a JSON value, which may be a
+// string or an object with its own message
+func jsonMessage(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return cleanText(v)
+	case map[string]interface{}:
+		for _, key := range []string{"message", "msg", "detail"} {
+			if message, ok := v[key].(string); ok {
+				return cleanText(message)
+			}
+		}
+	}
+	return ""
+}
+
+// cleanText strips tags and collapses whitespace, and keeps messages short
+func cleanText(s string) string {
+	s = html.UnescapeString(htmlTag.ReplaceAllString(s, " "))
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
+
+// extractLinks returns the share links in one line of subscription content.
+// Comments and noise yield nothing; links embedded in markup and lines that
+// are themselves base64 blocks of links are recovered.
+func extractLinks(line string) []string {
+	return extractLinksDepth(line, 0)
+}
+
+func extractLinksDepth(line string, depth int) []string {
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") || strings.HasPrefix(line, ";") {
+		return nil
+	}
+	if hasLinkScheme(line) {
+		return []string{line}
+	}
+
+	if links := linkPattern.FindAllString(html.UnescapeString(line), -1); len(links) > 0 {
+		return links
+	}
+
+	if depth == 0 && base64Block.MatchString(line) {
+		decoded, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			decoded, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(line, "="))
+		}
+		if err == nil {
+			var links []string
+			for _, inner := range strings.Split(string(decoded), "\n") {
+				links = append(links, extractLinksDepth(strings.TrimSpace(inner), depth+1)...)
+			}
+			return links
+		}
+	}
+	return nil
+}
+
+func hasLinkScheme(line string) bool {
+	for _, scheme := range linkSchemes {
+		if strings.HasPrefix(line, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkScheme returns the scheme of a share link, e.g. "vless"
+func linkScheme(line string) string {
+	scheme, _, ok := strings.Cut(line, "://")
+	if !ok {
+		return ""
+	}
+	return scheme
+}
+
+// redactLink masks the credentials of a share link and shortens it, so it
+// can be shown in reports. Base64 payloads (vmess, legacy ss) carry their
+// credentials inside and are cut short.
+func redactLink(line string) string {
+	scheme, rest, ok := strings.Cut(line, "://")
+	if !ok {
+		return truncate(line, 40)
+	}
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		end = len(rest)
+	}
+	if at := strings.LastIndex(rest[:end], "@"); at >= 0 {
+		return truncate(scheme+"://***"+rest[at:], 120)
+	}
+	return truncate(scheme+"://"+rest, len(scheme)+3+16)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
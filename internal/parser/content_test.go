@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseMixedContent(t *testing.T) {
+	block := base64.StdEncoding.EncodeToString([]byte("trojan://pw@b.example.com:443#b\nvless://uuid@c.example.com:443#c"))
+	content := "# provider: example\n" +
+		"trojan://pw@a.example.com:443#a\n" +
+		"\n" +
+		"<p>Backup: <a href=\"tuic://uuid:pw@d.example.com:443?alpn=h3&amp;congestion_control=bbr#d\">d</a></p>\n" +
+		block + "\n" +
+		"// end of list\n"
+
+	protocols, _, err := NewDecoder().parseProtocols(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range protocols {
+		names = append(names, p.Name)
+	}
+	if len(names) != 4 || names[0] != "a" || names[1] != "d" || names[2] != "b" || names[3] != "c" {
+		t.Fatalf("parsed %v, want [a d b c]", names)
+	}
+	if protocols[1].Extra["congestion_control"] != "bbr" {
+		t.Errorf("HTML entities not decoded in embedded link: %v", protocols[1].Extra)
+	}
+}
+
+func TestParseProviderError(t *testing.T) {
+	tests := []struct {
+		content string
+		message string
+	}{
+		{"<!DOCTYPE html><html><head><title>Subscription expired</title></head><body>Renew</body></html>", "Subscription expired"},
+		{"<html><body><h1>403 &amp; Forbidden</h1></body></html>", "403 & Forbidden"},
+		{`{"code":403,"error":{"message":"token invalid"}}`, "token invalid"},
+		{`{"msg":"traffic exhausted"}`, "traffic exhausted"},
+	}
+	for _, tt := range tests {
+		_, _, err := NewDecoder().parseProtocols(tt.content)
+		var perr *ProviderError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: error %v, want a ProviderError", tt.content, err)
+			continue
+		}
+		if perr.Message != tt.message {
+			t.Errorf("%q: message %q, want %q", tt.content, perr.Message, tt.message)
+		}
+	}
+
+	// Plain garbage is not attributed to the provider
+	_, _, err := NewDecoder().parseProtocols("hello\nworld")
+	var perr *ProviderError
+	if err == nil || errors.As(err, &perr) {
+		t.Errorf("garbage: error %v, want a plain parse error", err)
+	}
+}
+
+func TestParseReport(t *testing.T) {
+	content := "trojan://pw@a.example.com:443#a\n" +
+		"vless://secret-uuid@b.example.com:99999?type=ws#b\n" +
+		"# comment\n" +
+		"vmess://not-base64!\n"
+
+	protocols, report, err := NewDecoder().parseProtocols(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(protocols) != 1 || report.Lines != 4 || report.Parsed != 1 || len(report.Skipped) != 2 {
+		t.Fatalf("report %+v", report)
+	}
+
+	vless := report.Skipped[0]
+	if vless.Line != 2 || vless.Scheme != "vless" || vless.Error == "" {
+		t.Errorf("vless issue %+v", vless)
+	}
+	if strings.Contains(vless.Snippet, "secret") || !strings.HasPrefix(vless.Snippet, "vless://***@b.example.com") {
+		t.Errorf("credentials not masked: %s", vless.Snippet)
+	}
+	if report.Skipped[1].Line != 4 || report.Skipped[1].Scheme != "vmess" {
+		t.Errorf("vmess issue %+v", report.Skipped[1])
+	}
+}
@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// countryNames maps ISO country codes to the names and major cities that
+// appear in node names, in English and Chinese. Chinese names are matched
+// as substrings, so single characters like 美 are left out.
+var countryNames = map[string][]string{
+	"AE": {"united arab emirates", "emirates", "dubai", "阿联酋", "迪拜"},
+	"AR": {"argentina", "阿根廷"},
+	"AT": {"austria", "vienna", "奥地利"},
+	"AU": {"australia", "sydney", "melbourne", "澳大利亚", "澳洲", "悉尼"},
+	"BE": {"belgium", "比利时"},
+	"BR": {"brazil", "sao paulo", "巴西"},
+	"CA": {"canada", "toronto", "vancouver", "montreal", "加拿大", "多伦多"},
+	"CH": {"switzerland", "zurich", "瑞士"},
+	"CL": {"chile", "智利"},
+	"CN": {"china", "中国", "大陆"},
+	"CZ": {"czechia", "czech republic", "prague", "捷克"},
+	"DE": {"germany", "frankfurt", "berlin", "德国", "法兰克福"},
+	"DK": {"denmark", "丹麦"},
+	"EG": {"egypt", "埃及"},
+	"ES": {"spain", "madrid", "西班牙"},
+	"FI": {"finland", "helsinki", "芬兰"},
+	"FR": {"france", "paris", "法国", "巴黎"},
+	"GB": {"united kingdom", "great britain", "england", "britain", "london", "英国", "伦敦"},
+	"HK": {"hong kong", "hongkong", "香港"},
+	"ID": {"indonesia", "jakarta", "印度尼西亚", "印尼"},
+	"IE": {"ireland", "dublin", "爱尔兰"},
+	"IL": {"israel", "以色列"},
+	"IN": {"india", "mumbai", "印度"},
+	"IR": {"iran", "tehran", "伊朗"},
+	"IT": {"italy", "milan", "意大利"},
+	"JP": {"japan", "tokyo", "osaka", "日本", "东京", "大阪"},
+	"KR": {"south korea", "korea", "seoul", "韩国", "首尔"},
+	"KZ": {"kazakhstan", "哈萨克斯坦"},
+	"MO": {"macau", "macao", "澳门"},
+	"MX": {"mexico", "墨西哥"},
+	"MY": {"malaysia", "kuala lumpur", "马来西亚"},
+	"NL": {"netherlands", "holland", "amsterdam", "荷兰"},
+	"NO": {"norway", "挪威"},
+	"NZ": {"new zealand", "新西兰"},
+	"PH": {"philippines", "manila", "菲律宾"},
+	"PL": {"poland", "warsaw", "波兰"},
+	"RO": {"romania", "罗马尼亚"},
+	"RU": {"russia", "moscow", "俄罗斯", "莫斯科"},
+	"SA": {"saudi arabia", "沙特"},
+	"SE": {"sweden", "stockholm", "瑞典"},
+	"SG": {"singapore", "新加坡", "狮城"},
+	"TH": {"thailand", "bangkok", "泰国"},
+	"TR": {"turkey", "türkiye", "istanbul", "土耳其"},
+	"TW": {"taiwan", "taipei", "台湾", "臺灣", "台北"},
+	"UA": {"ukraine", "kyiv", "乌克兰"},
+	"US": {"united states", "america", "los angeles", "san jose", "silicon valley", "seattle", "new york", "chicago", "dallas", "miami", "美国", "洛杉矶", "圣何塞", "硅谷", "西雅图", "纽约"},
+	"VN": {"vietnam", "viet nam", "越南"},
+	"ZA": {"south africa", "南非"},
+}
+
+// countryAliases are codes used in node names that are not ISO codes
+var countryAliases = map[string]string{"UK": "GB", "USA": "US", "UAE": "AE"}
+
+var (
+	countryByName = map[string]string{}
+	// englishNames needs word boundaries so "india" does not match
+	// "indiana"; \b is ASCII-only and would never match next to CJK text,
+	// so Chinese names have their own pattern
+	englishNames *regexp.Regexp
+	chineseNames *regexp.Regexp
+	codeToken    = regexp.MustCompile(`^([A-Z]{2,3})[0-9]*$`)
+)
+
+func init() {
+	var english, chinese []string
+	for code, names := range countryNames {
+		for _, name := range names {
+			countryByName[name] = code
+			if name[0] < unicode.MaxASCII {
+				english = append(english, regexp.QuoteMeta(name))
+			} else {
+				chinese = append(chinese, regexp.QuoteMeta(name))
+			}
+		}
+	}
+	// Longer names first, so "印度尼西亚" wins over "印度"
+	byLength := func(names []string) {
+		sort.Slice(names, func(i, j int) bool {
+			if len(names[i]) != len(names[j]) {
+				return len(names[i]) > len(names[j])
+			}
+			return names[i] < names[j]
+		})
+	}
+	byLength(english)
+	byLength(chinese)
+	englishNames = regexp.MustCompile(`\b(?:` + strings.Join(english, "|") + `)\b`)
+	chineseNames = regexp.MustCompile(strings.Join(chinese, "|"))
+}
+
+// CountryHint returns the ISO country code a node name advertises, or ""
+// when it names none. Flag emoji are the most explicit and are tried first,
+// then country and city names, then codes such as "[HK]", "JP-01" or "US 2".
+// When a name mentions several countries, e.g. a relay, the first one wins.
+func CountryHint(name string) string {
+	if code := flagCountry(name); code != "" {
+		return code
+	}
+	if code := nameCountry(name); code != "" {
+		return code
+	}
+	return codeCountry(name)
+}
+
+// flagCountry decodes the first flag emoji, a pair of regional indicator
+// symbols
+func flagCountry(name string) string {
+	var letters []rune
+	for _, r := range name {
+		if r < 0x1F1E6 || r > 0x1F1FF {
+			letters = letters[:0]
+			continue
+		}
+		letters = append(letters, 'A'+r-0x1F1E6)
+		if len(letters) == 2 {
+			code := string(letters)
+			// Not countries
+			if code != "EU" && code != "UN" {
+				return code
+			}
+			letters = letters[:0]
+		}
+	}
+	return ""
+}
+
+// nameCountry finds the leftmost country or city name
+func nameCountry(name string) string {
+	lower := strings.ToLower(name)
+	english := englishNames.FindStringIndex(lower)
+	chinese := chineseNames.FindStringIndex(lower)
+
+	match := english
+	if match == nil || (chinese != nil && chinese[0] < english[0]) {
+		match = chinese
+	}
+	if match == nil {
+		return ""
+	}
+	return countryByName[lower[match[0]:match[1]]]
+}
+
+// codeCountry finds an upper-case country code standing on its own or
+// followed by a node number. Only codes of countries in countryNames are
+// accepted, as short upper-case words like "VIP" or "GO" are common too.
+func codeCountry(name string) string {
+	tokens := strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for _, token := range tokens {
+		match := codeToken.FindStringSubmatch(token)
+		// CN2 is a China Telecom backbone route, not a location
+		if match == nil || token == "CN2" {
+			continue
+		}
+		code := match[1]
+		if alias, ok := countryAliases[code]; ok {
+			code = alias
+		}
+		if _, ok := countryNames[code]; ok {
+			return code
+		}
+	}
+	return ""
+}
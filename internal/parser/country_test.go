@@ -0,0 +1,31 @@
+package parser
+
+import "testing"
+
+func TestCountryHint(t *testing.T) {
+	tests := []struct {
+		name    string
+		country string
+	}{
+		{"🇭🇰 Premium 01", "HK"},
+		{"🇪🇺 🇩🇪 Frankfurt", "DE"},
+		{"[JP] Tokyo 02", "JP"},
+		{"Hong Kong | IPLC", "HK"},
+		{"HongKong-03", "HK"},
+		{"SG01 x2", "SG"},
+		{"UK London", "GB"},
+		{"USA 5", "US"},
+		{"香港 CN2 GIA", "HK"},
+		{"HK CN2 GIA", "HK"},
+		{"印度尼西亚 01", "ID"},
+		{"Indiana relay", ""},
+		{"VIP node 7", ""},
+		{"Free node", ""},
+		{"Japan → Russia relay", "JP"},
+	}
+	for _, tt := range tests {
+		if got := CountryHint(tt.name); got != tt.country {
+			t.Errorf("CountryHint(%q) = %q, want %q", tt.name, got, tt.country)
+		}
+	}
+}
@@ -3,16 +3,37 @@ package parser
 import (
 	"bufio"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/VenoMexx/ProtoScope/pkg/models"
 )
 
+// UnsupportedError is the parse error of a node that is well-formed but
+// needs a feature ProtoScope can't test, such as a Shadowsocks plugin
+type UnsupportedError struct {
+	Feature string
+}
+
+func (e *UnsupportedError) Error() string {
+	return e.Feature + " is not supported"
+}
+
+// unsupportedFeature returns the feature of an UnsupportedError, or ""
+func unsupportedFeature(err error) string {
+	var unsupported *UnsupportedError
+	if errors.As(err, &unsupported) {
+		return unsupported.Feature
+	}
+	return ""
+}
+
 // Decoder handles subscription link decoding
 type Decoder struct {
 	client *http.Client
@@ -50,7 +71,7 @@ func (d *Decoder) DecodeSubscription(url string) (*models.Subscription, error) {
 	}
 
 	// Parse protocols from decoded content
-	protocols, err := d.parseProtocols(decoded)
+	protocols, report, err := d.parseProtocols(decoded)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse protocols: %w", err)
 	}
@@ -59,6 +80,7 @@ func (d *Decoder) DecodeSubscription(url string) (*models.Subscription, error) {
 		URL:       url,
 		Protocols: protocols,
 		ParsedAt:  time.Now(),
+		Report:    report,
 	}, nil
 }
 
@@ -79,6 +101,12 @@ func (d *Decoder) fetchSubscription(url string) (string, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		// Providers often explain the failure (expired plan, bad token) in
+		// the body
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if perr := detectProviderError(string(body)); perr != nil {
+			return "", fmt.Errorf("unexpected status code: %d: %w", resp.StatusCode, perr)
+		}
 		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
@@ -113,53 +141,82 @@ func (d *Decoder) decodeBase64(content string) (string, error) {
 	return "", fmt.Errorf("failed to decode base64")
 }
 
-// parseProtocols parses protocols from decoded content
-func (d *Decoder) parseProtocols(content string) ([]*models.Protocol, error) {
+// parseProtocols parses protocols from decoded content. Comments and
+// noise such as HTML around the links are ignored; links that fail to parse
+// are recorded in the report. When no links are found at all, an error page
+// from the provider is reported as a ProviderError. Clash configs are read
+// from their proxies list instead, and sing-box configs from their outbounds
+// and endpoints.
+func (d *Decoder) parseProtocols(content string) ([]*models.Protocol, *models.ParseReport, error) {
+	if isClashYAML(content) {
+		return d.parseClashProxies(content)
+	}
+	if isSingboxJSON(content) {
+		return d.parseSingboxOutbounds(content)
+	}
+
 	var protocols []*models.Protocol
+	report := &models.ParseReport{}
 
 	scanner := bufio.NewScanner(strings.NewReader(content))
-	lineNum := 0
-	skippedCount := 0
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
 	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-
-		protocol, err := d.parseProtocolLine(line)
-		if err != nil {
-			// Skip invalid lines but continue parsing
-			skippedCount++
-			fmt.Printf("[DEBUG] Line %d - Skipped: %v\n", lineNum, err)
-			if len(line) > 120 {
-				fmt.Printf("[DEBUG]   Content: %s...\n", line[:120])
-			} else {
-				fmt.Printf("[DEBUG]   Content: %s\n", line)
+		report.Lines++
+		for _, line := range extractLinks(strings.TrimSpace(scanner.Text())) {
+			protocol, err := d.parseProtocolLine(line)
+			if err != nil {
+				// Skip invalid lines but continue parsing
+				report.Skipped = append(report.Skipped, models.ParseIssue{
+					Line:        report.Lines,
+					Scheme:      linkScheme(line),
+					Error:       err.Error(),
+					Snippet:     redactLink(line),
+					Unsupported: unsupportedFeature(err),
+				})
+				continue
 			}
-			continue
-		}
-
-		protocols = append(protocols, protocol)
-	}
 
-	if skippedCount > 0 {
-		fmt.Printf("\n⚠️  Warning: Skipped %d lines due to parse errors\n\n", skippedCount)
+			protocols = append(protocols, protocol)
+		}
 	}
+	report.Parsed = len(protocols)
 
 	if err := scanner.Err(); err != nil {
-		return nil, err
+		return nil, report, err
 	}
 
 	if len(protocols) == 0 {
-		return nil, fmt.Errorf("no valid protocols found")
+		if perr := detectProviderError(content); perr != nil {
+			return nil, report, perr
+		}
+		if len(report.Skipped) > 0 {
+			first := report.Skipped[0]
+			return nil, report, fmt.Errorf("no valid protocols found; %d links failed to parse, first on line %d: %s", len(report.Skipped), first.Line, first.Error)
+		}
+		return nil, report, fmt.Errorf("no valid protocols found")
 	}
 
-	return protocols, nil
+	return protocols, report, nil
 }
 
-// parseProtocolLine parses a single protocol line
+// ParseProtocol parses a single proxy URL
+func (d *Decoder) ParseProtocol(line string) (*models.Protocol, error) {
+	return d.parseProtocolLine(strings.TrimSpace(line))
+}
+
+// parseProtocolLine parses a single protocol line and validates the result
 func (d *Decoder) parseProtocolLine(line string) (*models.Protocol, error) {
+	protocol, err := parseLink(line)
+	if err != nil {
+		return nil, err
+	}
+	protocol.Warnings = Validate(protocol)
+	protocol.AdvertisedCountry = CountryHint(protocol.Name)
+	return protocol, nil
+}
+
+// parseLink dispatches a share link to the parser for its scheme
+func parseLink(line string) (*models.Protocol, error) {
 	// Detect protocol type from URL scheme
 	switch {
 	case strings.HasPrefix(line, "vmess://"):
@@ -174,6 +231,10 @@ func (d *Decoder) parseProtocolLine(line string) (*models.Protocol, error) {
 		return ParseHysteria2(line)
 	case strings.HasPrefix(line, "tuic://"):
 		return ParseTUIC(line)
+	case strings.HasPrefix(line, "wg://"), strings.HasPrefix(line, "wireguard://"):
+		return ParseWireGuard(line)
+	case strings.HasPrefix(line, "ssh://"):
+		return ParseSSH(line)
 	default:
 		return nil, fmt.Errorf("unknown protocol type")
 	}
@@ -195,7 +256,7 @@ func (d *Decoder) DecodeFromFile(filepath string) (*models.Subscription, error)
 	}
 
 	// Parse protocols from decoded content
-	protocols, err := d.parseProtocols(decoded)
+	protocols, report, err := d.parseProtocols(decoded)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse protocols: %w", err)
 	}
@@ -204,5 +265,18 @@ func (d *Decoder) DecodeFromFile(filepath string) (*models.Subscription, error)
 		URL:       filepath,
 		Protocols: protocols,
 		ParsedAt:  time.Now(),
+		Report:    report,
 	}, nil
 }
+
+// parsePort parses a port number and checks that it is in range
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %d out of range", port)
+	}
+	return port, nil
+}
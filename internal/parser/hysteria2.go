@@ -3,7 +3,6 @@ package parser
 import (
 	"fmt"
 	"net/url"
-	"strconv"
 	"strings"
 
 	"github.com/VenoMexx/ProtoScope/pkg/models"
@@ -38,7 +37,7 @@ func ParseHysteria2(rawURL string) (*models.Protocol, error) {
 	if portStr == "" {
 		portStr = "443" // Default port
 	}
-	port, err := strconv.Atoi(portStr)
+	port, err := parsePort(portStr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid port: %w", err)
 	}
@@ -73,6 +72,7 @@ func ParseHysteria2(rawURL string) (*models.Protocol, error) {
 			"pinSHA256":    query.Get("pinSHA256"),
 		},
 	}
+	keepUnknownParams(protocol.Extra, query, "sni")
 
 	return protocol, nil
 }
@@ -103,7 +103,7 @@ func ParseTUIC(rawURL string) (*models.Protocol, error) {
 	if portStr == "" {
 		portStr = "443" // Default port
 	}
-	port, err := strconv.Atoi(portStr)
+	port, err := parsePort(portStr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid port: %w", err)
 	}
@@ -138,6 +138,7 @@ func ParseTUIC(rawURL string) (*models.Protocol, error) {
 			"disable_sni":        query.Get("disable_sni"),
 		},
 	}
+	keepUnknownParams(protocol.Extra, query, "sni")
 
 	return protocol, nil
 }
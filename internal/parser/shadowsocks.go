@@ -4,7 +4,6 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/url"
-	"strconv"
 	"strings"
 
 	"github.com/VenoMexx/ProtoScope/pkg/models"
@@ -25,6 +24,16 @@ func ParseShadowsocks(rawURL string) (*models.Protocol, error) {
 		name, _ = url.QueryUnescape(parts[1])
 	}
 
+	// SIP002 links name their plugin in the query, e.g.
+	// ?plugin=obfs-local;obfs=http
+	if _, query, found := strings.Cut(encoded, "?"); found {
+		values, _ := url.ParseQuery(query)
+		if plugin := values.Get("plugin"); plugin != "" {
+			plugin, _, _ = strings.Cut(plugin, ";")
+			return nil, &UnsupportedError{Feature: shadowsocksPlugin(plugin)}
+		}
+	}
+
 	// Try to decode base64
 	decoded, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
@@ -63,8 +72,11 @@ func ParseShadowsocks(rawURL string) (*models.Protocol, error) {
 			return nil, fmt.Errorf("invalid shadowsocks format")
 		}
 		server = serverInfo[:colonIndex]
+		if server == "" {
+			return nil, fmt.Errorf("missing server in shadowsocks url")
+		}
 		portStr := serverInfo[colonIndex+1:]
-		port, err = strconv.Atoi(portStr)
+		port, err = parsePort(portStr)
 		if err != nil {
 			return nil, fmt.Errorf("invalid port: %w", err)
 		}
@@ -92,3 +104,16 @@ func ParseShadowsocks(rawURL string) (*models.Protocol, error) {
 
 	return protocol, nil
 }
+
+// shadowsocksPlugin names the feature a Shadowsocks plugin stands for in
+// the parse report. ProtoScope drives the backends without plugins, and
+// shadow-tls also needs a second outbound in front.
+func shadowsocksPlugin(plugin string) string {
+	switch plugin {
+	case "shadow-tls", "shadowtls":
+		return "shadow-tls"
+	case "obfs", "obfs-local", "simple-obfs":
+		return "obfs plugin"
+	}
+	return "shadowsocks plugin " + plugin
+}
@@ -0,0 +1,358 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// singboxOutbound is an outbound or endpoint of a sing-box config
+type singboxOutbound map[string]interface{}
+
+// singboxEntry is an outbound with the line it starts on
+type singboxEntry struct {
+	line     int
+	outbound singboxOutbound
+}
+
+// singboxNonProxy are outbound types that route traffic rather than proxy
+// it; they are left out silently
+var singboxNonProxy = map[string]bool{
+	"direct":   true,
+	"block":    true,
+	"dns":      true,
+	"selector": true,
+	"urltest":  true,
+}
+
+// isSingboxJSON reports whether content is a sing-box config rather than a
+// list of share links
+func isSingboxJSON(content string) bool {
+	trimmed := strings.TrimSpace(content)
+	return strings.HasPrefix(trimmed, "{") &&
+		(strings.Contains(trimmed, `"outbounds"`) || strings.Contains(trimmed, `"endpoints"`))
+}
+
+// parseSingboxOutbounds parses the outbounds and endpoints of a sing-box
+// config. Like links, each one is validated, and proxies that fail to
+// convert are recorded in the report with their line.
+func (d *Decoder) parseSingboxOutbounds(content string) ([]*models.Protocol, *models.ParseReport, error) {
+	report := &models.ParseReport{Lines: strings.Count(content, "\n") + 1}
+
+	entries, err := readSingboxOutbounds(content)
+	if err != nil {
+		return nil, report, fmt.Errorf("failed to parse sing-box config: %w", err)
+	}
+
+	// A shadow-tls outbound is the TLS layer of the outbound that detours
+	// through it, and reported with that one
+	types := make(map[string]string)
+	detoured := make(map[string]bool)
+	for _, entry := range entries {
+		types[entry.outbound.str("tag")] = entry.outbound.str("type")
+		detoured[entry.outbound.str("detour")] = true
+	}
+
+	var protocols []*models.Protocol
+	for _, entry := range entries {
+		typ := entry.outbound.str("type")
+		if singboxNonProxy[typ] || (typ == "shadowtls" && detoured[entry.outbound.str("tag")]) {
+			continue
+		}
+		var protocol *models.Protocol
+		var err error
+		if detour := entry.outbound.str("detour"); detour != "" {
+			err = &UnsupportedError{Feature: "detour through " + detour}
+			if types[detour] == "shadowtls" {
+				err = &UnsupportedError{Feature: "shadow-tls"}
+			}
+		} else {
+			protocol, err = entry.outbound.protocol()
+		}
+		if err != nil {
+			report.Skipped = append(report.Skipped, models.ParseIssue{
+				Line:        entry.line,
+				Scheme:      typ,
+				Error:       err.Error(),
+				Snippet:     truncate(entry.outbound.str("tag"), 40),
+				Unsupported: unsupportedFeature(err),
+			})
+			continue
+		}
+
+		finishStructured(protocol)
+		protocols = append(protocols, protocol)
+	}
+	report.Parsed = len(protocols)
+
+	if len(protocols) == 0 {
+		if len(report.Skipped) > 0 {
+			first := report.Skipped[0]
+			return nil, report, fmt.Errorf("no valid protocols found; %d sing-box outbounds failed to parse, first on line %d: %s", len(report.Skipped), first.Line, first.Error)
+		}
+		return nil, report, fmt.Errorf("no valid protocols found")
+	}
+
+	return protocols, report, nil
+}
+
+// readSingboxOutbounds decodes the outbounds and endpoints arrays of a
+// config, noting the line each entry starts on
+func readSingboxOutbounds(content string) ([]singboxEntry, error) {
+	dec := json.NewDecoder(strings.NewReader(content))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("config is not a JSON object")
+	}
+
+	var entries []singboxEntry
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key != "outbounds" && key != "endpoints" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return nil, fmt.Errorf("%s is not an array", key)
+		}
+		for dec.More() {
+			// The offset is past the previous element; skip to this one
+			start := int(dec.InputOffset())
+			start += len(content[start:]) - len(strings.TrimLeft(content[start:], " \t\r\n,"))
+			var outbound singboxOutbound
+			if err := dec.Decode(&outbound); err != nil {
+				return nil, err
+			}
+			entries = append(entries, singboxEntry{
+				line:     strings.Count(content[:start], "\n") + 1,
+				outbound: outbound,
+			})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// protocol converts the outbound, mapping its options to the Extra keys of
+// the share link parsers
+func (o singboxOutbound) protocol() (*models.Protocol, error) {
+	typ := o.str("type")
+	server, portStr := o.str("server"), o.str("server_port")
+	// WireGuard endpoints name the server in their peer
+	var peer singboxOutbound
+	if peers, ok := o["peers"].([]interface{}); ok && len(peers) > 0 {
+		peer = singboxOutbound(asOptions(peers[0]))
+		server, portStr = peer.str("address"), peer.str("port")
+	}
+	if portStr == "" && typ == "ssh" {
+		portStr = "22" // Default port
+	}
+
+	if server == "" {
+		return nil, fmt.Errorf("missing server in sing-box outbound")
+	}
+	port, err := parsePort(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port: %w", err)
+	}
+	name := o.str("tag")
+	if name == "" {
+		name = fmt.Sprintf("%s:%d", server, port)
+	}
+
+	protocol := &models.Protocol{
+		Name:   name,
+		Server: server,
+		Port:   port,
+		Extra:  map[string]interface{}{},
+	}
+
+	switch typ {
+	case "shadowsocks":
+		if plugin := o.str("plugin"); plugin != "" {
+			return nil, &UnsupportedError{Feature: shadowsocksPlugin(plugin)}
+		}
+		protocol.Type = models.ProtocolShadowsocks
+		protocol.Password = o.str("password")
+		protocol.Network = "tcp"
+		protocol.Extra["method"] = o.str("method")
+
+	case "vmess":
+		protocol.Type = models.ProtocolVMess
+		protocol.UUID = o.str("uuid")
+		protocol.Extra["aid"] = o.str("alter_id")
+		if protocol.Extra["aid"] == "" {
+			protocol.Extra["aid"] = "0"
+		}
+		o.tls(protocol)
+		o.transport(protocol)
+
+	case "vless":
+		protocol.Type = models.ProtocolVLESS
+		protocol.UUID = o.str("uuid")
+		setExtra(protocol.Extra, "flow", o.str("flow"))
+		o.tls(protocol)
+		o.transport(protocol)
+
+	case "trojan":
+		protocol.Type = models.ProtocolTrojan
+		protocol.Password = o.str("password")
+		o.tls(protocol)
+		protocol.TLS = true
+		if protocol.SNI == "" {
+			protocol.SNI = server
+		}
+		o.transport(protocol)
+
+	case "hysteria2":
+		protocol.Type = models.ProtocolHysteria2
+		protocol.Password = o.str("password")
+		protocol.Network = "udp"
+		o.tls(protocol)
+		protocol.TLS = true
+		if protocol.SNI == "" {
+			protocol.SNI = server
+		}
+		if obfs := o.sub("obfs"); obfs != nil {
+			setExtra(protocol.Extra, "obfs", obfs.str("type"))
+			setExtra(protocol.Extra, "obfs-password", obfs.str("password"))
+		}
+
+	case "tuic":
+		protocol.Type = models.ProtocolTUIC
+		protocol.UUID = o.str("uuid")
+		protocol.Password = o.str("password")
+		protocol.Network = "udp"
+		o.tls(protocol)
+		protocol.TLS = true
+		if protocol.SNI == "" {
+			protocol.SNI = server
+		}
+		setExtra(protocol.Extra, "congestion_control", o.str("congestion_control"))
+
+	case "wireguard":
+		protocol.Type = models.ProtocolWireGuard
+		protocol.Password = o.str("private_key")
+		protocol.Network = "udp"
+		// The endpoint keeps the peer's keys in the peer, the legacy
+		// outbound at the top level
+		keys := o
+		if peer != nil {
+			keys = peer
+		}
+		setExtra(protocol.Extra, "publickey", keys.str("public_key"))
+		setExtra(protocol.Extra, "publickey", o.str("peer_public_key"))
+		setExtra(protocol.Extra, "presharedkey", keys.str("pre_shared_key"))
+		setExtra(protocol.Extra, "reserved", keys.str("reserved"))
+		setExtra(protocol.Extra, "address", o.str("address"))
+		setExtra(protocol.Extra, "address", o.str("local_address"))
+		setExtra(protocol.Extra, "mtu", o.str("mtu"))
+
+	case "ssh":
+		protocol.Type = models.ProtocolSSH
+		protocol.Password = o.str("password")
+		protocol.Network = "tcp"
+		setExtra(protocol.Extra, "user", o.str("user"))
+		// A key may be given as an array of its lines
+		privateKey := o.str("private_key")
+		if lines, ok := o["private_key"].([]interface{}); ok {
+			keyLines := make([]string, len(lines))
+			for i, line := range lines {
+				keyLines[i] = fmt.Sprint(line)
+			}
+			privateKey = strings.Join(keyLines, "\n")
+		}
+		setExtra(protocol.Extra, "privatekey", privateKey)
+		setExtra(protocol.Extra, "passphrase", o.str("private_key_passphrase"))
+		setExtra(protocol.Extra, "hostkey", o.str("host_key"))
+
+	default:
+		if typ == "shadowtls" {
+			return nil, &UnsupportedError{Feature: "shadow-tls"}
+		}
+		return nil, fmt.Errorf("unsupported sing-box outbound type %q", typ)
+	}
+
+	return protocol, nil
+}
+
+// tls maps the TLS options: server name, uTLS fingerprint, REALITY and ALPN
+func (o singboxOutbound) tls(protocol *models.Protocol) {
+	tls := o.sub("tls")
+	if tls == nil || !tls.bool("enabled") {
+		return
+	}
+	protocol.TLS = true
+	protocol.SNI = tls.str("server_name")
+	if protocol.Type == models.ProtocolVLESS {
+		protocol.Extra["security"] = "tls"
+	}
+	if utls := tls.sub("utls"); utls != nil && utls.bool("enabled") {
+		setExtra(protocol.Extra, "fp", utls.str("fingerprint"))
+	}
+	if reality := tls.sub("reality"); reality != nil && reality.bool("enabled") {
+		protocol.Extra["security"] = "reality"
+		setExtra(protocol.Extra, "pbk", reality.str("public_key"))
+		setExtra(protocol.Extra, "sid", reality.str("short_id"))
+	}
+	setExtra(protocol.Extra, "alpn", tls.str("alpn"))
+	if tls.bool("insecure") {
+		protocol.Extra["insecure"] = "1"
+	}
+}
+
+// transport maps the ws, grpc, http and httpupgrade transports of V2Ray-based
+// proxies
+func (o singboxOutbound) transport(protocol *models.Protocol) {
+	transport := o.sub("transport")
+	if transport == nil {
+		protocol.Network = "tcp"
+		return
+	}
+	protocol.Network = transport.str("type")
+	switch protocol.Network {
+	case "ws", "httpupgrade":
+		setExtra(protocol.Extra, "path", transport.str("path"))
+		if headers := transport.sub("headers"); headers != nil {
+			setExtra(protocol.Extra, "host", headers.str("Host"))
+		}
+		setExtra(protocol.Extra, "host", transport.str("host"))
+	case "grpc":
+		setExtra(protocol.Extra, "serviceName", transport.str("service_name"))
+	case "http":
+		setExtra(protocol.Extra, "path", transport.str("path"))
+		setExtra(protocol.Extra, "host", transport.str("host"))
+	}
+}
+
+// str returns an option as a string, as clashProxy.str does
+func (o singboxOutbound) str(key string) string {
+	return clashProxy(o).str(key)
+}
+
+// bool returns a boolean option
+func (o singboxOutbound) bool(key string) bool {
+	return clashProxy(o).bool(key)
+}
+
+// sub returns nested options such as tls, or nil
+func (o singboxOutbound) sub(key string) singboxOutbound {
+	return singboxOutbound(asOptions(o[key]))
+}
+
+// asOptions returns a decoded JSON object as a map, or nil
+func asOptions(value interface{}) map[string]interface{} {
+	options, _ := value.(map[string]interface{})
+	return options
+}
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+const singboxSubscription = `{
+  "log": {"level": "warn"},
+  "outbounds": [
+    {"type": "selector", "tag": "proxy", "outbounds": ["🇩🇪 SS", "VLESS Reality"]},
+    {"type": "shadowsocks", "tag": "🇩🇪 SS", "server": "ss.example.com", "server_port": 8388, "method": "aes-256-gcm", "password": "pw"},
+    {
+      "type": "vless",
+      "tag": "VLESS Reality",
+      "server": "vless.example.com",
+      "server_port": 443,
+      "uuid": "2b5c6a6e-7d4f-4f3a-9a3e-1c2d3e4f5a6b",
+      "flow": "xtls-rprx-vision",
+      "tls": {
+        "enabled": true,
+        "server_name": "www.microsoft.com",
+        "utls": {"enabled": true, "fingerprint": "chrome"},
+        "reality": {"enabled": true, "public_key": "pbk123", "short_id": "ab12"}
+      }
+    },
+    {"type": "vmess", "tag": "VMess WS", "server": "vmess.example.com", "server_port": 443, "uuid": "2b5c6a6e-7d4f-4f3a-9a3e-1c2d3e4f5a6b",
+     "tls": {"enabled": true, "server_name": "cdn.example.com"},
+     "transport": {"type": "ws", "path": "/ws", "headers": {"Host": "cdn.example.com"}}},
+    {"type": "trojan", "tag": "Trojan gRPC", "server": "trojan.example.com", "server_port": 443, "password": "pw", "tls": {"enabled": true, "insecure": true}, "transport": {"type": "grpc", "service_name": "svc"}},
+    {"type": "hysteria2", "tag": "HY2", "server": "hy2.example.com", "server_port": 443, "password": "pw", "obfs": {"type": "salamander", "password": "x"}, "tls": {"enabled": true, "insecure": true}},
+    {"type": "tuic", "tag": "TUIC", "server": "tuic.example.com", "server_port": 443, "uuid": "2b5c6a6e-7d4f-4f3a-9a3e-1c2d3e4f5a6b", "password": "pw", "congestion_control": "bbr", "tls": {"enabled": true, "alpn": ["h3"]}},
+    {"type": "shadowsocks", "tag": "Plugin", "server": "p.example.com", "server_port": 8388, "method": "aes-256-gcm", "password": "pw", "plugin": "obfs-local"},
+    {"type": "shadowsocks", "tag": "ShadowTLS", "method": "2022-blake3-aes-128-gcm", "password": "pw", "detour": "shadowtls-out"},
+    {"type": "shadowtls", "tag": "shadowtls-out", "server": "stls.example.com", "server_port": 443, "version": 3, "password": "pw", "tls": {"enabled": true, "server_name": "www.bing.com"}},
+    {"type": "direct", "tag": "direct"}
+  ],
+  "endpoints": [
+    {"type": "wireguard", "tag": "WG", "address": ["10.0.0.2/32"], "private_key": "cHJpdmF0ZWtleXByaXZhdGVrZXlwcml2YXRla2V5MDA=",
+     "peers": [{"address": "wg.example.com", "port": 51820, "public_key": "cHVibGlja2V5cHVibGlja2V5cHVibGlja2V5cHViMDA=", "reserved": [1, 2, 3]}]}
+  ]
+}`
+
+func TestParseSingboxJSON(t *testing.T) {
+	protocols, report, err := NewDecoder().parseProtocols(singboxSubscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(protocols) != 7 || report.Parsed != 7 {
+		t.Fatalf("parsed %d nodes (report %d), want 7", len(protocols), report.Parsed)
+	}
+	skipped := report.Skipped
+	if len(skipped) != 2 || skipped[0].Line != 26 || skipped[0].Scheme != "shadowsocks" || skipped[0].Unsupported != "obfs plugin" ||
+		skipped[1].Line != 27 || skipped[1].Unsupported != "shadow-tls" {
+		t.Errorf("skipped = %+v, want the obfs node on line 26 and the shadow-tls node on line 27, as unsupported", skipped)
+	}
+
+	want := []struct {
+		typ   models.ProtocolType
+		extra map[string]string
+	}{
+		{models.ProtocolShadowsocks, map[string]string{"method": "aes-256-gcm"}},
+		{models.ProtocolVLESS, map[string]string{"security": "reality", "pbk": "pbk123", "sid": "ab12", "fp": "chrome", "flow": "xtls-rprx-vision"}},
+		{models.ProtocolVMess, map[string]string{"path": "/ws", "host": "cdn.example.com", "aid": "0"}},
+		{models.ProtocolTrojan, map[string]string{"serviceName": "svc", "insecure": "1"}},
+		{models.ProtocolHysteria2, map[string]string{"obfs": "salamander", "insecure": "1"}},
+		{models.ProtocolTUIC, map[string]string{"alpn": "h3", "congestion_control": "bbr"}},
+		{models.ProtocolWireGuard, map[string]string{"address": "10.0.0.2/32", "reserved": "1,2,3", "publickey": "cHVibGlja2V5cHVibGlja2V5cHVibGlja2V5cHViMDA="}},
+	}
+	for i, w := range want {
+		p := protocols[i]
+		if p.Type != w.typ {
+			t.Errorf("%s: type %s, want %s", p.Name, p.Type, w.typ)
+		}
+		for key, value := range w.extra {
+			if p.Extra[key] != value {
+				t.Errorf("%s: extra %s = %v, want %s", p.Name, key, p.Extra[key], value)
+			}
+		}
+		if len(p.Warnings) != 0 {
+			t.Errorf("%s: warnings %v", p.Name, p.Warnings)
+		}
+
+		// The share link stands in for the outbound and parses the same
+		reparsed, err := NewDecoder().ParseProtocol(p.Raw)
+		if err != nil {
+			t.Errorf("%s: share link %q: %v", p.Name, p.Raw, err)
+			continue
+		}
+		if a, _ := Canonical(reparsed); a != p.Raw {
+			t.Errorf("%s: share link %q reparses as %q", p.Name, p.Raw, a)
+		}
+	}
+	if protocols[0].AdvertisedCountry != "DE" {
+		t.Errorf("country = %q, want DE", protocols[0].AdvertisedCountry)
+	}
+	if wg := protocols[6]; wg.Server != "wg.example.com" || wg.Port != 51820 {
+		t.Errorf("wireguard server %s:%d, want the peer's", wg.Server, wg.Port)
+	}
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// defaultPreFetchTimeout bounds a pre-fetch hook without a configured timeout
+const defaultPreFetchTimeout = 30 * time.Second
+
+// sourceData is passed to subscription URL templates
+type sourceData struct {
+	Token string
+	Now   time.Time
+}
+
+var sourceFuncs = template.FuncMap{
+	"env": os.Getenv,
+}
+
+// ResolveSource runs the pre-fetch hook of a subscription source, if any,
+// and expands its URL template into the URL to fetch
+func ResolveSource(ctx context.Context, source models.SubscriptionSource, now time.Time) (string, error) {
+	data := sourceData{Now: now}
+
+	if source.PreFetch != "" {
+		token, err := runPreFetch(ctx, source)
+		if err != nil {
+			return "", err
+		}
+		data.Token = token
+	}
+
+	tmpl, err := template.New(source.Name).Funcs(sourceFuncs).Option("missingkey=error").Parse(source.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid subscription URL template: %w", err)
+	}
+
+	var url strings.Builder
+	if err := tmpl.Execute(&url, data); err != nil {
+		return "", fmt.Errorf("failed to expand subscription URL: %w", err)
+	}
+
+	return strings.TrimSpace(url.String()), nil
+}
+
+// runPreFetch runs the hook through the system shell and returns its
+// trimmed standard output
+func runPreFetch(ctx context.Context, source models.SubscriptionSource) (string, error) {
+	timeout := source.PreFetchTimeout
+	if timeout <= 0 {
+		timeout = defaultPreFetchTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", source.PreFetch)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", source.PreFetch)
+	}
+	cmd.Env = append(os.Environ(), "PROTOSCOPE_SUBSCRIPTION="+source.Name)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("pre-fetch hook failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("pre-fetch hook failed: %w", err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestResolveSource(t *testing.T) {
+	now := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+	t.Setenv("PROTOSCOPE_TEST_KEY", "k1")
+
+	url, err := ResolveSource(context.Background(), models.SubscriptionSource{
+		Name: "dated",
+		URL:  `https://example.com/sub/{{.Now.Format "20060102"}}?key={{env "PROTOSCOPE_TEST_KEY"}}`,
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://example.com/sub/20260307?key=k1" {
+		t.Errorf("url = %s", url)
+	}
+
+	if _, err := ResolveSource(context.Background(), models.SubscriptionSource{URL: "https://example.com/{{.Missing}}"}, now); err == nil {
+		t.Error("expected an error for an unknown template field")
+	}
+}
+
+func TestResolveSourcePreFetch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook uses a POSIX shell command")
+	}
+	now := time.Now()
+
+	url, err := ResolveSource(context.Background(), models.SubscriptionSource{
+		Name:     "rotating",
+		URL:      "https://example.com/sub?token={{.Token}}",
+		PreFetch: `echo "tok-$PROTOSCOPE_SUBSCRIPTION"`,
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://example.com/sub?token=tok-rotating" {
+		t.Errorf("url = %s", url)
+	}
+
+	_, err = ResolveSource(context.Background(), models.SubscriptionSource{
+		URL:      "https://example.com/sub?token={{.Token}}",
+		PreFetch: "echo expired >&2; exit 3",
+	}, now)
+	if err == nil {
+		t.Fatal("expected failing hook to abort the fetch")
+	}
+}
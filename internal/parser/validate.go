@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// uuidPattern matches a UUID with or without hyphens
+var uuidPattern = regexp.MustCompile(`^(?i)[0-9a-f]{8}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{12}$`)
+
+// shadowsocksKeySizes lists the methods the backends accept. The 2022
+// methods take base64 keys of a fixed size; the others take any password,
+// marked with 0.
+var shadowsocksKeySizes = map[string]int{
+	"2022-blake3-aes-128-gcm":       16,
+	"2022-blake3-aes-256-gcm":       32,
+	"2022-blake3-chacha20-poly1305": 32,
+	"aes-128-gcm":                   0,
+	"aes-192-gcm":                   0,
+	"aes-256-gcm":                   0,
+	"chacha20-ietf-poly1305":        0,
+	"chacha20-poly1305":             0,
+	"xchacha20-ietf-poly1305":       0,
+	"xchacha20-poly1305":            0,
+	"aes-128-ctr":                   0,
+	"aes-192-ctr":                   0,
+	"aes-256-ctr":                   0,
+	"aes-128-cfb":                   0,
+	"aes-192-cfb":                   0,
+	"aes-256-cfb":                   0,
+	"chacha20-ietf":                 0,
+	"xchacha20":                     0,
+	"rc4-md5":                       0,
+	"none":                          0,
+	"plain":                         0,
+}
+
+// Validate checks the credentials and port of a parsed node and returns the
+// problems that would make the backend or the server reject it. Parsers only
+// reject links they cannot read; a link that reads fine but can never
+// connect is kept and carries these as Warnings.
+func Validate(p *models.Protocol) []string {
+	var warnings []string
+	if p.Port < 1 || p.Port > 65535 {
+		warnings = append(warnings, fmt.Sprintf("port %d out of range", p.Port))
+	}
+
+	switch p.Type {
+	case models.ProtocolVMess, models.ProtocolVLESS:
+		// Xray maps ids of up to 30 bytes that are not UUIDs onto one
+		if !uuidPattern.MatchString(p.UUID) && (p.UUID == "" || len(p.UUID) > 30) {
+			warnings = append(warnings, fmt.Sprintf("invalid uuid %q", truncate(p.UUID, 40)))
+		}
+
+	case models.ProtocolTUIC:
+		if !uuidPattern.MatchString(p.UUID) {
+			warnings = append(warnings, fmt.Sprintf("invalid uuid %q", truncate(p.UUID, 40)))
+		}
+
+	case models.ProtocolWireGuard:
+		keys := []struct{ name, value string }{
+			{"private key", p.Password},
+			{"peer public key", extraString(p.Extra["publickey"])},
+		}
+		if psk := extraString(p.Extra["presharedkey"]); psk != "" {
+			keys = append(keys, struct{ name, value string }{"pre-shared key", psk})
+		}
+		for _, key := range keys {
+			if decoded, err := base64.StdEncoding.DecodeString(key.value); err != nil || len(decoded) != 32 {
+				warnings = append(warnings, fmt.Sprintf("wireguard %s must be 32 bytes of base64", key.name))
+			}
+		}
+		if extraString(p.Extra["address"]) == "" {
+			warnings = append(warnings, "missing wireguard local address")
+		}
+
+	case models.ProtocolSSH:
+		privateKey := extraString(p.Extra["privatekey"])
+		if p.Password == "" && privateKey == "" {
+			warnings = append(warnings, "ssh node needs a password or a private key")
+		}
+		if privateKey != "" && !strings.Contains(privateKey, "-----BEGIN ") {
+			warnings = append(warnings, "ssh private key is not in PEM format")
+		}
+
+	case models.ProtocolShadowsocks:
+		method := strings.ToLower(extraString(p.Extra["method"]))
+		keySize, ok := shadowsocksKeySizes[method]
+		switch {
+		case !ok:
+			warnings = append(warnings, fmt.Sprintf("unknown shadowsocks method %q", truncate(method, 40)))
+		case keySize > 0:
+			// Multi-user servers take "server key:user key"
+			for _, key := range strings.Split(p.Password, ":") {
+				if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != keySize {
+					warnings = append(warnings, fmt.Sprintf("%s needs a base64 key of %d bytes", method, keySize))
+					break
+				}
+			}
+		case method != "none" && method != "plain" && p.Password == "":
+			warnings = append(warnings, "missing shadowsocks password")
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	ss := func(method, password string) string {
+		return "ss://" + base64.StdEncoding.EncodeToString([]byte(method+":"+password+"@ss.example.com:8388"))
+	}
+	key16 := base64.StdEncoding.EncodeToString(make([]byte, 16))
+
+	tests := []struct {
+		link    string
+		warning string // empty if the node is valid
+	}{
+		{"vless://b831381d-6324-4d53-ad4f-8cda48b30811@a.example.com:443", ""},
+		{"vless://short-id@a.example.com:443", ""}, // mapped onto a UUID by xray
+		{"vless://" + strings.Repeat("x", 31) + "@a.example.com:443", "invalid uuid"},
+		{"tuic://short-id:pw@a.example.com:443", "invalid uuid"},
+		{"tuic://b831381d63244d53ad4f8cda48b30811:pw@a.example.com:443", ""},
+		{ss("aes-256-gcm", "secret"), ""},
+		{ss("aes-256-gcn", "secret"), "unknown shadowsocks method"},
+		{ss("2022-blake3-aes-128-gcm", key16), ""},
+		{ss("2022-blake3-aes-128-gcm", key16+":"+key16), ""},
+		{ss("2022-blake3-aes-256-gcm", key16), "needs a base64 key of 32 bytes"},
+		{ss("chacha20-ietf-poly1305", ""), "missing shadowsocks password"},
+	}
+	for _, tt := range tests {
+		protocol, err := NewDecoder().ParseProtocol(tt.link)
+		if err != nil {
+			t.Errorf("%s: %v", tt.link, err)
+			continue
+		}
+		warnings := strings.Join(protocol.Warnings, "; ")
+		if tt.warning == "" && warnings != "" {
+			t.Errorf("%s: unexpected warnings %q", tt.link, warnings)
+		}
+		if tt.warning != "" && !strings.Contains(warnings, tt.warning) {
+			t.Errorf("%s: warnings %q, want %q", tt.link, warnings, tt.warning)
+		}
+	}
+}
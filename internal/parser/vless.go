@@ -3,7 +3,6 @@ package parser
 import (
 	"fmt"
 	"net/url"
-	"strconv"
 
 	"github.com/VenoMexx/ProtoScope/pkg/models"
 )
@@ -33,7 +32,7 @@ func ParseVLESS(rawURL string) (*models.Protocol, error) {
 	if portStr == "" {
 		portStr = "443" // Default port
 	}
-	port, err := strconv.Atoi(portStr)
+	port, err := parsePort(portStr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid port: %w", err)
 	}
@@ -78,6 +77,7 @@ func ParseVLESS(rawURL string) (*models.Protocol, error) {
 			"path":      query.Get("path"),
 		},
 	}
+	keepUnknownParams(protocol.Extra, query, "type", "sni", "peer")
 
 	return protocol, nil
 }
@@ -4,7 +4,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/VenoMexx/ProtoScope/pkg/models"
@@ -71,8 +70,12 @@ func ParseVMess(url string) (*models.Protocol, error) {
 		return nil, fmt.Errorf("failed to parse vmess config: %w", err)
 	}
 
+	if config.Add == "" {
+		return nil, fmt.Errorf("missing server in vmess config")
+	}
+
 	// Convert port to int
-	port, err := strconv.Atoi(string(config.Port))
+	port, err := parsePort(string(config.Port))
 	if err != nil {
 		return nil, fmt.Errorf("invalid port: %w", err)
 	}
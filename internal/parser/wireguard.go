@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// wireguardParams maps the parameter names clients use in WireGuard links
+// to the ones kept in Extra: v2rayN's, Hiddify's and sing-box's
+var wireguardParams = map[string][]string{
+	"publickey":    {"publickey", "public_key", "peer_pk", "peer_public_key"},
+	"presharedkey": {"presharedkey", "pre_shared_key", "psk"},
+	"address":      {"address", "local_address", "ip"},
+	"reserved":     {"reserved"},
+	"mtu":          {"mtu"},
+}
+
+// ParseWireGuard parses a WireGuard URL
+// Format: wg://privatekey@server:port?publickey=...&address=10.0.0.2/32#name
+// (also wireguard://). The private key may instead be given as the pk or
+// privatekey parameter.
+func ParseWireGuard(rawURL string) (*models.Protocol, error) {
+	// Parse URL
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse wireguard url: %w", err)
+	}
+	query := u.Query()
+
+	// Extract the private key from user info or the query
+	privateKey := u.User.Username()
+	for _, key := range []string{"privatekey", "private_key", "pk"} {
+		if privateKey == "" {
+			privateKey = wireguardKey(query.Get(key))
+		}
+	}
+	if privateKey == "" {
+		return nil, fmt.Errorf("missing private key in wireguard url")
+	}
+
+	// Extract server and port
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("missing host in wireguard url")
+	}
+
+	portStr := u.Port()
+	if portStr == "" {
+		portStr = "51820" // Default port
+	}
+	port, err := parsePort(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port: %w", err)
+	}
+
+	// Extract name from fragment
+	name := u.Fragment
+	if name == "" {
+		name = fmt.Sprintf("%s:%d", host, port)
+	}
+
+	extra := make(map[string]interface{}, len(wireguardParams))
+	consumed := []string{"privatekey", "private_key", "pk"}
+	for field, aliases := range wireguardParams {
+		for _, alias := range aliases {
+			if value := query.Get(alias); value != "" && extra[field] == nil {
+				if strings.HasSuffix(field, "key") {
+					extra[field] = wireguardKey(value)
+				} else {
+					extra[field] = strings.ReplaceAll(value, " ", "")
+				}
+			}
+			consumed = append(consumed, alias)
+		}
+	}
+	if extra["publickey"] == nil {
+		return nil, fmt.Errorf("missing peer public key in wireguard url")
+	}
+
+	protocol := &models.Protocol{
+		Type:     models.ProtocolWireGuard,
+		Name:     name,
+		Server:   host,
+		Port:     port,
+		Password: privateKey,
+		Network:  "udp", // WireGuard runs over UDP
+		Raw:      rawURL,
+		Extra:    extra,
+	}
+	keepUnknownParams(protocol.Extra, query, consumed...)
+
+	return protocol, nil
+}
+
+// wireguardKey restores the + signs of a base64 key left unescaped in a
+// query, which decoding turned into spaces
+func wireguardKey(value string) string {
+	return strings.ReplaceAll(value, " ", "+")
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseWireGuard(t *testing.T) {
+	const (
+		privateKey = "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk="
+		publicKey  = "HIgo9xNzJMWLKASShiTqIybxZ0U3wGLiUeJ1PKf8ykw="
+	)
+	links := []string{
+		// v2rayN
+		"wireguard://" + strings.ReplaceAll(privateKey, "/", "%2F") + "@example.com:51820/?publickey=" + publicKey + "&address=10.0.0.2/32,fd00::2/128&reserved=0,0,0&mtu=1280#WG",
+		// Hiddify, with the + signs of the keys left unescaped
+		"wg://example.com:51820?pk=" + privateKey + "&peer_pk=" + publicKey + "&local_address=10.0.0.2/32,%20fd00::2/128&reserved=0,0,0&mtu=1280#WG",
+	}
+
+	for _, link := range links {
+		protocol, err := NewDecoder().ParseProtocol(link)
+		if err != nil {
+			t.Fatalf("%s: %v", link, err)
+		}
+		if protocol.Password != privateKey || protocol.Extra["publickey"] != publicKey {
+			t.Errorf("%s: keys = %q, %q", link, protocol.Password, protocol.Extra["publickey"])
+		}
+		if protocol.Extra["address"] != "10.0.0.2/32,fd00::2/128" || protocol.Extra["mtu"] != "1280" {
+			t.Errorf("%s: extra = %v", link, protocol.Extra)
+		}
+		if protocol.Server != "example.com" || protocol.Port != 51820 || protocol.Name != "WG" {
+			t.Errorf("%s: parsed %s:%d %q", link, protocol.Server, protocol.Port, protocol.Name)
+		}
+		if len(protocol.Warnings) != 0 {
+			t.Errorf("%s: warnings %v", link, protocol.Warnings)
+		}
+	}
+}
+
+func TestParseWireGuardInvalid(t *testing.T) {
+	if _, err := ParseWireGuard("wg://key@example.com:51820?address=10.0.0.2/32"); err == nil {
+		t.Error("expected an error without a peer public key")
+	}
+
+	protocol, err := ParseWireGuard("wg://short@example.com?publickey=short")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if protocol.Port != 51820 {
+		t.Errorf("default port = %d, want 51820", protocol.Port)
+	}
+	if warnings := Validate(protocol); len(warnings) != 3 {
+		t.Errorf("warnings = %v, want 2 bad keys and a missing address", warnings)
+	}
+}
@@ -0,0 +1,130 @@
+// Package ratelimit throttles requests to shared public test endpoints.
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket limiter safe for concurrent use
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a limiter allowing rate requests per second with the
+// given burst. The bucket starts full.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		wait := l.reserve()
+		if wait == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token if one is available, otherwise it returns how long
+// to wait before trying again
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+
+	missing := 1 - l.tokens
+	return time.Duration(missing / l.rate * float64(time.Second))
+}
+
+// HostLimiter keeps one token bucket per destination host, shared by all
+// workers of a run
+type HostLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    int
+	limiters map[string]*Limiter
+}
+
+// NewHostLimiter creates a per-host limiter
+func NewHostLimiter(rate float64, burst int) *HostLimiter {
+	return &HostLimiter{
+		rate:     rate,
+		burst:    burst,
+		limiters: make(map[string]*Limiter),
+	}
+}
+
+// Wait blocks until a request to host is allowed
+func (h *HostLimiter) Wait(ctx context.Context, host string) error {
+	return h.limiter(host).Wait(ctx)
+}
+
+func (h *HostLimiter) limiter(host string) *Limiter {
+	host = strings.ToLower(host)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	limiter, ok := h.limiters[host]
+	if !ok {
+		limiter = NewLimiter(h.rate, h.burst)
+		h.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// Transport is an http.RoundTripper that waits for the destination host's
+// limiter before each request
+type Transport struct {
+	Base    http.RoundTripper
+	Limiter *HostLimiter
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.Limiter.Wait(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterBurstThenThrottle(t *testing.T) {
+	limiter := NewLimiter(20, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+
+	// Two tokens come from the burst, the third needs ~50ms at 20/s
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected third request to be throttled, took %v", elapsed)
+	}
+}
+
+func TestLimiterRespectsContext(t *testing.T) {
+	limiter := NewLimiter(0.001, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("first Wait should use the burst token: %v", err)
+	}
+	if err := limiter.Wait(ctx); err == nil {
+		t.Fatal("expected context error while waiting for a token")
+	}
+}
@@ -0,0 +1,137 @@
+package report
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/stat"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Comparison sets the subscriptions of a run side by side, for choosing
+// between providers
+type Comparison struct {
+	// Regions are the geo access regions tested, one unlock column each
+	Regions       []string
+	Subscriptions []SubscriptionStats
+}
+
+// SubscriptionStats summarizes the nodes of one subscription
+type SubscriptionStats struct {
+	Name    string
+	Total   int
+	Working int
+	// MedianLatency and MedianSpeed are over the working nodes that
+	// measured them; zero when none did
+	MedianLatency time.Duration
+	MedianSpeed   float64
+	// Unlock is the percentage of each region's geo access domains the
+	// working nodes reached
+	Unlock map[string]float64
+
+	latencies []time.Duration
+	speeds    []float64
+	reached   map[string]int
+	tested    map[string]int
+}
+
+// CompareSubscriptions compares the subscriptions the results came from, in
+// the order they first appear. It returns nil unless the results come from
+// at least two; nodes without a subscription are left out.
+func CompareSubscriptions(results []*models.TestResult) *Comparison {
+	comparison := &Comparison{}
+	index := map[string]int{}
+	regions := map[string]bool{}
+
+	for _, result := range results {
+		if result == nil || result.Protocol == nil || result.Protocol.Subscription == "" {
+			continue
+		}
+		name := result.Protocol.Subscription
+		i, ok := index[name]
+		if !ok {
+			i = len(comparison.Subscriptions)
+			index[name] = i
+			comparison.Subscriptions = append(comparison.Subscriptions, SubscriptionStats{
+				Name:    name,
+				reached: map[string]int{},
+				tested:  map[string]int{},
+			})
+		}
+		stats := &comparison.Subscriptions[i]
+		stats.Total++
+		if !result.Success {
+			continue
+		}
+		stats.Working++
+
+		if result.Connectivity != nil {
+			stats.latencies = append(stats.latencies, result.Connectivity.ResponseTime)
+		}
+		if result.Performance != nil && result.Performance.DownloadSpeed > 0 {
+			stats.speeds = append(stats.speeds, result.Performance.DownloadSpeed)
+		}
+		if result.GeoAccess != nil {
+			for region, domains := range result.GeoAccess.Regions {
+				regions[region] = true
+				for _, status := range domains {
+					stats.tested[region]++
+					if status.Accessible {
+						stats.reached[region]++
+					}
+				}
+			}
+		}
+	}
+	if len(comparison.Subscriptions) < 2 {
+		return nil
+	}
+
+	comparison.Regions = slices.Sorted(maps.Keys(regions))
+	for i := range comparison.Subscriptions {
+		stats := &comparison.Subscriptions[i]
+		stats.MedianLatency = stat.Median(stats.latencies)
+		stats.MedianSpeed = stat.Median(stats.speeds)
+		stats.Unlock = make(map[string]float64, len(stats.tested))
+		for region, tested := range stats.tested {
+			stats.Unlock[region] = float64(stats.reached[region]) / float64(tested) * 100
+		}
+	}
+	return comparison
+}
+
+// WorkingPercent is the share of the subscription's nodes that work
+func (s SubscriptionStats) WorkingPercent() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Working) / float64(s.Total) * 100
+}
+
+// LatencyLabel is the median latency, or "-" when none was measured
+func (s SubscriptionStats) LatencyLabel() string {
+	if len(s.latencies) == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dms", s.MedianLatency.Milliseconds())
+}
+
+// SpeedLabel is the median download speed, or "-" when none was measured
+func (s SubscriptionStats) SpeedLabel() string {
+	if len(s.speeds) == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f Mbps", s.MedianSpeed)
+}
+
+// UnlockLabel is the unlock rate of a region, or "-" when the region was
+// not tested through the subscription's nodes
+func (s SubscriptionStats) UnlockLabel(region string) string {
+	unlock, ok := s.Unlock[region]
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", unlock)
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func compareResult(subscription string, success bool, latency time.Duration, speed float64, unlocked bool) *models.TestResult {
+	accessible := 1
+	if unlocked {
+		accessible = 2
+	}
+	return &models.TestResult{
+		Protocol:     &models.Protocol{Name: subscription + "-node", Subscription: subscription},
+		Success:      success,
+		Connectivity: &models.ConnectivityResult{Connected: success, ResponseTime: latency},
+		Performance:  &models.PerformanceResult{DownloadSpeed: speed},
+		GeoAccess: &models.GeoAccessResult{Regions: map[string]map[string]models.AccessStatus{
+			"US": {"netflix.com": {Accessible: unlocked}, "google.com": {Accessible: true}},
+		}, Summary: models.GeoAccessSummary{TotalTested: 2, TotalAccessible: accessible}},
+	}
+}
+
+func TestCompareSubscriptions(t *testing.T) {
+	results := []*models.TestResult{
+		compareResult("b", true, 100*time.Millisecond, 10, true),
+		compareResult("a", true, 200*time.Millisecond, 40, false),
+		compareResult("a", true, 100*time.Millisecond, 20, true),
+		compareResult("a", false, 0, 0, false),
+		compareResult("a", true, 300*time.Millisecond, 30, true),
+	}
+
+	comparison := CompareSubscriptions(results)
+	if comparison == nil || len(comparison.Subscriptions) != 2 {
+		t.Fatalf("comparison = %+v, want two subscriptions", comparison)
+	}
+	if comparison.Subscriptions[0].Name != "b" {
+		t.Errorf("first subscription %q, want b in order of appearance", comparison.Subscriptions[0].Name)
+	}
+
+	a := comparison.Subscriptions[1]
+	if a.Total != 4 || a.Working != 3 || a.WorkingPercent() != 75 {
+		t.Errorf("a: %d/%d working, want 3/4", a.Working, a.Total)
+	}
+	if a.MedianLatency != 200*time.Millisecond || a.SpeedLabel() != "30.0 Mbps" {
+		t.Errorf("a: median %s, %s, want 200ms, 30.0 Mbps", a.MedianLatency, a.SpeedLabel())
+	}
+	// 5 of the 6 domains tested through the working nodes were reached
+	if got := a.UnlockLabel("US"); got != "83%" {
+		t.Errorf("a: unlock US %s, want 83%%", got)
+	}
+	if got := a.UnlockLabel("RU"); got != "-" {
+		t.Errorf("a: unlock of an untested region %s, want -", got)
+	}
+
+	if CompareSubscriptions(results[1:]) != nil {
+		t.Error("comparison of a single subscription, want nil")
+	}
+}
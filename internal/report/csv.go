@@ -0,0 +1,99 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// csvHeader names the columns of the CSV report
+var csvHeader = []string{
+	"name", "type", "server", "port", "connected", "status",
+	"latency_ms", "download_mbps", "upload_mbps", "geo_access_pct",
+	"dns_leak", "score", "error",
+}
+
+// utf8BOM starts the CSV so Excel reads it as UTF-8; node names are full
+// of emoji flags
+const utf8BOM = "\ufeff"
+
+// CSV writes a row per result for spreadsheets. Cells of checks that did
+// not run are left empty rather than zero, so averages skip them.
+func CSV(w io.Writer, results []*models.TestResult) error {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		cw.Write(csvRow(result))
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvRow flattens a result into the csvHeader columns
+func csvRow(result *models.TestResult) []string {
+	p := result.Protocol
+	row := []string{
+		csvText(p.Name), string(p.Type), csvText(p.Server), strconv.Itoa(p.Port),
+		strconv.FormatBool(result.Success), result.Status(),
+		"", "", "", "", "", "", csvText(result.Error),
+	}
+
+	if result.Connectivity != nil && result.Success {
+		row[6] = strconv.FormatInt(result.Connectivity.ResponseTime.Milliseconds(), 10)
+	}
+	if perf := result.Performance; perf != nil {
+		if perf.DownloadSpeed > 0 {
+			row[7] = fmt.Sprintf("%.2f", perf.DownloadSpeed)
+		}
+		if perf.UploadSpeed > 0 {
+			row[8] = fmt.Sprintf("%.2f", perf.UploadSpeed)
+		}
+	}
+	if geo := result.GeoAccess; geo != nil && geo.Summary.TotalTested > 0 {
+		row[9] = fmt.Sprintf("%.1f", geo.Summary.AccessPercentage)
+	}
+	if leak, ok := dnsLeak(result); ok {
+		row[10] = strconv.FormatBool(leak)
+	}
+	if result.Success {
+		row[11] = strconv.Itoa(result.Score())
+	}
+
+	return row
+}
+
+// csvText keeps text from subscriptions, which anyone can write, from
+// being run as a formula by spreadsheets: cells starting with a formula
+// character get a leading quote
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// dnsLeak tells whether the DNS leak test or the privacy check saw a leak,
+// and whether either ran
+func dnsLeak(result *models.TestResult) (leak, tested bool) {
+	if result.DNS != nil && result.DNS.LeakDetection != nil {
+		tested = true
+		leak = result.DNS.LeakDetection.IsLeaking
+	}
+	if result.Privacy != nil {
+		tested = true
+		leak = leak || result.Privacy.DNSLeak
+	}
+	return leak, tested
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestCSV(t *testing.T) {
+	working := compareResult("", true, 120*time.Millisecond, 42.5, true)
+	working.Protocol.Name = "🇩🇪 DE, 1"
+	working.GeoAccess.Summary.AccessPercentage = 100
+	working.Privacy = &models.PrivacyResult{Score: 80, DNSLeak: true}
+	failed := compareResult("", false, 0, 0, false)
+	failed.Performance, failed.GeoAccess = nil, nil
+	failed.Error = "timeout"
+
+	var buf bytes.Buffer
+	if err := CSV(&buf, []*models.TestResult{working, failed}); err != nil {
+		t.Fatal(err)
+	}
+	data, found := strings.CutPrefix(buf.String(), utf8BOM)
+	if !found {
+		t.Error("no byte order mark")
+	}
+	rows, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || len(rows[1]) != len(csvHeader) {
+		t.Fatalf("rows = %q", rows)
+	}
+
+	cells := func(row []string) map[string]string {
+		named := map[string]string{}
+		for i, column := range csvHeader {
+			named[column] = row[i]
+		}
+		return named
+	}
+	wants := []map[string]string{{
+		"name": "🇩🇪 DE, 1", "connected": "true", "latency_ms": "120",
+		"download_mbps": "42.50", "upload_mbps": "", "geo_access_pct": "100.0",
+		"dns_leak": "true", "score": strconv.Itoa(working.Score()), "error": "",
+	}, {
+		"connected": "false", "status": "failed", "latency_ms": "",
+		"download_mbps": "", "geo_access_pct": "", "dns_leak": "",
+		"score": "", "error": "timeout",
+	}}
+	for i, want := range wants {
+		got := cells(rows[i+1])
+		for column, value := range want {
+			if got[column] != value {
+				t.Errorf("row %d %s = %q, want %q", i+1, column, got[column], value)
+			}
+		}
+	}
+}
+
+// TestCSVFormulas quotes text that spreadsheets would run as a formula
+func TestCSVFormulas(t *testing.T) {
+	tests := map[string]string{
+		`=HYPERLINK("http://evil.example","DE")`: `'=HYPERLINK("http://evil.example","DE")`,
+		"+1":                                     "'+1",
+		"-2+3":                                   "'-2+3",
+		"@SUM(A1)":                               "'@SUM(A1)",
+		"\t=1":                                   "'\t=1",
+		"\r=1":                                   "'\r=1",
+		"🇩🇪 DE 1=2":                              "🇩🇪 DE 1=2",
+		"":                                       "",
+	}
+	for name, want := range tests {
+		result := compareResult("", false, 0, 0, false)
+		result.Protocol.Name = name
+		result.Protocol.Server = name
+		result.Error = name
+		row := csvRow(result)
+		if row[0] != want || row[2] != want || row[12] != want {
+			t.Errorf("%q: name %q, server %q, error %q, want %q", name, row[0], row[2], row[12], want)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/stat"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// htmlRow is one node in the HTML report table
+type htmlRow struct {
+	Index   int
+	Name    string
+	Type    models.ProtocolType
+	Server  string
+	Status  string
+	Class   string
+	Latency string
+	Speed   string
+	Geo     string
+	Score   string
+	Notes   string
+
+	// Sort keys of the columns with units; -1 when not measured
+	LatencyMs int64
+	SpeedMbps float64
+	GeoPct    float64
+	ScoreNum  int
+}
+
+// htmlTable is the table of the nodes of one protocol
+type htmlTable struct {
+	Type    models.ProtocolType
+	Working int
+	Rows    []htmlRow
+}
+
+// htmlCard is a headline number at the top of the report
+type htmlCard struct {
+	Label string
+	Value string
+	Class string
+}
+
+// Chart geometry, in SVG units
+const (
+	chartHeight    = 170
+	chartBarArea   = 120 // height of the tallest bar
+	chartBarTop    = 20
+	chartBarWidth  = 44
+	chartBarStride = 58
+	chartBarLeft   = 12
+)
+
+// htmlChart is a histogram drawn as inline SVG
+type htmlChart struct {
+	Title  string
+	Unit   string
+	Bars   []htmlBar
+	Width  int
+	Height int
+	// LabelY is the baseline of the bucket labels under the bars
+	LabelY int
+}
+
+// htmlBar is one bucket of a histogram, laid out for the SVG
+type htmlBar struct {
+	Label  string
+	Count  int
+	X      int
+	Y      int
+	Width  int
+	Height int
+	LabelX int
+	CountY int
+}
+
+// Histogram buckets: a value falls in the first bucket whose bound exceeds
+// it; the last bucket is open-ended
+var (
+	latencyBuckets = []float64{100, 200, 300, 500, 1000}
+	speedBuckets   = []float64{5, 10, 25, 50, 100}
+)
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>ProtoScope Test Results</title>
+<style>
+body { font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; margin: 24px; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 16px; }
+th, td { border: 1px solid #ddd; padding: 6px 10px; text-align: left; font-size: 14px; }
+th { background: #f4f4f4; }
+table.sortable th { cursor: pointer; user-select: none; }
+table.sortable th[aria-sort=ascending]::after { content: " ▲"; }
+table.sortable th[aria-sort=descending]::after { content: " ▼"; }
+.cards { display: flex; flex-wrap: wrap; gap: 12px; margin-bottom: 8px; }
+.card { border: 1px solid #ddd; border-radius: 8px; padding: 12px 16px; min-width: 120px; }
+.card .value { font-size: 26px; font-weight: 600; }
+.card .label { color: #57606a; font-size: 13px; }
+.badge { display: inline-block; border-radius: 10px; padding: 1px 8px; font-size: 12px; font-weight: 600; white-space: nowrap; }
+.badge.working { background: #dafbe1; color: #1a7f37; }
+.badge.partial { background: #fff8c5; color: #9a6700; }
+.badge.failed { background: #ffebe9; color: #cf222e; }
+.working { color: #1a7f37; }
+.partial { color: #9a6700; }
+.failed { color: #cf222e; }
+.charts { display: flex; flex-wrap: wrap; gap: 24px; }
+.chart rect { fill: #54aeff; }
+.chart text { font-size: 11px; fill: #57606a; text-anchor: middle; }
+</style>
+</head>
+<body>
+<h1>ProtoScope Test Results</h1>
+<p><strong>Generated</strong>: {{.Generated}}</p>
+<h2>Summary</h2>
+<div class="cards">
+{{range .Cards}}<div class="card"><div class="value {{.Class}}">{{.Value}}</div><div class="label">{{.Label}}</div></div>
+{{end}}</div>
+<ul>
+{{if .Summary.Partial}}<li><strong>Partial</strong>: {{.Summary.Partial}} (working, some checks hit the deadline)</li>
+{{end}}{{if .Summary.Mismatched}}<li><strong>Country Mismatch</strong>: {{.Summary.Mismatched}} (exit country differs from the name)</li>
+{{end}}{{with .Summary.Metered}}<li><strong>Metered</strong>: {{.Label}}</li>
+{{end}}</ul>
+{{if .Charts}}<div class="charts">
+{{range $chart := .Charts}}<div class="chart">
+<h3>{{.Title}} ({{.Unit}})</h3>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" role="img" aria-label="{{.Title}}">
+{{range .Bars}}<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}"></rect>
+<text x="{{.LabelX}}" y="{{.CountY}}">{{.Count}}</text>
+<text x="{{.LabelX}}" y="{{$chart.LabelY}}">{{.Label}}</text>
+{{end}}</svg>
+</div>
+{{end}}</div>
+{{end}}{{with .Summary.Comparison}}<h2>Subscription Comparison</h2>
+<table>
+<tr><th>Subscription</th><th>Nodes</th><th>Working</th><th>Median Latency</th><th>Median Speed</th>{{range .Regions}}<th>Unlock {{.}}</th>{{end}}</tr>
+{{range $stats := .Subscriptions}}<tr><td>{{.Name}}</td><td>{{.Total}}</td><td>{{.Working}} ({{printf "%.1f" .WorkingPercent}}%)</td><td>{{.LatencyLabel}}</td><td>{{.SpeedLabel}}</td>{{range $.Summary.Comparison.Regions}}<td>{{$stats.UnlockLabel .}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}<h2>Detailed Results</h2>
+{{range .Tables}}<h3>{{.Type}} ({{.Working}}/{{len .Rows}} working)</h3>
+<table class="sortable">
+<thead><tr><th>#</th><th>Name</th><th>Server</th><th>Status</th><th>Latency</th><th>Download</th><th>Geo Access</th><th>Security</th><th>Notes</th></tr></thead>
+<tbody>
+{{range .Rows}}<tr><td>{{.Index}}</td><td>{{.Name}}</td><td>{{.Server}}</td><td data-sort="{{.Class}}"><span class="badge {{.Class}}">{{.Status}}</span></td><td data-sort="{{.LatencyMs}}">{{.Latency}}</td><td data-sort="{{.SpeedMbps}}">{{.Speed}}</td><td data-sort="{{.GeoPct}}">{{.Geo}}</td><td data-sort="{{.ScoreNum}}">{{.Score}}</td><td>{{.Notes}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}{{with .Info}}<h2>Run Information</h2>
+<ul>
+<li><strong>Version</strong>: ProtoScope {{.Version}}</li>
+<li><strong>Command Line</strong>: <code>protoscope {{$.Args}}</code></li>
+<li><strong>Config Hash</strong>: <code>{{.ConfigHash}}</code></li>
+<li><strong>Backend</strong>: {{$.Backend}}</li>
+<li><strong>Platform</strong>: {{.OS}}/{{.Arch}}</li>
+{{if .VantageCountry}}<li><strong>Vantage Country</strong>: {{.VantageCountry}}</li>
+{{end}}</ul>
+{{end}}<script>
+// Clicking a header sorts by its column: numerically by data-sort where
+// given, with unmeasured (-1) values last, and by text otherwise
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  table.querySelectorAll("th").forEach(function (th, col) {
+    th.addEventListener("click", function () {
+      var asc = th.getAttribute("aria-sort") !== "ascending";
+      table.querySelectorAll("th").forEach(function (h) { h.removeAttribute("aria-sort"); });
+      th.setAttribute("aria-sort", asc ? "ascending" : "descending");
+      var body = table.tBodies[0];
+      var key = function (row) {
+        var cell = row.cells[col];
+        var sort = cell.getAttribute("data-sort");
+        if (sort === null) {
+          var n = parseFloat(cell.textContent);
+          return isNaN(n) ? cell.textContent.toLowerCase() : n;
+        }
+        var v = parseFloat(sort);
+        return isNaN(v) ? sort : v;
+      };
+      Array.from(body.rows).sort(function (a, b) {
+        var x = key(a), y = key(b);
+        if (x === -1 || y === -1) return x === y ? 0 : (x === -1 ? 1 : -1);
+        if (x < y) return asc ? -1 : 1;
+        if (x > y) return asc ? 1 : -1;
+        return 0;
+      }).forEach(function (row) { body.appendChild(row); });
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// HTML writes a standalone HTML report of the results: summary cards,
+// latency and speed histograms, and a sortable table per protocol. It
+// loads nothing from the network. info adds a section on how the run was
+// made and may be nil.
+func HTML(w io.Writer, results []*models.TestResult, info *models.RunInfo) error {
+	summary := Summarize(results)
+
+	data := struct {
+		Generated string
+		Summary   Summary
+		Cards     []htmlCard
+		Charts    []htmlChart
+		Tables    []*htmlTable
+		Info      *models.RunInfo
+		Backend   string
+		Args      string
+	}{
+		Generated: time.Now().Format(time.RFC1123),
+		Summary:   summary,
+		Info:      info,
+	}
+	if info != nil {
+		data.Backend = BackendLabel(info)
+		data.Args = strings.Join(info.Args, " ")
+	}
+
+	data.Cards = []htmlCard{
+		{Label: "Total Protocols", Value: fmt.Sprint(summary.Total)},
+		{Label: "Working", Value: fmt.Sprint(summary.Working), Class: "working"},
+		{Label: "Failed", Value: fmt.Sprint(summary.Failed), Class: "failed"},
+	}
+	if summary.Total > 0 {
+		data.Cards[1].Value += fmt.Sprintf(" (%.0f%%)", summary.percent(summary.Working))
+	}
+	if summary.latencies > 0 {
+		data.Cards = append(data.Cards, htmlCard{Label: "Average Latency", Value: fmt.Sprintf("%dms", summary.AvgLatency.Milliseconds())})
+	}
+
+	var latencies, speeds []float64
+	tables := map[models.ProtocolType]*htmlTable{}
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		row := newHTMLRow(i+1, result)
+		table := tables[row.Type]
+		if table == nil {
+			table = &htmlTable{Type: row.Type}
+			tables[row.Type] = table
+			data.Tables = append(data.Tables, table)
+		}
+		table.Rows = append(table.Rows, row)
+		if result.Success {
+			table.Working++
+		}
+
+		if row.LatencyMs >= 0 {
+			latencies = append(latencies, float64(row.LatencyMs))
+		}
+		if row.SpeedMbps > 0 {
+			speeds = append(speeds, row.SpeedMbps)
+		}
+	}
+
+	if len(speeds) > 0 {
+		data.Cards = append(data.Cards, htmlCard{Label: "Median Speed", Value: fmt.Sprintf("%.1f Mbps", stat.Median(speeds))})
+	}
+	if len(latencies) > 0 {
+		data.Charts = append(data.Charts, newHTMLChart("Latency Distribution", "ms", latencies, latencyBuckets))
+	}
+	if len(speeds) > 0 {
+		data.Charts = append(data.Charts, newHTMLChart("Download Speed Distribution", "Mbps", speeds, speedBuckets))
+	}
+
+	return htmlTemplate.Execute(w, data)
+}
+
+// newHTMLRow flattens a result into a table row
+func newHTMLRow(index int, result *models.TestResult) htmlRow {
+	row := htmlRow{
+		Index:     index,
+		Name:      result.Protocol.Name,
+		Type:      result.Protocol.Type,
+		Server:    fmt.Sprintf("%s:%d", result.Protocol.Server, result.Protocol.Port),
+		Status:    statusLabel(result),
+		Class:     result.Status(),
+		LatencyMs: -1,
+		SpeedMbps: -1,
+		GeoPct:    -1,
+		ScoreNum:  -1,
+	}
+
+	if !result.Success {
+		row.Notes = result.Error
+		return row
+	}
+
+	if result.Connectivity != nil {
+		row.LatencyMs = result.Connectivity.ResponseTime.Milliseconds()
+		row.Latency = fmt.Sprintf("%dms", row.LatencyMs)
+	}
+	if result.Performance != nil {
+		row.SpeedMbps = result.Performance.DownloadSpeed
+		row.Speed = fmt.Sprintf("%.1f Mbps", result.Performance.DownloadSpeed)
+		if upload := result.Performance.UploadSpeed; upload > 0 {
+			row.Speed = fmt.Sprintf("↓%.1f / ↑%.1f Mbps", result.Performance.DownloadSpeed, upload)
+		}
+		if metered := result.Performance.Metered; metered != nil {
+			row.Speed += " (" + MeteredLabel(metered) + ")"
+		}
+	}
+	if result.GeoAccess != nil {
+		row.Geo = fmt.Sprintf("%d/%d", result.GeoAccess.Summary.TotalAccessible, result.GeoAccess.Summary.TotalTested)
+		row.GeoPct = result.GeoAccess.Summary.AccessPercentage
+	}
+	if result.Privacy != nil {
+		row.Score = fmt.Sprintf("%d/100", result.Privacy.Score)
+		row.ScoreNum = result.Privacy.Score
+	}
+	if result.Cached {
+		row.Notes = "unchanged, cached"
+	}
+	for _, skipped := range result.SkippedChecks {
+		if row.Notes != "" {
+			row.Notes += "; "
+		}
+		row.Notes += fmt.Sprintf("skipped %s: %s", skipped.Name, skipped.Reason)
+	}
+
+	return row
+}
+
+// newHTMLChart counts values into the buckets bounded by bounds and lays
+// out their bars
+func newHTMLChart(title, unit string, values, bounds []float64) htmlChart {
+	counts := make([]int, len(bounds)+1)
+	for _, value := range values {
+		i := 0
+		for i < len(bounds) && value >= bounds[i] {
+			i++
+		}
+		counts[i]++
+	}
+	most := 1
+	for _, count := range counts {
+		most = max(most, count)
+	}
+
+	chart := htmlChart{
+		Title:  title,
+		Unit:   unit,
+		Width:  chartBarLeft*2 + chartBarStride*len(counts),
+		Height: chartHeight,
+		LabelY: chartHeight - 10,
+	}
+	for i, count := range counts {
+		label := fmt.Sprintf("%g+", bounds[len(bounds)-1])
+		switch {
+		case i == 0:
+			label = fmt.Sprintf("<%g", bounds[0])
+		case i < len(bounds):
+			label = fmt.Sprintf("%g-%g", bounds[i-1], bounds[i])
+		}
+		height := count * chartBarArea / most
+		x := chartBarLeft + i*chartBarStride
+		chart.Bars = append(chart.Bars, htmlBar{
+			Label:  label,
+			Count:  count,
+			X:      x,
+			Y:      chartBarTop + chartBarArea - height,
+			Width:  chartBarWidth,
+			Height: height,
+			LabelX: x + chartBarWidth/2,
+			CountY: chartBarTop + chartBarArea - height - 4,
+		})
+	}
+	return chart
+}
@@ -0,0 +1,55 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestHTML(t *testing.T) {
+	results := []*models.TestResult{
+		compareResult("", true, 80*time.Millisecond, 30, true),
+		compareResult("", true, 250*time.Millisecond, 120, true),
+		compareResult("", false, 0, 0, false),
+	}
+	results[0].Protocol.Type = models.ProtocolVLESS
+	results[1].Protocol.Type = models.ProtocolTrojan
+	results[2].Protocol.Type = models.ProtocolVLESS
+
+	var buf bytes.Buffer
+	if err := HTML(&buf, results, nil); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+
+	for _, want := range []string{
+		"<h3>vless (1/2 working)</h3>",
+		"<h3>trojan (1/1 working)</h3>",
+		`<span class="badge failed">`,
+		`data-sort="250"`,
+		"Latency Distribution (ms)",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+	if strings.Contains(page, "http://") || strings.Contains(page, "https://") {
+		t.Error("report loads resources from the network")
+	}
+}
+
+func TestHTMLChart(t *testing.T) {
+	chart := newHTMLChart("Latency", "ms", []float64{50, 99, 100, 5000}, latencyBuckets)
+	counts := []int{2, 1, 0, 0, 0, 1}
+	for i, bar := range chart.Bars {
+		if bar.Count != counts[i] {
+			t.Errorf("bucket %s counted %d, want %d", bar.Label, bar.Count, counts[i])
+		}
+	}
+	if first, last := chart.Bars[0], chart.Bars[5]; first.Height != chartBarArea || first.Label != "<100" || last.Label != "1000+" {
+		t.Errorf("bars = %+v", chart.Bars)
+	}
+}
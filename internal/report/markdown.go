@@ -0,0 +1,246 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/i18n"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Markdown writes a markdown report of the results; info adds a section
+// on how the run was made and may be nil
+func Markdown(w io.Writer, results []*models.TestResult, info *models.RunInfo) {
+	fmt.Fprintf(w, "# %s\n", i18n.T("ProtoScope Test Results"))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "**%s**: %s\n\n", i18n.T("Generated"), time.Now().Format(time.RFC1123))
+	fmt.Fprintf(w, "**%s**: %d\n\n", i18n.T("Total Protocols"), len(results))
+
+	fmt.Fprintf(w, "## %s\n", i18n.T("Summary"))
+	fmt.Fprintln(w)
+
+	summary := Summarize(results)
+
+	fmt.Fprintf(w, "- **%s**: %d (%.1f%%)\n", i18n.T("Working"), summary.Working, summary.percent(summary.Working))
+	if summary.Partial > 0 {
+		fmt.Fprintf(w, "- **%s**: %d (%s)\n", i18n.T("Partial"), summary.Partial, i18n.T("working, some checks hit the deadline"))
+	}
+	fmt.Fprintf(w, "- **%s**: %d (%.1f%%)\n", i18n.T("Failed"), summary.Failed, summary.percent(summary.Failed))
+	if summary.latencies > 0 {
+		fmt.Fprintf(w, "- **%s**: %dms\n", i18n.T("Average Latency"), summary.AvgLatency.Milliseconds())
+	}
+	if summary.Mismatched > 0 {
+		fmt.Fprintf(w, "- **%s**: %d\n", i18n.T("Country Mismatch"), summary.Mismatched)
+	}
+	if summary.Metered != nil {
+		fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Metered"), summary.Metered.Label())
+	}
+	fmt.Fprintln(w)
+
+	if len(summary.Countries) > 0 {
+		fmt.Fprintf(w, "## %s\n\n", i18n.T("By Advertised Country"))
+		fmt.Fprintf(w, "| %s | %s | %s |\n", i18n.T("Country"), i18n.T("Nodes"), i18n.T("Working"))
+		fmt.Fprintln(w, "|---|---|---|")
+		for _, count := range summary.Countries {
+			fmt.Fprintf(w, "| %s | %d | %d |\n", count.Country, count.Total, count.Working)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if comparison := summary.Comparison; comparison != nil {
+		fmt.Fprintf(w, "## %s\n\n", i18n.T("Subscription Comparison"))
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s |", i18n.T("Subscription"), i18n.T("Nodes"), i18n.T("Working"), i18n.T("Median Latency"), i18n.T("Median Speed"))
+		for _, region := range comparison.Regions {
+			fmt.Fprintf(w, " %s |", fmt.Sprintf(i18n.T("Unlock %s"), region))
+		}
+		fmt.Fprintf(w, "\n|---|---|---|---|---|%s\n", strings.Repeat("---|", len(comparison.Regions)))
+		for _, stats := range comparison.Subscriptions {
+			fmt.Fprintf(w, "| %s | %d | %d (%.1f%%) | %s | %s |", stats.Name, stats.Total, stats.Working, stats.WorkingPercent(), stats.LatencyLabel(), stats.SpeedLabel())
+			for _, region := range comparison.Regions {
+				fmt.Fprintf(w, " %s |", stats.UnlockLabel(region))
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "## %s\n", i18n.T("Detailed Results"))
+	fmt.Fprintln(w)
+
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+
+		fmt.Fprintf(w, "### %d. %s - %s\n", i+1, result.Protocol.Name, statusLabel(result))
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Type"), result.Protocol.Type)
+		fmt.Fprintf(w, "- **%s**: %s:%d\n", i18n.T("Server"), result.Protocol.Server, result.Protocol.Port)
+		if result.Note != nil {
+			fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Note"), noteText(result.Note))
+		}
+		if result.Cached {
+			fmt.Fprintf(w, "- **%s**: %s (%s)\n", i18n.T("Tested"), result.Timestamp.Format(time.RFC1123), i18n.T("unchanged, cached"))
+		}
+
+		if result.Success {
+			if result.Connectivity != nil {
+				fmt.Fprintf(w, "- **%s**: %dms\n", i18n.T("Response Time"), result.Connectivity.ResponseTime.Milliseconds())
+			}
+
+			if result.Ping != nil && result.Ping.Received > 0 {
+				fmt.Fprintf(w, "- **"+i18n.T("Ping (%s)")+"**: "+i18n.T("%dms, %.0f%% loss")+"\n", result.Ping.Method, result.Ping.AvgRTT.Milliseconds(), result.Ping.PacketLoss)
+			}
+
+			if result.Trace != nil && result.Trace.Reached {
+				fmt.Fprintf(w, "- **%s**: "+i18n.T("%d hops, worst hop #%d %dms")+"\n", i18n.T("Trace"), result.Trace.HopCount, result.Trace.WorstHop, result.Trace.WorstHopRTT.Milliseconds())
+			}
+
+			if result.MTU != nil && result.MTU.PathMTU > 0 {
+				issue := ""
+				if result.MTU.FragmentationIssue {
+					issue = " ⚠ " + i18n.T("fragmentation issue")
+				}
+				fmt.Fprintf(w, "- **%s**: %d%s\n", i18n.T("Path MTU"), result.MTU.PathMTU, issue)
+			}
+
+			for _, ipResult := range result.IPResults {
+				if ipResult.Connected {
+					fmt.Fprintf(w, "- **IP %s**: ✓ %dms\n", ipResult.IP, ipResult.ResponseTime.Milliseconds())
+				} else {
+					fmt.Fprintf(w, "- **IP %s**: ✗ %s\n", ipResult.IP, ipResult.Error)
+				}
+			}
+
+			if result.Performance != nil {
+				if metered := result.Performance.Metered; metered != nil {
+					fmt.Fprintf(w, "- **%s**: %.1f Mbps (%s)\n", i18n.T("Download Speed"), result.Performance.DownloadSpeed, MeteredLabel(metered))
+				} else {
+					fmt.Fprintf(w, "- **%s**: %.1f Mbps\n", i18n.T("Download Speed"), result.Performance.DownloadSpeed)
+				}
+				if result.Performance.UploadSpeed > 0 {
+					fmt.Fprintf(w, "- **%s**: %.1f Mbps\n", i18n.T("Upload Speed"), result.Performance.UploadSpeed)
+				}
+				if traffic := result.Performance.BackendTraffic; traffic != nil {
+					fmt.Fprintf(w, "- **%s**: %.1f Mbps\n", i18n.T("Backend Counted Speed"), traffic.DownloadSpeed)
+				}
+				if result.Performance.SpeedTestServer != "" {
+					fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Speed Test Server"), SpeedTestServerLabel(result.Performance))
+				}
+				fmt.Fprintf(w, "- **%s**: %dms\n", i18n.T("Latency"), result.Performance.Latency.Milliseconds())
+			}
+
+			if result.GeoAccess != nil {
+				fmt.Fprintf(w, "- **%s**: %d/%d (%.0f%%)\n", i18n.T("Geo Access"),
+					result.GeoAccess.Summary.TotalAccessible,
+					result.GeoAccess.Summary.TotalTested,
+					result.GeoAccess.Summary.AccessPercentage)
+			}
+
+			if result.Privacy != nil {
+				fmt.Fprintf(w, "- **%s**: %d/100\n", i18n.T("Security Score"), result.Privacy.Score)
+			}
+
+			if result.DNS != nil && result.DNS.DNSSEC != nil {
+				fmt.Fprintf(w, "- **DNSSEC**: %s\n", DNSSECLabel(result.DNS.DNSSEC.Status))
+			}
+
+			if result.Privacy != nil && result.Privacy.CountryMismatch {
+				fmt.Fprintf(w, "- **%s**: ⚠ "+i18n.T("advertised as %s, exits in %s")+"\n", i18n.T("Country Mismatch"), result.Protocol.AdvertisedCountry, result.Privacy.ExitCountry)
+			}
+
+			if result.Privacy != nil && result.Privacy.Hosting != nil {
+				fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Hosting"), result.Privacy.Hosting)
+			}
+
+			if result.Privacy != nil && result.Privacy.ProxyDetection != nil {
+				detection := result.Privacy.ProxyDetection
+				verdict := i18n.T("not listed")
+				if detection.LikelyFlagged {
+					verdict = fmt.Sprintf("%s (%s)", i18n.T("likely flagged"), strings.Join(slices.Concat(detection.Lists, detection.Categories), ", "))
+				}
+				fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Proxy Detection"), verdict)
+			}
+
+			if fingerprint := result.TLSFingerprint; fingerprint != nil && fingerprint.Error == "" {
+				mismatch := ""
+				if fingerprint.Mismatch {
+					mismatch = " ⚠ " + fmt.Sprintf(i18n.T("fp=%s requested, but the backend sent a plain Go TLS hello"), fingerprint.Requested)
+				}
+				fmt.Fprintf(w, "- **%s**: JA3 `%s` (%s)%s\n", i18n.T("TLS Fingerprint"), fingerprint.JA3Hash, fingerprint.Profile, mismatch)
+			}
+
+			if obfuscation := result.Obfuscation; obfuscation != nil && obfuscation.Error == "" {
+				verdict := "✓ " + i18n.T("effective")
+				if !obfuscation.Effective {
+					verdict = "⚠ " + strings.Join(obfuscation.Issues, "; ")
+				}
+				fmt.Fprintf(w, "- **"+i18n.T("Obfuscation (%s)")+"**: %s\n", obfuscation.Method, verdict)
+			}
+
+			if probing := result.ActiveProbing; probing != nil && probing.Error == "" {
+				fmt.Fprintf(w, "- **%s**: %s (%d/100)\n", i18n.T("Active Probing"), i18n.T(probing.Verdict), probing.Score)
+			}
+
+			if result.Ookla != nil {
+				fmt.Fprintf(w, "- **Speedtest.net**: %s\n", OoklaLabel(result.Ookla))
+			}
+
+			if result.EdgeSpeed != nil {
+				fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("CDN Edge"), EdgeLabel(result.EdgeSpeed))
+			}
+
+			for _, name := range PluginNames(result) {
+				fmt.Fprintf(w, "- **%s**: %s\n", name, PluginLabel(result.Plugins[name]))
+			}
+
+			if result.Egress != nil {
+				blocked := i18n.T("none")
+				if len(result.Egress.Blocked) > 0 {
+					blocked = EgressBlocked(result.Egress)
+				}
+				fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Blocked Ports"), blocked)
+			}
+
+			if sensitive := result.SensitiveSites; sensitive != nil {
+				blocked := i18n.T("none")
+				if sensitive.Reachable < len(sensitive.Sites) {
+					blocked = SensitiveBlocked(sensitive)
+				}
+				fmt.Fprintf(w, "- **%s**: %d/%d (%s: %s)\n", i18n.T("Sensitive Sites"),
+					sensitive.Reachable, len(sensitive.Sites), i18n.T("blocked"), blocked)
+			}
+
+			if result.Gaming != nil {
+				fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Gaming"), GamingLabel(result.Gaming))
+			}
+
+			if result.Streaming != nil {
+				fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Streaming"), StreamingLabel(result.Streaming))
+			}
+
+			for _, skipped := range result.SkippedChecks {
+				fmt.Fprintf(w, "- **"+i18n.T("Skipped %s")+"**: %s\n", skipped.Name, skipped.Reason)
+			}
+		} else {
+			fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Error"), result.Error)
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if info != nil {
+		fmt.Fprintf(w, "## %s\n\n", i18n.T("Run Information"))
+		fmt.Fprintf(w, "- **%s**: ProtoScope %s\n", i18n.T("Version"), info.Version)
+		fmt.Fprintf(w, "- **%s**: `protoscope %s`\n", i18n.T("Command Line"), strings.Join(info.Args, " "))
+		fmt.Fprintf(w, "- **%s**: `%s`\n", i18n.T("Config Hash"), info.ConfigHash)
+		fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Backend"), BackendLabel(info))
+		fmt.Fprintf(w, "- **%s**: %s/%s\n", i18n.T("Platform"), info.OS, info.Arch)
+		if info.VantageCountry != "" {
+			fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Vantage Country"), info.VantageCountry)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+// Package report renders test results as markdown or HTML documents
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/i18n"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Summary holds the totals shown at the top of a report
+type Summary struct {
+	Total      int
+	Working    int
+	Partial    int
+	Failed     int
+	AvgLatency time.Duration
+	// Countries groups the nodes by the country their names advertise,
+	// largest group first; nodes without one are left out
+	Countries []CountryCount
+	// Mismatched counts nodes exiting in another country than advertised
+	Mismatched int
+	// Metered summarizes the capped speed tests of a metered run; nil
+	// otherwise
+	Metered *MeteredSummary
+	// Comparison sets the subscriptions side by side when the results come
+	// from several; nil otherwise
+	Comparison *Comparison
+
+	latencies int // working nodes with a connectivity measurement
+}
+
+// MeteredSummary is the data used by a metered run's speed tests and how
+// much their accuracy suffered
+type MeteredSummary struct {
+	Bytes int64
+	// AccuracyLoss is the average estimated shortfall of the measured
+	// speeds, as a fraction
+	AccuracyLoss float64
+	// Skipped counts nodes whose download was skipped because the run's
+	// data cap was used up
+	Skipped int
+
+	measured int
+}
+
+// CountryCount is the number of nodes advertising one country
+type CountryCount struct {
+	Country string
+	Total   int
+	Working int
+}
+
+// Summarize counts working, partial and failed nodes
+func Summarize(results []*models.TestResult) Summary {
+	summary := Summary{Total: len(results)}
+	countries := map[string]*CountryCount{}
+
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		if country := result.Protocol.AdvertisedCountry; country != "" {
+			count := countries[country]
+			if count == nil {
+				count = &CountryCount{Country: country}
+				countries[country] = count
+			}
+			count.Total++
+			if result.Success {
+				count.Working++
+			}
+		}
+		if result.Privacy != nil && result.Privacy.CountryMismatch {
+			summary.Mismatched++
+		}
+		if result.Performance != nil && result.Performance.Metered != nil {
+			summary.addMetered(result.Performance.Metered)
+		}
+		if result.PartialSuccess {
+			summary.Partial++
+		}
+		if result.Success {
+			summary.Working++
+			if result.Connectivity != nil {
+				summary.AvgLatency += result.Connectivity.ResponseTime
+				summary.latencies++
+			}
+		} else {
+			summary.Failed++
+		}
+	}
+
+	if summary.latencies > 0 {
+		summary.AvgLatency = summary.AvgLatency / time.Duration(summary.latencies)
+	}
+	if metered := summary.Metered; metered != nil && metered.measured > 0 {
+		metered.AccuracyLoss /= float64(metered.measured)
+	}
+
+	for _, count := range countries {
+		summary.Countries = append(summary.Countries, *count)
+	}
+	sort.Slice(summary.Countries, func(i, j int) bool {
+		a, b := summary.Countries[i], summary.Countries[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Country < b.Country
+	})
+	summary.Comparison = CompareSubscriptions(results)
+
+	return summary
+}
+
+// addMetered adds one node's capped speed test
+func (s *Summary) addMetered(speed *models.MeteredSpeed) {
+	if s.Metered == nil {
+		s.Metered = &MeteredSummary{}
+	}
+	s.Metered.Bytes += speed.Bytes
+	switch {
+	case speed.CapBytes == 0:
+		s.Metered.Skipped++
+	case speed.Bytes > 0:
+		s.Metered.AccuracyLoss += speed.AccuracyLoss
+		s.Metered.measured++
+	}
+}
+
+// Label describes a metered run's speed test data and accuracy
+func (m *MeteredSummary) Label() string {
+	label := fmt.Sprintf(i18n.T("%.1f MB downloaded, speeds may read ~%.0f%% low"), float64(m.Bytes)/1e6, m.AccuracyLoss*100)
+	if m.Skipped > 0 {
+		label += "; " + fmt.Sprintf(i18n.T("%d speed tests skipped, run data cap reached"), m.Skipped)
+	}
+	return label
+}
+
+// MeteredLabel describes one node's capped speed test
+func MeteredLabel(speed *models.MeteredSpeed) string {
+	if speed.CapBytes == 0 {
+		return i18n.T("skipped, run data cap reached")
+	}
+	return fmt.Sprintf(i18n.T("metered, %.1f MB; may read ~%.0f%% low"), float64(speed.Bytes)/1e6, speed.AccuracyLoss*100)
+}
+
+// SpeedTestServerLabel names the server a speed test ran against: the
+// host of its endpoint, marked when it is a LibreSpeed instance, and the
+// ping to it if measured
+func SpeedTestServerLabel(performance *models.PerformanceResult) string {
+	endpoint := performance.SpeedTestServer
+	kind := ""
+	if rest, ok := strings.CutPrefix(endpoint, "librespeed+"); ok {
+		endpoint, kind = rest, " (LibreSpeed)"
+	}
+	label := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		label = u.Host
+	}
+	label += kind
+	if performance.ServerPing > 0 {
+		label += fmt.Sprintf(", "+i18n.T("ping %dms"), performance.ServerPing.Milliseconds())
+	}
+	return label
+}
+
+// OoklaLabel describes a speedtest.net test and the server it ran against
+func OoklaLabel(ookla *models.OoklaResult) string {
+	server := ookla.Server
+	name := fmt.Sprintf("%s, %s (%s, #%s)", server.Sponsor, server.Name, server.Country, server.ID)
+	return fmt.Sprintf(i18n.T("↓%.1f / ↑%.1f Mbps, ping %dms, server %s"),
+		ookla.DownloadSpeed, ookla.UploadSpeed, ookla.Latency.Milliseconds(), name)
+}
+
+// EdgeLabel compares the downloads from the CDN edges near the exit and
+// near the user
+func EdgeLabel(edge *models.EdgeSpeedResult) string {
+	return fmt.Sprintf(i18n.T("%s near the exit, %s near you"), edgeSpeedLabel(edge.NearExit), edgeSpeedLabel(edge.NearUser))
+}
+
+// edgeSpeedLabel describes one edge download
+func edgeSpeedLabel(speed models.EdgeSpeed) string {
+	label := fmt.Sprintf("↓%.1f Mbps", speed.DownloadSpeed)
+	if speed.Error != "" {
+		label = "✗ " + i18n.T("Failed")
+	}
+	if speed.Edge != "" {
+		label += " (" + speed.Edge + ")"
+	}
+	return label
+}
+
+// PluginNames returns the names of a result's plugins in order
+func PluginNames(result *models.TestResult) []string {
+	names := make([]string, 0, len(result.Plugins))
+	for name := range result.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PluginLabel shows the JSON a plugin printed on one line, or its error
+func PluginLabel(plugin *models.PluginResult) string {
+	if plugin.Error != "" {
+		return "✗ " + plugin.Error
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, plugin.Output); err != nil {
+		return string(plugin.Output)
+	}
+	return compact.String()
+}
+
+// percent returns n as a percentage of all results
+func (s Summary) percent(n int) float64 {
+	return float64(n) / float64(s.Total) * 100
+}
+
+// statusLabel returns the status shown next to a node name
+func statusLabel(result *models.TestResult) string {
+	switch result.Status() {
+	case "working":
+		return "✓ " + i18n.T("Working")
+	case "partial":
+		return "⚠ " + i18n.T("Partial")
+	default:
+		return "✗ " + i18n.T("Failed")
+	}
+}
+
+// DNSSECLabel describes a DNSSEC validation status
+func DNSSECLabel(status string) string {
+	switch status {
+	case models.DNSSECValidating:
+		return "✓ " + i18n.T("validating")
+	case models.DNSSECNotValidating:
+		return "✗ " + i18n.T("not validating")
+	default:
+		return "? " + i18n.T("inconclusive")
+	}
+}
+
+// EgressBlocked lists the blocked ports of an egress probe with their
+// service names, e.g. "25/smtp, 3389/rdp"
+func EgressBlocked(egress *models.EgressResult) string {
+	var blocked []string
+	for _, port := range egress.Ports {
+		if port.Open {
+			continue
+		}
+		label := strconv.Itoa(port.Port)
+		if port.Service != "" {
+			label += "/" + port.Service
+		}
+		blocked = append(blocked, label)
+	}
+	return strings.Join(blocked, ", ")
+}
+
+// SensitiveBlocked lists the sensitive sites the exit could not reach with
+// what turned it away, e.g. "PayPal (captcha), IRS (http 403)"
+func SensitiveBlocked(sensitive *models.SensitiveSitesResult) string {
+	var blocked []string
+	for _, site := range sensitive.Sites {
+		if site.Reachable {
+			continue
+		}
+		reason := site.FraudBlock
+		if reason == "" {
+			reason = i18n.T("unreachable")
+		}
+		blocked = append(blocked, fmt.Sprintf("%s (%s)", site.Name, reason))
+	}
+	return strings.Join(blocked, ", ")
+}
+
+// StreamingLabel lists the services the exit unlocks with the region they
+// detected, then the locked ones with why, e.g. "Netflix US, ChatGPT US;
+// locked: Disney+ (not available in region)"
+func StreamingLabel(streaming *models.StreamingResult) string {
+	var unlocked, locked []string
+	for _, service := range streaming.Services {
+		if service.Unlocked {
+			label := service.Name
+			if service.Region != "" {
+				label += " " + service.Region
+			}
+			unlocked = append(unlocked, label)
+			continue
+		}
+		reason := service.Detail
+		if reason == "" {
+			reason = i18n.T("unreachable")
+		}
+		if service.Region != "" {
+			reason += ", " + service.Region
+		}
+		locked = append(locked, fmt.Sprintf("%s (%s)", service.Name, reason))
+	}
+
+	label := i18n.T("none")
+	if len(unlocked) > 0 {
+		label = strings.Join(unlocked, ", ")
+	}
+	if len(locked) > 0 {
+		label += "; " + i18n.T("locked") + ": " + strings.Join(locked, ", ")
+	}
+	return label
+}
+
+// GamingLabel rates a gaming latency test by its best region, e.g.
+// "good, best 42ms to Riot EU West (jitter 3ms)"
+func GamingLabel(gaming *models.GamingResult) string {
+	best := gaming.Best
+	if best == nil {
+		return i18n.T("unreachable")
+	}
+	name := best.Service
+	if best.Region != "" {
+		name += " " + best.Region
+	}
+	return fmt.Sprintf(i18n.T("%s, best %dms to %s (jitter %dms)"), i18n.T(gaming.Rating),
+		best.Latency.Milliseconds(), name, best.Jitter.Milliseconds())
+}
+
+// BackendLabel describes the backend of a run with the versions found,
+// e.g. "auto (sing-box version 1.10.1)"
+func BackendLabel(info *models.RunInfo) string {
+	if len(info.BackendVersions) == 0 {
+		return info.Backend
+	}
+	binaries := make([]string, 0, len(info.BackendVersions))
+	for binary := range info.BackendVersions {
+		binaries = append(binaries, binary)
+	}
+	sort.Strings(binaries)
+	versions := make([]string, 0, len(binaries))
+	for _, binary := range binaries {
+		versions = append(versions, info.BackendVersions[binary])
+	}
+	return fmt.Sprintf("%s (%s)", info.Backend, strings.Join(versions, "; "))
+}
+
+// noteText renders a node note as "text [label, label]"
+func noteText(note *models.NodeNote) string {
+	text := note.Text
+	if len(note.Labels) > 0 {
+		text = strings.TrimSpace(text + " [" + strings.Join(note.Labels, ", ") + "]")
+	}
+	return text
+}
+
+// Render renders the results in the given format (markdown or html)
+func Render(format string, results []*models.TestResult, info *models.RunInfo) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case "markdown", "md", "":
+		Markdown(&buf, results, info)
+	case "html":
+		if err := HTML(&buf, results, info); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown report format: %s", format)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,290 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/i18n"
+	"github.com/VenoMexx/ProtoScope/internal/stat"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Scorecard grades one provider's subscription by category, for sharing
+// in reviews of subscription sellers
+type Scorecard struct {
+	Provider string
+	Nodes    int
+	Working  int
+	// Score averages the category scores; Grade is its letter
+	Score      int
+	Grade      string
+	Categories []ScorecardCategory
+	Countries  []CountryCount
+	Protocols  []ProtocolCount
+}
+
+// ScorecardCategory is the grade of one aspect of a provider. Categories
+// no node measured are left off the scorecard.
+type ScorecardCategory struct {
+	// Name is the English name, translated when rendered
+	Name   string
+	Score  int
+	Grade  string
+	Detail string
+}
+
+// ProtocolCount is the number of nodes of one protocol
+type ProtocolCount struct {
+	Type  models.ProtocolType
+	Total int
+}
+
+// Scorecards builds a scorecard per subscription the results came from, in
+// order of appearance. Results without a subscription are graded together
+// under provider.
+func Scorecards(results []*models.TestResult, provider string) []*Scorecard {
+	var names []string
+	groups := map[string][]*models.TestResult{}
+	for _, result := range results {
+		if result == nil || result.Protocol == nil {
+			continue
+		}
+		name := result.Protocol.Subscription
+		if name == "" {
+			name = provider
+		}
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], result)
+	}
+
+	cards := make([]*Scorecard, 0, len(names))
+	for _, name := range names {
+		cards = append(cards, NewScorecard(name, groups[name]))
+	}
+	return cards
+}
+
+// NewScorecard grades the results of one provider
+func NewScorecard(provider string, results []*models.TestResult) *Scorecard {
+	card := &Scorecard{Provider: provider}
+	summary := Summarize(results)
+	card.Countries = summary.Countries
+
+	var latencies []time.Duration
+	var speeds []float64
+	var privacy, privacyTested, leaks int
+	var reached, tested, located, locatable int
+	protocols := map[models.ProtocolType]int{}
+
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		card.Nodes++
+		protocols[result.Protocol.Type]++
+		if !result.Success {
+			continue
+		}
+		card.Working++
+
+		if result.Connectivity != nil {
+			latencies = append(latencies, result.Connectivity.ResponseTime)
+		}
+		if result.Performance != nil && result.Performance.DownloadSpeed > 0 {
+			speeds = append(speeds, result.Performance.DownloadSpeed)
+		}
+		if geo := result.GeoAccess; geo != nil {
+			reached += geo.Summary.TotalAccessible
+			tested += geo.Summary.TotalTested
+		}
+		if p := result.Privacy; p != nil {
+			privacy += p.Score
+			privacyTested++
+			if p.DNSLeak || p.WebRTCLeak || p.IPv6Leak {
+				leaks++
+			}
+			if p.ExitCountry != "" && result.Protocol.AdvertisedCountry != "" {
+				locatable++
+				if !p.CountryMismatch {
+					located++
+				}
+			}
+		}
+	}
+
+	for typ, total := range protocols {
+		card.Protocols = append(card.Protocols, ProtocolCount{Type: typ, Total: total})
+	}
+	sort.Slice(card.Protocols, func(i, j int) bool {
+		a, b := card.Protocols[i], card.Protocols[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Type < b.Type
+	})
+
+	if card.Nodes > 0 {
+		card.add("Reliability", card.Working*100/card.Nodes,
+			fmt.Sprintf(i18n.T("%d/%d nodes working"), card.Working, card.Nodes))
+	}
+	if len(latencies) > 0 {
+		latency := stat.Median(latencies)
+		card.add("Latency", models.LatencyScore(latency),
+			fmt.Sprintf(i18n.T("median %dms"), latency.Milliseconds()))
+	}
+	if len(speeds) > 0 {
+		speed := stat.Median(speeds)
+		card.add("Speed", models.SpeedScore(speed),
+			fmt.Sprintf(i18n.T("median %.1f Mbps"), speed))
+	}
+	if tested > 0 {
+		card.add("Unlock", reached*100/tested,
+			fmt.Sprintf(i18n.T("%d/%d geo-restricted sites reached"), reached, tested))
+	}
+	if privacyTested > 0 {
+		card.add("Privacy", privacy/privacyTested,
+			fmt.Sprintf(i18n.T("average %d/100, %d nodes leaking"), privacy/privacyTested, leaks))
+	}
+	if locatable > 0 {
+		card.add("Advertised Locations", located*100/locatable,
+			fmt.Sprintf(i18n.T("%d/%d nodes exit in the advertised country"), located, locatable))
+	}
+
+	if len(card.Categories) > 0 {
+		total := 0
+		for _, category := range card.Categories {
+			total += category.Score
+		}
+		card.Score = (total + len(card.Categories)/2) / len(card.Categories)
+	}
+	card.Grade = models.GradeOf(card.Score)
+	if card.Working == 0 {
+		card.Grade = "F"
+	}
+
+	return card
+}
+
+// add grades a category
+func (c *Scorecard) add(name string, score int, detail string) {
+	c.Categories = append(c.Categories, ScorecardCategory{
+		Name:   name,
+		Score:  score,
+		Grade:  models.GradeOf(score),
+		Detail: detail,
+	})
+}
+
+// CountriesLabel lists the advertised countries with their node counts
+func (c *Scorecard) CountriesLabel() string {
+	countries := make([]string, len(c.Countries))
+	for i, count := range c.Countries {
+		countries[i] = fmt.Sprintf("%s %d", count.Country, count.Total)
+	}
+	return strings.Join(countries, ", ")
+}
+
+// ProtocolsLabel lists the protocols with their node counts
+func (c *Scorecard) ProtocolsLabel() string {
+	protocols := make([]string, len(c.Protocols))
+	for i, count := range c.Protocols {
+		protocols[i] = fmt.Sprintf("%s %d", count.Type, count.Total)
+	}
+	return strings.Join(protocols, ", ")
+}
+
+// scorecardFooter names the version and vantage country the scorecard was
+// measured with, since both change the numbers
+func scorecardFooter(info *models.RunInfo) string {
+	footer := fmt.Sprintf(i18n.T("Tested with ProtoScope %s"), info.Version)
+	if info.VantageCountry != "" {
+		footer += ", " + fmt.Sprintf(i18n.T("from %s"), info.VantageCountry)
+	}
+	return footer
+}
+
+// ScorecardMarkdown writes the scorecards as markdown; info adds how they
+// were measured and may be nil
+func ScorecardMarkdown(w io.Writer, cards []*Scorecard, info *models.RunInfo) {
+	for i, card := range cards {
+		if i > 0 {
+			fmt.Fprintln(w, "---")
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "# %s\n\n", fmt.Sprintf(i18n.T("Provider Scorecard: %s"), card.Provider))
+		fmt.Fprintf(w, "**%s**: %s\n\n", i18n.T("Generated"), time.Now().Format(time.RFC1123))
+		fmt.Fprintf(w, "## %s: %s (%d/100)\n\n", i18n.T("Overall Grade"), card.Grade, card.Score)
+		fmt.Fprintf(w, "- **%s**: %d (%s)\n", i18n.T("Nodes"), card.Nodes, fmt.Sprintf(i18n.T("%d working"), card.Working))
+		if len(card.Countries) > 0 {
+			fmt.Fprintf(w, "- **%s**: %s\n", i18n.T("Countries"), card.CountriesLabel())
+		}
+		fmt.Fprintf(w, "- **%s**: %s\n\n", i18n.T("Protocols"), card.ProtocolsLabel())
+
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", i18n.T("Category"), i18n.T("Grade"), i18n.T("Score"), i18n.T("Details"))
+		fmt.Fprintln(w, "|---|---|---|---|")
+		for _, category := range card.Categories {
+			fmt.Fprintf(w, "| %s | %s | %d | %s |\n", i18n.T(category.Name), category.Grade, category.Score, category.Detail)
+		}
+		fmt.Fprintln(w)
+		if info != nil {
+			fmt.Fprintf(w, "_%s_\n\n", scorecardFooter(info))
+		}
+	}
+}
+
+var scorecardTemplate = template.Must(template.New("scorecard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>ProtoScope Provider Scorecard</title>
+<style>
+body { font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; margin: 24px auto; max-width: 720px; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #d0d7de; padding: 6px 10px; text-align: left; }
+th { background: #f6f8fa; }
+.grade { font-weight: bold; text-align: center; }
+.A { color: #1a7f37; } .B { color: #4d8f1a; } .C { color: #9a6700; } .D, .F { color: #cf222e; }
+.overall { font-size: 48px; }
+footer { color: #57606a; font-size: 13px; margin-top: 16px; }
+</style>
+</head>
+<body>
+{{range .Cards}}<h1>Provider Scorecard: {{.Provider}}</h1>
+<p><strong>Generated</strong>: {{$.Generated}}</p>
+<p><span class="overall grade {{.Grade}}">{{.Grade}}</span> {{.Score}}/100</p>
+<ul>
+<li><strong>Nodes</strong>: {{.Nodes}} ({{.Working}} working)</li>
+{{if .Countries}}<li><strong>Countries</strong>: {{.CountriesLabel}}</li>
+{{end}}<li><strong>Protocols</strong>: {{.ProtocolsLabel}}</li>
+</ul>
+<table>
+<tr><th>Category</th><th>Grade</th><th>Score</th><th>Details</th></tr>
+{{range .Categories}}<tr><td>{{.Name}}</td><td class="grade {{.Grade}}">{{.Grade}}</td><td>{{.Score}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+{{if $.Footer}}<footer>{{$.Footer}}</footer>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// ScorecardHTML writes the scorecards as a standalone HTML page; info adds
+// how they were measured and may be nil
+func ScorecardHTML(w io.Writer, cards []*Scorecard, info *models.RunInfo) error {
+	data := struct {
+		Generated string
+		Cards     []*Scorecard
+		Footer    string
+	}{
+		Generated: time.Now().Format(time.RFC1123),
+		Cards:     cards,
+	}
+	if info != nil {
+		data.Footer = scorecardFooter(info)
+	}
+	return scorecardTemplate.Execute(w, data)
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestScorecard(t *testing.T) {
+	results := []*models.TestResult{
+		compareResult("", true, 100*time.Millisecond, 50, true),
+		compareResult("", true, 300*time.Millisecond, 25, false),
+		compareResult("", false, 0, 0, false),
+		compareResult("", true, 100*time.Millisecond, 100, true),
+	}
+	results[0].Privacy = &models.PrivacyResult{Score: 90, ExitCountry: "DE"}
+	results[0].Protocol.AdvertisedCountry = "DE"
+	results[1].Privacy = &models.PrivacyResult{Score: 50, DNSLeak: true, ExitCountry: "NL", CountryMismatch: true}
+	results[1].Protocol.AdvertisedCountry = "DE"
+
+	cards := Scorecards(results, "example.com")
+	if len(cards) != 1 {
+		t.Fatalf("%d scorecards, want 1", len(cards))
+	}
+	card := cards[0]
+	if card.Provider != "example.com" || card.Nodes != 4 || card.Working != 3 {
+		t.Errorf("card = %+v", card)
+	}
+
+	want := map[string]int{
+		"Reliability":          75,
+		"Latency":              100,
+		"Speed":                100,
+		"Unlock":               83,
+		"Privacy":              70,
+		"Advertised Locations": 50,
+	}
+	if len(card.Categories) != len(want) {
+		t.Fatalf("categories = %+v", card.Categories)
+	}
+	for _, category := range card.Categories {
+		if category.Score != want[category.Name] {
+			t.Errorf("%s scored %d, want %d", category.Name, category.Score, want[category.Name])
+		}
+	}
+	if card.Score != 80 || card.Grade != "A" {
+		t.Errorf("overall %s (%d), want A (80)", card.Grade, card.Score)
+	}
+
+	var md bytes.Buffer
+	ScorecardMarkdown(&md, cards, &models.RunInfo{Version: "v1", VantageCountry: "IR"})
+	if !strings.Contains(md.String(), "| Unlock | A | 83 | 5/6 geo-restricted sites reached |") {
+		t.Errorf("markdown missing the unlock row:\n%s", md.String())
+	}
+	var html bytes.Buffer
+	if err := ScorecardHTML(&html, cards, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScorecardsPerSubscription(t *testing.T) {
+	results := []*models.TestResult{
+		compareResult("a", true, 100*time.Millisecond, 10, true),
+		compareResult("b", false, 0, 0, false),
+	}
+	cards := Scorecards(results, "unused")
+	if len(cards) != 2 || cards[0].Provider != "a" || cards[1].Provider != "b" {
+		t.Fatalf("cards = %+v", cards)
+	}
+	if cards[1].Grade != "F" {
+		t.Errorf("provider without working nodes graded %s, want F", cards[1].Grade)
+	}
+}
@@ -0,0 +1,83 @@
+// Package sandbox runs proxy backends with reduced privileges. Backend
+// configs are built from untrusted subscription content, so a backend
+// exploited through one should not reach the rest of the system.
+//
+// The backend is started through ProtoScope's own sandbox-exec subcommand,
+// which confines itself and then executes the backend in its place: on
+// Linux it applies a Landlock ruleset that allows reading only the backend
+// binary, its config and the system files needed to resolve names and
+// verify certificates, and a seccomp filter that fails dangerous syscalls.
+// It can also switch to another user and to an AppArmor profile.
+package sandbox
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// CommandName is the protoscope subcommand that confines and runs a backend
+const CommandName = "sandbox-exec"
+
+// Options selects how a backend is confined
+type Options struct {
+	// Confine restricts filesystem access and syscalls
+	Confine bool
+	// User, when set, is the user the backend runs as; switching needs root
+	User string
+	// AppArmorProfile, when set, is the loaded profile the backend runs
+	// under; switching needs root
+	AppArmorProfile string
+}
+
+// Enabled reports whether any confinement is requested
+func (o Options) Enabled() bool {
+	return o.Confine || o.User != "" || o.AppArmorProfile != ""
+}
+
+// execArgs are the arguments of the sandbox-exec subcommand
+type execArgs struct {
+	confine  bool
+	apparmor string
+	readable []string
+	command  []string // the backend binary and its arguments
+}
+
+// wrapperArgs builds the sandbox-exec arguments running binary with args
+func wrapperArgs(opts Options, binary string, args []string, readable []string) []string {
+	wrapped := []string{CommandName}
+	if opts.Confine {
+		wrapped = append(wrapped, "-confine")
+		for _, path := range readable {
+			wrapped = append(wrapped, "-read", path)
+		}
+	}
+	if opts.AppArmorProfile != "" {
+		wrapped = append(wrapped, "-apparmor", opts.AppArmorProfile)
+	}
+	wrapped = append(wrapped, "--", binary)
+	return append(wrapped, args...)
+}
+
+// parseExecArgs parses the sandbox-exec arguments, without the subcommand
+func parseExecArgs(args []string) (execArgs, error) {
+	var parsed execArgs
+	flags := flag.NewFlagSet(CommandName, flag.ContinueOnError)
+	flags.BoolVar(&parsed.confine, "confine", false, "Restrict filesystem access and syscalls")
+	flags.StringVar(&parsed.apparmor, "apparmor", "", "AppArmor profile to run under")
+	flags.Func("read", "Path the backend may read (repeatable)", func(path string) error {
+		parsed.readable = append(parsed.readable, path)
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return parsed, err
+	}
+	parsed.command = flags.Args()
+	if len(parsed.command) == 0 {
+		return parsed, fmt.Errorf("usage: protoscope %s [-confine] [-read path]... [-apparmor profile] -- binary [args...]", CommandName)
+	}
+	if strings.ContainsAny(parsed.apparmor, "\x00\n") {
+		return parsed, fmt.Errorf("invalid AppArmor profile name %q", parsed.apparmor)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,291 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"runtime"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Command returns a command running binary with args confined as opts
+// asks. readable lists the files the backend needs besides its binary,
+// e.g. its config; with opts.User they are handed to that user.
+func Command(ctx context.Context, opts Options, binary string, args []string, readable ...string) (*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the protoscope executable: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, self, wrapperArgs(opts, binary, args, readable)...)
+
+	if opts.User != "" {
+		credential, err := lookupCredential(opts.User)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range readable {
+			if err := os.Chown(path, int(credential.Uid), int(credential.Gid)); err != nil {
+				return nil, fmt.Errorf("cannot hand %s to user %s: %w", path, opts.User, err)
+			}
+		}
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: credential}
+	}
+	return cmd, nil
+}
+
+// lookupCredential returns the uid and primary gid of a user name or uid,
+// without supplementary groups
+func lookupCredential(name string) (*syscall.Credential, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		if u, err = user.LookupId(name); err != nil {
+			return nil, fmt.Errorf("unknown backend user %q", name)
+		}
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %s has a non-numeric uid %q", name, u.Uid)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %s has a non-numeric gid %q", name, u.Gid)
+	}
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: []uint32{}}, nil
+}
+
+// Exec runs the sandbox-exec subcommand: it confines the current thread
+// and executes the backend on it, which keeps the confinement. It only
+// returns on failure.
+func Exec(args []string) error {
+	parsed, err := parseExecArgs(args)
+	if err != nil {
+		return err
+	}
+
+	binary, err := exec.LookPath(parsed.command[0])
+	if err != nil {
+		return err
+	}
+
+	// Landlock, seccomp, no_new_privs and the AppArmor transition all apply
+	// to the calling thread, which must be the one that executes the backend
+	runtime.LockOSThread()
+
+	if parsed.apparmor != "" {
+		if err := setAppArmorExec(parsed.apparmor); err != nil {
+			return err
+		}
+	}
+	if parsed.confine {
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return fmt.Errorf("cannot set no_new_privs: %w", err)
+		}
+		if err := restrictFilesystem(binary, parsed.readable); err != nil {
+			return err
+		}
+		if err := filterSyscalls(); err != nil {
+			return err
+		}
+	}
+
+	return unix.Exec(binary, parsed.command, os.Environ())
+}
+
+// setAppArmorExec makes the next exec of this thread switch to profile
+func setAppArmorExec(profile string) error {
+	request := []byte("exec " + profile)
+	// Kernels with stacked LSMs have an AppArmor-specific attribute
+	err := os.WriteFile("/proc/thread-self/attr/apparmor/exec", request, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		err = os.WriteFile("/proc/thread-self/attr/exec", request, 0)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot switch to AppArmor profile %s: %w", profile, err)
+	}
+	return nil
+}
+
+// Landlock access rights. Rights newer than the running kernel's Landlock
+// ABI are left out of the ruleset.
+const (
+	landlockRead    = unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR
+	landlockExecute = landlockRead | unix.LANDLOCK_ACCESS_FS_EXECUTE
+	landlockDevice  = unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_WRITE_FILE
+	// Rights that may be granted on a file rather than a directory
+	landlockFileRights = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_TRUNCATE | unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+)
+
+// systemReadable are read by backends to resolve names, verify
+// certificates and use local time
+var systemReadable = []string{
+	"/etc/resolv.conf", "/etc/hosts", "/etc/nsswitch.conf", "/etc/gai.conf",
+	"/etc/host.conf", "/etc/localtime", "/usr/share/zoneinfo",
+	"/etc/ssl", "/etc/pki", "/etc/ca-certificates", "/usr/share/ca-certificates",
+	"/etc/ld.so.cache", "/dev/urandom", "/dev/random",
+}
+
+// systemLibraries are needed to run dynamically linked backends
+var systemLibraries = []string{"/lib", "/lib32", "/lib64", "/usr/lib", "/usr/lib32", "/usr/lib64"}
+
+// restrictFilesystem applies a Landlock ruleset allowing only binary to
+// be executed and only readable and the system files to be read. Paths that
+// don't exist are skipped.
+func restrictFilesystem(binary string, readable []string) error {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return fmt.Errorf("landlock is not available (Linux 5.13+ with landlock enabled is needed): %w", errno)
+	}
+	var handled uint64 = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR | unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR | unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG | unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO | unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+	if abi >= 2 {
+		handled |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		handled |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	if abi >= 5 {
+		handled |= unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("cannot create landlock ruleset: %w", errno)
+	}
+	ruleset := int(fd)
+	defer unix.Close(ruleset)
+
+	allow := func(path string, access uint64) error {
+		file, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+		if errors.Is(err, unix.ENOENT) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot open %s for the sandbox: %w", path, err)
+		}
+		defer unix.Close(file)
+		var stat unix.Stat_t
+		if err := unix.Fstat(file, &stat); err != nil {
+			return err
+		}
+		if stat.Mode&unix.S_IFMT != unix.S_IFDIR {
+			access &= landlockFileRights
+		}
+		rule := unix.LandlockPathBeneathAttr{Allowed_access: access & handled, Parent_fd: int32(file)}
+		_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset),
+			unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+		if errno != 0 {
+			return fmt.Errorf("cannot allow %s in the sandbox: %w", path, errno)
+		}
+		return nil
+	}
+
+	if err := allow(binary, landlockExecute); err != nil {
+		return err
+	}
+	for _, path := range systemLibraries {
+		if err := allow(path, landlockExecute); err != nil {
+			return err
+		}
+	}
+	for _, path := range append(systemReadable, readable...) {
+		if err := allow(path, landlockRead); err != nil {
+			return err
+		}
+	}
+	if err := allow("/dev/null", landlockDevice); err != nil {
+		return err
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, uintptr(ruleset), 0, 0); errno != 0 {
+		return fmt.Errorf("cannot apply landlock ruleset: %w", errno)
+	}
+	return nil
+}
+
+// deniedSyscalls fail with EPERM in the sandbox. A proxy needs none of
+// them; they inspect other processes, change the system or widen the
+// kernel's attack surface.
+var deniedSyscalls = []uintptr{
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT, unix.SYS_CHROOT,
+	unix.SYS_FSOPEN, unix.SYS_FSMOUNT, unix.SYS_MOVE_MOUNT, unix.SYS_OPEN_TREE,
+	unix.SYS_UNSHARE, unix.SYS_SETNS,
+	unix.SYS_SWAPON, unix.SYS_SWAPOFF, unix.SYS_REBOOT, unix.SYS_KEXEC_LOAD,
+	unix.SYS_INIT_MODULE, unix.SYS_FINIT_MODULE, unix.SYS_DELETE_MODULE,
+	unix.SYS_BPF, unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD,
+	unix.SYS_KEYCTL, unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY, unix.SYS_ACCT,
+	unix.SYS_SETTIMEOFDAY, unix.SYS_CLOCK_SETTIME, unix.SYS_SETHOSTNAME, unix.SYS_SETDOMAINNAME,
+	unix.SYS_OPEN_BY_HANDLE_AT, unix.SYS_NAME_TO_HANDLE_AT,
+}
+
+// auditArches are the architectures the syscall filter knows; elsewhere
+// only Landlock applies
+var auditArches = map[string]uint32{
+	"amd64": unix.AUDIT_ARCH_X86_64,
+	"arm64": unix.AUDIT_ARCH_AARCH64,
+}
+
+// x32SyscallBit marks syscalls of the x32 ABI on amd64, which have their
+// own numbers
+const x32SyscallBit = 0x40000000
+
+// filterSyscalls installs a seccomp filter failing deniedSyscalls, and any
+// syscall made through another architecture's ABI
+func filterSyscalls() error {
+	arch, ok := auditArches[runtime.GOARCH]
+	if !ok {
+		return nil
+	}
+	const (
+		load    = unix.BPF_LD | unix.BPF_W | unix.BPF_ABS
+		jumpEq  = unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K
+		jumpGE  = unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K
+		ret     = unix.BPF_RET | unix.BPF_K
+		deny    = unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+		archOff = 4 // offsets in struct seccomp_data
+		nrOff   = 0
+	)
+
+	filter := []unix.SockFilter{
+		{Code: load, K: archOff},
+		{Code: jumpEq, Jt: 1, K: arch},
+		{Code: ret, K: deny},
+		{Code: load, K: nrOff},
+	}
+	jumps := len(filter)
+	if runtime.GOARCH == "amd64" {
+		filter = append(filter, unix.SockFilter{Code: jumpGE, K: x32SyscallBit})
+	}
+	for _, nr := range deniedSyscalls {
+		filter = append(filter, unix.SockFilter{Code: jumpEq, K: uint32(nr)})
+	}
+	filter = append(filter,
+		unix.SockFilter{Code: ret, K: unix.SECCOMP_RET_ALLOW},
+		unix.SockFilter{Code: ret, K: deny})
+	// Every comparison jumps to the final deny when it matches
+	for i := jumps; i < len(filter)-2; i++ {
+		filter[i].Jt = uint8(len(filter) - 1 - i - 1)
+	}
+
+	program := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if _, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER, 0, uintptr(unsafe.Pointer(&program))); errno != 0 {
+		return fmt.Errorf("cannot install seccomp filter: %w", errno)
+	}
+	return nil
+}
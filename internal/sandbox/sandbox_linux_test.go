@@ -0,0 +1,80 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestMain lets the test binary stand in for protoscope's sandbox-exec
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == CommandName {
+		if err := Exec(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func TestConfineReadsOnlyAllowedFiles(t *testing.T) {
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION); errno != 0 {
+		t.Skipf("landlock unavailable: %v", errno)
+	}
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat not found")
+	}
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.json")
+	secret := filepath.Join(dir, "secret")
+	os.WriteFile(config, []byte("config"), 0600)
+	os.WriteFile(secret, []byte("secret"), 0600)
+
+	run := func(path string) (string, error) {
+		cmd, err := Command(context.Background(), Options{Confine: true}, cat, []string{path}, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run(config); err != nil || output != "config" {
+		t.Fatalf("reading the config: %q, %v", output, err)
+	}
+	if output, err := run(secret); err == nil {
+		t.Fatalf("read a file outside the sandbox: %q", output)
+	}
+}
+
+func TestParseExecArgs(t *testing.T) {
+	args := wrapperArgs(Options{Confine: true, AppArmorProfile: "protoscope-backend"},
+		"/usr/bin/xray", []string{"run", "-c", "/tmp/c.json"}, []string{"/tmp/c.json"})
+	if args[0] != CommandName {
+		t.Fatalf("args start with %q", args[0])
+	}
+	parsed, err := parseExecArgs(args[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.confine || parsed.apparmor != "protoscope-backend" {
+		t.Errorf("parsed %+v", parsed)
+	}
+	if len(parsed.readable) != 1 || parsed.readable[0] != "/tmp/c.json" {
+		t.Errorf("readable = %v", parsed.readable)
+	}
+	if strings.Join(parsed.command, " ") != "/usr/bin/xray run -c /tmp/c.json" {
+		t.Errorf("command = %v", parsed.command)
+	}
+	if _, err := parseExecArgs([]string{"-confine"}); err == nil {
+		t.Error("accepted arguments without a command")
+	}
+}
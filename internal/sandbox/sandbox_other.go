@@ -0,0 +1,20 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Command fails: backends can only be sandboxed on Linux
+func Command(ctx context.Context, opts Options, binary string, args []string, readable ...string) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("backend sandboxing is not supported on %s", runtime.GOOS)
+}
+
+// Exec fails: backends can only be sandboxed on Linux
+func Exec(args []string) error {
+	return fmt.Errorf("backend sandboxing is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// maxWorkFactor is log2 of the highest scrypt cost a passphrase-encrypted
+// file may ask for, the age default
+const maxWorkFactor = 22
+
+// Identity decrypts age files; Recipient encrypts them. Secrets files are
+// plain age files, so they can also be read and written with age itself.
+type (
+	Identity  = age.Identity
+	Recipient = age.Recipient
+)
+
+// GenerateX25519Identity creates a new key pair, as age-keygen does
+func GenerateX25519Identity() (*age.X25519Identity, error) {
+	return age.GenerateX25519Identity()
+}
+
+// ParseX25519Identity reads an AGE-SECRET-KEY-1... key
+func ParseX25519Identity(s string) (*age.X25519Identity, error) {
+	return age.ParseX25519Identity(s)
+}
+
+// LoadX25519Identity reads the first key of an identity file: lines of
+// keys, with # comments and blank lines ignored
+func LoadX25519Identity(path string) (*age.X25519Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		identity, err := ParseX25519Identity(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return identity, nil
+	}
+	return nil, fmt.Errorf("%s: no identity found", path)
+}
+
+// NewScryptIdentity returns the identity of a passphrase (age -p)
+func NewScryptIdentity(passphrase string) (*age.ScryptIdentity, error) {
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	identity.SetMaxWorkFactor(maxWorkFactor)
+	return identity, nil
+}
+
+// NewScryptRecipient returns the recipient of a passphrase
+func NewScryptRecipient(passphrase string) (*age.ScryptRecipient, error) {
+	return age.NewScryptRecipient(passphrase)
+}
+
+// Encrypt encrypts plaintext to the recipients in the binary age format
+func Encrypt(plaintext []byte, recipients ...Recipient) ([]byte, error) {
+	var out bytes.Buffer
+	w, err := age.Encrypt(&out, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Decrypt decrypts a binary age file with the first identity that matches
+// one of its recipients
+func Decrypt(ciphertext []byte, identities ...Identity) ([]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
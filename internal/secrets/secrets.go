@@ -0,0 +1,188 @@
+// Package secrets keeps bot tokens, passwords and API keys in an
+// age-encrypted file, so the config file can refer to them by alias
+// (secret:<alias>) instead of holding them in plain text
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Prefix marks a config value as a reference to a secret
+const Prefix = "secret:"
+
+// DefaultPath returns the default secrets file location in the user's
+// config directory
+func DefaultPath() string {
+	return filepath.Join(configDir(), "protoscope", "secrets.age")
+}
+
+// DefaultIdentityPath returns the default location of the key written by
+// protoscope secrets keygen
+func DefaultIdentityPath() string {
+	return filepath.Join(configDir(), "protoscope", "identity.txt")
+}
+
+func configDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "."
+	}
+	return dir
+}
+
+// Store is a decrypted secrets file: a YAML map of aliases to values
+type Store struct {
+	path      string
+	recipient Recipient
+	values    map[string]string
+}
+
+// Open decrypts the secrets file at path with identity. A missing file
+// yields an empty store. Save encrypts the file to recipient only, so
+// other recipients of a file made with age lose access.
+func Open(path string, identity Identity, recipient Recipient) (*Store, error) {
+	s := &Store{path: path, recipient: recipient, values: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+
+	plaintext, err := Decrypt(data, identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(plaintext, &s.values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if s.values == nil {
+		s.values = map[string]string{}
+	}
+	return s, nil
+}
+
+// Get returns the value of an alias
+func (s *Store) Get(alias string) (string, bool) {
+	value, ok := s.values[alias]
+	return value, ok
+}
+
+// Set stores a value under an alias
+func (s *Store) Set(alias, value string) {
+	s.values[alias] = value
+}
+
+// Remove deletes an alias and reports whether it existed
+func (s *Store) Remove(alias string) bool {
+	_, ok := s.values[alias]
+	delete(s.values, alias)
+	return ok
+}
+
+// Aliases returns the stored aliases in order
+func (s *Store) Aliases() []string {
+	aliases := make([]string, 0, len(s.values))
+	for alias := range s.values {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// Save encrypts the store back to its file, readable by the owner only
+func (s *Store) Save() error {
+	plaintext, err := yaml.Marshal(s.values)
+	if err != nil {
+		return err
+	}
+	data, err := Encrypt(plaintext, s.recipient)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+// Resolve replaces every string in v, a pointer to a struct such as the
+// config, that reads secret:<alias> with the alias's value
+func (s *Store) Resolve(v interface{}) error {
+	var missing []string
+	walkStrings(reflect.ValueOf(v), func(value string) (string, bool) {
+		alias, ok := strings.CutPrefix(value, Prefix)
+		if !ok {
+			return "", false
+		}
+		secret, ok := s.values[alias]
+		if !ok {
+			missing = append(missing, alias)
+			return "", false
+		}
+		return secret, true
+	})
+	if len(missing) > 0 {
+		return fmt.Errorf("unknown secrets: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// HasReferences reports whether any string in v refers to a secret
+func HasReferences(v interface{}) bool {
+	found := false
+	walkStrings(reflect.ValueOf(v), func(value string) (string, bool) {
+		if strings.HasPrefix(value, Prefix) {
+			found = true
+		}
+		return "", false
+	})
+	return found
+}
+
+// walkStrings calls replace with the exported strings reachable from v,
+// setting those it returns true for. Strings in map values are replaced
+// in the map.
+func walkStrings(v reflect.Value, replace func(string) (string, bool)) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			walkStrings(v.Elem(), replace)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				walkStrings(v.Field(i), replace)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkStrings(v.Index(i), replace)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			value := iter.Value()
+			if value.Kind() != reflect.String {
+				walkStrings(value, replace)
+				continue
+			}
+			if secret, ok := replace(value.String()); ok {
+				v.SetMapIndex(iter.Key(), reflect.ValueOf(secret).Convert(value.Type()))
+			}
+		}
+	case reflect.String:
+		if secret, ok := replace(v.String()); ok && v.CanSet() {
+			v.SetString(secret)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package secrets
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	agetest "c2sp.org/CCTV/age"
+	"filippo.io/age"
+)
+
+func TestAgeRoundTrip(t *testing.T) {
+	identity, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseX25519Identity(identity.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(identity.String(), "AGE-SECRET-KEY-1") || !strings.HasPrefix(parsed.Recipient().String(), "age1") {
+		t.Errorf("keys encoded as %s, %s", identity, parsed.Recipient())
+	}
+
+	other, _ := GenerateX25519Identity()
+	ciphertext, err := Encrypt([]byte("token"), other.Recipient(), identity.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Decrypt(ciphertext, parsed); err != nil || string(got) != "token" {
+		t.Errorf("decrypted %q, %v", got, err)
+	}
+
+	third, _ := GenerateX25519Identity()
+	if _, err := Decrypt(ciphertext, third); err == nil {
+		t.Error("decrypted with the wrong key")
+	}
+}
+
+func TestScryptRoundTrip(t *testing.T) {
+	recipient, err := NewScryptRecipient("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient.SetWorkFactor(10) // keep the test fast
+
+	ciphertext, err := Encrypt([]byte("token"), recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, _ := NewScryptIdentity("correct horse")
+	if got, err := Decrypt(ciphertext, identity); err != nil || string(got) != "token" {
+		t.Errorf("decrypted %q, %v", got, err)
+	}
+	wrong, _ := NewScryptIdentity("wrong")
+	if _, err := Decrypt(ciphertext, wrong); err == nil {
+		t.Error("wrong passphrase accepted")
+	}
+}
+
+// TestAgeVectors decrypts the age test suite (c2sp.org/CCTV/age), files
+// made by age and malformed ones it must refuse. Secrets files are never
+// armored, so the armored vectors are left out.
+func TestAgeVectors(t *testing.T) {
+	vectors, err := fs.ReadDir(agetest.Vectors, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, vector := range vectors {
+		t.Run(vector.Name(), func(t *testing.T) {
+			data, err := fs.ReadFile(agetest.Vectors, vector.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, body, _ := bytes.Cut(data, []byte("\n\n"))
+
+			var expect, payload string
+			var identities []Identity
+			for _, line := range strings.Split(string(header), "\n") {
+				key, value, _ := strings.Cut(line, ": ")
+				switch key {
+				case "expect":
+					expect = value
+				case "payload":
+					payload = value
+				case "armored":
+					t.Skip("armored")
+				case "compressed":
+					r, err := zlib.NewReader(bytes.NewReader(body))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if body, err = io.ReadAll(r); err != nil {
+						t.Fatal(err)
+					}
+				case "identity":
+					parsed, err := age.ParseIdentities(strings.NewReader(value))
+					if err != nil {
+						t.Fatal(err)
+					}
+					identities = append(identities, parsed...)
+				case "passphrase":
+					identity, err := NewScryptIdentity(value)
+					if err != nil {
+						t.Fatal(err)
+					}
+					identities = append(identities, identity)
+				}
+			}
+
+			plaintext, err := Decrypt(body, identities...)
+			if expect != "success" {
+				if err == nil {
+					t.Fatalf("decrypted, want %s", expect)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sum := sha256.Sum256(plaintext); hex.EncodeToString(sum[:]) != payload {
+				t.Errorf("payload hash %x, want %s", sum, payload)
+			}
+		})
+	}
+}
+
+type testConfig struct {
+	Email struct {
+		Password string
+		To       []string
+	}
+	Headers map[string]string
+	Plain   string
+}
+
+func TestStoreResolve(t *testing.T) {
+	identity, _ := GenerateX25519Identity()
+	path := filepath.Join(t.TempDir(), "secrets.age")
+
+	store, err := Open(path, identity, identity.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("smtp", "hunter2")
+	store.Set("api", "key-123")
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = Open(path, identity, identity.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aliases := store.Aliases(); len(aliases) != 2 || aliases[0] != "api" {
+		t.Errorf("aliases = %v", aliases)
+	}
+
+	config := &testConfig{Headers: map[string]string{"Authorization": "secret:api"}, Plain: "secret"}
+	config.Email.Password = "secret:smtp"
+	config.Email.To = []string{"me@example.com"}
+	if !HasReferences(config) {
+		t.Fatal("references not found")
+	}
+	if err := store.Resolve(config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Email.Password != "hunter2" || config.Headers["Authorization"] != "key-123" || config.Plain != "secret" {
+		t.Errorf("resolved config = %+v", config)
+	}
+
+	config.Plain = "secret:missing"
+	if err := store.Resolve(config); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("unknown alias: %v", err)
+	}
+}
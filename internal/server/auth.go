@@ -0,0 +1,143 @@
+package server
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// SetTokens requires one of tokens on every request but the health
+// probes. Without tokens everyone may read what doesn't reveal the nodes,
+// and nobody may change anything.
+func (s *Server) SetTokens(tokens []models.APIToken) {
+	s.tokens = tokens
+}
+
+// SetTLS makes ListenAndServe speak HTTPS with config. When config has
+// ClientCAs, requests other than the health probes need a verified client
+// certificate.
+func (s *Server) SetTLS(config *tls.Config) {
+	s.tlsConfig = config
+}
+
+// LoadTLS builds the TLS config for a certificate and key, and with a
+// client CA bundle, for mTLS
+func LoadTLS(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		data, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("no certificates in client CA " + clientCAFile)
+		}
+		config.ClientCAs = pool
+		// Health probes come without a certificate; authorize rejects
+		// the other requests that lack one
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config, nil
+}
+
+// authorize checks the client certificate and token of each request.
+// GET requests need a read token, anything else an admin token.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.tlsConfig != nil && s.tlsConfig.ClientCAs != nil &&
+			(r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			writeClashError(w, http.StatusUnauthorized, "Client certificate required")
+			return
+		}
+
+		scope := models.ScopeAdmin
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			scope = models.ScopeRead
+		}
+
+		if len(s.tokens) == 0 {
+			if scope != models.ScopeRead {
+				writeClashError(w, http.StatusForbidden, "No admin token configured")
+				return
+			}
+			if revealsNodes(r.URL.Path) {
+				writeClashError(w, http.StatusForbidden, "No read token configured")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := s.lookupToken(requestToken(r))
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="protoscope"`)
+			writeClashError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if scope == models.ScopeAdmin && token.Scope != models.ScopeAdmin {
+			writeClashError(w, http.StatusForbidden, "Token "+token.Name+" is read-only")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// revealsNodes reports whether path serves node links, which carry their
+// credentials, or node names, as the labels of /metrics do. Without tokens
+// anyone who reaches the server, e.g. a web page through the operator's
+// browser, could read them.
+func revealsNodes(path string) bool {
+	switch {
+	case path == "/sub/working", path == "/events", path == "/proxies", path == "/metrics":
+		return true
+	case strings.HasPrefix(path, "/proxies/"):
+		return true
+	case strings.HasPrefix(path, "/runs/"):
+		return strings.HasSuffix(path, "/results") || strings.HasSuffix(path, "/events")
+	}
+	return false
+}
+
+// requestToken returns the bearer token of r. Subscription clients can't
+// set headers, so the token query parameter is accepted too.
+func requestToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
+
+// lookupToken finds the configured token equal to value, comparing in
+// constant time
+func (s *Server) lookupToken(value string) (models.APIToken, bool) {
+	if value == "" {
+		return models.APIToken{}, false
+	}
+	for _, token := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Token), []byte(value)) == 1 {
+			return token, true
+		}
+	}
+	return models.APIToken{}, false
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/export"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Clash-compatible API: a read-only subset of the Clash/mihomo external
+// controller API backed by the latest results, so dashboards such as yacd
+// or metacubexd can display them. Delay tests return the measured latency
+// instead of testing again.
+
+// clashGroupName is the group listing working nodes best first
+const clashGroupName = "ProtoScope"
+
+// clashProxy is a proxy or group in the Clash API format
+type clashProxy struct {
+	Name    string         `json:"name"`
+	Type    string         `json:"type"`
+	UDP     bool           `json:"udp"`
+	Alive   bool           `json:"alive"`
+	History []clashHistory `json:"history"`
+	Now     string         `json:"now,omitempty"`
+	All     []string       `json:"all,omitempty"`
+}
+
+// clashHistory is one delay measurement
+type clashHistory struct {
+	Time  time.Time `json:"time"`
+	Delay int64     `json:"delay"`
+}
+
+// clashTypes maps protocol types to Clash type names
+var clashTypes = map[models.ProtocolType]string{
+	models.ProtocolVMess:       "Vmess",
+	models.ProtocolVLESS:       "Vless",
+	models.ProtocolTrojan:      "Trojan",
+	models.ProtocolShadowsocks: "Shadowsocks",
+	models.ProtocolHysteria2:   "Hysteria2",
+	models.ProtocolTUIC:        "Tuic",
+	models.ProtocolWireGuard:   "WireGuard",
+	models.ProtocolSSH:         "Ssh",
+}
+
+// registerClashAPI adds the Clash-compatible routes to mux
+func (s *Server) registerClashAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /version", s.handleClashVersion)
+	mux.HandleFunc("GET /configs", s.handleClashConfigs)
+	mux.HandleFunc("GET /proxies", s.handleClashProxies)
+	mux.HandleFunc("GET /proxies/{name}", s.handleClashProxy)
+	mux.HandleFunc("GET /proxies/{name}/delay", s.handleClashDelay)
+}
+
+func (s *Server) handleClashVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"version": "protoscope",
+		"meta":    true,
+	})
+}
+
+func (s *Server) handleClashConfigs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"port":       0,
+		"socks-port": 0,
+		"mixed-port": 0,
+		"allow-lan":  false,
+		"mode":       "global",
+		"log-level":  "info",
+	})
+}
+
+func (s *Server) handleClashProxies(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"proxies": s.clashProxies(),
+	})
+}
+
+func (s *Server) handleClashProxy(w http.ResponseWriter, r *http.Request) {
+	proxy, ok := s.clashProxies()[r.PathValue("name")]
+	if !ok {
+		writeClashError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, proxy)
+}
+
+func (s *Server) handleClashDelay(w http.ResponseWriter, r *http.Request) {
+	proxy, ok := s.clashProxies()[r.PathValue("name")]
+	if !ok {
+		writeClashError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	if !proxy.Alive || len(proxy.History) == 0 {
+		writeClashError(w, http.StatusServiceUnavailable, "An error occurred in the delay test")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{
+		"delay": proxy.History[len(proxy.History)-1].Delay,
+	})
+}
+
+// clashProxies converts the latest results into Clash proxies: one entry
+// per node plus the ProtoScope group (working nodes, best first) and the
+// GLOBAL group dashboards expect
+func (s *Server) clashProxies() map[string]clashProxy {
+	results, _ := s.latest()
+
+	var tested []*models.TestResult
+	for _, result := range results {
+		if result != nil && result.Protocol != nil {
+			tested = append(tested, result)
+		}
+	}
+
+	names := export.UniqueNames(tested)
+	nameOf := make(map[*models.TestResult]string, len(tested))
+	proxies := make(map[string]clashProxy, len(tested)+2)
+
+	for i, result := range tested {
+		nameOf[result] = names[i]
+
+		proxy := clashProxy{
+			Name:    names[i],
+			Type:    clashTypes[result.Protocol.Type],
+			UDP:     result.Protocol.Type == models.ProtocolHysteria2 || result.Protocol.Type == models.ProtocolTUIC || result.Protocol.Type == models.ProtocolWireGuard,
+			Alive:   result.Success,
+			History: []clashHistory{},
+		}
+		if proxy.Type == "" {
+			proxy.Type = string(result.Protocol.Type)
+		}
+
+		delay := int64(0)
+		if result.Success && result.Connectivity != nil {
+			delay = result.Connectivity.ResponseTime.Milliseconds()
+		}
+		proxy.History = append(proxy.History, clashHistory{Time: result.Timestamp, Delay: delay})
+
+		proxies[proxy.Name] = proxy
+	}
+
+	var ranked []string
+	for _, result := range models.RankResults(tested) {
+		ranked = append(ranked, nameOf[result])
+	}
+	group := clashProxy{
+		Name:    clashGroupName,
+		Type:    "URLTest",
+		Alive:   len(ranked) > 0,
+		History: []clashHistory{},
+		All:     ranked,
+	}
+	if len(ranked) > 0 {
+		group.Now = ranked[0]
+	}
+	proxies[group.Name] = group
+
+	proxies["GLOBAL"] = clashProxy{
+		Name:    "GLOBAL",
+		Type:    "Selector",
+		Alive:   true,
+		History: []clashHistory{},
+		Now:     clashGroupName,
+		All:     append([]string{clashGroupName}, names...),
+	}
+
+	return proxies
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeClashError writes an error in the Clash API format
+func writeClashError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
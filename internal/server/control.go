@@ -0,0 +1,51 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// settings is the body of /settings: the daemon settings that can be
+// changed while it runs
+type settings struct {
+	Interval string `json:"interval"`
+}
+
+// SetInterval sets the time between daemon runs reported by /settings
+func (s *Server) SetInterval(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = d
+}
+
+// Interval returns the time between daemon runs, as last changed through
+// /settings
+func (s *Server) Interval() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.interval
+}
+
+func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, settings{Interval: s.Interval().String()})
+}
+
+// handlePatchSettings changes the settings given in the body
+func (s *Server) handlePatchSettings(w http.ResponseWriter, r *http.Request) {
+	var patch settings
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&patch); err != nil {
+		writeClashError(w, http.StatusBadRequest, "Invalid body: "+err.Error())
+		return
+	}
+
+	if patch.Interval != "" {
+		interval, err := time.ParseDuration(patch.Interval)
+		if err != nil || interval <= 0 {
+			writeClashError(w, http.StatusBadRequest, "interval must be a positive duration such as 30m")
+			return
+		}
+		s.SetInterval(interval)
+	}
+	s.handleSettings(w, r)
+}
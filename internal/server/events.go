@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// event is a message of the event streams. Over SSE Name is the event and
+// Data its data; over WebSocket each message is the event as JSON.
+type event struct {
+	Name string      `json:"event"` // started, progress or done
+	Data interface{} `json:"data"`
+}
+
+// runProgress is a progress update in /events, which carries every run's
+type runProgress struct {
+	Run string `json:"run"`
+	models.TestProgress
+}
+
+// publish sends e to the /events streams; slow readers miss it
+func (s *Server) publish(e event) {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	for ch := range s.watchers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func (s *Server) watch() chan event {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	if s.watchers == nil {
+		s.watchers = make(map[chan event]struct{})
+	}
+	ch := make(chan event, 256)
+	s.watchers[ch] = struct{}{}
+	return ch
+}
+
+func (s *Server) unwatch(ch chan event) {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	delete(s.watchers, ch)
+}
+
+// handleEvents streams the events of all runs, scheduled or requested: a
+// started event, progress updates and a done event per run
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	events := s.watch()
+	defer s.unwatch(events)
+
+	s.streamEvents(w, r, func(ctx context.Context, send func(event) error) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-events:
+				if send(e) != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// streamEvents serves the events stream sends as server-sent events, or
+// over a WebSocket when the client asks to upgrade. stream returns when
+// it is done or ctx, cancelled when the client goes away, is done.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, stream func(ctx context.Context, send func(event) error)) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		ws := websocket.Server{
+			// CORS doesn't cover WebSockets: browsers connect from any
+			// page, sending its origin
+			Handshake: func(_ *websocket.Config, req *http.Request) error {
+				return s.checkOrigin(req)
+			},
+			Handler: func(conn *websocket.Conn) {
+				ctx, cancel := context.WithCancel(r.Context())
+				defer cancel()
+				go func() {
+					// Clients only send control frames; a read error
+					// means they went away
+					io.Copy(io.Discard, conn)
+					cancel()
+				}()
+				stream(ctx, func(e event) error {
+					return websocket.JSON.Send(conn, e)
+				})
+			},
+		}
+		ws.ServeHTTP(w, r)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeClashError(w, http.StatusInternalServerError, "Streaming unsupported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	stream(r.Context(), func(e event) error {
+		data, err := json.Marshal(e.Data)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Name, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+}
+
+// checkOrigin accepts WebSocket clients that are not browsers, which send
+// no origin, pages served by the server itself and the allowed CORS
+// origins
+func (s *Server) checkOrigin(r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" || s.allowedOrigin(origin) {
+		return nil
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return nil
+	}
+	return fmt.Errorf("origin %s not allowed", origin)
+}
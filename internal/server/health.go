@@ -0,0 +1,62 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// healthStatus is the body of /healthz and /readyz
+type healthStatus struct {
+	Status  string     `json:"status"` // ok or unavailable
+	Reason  string     `json:"reason,omitempty"`
+	LastRun *time.Time `json:"last_run,omitempty"`
+	Nodes   int        `json:"nodes"`
+}
+
+// Heartbeat records that the daemon loop is alive and will check in again
+// within d. /healthz fails once that time has passed, e.g. because a run hung.
+func (s *Server) Heartbeat(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.heartbeatDue = time.Now().Add(d)
+}
+
+// status summarizes the served results for the health endpoints
+func (s *Server) status() (healthStatus, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := healthStatus{Status: "ok", Nodes: len(s.results)}
+	if !s.updated.IsZero() {
+		updated := s.updated
+		status.LastRun = &updated
+	}
+	return status, s.heartbeatDue
+}
+
+// handleHealthz is the liveness probe: it fails when the daemon loop missed
+// its heartbeat, so an orchestrator can restart a stuck daemon
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	status, due := s.status()
+	if !due.IsZero() && time.Now().After(due) {
+		status.Status = "unavailable"
+		status.Reason = fmt.Sprintf("no heartbeat for %s", time.Since(due).Round(time.Second))
+		writeJSON(w, http.StatusServiceUnavailable, status)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleReadyz is the readiness probe: it fails until the first run has
+// finished and there are results to serve
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	status, _ := s.status()
+	if status.LastRun == nil {
+		status.Status = "unavailable"
+		status.Reason = "first run not finished"
+		writeJSON(w, http.StatusServiceUnavailable, status)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
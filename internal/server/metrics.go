@@ -0,0 +1,115 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// metricsNode is a node's labels and values in /metrics; a nil value was
+// not measured and has no sample
+type metricsNode struct {
+	labels   string
+	up       float64
+	latency  *float64
+	download *float64
+	security *float64
+	score    float64
+}
+
+// metricFamily is a per-node gauge of /metrics
+type metricFamily struct {
+	name  string
+	help  string
+	value func(metricsNode) *float64
+}
+
+var nodeMetrics = []metricFamily{
+	{"protoscope_node_up", "Whether the node worked in the latest run (1) or not (0)",
+		func(n metricsNode) *float64 { return &n.up }},
+	{"protoscope_node_latency_ms", "Response time through the node in milliseconds",
+		func(n metricsNode) *float64 { return n.latency }},
+	{"protoscope_node_download_mbps", "Download speed through the node in Mbps",
+		func(n metricsNode) *float64 { return n.download }},
+	{"protoscope_node_security_score", "Privacy and security score of the node, 0-100",
+		func(n metricsNode) *float64 { return n.security }},
+	{"protoscope_node_score", "Overall quality score of the node, 0-100",
+		func(n metricsNode) *float64 { return &n.score }},
+}
+
+// handleMetrics exposes the latest results in the Prometheus text format,
+// one series per node, labelled with its fingerprint, name and type
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	results, updated := s.latest()
+
+	var nodes []metricsNode
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if result == nil || result.Protocol == nil {
+			continue
+		}
+		// A node listed by two subscriptions would repeat its series
+		fingerprint := result.Protocol.Fingerprint()
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+		nodes = append(nodes, newMetricsNode(result, fingerprint))
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	gauge(w, "protoscope_nodes", "Number of nodes in the latest run")
+	fmt.Fprintf(w, "protoscope_nodes %d\n", len(nodes))
+	if !updated.IsZero() {
+		gauge(w, "protoscope_last_run_timestamp_seconds", "Unix time the latest run finished")
+		fmt.Fprintf(w, "protoscope_last_run_timestamp_seconds %d\n", updated.Unix())
+	}
+
+	for _, family := range nodeMetrics {
+		gauge(w, family.name, family.help)
+		for _, node := range nodes {
+			if value := family.value(node); value != nil {
+				fmt.Fprintf(w, "%s{%s} %s\n", family.name, node.labels, strconv.FormatFloat(*value, 'f', -1, 64))
+			}
+		}
+	}
+}
+
+func newMetricsNode(result *models.TestResult, fingerprint string) metricsNode {
+	p := result.Protocol
+	node := metricsNode{
+		labels: fmt.Sprintf(`fingerprint="%s",name="%s",type="%s"`,
+			labelValue(fingerprint), labelValue(p.Name), labelValue(string(p.Type))),
+		score: float64(result.Score()),
+	}
+	if result.Success {
+		node.up = 1
+	}
+	if result.Success && result.Connectivity != nil && result.Connectivity.ResponseTime > 0 {
+		latency := float64(result.Connectivity.ResponseTime.Milliseconds())
+		node.latency = &latency
+	}
+	if result.Performance != nil && result.Performance.DownloadSpeed > 0 {
+		download := result.Performance.DownloadSpeed
+		node.download = &download
+	}
+	if result.Privacy != nil {
+		security := float64(result.Privacy.Score)
+		node.security = &security
+	}
+	return node
+}
+
+// gauge writes the HELP and TYPE lines of a gauge
+func gauge(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// labelValue escapes a label value of the text format
+func labelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
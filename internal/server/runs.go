@@ -0,0 +1,437 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// runHistory is the number of finished runs kept for GET /runs
+const runHistory = 20
+
+// Run is a test run tracked by the server, scheduled or requested through
+// POST /runs. Each has its own progress stream at /runs/{id}/events.
+type Run struct {
+	id      string
+	trigger string
+	queued  time.Time
+	seq     int     // orders queued runs of equal priority
+	server  *Server // publishes the run's events to /events
+
+	mu          sync.Mutex
+	request     RunRequest
+	started     time.Time // zero while queued
+	nodes       int
+	done        int
+	finished    time.Time
+	results     []*models.TestResult
+	err         error
+	subscribers map[chan event]struct{}
+	closed      chan struct{} // closed by Finish
+}
+
+// RunRequest is the body of POST /runs
+type RunRequest struct {
+	// Priority orders queued runs, highest first
+	Priority int `json:"priority"`
+	// URL is a subscription to fetch and test instead of the server's
+	// nodes. It often holds an access token, so statuses leave it out.
+	URL string `json:"url,omitempty"`
+	// Nodes limits the run to the nodes with these names or fingerprint
+	// prefixes; empty tests all
+	Nodes []string `json:"nodes,omitempty"`
+}
+
+// key identifies requests for the same nodes, whatever their order
+func (r RunRequest) key() string {
+	nodes := append([]string(nil), r.Nodes...)
+	slices.Sort(nodes)
+	return r.URL + "\x01" + strings.Join(slices.Compact(nodes), "\x00")
+}
+
+// validate rejects subscription URLs that are not http(s)
+func (r RunRequest) validate() error {
+	if r.URL == "" {
+		return nil
+	}
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http or https subscription URL")
+	}
+	return nil
+}
+
+// runStatus is the JSON form of a run
+type runStatus struct {
+	ID        string     `json:"id"`
+	Trigger   string     `json:"trigger"` // schedule or api
+	State     string     `json:"state"`   // queued, running, finished or failed
+	Priority  int        `json:"priority"`
+	Requested []string   `json:"requested_nodes,omitempty"`
+	Queued    time.Time  `json:"queued"`
+	Started   *time.Time `json:"started,omitempty"`
+	Finished  *time.Time `json:"finished,omitempty"`
+	Nodes     int        `json:"nodes"`
+	Done      int        `json:"done"`
+	Working   int        `json:"working"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// ID returns the run's identifier in the /runs routes
+func (r *Run) ID() string {
+	return r.id
+}
+
+// Request returns what the run was requested with; empty for scheduled
+// runs
+func (r *Run) Request() RunRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.request
+}
+
+// SetNodes records the number of nodes the run tests
+func (r *Run) SetNodes(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nodes = n
+}
+
+// Progress publishes a progress update to the run's event streams; slow
+// readers miss updates rather than slowing the run down. It is safe for
+// concurrent use, as TestRunner.SetProgress needs.
+func (r *Run) Progress(progress models.TestProgress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if progress.Stage == "done" {
+		r.done++
+	}
+	for ch := range r.subscribers {
+		select {
+		case ch <- event{Name: "progress", Data: progress}:
+		default:
+		}
+	}
+	r.server.publish(event{Name: "progress", Data: runProgress{Run: r.id, TestProgress: progress}})
+}
+
+// Finish records the run's results, or why it failed, and ends its event
+// streams
+func (r *Run) Finish(results []*models.TestResult, err error) {
+	r.mu.Lock()
+	if !r.finished.IsZero() {
+		r.mu.Unlock()
+		return
+	}
+	r.finished = time.Now()
+	r.results = results
+	r.err = err
+	close(r.closed)
+	r.mu.Unlock()
+
+	r.server.publish(event{Name: "done", Data: r.status()})
+}
+
+// status returns the run's current state
+func (r *Run) status() runStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := runStatus{
+		ID:        r.id,
+		Trigger:   r.trigger,
+		State:     "queued",
+		Priority:  r.request.Priority,
+		Requested: r.request.Nodes,
+		Queued:    r.queued,
+		Nodes:     r.nodes,
+		Done:      r.done,
+	}
+	if !r.started.IsZero() {
+		started := r.started
+		status.Started = &started
+		status.State = "running"
+	}
+	if !r.finished.IsZero() {
+		finished := r.finished
+		status.Finished = &finished
+		status.State = "finished"
+	}
+	if r.err != nil {
+		status.State = "failed"
+		status.Error = r.err.Error()
+	}
+	for _, result := range r.results {
+		if result != nil && result.Success {
+			status.Working++
+		}
+	}
+	return status
+}
+
+// subscribe returns a channel of the run's progress updates, and the
+// channel closed when the run finishes
+func (r *Run) subscribe() (chan event, <-chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ch := make(chan event, 64)
+	r.subscribers[ch] = struct{}{}
+	return ch, r.closed
+}
+
+func (r *Run) unsubscribe(ch chan event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.subscribers, ch)
+}
+
+// StartRun registers a run that starts now; trigger is schedule or api.
+// The caller reports its progress and calls Finish.
+func (s *Server) StartRun(trigger string) *Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run := s.newRun(trigger)
+	run.started = run.queued
+	s.publish(event{Name: "started", Data: run.status()})
+	return run
+}
+
+// newRun registers a run that has not started yet; s.mu must be held
+func (s *Server) newRun(trigger string) *Run {
+	s.runSeq++
+	run := &Run{
+		id:          strconv.Itoa(s.runSeq),
+		trigger:     trigger,
+		queued:      time.Now(),
+		seq:         s.runSeq,
+		server:      s,
+		subscribers: make(map[chan event]struct{}),
+		closed:      make(chan struct{}),
+	}
+
+	// Drop the oldest finished runs beyond the history
+	s.runs = append(s.runs, run)
+	for len(s.runs) > runHistory {
+		oldest := -1
+		for i, old := range s.runs {
+			if old.status().Finished != nil {
+				oldest = i
+				break
+			}
+		}
+		if oldest < 0 {
+			break
+		}
+		s.runs = append(s.runs[:oldest], s.runs[oldest+1:]...)
+	}
+	return run
+}
+
+// SetRunHandler lets admins request runs with POST /runs. Requests wait
+// in a queue of up to maxQueued, highest priority first, and handler runs
+// up to maxConcurrent of them at once besides the scheduled run, each in
+// its own goroutine, reporting to the run.
+func (s *Server) SetRunHandler(handler func(*Run), maxConcurrent, maxQueued int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runHandler = handler
+	s.maxAPIRuns = maxConcurrent
+	s.maxQueued = maxQueued
+}
+
+// run returns the run with the given id
+func (s *Server) run(id string) (*Run, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, run := range s.runs {
+		if run.id == id {
+			return run, true
+		}
+	}
+	return nil, false
+}
+
+// handleStartRun queues a run. A request for the same nodes as a queued
+// one joins it instead, raising its priority if needed.
+func (s *Server) handleStartRun(w http.ResponseWriter, r *http.Request) {
+	var request RunRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&request); err != nil && err != io.EOF {
+		writeClashError(w, http.StatusBadRequest, "Invalid body: "+err.Error())
+		return
+	}
+	if err := request.validate(); err != nil {
+		writeClashError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.runHandler == nil {
+		writeClashError(w, http.StatusNotImplemented, "Runs can't be started on this server")
+		return
+	}
+
+	key := request.key()
+	for _, queued := range s.queue {
+		queued.mu.Lock()
+		same := queued.request.key() == key
+		if same && request.Priority > queued.request.Priority {
+			queued.request.Priority = request.Priority
+		}
+		queued.mu.Unlock()
+		if same {
+			w.Header().Set("Location", "/runs/"+queued.id)
+			writeJSON(w, http.StatusOK, queued.status())
+			return
+		}
+	}
+
+	if len(s.queue) >= s.maxQueued && s.apiRuns >= s.maxAPIRuns {
+		writeClashError(w, http.StatusTooManyRequests, fmt.Sprintf("%d runs in flight and %d queued", s.apiRuns, len(s.queue)))
+		return
+	}
+
+	run := s.newRun("api")
+	run.request = request
+	s.queue = append(s.queue, run)
+	s.dispatch()
+
+	w.Header().Set("Location", "/runs/"+run.id)
+	writeJSON(w, http.StatusAccepted, run.status())
+}
+
+// dispatch starts queued runs, highest priority and then oldest first,
+// while fewer than the maximum are in flight; s.mu must be held
+func (s *Server) dispatch() {
+	for s.apiRuns < s.maxAPIRuns && len(s.queue) > 0 {
+		next := 0
+		for i, run := range s.queue {
+			if run.priority() > s.queue[next].priority() ||
+				(run.priority() == s.queue[next].priority() && run.seq < s.queue[next].seq) {
+				next = i
+			}
+		}
+		run := s.queue[next]
+		s.queue = append(s.queue[:next], s.queue[next+1:]...)
+		s.apiRuns++
+
+		run.mu.Lock()
+		run.started = time.Now()
+		run.mu.Unlock()
+		s.publish(event{Name: "started", Data: run.status()})
+
+		handler := s.runHandler
+		go func() {
+			handler(run)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.apiRuns--
+			s.dispatch()
+		}()
+	}
+}
+
+// priority returns the run's requested priority
+func (r *Run) priority() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.request.Priority
+}
+
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	runs := append([]*Run(nil), s.runs...)
+	s.mu.RUnlock()
+
+	statuses := make([]runStatus, 0, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		statuses = append(statuses, s.publicStatus(runs[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"runs": statuses})
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	run, ok := s.run(r.PathValue("id"))
+	if !ok {
+		writeClashError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.publicStatus(run))
+}
+
+// publicStatus is the run's status as the run routes serve it. Without
+// tokens anyone can read them, so the requested node names are left out.
+func (s *Server) publicStatus(run *Run) runStatus {
+	status := run.status()
+	if len(s.tokens) == 0 {
+		status.Requested = nil
+	}
+	return status
+}
+
+// handleRunResults serves the results of a finished run
+func (s *Server) handleRunResults(w http.ResponseWriter, r *http.Request) {
+	run, ok := s.run(r.PathValue("id"))
+	if !ok {
+		writeClashError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	run.mu.Lock()
+	results, finished := run.results, !run.finished.IsZero()
+	run.mu.Unlock()
+	if !finished {
+		writeClashError(w, http.StatusConflict, "Run still in progress")
+		return
+	}
+	if results == nil {
+		results = []*models.TestResult{}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// handleRunEvents streams a run's progress as server-sent events or
+// WebSocket messages: a progress event per update and a done event with the
+// final status
+func (s *Server) handleRunEvents(w http.ResponseWriter, r *http.Request) {
+	run, ok := s.run(r.PathValue("id"))
+	if !ok {
+		writeClashError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	updates, closed := run.subscribe()
+	defer run.unsubscribe(updates)
+
+	s.streamEvents(w, r, func(ctx context.Context, send func(event) error) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-updates:
+				if send(e) != nil {
+					return
+				}
+			case <-closed:
+				// Updates sent before Finish may still be buffered
+				for len(updates) > 0 {
+					if send(<-updates) != nil {
+						return
+					}
+				}
+				send(event{Name: "done", Data: run.status()})
+				return
+			}
+		}
+	})
+}
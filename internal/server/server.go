@@ -0,0 +1,150 @@
+// Package server serves the results of the latest daemon run over HTTP,
+// e.g. as a subscription client apps can point at directly
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Server holds the latest results and serves them over HTTP
+type Server struct {
+	mu      sync.RWMutex
+	results []*models.TestResult
+	updated time.Time
+	// heartbeatDue is when the daemon loop is expected to check in next;
+	// zero until the first Heartbeat
+	heartbeatDue time.Time
+	interval     time.Duration
+
+	tokens      []models.APIToken
+	tlsConfig   *tls.Config
+	corsOrigins []string
+
+	runs       []*Run
+	runSeq     int
+	runHandler func(*Run)
+	maxAPIRuns int
+	apiRuns    int // requested through POST /runs and in flight
+	queue      []*Run
+	maxQueued  int
+
+	eventsMu sync.Mutex
+	watchers map[chan event]struct{} // /events streams
+}
+
+// New creates a server with no results yet
+func New() *Server {
+	return &Server{}
+}
+
+// Update replaces the served results with those of a finished run
+func (s *Server) Update(results []*models.TestResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = results
+	s.updated = time.Now()
+}
+
+// latest returns the current results and when they were produced
+func (s *Server) latest() ([]*models.TestResult, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.results, s.updated
+}
+
+// Handler returns the HTTP handler with all routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sub/working", s.handleWorkingSubscription)
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.HandleFunc("GET /runs", s.handleRuns)
+	mux.HandleFunc("POST /runs", s.handleStartRun)
+	mux.HandleFunc("GET /runs/{id}", s.handleRun)
+	mux.HandleFunc("GET /runs/{id}/results", s.handleRunResults)
+	mux.HandleFunc("GET /runs/{id}/events", s.handleRunEvents)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /settings", s.handleSettings)
+	mux.HandleFunc("PATCH /settings", s.handlePatchSettings)
+	s.registerClashAPI(mux)
+	return s.allowCORS(s.authorize(mux))
+}
+
+// SetCORSOrigins lets browser dashboards served from origins, such as
+// https://yacd.haishan.me, call the API; "*" allows any. Without origins
+// web pages can't read the server's answers.
+func (s *Server) SetCORSOrigins(origins []string) {
+	s.corsOrigins = origins
+}
+
+// allowedOrigin reports whether origin is one of the CORS origins
+func (s *Server) allowedOrigin(origin string) bool {
+	for _, allowed := range s.corsOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowCORS lets browser dashboards served from the allowed origins call
+// the API
+func (s *Server) allowCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.corsOrigins) > 0 {
+			w.Header().Add("Vary", "Origin")
+		}
+		origin := r.Header.Get("Origin")
+		if origin == "" || !s.allowedOrigin(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ListenAndServe serves on addr until ctx is cancelled, over HTTPS after
+// SetTLS
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+
+	httpServer := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
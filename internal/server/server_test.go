@@ -0,0 +1,491 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/VenoMexx/ProtoScope/internal/clashapi"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func node(raw string, success bool, speed float64, country string) *models.TestResult {
+	return &models.TestResult{
+		Protocol:    &models.Protocol{Type: models.ProtocolTrojan, Name: raw, Raw: raw},
+		Success:     success,
+		Performance: &models.PerformanceResult{DownloadSpeed: speed},
+		Privacy:     &models.PrivacyResult{ExitCountry: country, Score: 100},
+	}
+}
+
+// readToken is the read token get sends
+var readToken = models.APIToken{Name: "client", Token: "r3ad", Scope: models.ScopeRead}
+
+func get(t *testing.T, s *Server, target string) (int, []string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", target, nil)
+	req.Header.Set("Authorization", "Bearer "+readToken.Token)
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(rec.Body.String())
+	if err != nil {
+		t.Fatalf("body is not base64: %v", err)
+	}
+	return rec.Code, strings.Split(string(decoded), "\n")
+}
+
+func TestWorkingSubscription(t *testing.T) {
+	s := New()
+	s.SetTokens([]models.APIToken{readToken})
+
+	if code, _ := get(t, s, "/sub/working"); code != http.StatusServiceUnavailable {
+		t.Errorf("before first run: status %d, want 503", code)
+	}
+
+	s.Update([]*models.TestResult{
+		node("trojan://a", true, 50, "NL"),
+		node("trojan://b", false, 0, ""),
+		node("trojan://c", true, 5, "DE"),
+		node("trojan://d", true, 80, "DE"),
+	})
+
+	tests := []struct {
+		target string
+		code   int
+		links  int
+	}{
+		{"/sub/working", http.StatusOK, 3},
+		{"/sub/working?minSpeed=10", http.StatusOK, 2},
+		{"/sub/working?country=de", http.StatusOK, 2},
+		{"/sub/working?country=de&minSpeed=10", http.StatusOK, 1},
+		{"/sub/working?country=NL,DE", http.StatusOK, 3},
+		{"/sub/working?country=US", http.StatusNotFound, 0},
+		{"/sub/working?minSpeed=fast", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		code, links := get(t, s, tt.target)
+		if code != tt.code || len(links) != tt.links {
+			t.Errorf("%s: status %d with %d links, want %d with %d", tt.target, code, len(links), tt.code, tt.links)
+		}
+		for _, link := range links {
+			if link == "trojan://b" {
+				t.Errorf("%s: failed node served", tt.target)
+			}
+		}
+	}
+}
+
+// TestClashAPI reads the Clash-compatible API with the Clash client, as a
+// dashboard would
+func TestClashAPI(t *testing.T) {
+	s := New()
+	s.SetTokens([]models.APIToken{readToken})
+	s.Update([]*models.TestResult{
+		node("trojan://slow", true, 5, "DE"),
+		node("trojan://dead", false, 0, ""),
+		node("trojan://fast", true, 80, "NL"),
+	})
+	s.results[0].Connectivity = &models.ConnectivityResult{Connected: true, ResponseTime: 900 * time.Millisecond}
+	s.results[2].Connectivity = &models.ConnectivityResult{Connected: true, ResponseTime: 40 * time.Millisecond}
+
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+	ctx := context.Background()
+	client := clashapi.NewClient(srv.URL, readToken.Token)
+
+	nodes, err := client.Nodes(ctx, "ProtoScope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "trojan://fast" || nodes[0].Type != "Trojan" {
+		t.Fatalf("ProtoScope group = %+v, want working nodes best first", nodes)
+	}
+
+	all, err := client.Nodes(ctx, "GLOBAL")
+	if err != nil || len(all) != 3 {
+		t.Fatalf("GLOBAL group has %d nodes (%v), want 3", len(all), err)
+	}
+
+	delay, err := client.Delay(ctx, "trojan://fast", "http://example.com", time.Second)
+	if err != nil || delay != 40*time.Millisecond {
+		t.Errorf("delay = %v, %v", delay, err)
+	}
+	if _, err := client.Delay(ctx, "trojan://dead", "http://example.com", time.Second); err == nil {
+		t.Error("expected a delay error for a failed node")
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	s := New()
+	status := func(target string) int {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec.Code
+	}
+
+	if code := status("/healthz"); code != http.StatusOK {
+		t.Errorf("healthz before first heartbeat: %d, want 200", code)
+	}
+	if code := status("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("readyz before first run: %d, want 503", code)
+	}
+
+	s.Heartbeat(time.Minute)
+	s.Update([]*models.TestResult{node("trojan://a", true, 50, "NL")})
+	if code := status("/healthz"); code != http.StatusOK {
+		t.Errorf("healthz: %d, want 200", code)
+	}
+	if code := status("/readyz"); code != http.StatusOK {
+		t.Errorf("readyz after first run: %d, want 200", code)
+	}
+
+	s.Heartbeat(-time.Second)
+	if code := status("/healthz"); code != http.StatusServiceUnavailable {
+		t.Errorf("healthz after missed heartbeat: %d, want 503", code)
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	s := New()
+	s.SetInterval(time.Hour)
+	s.SetRunHandler(func(run *Run) { run.Finish(nil, nil) }, 1, 0)
+	do := func(method, target, token, body string) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do("GET", "/settings", "", ""); code != http.StatusOK {
+		t.Errorf("read without tokens configured: %d, want 200", code)
+	}
+	if code := do("POST", "/runs", "", ""); code != http.StatusForbidden {
+		t.Errorf("run without tokens configured: %d, want 403", code)
+	}
+	// Node links carry credentials and need a read token, as do node names
+	for _, target := range []string{"/sub/working", "/proxies", "/proxies/x/delay", "/metrics", "/events", "/runs/1/results", "/runs/1/events"} {
+		if code := do("GET", target, "", ""); code != http.StatusForbidden {
+			t.Errorf("%s without tokens configured: %d, want 403", target, code)
+		}
+	}
+
+	s.SetTokens([]models.APIToken{
+		{Name: "dashboard", Token: "r3ad", Scope: models.ScopeRead},
+		{Name: "ops", Token: "adm1n", Scope: models.ScopeAdmin},
+	})
+	tests := []struct {
+		method, target, token, body string
+		code                        int
+	}{
+		{"GET", "/healthz", "", "", http.StatusOK},
+		{"GET", "/proxies", "", "", http.StatusUnauthorized},
+		{"GET", "/proxies", "wrong", "", http.StatusUnauthorized},
+		{"GET", "/proxies", "r3ad", "", http.StatusOK},
+		{"GET", "/sub/working?token=r3ad", "", "", http.StatusServiceUnavailable},
+		{"POST", "/runs", "r3ad", "", http.StatusForbidden},
+		{"POST", "/runs", "adm1n", "", http.StatusAccepted},
+		{"PATCH", "/settings", "r3ad", `{"interval":"30m"}`, http.StatusForbidden},
+		{"PATCH", "/settings", "adm1n", `{"interval":"-1m"}`, http.StatusBadRequest},
+		{"PATCH", "/settings", "adm1n", `{"interval":"30m"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if code := do(tt.method, tt.target, tt.token, tt.body); code != tt.code {
+			t.Errorf("%s %s with token %q: %d, want %d", tt.method, tt.target, tt.token, code, tt.code)
+		}
+	}
+
+	if len(s.runs) != 1 || s.runs[0].trigger != "api" {
+		t.Errorf("runs = %v, want the admin's run", s.runs)
+	}
+	if s.Interval() != 30*time.Minute {
+		t.Errorf("interval = %s, want 30m", s.Interval())
+	}
+}
+
+// TestRunsHideRequestedNodes leaves the nodes a run was requested for out
+// of the run states anyone can read without tokens
+func TestRunsHideRequestedNodes(t *testing.T) {
+	s := New()
+	s.SetTokens([]models.APIToken{{Name: "ops", Token: "adm1n", Scope: models.ScopeAdmin}})
+	s.SetRunHandler(func(run *Run) { run.Finish(nil, nil) }, 1, 0)
+	get := func(method, target, token, body string) string {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	get("POST", "/runs", "adm1n", `{"nodes":["DE secret"]}`)
+	if body := get("GET", "/runs", "adm1n", ""); !strings.Contains(body, "DE secret") {
+		t.Errorf("/runs with a token lacks the requested nodes: %s", body)
+	}
+	s.SetTokens(nil)
+	for _, target := range []string{"/runs", "/runs/" + s.runs[0].ID()} {
+		if body := get("GET", target, "", ""); strings.Contains(body, "DE secret") {
+			t.Errorf("%s without tokens configured reveals the requested nodes: %s", target, body)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	s := New()
+	s.SetInterval(time.Hour)
+	allowOrigin := func(method, origin string) string {
+		req := httptest.NewRequest(method, "/settings", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := allowOrigin("GET", "https://evil.example"); got != "" {
+		t.Errorf("no origins allowed, but Access-Control-Allow-Origin is %q", got)
+	}
+
+	s.SetCORSOrigins([]string{"https://yacd.example/"})
+	if got := allowOrigin("GET", "https://yacd.example"); got != "https://yacd.example" {
+		t.Errorf("allowed origin: Access-Control-Allow-Origin is %q", got)
+	}
+	if got := allowOrigin("OPTIONS", "https://yacd.example"); got != "https://yacd.example" {
+		t.Errorf("preflight: Access-Control-Allow-Origin is %q", got)
+	}
+	if got := allowOrigin("GET", "https://evil.example"); got != "" {
+		t.Errorf("other origin: Access-Control-Allow-Origin is %q", got)
+	}
+}
+
+// TestRuns starts a run beside a scheduled one and follows its own
+// progress stream
+func TestRuns(t *testing.T) {
+	s := New()
+	scheduled := s.StartRun("schedule")
+
+	release := make(chan struct{})
+	s.SetRunHandler(func(run *Run) {
+		<-release
+		run.SetNodes(1)
+		run.Progress(models.TestProgress{Stage: "connectivity", StageIndex: 1, StageCount: 1})
+		run.Progress(models.TestProgress{Stage: "done", StageIndex: 1, StageCount: 1, Percent: 100})
+		run.Finish([]*models.TestResult{node("trojan://a", true, 50, "NL")}, nil)
+	}, 1, 0)
+
+	s.SetTokens([]models.APIToken{{Name: "ops", Token: "adm1n", Scope: models.ScopeAdmin}})
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+	do := func(method, path string) (*http.Response, error) {
+		req, _ := http.NewRequest(method, srv.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer adm1n")
+		return http.DefaultClient.Do(req)
+	}
+
+	resp, err := do("POST", "/runs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	location := resp.Header.Get("Location")
+	if resp.StatusCode != http.StatusAccepted || location == "" {
+		t.Fatalf("POST /runs: %s, location %q", resp.Status, location)
+	}
+	if resp, err := do("POST", "/runs"); err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("second run with no queue: %v, %v", resp.Status, err)
+	}
+
+	events, err := do("GET", location+"/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer events.Body.Close()
+	close(release)
+
+	body, err := io.ReadAll(events.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := string(body)
+	if strings.Count(stream, "event: progress") != 2 || !strings.Contains(stream, `"state":"finished"`) || !strings.Contains(stream, `"working":1`) {
+		t.Errorf("event stream:\n%s", stream)
+	}
+
+	if status := scheduled.status(); status.State != "running" || status.Done != 0 {
+		t.Errorf("scheduled run disturbed: %+v", status)
+	}
+	if resp, err := do("GET", location+"/results"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("results: %v, %v", resp.Status, err)
+	}
+}
+
+// TestRunQueue queues requests beyond the concurrency limit, joins
+// identical ones and starts the highest priority first
+func TestRunQueue(t *testing.T) {
+	s := New()
+	s.SetTokens([]models.APIToken{{Name: "ops", Token: "adm1n", Scope: models.ScopeAdmin}})
+
+	release := make(chan struct{})
+	started := make(chan RunRequest, 10)
+	s.SetRunHandler(func(run *Run) {
+		started <- run.Request()
+		<-release
+		run.Finish(nil, nil)
+	}, 1, 3)
+
+	post := func(body string) (int, runStatus) {
+		req := httptest.NewRequest("POST", "/runs", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer adm1n")
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		var status runStatus
+		json.Unmarshal(rec.Body.Bytes(), &status)
+		return rec.Code, status
+	}
+
+	if code, status := post(""); code != http.StatusAccepted || status.State != "running" {
+		t.Fatalf("first run: %d %+v", code, status)
+	}
+	<-started
+
+	_, low := post(`{"nodes":["a","b"]}`)
+	post(`{"priority":5,"nodes":["c"]}`)
+	code, joined := post(`{"priority":9,"nodes":["b","a","a"]}`)
+	if code != http.StatusOK || joined.ID != low.ID || joined.Priority != 9 || joined.State != "queued" {
+		t.Errorf("identical request: %d %+v, want to join run %s", code, joined, low.ID)
+	}
+	post(`{"nodes":["d"]}`)
+	if code, _ := post(`{"nodes":["e"]}`); code != http.StatusTooManyRequests {
+		t.Errorf("request beyond the queue: %d, want 429", code)
+	}
+	if code, _ := post(`{"nodes":`); code != http.StatusBadRequest {
+		t.Errorf("invalid body: %d, want 400", code)
+	}
+	if code, _ := post(`{"url":"file:///etc/passwd"}`); code != http.StatusBadRequest {
+		t.Errorf("subscription url that is not http: %d, want 400", code)
+	}
+
+	var order []string
+	for i := 0; i < 3; i++ {
+		release <- struct{}{}
+		order = append(order, strings.Join((<-started).Nodes, ","))
+	}
+	close(release)
+	if got := strings.Join(order, " "); got != "a,b c d" {
+		t.Errorf("start order %q, want by priority then age", got)
+	}
+}
+
+// TestEventStreams follows a scheduled run over WebSockets, on /events
+// with the read token in the query as browsers send it, and on the run's
+// own stream
+func TestEventStreams(t *testing.T) {
+	s := New()
+	s.SetTokens([]models.APIToken{{Name: "grafana", Token: "r3ad", Scope: models.ScopeRead}})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	if _, err := websocket.Dial(wsURL+"/events", "", ts.URL); err == nil {
+		t.Error("stream opened without a token")
+	}
+	if _, err := websocket.Dial(wsURL+"/events?token=r3ad", "", "https://evil.example"); err == nil {
+		t.Error("stream opened from another origin")
+	}
+	all, err := websocket.Dial(wsURL+"/events?token=r3ad", "", ts.URL)
+	if err != nil {
+		t.Fatalf("dial /events: %v", err)
+	}
+	defer all.Close()
+
+	run := s.StartRun("schedule")
+	config, _ := websocket.NewConfig(wsURL+"/runs/"+run.ID()+"/events", ts.URL)
+	config.Header.Set("Authorization", "Bearer r3ad")
+	one, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("dial run events: %v", err)
+	}
+	defer one.Close()
+
+	run.Progress(models.TestProgress{Stage: "connectivity", StageIndex: 1, StageCount: 2, Percent: 50})
+	run.Finish(nil, nil)
+
+	type message struct {
+		Event string `json:"event"`
+		Data  struct {
+			ID    string `json:"id"`
+			Run   string `json:"run"`
+			Stage string `json:"stage"`
+			State string `json:"state"`
+		} `json:"data"`
+	}
+	read := func(conn *websocket.Conn, n int) string {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var got []string
+		for i := 0; i < n; i++ {
+			var msg message
+			if err := websocket.JSON.Receive(conn, &msg); err != nil {
+				t.Fatalf("receive: %v", err)
+			}
+			got = append(got, msg.Event+":"+msg.Data.ID+msg.Data.Run+":"+msg.Data.Stage+msg.Data.State)
+		}
+		return strings.Join(got, " ")
+	}
+
+	if got := read(all, 3); got != "started:1:running progress:1:connectivity done:1:finished" {
+		t.Errorf("/events: %s", got)
+	}
+	if got := read(one, 2); got != "progress::connectivity done:1:finished" {
+		t.Errorf("run events: %s", got)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	up := node("trojan://a", true, 42.5, "NL")
+	up.Protocol.Name, up.Protocol.Server = `NL "fast"`, "a.example"
+	up.Connectivity = &models.ConnectivityResult{ResponseTime: 120 * time.Millisecond}
+	down := node("trojan://b", false, 0, "")
+	down.Protocol.Server = "b.example"
+	down.Performance, down.Privacy = nil, nil
+	s := New()
+	s.SetTokens([]models.APIToken{{Name: "prometheus", Token: "r3ad", Scope: models.ScopeRead}})
+	s.Update([]*models.TestResult{up, down, up})
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics?token=r3ad", nil))
+	body := rec.Body.String()
+
+	labels := func(result *models.TestResult, name string) string {
+		return `{fingerprint="` + result.Protocol.Fingerprint() + `",name="` + name + `",type="trojan"}`
+	}
+	for _, want := range []string{
+		"# TYPE protoscope_node_up gauge\n",
+		"protoscope_nodes 2\n",
+		"protoscope_node_up" + labels(up, `NL \"fast\"`) + " 1\n",
+		"protoscope_node_up" + labels(down, "trojan://b") + " 0\n",
+		"protoscope_node_latency_ms" + labels(up, `NL \"fast\"`) + " 120\n",
+		"protoscope_node_download_mbps" + labels(up, `NL \"fast\"`) + " 42.5\n",
+		"protoscope_node_security_score" + labels(up, `NL \"fast\"`) + " 100\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "protoscope_node_latency_ms"+labels(down, "trojan://b")) {
+		t.Error("latency reported for a failed node")
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/VenoMexx/ProtoScope/internal/export"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// handleWorkingSubscription serves GET /sub/working: a base64 subscription
+// of the nodes that passed the latest run, best first. Query parameters:
+//
+//	minSpeed  minimum download speed in Mbps
+//	country   comma-separated exit country codes
+//
+// Errors are returned as non-200 responses so clients keep their previous
+// node list instead of replacing it with an empty one.
+func (s *Server) handleWorkingSubscription(w http.ResponseWriter, r *http.Request) {
+	results, updated := s.latest()
+	if updated.IsZero() {
+		http.Error(w, "no test run has finished yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	filter, err := parseSubscriptionFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := export.Subscription(filter.apply(results))
+	if err != nil {
+		http.Error(w, "no working nodes match", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Last-Modified", updated.UTC().Format(http.TimeFormat))
+	w.Write(data)
+}
+
+// subscriptionFilter selects nodes for a served subscription
+type subscriptionFilter struct {
+	minSpeed  float64
+	countries map[string]bool
+}
+
+// parseSubscriptionFilter reads the filter from query parameters
+func parseSubscriptionFilter(query url.Values) (subscriptionFilter, error) {
+	var filter subscriptionFilter
+
+	if value := query.Get("minSpeed"); value != "" {
+		minSpeed, err := strconv.ParseFloat(value, 64)
+		if err != nil || minSpeed < 0 {
+			return filter, fmt.Errorf("invalid minSpeed: %q", value)
+		}
+		filter.minSpeed = minSpeed
+	}
+
+	if value := query.Get("country"); value != "" {
+		filter.countries = make(map[string]bool)
+		for _, country := range strings.Split(value, ",") {
+			if country = strings.TrimSpace(country); country != "" {
+				filter.countries[strings.ToUpper(country)] = true
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+// apply returns the working results that pass the filter. Nodes without a
+// speed or country measurement don't match a filter on it.
+func (f subscriptionFilter) apply(results []*models.TestResult) []*models.TestResult {
+	var matched []*models.TestResult
+
+	for _, result := range results {
+		if result == nil || !result.Success {
+			continue
+		}
+		if f.minSpeed > 0 && (result.Performance == nil || result.Performance.DownloadSpeed < f.minSpeed) {
+			continue
+		}
+		if f.countries != nil && (result.Privacy == nil || !f.countries[result.Privacy.ExitCountry]) {
+			continue
+		}
+		matched = append(matched, result)
+	}
+
+	return matched
+}
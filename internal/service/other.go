@@ -0,0 +1,15 @@
+//go:build !linux && !windows
+
+package service
+
+import "fmt"
+
+// Install is only implemented for systemd and Windows
+func Install(cfg Config) error {
+	return fmt.Errorf("service install is only supported on Linux (systemd) and Windows")
+}
+
+// Uninstall is only implemented for systemd and Windows
+func Uninstall(name string) error {
+	return fmt.Errorf("service uninstall is only supported on Linux (systemd) and Windows")
+}
@@ -0,0 +1,27 @@
+// Package service installs protoscope as a system service running daemon
+// mode: a systemd unit on Linux, a Windows service on Windows
+package service
+
+import "fmt"
+
+// Config describes the service to install
+type Config struct {
+	Name        string
+	Description string
+	Executable  string   // absolute path of the protoscope binary
+	Args        []string // arguments, starting with the daemon subcommand
+	// LogFile, when set, is where the daemon writes its output; the daemon
+	// rotates it itself (see the daemon's -log-* flags)
+	LogFile string
+}
+
+// Validate checks that the config can be installed
+func (c Config) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("service name is empty")
+	}
+	if c.Executable == "" {
+		return fmt.Errorf("executable path is empty")
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+//go:build linux
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// unitDir is where unit files are installed
+const unitDir = "/etc/systemd/system"
+
+// Install writes a systemd unit, enables it and starts it. Needs root.
+func Install(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	path := unitPath(cfg.Name)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists; uninstall first", path)
+	}
+	// Only root may read the unit: its command line can hold a
+	// subscription URL with the token
+	if err := os.WriteFile(path, []byte(systemdUnit(cfg)), 0600); err != nil {
+		return err
+	}
+
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", "--now", cfg.Name+".service")
+}
+
+// Uninstall stops and disables the unit and removes its file. Needs root.
+func Uninstall(name string) error {
+	path := unitPath(name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("service %s is not installed: %w", name, err)
+	}
+
+	if err := systemctl("disable", "--now", name+".service"); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return systemctl("daemon-reload")
+}
+
+func unitPath(name string) string {
+	return filepath.Join(unitDir, name+".service")
+}
+
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// systemdUnit renders the unit file. Output goes to the journal, which
+// rotates it, unless the daemon writes its own rotated log file.
+func systemdUnit(cfg Config) string {
+	description := cfg.Description
+	if description == "" {
+		description = "ProtoScope proxy tester"
+	}
+
+	command := []string{systemdQuote(cfg.Executable)}
+	for _, arg := range cfg.Args {
+		command = append(command, systemdQuote(arg))
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", description)
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("After=network-online.target\n\n")
+
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=simple\n")
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(command, " "))
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=10\n")
+	if cfg.LogFile != "" {
+		// The daemon writes and rotates the log file itself
+		b.WriteString("StandardOutput=null\n")
+		b.WriteString("StandardError=journal\n")
+	} else {
+		b.WriteString("StandardOutput=journal\n")
+		b.WriteString("StandardError=journal\n")
+		b.WriteString("LogRateLimitIntervalSec=30s\n")
+		b.WriteString("LogRateLimitBurst=1000\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// systemdQuote quotes an ExecStart argument when needed. systemd also
+// expands % specifiers and $ variables, so those are escaped.
+func systemdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	arg = strings.ReplaceAll(arg, "$", "$$")
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	arg = strings.ReplaceAll(arg, `"`, `\"`)
+	return `"` + arg + `"`
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSystemdUnit(t *testing.T) {
+	unit := systemdUnit(Config{
+		Name:       "protoscope",
+		Executable: "/usr/local/bin/protoscope",
+		Args:       []string{"daemon", "-config", "/etc/proto scope/config.yaml", "-sub", "https://x/?a=1%20$HOME"},
+	})
+
+	want := `ExecStart=/usr/local/bin/protoscope daemon -config "/etc/proto scope/config.yaml" -sub https://x/?a=1%%20$$HOME`
+	if !strings.Contains(unit, want+"\n") {
+		t.Errorf("unit has no %q:\n%s", want, unit)
+	}
+	if !strings.Contains(unit, "StandardOutput=journal") {
+		t.Error("output should go to the journal without a log file")
+	}
+
+	unit = systemdUnit(Config{Name: "p", Executable: "/bin/p", LogFile: "/var/log/p.log"})
+	if !strings.Contains(unit, "StandardOutput=null") {
+		t.Error("stdout should be discarded when the daemon writes its own log file")
+	}
+}
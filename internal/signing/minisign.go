@@ -0,0 +1,209 @@
+// Package signing signs and verifies result files in the minisign format,
+// so signatures can be checked with minisign itself as well as with
+// protoscope verify
+package signing
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// algHashed signs the BLAKE2b-512 hash of the file, the minisign default
+	algHashed = "ED"
+	// algLegacy signs the file itself; older minisign versions wrote it
+	algLegacy = "Ed"
+
+	untrustedPrefix = "untrusted comment: "
+	trustedPrefix   = "trusted comment: "
+)
+
+// PublicKey is a minisign public key
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// SecretKey is a decrypted minisign secret key
+type SecretKey struct {
+	ID  [8]byte
+	Key ed25519.PrivateKey
+}
+
+// KeyID returns the key ID the way minisign prints it
+func (k *PublicKey) KeyID() string {
+	return keyID(k.ID)
+}
+
+// keyID formats an ID as minisign does: the little-endian integer in hex
+func keyID(id [8]byte) string {
+	return strings.ToUpper(fmt.Sprintf("%016x", binary.LittleEndian.Uint64(id[:])))
+}
+
+// ParsePublicKey reads a public key from the contents of a minisign .pub
+// file or from the bare base64 line
+func ParsePublicKey(text string) (*PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(lastLine(text))
+	if err != nil || len(data) != 42 || string(data[:2]) != algLegacy {
+		return nil, errors.New("not a minisign public key")
+	}
+	key := &PublicKey{Key: ed25519.PublicKey(data[10:])}
+	copy(key.ID[:], data[2:10])
+	return key, nil
+}
+
+// ParseSecretKey reads a minisign secret key file. Keys are encrypted with
+// password unless they were generated with minisign -W.
+func ParseSecretKey(text, password string) (*SecretKey, error) {
+	data, err := base64.StdEncoding.DecodeString(lastLine(text))
+	if err != nil || len(data) != 158 || string(data[:2]) != algLegacy || string(data[4:6]) != "B2" {
+		return nil, errors.New("not a minisign secret key")
+	}
+	kdf := string(data[2:4])
+	salt := data[6:38]
+	opsLimit := binary.LittleEndian.Uint64(data[38:46])
+	memLimit := binary.LittleEndian.Uint64(data[46:54])
+	keyNum := bytes.Clone(data[54:])
+
+	switch kdf {
+	case "\x00\x00":
+	case "Sc":
+		if password == "" {
+			return nil, errors.New("secret key is encrypted and no password was given")
+		}
+		n, r, p := scryptParams(opsLimit, memLimit)
+		stream, err := scrypt.Key([]byte(password), salt, n, r, p, len(keyNum))
+		if err != nil {
+			return nil, err
+		}
+		subtle.XORBytes(keyNum, keyNum, stream)
+	default:
+		return nil, fmt.Errorf("unsupported key derivation %q", kdf)
+	}
+
+	key := &SecretKey{Key: ed25519.PrivateKey(keyNum[8:72])}
+	copy(key.ID[:], keyNum[:8])
+
+	checksum := blake2b.Sum256(append(append([]byte(algLegacy), keyNum[:8]...), keyNum[8:72]...))
+	if subtle.ConstantTimeCompare(checksum[:], keyNum[72:104]) != 1 {
+		return nil, errors.New("wrong password for secret key")
+	}
+	return key, nil
+}
+
+// scryptParams derives the scrypt cost parameters from libsodium's
+// opslimit and memlimit, as crypto_pwhash_scryptsalsa208sha256 does
+func scryptParams(opsLimit, memLimit uint64) (n, r, p int) {
+	opsLimit = max(opsLimit, 32768)
+	r = 8
+	var maxN uint64
+	if opsLimit < memLimit/32 {
+		p = 1
+		maxN = opsLimit / (uint64(r) * 4)
+	} else {
+		maxN = memLimit / (uint64(r) * 128)
+	}
+	logN := 1
+	for ; logN < 63; logN++ {
+		if uint64(1)<<logN > maxN/2 {
+			break
+		}
+	}
+	if opsLimit >= memLimit/32 {
+		maxRP := min((opsLimit/4)/(uint64(1)<<logN), 0x3fffffff)
+		p = int(maxRP) / r
+	}
+	return 1 << logN, r, p
+}
+
+// Sign returns a minisign signature of data. The trusted comment is signed
+// along with it and shown by verifiers.
+func Sign(key *SecretKey, data []byte, trustedComment string) []byte {
+	hash := blake2b.Sum512(data)
+	return signHash(key, hash[:], trustedComment)
+}
+
+// SignReader is Sign for data read from r, hashed as it is read, so large
+// files aren't held in memory
+func SignReader(key *SecretKey, r io.Reader, trustedComment string) ([]byte, error) {
+	hash, err := blake2b.New512(nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(hash, r); err != nil {
+		return nil, err
+	}
+	return signHash(key, hash.Sum(nil), trustedComment), nil
+}
+
+// signHash signs the BLAKE2b-512 hash of a file
+func signHash(key *SecretKey, hash []byte, trustedComment string) []byte {
+	signature := ed25519.Sign(key.Key, hash)
+	global := ed25519.Sign(key.Key, append(bytes.Clone(signature), trustedComment...))
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%ssignature from protoscope secret key %s\n", untrustedPrefix, keyID(key.ID))
+	fmt.Fprintln(&out, base64.StdEncoding.EncodeToString(append(append([]byte(algHashed), key.ID[:]...), signature...)))
+	fmt.Fprintf(&out, "%s%s\n", trustedPrefix, trustedComment)
+	fmt.Fprintln(&out, base64.StdEncoding.EncodeToString(global))
+	return out.Bytes()
+}
+
+// Verify checks a minisign signature of data and returns its trusted
+// comment
+func Verify(key *PublicKey, data, sig []byte) (string, error) {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(string(sig), "\r\n", "\n")), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], untrustedPrefix) || !strings.HasPrefix(lines[2], trustedPrefix) {
+		return "", errors.New("not a minisign signature")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(decoded) != 74 {
+		return "", errors.New("not a minisign signature")
+	}
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return "", errors.New("not a minisign signature")
+	}
+
+	var id [8]byte
+	copy(id[:], decoded[2:10])
+	if id != key.ID {
+		return "", fmt.Errorf("signed with key %s, not %s", keyID(id), key.KeyID())
+	}
+
+	signature := decoded[10:]
+	message := data
+	switch string(decoded[:2]) {
+	case algHashed:
+		hash := blake2b.Sum512(data)
+		message = hash[:]
+	case algLegacy:
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm %q", decoded[:2])
+	}
+	if !ed25519.Verify(key.Key, message, signature) {
+		return "", errors.New("signature does not match the file")
+	}
+
+	trustedComment := strings.TrimPrefix(lines[2], trustedPrefix)
+	if !ed25519.Verify(key.Key, append(bytes.Clone(signature), trustedComment...), global) {
+		return "", errors.New("trusted comment was modified")
+	}
+	return trustedComment, nil
+}
+
+// lastLine returns the key line of a key file, skipping the comment line
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
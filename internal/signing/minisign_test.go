@@ -0,0 +1,148 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/scrypt"
+)
+
+// secretKeyFile encodes a key the way minisign -G writes it, encrypted
+// with cheap scrypt parameters unless password is empty
+func secretKeyFile(t *testing.T, id [8]byte, key ed25519.PrivateKey, password string) string {
+	t.Helper()
+	checksum := blake2b.Sum256(append(append([]byte("Ed"), id[:]...), key...))
+	keyNum := append(append(append([]byte{}, id[:]...), key...), checksum[:]...)
+
+	kdf := []byte{0, 0}
+	salt := make([]byte, 32)
+	limits := make([]byte, 16)
+	if password != "" {
+		kdf = []byte("Sc")
+		rand.Read(salt)
+		binary.LittleEndian.PutUint64(limits[:8], 32768)
+		binary.LittleEndian.PutUint64(limits[8:], 1<<24)
+		n, r, p := scryptParams(32768, 1<<24)
+		stream, err := scrypt.Key([]byte(password), salt, n, r, p, len(keyNum))
+		if err != nil {
+			t.Fatal(err)
+		}
+		subtle.XORBytes(keyNum, keyNum, stream)
+	}
+
+	data := bytes.Join([][]byte{[]byte("Ed"), kdf, []byte("B2"), salt, limits, keyNum}, nil)
+	return "untrusted comment: minisign encrypted secret key\n" + base64.StdEncoding.EncodeToString(data) + "\n"
+}
+
+func TestSignVerify(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+	pubFile := "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), id[:]...), public...)) + "\n"
+
+	for _, password := range []string{"", "hunter2"} {
+		secret, err := ParseSecretKey(secretKeyFile(t, id, private, password), password)
+		if err != nil {
+			t.Fatalf("password %q: %v", password, err)
+		}
+		if password != "" {
+			if _, err := ParseSecretKey(secretKeyFile(t, id, private, password), "wrong"); err == nil {
+				t.Error("wrong password accepted")
+			}
+		}
+
+		pub, err := ParsePublicKey(pubFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pub.KeyID() != "0807060504030201" {
+			t.Errorf("key ID %s", pub.KeyID())
+		}
+
+		data := []byte(`[{"success": true}]`)
+		sig := Sign(secret, data, "timestamp:1\tfile:results.json")
+		comment, err := Verify(pub, data, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if comment != "timestamp:1\tfile:results.json" {
+			t.Errorf("trusted comment %q", comment)
+		}
+
+		streamed, err := SignReader(secret, bytes.NewReader(data), "timestamp:1\tfile:results.json")
+		if err != nil || !bytes.Equal(streamed, sig) {
+			t.Errorf("SignReader = %q, %v; want Sign's %q", streamed, err, sig)
+		}
+
+		if _, err := Verify(pub, []byte(`[{"success": false}]`), sig); err == nil {
+			t.Error("edited file verified")
+		}
+		forged := strings.Replace(string(sig), "file:results.json", "file:other.json", 1)
+		if _, err := Verify(pub, data, []byte(forged)); err == nil {
+			t.Error("edited trusted comment verified")
+		}
+	}
+}
+
+func TestScryptParams(t *testing.T) {
+	// minisign's defaults, OPSLIMIT_SENSITIVE and MEMLIMIT_SENSITIVE
+	n, r, p := scryptParams(33554432, 1073741824)
+	if n != 1<<20 || r != 8 || p != 1 {
+		t.Errorf("got N=%d r=%d p=%d, want N=2^20 r=8 p=1", n, r, p)
+	}
+}
+
+// TestVerifyMinisignVectors checks signatures made by minisign itself, from
+// the test suite of its author's Go implementation
+func TestVerifyMinisignVectors(t *testing.T) {
+	pub, err := ParsePublicKey("untrusted comment: minisign public key E7620F1842B4E81F\nRWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.KeyID() != "E7620F1842B4E81F" {
+		t.Errorf("key ID %s, want E7620F1842B4E81F", pub.KeyID())
+	}
+
+	tests := map[string]struct {
+		sig     string
+		comment string
+	}{
+		"legacy": {
+			sig: "untrusted comment: signature from minisign secret key\n" +
+				"RWQf6LRCGA9i59SLOFxz6NxvASXDJeRtuZykwQepbDEGt87ig1BNpWaVWuNrm73YiIiJbq71Wi+dP9eKL8OC351vwIasSSbXxwA=\n" +
+				"trusted comment: timestamp:1635442742\tfile:test\n" +
+				"0YteLgV960ia80vnA/fHbvkyjl/IoP/HNOCaZfrF0CdhAlp7ok+Tpkya+VpWPX5C/Is3q8a/kEDSY7fBmmgJCg==\n",
+			comment: "timestamp:1635442742\tfile:test",
+		},
+		"hashed": {
+			sig: "untrusted comment: signature from minisign secret key\n" +
+				"RUQf6LRCGA9i559r3g7V1qNyJDApGip8MfqcadIgT9CuhV3EMhHoN1mGTkUidF/z7SrlQgXdy8ofjb7bNJJylDOocrCo8KLzZwo=\n" +
+				"trusted comment: timestamp:1635443258\tfile:test\thashed\n" +
+				"/cj37GK60vryibFn+ftOgbCvW9NKhKYgjVpFFQUcWPAnjO23wrvVDTt7cloNC06maoBli9q6qwZDXXoaxweICQ==\n",
+			comment: "timestamp:1635443258\tfile:test\thashed",
+		},
+	}
+	for name, test := range tests {
+		comment, err := Verify(pub, []byte("test"), []byte(test.sig))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if comment != test.comment {
+			t.Errorf("%s: trusted comment %q, want %q", name, comment, test.comment)
+		}
+		if _, err := Verify(pub, []byte("test\n"), []byte(test.sig)); err == nil {
+			t.Errorf("%s: signature verified for other data", name)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+// Package site generates a static HTML site from the run history: an index
+// of runs with trend charts and a page per run, for GitHub Pages or any
+// other static host. Like the history it holds no links or credentials.
+package site
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/stat"
+	"github.com/VenoMexx/ProtoScope/internal/store"
+)
+
+// uptimeRuns is the number of latest runs in a node's uptime strip
+const uptimeRuns = 60
+
+// Chart geometry, in SVG units
+const (
+	chartWidth  = 640
+	chartHeight = 180
+	chartLeft   = 44
+	chartRight  = 12
+	chartTop    = 12
+	chartBottom = 24
+	stripCell   = 6
+	stripHeight = 18
+)
+
+// run is the results of one recorded run
+type run struct {
+	ID      int64
+	Started time.Time
+	Points  []store.Point
+	Working int
+	Latency time.Duration // median of the working nodes'
+}
+
+// Page returns the run's page, relative to the site root
+func (r run) Page() string {
+	return fmt.Sprintf("runs/%d.html", r.ID)
+}
+
+// chart is a line chart of a value over the runs, drawn as inline SVG
+type chart struct {
+	Title  string
+	Points string // polyline points
+	Width  int
+	Height int
+	// Axis labels and their positions
+	Max, First, Last     string
+	AxisX, AxisY, LabelY int
+	RightX               int
+}
+
+// nodeRow is a node in the index's trend table
+type nodeRow struct {
+	Fingerprint string
+	Name        string
+	Type        string
+	Period      store.Period
+	Strip       []stripCellData
+	StripWidth  int
+}
+
+// stripCellData is one run of a node's uptime strip
+type stripCellData struct {
+	X     int
+	Class string // working or failed
+	Title string
+}
+
+// resultRow is a node on a run's page
+type resultRow struct {
+	Name     string
+	Type     string
+	Status   string
+	Latency  string
+	Download string
+	Upload   string
+	Score    string
+}
+
+const style = `<style>
+body { font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; margin: 24px; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 16px; }
+th, td { border: 1px solid #ddd; padding: 6px 10px; text-align: left; font-size: 14px; }
+th { background: #f4f4f4; }
+.cards { display: flex; flex-wrap: wrap; gap: 12px; margin-bottom: 8px; }
+.card { border: 1px solid #ddd; border-radius: 8px; padding: 12px 16px; min-width: 120px; }
+.card .value { font-size: 26px; font-weight: 600; }
+.card .label { color: #57606a; font-size: 13px; }
+.working { color: #1a7f37; }
+.failed { color: #cf222e; }
+.charts { display: flex; flex-wrap: wrap; gap: 24px; }
+.chart polyline { fill: none; stroke: #0969da; stroke-width: 2; }
+.chart line { stroke: #d0d7de; }
+.chart text { font-size: 11px; fill: #57606a; }
+rect.working { fill: #2da44e; }
+rect.failed { fill: #cf222e; }
+.muted { color: #57606a; font-size: 13px; }
+</style>`
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+` + style + `
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="muted">{{len .Runs}} runs from {{.From}} to {{.To}}. Generated {{.Generated}}.</p>
+{{with .Latest}}<h2>Latest Run</h2>
+<div class="cards">
+<div class="card"><div class="value">{{len .Points}}</div><div class="label">Nodes</div></div>
+<div class="card"><div class="value working">{{.Working}}</div><div class="label">Working</div></div>
+{{if .Latency}}<div class="card"><div class="value">{{.Latency.Milliseconds}}ms</div><div class="label">Median Latency</div></div>
+{{end}}</div>
+<p><a href="{{.Page}}">Results of {{.Started.UTC.Format "2006-01-02 15:04 UTC"}}</a></p>
+{{end}}<h2>Trends</h2>
+<div class="charts">
+{{range .Charts}}<div class="chart">
+<h3>{{.Title}}</h3>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" role="img" aria-label="{{.Title}}">
+<line x1="{{.AxisX}}" y1="{{.AxisY}}" x2="{{.RightX}}" y2="{{.AxisY}}"></line>
+<text x="2" y="20">{{.Max}}</text>
+<text x="2" y="{{.AxisY}}">0</text>
+<text x="{{.AxisX}}" y="{{.LabelY}}">{{.First}}</text>
+<text x="{{.RightX}}" y="{{.LabelY}}" text-anchor="end">{{.Last}}</text>
+<polyline points="{{.Points}}"></polyline>
+</svg>
+</div>
+{{end}}</div>
+<h2>Nodes</h2>
+<table>
+<tr><th>Node</th><th>Type</th><th>Availability</th><th>Median Latency</th><th>Median Speed</th><th>Latest Runs</th></tr>
+{{range .Nodes}}<tr><td>{{.Name}}<br><span class="muted">{{printf "%.12s" .Fingerprint}}</span></td><td>{{.Type}}</td><td>{{printf "%.0f" .Period.Availability}}% of {{.Period.Runs}}</td><td>{{if .Period.Latency}}{{.Period.Latency.Milliseconds}}ms{{else}}-{{end}}</td><td>{{if .Period.Speed}}{{printf "%.1f" .Period.Speed}} Mbps{{else}}-{{end}}</td>
+<td><svg width="{{.StripWidth}}" height="` + fmt.Sprint(stripHeight) + `">{{range .Strip}}<rect x="{{.X}}" width="` + fmt.Sprint(stripCell-1) + `" height="` + fmt.Sprint(stripHeight) + `" class="{{.Class}}"><title>{{.Title}}</title></rect>{{end}}</svg></td></tr>
+{{end}}</table>
+<h2>Runs</h2>
+<table>
+<tr><th>Started</th><th>Nodes</th><th>Working</th><th>Median Latency</th></tr>
+{{range .Runs}}<tr><td><a href="{{.Page}}">{{.Started.UTC.Format "2006-01-02 15:04 UTC"}}</a></td><td>{{len .Points}}</td><td>{{.Working}}</td><td>{{if .Latency}}{{.Latency.Milliseconds}}ms{{else}}-{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+var runTemplate = template.Must(template.New("run").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}: {{.Started}}</title>
+` + style + `
+</head>
+<body>
+<p><a href="../index.html">← {{.Title}}</a></p>
+<h1>Run of {{.Started}}</h1>
+<p>{{.Working}} of {{len .Rows}} nodes working.</p>
+<table>
+<tr><th>Node</th><th>Type</th><th>Status</th><th>Latency</th><th>Download</th><th>Upload</th><th>Score</th></tr>
+{{range .Rows}}<tr><td>{{.Name}}</td><td>{{.Type}}</td><td class="{{.Status}}">{{.Status}}</td><td>{{.Latency}}</td><td>{{.Download}}</td><td>{{.Upload}}</td><td>{{.Score}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Generate writes the site for the history points, as returned by
+// store.Points, to dir: index.html and a page per run under runs/, which
+// is rebuilt from scratch. It returns the number of runs published.
+func Generate(dir, title string, points []store.Point) (int, error) {
+	runs := groupRuns(points)
+
+	runsDir := filepath.Join(dir, "runs")
+	if err := os.RemoveAll(runsDir); err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(runsDir, 0755); err != nil {
+		return 0, err
+	}
+	// Serve the files as they are on GitHub Pages
+	if err := os.WriteFile(filepath.Join(dir, ".nojekyll"), nil, 0644); err != nil {
+		return 0, err
+	}
+
+	for _, r := range runs {
+		if err := writeRunPage(filepath.Join(dir, r.Page()), title, r); err != nil {
+			return 0, err
+		}
+	}
+	if err := writeIndex(filepath.Join(dir, "index.html"), title, points, runs); err != nil {
+		return 0, err
+	}
+	return len(runs), nil
+}
+
+// groupRuns groups points, oldest first, by run
+func groupRuns(points []store.Point) []run {
+	var runs []run
+	for _, point := range points {
+		if len(runs) == 0 || runs[len(runs)-1].ID != point.RunID {
+			runs = append(runs, run{ID: point.RunID, Started: point.Started})
+		}
+		r := &runs[len(runs)-1]
+		r.Points = append(r.Points, point)
+		if point.Success {
+			r.Working++
+		}
+	}
+	for i := range runs {
+		var latencies []time.Duration
+		for _, point := range runs[i].Points {
+			if point.Success && point.Latency > 0 {
+				latencies = append(latencies, point.Latency)
+			}
+		}
+		runs[i].Latency = stat.Median(latencies)
+	}
+	return runs
+}
+
+func writeIndex(path, title string, points []store.Point, runs []run) error {
+	data := struct {
+		Title     string
+		Generated string
+		From, To  string
+		Latest    *run
+		Charts    []chart
+		Nodes     []nodeRow
+		Runs      []run // newest first
+	}{
+		Title:     title,
+		Generated: time.Now().UTC().Format("2006-01-02 15:04 UTC"),
+	}
+
+	if len(runs) > 0 {
+		data.Latest = &runs[len(runs)-1]
+		data.From = runs[0].Started.UTC().Format(time.DateOnly)
+		data.To = data.Latest.Started.UTC().Format(time.DateOnly)
+
+		working := make([]float64, len(runs))
+		latency := make([]float64, len(runs))
+		for i, r := range runs {
+			working[i] = float64(r.Working)
+			latency[i] = math.NaN()
+			if r.Latency > 0 {
+				latency[i] = float64(r.Latency.Milliseconds())
+			}
+		}
+		data.Charts = []chart{
+			newChart("Working Nodes", "", runs, working),
+			newChart("Median Latency", "ms", runs, latency),
+		}
+	}
+
+	// A zero split puts every point in the current period
+	for _, trend := range store.Trends(points, time.Time{}) {
+		data.Nodes = append(data.Nodes, newNodeRow(trend, points))
+	}
+
+	for i := len(runs) - 1; i >= 0; i-- {
+		data.Runs = append(data.Runs, runs[i])
+	}
+
+	return writeFile(path, func(f *os.File) error { return indexTemplate.Execute(f, data) })
+}
+
+// newNodeRow summarizes a node with an uptime strip of its latest runs
+func newNodeRow(trend store.Trend, points []store.Point) nodeRow {
+	row := nodeRow{
+		Fingerprint: trend.Fingerprint,
+		Name:        trend.Name,
+		Type:        trend.Type,
+		Period:      trend.Current,
+	}
+
+	var own []store.Point
+	for _, point := range points {
+		if point.Fingerprint == trend.Fingerprint {
+			own = append(own, point)
+		}
+	}
+	if len(own) > uptimeRuns {
+		own = own[len(own)-uptimeRuns:]
+	}
+	for i, point := range own {
+		cell := stripCellData{X: i * stripCell, Class: "failed", Title: point.Started.UTC().Format("2006-01-02 15:04") + " failed"}
+		if point.Success {
+			cell.Class = "working"
+			cell.Title = point.Started.UTC().Format("2006-01-02 15:04") + " working"
+			if point.Latency > 0 {
+				cell.Title += fmt.Sprintf(", %dms", point.Latency.Milliseconds())
+			}
+		}
+		row.Strip = append(row.Strip, cell)
+	}
+	row.StripWidth = len(row.Strip) * stripCell
+	return row
+}
+
+// newChart lays out values, one per run, as a line; NaN values are
+// skipped
+func newChart(title, unit string, runs []run, values []float64) chart {
+	c := chart{
+		Title:  title,
+		Width:  chartWidth,
+		Height: chartHeight,
+		AxisX:  chartLeft,
+		AxisY:  chartHeight - chartBottom,
+		LabelY: chartHeight - 6,
+		RightX: chartWidth - chartRight,
+		First:  runs[0].Started.UTC().Format("Jan 2"),
+		Last:   runs[len(runs)-1].Started.UTC().Format("Jan 2"),
+	}
+	if unit != "" {
+		c.Title += " (" + unit + ")"
+	}
+
+	most := 0.0
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			most = math.Max(most, value)
+		}
+	}
+	if most == 0 {
+		most = 1
+	}
+	c.Max = fmt.Sprintf("%.0f", most)
+
+	plotWidth := float64(chartWidth - chartLeft - chartRight)
+	plotHeight := float64(chartHeight - chartTop - chartBottom)
+	var coords []string
+	for i, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
+		x := float64(chartLeft)
+		if len(values) > 1 {
+			x += plotWidth * float64(i) / float64(len(values)-1)
+		}
+		y := float64(chartTop) + plotHeight*(1-value/most)
+		coords = append(coords, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	c.Points = strings.Join(coords, " ")
+	return c
+}
+
+func writeRunPage(path, title string, r run) error {
+	data := struct {
+		Title   string
+		Started string
+		Working int
+		Rows    []resultRow
+	}{
+		Title:   title,
+		Started: r.Started.UTC().Format("2006-01-02 15:04 UTC"),
+		Working: r.Working,
+	}
+
+	points := append([]store.Point(nil), r.Points...)
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Name < points[j].Name })
+	for _, point := range points {
+		row := resultRow{Name: point.Name, Type: point.Type, Status: "failed", Latency: "-", Download: "-", Upload: "-", Score: "-"}
+		if point.Success {
+			row.Status = "working"
+			row.Score = fmt.Sprint(point.Score)
+		}
+		if point.Latency > 0 {
+			row.Latency = fmt.Sprintf("%dms", point.Latency.Milliseconds())
+		}
+		if point.Download > 0 {
+			row.Download = fmt.Sprintf("%.1f Mbps", point.Download)
+		}
+		if point.Upload > 0 {
+			row.Upload = fmt.Sprintf("%.1f Mbps", point.Upload)
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	return writeFile(path, func(f *os.File) error { return runTemplate.Execute(f, data) })
+}
+
+// writeFile creates path and fills it with write
+func writeFile(path string, write func(*os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,71 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/store"
+)
+
+func TestGenerate(t *testing.T) {
+	day := time.Date(2025, 3, 1, 6, 0, 0, 0, time.UTC)
+	point := func(run int64, name string, success bool, latency time.Duration) store.Point {
+		return store.Point{
+			RunID:       run,
+			Started:     day.AddDate(0, 0, int(run)),
+			Fingerprint: strings.Repeat(name[:1], 64),
+			Name:        name,
+			Type:        "vless",
+			Success:     success,
+			Latency:     latency,
+			Score:       80,
+		}
+	}
+	points := []store.Point{
+		point(1, "DE-01", true, 100*time.Millisecond),
+		point(1, "<NL>", false, 0),
+		point(2, "DE-01", true, 300*time.Millisecond),
+		point(2, "<NL>", true, 200*time.Millisecond),
+	}
+
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "runs", "99.html")
+	os.MkdirAll(filepath.Dir(stale), 0755)
+	os.WriteFile(stale, nil, 0644)
+
+	runs, err := Generate(dir, "Health", points)
+	if err != nil || runs != 2 {
+		t.Fatalf("Generate = %d, %v", runs, err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<a href="runs/2.html">2025-03-03 06:00 UTC</a>`,
+		"&lt;NL&gt;",
+		"50% of 2",       // <NL> worked in one of two runs
+		"<td>200ms</td>", // median latency of DE-01
+		`class="failed"`,
+		"<polyline points=",
+	} {
+		if !strings.Contains(string(index), want) {
+			t.Errorf("index lacks %q", want)
+		}
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "runs", "1.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), "1 of 2 nodes working") || !strings.Contains(string(page), "<td>100ms</td>") {
+		t.Errorf("run page:\n%s", page)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("page of a run no longer in the history kept")
+	}
+}
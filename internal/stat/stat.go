@@ -0,0 +1,19 @@
+// Package stat summarizes measurements shared by reports, history and the
+// results site.
+package stat
+
+import "slices"
+
+// Median returns the middle value, or the mean of the two middle values;
+// zero for no values
+func Median[T ~int64 | ~float64](values []T) T {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Sorted(slices.Values(values))
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,18 @@
+package stat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMedian(t *testing.T) {
+	if got := Median([]float64{3, 1, 2}); got != 2 {
+		t.Errorf("odd count: %v, want 2", got)
+	}
+	if got := Median([]time.Duration{4, 1, 3, 2}); got != 2 {
+		t.Errorf("even count: %v, want 2", got)
+	}
+	if got := Median[float64](nil); got != 0 {
+		t.Errorf("no values: %v, want 0", got)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !nohistory && !mips && !mipsle && !mips64 && !mips64le
+
+package store
+
+// The SQLite driver is left out of builds tagged nohistory and of MIPS
+// builds, which it doesn't support, so protoscope still builds for routers;
+// Open fails there
+
+import _ "modernc.org/sqlite" // registers the "sqlite" driver
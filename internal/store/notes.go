@@ -0,0 +1,62 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Notes returns the notes attached to nodes, by node fingerprint
+func (s *Store) Notes() (map[string]*models.NodeNote, error) {
+	rows, err := s.db.Query(`SELECT fingerprint, name, text, labels, updated FROM notes`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := make(map[string]*models.NodeNote)
+	for rows.Next() {
+		var fingerprint, labels string
+		var updated int64
+		note := &models.NodeNote{}
+		if err := rows.Scan(&fingerprint, &note.Name, &note.Text, &labels, &updated); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(labels), &note.Labels); err != nil {
+			return nil, err
+		}
+		note.Updated = time.Unix(updated, 0)
+		notes[fingerprint] = note
+	}
+	return notes, rows.Err()
+}
+
+// SaveNotes replaces the stored notes with notes, keyed by fingerprint
+func (s *Store) SaveNotes(notes map[string]*models.NodeNote) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM notes`); err != nil {
+		return err
+	}
+	insert, err := tx.Prepare(`INSERT INTO notes (fingerprint, name, text, labels, updated) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	for fingerprint, note := range notes {
+		labels, err := json.Marshal(note.Labels)
+		if err != nil {
+			return err
+		}
+		if _, err := insert.Exec(fingerprint, note.Name, note.Text, string(labels), note.Updated.Unix()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,197 @@
+// Package store keeps the results of every run in a local SQLite database,
+// keyed by node fingerprint, so latency, speed and availability can be
+// followed over time
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// schema creates the tables on first use. Only measurements and the
+// user's notes are kept, not the nodes' links, so the database holds no
+// credentials.
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id      INTEGER PRIMARY KEY,
+	started INTEGER NOT NULL -- unix seconds
+);
+CREATE TABLE IF NOT EXISTS results (
+	run_id        INTEGER NOT NULL REFERENCES runs(id),
+	fingerprint   TEXT NOT NULL,
+	name          TEXT NOT NULL,
+	type          TEXT NOT NULL,
+	success       INTEGER NOT NULL,
+	latency_ms    INTEGER, -- NULL when not measured, as are the others
+	download_mbps REAL,
+	upload_mbps   REAL,
+	score         INTEGER
+);
+CREATE INDEX IF NOT EXISTS results_fingerprint ON results (fingerprint, run_id);
+CREATE TABLE IF NOT EXISTS notes (
+	fingerprint TEXT PRIMARY KEY,
+	name        TEXT NOT NULL, -- the node's name when the note was set
+	text        TEXT NOT NULL,
+	labels      TEXT NOT NULL, -- JSON array
+	updated     INTEGER NOT NULL -- unix seconds
+);
+`
+
+// DefaultPath returns the history database in the user's config directory
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "protoscope", "history.db")
+}
+
+// Store is an open history database
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the database at path, creating it and its directory if
+// needed
+func Open(path string) (*Store, error) {
+	if !slices.Contains(sql.Drivers(), "sqlite") {
+		return nil, errors.New("this build of protoscope has no SQLite support, so no history")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	// A daemon and a one-off run may write at the same time
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open history %s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// SaveRun records the results of a run started at started. Cached results
+// were not measured in this run and are left out.
+func (s *Store) SaveRun(started time.Time, results []*models.TestResult) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO runs (started) VALUES (?)`, started.Unix())
+	if err != nil {
+		return err
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	insert, err := tx.Prepare(`INSERT INTO results
+		(run_id, fingerprint, name, type, success, latency_ms, download_mbps, upload_mbps, score)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	for _, result := range results {
+		if result == nil || result.Protocol == nil || result.Cached {
+			continue
+		}
+		var latency, download, upload, score interface{}
+		if result.Success && result.Connectivity != nil && result.Connectivity.ResponseTime > 0 {
+			latency = result.Connectivity.ResponseTime.Milliseconds()
+		}
+		if perf := result.Performance; perf != nil {
+			if perf.DownloadSpeed > 0 {
+				download = perf.DownloadSpeed
+			}
+			if perf.UploadSpeed > 0 {
+				upload = perf.UploadSpeed
+			}
+		}
+		if result.Success {
+			score = result.Score()
+		}
+
+		p := result.Protocol
+		if _, err := insert.Exec(runID, p.Fingerprint(), p.Name, string(p.Type), result.Success,
+			latency, download, upload, score); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Point is a node's result in one run
+type Point struct {
+	RunID       int64
+	Started     time.Time
+	Fingerprint string
+	Name        string
+	Type        string
+	Success     bool
+	Latency     time.Duration // 0 when not measured
+	Download    float64       // Mbps, 0 when not measured
+	Upload      float64
+	Score       int
+}
+
+// Points returns the results recorded since a time, oldest first. A
+// non-empty node limits them to the node with that name or whose
+// fingerprint starts with it.
+func (s *Store) Points(since time.Time, node string) ([]Point, error) {
+	query := `SELECT x.run_id, r.started, x.fingerprint, x.name, x.type, x.success,
+			x.latency_ms, x.download_mbps, x.upload_mbps, x.score
+		FROM results x JOIN runs r ON r.id = x.run_id
+		WHERE r.started >= ?`
+	args := []interface{}{since.Unix()}
+	if node != "" {
+		query += ` AND (x.name = ? OR (length(?) >= 8 AND x.fingerprint LIKE ? || '%'))`
+		args = append(args, node, node, node)
+	}
+	query += ` ORDER BY r.started, x.rowid`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []Point
+	for rows.Next() {
+		var point Point
+		var started int64
+		var latency, score sql.NullInt64
+		var download, upload sql.NullFloat64
+		if err := rows.Scan(&point.RunID, &started, &point.Fingerprint, &point.Name, &point.Type, &point.Success,
+			&latency, &download, &upload, &score); err != nil {
+			return nil, err
+		}
+		point.Started = time.Unix(started, 0)
+		point.Latency = time.Duration(latency.Int64) * time.Millisecond
+		point.Download = download.Float64
+		point.Upload = upload.Float64
+		point.Score = int(score.Int64)
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
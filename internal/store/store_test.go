@@ -0,0 +1,72 @@
+//go:build !nohistory && !mips && !mipsle && !mips64 && !mips64le
+
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func storeResult(name string, success bool, latency time.Duration, speed float64) *models.TestResult {
+	return &models.TestResult{
+		Protocol:     &models.Protocol{Type: models.ProtocolTrojan, Name: name, Server: name + ".example.com", Port: 443, Password: "pw"},
+		Success:      success,
+		Connectivity: &models.ConnectivityResult{Connected: success, ResponseTime: latency},
+		Performance:  &models.PerformanceResult{DownloadSpeed: speed},
+	}
+}
+
+func TestHistoryTrends(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "history", "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	day := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	runs := [][]*models.TestResult{
+		{storeResult("a", true, 300*time.Millisecond, 10), storeResult("b", true, 100*time.Millisecond, 50)},
+		{storeResult("a", false, 0, 0), storeResult("b", true, 120*time.Millisecond, 40)},
+		{storeResult("a", true, 100*time.Millisecond, 30), storeResult("b", false, 0, 0)},
+		{storeResult("a", true, 200*time.Millisecond, 20), storeResult("b", false, 0, 0)},
+	}
+	cached := storeResult("c", true, time.Millisecond, 1)
+	cached.Cached = true
+	runs[3] = append(runs[3], cached)
+	for i, results := range runs {
+		if err := s.SaveRun(day.AddDate(0, 0, i), results); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	points, err := s.Points(day, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 8 {
+		t.Fatalf("%d points, want 8 without the cached result", len(points))
+	}
+
+	trends := Trends(points, day.AddDate(0, 0, 2))
+	if len(trends) != 2 || trends[0].Name != "a" {
+		t.Fatalf("trends = %+v", trends)
+	}
+	a := trends[0]
+	if a.Previous.Runs != 2 || a.Previous.Availability() != 50 || a.Previous.Latency != 300*time.Millisecond {
+		t.Errorf("a previous = %+v", a.Previous)
+	}
+	if a.Current.Availability() != 100 || a.Current.Latency != 150*time.Millisecond || a.Current.Speed != 25 {
+		t.Errorf("a current = %+v", a.Current)
+	}
+	if b := trends[1]; b.Current.Working != 0 || b.Current.Latency != 0 {
+		t.Errorf("b current = %+v", b.Current)
+	}
+
+	node, err := s.Points(time.Time{}, a.Fingerprint[:8])
+	if err != nil || len(node) != 4 || node[1].Success {
+		t.Errorf("points of a by fingerprint = %+v, %v", node, err)
+	}
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/stat"
+)
+
+// Period summarizes a node's results over some runs
+type Period struct {
+	Runs    int
+	Working int
+	// Latency and Speed are medians over the runs that measured them
+	Latency time.Duration
+	Speed   float64
+}
+
+// Availability returns the share of runs the node worked in, in percent
+func (p Period) Availability() float64 {
+	if p.Runs == 0 {
+		return 0
+	}
+	return float64(p.Working) / float64(p.Runs) * 100
+}
+
+// Trend compares a node's recent results with those of the period before
+type Trend struct {
+	Fingerprint string
+	Name        string // as of the latest run
+	Type        string
+	Previous    Period
+	Current     Period
+}
+
+// Trends groups points by node and summarizes those before split as the
+// previous period and the rest as the current one. Nodes are ordered by
+// name.
+func Trends(points []Point, split time.Time) []Trend {
+	type samples struct {
+		latencies []time.Duration
+		speeds    []float64
+	}
+	byNode := make(map[string]*Trend)
+	measured := make(map[*Period]*samples)
+
+	for _, point := range points {
+		trend, ok := byNode[point.Fingerprint]
+		if !ok {
+			trend = &Trend{Fingerprint: point.Fingerprint}
+			byNode[point.Fingerprint] = trend
+		}
+		trend.Name, trend.Type = point.Name, point.Type
+
+		period := &trend.Current
+		if point.Started.Before(split) {
+			period = &trend.Previous
+		}
+		period.Runs++
+		if !point.Success {
+			continue
+		}
+		period.Working++
+
+		s, ok := measured[period]
+		if !ok {
+			s = &samples{}
+			measured[period] = s
+		}
+		if point.Latency > 0 {
+			s.latencies = append(s.latencies, point.Latency)
+		}
+		if point.Download > 0 {
+			s.speeds = append(s.speeds, point.Download)
+		}
+	}
+
+	for period, s := range measured {
+		period.Latency = stat.Median(s.latencies)
+		period.Speed = stat.Median(s.speeds)
+	}
+
+	trends := make([]Trend, 0, len(byNode))
+	for _, trend := range byNode {
+		trends = append(trends, *trend)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Name != trends[j].Name {
+			return trends[i].Name < trends[j].Name
+		}
+		return trends[i].Fingerprint < trends[j].Fingerprint
+	})
+	return trends
+}
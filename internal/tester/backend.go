@@ -1,7 +1,13 @@
 package tester
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/VenoMexx/ProtoScope/pkg/models"
 )
@@ -14,6 +20,8 @@ const (
 	BackendXray ProxyBackend = "xray"
 	// BackendSingbox uses sing-box
 	BackendSingbox ProxyBackend = "sing-box"
+	// BackendMock simulates nodes with canned responses, no network needed
+	BackendMock ProxyBackend = "mock"
 )
 
 // SelectBackend selects the appropriate backend for a protocol
@@ -28,18 +36,10 @@ func SelectBackend(protocol *models.Protocol) ProxyBackend {
 
 // IsBackendAvailable checks if a backend binary is available
 func IsBackendAvailable(backend ProxyBackend) bool {
-	var binaryName string
-	switch backend {
-	case BackendXray:
-		binaryName = "xray"
-	case BackendSingbox:
-		binaryName = "sing-box"
-	default:
-		return false
+	if backend == BackendMock {
+		return true
 	}
-
-	// Check if binary exists in PATH
-	_, err := exec.LookPath(binaryName)
+	_, err := FindBackend(backend)
 	return err == nil
 }
 
@@ -54,3 +54,53 @@ func GetBackendBinary(backend ProxyBackend) string {
 		return ""
 	}
 }
+
+// FindBackend returns the path of a backend binary: on PATH, or else next
+// to the protoscope executable, where Windows users tend to unpack it, or
+// in Homebrew's directories on macOS, which services started by launchd
+// don't have on PATH. Windows finds it with its .exe extension.
+func FindBackend(backend ProxyBackend) (string, error) {
+	name := GetBackendBinary(backend)
+	if name == "" {
+		return "", fmt.Errorf("unsupported backend: %s", backend)
+	}
+	path, err := exec.LookPath(name)
+	if err == nil {
+		return path, nil
+	}
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	for _, dir := range backendDirs() {
+		if path, lookErr := exec.LookPath(filepath.Join(dir, name)); lookErr == nil {
+			return path, nil
+		}
+	}
+	return "", err
+}
+
+// backendDirs are searched for backends missing from PATH
+func backendDirs() []string {
+	var dirs []string
+	if self, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(self))
+	}
+	if runtime.GOOS == "darwin" {
+		dirs = append(dirs, "/opt/homebrew/bin", "/usr/local/bin")
+	}
+	return dirs
+}
+
+// BackendVersion runs "<binary> version" and returns the first line of its
+// output
+func BackendVersion(ctx context.Context, backend ProxyBackend) (string, error) {
+	path, err := FindBackend(backend)
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.CommandContext(ctx, path, "version").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0]), nil
+}
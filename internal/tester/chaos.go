@@ -0,0 +1,110 @@
+package tester
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// ChaosOptions injects faults into proxy managers so retry, error
+// classification and cleanup paths can be exercised. Faults are drawn from
+// a generator seeded per node, so the same seed reproduces the same faults
+// regardless of worker scheduling.
+type ChaosOptions struct {
+	Latency     time.Duration // added to every request through the proxy
+	TimeoutRate float64       // fraction of requests that fail with a timeout
+	CrashRate   float64       // fraction of proxies whose backend dies after start
+	Seed        int64
+}
+
+// Enabled reports whether any fault is configured
+func (c ChaosOptions) Enabled() bool {
+	return c.Latency > 0 || c.TimeoutRate > 0 || c.CrashRate > 0
+}
+
+// chaosTimeoutError mimics a network timeout so it is classified like one
+type chaosTimeoutError struct{}
+
+func (chaosTimeoutError) Error() string   { return "chaos: i/o timeout" }
+func (chaosTimeoutError) Timeout() bool   { return true }
+func (chaosTimeoutError) Temporary() bool { return true }
+
+// chaos holds the fault state of a single proxy manager
+type chaos struct {
+	options ChaosOptions
+
+	mu         sync.Mutex
+	rng        *rand.Rand
+	crashAfter int // requests served before the backend dies; -1 = never
+	requests   int
+	crashed    bool
+	onCrash    func()
+}
+
+// newChaos creates the fault state for one node
+func newChaos(options ChaosOptions, protocol *models.Protocol) *chaos {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%s|%d", protocol.Name, protocol.Server, protocol.Port)
+
+	c := &chaos{
+		options:    options,
+		rng:        rand.New(rand.NewSource(options.Seed ^ int64(h.Sum64()))),
+		crashAfter: -1,
+	}
+	if c.rng.Float64() < options.CrashRate {
+		// Crash early in the run: during connectivity or the first checks
+		c.crashAfter = c.rng.Intn(4)
+	}
+	return c
+}
+
+// next decides the fault for the next request
+func (c *chaos) next() (crashed, timeout bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.crashed && c.crashAfter >= 0 && c.requests >= c.crashAfter {
+		c.crashed = true
+		if c.onCrash != nil {
+			c.onCrash()
+		}
+	}
+	c.requests++
+
+	if c.crashed {
+		return true, false
+	}
+	return false, c.rng.Float64() < c.options.TimeoutRate
+}
+
+// chaosTransport applies chaos faults before handing requests to Base
+type chaosTransport struct {
+	Base  http.RoundTripper
+	chaos *chaos
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.chaos.options.Latency > 0 {
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(t.chaos.options.Latency):
+		}
+	}
+
+	crashed, timeout := t.chaos.next()
+	if crashed {
+		return nil, fmt.Errorf("chaos: backend crashed: connection refused")
+	}
+	if timeout {
+		return nil, chaosTimeoutError{}
+	}
+
+	return t.Base.RoundTrip(req)
+}
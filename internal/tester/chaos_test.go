@@ -0,0 +1,73 @@
+package tester
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestChaosIsDeterministicPerNode(t *testing.T) {
+	protocol := &models.Protocol{Name: "DE-01", Server: "de.example.com", Port: 443}
+	options := ChaosOptions{TimeoutRate: 0.5, CrashRate: 0.5, Seed: 42}
+
+	a, b := newChaos(options, protocol), newChaos(options, protocol)
+	if a.crashAfter != b.crashAfter {
+		t.Fatalf("crash point differs between runs: %d vs %d", a.crashAfter, b.crashAfter)
+	}
+	for i := 0; i < 20; i++ {
+		crashedA, timeoutA := a.next()
+		crashedB, timeoutB := b.next()
+		if crashedA != crashedB || timeoutA != timeoutB {
+			t.Fatalf("request %d: faults differ between runs", i)
+		}
+	}
+}
+
+func TestChaosTimeoutIsClassified(t *testing.T) {
+	runner := newMockRunner()
+	runner.SetChaos(ChaosOptions{TimeoutRate: 1})
+
+	result, _ := runner.QuickTest(context.Background(), &models.Protocol{
+		Type: models.ProtocolVLESS, Name: "NL-01", Server: "nl.example.com", Port: 443,
+	})
+	if result.Success {
+		t.Fatal("expected chaos timeout to fail the node")
+	}
+	if result.ErrorDetails == nil || result.ErrorDetails.Type != models.ErrorTypeProxyTimeout {
+		t.Fatalf("expected proxy timeout classification, got %+v", result.ErrorDetails)
+	}
+}
+
+func TestChaosCrashStopsBackend(t *testing.T) {
+	protocol := &models.Protocol{Type: models.ProtocolVLESS, Name: "NL-01", Server: "nl.example.com", Port: 443}
+	pm := NewProxyManager(protocol, 0)
+	pm.SetBackend(BackendMock)
+	pm.SetChaos(ChaosOptions{CrashRate: 1})
+	pm.chaos.crashAfter = 1
+
+	if err := pm.Start(context.Background()); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer pm.Stop()
+
+	client, err := pm.GetHTTPClient(5 * time.Second)
+	if err != nil {
+		t.Fatalf("GetHTTPClient returned error: %v", err)
+	}
+
+	resp, err := client.Get("http://www.gstatic.com/generate_204")
+	if err != nil {
+		t.Fatalf("expected first request to succeed before the crash: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := client.Get("http://www.gstatic.com/generate_204"); err == nil {
+		t.Fatal("expected request after the crash to fail")
+	}
+	if !strings.Contains(pm.GetBackendLogs(), "crashed") {
+		t.Fatalf("expected crash in backend logs, got %q", pm.GetBackendLogs())
+	}
+}
@@ -0,0 +1,70 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/checks"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// ClockTolerance returns how far the client clock may be off before the
+// server rejects the node's handshakes, or 0 if its protocol does not
+// depend on the time. VMess authenticates with a timestamp checked within
+// 90 seconds and Shadowsocks 2022 within 30; VLESS, Trojan, Hysteria2 and
+// TUIC carry no timestamp.
+func ClockTolerance(protocol *models.Protocol) time.Duration {
+	switch protocol.Type {
+	case models.ProtocolVMess:
+		return 90 * time.Second
+	case models.ProtocolShadowsocks:
+		if method, _ := protocol.Extra["method"].(string); strings.HasPrefix(method, "2022-") {
+			return 30 * time.Second
+		}
+	}
+	return 0
+}
+
+// ClockSkew measures the local clock against the configured time sources,
+// directly and at most once per clockTTL. It returns nil when no source
+// answered.
+func (tr *TestRunner) ClockSkew(ctx context.Context) *checks.ClockSkew {
+	skew, _ := tr.clock.get(clockTTL, func() (*checks.ClockSkew, error) {
+		ntp := tr.config.APIEndpoints.NTP
+		if tr.isMock() {
+			// Mock runs stay offline; NTP is UDP and bypasses the mock transport
+			ntp = nil
+		}
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		return checks.MeasureClockSkew(fetchCtx, tr.directClient(), ntp, tr.config.APIEndpoints.TimeHTTP)
+	})
+	return skew
+}
+
+// annotateClockSkew points at the local clock when a time-sensitive node
+// failed in a way a rejected handshake looks like and the clock is off by
+// more than the protocol allows
+func (tr *TestRunner) annotateClockSkew(ctx context.Context, result *models.TestResult) {
+	if result.Success || result.ErrorDetails == nil {
+		return
+	}
+	switch result.ErrorDetails.Type {
+	case models.ErrorTypeAuthentication, models.ErrorTypeConnectivity, models.ErrorTypeProxyTimeout, models.ErrorTypeUnknown:
+	default:
+		return
+	}
+	tolerance := ClockTolerance(result.Protocol)
+	if tolerance == 0 {
+		return
+	}
+	skew := tr.ClockSkew(ctx)
+	if skew == nil || skew.Abs() <= tolerance {
+		return
+	}
+
+	result.ErrorDetails.Details += fmt.Sprintf("\nLocal clock is %s; %s servers reject clients more than %s off", skew, result.Protocol.Type, tolerance)
+	result.ErrorDetails.Suggestion = "Your system clock is off. Sync it (e.g. enable NTP) and test again."
+}
@@ -0,0 +1,78 @@
+package tester
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SetConfigStdin sets whether the backend gets its config on stdin when it
+// can read it there, rather than in a file
+func (pm *ProxyManager) SetConfigStdin(stdin bool) {
+	pm.configStdin = stdin
+}
+
+// stdinConfigArgs returns the arguments making a backend read its config
+// from stdin, or nil when it can't
+func stdinConfigArgs(backend ProxyBackend) []string {
+	switch backend {
+	case BackendXray:
+		return []string{"run", "-c", "stdin:", "-format", "json"}
+	case BackendSingbox:
+		return []string{"run", "-c", "stdin"}
+	}
+	return nil
+}
+
+// runDirBase returns where private run directories are created: the
+// user's runtime directory, usually a tmpfs, or the temp directory. A
+// backend running as another user can't enter the runtime directory.
+func (pm *ProxyManager) runDirBase() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && pm.sandbox.User == "" {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return os.TempDir()
+}
+
+// writeConfigFile writes the backend config, readable only by its owner,
+// into a fresh directory only its owner can enter
+func (pm *ProxyManager) writeConfigFile(data []byte) (string, error) {
+	dir, err := os.MkdirTemp(pm.runDirBase(), "protoscope-")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	pm.runDir = dir
+	return path, nil
+}
+
+// removeConfigFile shreds the config file and removes its directory
+func (pm *ProxyManager) removeConfigFile() {
+	if pm.configFile != "" {
+		shredFile(pm.configFile)
+		pm.configFile = ""
+	}
+	if pm.runDir != "" {
+		os.RemoveAll(pm.runDir)
+		pm.runDir = ""
+	}
+}
+
+// shredFile overwrites a file with zeros before removing it, so its
+// contents don't survive in freed blocks. Journaling and copy-on-write
+// filesystems may still keep old copies.
+func shredFile(path string) {
+	if file, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+		if info, err := file.Stat(); err == nil {
+			file.WriteAt(make([]byte, info.Size()), 0)
+			file.Sync()
+		}
+		file.Close()
+	}
+	os.Remove(path)
+}
@@ -0,0 +1,106 @@
+package tester
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// fakeSingbox puts a sing-box on PATH that records its arguments and
+// config, then exits without serving
+func fakeSingbox(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake backend is a shell script")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+echo "$@" > "` + dir + `/args"
+if [ "$3" = stdin ]; then cat > "` + dir + `/config"; else cat "$3" > "` + dir + `/config"; fi
+`
+	if err := os.WriteFile(filepath.Join(dir, "sing-box"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	return dir
+}
+
+func startFakeSingbox(t *testing.T, stdin bool) (string, string) {
+	dir := fakeSingbox(t)
+	protocol := &models.Protocol{Type: models.ProtocolHysteria2, Name: "JP-01", Server: "jp.example.com", Port: 443, Password: "hunter2"}
+	pm := NewProxyManager(protocol, 0)
+	pm.SetBackend(BackendSingbox)
+	pm.SetConfigStdin(stdin)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pm.Start(ctx); err == nil {
+		t.Fatal("fake backend started")
+	}
+	if pm.configFile != "" || pm.runDir != "" {
+		t.Errorf("config left behind: %q in %q", pm.configFile, pm.runDir)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	config, _ := os.ReadFile(filepath.Join(dir, "config"))
+	if !strings.Contains(string(config), "hunter2") {
+		t.Errorf("backend got config %q", config)
+	}
+	return strings.TrimSpace(string(args)), os.Getenv("XDG_RUNTIME_DIR")
+}
+
+func TestConfigOnStdin(t *testing.T) {
+	args, runtimeDir := startFakeSingbox(t, true)
+	if args != "run -c stdin" {
+		t.Errorf("args = %q", args)
+	}
+	if entries, _ := os.ReadDir(runtimeDir); len(entries) != 0 {
+		t.Errorf("run directory created: %v", entries)
+	}
+}
+
+func TestConfigFileShredded(t *testing.T) {
+	args, runtimeDir := startFakeSingbox(t, false)
+	path := strings.TrimPrefix(args, "run -c ")
+	if !strings.HasPrefix(path, runtimeDir) {
+		t.Errorf("config written to %s, outside the runtime directory", path)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Errorf("run directory not removed: %v", err)
+	}
+}
+
+func TestWriteConfigFilePrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions")
+	}
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	pm := NewProxyManager(&models.Protocol{}, 0)
+	path, err := pm.writeConfigFile([]byte(`{"secret":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm.configFile = path
+
+	for name, want := range map[string]os.FileMode{path: 0600, pm.runDir: 0700} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("%s has mode %o, want %o", name, info.Mode().Perm(), want)
+		}
+	}
+
+	dir := pm.runDir
+	pm.removeConfigFile()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("run directory not removed: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package tester
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/checks"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// wrongCredentials returns a copy of protocol with random credentials of
+// the same form, or nil when the protocol has none a server could ignore
+func wrongCredentials(protocol *models.Protocol) *models.Protocol {
+	wrong := protocol.Clone()
+	switch protocol.Type {
+	case models.ProtocolVMess, models.ProtocolVLESS:
+		wrong.UUID = randomUUID()
+	case models.ProtocolTrojan:
+		wrong.Password = randomHex(16)
+	case models.ProtocolShadowsocks:
+		// 2022 methods take base64 keys of a fixed size, possibly one per
+		// user separated by colons
+		if method, _ := protocol.Extra["method"].(string); strings.HasPrefix(method, "2022-") {
+			keys := strings.Split(protocol.Password, ":")
+			for i, key := range keys {
+				decoded, err := base64.StdEncoding.DecodeString(key)
+				if err != nil {
+					return nil
+				}
+				keys[i] = base64.StdEncoding.EncodeToString(randomKey(len(decoded)))
+			}
+			wrong.Password = strings.Join(keys, ":")
+		} else {
+			wrong.Password = randomHex(16)
+		}
+	case models.ProtocolSSH:
+		// Key-only logins would need a key of our own
+		if protocol.Password == "" {
+			return nil
+		}
+		wrong.Password = randomHex(16)
+	default:
+		return nil
+	}
+	return wrong
+}
+
+// probeCredentials tries to proxy through the node with wrong credentials.
+// It returns nil when the backend didn't get as far as a request.
+func (tr *TestRunner) probeCredentials(ctx context.Context, wrong *models.Protocol) *models.ActiveProbe {
+	start := time.Now()
+	result := tr.quickTest(ctx, wrong)
+	probe := &models.ActiveProbe{Name: checks.CredentialProbeName, Elapsed: time.Since(start)}
+	switch {
+	case result.Success:
+		probe.Response = checks.ProbeAccepted
+		probe.Detail = "proxied with random credentials"
+	case result.Connectivity != nil:
+		probe.Response = checks.ProbeRejected
+		probe.Detail = result.Connectivity.Error
+	default:
+		return nil
+	}
+	return probe
+}
+
+func randomUUID() string {
+	b := randomKey(16)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func randomHex(n int) string {
+	return hex.EncodeToString(randomKey(n))
+}
+
+func randomKey(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
@@ -0,0 +1,39 @@
+package tester
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestWrongCredentials(t *testing.T) {
+	vless := &models.Protocol{Type: models.ProtocolVLESS, UUID: "b831381d-6324-4d53-ad4f-8cda48b30811"}
+	wrong := wrongCredentials(vless)
+	if wrong.UUID == vless.UUID || !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(wrong.UUID) {
+		t.Errorf("wrong UUID %q", wrong.UUID)
+	}
+
+	key16 := base64.StdEncoding.EncodeToString(make([]byte, 16))
+	key32 := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	ss := &models.Protocol{
+		Type:     models.ProtocolShadowsocks,
+		Password: key32 + ":" + key16,
+		Extra:    map[string]interface{}{"method": "2022-blake3-aes-256-gcm"},
+	}
+	keys := strings.Split(wrongCredentials(ss).Password, ":")
+	if len(keys) != 2 || keys[0] == key32 || keys[1] == key16 {
+		t.Fatalf("wrong 2022 keys %q", keys)
+	}
+	for i, want := range []int{32, 16} {
+		if decoded, err := base64.StdEncoding.DecodeString(keys[i]); err != nil || len(decoded) != want {
+			t.Errorf("key %d: %d bytes, %v; want %d", i, len(decoded), err, want)
+		}
+	}
+
+	if wrongCredentials(&models.Protocol{Type: models.ProtocolSSH}) != nil {
+		t.Error("key-only SSH login got wrong credentials")
+	}
+}
@@ -0,0 +1,62 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Hooks let applications embedding the runner log, filter and stop tests
+// without changing the runner. Any hook may be nil. Nodes are tested
+// concurrently, so hooks must be safe for concurrent use.
+type Hooks struct {
+	// BeforeTest runs before a node is tested. An error leaves the node
+	// untested, with the error in its result.
+	BeforeTest func(ctx context.Context, protocol *models.Protocol) error
+	// AfterTest runs with each node's result. An error aborts the run:
+	// nodes not started yet are left untested and RunTests returns it.
+	AfterTest func(ctx context.Context, protocol *models.Protocol, result *models.TestResult) error
+	// BeforeCheck runs before each check stage after connectivity, e.g.
+	// "performance" or "geo". An error skips the stage.
+	BeforeCheck func(ctx context.Context, protocol *models.Protocol, check string) error
+	// AfterCheck runs after each check stage that ran, with the result so
+	// far and the stage's error. An error skips the node's remaining
+	// stages.
+	AfterCheck func(ctx context.Context, protocol *models.Protocol, check string, result *models.TestResult, checkErr error) error
+}
+
+// SetHooks installs hooks called around nodes and their checks
+func (tr *TestRunner) SetHooks(hooks Hooks) {
+	tr.hooks = hooks
+}
+
+// beforeTest runs the BeforeTest hook, returning the result of a node it
+// rejected
+func (tr *TestRunner) beforeTest(ctx context.Context, protocol *models.Protocol) *models.TestResult {
+	if tr.hooks.BeforeTest == nil {
+		return nil
+	}
+	if err := tr.hooks.BeforeTest(ctx, protocol); err != nil {
+		return notTested(protocol, err)
+	}
+	return nil
+}
+
+// afterTest runs the AfterTest hook
+func (tr *TestRunner) afterTest(ctx context.Context, protocol *models.Protocol, result *models.TestResult) error {
+	if tr.hooks.AfterTest == nil {
+		return nil
+	}
+	return tr.hooks.AfterTest(ctx, protocol, result)
+}
+
+// notTested is the result of a node that was never tested
+func notTested(protocol *models.Protocol, reason error) *models.TestResult {
+	return &models.TestResult{
+		Protocol:  protocol,
+		Timestamp: time.Now(),
+		Error:     fmt.Sprintf("Not tested: %v", reason),
+	}
+}
@@ -0,0 +1,54 @@
+package tester
+
+import (
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/sandbox"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// BackendLimits confine each xray/sing-box process, so a node config that
+// makes its backend spin or balloon doesn't starve the rest of the run.
+// Linux uses a cgroup when it can create one and otherwise watches the
+// process; Windows uses a job object; elsewhere only Nice applies.
+type BackendLimits struct {
+	MemoryBytes int64 // 0 means unlimited
+	CPUPercent  int   // of one core; 0 means unlimited
+	Nice        int
+}
+
+// BackendLimitsFromConfig returns the backend limits set in the config
+func BackendLimitsFromConfig(cfg *models.TestConfig) BackendLimits {
+	return BackendLimits{
+		MemoryBytes: int64(cfg.BackendMemoryMB) << 20,
+		CPUPercent:  cfg.BackendCPUPercent,
+		Nice:        cfg.BackendNice,
+	}
+}
+
+// Where limits are only watched, a backend is killed when it goes over
+// its memory limit, or over its CPU limit for runawayAfter in a row.
+// Backends legitimately burst during speed tests, which take seconds.
+const (
+	watchInterval = 500 * time.Millisecond
+	runawayAfter  = 30 * time.Second
+)
+
+// SetLimits sets the resource limits of the backend process
+func (pm *ProxyManager) SetLimits(limits BackendLimits) {
+	pm.limits = limits
+}
+
+// SetSandbox sets how the backend process is confined
+func (pm *ProxyManager) SetSandbox(options sandbox.Options) {
+	pm.sandbox = options
+}
+
+// SandboxFromConfig returns the backend sandbox options set in the config
+func SandboxFromConfig(cfg *models.TestConfig) sandbox.Options {
+	return sandbox.Options{
+		Confine:         cfg.BackendSandbox,
+		User:            cfg.BackendUser,
+		AppArmorProfile: cfg.BackendAppArmorProfile,
+	}
+}
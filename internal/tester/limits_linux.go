@@ -0,0 +1,226 @@
+//go:build linux
+
+package tester
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// cgroupRoot is where the cgroup v2 hierarchy is mounted
+const cgroupRoot = "/sys/fs/cgroup"
+
+// clockTicks is USER_HZ, the unit of CPU times in /proc, which is 100 on
+// every Linux architecture Go supports
+const clockTicks = 100
+
+// processLimiter applies BackendLimits to one backend process
+type processLimiter struct {
+	limits BackendLimits
+	cgroup string // the backend's cgroup directory; "" when watched instead
+
+	stop chan struct{}
+	done chan struct{}
+
+	mu     sync.Mutex
+	killed string // why the watchdog killed the process
+}
+
+func newProcessLimiter(limits BackendLimits) *processLimiter {
+	return &processLimiter{limits: limits}
+}
+
+// attach confines a started process. It joins a cgroup of its own where
+// the cgroup v2 hierarchy is writable, e.g. as root or with delegation;
+// otherwise the process is watched and killed when it runs away.
+func (l *processLimiter) attach(process *os.Process) {
+	if l.limits.Nice != 0 {
+		unix.Setpriority(unix.PRIO_PROCESS, process.Pid, l.limits.Nice)
+	}
+	if l.limits.MemoryBytes <= 0 && l.limits.CPUPercent <= 0 {
+		return
+	}
+
+	if dir, err := createBackendCgroup(l.limits); err == nil {
+		procs := filepath.Join(dir, "cgroup.procs")
+		if err := os.WriteFile(procs, []byte(strconv.Itoa(process.Pid)), 0); err == nil {
+			l.cgroup = dir
+			return
+		}
+		os.Remove(dir)
+	}
+
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.watch(process)
+}
+
+// createBackendCgroup creates a cgroup below ProtoScope's own with the
+// memory and CPU limits set
+func createBackendCgroup(limits BackendLimits) (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("no cgroup v2 hierarchy: %w", err)
+	}
+	self, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	var parent string
+	for _, line := range strings.Split(string(self), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			parent = filepath.Join(cgroupRoot, path)
+		}
+	}
+	if parent == "" {
+		return "", fmt.Errorf("not in a cgroup v2 group")
+	}
+
+	// Children only get the controllers their parent passes down; this is
+	// refused when they are already on or the parent holds processes itself
+	os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+memory +cpu"), 0)
+
+	dir, err := os.MkdirTemp(parent, "protoscope-backend-")
+	if err != nil {
+		return "", err
+	}
+	settings := make(map[string]string)
+	if limits.MemoryBytes > 0 {
+		settings["memory.max"] = strconv.FormatInt(limits.MemoryBytes, 10)
+	}
+	if limits.CPUPercent > 0 {
+		// Quota per 100ms period; 1000µs is 1% of a core
+		settings["cpu.max"] = fmt.Sprintf("%d 100000", limits.CPUPercent*1000)
+	}
+	for name, value := range settings {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0); err != nil {
+			os.Remove(dir)
+			return "", err
+		}
+	}
+	// Swapping would dodge the memory limit; not every kernel has swap
+	// accounting
+	os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0)
+	return dir, nil
+}
+
+// watch polls the process's memory and CPU use and kills it when it goes
+// over its memory limit or stays over its CPU limit for runawayAfter
+func (l *processLimiter) watch(process *os.Process) {
+	defer close(l.done)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	var lastCPU, over time.Duration
+	first := true
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		cpu, rss, err := procUsage(process.Pid)
+		if err != nil {
+			return // exited
+		}
+		if l.limits.MemoryBytes > 0 && rss > l.limits.MemoryBytes {
+			l.kill(process, fmt.Sprintf("using %d MB, over its %d MB memory limit", rss>>20, l.limits.MemoryBytes>>20))
+			return
+		}
+		if l.limits.CPUPercent > 0 && !first {
+			percent := float64(cpu-lastCPU) / float64(watchInterval) * 100
+			if percent > float64(l.limits.CPUPercent) {
+				over += watchInterval
+			} else {
+				over = 0
+			}
+			if over >= runawayAfter {
+				l.kill(process, fmt.Sprintf("over its %d%% CPU limit for %s", l.limits.CPUPercent, runawayAfter))
+				return
+			}
+		}
+		lastCPU, first = cpu, false
+	}
+}
+
+// kill stops a runaway process and records why
+func (l *processLimiter) kill(process *os.Process, reason string) {
+	l.mu.Lock()
+	l.killed = reason
+	l.mu.Unlock()
+	process.Kill()
+}
+
+// procUsage returns the CPU time and resident memory of a process
+func procUsage(pid int) (time.Duration, int64, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	// The command name may contain spaces and parentheses; the fields
+	// after it start with the state, field 3 of proc(5)
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, 0, fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 22 {
+		return 0, 0, fmt.Errorf("malformed /proc/%d/stat", pid)
+	}
+	utime, _ := strconv.ParseInt(fields[11], 10, 64)
+	stime, _ := strconv.ParseInt(fields[12], 10, 64)
+	rss, _ := strconv.ParseInt(fields[21], 10, 64)
+	if fields[0] == "Z" {
+		return 0, 0, fmt.Errorf("process %d exited", pid)
+	}
+	cpu := time.Duration(utime+stime) * time.Second / clockTicks
+	return cpu, rss * int64(os.Getpagesize()), nil
+}
+
+// reason explains why the process was killed for its limits, or returns ""
+func (l *processLimiter) reason() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.killed == "" && l.cgroup != "" && cgroupOOMKilled(l.cgroup) {
+		l.killed = fmt.Sprintf("over its %d MB memory limit", l.limits.MemoryBytes>>20)
+	}
+	return l.killed
+}
+
+// cgroupOOMKilled reports whether the kernel killed a process of the cgroup
+// for going over memory.max
+func cgroupOOMKilled(dir string) bool {
+	events, err := os.ReadFile(filepath.Join(dir, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(events), "\n") {
+		if count, ok := strings.CutPrefix(line, "oom_kill "); ok && count != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// release stops watching and removes the cgroup, once the process exited.
+// The kill reason is kept.
+func (l *processLimiter) release() {
+	if l.stop != nil {
+		close(l.stop)
+		<-l.done
+		l.stop = nil
+	}
+	if l.cgroup != "" {
+		l.reason()
+		os.Remove(l.cgroup)
+		l.cgroup = ""
+	}
+}
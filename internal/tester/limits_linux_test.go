@@ -0,0 +1,50 @@
+//go:build linux
+
+package tester
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcUsage(t *testing.T) {
+	cpu, rss, err := procUsage(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpu < 0 || rss <= 0 {
+		t.Errorf("procUsage = %s CPU, %d bytes RSS", cpu, rss)
+	}
+}
+
+func TestWatchKillsOverMemory(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skip("no sleep command:", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	// Watched directly; attach would prefer a cgroup where it can make one
+	l := newProcessLimiter(BackendLimits{MemoryBytes: 4096})
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.watch(cmd.Process)
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("process over its memory limit was not killed")
+	}
+	l.release()
+	if !strings.Contains(l.reason(), "memory limit") {
+		t.Errorf("reason %q, want the memory limit", l.reason())
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !linux && !windows
+
+package tester
+
+import (
+	"os"
+	"syscall"
+)
+
+// processLimiter applies BackendLimits to one backend process. Without
+// cgroups or job objects only the niceness is applied.
+type processLimiter struct {
+	limits BackendLimits
+}
+
+func newProcessLimiter(limits BackendLimits) *processLimiter {
+	return &processLimiter{limits: limits}
+}
+
+// attach lowers the priority of a started process
+func (l *processLimiter) attach(process *os.Process) {
+	if l.limits.Nice != 0 {
+		syscall.Setpriority(syscall.PRIO_PROCESS, process.Pid, l.limits.Nice)
+	}
+}
+
+// reason returns "": processes are never killed for their limits here
+func (l *processLimiter) reason() string {
+	return ""
+}
+
+// release does nothing
+func (l *processLimiter) release() {}
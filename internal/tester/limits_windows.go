@@ -0,0 +1,95 @@
+//go:build windows
+
+package tester
+
+import (
+	"os"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// JOBOBJECT_CPU_RATE_CONTROL_INFORMATION, missing from x/sys/windows
+type jobCPURateControl struct {
+	ControlFlags uint32
+	CPURate      uint32 // in 1/100 of a percent of all processors
+}
+
+const (
+	jobCPURateControlEnable  = 0x1
+	jobCPURateControlHardCap = 0x4
+)
+
+// processLimiter applies BackendLimits to one backend process through a
+// job object, which Windows enforces itself
+type processLimiter struct {
+	limits BackendLimits
+	job    windows.Handle
+}
+
+func newProcessLimiter(limits BackendLimits) *processLimiter {
+	return &processLimiter{limits: limits}
+}
+
+// attach puts a started process into a job object with its limits. A
+// process over its memory limit fails to allocate, which crashes the
+// backend; CPU beyond the limit is not scheduled.
+func (l *processLimiter) attach(process *os.Process) {
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE|windows.PROCESS_SET_INFORMATION, false, uint32(process.Pid))
+	if err != nil {
+		return
+	}
+	defer windows.CloseHandle(handle)
+
+	if l.limits.Nice > 0 {
+		windows.SetPriorityClass(handle, windows.BELOW_NORMAL_PRIORITY_CLASS)
+	}
+	if l.limits.MemoryBytes <= 0 && l.limits.CPUPercent <= 0 {
+		return
+	}
+
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return
+	}
+
+	// Closing the job, in release or when ProtoScope dies, kills the backend
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if l.limits.MemoryBytes > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_PROCESS_MEMORY
+		info.ProcessMemoryLimit = uintptr(l.limits.MemoryBytes)
+	}
+	windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)))
+
+	if l.limits.CPUPercent > 0 {
+		rate := min(max(l.limits.CPUPercent*100/runtime.NumCPU(), 1), 10000)
+		cpu := jobCPURateControl{
+			ControlFlags: jobCPURateControlEnable | jobCPURateControlHardCap,
+			CPURate:      uint32(rate),
+		}
+		windows.SetInformationJobObject(job, windows.JobObjectCpuRateControlInformation,
+			uintptr(unsafe.Pointer(&cpu)), uint32(unsafe.Sizeof(cpu)))
+	}
+
+	if err := windows.AssignProcessToJobObject(job, handle); err != nil {
+		windows.CloseHandle(job)
+		return
+	}
+	l.job = job
+}
+
+// reason returns "": Windows doesn't say a process crashed over its limit
+func (l *processLimiter) reason() string {
+	return ""
+}
+
+// release closes the job object
+func (l *processLimiter) release() {
+	if l.job != 0 {
+		windows.CloseHandle(l.job)
+		l.job = 0
+	}
+}
@@ -10,10 +10,13 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"sync"
 	"time"
 
 	"golang.org/x/net/proxy"
 
+	"github.com/VenoMexx/ProtoScope/internal/ratelimit"
+	"github.com/VenoMexx/ProtoScope/internal/sandbox"
 	"github.com/VenoMexx/ProtoScope/pkg/models"
 )
 
@@ -24,11 +27,30 @@ type ProxyManager struct {
 	proxyCmd     *exec.Cmd
 	socksAddress string
 	socksPort    int
+	inboundType  string
 	configFile   string
+	runDir       string // private directory holding configFile
+	configStdin  bool   // pass the config on stdin where the backend reads it
 	isRunning    bool
 	stderrBuf    *bytes.Buffer
 	stdoutBuf    *bytes.Buffer
 	verbose      bool
+	limiter      *ratelimit.HostLimiter
+	mock         *mockTransport
+	mockReplay   []MockResponse
+	chaos        *chaos
+	metricsPort  int // xray metrics server exposing traffic counters
+	httpPort     int // xray's HTTP inbound beside a mixed SOCKS one
+	limits       BackendLimits
+	resources    *processLimiter // confines the running backend process
+	sandbox      sandbox.Options
+	ports        []int         // ports from allocatePort, released on Stop
+	portPool     *PortPool     // nil takes any free port
+	exited       chan struct{} // closed when the backend process exits
+	exitErr      error
+	lowMemory    bool // keep connection pools small
+	transportsMu sync.Mutex
+	transports   []*http.Transport // of the clients handed out, closed on Stop
 }
 
 // NewProxyManager creates a new proxy manager
@@ -39,6 +61,8 @@ func NewProxyManager(protocol *models.Protocol, socksPort int) *ProxyManager {
 		backend:      backend,
 		socksAddress: "127.0.0.1",
 		socksPort:    socksPort,
+		inboundType:  "socks",
+		configStdin:  true,
 		isRunning:    false,
 		stderrBuf:    &bytes.Buffer{},
 		stdoutBuf:    &bytes.Buffer{},
@@ -51,8 +75,89 @@ func (pm *ProxyManager) SetVerbose(verbose bool) {
 	pm.verbose = verbose
 }
 
+// SetLowMemory keeps the proxy's HTTP connection pools small
+func (pm *ProxyManager) SetLowMemory(lowMemory bool) {
+	pm.lowMemory = lowMemory
+}
+
+// SetListenAddress changes the address the local inbound listens on
+func (pm *ProxyManager) SetListenAddress(address string) {
+	pm.socksAddress = address
+}
+
+// SOCKSAddress returns the host:port of the local SOCKS5 inbound
+func (pm *ProxyManager) SOCKSAddress() string {
+	return fmt.Sprintf("%s:%d", pm.socksAddress, pm.socksPort)
+}
+
+// HTTPAddress returns the host:port HTTP proxy clients connect to with a
+// mixed inbound: the SOCKS port on sing-box, a port of its own on xray
+func (pm *ProxyManager) HTTPAddress() string {
+	if pm.httpPort > 0 {
+		return fmt.Sprintf("%s:%d", pm.socksAddress, pm.httpPort)
+	}
+	return pm.SOCKSAddress()
+}
+
+// allowInsecure reports whether the node's config turns certificate
+// verification off, as for servers with self-signed certificates
+func (pm *ProxyManager) allowInsecure() bool {
+	insecure, _ := pm.protocol.Extra["insecure"].(string)
+	return insecure == "1"
+}
+
+// SetMixedInbound makes the local inbound accept both SOCKS and HTTP proxy
+// clients. xray can't serve both on one port, so it gets an HTTP inbound
+// on a second port; see HTTPAddress.
+func (pm *ProxyManager) SetMixedInbound(mixed bool) {
+	if mixed {
+		pm.inboundType = "mixed"
+	} else {
+		pm.inboundType = "socks"
+	}
+}
+
+// SetBackend overrides the automatically selected backend
+func (pm *ProxyManager) SetBackend(backend ProxyBackend) {
+	pm.backend = backend
+}
+
+// SetMockReplay sets canned responses replayed by the mock backend
+func (pm *ProxyManager) SetMockReplay(responses []MockResponse) {
+	pm.mockReplay = responses
+}
+
+// SetRateLimiter throttles requests made through the proxy's HTTP clients
+// with a limiter shared across workers
+func (pm *ProxyManager) SetRateLimiter(limiter *ratelimit.HostLimiter) {
+	pm.limiter = limiter
+}
+
+// SetChaos injects artificial latency, timeouts and backend crashes
+func (pm *ProxyManager) SetChaos(options ChaosOptions) {
+	if !options.Enabled() {
+		pm.chaos = nil
+		return
+	}
+	pm.chaos = newChaos(options, pm.protocol)
+	pm.chaos.onCrash = pm.crash
+}
+
+// crash simulates the backend dying while the proxy is in use
+func (pm *ProxyManager) crash() {
+	fmt.Fprintf(pm.stderrBuf, "chaos: backend %s crashed\n", pm.backend)
+	if pm.proxyCmd != nil && pm.proxyCmd.Process != nil {
+		killBackend(pm.proxyCmd.Process)
+	}
+}
+
 // GetBackendLogs returns captured backend logs
 func (pm *ProxyManager) GetBackendLogs() string {
+	if pm.resources != nil {
+		if reason := pm.resources.reason(); reason != "" {
+			return fmt.Sprintf("%s killed: %s\n%s", pm.backend, reason, pm.stderrBuf.String())
+		}
+	}
 	if pm.stderrBuf.Len() > 0 {
 		return pm.stderrBuf.String()
 	}
@@ -75,12 +180,37 @@ func (pm *ProxyManager) Start(ctx context.Context) error {
 		return fmt.Errorf("%s binary not found (please install %s)", pm.backend, pm.backend)
 	}
 
+	// The mock backend simulates the proxy in-process
+	if pm.backend == BackendMock {
+		return pm.startMock()
+	}
+
 	// Generate config based on backend
 	var config map[string]interface{}
 	var err error
 
+	// Ports are given back when the backend doesn't come up
+	defer func() {
+		if !pm.isRunning {
+			pm.releasePorts()
+		}
+	}()
+	if pm.socksPort == 0 {
+		if pm.socksPort, err = pm.allocatePort(); err != nil {
+			return fmt.Errorf("failed to reserve SOCKS port: %w", err)
+		}
+	}
+
 	switch pm.backend {
 	case BackendXray:
+		if pm.metricsPort, err = pm.allocatePort(); err != nil {
+			return fmt.Errorf("failed to reserve xray metrics port: %w", err)
+		}
+		if pm.inboundType == "mixed" {
+			if pm.httpPort, err = pm.allocatePort(); err != nil {
+				return fmt.Errorf("failed to reserve xray HTTP port: %w", err)
+			}
+		}
 		config, err = pm.generateXrayConfig()
 	case BackendSingbox:
 		config, err = pm.generateSingboxConfig()
@@ -92,30 +222,47 @@ func (pm *ProxyManager) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to generate config: %w", err)
 	}
 
-	// Write config to temp file
-	configFile, err := pm.writeConfigFile(config)
+	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+		return fmt.Errorf("failed to encode config: %w", err)
 	}
-	pm.configFile = configFile
 
-	// Get binary path
-	binaryName := GetBackendBinary(pm.backend)
-	binaryPath, err := exec.LookPath(binaryName)
+	binaryPath, err := FindBackend(pm.backend)
 	if err != nil {
-		return fmt.Errorf("%s binary not found: %w", binaryName, err)
+		return fmt.Errorf("%s binary not found: %w", GetBackendBinary(pm.backend), err)
 	}
 
-	// Start proxy process
-	var args []string
-	switch pm.backend {
-	case BackendXray:
-		args = []string{"run", "-c", configFile}
-	case BackendSingbox:
+	// Node credentials stay off the disk when the backend reads its config
+	// from stdin; otherwise they go to a private file shredded on Stop
+	args := stdinConfigArgs(pm.backend)
+	var readable []string
+	if !pm.configStdin || args == nil {
+		configFile, err := pm.writeConfigFile(data)
+		if err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+		pm.configFile = configFile
 		args = []string{"run", "-c", configFile}
+		readable = []string{pm.runDir, configFile}
 	}
 
-	pm.proxyCmd = exec.CommandContext(ctx, binaryPath, args...)
+	if pm.sandbox.Enabled() {
+		pm.proxyCmd, err = sandbox.Command(ctx, pm.sandbox, binaryPath, args, readable...)
+		if err != nil {
+			pm.removeConfigFile()
+			return fmt.Errorf("failed to sandbox %s: %w", pm.backend, err)
+		}
+	} else {
+		pm.proxyCmd = exec.CommandContext(ctx, binaryPath, args...)
+	}
+	if pm.configFile == "" {
+		pm.proxyCmd.Stdin = bytes.NewReader(data)
+	}
+	prepareBackend(pm.proxyCmd)
+	cmd := pm.proxyCmd
+	cmd.Cancel = func() error {
+		return killBackend(cmd.Process)
+	}
 
 	// Capture stdout and stderr for diagnostics
 	if pm.verbose {
@@ -129,8 +276,16 @@ func (pm *ProxyManager) Start(ctx context.Context) error {
 	}
 
 	if err := pm.proxyCmd.Start(); err != nil {
+		pm.removeConfigFile()
 		return fmt.Errorf("failed to start %s: %w", pm.backend, err)
 	}
+	pm.resources = newProcessLimiter(pm.limits)
+	pm.resources.attach(pm.proxyCmd.Process)
+	pm.exited = make(chan struct{})
+	go func() {
+		pm.exitErr = cmd.Wait()
+		close(pm.exited)
+	}()
 
 	// Wait for proxy to be ready
 	if err := pm.waitForProxy(ctx, 10*time.Second); err != nil {
@@ -144,25 +299,50 @@ func (pm *ProxyManager) Start(ctx context.Context) error {
 
 // Stop stops the proxy
 func (pm *ProxyManager) Stop() error {
-	if pm.proxyCmd != nil && pm.proxyCmd.Process != nil {
-		pm.proxyCmd.Process.Kill()
-		pm.proxyCmd.Wait()
+	if pm.exited != nil {
+		killBackend(pm.proxyCmd.Process)
+		<-pm.exited
 	}
-
-	if pm.configFile != "" {
-		os.Remove(pm.configFile)
+	if pm.resources != nil {
+		pm.resources.release()
 	}
 
+	pm.closeTransports()
+	pm.removeConfigFile()
+	pm.releasePorts()
+
 	pm.isRunning = false
 	return nil
 }
 
+// closeTransports drops the idle connections of every client handed out,
+// which would otherwise linger until their idle timeout
+func (pm *ProxyManager) closeTransports() {
+	pm.transportsMu.Lock()
+	defer pm.transportsMu.Unlock()
+	for _, transport := range pm.transports {
+		transport.CloseIdleConnections()
+	}
+	pm.transports = nil
+}
+
 // GetHTTPClient returns an HTTP client configured to use the proxy
 func (pm *ProxyManager) GetHTTPClient(timeout time.Duration) (*http.Client, error) {
+	return pm.NewHTTPClient(timeout, true)
+}
+
+// NewHTTPClient returns an HTTP client through the proxy with its own
+// connection pool. Without keepAlive every request opens a new connection
+// through the tunnel, so repeated measurements time the same thing.
+func (pm *ProxyManager) NewHTTPClient(timeout time.Duration, keepAlive bool) (*http.Client, error) {
 	if !pm.isRunning {
 		return nil, fmt.Errorf("proxy is not running")
 	}
 
+	if pm.mock != nil {
+		return &http.Client{Transport: pm.wrapTransport(pm.mock), Timeout: timeout}, nil
+	}
+
 	// Create SOCKS5 dialer
 	dialer, err := proxy.SOCKS5("tcp",
 		fmt.Sprintf("%s:%d", pm.socksAddress, pm.socksPort),
@@ -181,22 +361,47 @@ func (pm *ProxyManager) GetHTTPClient(timeout time.Duration) (*http.Client, erro
 		MaxIdleConns:        100,
 		IdleConnTimeout:     90 * time.Second,
 		TLSHandshakeTimeout: 10 * time.Second,
+		DisableKeepAlives:   !keepAlive,
+	}
+	if pm.lowMemory {
+		// Every idle connection holds buffers on both ends of the tunnel
+		transport.MaxIdleConns = 4
+		transport.MaxIdleConnsPerHost = 1
+		transport.IdleConnTimeout = 10 * time.Second
 	}
+	pm.transportsMu.Lock()
+	pm.transports = append(pm.transports, transport)
+	pm.transportsMu.Unlock()
 
 	client := &http.Client{
-		Transport: transport,
+		Transport: pm.wrapTransport(transport),
 		Timeout:   timeout,
 	}
 
 	return client, nil
 }
 
+// wrapTransport layers chaos faults and rate limiting over the proxy transport
+func (pm *ProxyManager) wrapTransport(base http.RoundTripper) http.RoundTripper {
+	if pm.chaos != nil {
+		base = &chaosTransport{Base: base, chaos: pm.chaos}
+	}
+	if pm.limiter != nil {
+		base = &ratelimit.Transport{Base: base, Limiter: pm.limiter}
+	}
+	return base
+}
+
 // GetDialer returns a proxy dialer
 func (pm *ProxyManager) GetDialer() (proxy.Dialer, error) {
 	if !pm.isRunning {
 		return nil, fmt.Errorf("proxy is not running")
 	}
 
+	if pm.mock != nil {
+		return pm.mock, nil
+	}
+
 	dialer, err := proxy.SOCKS5("tcp",
 		fmt.Sprintf("%s:%d", pm.socksAddress, pm.socksPort),
 		nil,
@@ -209,6 +414,19 @@ func (pm *ProxyManager) GetDialer() (proxy.Dialer, error) {
 	return dialer, nil
 }
 
+// startMock brings up the simulated proxy of the mock backend
+func (pm *ProxyManager) startMock() error {
+	mock := newMockTransport(pm.protocol, pm.mockReplay)
+	if mock.node.dead {
+		fmt.Fprintf(pm.stderrBuf, "mock: node %s is simulated as dead\n", pm.protocol.Name)
+		return fmt.Errorf("proxy failed to start: timeout waiting for proxy to start")
+	}
+
+	pm.mock = mock
+	pm.isRunning = true
+	return nil
+}
+
 // waitForProxy waits for the proxy to be ready
 func (pm *ProxyManager) waitForProxy(ctx context.Context, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
@@ -217,6 +435,10 @@ func (pm *ProxyManager) waitForProxy(ctx context.Context, timeout time.Duration)
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-pm.exited:
+			// Don't wait out the timeout for a backend that died, e.g. on a
+			// config it rejected
+			return fmt.Errorf("%s exited before listening: %v", pm.backend, pm.exitErr)
 		default:
 		}
 
@@ -236,28 +458,6 @@ func (pm *ProxyManager) waitForProxy(ctx context.Context, timeout time.Duration)
 	return fmt.Errorf("timeout waiting for proxy to start")
 }
 
-// writeConfigFile writes config to a temporary file
-func (pm *ProxyManager) writeConfigFile(config map[string]interface{}) (string, error) {
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return "", err
-	}
-
-	tmpFile, err := os.CreateTemp("", "xray-config-*.json")
-	if err != nil {
-		return "", err
-	}
-
-	if _, err := tmpFile.Write(data); err != nil {
-		tmpFile.Close()
-		os.Remove(tmpFile.Name())
-		return "", err
-	}
-
-	tmpFile.Close()
-	return tmpFile.Name(), nil
-}
-
 // generateXrayConfig generates Xray configuration
 func (pm *ProxyManager) generateXrayConfig() (map[string]interface{}, error) {
 	config := map[string]interface{}{
@@ -276,6 +476,13 @@ func (pm *ProxyManager) generateXrayConfig() (map[string]interface{}, error) {
 		},
 		"outbounds": []map[string]interface{}{},
 	}
+	if pm.httpPort > 0 {
+		config["inbounds"] = append(config["inbounds"].([]map[string]interface{}), map[string]interface{}{
+			"port":     pm.httpPort,
+			"protocol": "http",
+			"listen":   pm.socksAddress,
+		})
+	}
 
 	// Generate outbound based on protocol type
 	var outbound map[string]interface{}
@@ -298,8 +505,13 @@ func (pm *ProxyManager) generateXrayConfig() (map[string]interface{}, error) {
 		return nil, err
 	}
 
+	outbound["tag"] = xrayOutboundTag
 	config["outbounds"] = []map[string]interface{}{outbound}
 
+	if pm.metricsPort > 0 {
+		xrayStatsConfig(config, pm.metricsPort)
+	}
+
 	return config, nil
 }
 
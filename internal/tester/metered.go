@@ -0,0 +1,54 @@
+package tester
+
+import "sync"
+
+// dataBudget is the download data left for a metered run, shared by all
+// workers
+type dataBudget struct {
+	mu        sync.Mutex
+	remaining int64
+}
+
+// reserve takes up to n bytes from the budget and returns how many it got
+func (b *dataBudget) reserve(n int64) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n = max(min(n, b.remaining), 0)
+	b.remaining -= n
+	return n
+}
+
+// release returns bytes reserved but not downloaded
+func (b *dataBudget) release(n int64) {
+	if n <= 0 {
+		return
+	}
+	b.mu.Lock()
+	b.remaining += n
+	b.mu.Unlock()
+}
+
+// resetBudget gives the next run the full MeteredRunBytes; a daemon reuses
+// the runner, and the cap is per run
+func (tr *TestRunner) resetBudget() {
+	tr.budgetMu.Lock()
+	tr.budget = nil
+	tr.budgetMu.Unlock()
+}
+
+// meteredCap reserves the download cap of the next node in metered mode
+// from the run's budget, which gets back what the node doesn't use. It
+// returns a nil budget when the run is not metered.
+func (tr *TestRunner) meteredCap() (*dataBudget, int64) {
+	testConfig := tr.config.TestConfig
+	if !testConfig.Metered {
+		return nil, 0
+	}
+	tr.budgetMu.Lock()
+	if tr.budget == nil {
+		tr.budget = &dataBudget{remaining: testConfig.MeteredRunBytes}
+	}
+	budget := tr.budget
+	tr.budgetMu.Unlock()
+	return budget, budget.reserve(testConfig.MeteredNodeBytes)
+}
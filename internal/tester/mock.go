@@ -0,0 +1,219 @@
+package tester
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// MockResponse is a canned HTTP response replayed by the mock backend.
+// URL matches by prefix, so "https://api.ipify.org" matches any path there.
+type MockResponse struct {
+	URL     string            `json:"url"`
+	Status  int               `json:"status"`
+	Body    string            `json:"body,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Delay   string            `json:"delay,omitempty"` // e.g. "150ms"
+	Error   string            `json:"error,omitempty"` // simulate a network error instead
+}
+
+// LoadMockReplay loads canned responses from a JSON file (array of MockResponse)
+func LoadMockReplay(path string) ([]MockResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock replay file: %w", err)
+	}
+
+	var responses []MockResponse
+	if err := json.Unmarshal(data, &responses); err != nil {
+		return nil, fmt.Errorf("failed to parse mock replay file: %w", err)
+	}
+	return responses, nil
+}
+
+// mockNode describes the simulated behavior of one node. It is derived from
+// the node itself so runs are deterministic.
+type mockNode struct {
+	dead    bool
+	latency time.Duration
+	exitIP  string
+}
+
+// newMockNode derives simulated behavior for a protocol. Nodes whose name
+// contains "dead" or "fail" never come up; everything else gets a stable
+// latency and exit IP from a hash of the name and server.
+func newMockNode(protocol *models.Protocol) mockNode {
+	h := fnv.New32a()
+	h.Write([]byte(protocol.Name))
+	h.Write([]byte(protocol.Server))
+	sum := h.Sum32()
+
+	name := strings.ToLower(protocol.Name)
+	return mockNode{
+		dead:    strings.Contains(name, "dead") || strings.Contains(name, "fail"),
+		latency: time.Duration(20+sum%60) * time.Millisecond,
+		exitIP:  fmt.Sprintf("203.0.113.%d", 1+sum%254),
+	}
+}
+
+// mockTransport answers HTTP requests with canned responses instead of
+// going through a real proxy
+type mockTransport struct {
+	node      mockNode
+	responses []MockResponse
+}
+
+// newMockTransport creates the mock transport for a node
+func newMockTransport(protocol *models.Protocol, responses []MockResponse) *mockTransport {
+	return &mockTransport{
+		node:      newMockNode(protocol),
+		responses: responses,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (m *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	response := m.lookup(req)
+
+	delay := m.node.latency
+	if response.Delay != "" {
+		if d, err := time.ParseDuration(response.Delay); err == nil {
+			delay = d
+		}
+	}
+
+	select {
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	case <-time.After(delay):
+	}
+
+	if response.Error != "" {
+		return nil, fmt.Errorf("mock: %s", response.Error)
+	}
+
+	header := make(http.Header)
+	for key, value := range response.Headers {
+		header.Set(key, value)
+	}
+
+	var body io.ReadCloser = io.NopCloser(strings.NewReader(response.Body))
+	contentLength := int64(len(response.Body))
+
+	// Speed test downloads stream the requested number of zero bytes
+	if size := req.URL.Query().Get("bytes"); size != "" && response.Body == "" {
+		if n, err := strconv.ParseInt(size, 10, 64); err == nil {
+			body = io.NopCloser(io.LimitReader(zeroReader{}, n))
+			contentLength = n
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.Status, http.StatusText(response.Status)),
+		StatusCode:    response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: contentLength,
+		Request:       req,
+	}, nil
+}
+
+// lookup returns the replayed response for a request, falling back to the
+// built-in canned responses
+func (m *mockTransport) lookup(req *http.Request) MockResponse {
+	url := req.URL.String()
+	for _, response := range m.responses {
+		if strings.HasPrefix(url, response.URL) {
+			if response.Status == 0 {
+				response.Status = http.StatusOK
+			}
+			return response
+		}
+	}
+
+	host := req.URL.Hostname()
+	switch {
+	case strings.HasSuffix(req.URL.Path, "generate_204"), host == "cp.cloudflare.com":
+		return MockResponse{Status: http.StatusNoContent}
+	case strings.HasPrefix(host, "ipv6.") || strings.HasPrefix(host, "api6."):
+		return MockResponse{Error: "network is unreachable"}
+	case host == "api.ipify.org", host == "ifconfig.me", host == "icanhazip.com":
+		return MockResponse{Status: http.StatusOK, Body: m.node.exitIP}
+	case host == "api.myip.com":
+		return MockResponse{Status: http.StatusOK, Body: fmt.Sprintf(`{"ip":"%s"}`, m.node.exitIP)}
+	case host == "ip-api.com":
+		return MockResponse{Status: http.StatusOK, Body: fmt.Sprintf(`{"status":"success","countryCode":"NL","country":"Netherlands","city":"Amsterdam","as":"AS24940 Hetzner Online GmbH","query":"%s"}`, m.node.exitIP)}
+	case host == "ip-ranges.amazonaws.com":
+		// Half of the mock exit IPs are in AWS, the rest fall back to the ASN
+		return MockResponse{Status: http.StatusOK, Body: `{"prefixes":[{"ip_prefix":"203.0.113.0/25","region":"eu-central-1","service":"EC2"}]}`}
+	case host == "sigfail.verteiltesysteme.net", host == "www.dnssec-failed.org":
+		// Mock nodes resolve through a validating resolver
+		return MockResponse{Error: "dial tcp: lookup " + host + ": server misbehaving"}
+	case strings.Contains(host, "dnsleaktest.com"):
+		return MockResponse{Status: http.StatusOK, Body: "[]"}
+	case req.URL.Query().Has("bytes"):
+		// Empty, so the transport streams the requested size
+		return MockResponse{Status: http.StatusOK}
+	default:
+		return MockResponse{Status: http.StatusOK, Body: "<html><body>mock</body></html>"}
+	}
+}
+
+// Dial implements proxy.Dialer. The returned connection answers a single
+// plain HTTP request with the same canned responses as the transport.
+func (m *mockTransport) Dial(network, addr string) (net.Conn, error) {
+	// Like many VPS exits, mock nodes block outbound SMTP
+	if _, port, _ := net.SplitHostPort(addr); port == "25" {
+		return nil, fmt.Errorf("dial tcp %s: connection refused", addr)
+	}
+
+	client, server := net.Pipe()
+
+	go func() {
+		defer server.Close()
+
+		req, err := http.ReadRequest(bufio.NewReader(server))
+		if err != nil {
+			return
+		}
+		req.URL.Scheme = "http"
+		req.URL.Host = addr
+
+		resp, err := m.RoundTrip(req.WithContext(context.Background()))
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+
+		var buf bytes.Buffer
+		resp.Write(&buf)
+		server.Write(buf.Bytes())
+	}()
+
+	return client, nil
+}
+
+// zeroReader yields an endless stream of zero bytes
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,22 @@
+package tester
+
+import (
+	"math/rand"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// ShuffleProtocols returns a copy of protocols in a random order derived
+// from seed. The same seed always yields the same order, so a shuffled run
+// can be reproduced exactly.
+func ShuffleProtocols(protocols []*models.Protocol, seed int64) []*models.Protocol {
+	shuffled := make([]*models.Protocol, len(protocols))
+	copy(shuffled, protocols)
+
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	return shuffled
+}
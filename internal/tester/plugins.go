@@ -0,0 +1,50 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/checks"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// pluginTimeout is the request timeout of a WASM plugin's HTTP client,
+// before protocol scaling
+const pluginTimeout = 30 * time.Second
+
+// stages returns the built-in checks followed by one stage per configured
+// plugin, named "plugin:<name>"
+func (tr *TestRunner) stages() []checkStage {
+	stages := defaultStages()
+	for _, plugin := range tr.config.Plugins {
+		stages = append(stages, pluginStage(plugin))
+	}
+	return stages
+}
+
+// pluginStage runs an external check and stores its output under the
+// plugin's name
+func pluginStage(plugin models.PluginConfig) checkStage {
+	return checkStage{
+		name: "plugin:" + plugin.Name,
+		run: func(ctx context.Context, env *stageEnv) error {
+			var pluginResult *models.PluginResult
+			var err error
+			switch {
+			case plugin.WASM != "":
+				// The sandbox reaches the network only through the node
+				pluginResult, err = checks.NewWASMPluginChecker(plugin).Check(ctx, env.checkClient(pluginTimeout, true), env.protocol)
+			case env.proxyMgr == nil:
+				return fmt.Errorf("no local proxy for plugin %s", plugin.Name)
+			default:
+				pluginResult, err = checks.NewPluginChecker(plugin).Check(ctx, env.proxyMgr.SOCKSAddress(), env.protocol)
+			}
+			if env.result.Plugins == nil {
+				env.result.Plugins = make(map[string]*models.PluginResult)
+			}
+			env.result.Plugins[plugin.Name] = pluginResult
+			return err
+		},
+	}
+}
@@ -0,0 +1,205 @@
+package tester
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A port taken from the kernel is only free until the backend binds it,
+// so ports handed out are remembered until released: concurrent workers
+// must not start two backends on the same one.
+var (
+	portsMu    sync.Mutex
+	portsInUse = make(map[int]bool)
+	// pooledRanges are the ranges of the parsed pools, kept from backends
+	// that take any free port
+	pooledRanges []portRange
+)
+
+// portAttempts bounds the search for a port nobody else holds
+const portAttempts = 20
+
+// allocatePort returns a free TCP port on localhost for a backend to
+// listen on, from pool if it isn't nil, and keeps it from other backends
+// until releasePort
+func allocatePort(pool *PortPool) (int, error) {
+	portsMu.Lock()
+	defer portsMu.Unlock()
+	if pool != nil {
+		return pool.allocate()
+	}
+	for attempt := 0; attempt < portAttempts; attempt++ {
+		port, err := freeLocalPort()
+		if err != nil {
+			return 0, err
+		}
+		if portsInUse[port] || PortReserved(port) || pooled(port) {
+			continue
+		}
+		portsInUse[port] = true
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free local port after %d attempts", portAttempts)
+}
+
+// allocatePort reserves a port for the manager's backend until Stop
+func (pm *ProxyManager) allocatePort() (int, error) {
+	port, err := allocatePort(pm.portPool)
+	if err == nil {
+		pm.ports = append(pm.ports, port)
+	}
+	return port, err
+}
+
+// releasePorts gives back the ports reserved for the manager's backend
+func (pm *ProxyManager) releasePorts() {
+	for _, port := range pm.ports {
+		releasePort(port)
+	}
+	pm.ports = nil
+}
+
+// SetPortPool makes the manager's backend listen on ports from pool
+func (pm *ProxyManager) SetPortPool(pool *PortPool) {
+	pm.portPool = pool
+}
+
+// PortPool is a range of local ports that a run's backends listen on, so
+// concurrent runs keep to their own ports
+type PortPool struct {
+	start, end int
+	next       int // where the search for a free port resumes
+}
+
+// ParsePortPool parses a port range such as "20000-20999"
+func ParsePortPool(s string) (*PortPool, error) {
+	first, last, found := strings.Cut(s, "-")
+	if !found {
+		last = first
+	}
+	start, err1 := strconv.Atoi(strings.TrimSpace(first))
+	end, err2 := strconv.Atoi(strings.TrimSpace(last))
+	if err1 != nil || err2 != nil || start < 1 || end > 65535 || start > end {
+		return nil, fmt.Errorf("invalid port range %q, want e.g. 20000-20999", s)
+	}
+	portsMu.Lock()
+	pooledRanges = append(pooledRanges, portRange{start, end})
+	portsMu.Unlock()
+	return &PortPool{start: start, end: end, next: start}, nil
+}
+
+// Split divides the pool into n pools of about equal size, one for each
+// of n concurrent runs
+func (p *PortPool) Split(n int) ([]*PortPool, error) {
+	size := p.end - p.start + 1
+	if n < 1 || size < n {
+		return nil, fmt.Errorf("port range %d-%d too small for %d runs", p.start, p.end, n)
+	}
+	pools := make([]*PortPool, n)
+	start := p.start
+	for i := range pools {
+		end := start + size/n - 1
+		if i < size%n {
+			end++
+		}
+		pools[i] = &PortPool{start: start, end: end, next: start}
+		start = end + 1
+	}
+	return pools, nil
+}
+
+// pooled reports whether port belongs to a parsed pool; portsMu must be
+// held
+func pooled(port int) bool {
+	for _, r := range pooledRanges {
+		if port >= r.start && port <= r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// allocate returns the next port of the pool that is free; portsMu must
+// be held
+func (p *PortPool) allocate() (int, error) {
+	size := p.end - p.start + 1
+	for i := 0; i < size; i++ {
+		port := p.next
+		if p.next++; p.next > p.end {
+			p.next = p.start
+		}
+		if portsInUse[port] || PortReserved(port) || !portFree(port) {
+			continue
+		}
+		portsInUse[port] = true
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free port in %d-%d", p.start, p.end)
+}
+
+// portFree reports whether port can be bound on localhost right now
+func portFree(port int) bool {
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// releasePort makes a port from allocatePort available again
+func releasePort(port int) {
+	portsMu.Lock()
+	delete(portsInUse, port)
+	portsMu.Unlock()
+}
+
+// freeLocalPort returns a TCP port on localhost that is free right now
+func freeLocalPort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// portRange is an inclusive range of ports
+type portRange struct {
+	start, end int
+}
+
+// PortReserved reports whether the OS keeps a port from being bound, as
+// Windows does for the port ranges Hyper-V, WSL and Docker exclude. Those
+// ranges change at boot and often cover common proxy ports.
+func PortReserved(port int) bool {
+	for _, r := range reservedPortRanges() {
+		if port >= r.start && port <= r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExcludedPortRanges parses the output of
+// "netsh interface ipv4 show excludedportrange protocol=tcp": a table of
+// start and end ports, some marked as administered with a trailing *
+func parseExcludedPortRanges(output string) []portRange {
+	var ranges []portRange
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		start, err1 := strconv.Atoi(fields[0])
+		end, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil || start > end {
+			continue
+		}
+		ranges = append(ranges, portRange{start, end})
+	}
+	return ranges
+}
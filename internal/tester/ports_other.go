@@ -0,0 +1,8 @@
+//go:build !windows
+
+package tester
+
+// reservedPortRanges returns nil: only Windows excludes port ranges
+func reservedPortRanges() []portRange {
+	return nil
+}
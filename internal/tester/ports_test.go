@@ -0,0 +1,98 @@
+package tester
+
+import "testing"
+
+func TestParseExcludedPortRanges(t *testing.T) {
+	output := `
+Protocol tcp Port Exclusion Ranges
+
+Start Port    End Port
+----------    --------
+      5357        5357
+     10801       10900
+     50000       50059     *
+
+* - Administered port exclusions.
+`
+	ranges := parseExcludedPortRanges(output)
+	want := []portRange{{5357, 5357}, {10801, 10900}, {50000, 50059}}
+	if len(ranges) != len(want) {
+		t.Fatalf("ranges = %v, want %v", ranges, want)
+	}
+	for i := range want {
+		if ranges[i] != want[i] {
+			t.Errorf("range %d = %v, want %v", i, ranges[i], want[i])
+		}
+	}
+}
+
+func TestAllocatePortUnique(t *testing.T) {
+	seen := make(map[int]bool)
+	for i := 0; i < 10; i++ {
+		port, err := allocatePort(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[port] {
+			t.Fatalf("port %d allocated twice", port)
+		}
+		seen[port] = true
+	}
+	for port := range seen {
+		releasePort(port)
+	}
+	if len(portsInUse) != 0 {
+		t.Errorf("ports still in use: %v", portsInUse)
+	}
+}
+
+func TestPortPool(t *testing.T) {
+	if _, err := ParsePortPool("30000-29999"); err == nil {
+		t.Error("expected an error for a reversed range")
+	}
+	pool, err := ParsePortPool("41000-41002")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ports []int
+	for i := 0; i < 3; i++ {
+		port, err := allocatePort(pool)
+		if err != nil {
+			t.Skipf("port range not free on this machine: %v", err)
+		}
+		if port < 41000 || port > 41002 {
+			t.Errorf("port %d outside the pool", port)
+		}
+		ports = append(ports, port)
+	}
+	if _, err := allocatePort(pool); err == nil {
+		t.Error("expected an error with the pool exhausted")
+	}
+	for _, port := range ports {
+		releasePort(port)
+	}
+	if _, err := allocatePort(pool); err != nil {
+		t.Errorf("released port not reused: %v", err)
+	}
+	for port := range portsInUse {
+		releasePort(port)
+	}
+}
+
+func TestPortPoolSplit(t *testing.T) {
+	pool := &PortPool{start: 20000, end: 20009, next: 20000}
+	if _, err := pool.Split(11); err == nil {
+		t.Error("expected an error with more runs than ports")
+	}
+	pools, err := pool.Split(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]int{{20000, 20003}, {20004, 20006}, {20007, 20009}}
+	for i, share := range pools {
+		if share.start != want[i][0] || share.end != want[i][1] || share.next != share.start {
+			t.Errorf("share %d = %d-%d, want %d-%d", i, share.start, share.end, want[i][0], want[i][1])
+		}
+	}
+}
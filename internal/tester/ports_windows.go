@@ -0,0 +1,29 @@
+//go:build windows
+
+package tester
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	reservedOnce   sync.Once
+	reservedRanges []portRange
+)
+
+// reservedPortRanges returns the TCP port ranges Windows excludes from
+// binding, read once per run
+func reservedPortRanges() []portRange {
+	reservedOnce.Do(func() {
+		cmd := exec.Command("netsh", "interface", "ipv4", "show", "excludedportrange", "protocol=tcp")
+		cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NO_WINDOW}
+		if out, err := cmd.Output(); err == nil {
+			reservedRanges = parseExcludedPortRanges(string(out))
+		}
+	})
+	return reservedRanges
+}
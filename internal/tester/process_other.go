@@ -0,0 +1,17 @@
+//go:build !windows
+
+package tester
+
+import (
+	"os"
+	"os/exec"
+)
+
+// prepareBackend leaves the backend in ProtoScope's process group, so a
+// Ctrl-C in the terminal also stops it when ProtoScope can't
+func prepareBackend(cmd *exec.Cmd) {}
+
+// killBackend kills a backend with SIGKILL; backends start no children
+func killBackend(process *os.Process) error {
+	return process.Kill()
+}
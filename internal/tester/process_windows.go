@@ -0,0 +1,33 @@
+//go:build windows
+
+package tester
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// prepareBackend keeps the backend from opening a console window when
+// ProtoScope runs without one, e.g. as a service
+func prepareBackend(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= windows.CREATE_NO_WINDOW
+}
+
+// killBackend ends a backend and the processes it started. Windows has no
+// signals and TerminateProcess leaves children running, so the tree is
+// ended with taskkill, falling back to the process alone.
+func killBackend(process *os.Process) error {
+	cmd := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(process.Pid))
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NO_WINDOW}
+	if err := cmd.Run(); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
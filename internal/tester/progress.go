@@ -0,0 +1,74 @@
+package tester
+
+import "github.com/VenoMexx/ProtoScope/pkg/models"
+
+// SetProgress makes the runner call progress as each node moves through
+// its stages, and within long stages. Nodes are tested concurrently, so
+// progress must be safe for concurrent use.
+func (tr *TestRunner) SetProgress(progress func(models.TestProgress)) {
+	tr.progress = progress
+}
+
+// nodeProgress tracks one node's position in its stages; a nil
+// *nodeProgress reports nothing
+type nodeProgress struct {
+	report   func(models.TestProgress)
+	protocol *models.Protocol
+	stage    string
+	index    int
+	count    int
+}
+
+// newNodeProgress starts tracking protocol through connectivity and the
+// given check stages, of which the enabled ones count
+func (tr *TestRunner) newNodeProgress(protocol *models.Protocol, stages []checkStage) *nodeProgress {
+	if tr.progress == nil {
+		return nil
+	}
+	count := 1 // connectivity
+	for _, stage := range stages {
+		if stage.enabled == nil || stage.enabled(&tr.config.TestConfig) {
+			count++
+		}
+	}
+	return &nodeProgress{report: tr.progress, protocol: protocol, count: count}
+}
+
+// start reports the beginning of the next stage
+func (p *nodeProgress) start(stage string) {
+	if p == nil {
+		return
+	}
+	p.stage = stage
+	p.index = min(p.index+1, p.count)
+	p.step("", 0)
+}
+
+// step reports a step within the current stage, fraction of it done
+func (p *nodeProgress) step(detail string, fraction float64) {
+	if p == nil {
+		return
+	}
+	p.report(models.TestProgress{
+		Protocol:   p.protocol,
+		Stage:      p.stage,
+		StageIndex: p.index,
+		StageCount: p.count,
+		Detail:     detail,
+		Percent:    (float64(p.index-1) + fraction) / float64(p.count) * 100,
+	})
+}
+
+// done reports the node finished, whether or not all stages ran
+func (p *nodeProgress) done() {
+	if p == nil {
+		return
+	}
+	p.report(models.TestProgress{
+		Protocol:   p.protocol,
+		Stage:      "done",
+		StageIndex: p.count,
+		StageCount: p.count,
+		Percent:    100,
+	})
+}
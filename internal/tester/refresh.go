@@ -0,0 +1,51 @@
+package tester
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// feedTTL is how long downloaded IP ranges and lists are used before a
+	// long-running daemon fetches them again
+	feedTTL = 6 * time.Hour
+	// clockTTL is how long a clock skew measurement holds; clocks drift
+	clockTTL = time.Hour
+	// refreshRetry is how soon a fetch that failed, in full or in part, is
+	// tried again
+	refreshRetry = 5 * time.Minute
+)
+
+// refreshing holds data fetched directly on first use and shared by all
+// workers, fetched again once it is older than its TTL. A failed fetch is
+// retried after refreshRetry instead of leaving the data empty for good.
+type refreshing[T comparable] struct {
+	mu      sync.Mutex
+	value   T
+	err     error
+	expires time.Time
+}
+
+// get returns the data, calling fetch first when it is missing or stale.
+// fetch returns what it loaded, the zero value if nothing, and an error
+// when anything failed. A fetch that loaded nothing keeps the previous
+// data. The error is that of the last fetch.
+func (r *refreshing[T]) get(ttl time.Duration, fetch func() (T, error)) (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Now().Before(r.expires) {
+		return r.value, r.err
+	}
+
+	value, err := fetch()
+	var zero T
+	if value != zero {
+		r.value = value
+	}
+	r.err = err
+	if err != nil {
+		ttl = refreshRetry
+	}
+	r.expires = time.Now().Add(ttl)
+	return r.value, r.err
+}
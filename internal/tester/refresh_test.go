@@ -0,0 +1,41 @@
+package tester
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRefreshingRetriesFailedFetch(t *testing.T) {
+	var r refreshing[*string]
+	fetches := 0
+	loaded := "ranges"
+	fetch := func(value *string, err error) func() (*string, error) {
+		return func() (*string, error) {
+			fetches++
+			return value, err
+		}
+	}
+
+	if got, err := r.get(time.Hour, fetch(nil, errors.New("offline"))); got != nil || err == nil {
+		t.Fatalf("failed fetch returned %v, %v", got, err)
+	}
+	// Still failed: no fetch before refreshRetry
+	r.get(time.Hour, fetch(&loaded, nil))
+	if fetches != 1 {
+		t.Fatalf("fetched %d times before the retry was due", fetches)
+	}
+
+	r.expires = time.Now()
+	if got, err := r.get(time.Hour, fetch(&loaded, nil)); got != &loaded || err != nil {
+		t.Fatalf("retry returned %v, %v", got, err)
+	}
+	// Stale data is fetched again; a fetch that loads nothing keeps it
+	r.expires = time.Now()
+	if got, err := r.get(time.Hour, fetch(nil, errors.New("offline"))); got != &loaded || err == nil {
+		t.Fatalf("failed refresh returned %v, %v", got, err)
+	}
+	if fetches != 3 {
+		t.Fatalf("fetched %d times, want 3", fetches)
+	}
+}
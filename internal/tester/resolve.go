@@ -0,0 +1,106 @@
+package tester
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// resolveServer returns all A/AAAA records of the node server.
+// IP literals are returned as-is.
+func resolveServer(ctx context.Context, server string) []string {
+	if ip := net.ParseIP(server); ip != nil {
+		return []string{ip.String()}
+	}
+
+	resolveCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(resolveCtx, server)
+	if err != nil {
+		return nil
+	}
+
+	ips := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP.String())
+	}
+	return ips
+}
+
+// testResolvedIPs runs a connectivity test pinned to each resolved IP.
+// Anycast and multi-IP nodes often have one dead and one healthy endpoint,
+// which a single hostname-based test hides.
+func (tr *TestRunner) testResolvedIPs(ctx context.Context, protocol *models.Protocol, ips []string) []models.IPResult {
+	results := make([]models.IPResult, 0, len(ips))
+
+	for _, ip := range ips {
+		if ctx.Err() != nil {
+			break
+		}
+
+		ipResult := models.IPResult{IP: ip}
+		testResult := tr.quickTest(ctx, pinnedProtocol(protocol, ip))
+		if testResult.Connectivity != nil {
+			ipResult.Connected = testResult.Connectivity.Connected
+			ipResult.ResponseTime = testResult.Connectivity.ResponseTime
+			ipResult.Error = testResult.Connectivity.Error
+		}
+		if !ipResult.Connected && ipResult.Error == "" {
+			ipResult.Error = testResult.Error
+		}
+
+		results = append(results, ipResult)
+	}
+
+	return results
+}
+
+// pinnedProtocol returns a copy of protocol connecting to ip. The hostname
+// stays in the SNI and the WebSocket Host header, so the server sees what
+// the backend would send for the hostname itself.
+func pinnedProtocol(protocol *models.Protocol, ip string) *models.Protocol {
+	pinned := protocol.Clone()
+	pinned.Server = ip
+	if net.ParseIP(protocol.Server) != nil {
+		return pinned
+	}
+	if pinned.TLS && pinned.SNI == "" {
+		pinned.SNI = protocol.Server
+	}
+	if host, _ := pinned.Extra["host"].(string); host == "" && pinned.Network == "ws" {
+		if pinned.Extra == nil {
+			pinned.Extra = map[string]interface{}{}
+		}
+		pinned.Extra["host"] = protocol.Server
+	}
+	return pinned
+}
+
+// dialAddress picks the resolved IP the node is tested through: for TCP
+// nodes the one a direct connection reaches first, trying the addresses
+// as clients do, else the first. It returns "" when nothing resolved.
+func dialAddress(ctx context.Context, protocol *models.Protocol, ips []string) string {
+	if len(ips) == 0 {
+		return ""
+	}
+	if isUDPProtocol(protocol.Type) {
+		return ips[0]
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(dialCtx, "tcp", net.JoinHostPort(protocol.Server, strconv.Itoa(protocol.Port)))
+	if err != nil {
+		return ips[0]
+	}
+	defer conn.Close()
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+	return ips[0]
+}
@@ -0,0 +1,46 @@
+package tester
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestPinnedProtocolKeepsHostname(t *testing.T) {
+	protocol := &models.Protocol{Type: models.ProtocolVLESS, Server: "node.example", Port: 443, TLS: true, Network: "ws"}
+	pinned := pinnedProtocol(protocol, "192.0.2.7")
+	if pinned.Server != "192.0.2.7" || pinned.SNI != "node.example" || pinned.Extra["host"] != "node.example" {
+		t.Errorf("pinned to %s with SNI %q and host %v", pinned.Server, pinned.SNI, pinned.Extra["host"])
+	}
+	if protocol.Server != "node.example" || protocol.SNI != "" || protocol.Extra != nil {
+		t.Error("pinning changed the original")
+	}
+}
+
+func TestDialAddressReportsConnectedIP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	protocol := &models.Protocol{Type: models.ProtocolTrojan, Server: "localhost", Port: port}
+	if ip := dialAddress(context.Background(), protocol, []string{"::1", "127.0.0.1"}); ip != "127.0.0.1" {
+		t.Errorf("dialed %s, want 127.0.0.1", ip)
+	}
+	if ip := dialAddress(context.Background(), protocol, nil); ip != "" {
+		t.Errorf("dialed %s with nothing resolved", ip)
+	}
+}
@@ -2,27 +2,142 @@ package tester
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/VenoMexx/ProtoScope/internal/cache"
 	"github.com/VenoMexx/ProtoScope/internal/checks"
+	"github.com/VenoMexx/ProtoScope/internal/notes"
+	"github.com/VenoMexx/ProtoScope/internal/ratelimit"
 	"github.com/VenoMexx/ProtoScope/pkg/models"
 )
 
 // TestRunner orchestrates all tests for protocols
 type TestRunner struct {
 	config      *models.Config
+	locationMu  sync.RWMutex // guards realIP, country and located
 	realIP      string
+	country     string
+	located     bool
 	concurrency int
+	limiter     *ratelimit.HostLimiter
+	ipPool      *checks.EndpointPool
+	mockReplay  []MockResponse
+	cache       *cache.ResultCache
+	notes       *notes.Store
+	chaos       ChaosOptions
+
+	hosting    refreshing[*checks.HostingRanges]
+	proxyLists refreshing[*checks.ProxyLists]
+	blocklists refreshing[*checks.Blocklists]
+	clock      refreshing[*checks.ClockSkew]
+	budgetMu   sync.Mutex
+	budget     *dataBudget // metered mode only
+	progress   func(models.TestProgress)
+	hooks      Hooks
+	portPool   *PortPool
+	streamOnly bool
 }
 
 // NewTestRunner creates a new test runner
 func NewTestRunner(config *models.Config) *TestRunner {
-	return &TestRunner{
+	tr := &TestRunner{
 		config:      config,
 		concurrency: config.TestConfig.Concurrency,
 	}
+
+	if len(config.APIEndpoints.IPCheck) > 0 {
+		tr.ipPool = checks.NewEndpointPool(config.APIEndpoints.IPCheck)
+	}
+
+	// One limiter for the whole run so concurrent workers share the budget
+	if config.TestConfig.EndpointRateLimit > 0 {
+		tr.limiter = ratelimit.NewHostLimiter(config.TestConfig.EndpointRateLimit, config.TestConfig.EndpointRateBurst)
+	}
+
+	return tr
+}
+
+// newProxyManager creates a proxy manager wired to the runner's shared state
+func (tr *TestRunner) newProxyManager(protocol *models.Protocol) *ProxyManager {
+	// Port 0 lets the manager allocate a free port when it starts
+	proxyMgr := NewProxyManager(protocol, 0)
+	if tr.limiter != nil {
+		proxyMgr.SetRateLimiter(tr.limiter)
+	}
+	if tr.config.TestConfig.Backend != "" {
+		proxyMgr.SetBackend(ProxyBackend(tr.config.TestConfig.Backend))
+	}
+	if tr.mockReplay != nil {
+		proxyMgr.SetMockReplay(tr.mockReplay)
+	}
+	proxyMgr.SetChaos(tr.chaos)
+	proxyMgr.SetLimits(BackendLimitsFromConfig(&tr.config.TestConfig))
+	proxyMgr.SetSandbox(SandboxFromConfig(&tr.config.TestConfig))
+	proxyMgr.SetConfigStdin(tr.config.TestConfig.BackendConfigStdin)
+	proxyMgr.SetLowMemory(tr.config.TestConfig.LowMemory)
+	proxyMgr.SetPortPool(tr.portPool)
+	return proxyMgr
+}
+
+// SetMockReplay sets canned responses used when the backend is "mock"
+func (tr *TestRunner) SetMockReplay(responses []MockResponse) {
+	tr.mockReplay = responses
+}
+
+// SetPortPool makes the runner's backends listen on ports from pool
+func (tr *TestRunner) SetPortPool(pool *PortPool) {
+	tr.portPool = pool
+}
+
+// SetStreamOnly makes RunTestsStream hand each result to its callback
+// only, leaving the returned slice empty, so a run doesn't hold all the
+// results in memory
+func (tr *TestRunner) SetStreamOnly(streamOnly bool) {
+	tr.streamOnly = streamOnly
+}
+
+// SetResultCache enables skipping nodes whose settings and recent result
+// are unchanged; fresh results are stored in the cache
+func (tr *TestRunner) SetResultCache(resultCache *cache.ResultCache) {
+	tr.cache = resultCache
+}
+
+// SetNotes attaches the user's node notes to results
+func (tr *TestRunner) SetNotes(store *notes.Store) {
+	tr.notes = store
+}
+
+// SaveResultCache writes the result cache, if one is set
+func (tr *TestRunner) SaveResultCache() error {
+	if tr.cache == nil {
+		return nil
+	}
+	return tr.cache.Save()
+}
+
+// SetChaos injects faults into every proxy the runner starts
+func (tr *TestRunner) SetChaos(options ChaosOptions) {
+	tr.chaos = options
+}
+
+// isMock reports whether the runner simulates nodes instead of testing them
+func (tr *TestRunner) isMock() bool {
+	return ProxyBackend(tr.config.TestConfig.Backend) == BackendMock
+}
+
+// directClient returns the client for requests that bypass the proxy
+func (tr *TestRunner) directClient() *http.Client {
+	if tr.isMock() {
+		direct := &models.Protocol{Name: "direct", Server: "localhost"}
+		return &http.Client{Transport: newMockTransport(direct, tr.mockReplay)}
+	}
+	return &http.Client{}
 }
 
 // RunTests runs all tests for the given protocols
@@ -36,15 +151,20 @@ func (tr *TestRunner) RunTestsStream(ctx context.Context, protocols []*models.Pr
 }
 
 func (tr *TestRunner) runTests(ctx context.Context, protocols []*models.Protocol, onResult func(int, *models.TestResult)) ([]*models.TestResult, error) {
-	// Get real IP first (without proxy)
-	realIP, err := checks.GetRealIP(ctx)
-	if err != nil {
-		// Not fatal, continue without real IP
-		realIP = ""
+	// Get real IP and location first (without proxy)
+	tr.detectLocation(ctx)
+	tr.resetBudget()
+
+	var results []*models.TestResult
+	if !tr.streamOnly || onResult == nil {
+		results = make([]*models.TestResult, len(protocols))
 	}
-	tr.realIP = realIP
 
-	results := make([]*models.TestResult, len(protocols))
+	// An AfterTest hook can abort the run; nodes not started by then are
+	// left untested with its error as the cause
+	ctx, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
+	var abortErr error
 
 	// Use semaphore for concurrency control
 	sem := make(chan struct{}, tr.concurrency)
@@ -56,25 +176,160 @@ func (tr *TestRunner) runTests(ctx context.Context, protocols []*models.Protocol
 		go func(idx int, proto *models.Protocol) {
 			defer wg.Done()
 
-			// Acquire semaphore
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
-			result := tr.testProtocol(ctx, proto)
+			result := tr.runWorker(ctx, sem, proto)
+			if tr.notes != nil {
+				result.Note = tr.notes.Get(proto)
+			}
+			hookErr := tr.afterTest(ctx, proto, result)
 
 			if onResult != nil {
 				onResult(idx, result)
 			}
 
 			mu.Lock()
-			results[idx] = result
+			if results != nil {
+				results[idx] = result
+			}
+			if hookErr != nil && abortErr == nil {
+				abortErr = fmt.Errorf("run aborted after %s: %w", proto.Name, hookErr)
+				abort(abortErr)
+			}
 			mu.Unlock()
 		}(i, protocol)
 	}
 
 	wg.Wait()
 
-	return results, nil
+	return results, abortErr
+}
+
+// runWorker produces the result for one node: from the cache when the node is
+// unchanged, otherwise by testing it once a concurrency slot is free
+func (tr *TestRunner) runWorker(ctx context.Context, sem chan struct{}, protocol *models.Protocol) *models.TestResult {
+	if rejected := tr.beforeTest(ctx, protocol); rejected != nil {
+		return rejected
+	}
+	if tr.cache != nil {
+		if cached, ok := tr.cache.Get(protocol); ok {
+			return cached
+		}
+	}
+
+	// Acquire semaphore, giving up if the run is cancelled while waiting
+	select {
+	case sem <- struct{}{}:
+		defer func() { <-sem }()
+	case <-ctx.Done():
+		return notTested(protocol, context.Cause(ctx))
+	}
+	// select picks at random when both are ready
+	if ctx.Err() != nil {
+		return notTested(protocol, context.Cause(ctx))
+	}
+
+	result := tr.testProtocol(ctx, protocol)
+	tr.annotateClockSkew(ctx, result)
+
+	// Results cut short by cancellation say nothing about the node
+	if tr.cache != nil && ctx.Err() == nil && !result.PartialSuccess {
+		tr.cache.Put(result)
+	}
+
+	return result
+}
+
+// detectLocation looks up the real IP and, unless configured, its country.
+// Both are optional: tests run without them.
+func (tr *TestRunner) detectLocation(ctx context.Context) {
+	realIP, err := checks.GetRealIP(ctx, tr.directClient(), tr.ipPool)
+	if err != nil {
+		realIP = ""
+	}
+
+	country := tr.config.TestConfig.Country
+	if country == "" && realIP != "" && len(tr.config.APIEndpoints.GeoLocation) > 0 {
+		if detected, err := checks.GetCountry(ctx, tr.directClient(), tr.config.APIEndpoints.GeoLocation[0], realIP); err == nil {
+			country = detected
+		}
+	}
+
+	tr.locationMu.Lock()
+	tr.realIP, tr.country, tr.located = realIP, country, true
+	tr.locationMu.Unlock()
+}
+
+// ensureLocated detects the location unless a run already has
+func (tr *TestRunner) ensureLocated(ctx context.Context) {
+	tr.locationMu.RLock()
+	located := tr.located
+	tr.locationMu.RUnlock()
+	if !located {
+		tr.detectLocation(ctx)
+	}
+}
+
+// location returns the real IP and the country tests run from
+func (tr *TestRunner) location() (realIP, country string) {
+	tr.locationMu.RLock()
+	defer tr.locationMu.RUnlock()
+	return tr.realIP, tr.country
+}
+
+// hostingRanges returns the cloud IP ranges, fetched directly and shared by
+// all workers; refreshed after feedTTL. Feeds that fail to load are skipped
+// and retried later; exit IPs are then only matched by ASN.
+func (tr *TestRunner) hostingRanges(ctx context.Context) *checks.HostingRanges {
+	hosting, _ := tr.hosting.get(feedTTL, func() (*checks.HostingRanges, error) {
+		// The first caller's node deadline must not cut the fetch short for
+		// everyone else
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		hosting, errs := checks.LoadHostingRanges(fetchCtx, tr.directClient(), tr.config.APIEndpoints.HostingRanges)
+		return hosting, errors.Join(errs...)
+	})
+	return hosting
+}
+
+// detectionLists returns the Tor/VPN/proxy detection lists, fetched
+// directly and shared by all workers; refreshed after feedTTL. Lists that
+// fail to load are left out and retried later.
+func (tr *TestRunner) detectionLists(ctx context.Context) *checks.ProxyLists {
+	lists, _ := tr.proxyLists.get(feedTTL, func() (*checks.ProxyLists, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		lists, errs := checks.LoadProxyLists(fetchCtx, tr.directClient(), tr.config.APIEndpoints.ProxyLists)
+		return lists, errors.Join(errs...)
+	})
+	return lists
+}
+
+// sampledBlocklists returns the domains sampled from the ad, tracker and
+// malware blocklists, fetched directly and shared by all workers; refreshed
+// after feedTTL. nil unless a sample size is configured. The error names
+// the lists that failed to load, which are retried later.
+func (tr *TestRunner) sampledBlocklists(ctx context.Context) (*checks.Blocklists, error) {
+	sample := tr.config.TestConfig.BlocklistSample
+	if sample <= 0 {
+		return nil, nil
+	}
+	return tr.blocklists.get(feedTTL, func() (*checks.Blocklists, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		blocklists, errs := checks.LoadBlocklists(fetchCtx, tr.directClient(), tr.config.APIEndpoints.Blocklists, sample)
+		return blocklists, errors.Join(errs...)
+	})
+}
+
+// Country returns the country tests run from, detected from the real IP
+// unless configured; empty before the first run or if detection failed
+func (tr *TestRunner) Country() string {
+	_, country := tr.location()
+	return country
+}
+
+// ConnectivityURLs returns the connectivity endpoints for the user's country
+func (tr *TestRunner) ConnectivityURLs() []string {
+	return tr.config.APIEndpoints.ConnectivityEndpoints(tr.Country())
 }
 
 // testProtocol tests a single protocol
@@ -84,15 +339,36 @@ func (tr *TestRunner) testProtocol(ctx context.Context, protocol *models.Protoco
 		Timestamp: time.Now(),
 		Success:   false,
 	}
+	if rejectInvalid(result) {
+		return result
+	}
 
-	// Create proxy manager with dynamic port
-	socksPort := 10808 + (int(time.Now().UnixNano()) % 1000)
-	proxyMgr := NewProxyManager(protocol, socksPort)
+	stages := tr.stages()
+	progress := tr.newNodeProgress(protocol, stages)
+	defer progress.done()
+	progress.start("connectivity")
 
-	// Start proxy
-	proxyCtx, cancel := context.WithTimeout(ctx, tr.config.TestConfig.Timeout)
+	// The node's deadline covers everything done for it, direct probes of
+	// the server included, so a node can't hold its worker past it
+	timeout := tr.config.TestConfig.TimeoutFor(protocol.Type)
+	proxyCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	tr.inspectServer(proxyCtx, protocol, result)
+
+	// The backend dials the address chosen here, so results tell which of
+	// several the node was tested through
+	backendProtocol := protocol
+	if net.ParseIP(protocol.Server) == nil {
+		if result.DialedIP = dialAddress(proxyCtx, protocol, result.ResolvedIPs); result.DialedIP != "" {
+			backendProtocol = pinnedProtocol(protocol, result.DialedIP)
+		}
+	}
+
+	// Create proxy manager with dynamic port
+	proxyMgr := tr.newProxyManager(backendProtocol)
+
+	// Start proxy
 	if err := proxyMgr.Start(proxyCtx); err != nil {
 		result.Error = fmt.Sprintf("Failed to start proxy: %v", err)
 		result.ErrorDetails = proxyMgr.GetLastError(err)
@@ -101,7 +377,7 @@ func (tr *TestRunner) testProtocol(ctx context.Context, protocol *models.Protoco
 	defer proxyMgr.Stop()
 
 	// Get HTTP client
-	client, err := proxyMgr.GetHTTPClient(tr.config.TestConfig.Timeout)
+	client, err := proxyMgr.GetHTTPClient(timeout)
 	if err != nil {
 		result.Error = fmt.Sprintf("Failed to create HTTP client: %v", err)
 		result.ErrorDetails = proxyMgr.GetLastError(err)
@@ -109,123 +385,224 @@ func (tr *TestRunner) testProtocol(ctx context.Context, protocol *models.Protoco
 	}
 
 	// Run connectivity test
-	connectivityChecker := checks.NewConnectivityChecker(10 * time.Second)
-	connectivityResult, err := connectivityChecker.CheckHTTP(proxyCtx, "http://www.gstatic.com/generate_204", client)
+	connectivityChecker := checks.NewConnectivityChecker(tr.config.TestConfig.ScaleTimeout(protocol.Type, 10*time.Second))
+	connectivityResult, err := connectivityChecker.CheckAny(proxyCtx, tr.ConnectivityURLs(), client)
 	if err != nil || !connectivityResult.Connected {
 		result.Error = "Connectivity test failed"
 		result.Connectivity = connectivityResult
+		result.ErrorDetails = connectivityFailure(proxyMgr, connectivityResult, err)
 		return result
 	}
 	result.Connectivity = connectivityResult
 	result.Success = true
 
-	// Run performance tests if enabled
-	if tr.config.TestConfig.EnableSpeedTest {
-		perfChecker := checks.NewPerformanceChecker(30 * time.Second)
-		perfResult, err := perfChecker.Check(proxyCtx, client)
-		if err == nil {
-			result.Performance = perfResult
-		}
+	// Raw TCP checks dial through the proxy; without a dialer they fail
+	dialer, _ := proxyMgr.GetDialer()
+
+	// Run the remaining checks in dependency order
+	tr.runStages(proxyCtx, stages, &stageEnv{
+		runner:   tr,
+		protocol: protocol,
+		client:   client,
+		dialer:   dialer,
+		result:   result,
+		traffic:  proxyMgr.TrafficCounter(),
+		proxyMgr: proxyMgr,
+		progress: progress,
+	})
+
+	return result
+}
+
+// connectivityFailure classifies a failed connectivity check. CheckHTTP
+// reports request errors in the result rather than returning them.
+func connectivityFailure(proxyMgr *ProxyManager, connectivityResult *models.ConnectivityResult, err error) *models.DetailedError {
+	if err == nil && connectivityResult != nil && connectivityResult.Error != "" {
+		err = errors.New(connectivityResult.Error)
 	}
+	if err == nil {
+		return nil
+	}
+	return proxyMgr.GetLastError(err)
+}
 
-	// Run geo-access tests if enabled
-	if tr.config.TestConfig.EnableGeoTest {
-		geoChecker := checks.NewGeoAccessChecker(10 * time.Second)
-		geoResult, err := geoChecker.Check(proxyCtx, client)
-		if err == nil {
-			result.GeoAccess = geoResult
-		}
+// inspectServer collects direct, tunnel-independent facts about the node server
+func (tr *TestRunner) inspectServer(ctx context.Context, protocol *models.Protocol, result *models.TestResult) {
+	// Simulated nodes have no real server to look at
+	if tr.isMock() {
+		return
 	}
 
-	// Run DNS tests if enabled
-	if tr.config.TestConfig.EnableDNSTest {
-		// Try to get expected country from geo result
-		expectedCountry := ""
-		if result.GeoAccess != nil {
-			// Simple heuristic based on which regions are accessible
-			if result.GeoAccess.Summary.AccessPercentage > 50 {
-				expectedCountry = "US" // Assume US if most sites are accessible
-			}
-		}
+	// Ping the server directly so path RTT can be told apart from proxy overhead
+	if tr.config.TestConfig.EnablePing {
+		result.Ping = tr.pingServer(ctx, protocol)
+	}
 
-		dnsChecker := checks.NewDNSChecker(10 * time.Second)
-		dnsResult, err := dnsChecker.Check(proxyCtx, client, expectedCountry)
-		if err == nil {
-			result.DNS = dnsResult
-		}
+	// Trace the path to the server to spot long or lossy routes
+	if tr.config.TestConfig.EnableTrace {
+		result.Trace = tr.traceServer(ctx, protocol)
+	}
+
+	// QUIC nodes that connect but stall usually sit behind a bad path MTU
+	if tr.config.TestConfig.EnableMTUProbe && isUDPProtocol(protocol.Type) {
+		mtuChecker := checks.NewMTUChecker(time.Second)
+		result.MTU, _ = mtuChecker.Check(ctx, protocol.Server)
+	}
+
+	// Censors confirm suspected proxies by probing them; QUIC servers
+	// ignore stray datagrams, so only TCP nodes are probed
+	if tr.config.TestConfig.EnableActiveProbing && !isUDPProtocol(protocol.Type) {
+		result.ActiveProbing = tr.probeServer(ctx, protocol)
 	}
 
-	// Run privacy tests if enabled
-	if tr.config.TestConfig.EnablePrivacyTest {
-		privacyChecker := checks.NewPrivacyChecker(tr.realIP)
-		privacyResult, err := privacyChecker.Check(proxyCtx, client)
-		if err == nil {
-			result.Privacy = privacyResult
+	result.ResolvedIPs = resolveServer(ctx, protocol.Server)
+	if tr.config.TestConfig.TestAllIPs && len(result.ResolvedIPs) > 1 {
+		result.IPResults = tr.testResolvedIPs(ctx, protocol, result.ResolvedIPs)
+	}
+}
+
+// pingServer sends ICMP echoes to the node server outside the tunnel
+func (tr *TestRunner) pingServer(ctx context.Context, protocol *models.Protocol) *models.PingResult {
+	pingChecker := checks.NewPingChecker(2*time.Second, tr.config.TestConfig.PingCount)
+	pingResult, _ := pingChecker.Check(ctx, protocol.Server)
+	return pingResult
+}
+
+// isUDPProtocol reports whether the protocol runs over QUIC/UDP or, for
+// WireGuard, plain UDP
+func isUDPProtocol(protocolType models.ProtocolType) bool {
+	return protocolType == models.ProtocolHysteria2 || protocolType == models.ProtocolTUIC || protocolType == models.ProtocolWireGuard
+}
+
+// probeServer rates the node server's resistance to active probing
+func (tr *TestRunner) probeServer(ctx context.Context, protocol *models.Protocol) *models.ActiveProbingResult {
+	sni := ""
+	if protocol.TLS {
+		sni = protocol.SNI
+		if sni == "" {
+			sni = protocol.Server
 		}
 	}
+	probeChecker := checks.NewActiveProbeChecker(5 * time.Second)
+	if wrong := wrongCredentials(protocol); wrong != nil {
+		probeChecker.SetCredentialProbe(func(ctx context.Context) *models.ActiveProbe {
+			return tr.probeCredentials(ctx, wrong)
+		})
+	}
+	probeResult, _ := probeChecker.Check(ctx, protocol.Server, protocol.Port, sni)
+	return probeResult
+}
 
-	return result
+// traceServer traces the route to the node server outside the tunnel
+func (tr *TestRunner) traceServer(ctx context.Context, protocol *models.Protocol) *models.TraceResult {
+	traceChecker := checks.NewTraceChecker(time.Second, tr.config.TestConfig.TraceMaxHops, 2)
+	traceResult, _ := traceChecker.Check(ctx, protocol.Server)
+	return traceResult
+}
+
+// IPCheckStats returns the health of the IP-check endpoints used so far
+func (tr *TestRunner) IPCheckStats() []checks.EndpointStats {
+	if tr.ipPool == nil {
+		return nil
+	}
+	return tr.ipPool.Stats()
 }
 
 // TestSingle tests a single protocol and returns the result
 func (tr *TestRunner) TestSingle(ctx context.Context, protocol *models.Protocol) (*models.TestResult, error) {
 	// Get real IP if not already set
-	if tr.realIP == "" {
-		realIP, err := checks.GetRealIP(ctx)
-		if err == nil {
-			tr.realIP = realIP
-		}
-	}
+	tr.ensureLocated(ctx)
 
+	if rejected := tr.beforeTest(ctx, protocol); rejected != nil {
+		return rejected, nil
+	}
 	result := tr.testProtocol(ctx, protocol)
-	return result, nil
+	tr.annotateClockSkew(ctx, result)
+	return result, tr.afterTest(ctx, protocol, result)
 }
 
 // QuickTest performs only connectivity test
 func (tr *TestRunner) QuickTest(ctx context.Context, protocol *models.Protocol) (*models.TestResult, error) {
+	// Regional connectivity endpoints depend on where the user is
+	tr.ensureLocated(ctx)
+
+	if rejected := tr.beforeTest(ctx, protocol); rejected != nil {
+		return rejected, nil
+	}
+	// The node's deadline covers the direct probes of the server too
+	nodeCtx, cancel := context.WithTimeout(ctx, tr.config.TestConfig.TimeoutFor(protocol.Type))
+	defer cancel()
+	result := tr.quickTest(nodeCtx, protocol)
+	tr.annotateClockSkew(ctx, result)
+	tr.inspectServer(nodeCtx, protocol, result)
+	return result, tr.afterTest(ctx, protocol, result)
+}
+
+// rejectInvalid fails a node whose settings were found broken at parse time,
+// without starting a backend for it
+func rejectInvalid(result *models.TestResult) bool {
+	warnings := result.Protocol.Warnings
+	if len(warnings) == 0 {
+		return false
+	}
+	result.Error = "Invalid config: " + strings.Join(warnings, "; ")
+	result.ErrorDetails = &models.DetailedError{
+		Type:    models.ErrorTypeInvalidConfig,
+		Message: result.Error,
+	}
+	result.ErrorDetails.Suggestion = result.ErrorDetails.GetTroubleshootingSuggestion()
+	return true
+}
+
+// quickTest starts the proxy and runs the connectivity test only
+func (tr *TestRunner) quickTest(ctx context.Context, protocol *models.Protocol) *models.TestResult {
 	result := &models.TestResult{
 		Protocol:  protocol,
 		Timestamp: time.Now(),
 		Success:   false,
 	}
+	if rejectInvalid(result) {
+		return result
+	}
+
+	progress := tr.newNodeProgress(protocol, nil)
+	defer progress.done()
+	progress.start("connectivity")
 
 	// Create proxy manager
-	socksPort := 10808 + (int(time.Now().UnixNano()) % 1000)
-	proxyMgr := NewProxyManager(protocol, socksPort)
+	proxyMgr := tr.newProxyManager(protocol)
 
 	// Start proxy
-	proxyCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	proxyCtx, cancel := context.WithTimeout(ctx, tr.config.TestConfig.ScaleTimeout(protocol.Type, 15*time.Second))
 	defer cancel()
 
 	if err := proxyMgr.Start(proxyCtx); err != nil {
 		result.Error = fmt.Sprintf("Failed to start proxy: %v", err)
 		result.ErrorDetails = proxyMgr.GetLastError(err)
-		return result, nil
+		return result
 	}
 	defer proxyMgr.Stop()
 
 	// Get HTTP client
-	client, err := proxyMgr.GetHTTPClient(10 * time.Second)
+	client, err := proxyMgr.GetHTTPClient(tr.config.TestConfig.ScaleTimeout(protocol.Type, 10*time.Second))
 	if err != nil {
 		result.Error = fmt.Sprintf("Failed to create HTTP client: %v", err)
 		result.ErrorDetails = proxyMgr.GetLastError(err)
-		return result, nil
+		return result
 	}
 
 	// Run connectivity test only
-	connectivityChecker := checks.NewConnectivityChecker(10 * time.Second)
-	connectivityResult, err := connectivityChecker.CheckHTTP(proxyCtx, "http://www.gstatic.com/generate_204", client)
+	connectivityChecker := checks.NewConnectivityChecker(tr.config.TestConfig.ScaleTimeout(protocol.Type, 10*time.Second))
+	connectivityResult, err := connectivityChecker.CheckAny(proxyCtx, tr.ConnectivityURLs(), client)
 	if err != nil || !connectivityResult.Connected {
 		result.Error = "Connectivity test failed"
 		result.Connectivity = connectivityResult
-		if err != nil {
-			result.ErrorDetails = proxyMgr.GetLastError(err)
-		}
-		return result, nil
+		result.ErrorDetails = connectivityFailure(proxyMgr, connectivityResult, err)
+		return result
 	}
 
 	result.Connectivity = connectivityResult
 	result.Success = true
 
-	return result, nil
+	return result
 }
@@ -0,0 +1,339 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func newMockRunner() *TestRunner {
+	config := models.DefaultConfig()
+	config.TestConfig.Backend = string(BackendMock)
+	config.TestConfig.Timeout = 10 * time.Second
+	config.TestConfig.EnableDNSTest = false
+	config.TestConfig.EnableGeoTest = false
+	config.TestConfig.EndpointRateLimit = 0
+	return NewTestRunner(config)
+}
+
+func TestRunTestsWithMockBackend(t *testing.T) {
+	runner := newMockRunner()
+
+	protocols := []*models.Protocol{
+		{Type: models.ProtocolVLESS, Name: "NL-01", Server: "nl.example.com", Port: 443},
+		{Type: models.ProtocolTrojan, Name: "dead-node", Server: "dead.example.com", Port: 443},
+	}
+
+	results, err := runner.RunTests(context.Background(), protocols)
+	if err != nil {
+		t.Fatalf("RunTests returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	working := results[0]
+	if !working.Success {
+		t.Fatalf("expected mock node to work, got error %q", working.Error)
+	}
+	if working.Connectivity == nil || !working.Connectivity.Connected {
+		t.Fatal("expected connectivity result")
+	}
+	if working.Performance == nil || working.Performance.DownloadSpeed <= 0 {
+		t.Fatal("expected download speed from mock speed test")
+	}
+	if working.Privacy == nil || working.Privacy.ProxyIP == "" {
+		t.Fatal("expected proxy IP from mock IP-check endpoints")
+	}
+	if working.Privacy.IPv6Leak {
+		t.Fatal("mock backend has no IPv6, expected no IPv6 leak")
+	}
+
+	dead := results[1]
+	if dead.Success {
+		t.Fatal("expected dead mock node to fail")
+	}
+	if dead.ErrorDetails == nil || dead.ErrorDetails.Type != models.ErrorTypeProxyTimeout {
+		t.Fatalf("expected proxy timeout classification, got %+v", dead.ErrorDetails)
+	}
+}
+
+func TestInvalidConfigNotRun(t *testing.T) {
+	runner := newMockRunner()
+
+	protocol := &models.Protocol{
+		Type: models.ProtocolVLESS, Name: "NL-02", Server: "nl.example.com", Port: 443,
+		Warnings: []string{"invalid uuid \"x\""},
+	}
+	results, err := runner.RunTests(context.Background(), []*models.Protocol{protocol})
+	if err != nil {
+		t.Fatalf("RunTests returned error: %v", err)
+	}
+	result := results[0]
+	if result.Success || result.Connectivity != nil {
+		t.Fatal("expected node with parse warnings to be rejected before testing")
+	}
+	if result.ErrorDetails == nil || result.ErrorDetails.Type != models.ErrorTypeInvalidConfig {
+		t.Fatalf("expected invalid config classification, got %+v", result.ErrorDetails)
+	}
+}
+
+func TestCountryMismatch(t *testing.T) {
+	runner := newMockRunner()
+
+	// The mock geolocation endpoint places every exit in NL
+	protocols := []*models.Protocol{
+		{Type: models.ProtocolVLESS, Name: "JP-01", Server: "jp.example.com", Port: 443, AdvertisedCountry: "JP"},
+		{Type: models.ProtocolVLESS, Name: "NL-01", Server: "nl.example.com", Port: 443, AdvertisedCountry: "NL"},
+		{Type: models.ProtocolVLESS, Name: "node-3", Server: "n3.example.com", Port: 443},
+	}
+	results, err := runner.RunTests(context.Background(), protocols)
+	if err != nil {
+		t.Fatalf("RunTests returned error: %v", err)
+	}
+	for i, want := range []bool{true, false, false} {
+		privacy := results[i].Privacy
+		if privacy == nil || privacy.ExitCountry != "NL" {
+			t.Fatalf("%s: expected exit country NL, got %+v", protocols[i].Name, privacy)
+		}
+		if privacy.CountryMismatch != want {
+			t.Errorf("%s: country mismatch %t, want %t", protocols[i].Name, privacy.CountryMismatch, want)
+		}
+	}
+}
+
+func TestMeteredRun(t *testing.T) {
+	runner := newMockRunner()
+	runner.config.TestConfig.Metered = true
+	runner.config.TestConfig.MeteredNodeBytes = 1000
+	runner.config.TestConfig.MeteredRunBytes = 2500
+
+	protocols := []*models.Protocol{
+		{Type: models.ProtocolVLESS, Name: "node-1", Server: "n1.example.com", Port: 443},
+		{Type: models.ProtocolVLESS, Name: "node-2", Server: "n2.example.com", Port: 443},
+		{Type: models.ProtocolVLESS, Name: "node-3", Server: "n3.example.com", Port: 443},
+		{Type: models.ProtocolVLESS, Name: "node-4", Server: "n4.example.com", Port: 443},
+	}
+	// The daemon reuses its runner; every run gets the whole cap
+	for run := 1; run <= 2; run++ {
+		results, err := runner.RunTests(context.Background(), protocols)
+		if err != nil {
+			t.Fatalf("RunTests returned error: %v", err)
+		}
+
+		var total int64
+		skipped := 0
+		for _, result := range results {
+			performance := result.Performance
+			if performance == nil || performance.Metered == nil {
+				t.Fatalf("%s: expected a metered speed test, got %+v", result.Protocol.Name, performance)
+			}
+			if performance.Metered.Bytes > 1000 {
+				t.Errorf("%s: downloaded %d bytes, over the node cap", result.Protocol.Name, performance.Metered.Bytes)
+			}
+			if performance.JitterSamples != nil {
+				t.Errorf("%s: jitter sampled in metered mode", result.Protocol.Name)
+			}
+			if performance.Metered.CapBytes == 0 {
+				skipped++
+			}
+			total += performance.Metered.Bytes
+		}
+		if total != 2500 || skipped != 1 {
+			t.Errorf("run %d downloaded %d bytes with %d nodes skipped, want the 2500 byte run cap and 1 skipped", run, total, skipped)
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+	runner := newMockRunner()
+	runner.config.TestConfig.EnableGeoTest = true
+	var mu sync.Mutex
+	var events []models.TestProgress
+	runner.SetProgress(func(progress models.TestProgress) {
+		mu.Lock()
+		events = append(events, progress)
+		mu.Unlock()
+	})
+
+	protocol := &models.Protocol{Type: models.ProtocolVLESS, Name: "NL-01", Server: "nl.example.com", Port: 443}
+	if _, err := runner.TestSingle(context.Background(), protocol); err != nil {
+		t.Fatal(err)
+	}
+
+	last := -1.0
+	geoSteps := 0
+	for _, event := range events {
+		if event.Percent < last {
+			t.Errorf("progress went back from %.0f%% to %.0f%% at %s", last, event.Percent, event.Stage)
+		}
+		last = event.Percent
+		if event.Stage == "geo" && event.Detail != "" {
+			geoSteps++
+		}
+	}
+	if len(events) == 0 || events[0].Stage != "connectivity" || events[0].StageIndex != 1 {
+		t.Fatalf("first event %+v, want connectivity as stage 1", events[0])
+	}
+	if final := events[len(events)-1]; final.Stage != "done" || final.Percent != 100 || final.StageIndex != final.StageCount {
+		t.Errorf("final event %+v, want done at 100%%", final)
+	}
+	if geoSteps < 2 {
+		t.Errorf("%d geo domain events, want one per domain", geoSteps)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	runner := newMockRunner()
+	runner.concurrency = 1
+	errEnough := errors.New("enough")
+	runner.SetHooks(Hooks{
+		BeforeTest: func(ctx context.Context, protocol *models.Protocol) error {
+			if strings.HasPrefix(protocol.Name, "skip") {
+				return errors.New("filtered")
+			}
+			return nil
+		},
+		BeforeCheck: func(ctx context.Context, protocol *models.Protocol, check string) error {
+			if check == "performance" {
+				return errors.New("no speed test")
+			}
+			return nil
+		},
+		AfterTest: func(ctx context.Context, protocol *models.Protocol, result *models.TestResult) error {
+			if result.Success {
+				return errEnough
+			}
+			return nil
+		},
+	})
+
+	protocols := []*models.Protocol{
+		{Type: models.ProtocolVLESS, Name: "skip-1", Server: "s1.example.com", Port: 443},
+		{Type: models.ProtocolVLESS, Name: "NL-01", Server: "nl.example.com", Port: 443},
+	}
+	results, err := runner.RunTests(context.Background(), protocols)
+	if !errors.Is(err, errEnough) {
+		t.Fatalf("RunTests returned %v, want the AfterTest error", err)
+	}
+	if results[0].Connectivity != nil || !strings.Contains(results[0].Error, "filtered") {
+		t.Errorf("filtered node result %+v, want it untested", results[0])
+	}
+	working := results[1]
+	if !working.Success || working.Performance != nil {
+		t.Fatalf("expected working node without speed test, got %+v", working)
+	}
+	if len(working.SkippedChecks) == 0 || working.SkippedChecks[0].Reason != "no speed test" {
+		t.Errorf("skipped checks %+v, want performance skipped by the hook", working.SkippedChecks)
+	}
+
+	// Nodes waiting when the run is aborted are not tested
+	runner.SetHooks(Hooks{AfterTest: func(context.Context, *models.Protocol, *models.TestResult) error { return errEnough }})
+	protocols = []*models.Protocol{
+		{Type: models.ProtocolVLESS, Name: "n1", Server: "n1.example.com", Port: 443},
+		{Type: models.ProtocolVLESS, Name: "n2", Server: "n2.example.com", Port: 443},
+		{Type: models.ProtocolVLESS, Name: "n3", Server: "n3.example.com", Port: 443},
+	}
+	results, _ = runner.RunTests(context.Background(), protocols)
+	untested := 0
+	for _, result := range results {
+		if strings.Contains(result.Error, "enough") {
+			untested++
+		}
+	}
+	if untested == 0 {
+		t.Error("expected nodes left untested after the abort")
+	}
+}
+
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin commands below need a POSIX shell")
+	}
+	runner := newMockRunner()
+	runner.config.TestConfig.EnableSpeedTest = false
+	runner.config.Plugins = []models.PluginConfig{
+		{Name: "socks", Command: `echo "\"$PROTOSCOPE_SOCKS\""`},
+		{Name: "broken", Command: "exit 1"},
+	}
+
+	results, err := runner.RunTests(context.Background(), []*models.Protocol{
+		{Type: models.ProtocolVLESS, Name: "NL-01", Server: "nl.example.com", Port: 443},
+	})
+	if err != nil {
+		t.Fatalf("RunTests returned error: %v", err)
+	}
+	plugins := results[0].Plugins
+	if socks := plugins["socks"]; socks == nil || !strings.HasPrefix(string(socks.Output), `"127.0.0.1:`) {
+		t.Errorf("socks plugin result %+v, want the node's SOCKS address", socks)
+	}
+	if broken := plugins["broken"]; broken == nil || broken.Error == "" {
+		t.Errorf("broken plugin result %+v, want an error", broken)
+	}
+}
+
+func TestClockSkewAnnotatesFailures(t *testing.T) {
+	runner := newMockRunner()
+	runner.SetMockReplay([]MockResponse{
+		{URL: "https://www.cloudflare.com", Status: 200, Headers: map[string]string{
+			"Date": time.Now().Add(-5 * time.Minute).UTC().Format(http.TimeFormat),
+		}},
+	})
+
+	protocols := []*models.Protocol{
+		{Type: models.ProtocolVMess, Name: "dead-vmess", Server: "dead.example.com", Port: 443},
+		{Type: models.ProtocolTrojan, Name: "dead-trojan", Server: "dead.example.com", Port: 443},
+	}
+	results, err := runner.RunTests(context.Background(), protocols)
+	if err != nil {
+		t.Fatalf("RunTests returned error: %v", err)
+	}
+	if !strings.Contains(results[0].ErrorDetails.Details, "Local clock is 5m") {
+		t.Errorf("vmess failure not annotated with the clock skew: %q", results[0].ErrorDetails.Details)
+	}
+	if strings.Contains(results[1].ErrorDetails.Details, "Local clock") {
+		t.Errorf("trojan does not depend on the clock, got %q", results[1].ErrorDetails.Details)
+	}
+}
+
+func TestMockReplayOverridesResponses(t *testing.T) {
+	protocol := &models.Protocol{Type: models.ProtocolVMess, Name: "JP-01", Server: "jp.example.com", Port: 443}
+
+	// A blocked first endpoint falls back to the next one
+	runner := newMockRunner()
+	runner.SetMockReplay([]MockResponse{
+		{URL: "http://www.gstatic.com/generate_204", Status: 503},
+	})
+
+	result, err := runner.QuickTest(context.Background(), protocol)
+	if err != nil {
+		t.Fatalf("QuickTest returned error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected fallback endpoint to pass connectivity, got %q", result.Connectivity.Error)
+	}
+	if result.Connectivity.Endpoint != "http://cp.cloudflare.com/generate_204" {
+		t.Fatalf("expected cloudflare fallback, got %q", result.Connectivity.Endpoint)
+	}
+
+	// With every endpoint blocked the node fails
+	runner = newMockRunner()
+	runner.SetMockReplay([]MockResponse{
+		{URL: "http://", Status: 503},
+	})
+
+	result, err = runner.QuickTest(context.Background(), protocol)
+	if err != nil {
+		t.Fatalf("QuickTest returned error: %v", err)
+	}
+	if result.Success {
+		t.Fatal("expected replayed 503 on all endpoints to fail connectivity")
+	}
+}
@@ -0,0 +1,202 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/proxy"
+
+	"github.com/VenoMexx/ProtoScope/internal/checks"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// checkStage is one check in the per-node pipeline.
+// Stages declare which other stages they need and may skip themselves based
+// on results gathered so far, so obviously broken nodes don't burn time on
+// checks whose outcome is already known.
+type checkStage struct {
+	name string
+	// dependsOn names the stages whose results this one needs. It runs
+	// after them and is skipped when one failed or was skipped; a disabled
+	// dependency doesn't hold it back, it then runs without that result.
+	dependsOn []string
+
+	// enabled reports whether the stage is turned on in the config
+	enabled func(cfg *models.TestConfig) bool
+
+	// skipReason returns a non-empty reason when the stage should not run
+	skipReason func(cfg *models.TestConfig, result *models.TestResult) string
+
+	// run executes the check and stores its output in env.result
+	run func(ctx context.Context, env *stageEnv) error
+}
+
+// stageEnv is the state shared by all stages of one node
+type stageEnv struct {
+	runner   *TestRunner
+	protocol *models.Protocol
+	client   *http.Client
+	dialer   proxy.Dialer // raw TCP through the proxy
+	result   *models.TestResult
+	traffic  checks.TrafficCounter // nil unless the backend counts traffic
+	proxyMgr *ProxyManager         // nil in tests; checkClient then shares client
+	progress *nodeProgress         // nil unless progress is reported
+}
+
+// checkClient returns an HTTP client for one check, with the check's own
+// timeout (scaled for the protocol) and connection pool. Failing that, the
+// shared client is returned.
+func (env *stageEnv) checkClient(timeout time.Duration, keepAlive bool) *http.Client {
+	if env.proxyMgr == nil {
+		return env.client
+	}
+	timeout = env.runner.config.TestConfig.ScaleTimeout(env.protocol.Type, timeout)
+	client, err := env.proxyMgr.NewHTTPClient(timeout, keepAlive)
+	if err != nil {
+		return env.client
+	}
+	return client
+}
+
+// stageStatus is the outcome of a stage within one scheduler run
+type stageStatus int
+
+const (
+	stageCompleted stageStatus = iota
+	stageDisabled
+	stageSkipped
+	stageFailed
+)
+
+// orderStages sorts stages so that every stage comes after its dependencies.
+// Declaration order is kept where dependencies allow it.
+func orderStages(stages []checkStage) ([]checkStage, error) {
+	byName := make(map[string]bool, len(stages))
+	for _, stage := range stages {
+		byName[stage.name] = true
+	}
+
+	ordered := make([]checkStage, 0, len(stages))
+	placed := make(map[string]bool, len(stages))
+
+	for len(ordered) < len(stages) {
+		progress := false
+
+		for _, stage := range stages {
+			if placed[stage.name] {
+				continue
+			}
+
+			ready := true
+			for _, dep := range stage.dependsOn {
+				if byName[dep] && !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+
+			ordered = append(ordered, stage)
+			placed[stage.name] = true
+			progress = true
+		}
+
+		if !progress {
+			return nil, fmt.Errorf("check stages have a dependency cycle")
+		}
+	}
+
+	return ordered, nil
+}
+
+// runStages executes the stages in dependency order. A stage is skipped when
+// it is disabled, when one of its dependencies did not complete, when its
+// own skip condition matches, or when ctx is already done.
+func (tr *TestRunner) runStages(ctx context.Context, stages []checkStage, env *stageEnv) {
+	ordered, err := orderStages(stages)
+	if err != nil {
+		env.result.Error = err.Error()
+		return
+	}
+
+	cfg := &tr.config.TestConfig
+	status := make(map[string]stageStatus, len(ordered))
+	var stopped error // set when an AfterCheck hook stops the node
+
+	for _, stage := range ordered {
+		if stage.enabled != nil && !stage.enabled(cfg) {
+			status[stage.name] = stageDisabled
+			continue
+		}
+		env.progress.start(stage.name)
+
+		// The node hit its deadline; don't start checks that can't finish
+		if ctx.Err() != nil {
+			status[stage.name] = stageSkipped
+			env.result.PartialSuccess = true
+			env.result.SkippedChecks = append(env.result.SkippedChecks, models.SkippedCheck{Name: stage.name, Reason: "node deadline reached"})
+			continue
+		}
+
+		if reason := dependencyReason(stage, status); reason != "" {
+			status[stage.name] = stageSkipped
+			env.result.SkippedChecks = append(env.result.SkippedChecks, models.SkippedCheck{Name: stage.name, Reason: reason})
+			continue
+		}
+
+		if stage.skipReason != nil {
+			if reason := stage.skipReason(cfg, env.result); reason != "" {
+				status[stage.name] = stageSkipped
+				env.result.SkippedChecks = append(env.result.SkippedChecks, models.SkippedCheck{Name: stage.name, Reason: reason})
+				continue
+			}
+		}
+
+		if stopped != nil {
+			status[stage.name] = stageSkipped
+			env.result.SkippedChecks = append(env.result.SkippedChecks, models.SkippedCheck{Name: stage.name, Reason: stopped.Error()})
+			continue
+		}
+		if hook := tr.hooks.BeforeCheck; hook != nil {
+			if err := hook(ctx, env.protocol, stage.name); err != nil {
+				status[stage.name] = stageSkipped
+				env.result.SkippedChecks = append(env.result.SkippedChecks, models.SkippedCheck{Name: stage.name, Reason: err.Error()})
+				continue
+			}
+		}
+
+		err := stage.run(ctx, env)
+		if hook := tr.hooks.AfterCheck; hook != nil {
+			if hookErr := hook(ctx, env.protocol, stage.name, env.result, err); hookErr != nil {
+				stopped = fmt.Errorf("stopped after %s: %w", stage.name, hookErr)
+			}
+		}
+		if err != nil {
+			status[stage.name] = stageFailed
+			if ctx.Err() != nil {
+				// Whatever the stage stored before the deadline is kept
+				env.result.PartialSuccess = true
+				env.result.SkippedChecks = append(env.result.SkippedChecks, models.SkippedCheck{Name: stage.name, Reason: "interrupted by node deadline"})
+			}
+			continue
+		}
+		status[stage.name] = stageCompleted
+	}
+}
+
+// dependencyReason explains why a stage cannot run because of its dependencies
+func dependencyReason(stage checkStage, status map[string]stageStatus) string {
+	for _, dep := range stage.dependsOn {
+		switch status[dep] {
+		case stageSkipped:
+			return fmt.Sprintf("depends on %s, which was skipped", dep)
+		case stageFailed:
+			return fmt.Sprintf("depends on %s, which failed", dep)
+		}
+	}
+	return ""
+}
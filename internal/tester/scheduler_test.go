@@ -0,0 +1,228 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestOrderStagesRespectsDependencies(t *testing.T) {
+	stages := []checkStage{
+		{name: "b", dependsOn: []string{"a"}},
+		{name: "a"},
+		{name: "c", dependsOn: []string{"b"}},
+	}
+
+	ordered, err := orderStages(stages)
+	if err != nil {
+		t.Fatalf("orderStages returned error: %v", err)
+	}
+
+	got := ""
+	for _, stage := range ordered {
+		got += stage.name
+	}
+	if got != "abc" {
+		t.Fatalf("expected order abc, got %s", got)
+	}
+}
+
+func TestOrderStagesDetectsCycle(t *testing.T) {
+	stages := []checkStage{
+		{name: "a", dependsOn: []string{"b"}},
+		{name: "b", dependsOn: []string{"a"}},
+	}
+
+	if _, err := orderStages(stages); err == nil {
+		t.Fatal("expected cycle error")
+	}
+}
+
+func TestRunStagesSkipsDependentsOfFailedStage(t *testing.T) {
+	ran := map[string]bool{}
+	stages := []checkStage{
+		{
+			name: "first",
+			run: func(ctx context.Context, env *stageEnv) error {
+				ran["first"] = true
+				return errors.New("boom")
+			},
+		},
+		{
+			name:      "second",
+			dependsOn: []string{"first"},
+			run: func(ctx context.Context, env *stageEnv) error {
+				ran["second"] = true
+				return nil
+			},
+		},
+	}
+
+	tr := NewTestRunner(models.DefaultConfig())
+	env := &stageEnv{result: &models.TestResult{}}
+	tr.runStages(context.Background(), stages, env)
+
+	if !ran["first"] || ran["second"] {
+		t.Fatalf("unexpected stages ran: %v", ran)
+	}
+	if len(env.result.SkippedChecks) != 1 || env.result.SkippedChecks[0].Name != "second" {
+		t.Fatalf("expected second to be recorded as skipped, got %+v", env.result.SkippedChecks)
+	}
+}
+
+func TestRunStagesRunsDependentsOfDisabledStage(t *testing.T) {
+	ran := map[string]bool{}
+	stages := []checkStage{
+		{
+			name:    "first",
+			enabled: func(*models.TestConfig) bool { return false },
+			run: func(ctx context.Context, env *stageEnv) error {
+				ran["first"] = true
+				return nil
+			},
+		},
+		{
+			name:      "second",
+			dependsOn: []string{"first"},
+			run: func(ctx context.Context, env *stageEnv) error {
+				ran["second"] = true
+				return nil
+			},
+		},
+	}
+
+	tr := NewTestRunner(models.DefaultConfig())
+	env := &stageEnv{result: &models.TestResult{}}
+	tr.runStages(context.Background(), stages, env)
+
+	if ran["first"] || !ran["second"] {
+		t.Fatalf("unexpected stages ran: %v", ran)
+	}
+}
+
+// TestDefaultStagesDependencies keeps the declared dependencies valid
+func TestDefaultStagesDependencies(t *testing.T) {
+	stages := defaultStages()
+	names := map[string]bool{}
+	for _, stage := range stages {
+		names[stage.name] = true
+	}
+	for _, stage := range stages {
+		for _, dep := range stage.dependsOn {
+			if !names[dep] {
+				t.Errorf("stage %s depends on unknown stage %s", stage.name, dep)
+			}
+		}
+	}
+
+	ordered, err := orderStages(stages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	position := map[string]int{}
+	for i, stage := range ordered {
+		position[stage.name] = i
+	}
+	for _, stage := range ordered {
+		for _, dep := range stage.dependsOn {
+			if position[dep] > position[stage.name] {
+				t.Errorf("stage %s runs before its dependency %s", stage.name, dep)
+			}
+		}
+	}
+}
+
+func TestStreamingSkippedForSlowNode(t *testing.T) {
+	var streaming checkStage
+	for _, stage := range defaultStages() {
+		if stage.name == "streaming" {
+			streaming = stage
+		}
+	}
+	ran := false
+	streaming.run = func(ctx context.Context, env *stageEnv) error {
+		ran = true
+		return nil
+	}
+
+	config := models.DefaultConfig()
+	config.TestConfig.EnableStreamingCheck = true
+	config.TestConfig.SlowNodeThreshold = 100 * time.Millisecond
+	tr := NewTestRunner(config)
+	env := &stageEnv{result: &models.TestResult{
+		Connectivity: &models.ConnectivityResult{Connected: true, ResponseTime: 500 * time.Millisecond},
+	}}
+	tr.runStages(context.Background(), []checkStage{streaming}, env)
+
+	if ran {
+		t.Fatal("expected streaming to be skipped for a slow node")
+	}
+	if len(env.result.SkippedChecks) != 1 || env.result.SkippedChecks[0].Name != "streaming" {
+		t.Fatalf("expected streaming to be recorded as skipped, got %+v", env.result.SkippedChecks)
+	}
+}
+
+func TestRunStagesStopsAtDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := map[string]bool{}
+	stages := []checkStage{
+		{
+			name: "first",
+			run: func(ctx context.Context, env *stageEnv) error {
+				ran["first"] = true
+				cancel()
+				return ctx.Err()
+			},
+		},
+		{
+			name: "second",
+			run: func(ctx context.Context, env *stageEnv) error {
+				ran["second"] = true
+				return nil
+			},
+		},
+	}
+
+	tr := NewTestRunner(models.DefaultConfig())
+	env := &stageEnv{result: &models.TestResult{}}
+	tr.runStages(ctx, stages, env)
+
+	if ran["second"] {
+		t.Fatal("expected no stage to start after the deadline")
+	}
+	skipped := env.result.SkippedChecks
+	if len(skipped) == 0 || skipped[len(skipped)-1].Name != "second" {
+		t.Fatalf("expected second to be recorded as skipped, got %+v", skipped)
+	}
+}
+
+func TestRunStagesKeepsResultsInterruptedByDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stages := []checkStage{
+		{name: "geo", run: func(ctx context.Context, env *stageEnv) error {
+			env.result.GeoAccess = &models.GeoAccessResult{Summary: models.GeoAccessSummary{TotalTested: 3}}
+			cancel()
+			return ctx.Err()
+		}},
+		{name: "privacy", run: func(ctx context.Context, env *stageEnv) error {
+			return nil
+		}},
+	}
+
+	tr := NewTestRunner(models.DefaultConfig())
+	env := &stageEnv{result: &models.TestResult{Success: true}}
+	tr.runStages(ctx, stages, env)
+
+	if env.result.Status() != "partial" {
+		t.Fatalf("expected partial status, got %s", env.result.Status())
+	}
+	if env.result.GeoAccess == nil || env.result.GeoAccess.Summary.TotalTested != 3 {
+		t.Fatal("expected geo results gathered before the deadline to be kept")
+	}
+	if len(env.result.SkippedChecks) != 2 {
+		t.Fatalf("expected interrupted and skipped checks to be recorded, got %+v", env.result.SkippedChecks)
+	}
+}
@@ -1,8 +1,11 @@
 package tester
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/VenoMexx/ProtoScope/pkg/models"
 )
@@ -15,7 +18,7 @@ func (pm *ProxyManager) generateSingboxConfig() (map[string]interface{}, error)
 		},
 		"inbounds": []map[string]interface{}{
 			{
-				"type":   "socks",
+				"type":   pm.inboundType,
 				"tag":    "socks-in",
 				"listen": pm.socksAddress,
 				"listen_port": pm.socksPort,
@@ -25,33 +28,45 @@ func (pm *ProxyManager) generateSingboxConfig() (map[string]interface{}, error)
 	}
 
 	// Generate outbound based on protocol type
-	var outbound map[string]interface{}
-	var err error
+	outbound, err := pm.generateSingboxOutbound()
+	if err != nil {
+		return nil, err
+	}
+
+	if IsSingboxEndpoint(outbound) {
+		// The proxy is an endpoint, which has no default route to it
+		config["endpoints"] = []map[string]interface{}{outbound}
+		config["route"] = map[string]interface{}{"final": outbound["tag"]}
+		return config, nil
+	}
+
+	config["outbounds"] = []map[string]interface{}{outbound}
+
+	return config, nil
+}
 
+// generateSingboxOutbound generates the sing-box outbound for the protocol type
+func (pm *ProxyManager) generateSingboxOutbound() (map[string]interface{}, error) {
 	switch pm.protocol.Type {
 	case models.ProtocolHysteria2:
-		outbound, err = pm.generateHysteria2Outbound()
+		return pm.generateHysteria2Outbound()
 	case models.ProtocolTUIC:
-		outbound, err = pm.generateTUICOutbound()
+		return pm.generateTUICOutbound()
 	case models.ProtocolVMess:
-		outbound, err = pm.generateSingboxVMessOutbound()
+		return pm.generateSingboxVMessOutbound()
 	case models.ProtocolVLESS:
-		outbound, err = pm.generateSingboxVLESSOutbound()
+		return pm.generateSingboxVLESSOutbound()
 	case models.ProtocolTrojan:
-		outbound, err = pm.generateSingboxTrojanOutbound()
+		return pm.generateSingboxTrojanOutbound()
 	case models.ProtocolShadowsocks:
-		outbound, err = pm.generateSingboxShadowsocksOutbound()
+		return pm.generateSingboxShadowsocksOutbound()
+	case models.ProtocolWireGuard:
+		return pm.generateWireGuardEndpoint()
+	case models.ProtocolSSH:
+		return pm.generateSSHOutbound()
 	default:
 		return nil, fmt.Errorf("unsupported protocol for sing-box: %s", pm.protocol.Type)
 	}
-
-	if err != nil {
-		return nil, err
-	}
-
-	config["outbounds"] = []map[string]interface{}{outbound}
-
-	return config, nil
 }
 
 // generateHysteria2Outbound generates Hysteria2 outbound for sing-box
@@ -116,6 +131,9 @@ func (pm *ProxyManager) generateTUICOutbound() (map[string]interface{}, error) {
 	if pm.protocol.SNI != "" {
 		tls["server_name"] = pm.protocol.SNI
 	}
+	if pm.allowInsecure() {
+		tls["insecure"] = true
+	}
 
 	// Add ALPN to TLS (not root level!)
 	if alpn, ok := pm.protocol.Extra["alpn"].(string); ok && alpn != "" {
@@ -177,6 +195,9 @@ func (pm *ProxyManager) generateSingboxVMessOutbound() (map[string]interface{},
 		if pm.protocol.SNI != "" {
 			tls["server_name"] = pm.protocol.SNI
 		}
+		if pm.allowInsecure() {
+			tls["insecure"] = true
+		}
 
 		// Add uTLS if fingerprint specified (optional for VMess)
 		if fp, ok := pm.protocol.Extra["fp"].(string); ok && fp != "" {
@@ -245,6 +266,9 @@ func (pm *ProxyManager) generateSingboxVLESSOutbound() (map[string]interface{},
 		if pm.protocol.SNI != "" {
 			tls["server_name"] = pm.protocol.SNI
 		}
+		if pm.allowInsecure() {
+			tls["insecure"] = true
+		}
 
 		// Check for REALITY
 		if security, ok := pm.protocol.Extra["security"].(string); ok && security == "reality" {
@@ -308,6 +332,9 @@ func (pm *ProxyManager) generateSingboxTrojanOutbound() (map[string]interface{},
 	if pm.protocol.SNI != "" {
 		tls["server_name"] = pm.protocol.SNI
 	}
+	if pm.allowInsecure() {
+		tls["insecure"] = true
+	}
 
 	// Add uTLS if fingerprint specified (optional for Trojan)
 	if fp, ok := pm.protocol.Extra["fp"].(string); ok && fp != "" {
@@ -371,6 +398,131 @@ func (pm *ProxyManager) generateSingboxShadowsocksOutbound() (map[string]interfa
 	return outbound, nil
 }
 
+// generateWireGuardEndpoint generates the WireGuard endpoint for sing-box,
+// which replaced the WireGuard outbound in sing-box 1.11
+func (pm *ProxyManager) generateWireGuardEndpoint() (map[string]interface{}, error) {
+	extra := func(key string) string {
+		value, _ := pm.protocol.Extra[key].(string)
+		return value
+	}
+
+	peer := map[string]interface{}{
+		"address":     pm.protocol.Server,
+		"port":        pm.protocol.Port,
+		"public_key":  extra("publickey"),
+		"allowed_ips": []string{"0.0.0.0/0", "::/0"},
+	}
+	if psk := extra("presharedkey"); psk != "" {
+		peer["pre_shared_key"] = psk
+	}
+	if reserved := extra("reserved"); reserved != "" {
+		bytes, err := parseWireGuardReserved(reserved)
+		if err != nil {
+			return nil, err
+		}
+		peer["reserved"] = bytes
+	}
+
+	address := extra("address")
+	if address == "" {
+		return nil, fmt.Errorf("wireguard node has no local address")
+	}
+	endpoint := map[string]interface{}{
+		"type":        "wireguard",
+		"tag":         "proxy",
+		"address":     strings.Split(address, ","),
+		"private_key": pm.protocol.Password,
+		"peers":       []map[string]interface{}{peer},
+	}
+	if mtu := extra("mtu"); mtu != "" {
+		value, err := strconv.Atoi(mtu)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wireguard mtu %q", mtu)
+		}
+		endpoint["mtu"] = value
+	}
+
+	return endpoint, nil
+}
+
+// parseWireGuardReserved parses the 3 reserved bytes some servers (such as
+// Cloudflare WARP) expect, written as "1,2,3" or as base64
+func parseWireGuardReserved(reserved string) ([]int, error) {
+	var values []int
+	if strings.Contains(reserved, ",") {
+		for _, field := range strings.Split(reserved, ",") {
+			value, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || value < 0 || value > 255 {
+				return nil, fmt.Errorf("invalid wireguard reserved bytes %q", reserved)
+			}
+			values = append(values, value)
+		}
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(reserved)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wireguard reserved bytes %q", reserved)
+		}
+		for _, b := range decoded {
+			values = append(values, int(b))
+		}
+	}
+	if len(values) != 3 {
+		return nil, fmt.Errorf("wireguard reserved needs 3 bytes, got %d", len(values))
+	}
+	return values, nil
+}
+
+// generateSSHOutbound generates the SSH outbound for sing-box
+func (pm *ProxyManager) generateSSHOutbound() (map[string]interface{}, error) {
+	extra := func(key string) string {
+		value, _ := pm.protocol.Extra[key].(string)
+		return value
+	}
+
+	outbound := map[string]interface{}{
+		"type":        "ssh",
+		"tag":         "proxy",
+		"server":      pm.protocol.Server,
+		"server_port": pm.protocol.Port,
+		"user":        extra("user"),
+	}
+	if pm.protocol.Password != "" {
+		outbound["password"] = pm.protocol.Password
+	}
+	if key := extra("privatekey"); key != "" {
+		outbound["private_key"] = key
+		if passphrase := extra("passphrase"); passphrase != "" {
+			outbound["private_key_passphrase"] = passphrase
+		}
+	}
+	// Without host keys sing-box accepts any server key
+	if hostKeys := extra("hostkey"); hostKeys != "" {
+		outbound["host_key"] = strings.Split(hostKeys, ",")
+	}
+
+	return outbound, nil
+}
+
+// IsSingboxEndpoint reports whether a generated sing-box outbound is an
+// endpoint, which belongs in a config's endpoints instead of its outbounds
+func IsSingboxEndpoint(outbound map[string]interface{}) bool {
+	return outbound["type"] == "wireguard"
+}
+
+// SingboxOutbound returns the sing-box outbound for a protocol with the given
+// tag, for embedding nodes into exported client configs
+func SingboxOutbound(protocol *models.Protocol, tag string) (map[string]interface{}, error) {
+	pm := &ProxyManager{protocol: protocol}
+
+	outbound, err := pm.generateSingboxOutbound()
+	if err != nil {
+		return nil, err
+	}
+
+	outbound["tag"] = tag
+	return outbound, nil
+}
+
 // GetSingboxConfig returns the generated sing-box config as JSON string for debugging
 func (pm *ProxyManager) GetSingboxConfig() (string, error) {
 	config, err := pm.generateSingboxConfig()
@@ -0,0 +1,113 @@
+package tester
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestSingboxConfigWireGuardEndpoint(t *testing.T) {
+	pm := NewProxyManager(&models.Protocol{
+		Type:     models.ProtocolWireGuard,
+		Server:   "engage.cloudflareclient.com",
+		Port:     2408,
+		Password: "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=",
+		Extra: map[string]interface{}{
+			"publickey": "bmXOC+F1FxEMF9dyiK2H5/1SUtzH0JuVo51h2wPfgyo=",
+			"address":   "172.16.0.2/32,fd01::2/128",
+			"reserved":  "AQID",
+			"mtu":       "1280",
+		},
+	}, 10808)
+
+	config, err := pm.generateSingboxConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpoints, ok := config["endpoints"].([]map[string]interface{})
+	if !ok || len(endpoints) != 1 {
+		t.Fatalf("endpoints = %v", config["endpoints"])
+	}
+	if outbounds := config["outbounds"].([]map[string]interface{}); len(outbounds) != 0 {
+		t.Errorf("outbounds = %v, want none", outbounds)
+	}
+	route, _ := config["route"].(map[string]interface{})
+	if route["final"] != endpoints[0]["tag"] {
+		t.Errorf("route = %v, want final %v", route, endpoints[0]["tag"])
+	}
+
+	endpoint := endpoints[0]
+	if !slices.Equal(endpoint["address"].([]string), []string{"172.16.0.2/32", "fd01::2/128"}) || endpoint["mtu"] != 1280 {
+		t.Errorf("endpoint = %v", endpoint)
+	}
+	peer := endpoint["peers"].([]map[string]interface{})[0]
+	if !slices.Equal(peer["reserved"].([]int), []int{1, 2, 3}) || peer["port"] != 2408 {
+		t.Errorf("peer = %v", peer)
+	}
+}
+
+func TestParseWireGuardReserved(t *testing.T) {
+	for _, reserved := range []string{"1,2,3", "1, 2, 3", "AQID"} {
+		got, err := parseWireGuardReserved(reserved)
+		if err != nil || !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("parseWireGuardReserved(%q) = %v, %v", reserved, got, err)
+		}
+	}
+	for _, reserved := range []string{"1,2", "1,2,256", "AQIDBA==", "x"} {
+		if _, err := parseWireGuardReserved(reserved); err == nil {
+			t.Errorf("parseWireGuardReserved(%q) succeeded", reserved)
+		}
+	}
+}
+
+func TestSingboxConfigSSH(t *testing.T) {
+	pm := NewProxyManager(&models.Protocol{
+		Type:     models.ProtocolSSH,
+		Server:   "example.com",
+		Port:     22,
+		Password: "secret",
+		Extra: map[string]interface{}{
+			"user":    "root",
+			"hostkey": "ssh-ed25519 AAAA,ssh-rsa BBBB",
+		},
+	}, 10808)
+
+	config, err := pm.generateSingboxConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outbound := config["outbounds"].([]map[string]interface{})[0]
+	if outbound["type"] != "ssh" || outbound["user"] != "root" || outbound["password"] != "secret" {
+		t.Errorf("outbound = %v", outbound)
+	}
+	if _, ok := outbound["private_key"]; ok {
+		t.Error("private_key set without a key")
+	}
+	if !slices.Equal(outbound["host_key"].([]string), []string{"ssh-ed25519 AAAA", "ssh-rsa BBBB"}) {
+		t.Errorf("host_key = %v", outbound["host_key"])
+	}
+}
+
+func TestSingboxConfigInsecureTLS(t *testing.T) {
+	pm := NewProxyManager(&models.Protocol{
+		Type:     models.ProtocolTrojan,
+		Server:   "example.com",
+		Port:     443,
+		Password: "pw",
+		TLS:      true,
+		Extra:    map[string]interface{}{"insecure": "1"},
+	}, 10808)
+
+	config, err := pm.generateSingboxConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbound := config["outbounds"].([]map[string]interface{})[0]
+	tls, _ := outbound["tls"].(map[string]interface{})
+	if tls["insecure"] != true {
+		t.Errorf("tls = %v, want insecure", tls)
+	}
+}
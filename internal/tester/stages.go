@@ -0,0 +1,396 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/checks"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// defaultStages returns the checks run after a node passed connectivity
+func defaultStages() []checkStage {
+	return []checkStage{
+		{
+			name: "performance",
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnableSpeedTest
+			},
+			run: runPerformanceStage,
+		},
+		{
+			name: "geo",
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnableGeoTest
+			},
+			run: runGeoStage,
+		},
+		{
+			name: "dns",
+			// Geo access tells full censorship, which DNS blocking
+			// results are meaningless behind
+			dependsOn: []string{"geo"},
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnableDNSTest
+			},
+			skipReason: skipIfFullyCensored,
+			run:        runDNSStage,
+		},
+		{
+			name: "privacy",
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnablePrivacyTest
+			},
+			skipReason: skipIfSlow,
+			run:        runPrivacyStage,
+		},
+		{
+			name: "egress",
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnableEgressCheck && len(cfg.EgressPorts) > 0
+			},
+			run: runEgressStage,
+		},
+		{
+			name: "sensitive-sites",
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnableSensitiveCheck && len(cfg.SensitiveSites) > 0
+			},
+			run: runSensitiveStage,
+		},
+		{
+			name: "gaming",
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnableGamingTest && len(cfg.GamingEndpoints) > 0
+			},
+			run: runGamingStage,
+		},
+		{
+			name: "streaming",
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnableStreamingCheck
+			},
+			skipReason: skipIfSlow,
+			run:        runStreamingStage,
+		},
+		{
+			name: "ookla",
+			// A node that failed the built-in speed test can't carry
+			// the downloads of the throughput tests either
+			dependsOn: []string{"performance"},
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnableOokla
+			},
+			skipReason: skipIfMetered,
+			run:        runOoklaStage,
+		},
+		{
+			name:      "cdn-edge",
+			dependsOn: []string{"performance"},
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnableEdgeTest
+			},
+			skipReason: skipIfMetered,
+			run:        runEdgeStage,
+		},
+		{
+			name: "tls-fingerprint",
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnableTLSFingerprint
+			},
+			run: runTLSFingerprintStage,
+		},
+		{
+			name: "obfuscation",
+			enabled: func(cfg *models.TestConfig) bool {
+				return cfg.EnableObfuscationCheck
+			},
+			run: runObfuscationStage,
+		},
+	}
+}
+
+// skipIfSlow skips a stage when the node's connectivity latency is above
+// the configured threshold
+func skipIfSlow(cfg *models.TestConfig, result *models.TestResult) string {
+	if cfg.SlowNodeThreshold <= 0 || result.Connectivity == nil {
+		return ""
+	}
+	if result.Connectivity.ResponseTime > cfg.SlowNodeThreshold {
+		return fmt.Sprintf("connectivity latency %dms exceeds %dms threshold",
+			result.Connectivity.ResponseTime.Milliseconds(), cfg.SlowNodeThreshold.Milliseconds())
+	}
+	return ""
+}
+
+// skipIfMetered skips a stage that transfers too much for metered mode
+func skipIfMetered(cfg *models.TestConfig, result *models.TestResult) string {
+	if cfg.Metered {
+		return "metered mode"
+	}
+	return ""
+}
+
+// skipIfFullyCensored skips a stage when geo checks reached no domain at all;
+// DNS blocking results are meaningless behind full censorship
+func skipIfFullyCensored(cfg *models.TestConfig, result *models.TestResult) string {
+	if result.GeoAccess == nil || result.GeoAccess.Summary.TotalTested == 0 {
+		return ""
+	}
+	if result.GeoAccess.Summary.TotalAccessible == 0 {
+		return "geo checks show full censorship"
+	}
+	return ""
+}
+
+// Request timeouts of the checks' HTTP clients, before protocol scaling.
+// The shared client's timeout is the whole node budget, too long for a
+// latency sample and too short for a download on a slow node.
+const (
+	latencyTimeout   = 10 * time.Second
+	downloadTimeout  = 60 * time.Second
+	geoTimeout       = 10 * time.Second
+	dnsTimeout       = 10 * time.Second
+	privacyTimeout   = 15 * time.Second
+	ooklaTimeout     = 10 * time.Second
+	sensitiveTimeout = 15 * time.Second
+	streamingTimeout = 15 * time.Second
+)
+
+// Stage functions keep whatever a checker returned alongside an error, so
+// results gathered before a deadline are not discarded.
+
+func runPerformanceStage(ctx context.Context, env *stageEnv) error {
+	perfChecker := checks.NewPerformanceChecker(30 * time.Second)
+	if env.traffic != nil {
+		perfChecker.SetTrafficCounter(env.traffic)
+	}
+	endpoints := env.runner.config.APIEndpoints
+	perfChecker.SetEndpoints(endpoints.Latency, endpoints.SpeedTest)
+	testConfig := env.runner.config.TestConfig
+	perfChecker.SetJitterSampling(testConfig.JitterSamples, testConfig.JitterInterval)
+	budget, capBytes := env.runner.meteredCap()
+	if budget != nil {
+		perfChecker.SetDataCap(capBytes)
+	}
+	// Fresh connections for every latency sample and for the download, so
+	// samples don't mix cold and reused connections and the download doesn't
+	// ride on a connection warmed by them
+	perfChecker.SetDownloadClient(env.checkClient(downloadTimeout, false))
+	perfResult, err := perfChecker.Check(ctx, env.checkClient(latencyTimeout, false))
+	if perfResult != nil {
+		env.result.Performance = perfResult
+	}
+	if budget != nil {
+		used := int64(0)
+		if perfResult != nil && perfResult.Metered != nil {
+			used = perfResult.Metered.Bytes
+		}
+		budget.release(capBytes - used)
+	}
+	return err
+}
+
+func runGeoStage(ctx context.Context, env *stageEnv) error {
+	geoChecker := checks.NewGeoAccessChecker(10 * time.Second)
+	geoChecker.SetProgress(func(domain string, done, total int) {
+		env.progress.step(domain, float64(done)/float64(total))
+	})
+	geoChecker.SetExpectations(env.runner.config.TestConfig.GeoExpect)
+	geoChecker.SetRegions(env.runner.config.TestConfig.GeoRegions)
+	geoResult, err := geoChecker.Check(ctx, env.checkClient(geoTimeout, true))
+	if geoResult != nil {
+		env.result.GeoAccess = geoResult
+	}
+	return err
+}
+
+func runDNSStage(ctx context.Context, env *stageEnv) error {
+	// Try to get expected country from geo result
+	expectedCountry := ""
+	if env.result.GeoAccess != nil {
+		// Simple heuristic based on which regions are accessible
+		if env.result.GeoAccess.Summary.AccessPercentage > 50 {
+			expectedCountry = "US" // Assume US if most sites are accessible
+		}
+	}
+
+	dnsChecker := checks.NewDNSChecker(10 * time.Second)
+	if canary := env.runner.config.APIEndpoints.DNSCanary; canary != "" {
+		dnsChecker.SetCanary(canary)
+	}
+	if env.dialer != nil {
+		dnsChecker.SetDialer(env.dialer)
+	}
+	dnsChecker.SetWeights(env.runner.config.TestConfig.BlockingWeights)
+	blocklists, blocklistErr := env.runner.sampledBlocklists(ctx)
+	dnsChecker.SetBlocklists(blocklists)
+	dnsResult, err := dnsChecker.Check(ctx, env.checkClient(dnsTimeout, true), expectedCountry)
+	if dnsResult != nil {
+		if dnsResult.Blocking != nil && blocklistErr != nil {
+			dnsResult.Blocking.BlocklistError = strings.ReplaceAll(blocklistErr.Error(), "\n", "; ")
+		}
+		env.result.DNS = dnsResult
+	}
+	return err
+}
+
+func runPrivacyStage(ctx context.Context, env *stageEnv) error {
+	realIP, _ := env.runner.location()
+	privacyChecker := checks.NewPrivacyChecker(realIP)
+	if env.runner.ipPool != nil {
+		privacyChecker.SetIPCheckPool(env.runner.ipPool)
+	}
+	client := env.checkClient(privacyTimeout, true)
+	privacyResult, err := privacyChecker.Check(ctx, client)
+	if privacyResult != nil {
+		env.result.Privacy = privacyResult
+	}
+	if err != nil {
+		return err
+	}
+
+	if privacyResult.ProxyIP == "" {
+		return nil
+	}
+
+	// The exit country lets subscriptions be filtered by region and shows
+	// nodes whose name claims another country; a failed lookup doesn't fail
+	// the stage
+	var geo *checks.GeoInfo
+	geoEndpoints := env.runner.config.APIEndpoints.GeoLocation
+	if len(geoEndpoints) > 0 {
+		if geo, err = checks.LookupGeo(ctx, client, geoEndpoints[0], privacyResult.ProxyIP); err == nil {
+			privacyResult.ExitCountry = geo.CountryCode
+			advertised := env.result.Protocol.AdvertisedCountry
+			privacyResult.CountryMismatch = advertised != "" && advertised != geo.CountryCode
+		}
+	}
+
+	// Published cloud ranges give the region; the ASN catches providers
+	// without a feed
+	privacyResult.Hosting = env.runner.hostingRanges(ctx).Lookup(privacyResult.ProxyIP)
+	if privacyResult.Hosting == nil && geo != nil {
+		if hosting := checks.HostingFromAS(geo.AS); hosting != nil {
+			hosting.Region = geo.City
+			privacyResult.Hosting = hosting
+		}
+	}
+
+	if len(env.runner.config.APIEndpoints.ProxyLists) > 0 {
+		privacyResult.ProxyDetection = env.runner.detectionLists(ctx).Check(privacyResult.ProxyIP, privacyResult.Hosting)
+	}
+	return nil
+}
+
+func runEgressStage(ctx context.Context, env *stageEnv) error {
+	if env.dialer == nil {
+		return fmt.Errorf("no proxy dialer for egress probes")
+	}
+
+	egressChecker := checks.NewEgressChecker(5 * time.Second)
+	egressResult, err := egressChecker.Check(ctx, env.dialer, env.runner.config.APIEndpoints.EgressProbe, env.runner.config.TestConfig.EgressPorts)
+	if egressResult != nil {
+		env.result.Egress = egressResult
+	}
+	return err
+}
+
+func runSensitiveStage(ctx context.Context, env *stageEnv) error {
+	timeout := env.runner.config.TestConfig.ScaleTimeout(env.protocol.Type, sensitiveTimeout)
+	sensitiveChecker := checks.NewSensitiveChecker(timeout)
+	sensitiveResult, err := sensitiveChecker.Check(ctx, env.checkClient(sensitiveTimeout, true), env.runner.config.TestConfig.SensitiveSites)
+	if sensitiveResult != nil {
+		env.result.SensitiveSites = sensitiveResult
+	}
+	return err
+}
+
+func runGamingStage(ctx context.Context, env *stageEnv) error {
+	testConfig := env.runner.config.TestConfig
+	timeout := testConfig.ScaleTimeout(env.protocol.Type, latencyTimeout)
+	gamingChecker := checks.NewGamingChecker(timeout)
+	gamingChecker.SetSampling(testConfig.JitterSamples, testConfig.JitterInterval)
+	// Kept-alive connections, as a game holds one open to its server
+	gamingResult, err := gamingChecker.Check(ctx, env.checkClient(latencyTimeout, true), testConfig.GamingEndpoints)
+	if gamingResult != nil {
+		env.result.Gaming = gamingResult
+	}
+	return err
+}
+
+func runStreamingStage(ctx context.Context, env *stageEnv) error {
+	// Simulated nodes only answer plain HTTP, not the services' HTTPS
+	if env.runner.isMock() {
+		return nil
+	}
+
+	timeout := env.runner.config.TestConfig.ScaleTimeout(env.protocol.Type, streamingTimeout)
+	streamingChecker := checks.NewStreamingChecker(timeout)
+	streamingResult, err := streamingChecker.Check(ctx, env.checkClient(streamingTimeout, true))
+	if streamingResult != nil {
+		env.result.Streaming = streamingResult
+	}
+	return err
+}
+
+func runOoklaStage(ctx context.Context, env *stageEnv) error {
+	// Simulated nodes only answer HTTP
+	if env.runner.isMock() {
+		return nil
+	}
+	if env.dialer == nil {
+		return fmt.Errorf("no proxy dialer for the Ookla test")
+	}
+
+	timeout := env.runner.config.TestConfig.ScaleTimeout(env.protocol.Type, ooklaTimeout)
+	ooklaChecker := checks.NewOoklaChecker(timeout)
+	ooklaResult, err := ooklaChecker.Check(ctx, env.checkClient(ooklaTimeout, false), env.dialer, env.runner.config.APIEndpoints.OoklaServers)
+	if ooklaResult != nil {
+		env.result.Ookla = ooklaResult
+	}
+	return err
+}
+
+func runEdgeStage(ctx context.Context, env *stageEnv) error {
+	// Simulated nodes answer plain HTTP only, not the CDN's TLS
+	if env.runner.isMock() {
+		return nil
+	}
+	if env.dialer == nil {
+		return fmt.Errorf("no proxy dialer for the CDN edge test")
+	}
+
+	timeout := env.runner.config.TestConfig.ScaleTimeout(env.protocol.Type, downloadTimeout)
+	edgeChecker := checks.NewEdgeChecker(timeout)
+	edgeChecker.SetDirectClient(env.runner.directClient())
+	edgeChecker.SetNearUserURL(env.runner.config.APIEndpoints.CDNEdgeNearUser)
+	edgeResult, err := edgeChecker.Check(ctx, env.checkClient(downloadTimeout, false), env.dialer, env.runner.config.APIEndpoints.CDNEdge)
+	if edgeResult != nil {
+		env.result.EdgeSpeed = edgeResult
+	}
+	return err
+}
+
+func runTLSFingerprintStage(ctx context.Context, env *stageEnv) error {
+	// Simulated nodes send no ClientHello, and plain or QUIC nodes none
+	// that a TCP tap could see
+	if env.runner.isMock() || !usesTCPTLS(env.protocol) {
+		return nil
+	}
+
+	env.result.TLSFingerprint = env.runner.observeTLSFingerprint(ctx, env.protocol)
+	return nil
+}
+
+func runObfuscationStage(ctx context.Context, env *stageEnv) error {
+	method := obfuscationMethod(env.protocol)
+	if env.runner.isMock() || method == "" {
+		return nil
+	}
+
+	env.result.Obfuscation = env.runner.checkObfuscation(ctx, env.protocol, method)
+	return nil
+}
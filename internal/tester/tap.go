@@ -0,0 +1,214 @@
+package tester
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/checks"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// maxTapCapture caps how much of the backend's first flight is kept
+const maxTapCapture = 16 * 1024
+
+// captureFirstFlight points a copy of the node at a local listener and
+// returns the first bytes the backend sends when a request goes through
+// it; that is what anyone on the path to the real server sees. The tap
+// never answers, so only the client's opening message is captured. QUIC
+// nodes are tapped with a UDP socket and yield their first datagram.
+func (tr *TestRunner) captureFirstFlight(ctx context.Context, protocol *models.Protocol) ([]byte, error) {
+	tapCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	if isUDPProtocol(protocol.Type) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			return nil, fmt.Errorf("failed to open tap socket: %w", err)
+		}
+		defer conn.Close()
+
+		proxyMgr, err := tr.startTapBackend(tapCtx, protocol, conn.LocalAddr().(*net.UDPAddr).Port)
+		if err != nil {
+			return nil, err
+		}
+		defer proxyMgr.Stop()
+
+		deadline, _ := tapCtx.Deadline()
+		conn.SetReadDeadline(deadline)
+		buf := make([]byte, 2048)
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil, fmt.Errorf("backend never sent to the tap: %w", err)
+		}
+		return buf[:n], nil
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tap listener: %w", err)
+	}
+	defer listener.Close()
+
+	proxyMgr, err := tr.startTapBackend(tapCtx, protocol, listener.Addr().(*net.TCPAddr).Port)
+	if err != nil {
+		return nil, err
+	}
+	defer proxyMgr.Stop()
+
+	type accepted struct {
+		conn net.Conn
+		err  error
+	}
+	conns := make(chan accepted, 1)
+	go func() {
+		conn, err := listener.Accept()
+		conns <- accepted{conn, err}
+	}()
+
+	var conn net.Conn
+	select {
+	case a := <-conns:
+		if a.err != nil {
+			return nil, a.err
+		}
+		conn = a.conn
+	case <-tapCtx.Done():
+		return nil, fmt.Errorf("backend never connected to the tap: %w", tapCtx.Err())
+	}
+	defer conn.Close()
+
+	return readFirstFlight(conn, 2*time.Second)
+}
+
+// startTapBackend starts a backend for a copy of the node pointed at the
+// local tap port and sends a request through it so that it dials out
+func (tr *TestRunner) startTapBackend(ctx context.Context, protocol *models.Protocol, port int) (*ProxyManager, error) {
+	tapped := protocol.Clone()
+	tapped.Server = "127.0.0.1"
+	tapped.Port = port
+	// Keep the original hostname for TLS so the hello is the real one
+	if tapped.SNI == "" && net.ParseIP(protocol.Server) == nil {
+		tapped.SNI = protocol.Server
+	}
+
+	proxyMgr := tr.newProxyManager(tapped)
+	if err := proxyMgr.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start tap backend: %w", err)
+	}
+
+	client, err := proxyMgr.GetHTTPClient(5 * time.Second)
+	if err != nil {
+		proxyMgr.Stop()
+		return nil, err
+	}
+
+	// The request only makes the backend dial the tap; it never succeeds
+	go func() {
+		resp, err := client.Get("http://www.gstatic.com/generate_204")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	return proxyMgr, nil
+}
+
+// readFirstFlight reads until a complete TLS record arrived, the peer went
+// quiet for idle, or maxTapCapture bytes were read
+func readFirstFlight(conn net.Conn, idle time.Duration) ([]byte, error) {
+	var data []byte
+	buf := make([]byte, 4096)
+	for len(data) < maxTapCapture && !tlsRecordComplete(data) {
+		conn.SetReadDeadline(time.Now().Add(idle))
+		n, err := conn.Read(buf)
+		data = append(data, buf[:n]...)
+		if err != nil {
+			// Going quiet or closing after some bytes ends the first flight
+			if len(data) > 0 {
+				break
+			}
+			return nil, fmt.Errorf("backend sent nothing: %w", err)
+		}
+	}
+	if len(data) > maxTapCapture {
+		data = data[:maxTapCapture]
+	}
+	return data, nil
+}
+
+// tlsRecordComplete reports whether data starts with a whole TLS handshake
+// record
+func tlsRecordComplete(data []byte) bool {
+	if len(data) < 5 || data[0] != 0x16 {
+		return false
+	}
+	return len(data) >= 5+int(binary.BigEndian.Uint16(data[3:5]))
+}
+
+// usesTCPTLS reports whether the backend speaks TLS to the node over TCP
+func usesTCPTLS(protocol *models.Protocol) bool {
+	return protocol.TLS && !isUDPProtocol(protocol.Type)
+}
+
+// observeTLSFingerprint records the ClientHello the backend sends to a TLS
+// node. A fingerprint echo service reached through the tunnel would only
+// see our own inner TLS client, so the hello is taken from a local tap.
+func (tr *TestRunner) observeTLSFingerprint(ctx context.Context, protocol *models.Protocol) *models.TLSFingerprintResult {
+	result := &models.TLSFingerprintResult{Requested: requestedFingerprint(protocol)}
+
+	data, err := tr.captureFirstFlight(ctx, protocol)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	hello, err := checks.ParseClientHello(data)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Profile = hello.Profile()
+	result.JA3 = hello.JA3()
+	result.JA3Hash = hello.JA3Hash()
+	result.JA3NHash = hello.JA3NHash()
+	result.ServerName = hello.ServerName
+	result.ALPN = hello.ALPN
+	result.Mismatch = result.Requested != "" && result.Profile == checks.ProfileGo
+	return result
+}
+
+// requestedFingerprint returns the uTLS fingerprint set in the node link
+func requestedFingerprint(protocol *models.Protocol) string {
+	for _, key := range []string{"fp", "fingerprint"} {
+		if fp, ok := protocol.Extra[key].(string); ok && fp != "" {
+			return fp
+		}
+	}
+	return ""
+}
+
+// obfuscationMethod returns the obfuscation a node is configured with, or
+// "" for nodes without one
+func obfuscationMethod(protocol *models.Protocol) string {
+	if security, _ := protocol.Extra["security"].(string); security == "reality" && !isUDPProtocol(protocol.Type) {
+		return checks.ObfsReality
+	}
+	if obfs, _ := protocol.Extra["obfs"].(string); obfs != "" && protocol.Type == models.ProtocolHysteria2 {
+		return obfs
+	}
+	return ""
+}
+
+// checkObfuscation captures what an obfuscated node sends on the wire and
+// checks that it doesn't give away the proxy protocol
+func (tr *TestRunner) checkObfuscation(ctx context.Context, protocol *models.Protocol, method string) *models.ObfuscationResult {
+	data, err := tr.captureFirstFlight(ctx, protocol)
+	if err != nil {
+		return &models.ObfuscationResult{Method: method, Error: err.Error()}
+	}
+	return checks.CheckObfuscation(method, protocol.SNI, data)
+}
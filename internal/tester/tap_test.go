@@ -0,0 +1,39 @@
+package tester
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReadFirstFlight(t *testing.T) {
+	// A TLS record split over two writes is read whole
+	record := []byte{0x16, 0x03, 0x01, 0x00, 0x04, 1, 2, 3, 4}
+	client, server := net.Pipe()
+	go func() {
+		client.Write(record[:3])
+		client.Write(record[3:])
+	}()
+
+	data, err := readFirstFlight(server, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != len(record) {
+		t.Fatalf("read %d bytes, want %d", len(data), len(record))
+	}
+	server.Close()
+
+	// Anything else is read until the client goes quiet
+	client, server = net.Pipe()
+	defer server.Close()
+	go client.Write([]byte("GET / HTTP/1.1\r\n"))
+
+	data, err = readFirstFlight(server, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "GET / HTTP/1.1\r\n" {
+		t.Fatalf("read %q", data)
+	}
+}
@@ -124,7 +124,7 @@ func (pm *ProxyManager) generateStreamSettings() map[string]interface{} {
 	// Add TLS settings if enabled
 	if pm.protocol.TLS {
 		tlsSettings := map[string]interface{}{
-			"allowInsecure": false,
+			"allowInsecure": pm.allowInsecure(),
 		}
 
 		if pm.protocol.SNI != "" {
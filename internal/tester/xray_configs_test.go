@@ -0,0 +1,32 @@
+package tester
+
+import (
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestXrayConfigMixedInbound(t *testing.T) {
+	pm := NewProxyManager(&models.Protocol{
+		Type:     models.ProtocolTrojan,
+		Server:   "example.com",
+		Port:     443,
+		Password: "secret",
+		TLS:      true,
+	}, 10808)
+	pm.SetMixedInbound(true)
+	pm.httpPort = 10809
+
+	config, err := pm.generateXrayConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inbounds := config["inbounds"].([]map[string]interface{})
+	if len(inbounds) != 2 || inbounds[1]["protocol"] != "http" || inbounds[1]["port"] != 10809 {
+		t.Fatalf("inbounds = %v, want socks and http", inbounds)
+	}
+	if got := pm.HTTPAddress(); got != "127.0.0.1:10809" {
+		t.Errorf("HTTPAddress = %s, want 127.0.0.1:10809", got)
+	}
+}
@@ -0,0 +1,81 @@
+package tester
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/checks"
+)
+
+// xrayOutboundTag tags the node's outbound so its stats can be found
+const xrayOutboundTag = "proxy"
+
+// xrayStatsConfig enables per-outbound traffic counters and exposes them
+// through xray's metrics server, which serves expvar JSON over plain HTTP
+// (no gRPC client needed, unlike the stats API)
+func xrayStatsConfig(config map[string]interface{}, metricsPort int) {
+	config["stats"] = map[string]interface{}{}
+	config["policy"] = map[string]interface{}{
+		"system": map[string]interface{}{
+			"statsOutboundUplink":   true,
+			"statsOutboundDownlink": true,
+		},
+	}
+	config["metrics"] = map[string]interface{}{
+		"tag":    "metrics",
+		"listen": fmt.Sprintf("127.0.0.1:%d", metricsPort),
+	}
+}
+
+// TrafficCounter returns a reader for the backend's traffic counters of
+// the node outbound, or nil when the backend doesn't expose them
+func (pm *ProxyManager) TrafficCounter() checks.TrafficCounter {
+	if pm.backend != BackendXray || pm.metricsPort == 0 || !pm.isRunning {
+		return nil
+	}
+	return func(ctx context.Context) (int64, int64, error) {
+		return readXrayTraffic(ctx, pm.metricsPort, xrayOutboundTag)
+	}
+}
+
+// readXrayTraffic reads the uplink/downlink counters of an outbound from
+// xray's metrics server. Counters only appear after the first traffic, so
+// missing counters read as zero.
+func readXrayTraffic(ctx context.Context, metricsPort int, tag string) (int64, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/debug/vars", metricsPort)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("xray metrics: unexpected status: %d", resp.StatusCode)
+	}
+
+	var vars struct {
+		Stats *struct {
+			Outbound map[string]map[string]int64 `json:"outbound"`
+		} `json:"stats"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&vars); err != nil {
+		return 0, 0, fmt.Errorf("xray metrics: invalid response: %w", err)
+	}
+	if vars.Stats == nil {
+		return 0, 0, fmt.Errorf("xray metrics: stats are not enabled")
+	}
+
+	counters := vars.Stats.Outbound[tag]
+	return counters["uplink"], counters["downlink"], nil
+}
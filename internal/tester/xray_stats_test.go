@@ -0,0 +1,73 @@
+package tester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+func TestReadXrayTraffic(t *testing.T) {
+	// Shape of xray's /debug/vars with outbound stats enabled
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/debug/vars" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"cmdline": ["xray"], "stats": {
+			"inbound": {},
+			"outbound": {"proxy": {"uplink": 2048, "downlink": 10485760}},
+			"user": {}
+		}}`))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	up, down, err := readXrayTraffic(context.Background(), port, xrayOutboundTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up != 2048 || down != 10485760 {
+		t.Errorf("traffic = %d/%d, want 2048/10485760", up, down)
+	}
+
+	// No traffic through an outbound yet: no counters, reads as zero
+	up, down, err = readXrayTraffic(context.Background(), port, "other")
+	if err != nil || up != 0 || down != 0 {
+		t.Errorf("missing outbound = %d/%d, %v", up, down, err)
+	}
+}
+
+func TestXrayConfigEnablesStats(t *testing.T) {
+	pm := NewProxyManager(&models.Protocol{
+		Type:     models.ProtocolTrojan,
+		Server:   "example.com",
+		Port:     443,
+		Password: "secret",
+		TLS:      true,
+	}, 10808)
+	pm.metricsPort = 11111
+
+	config, err := pm.generateXrayConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outbounds := config["outbounds"].([]map[string]interface{})
+	if outbounds[0]["tag"] != xrayOutboundTag {
+		t.Errorf("outbound tag = %v", outbounds[0]["tag"])
+	}
+	metrics, ok := config["metrics"].(map[string]interface{})
+	if !ok || metrics["listen"] != "127.0.0.1:11111" {
+		t.Errorf("metrics = %v", config["metrics"])
+	}
+	if _, ok := config["stats"]; !ok {
+		t.Error("stats not enabled")
+	}
+}
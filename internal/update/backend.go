@@ -0,0 +1,272 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// BackendRepos maps each backend binary to the GitHub repository it is
+// released from
+var BackendRepos = map[string]string{
+	"sing-box": "SagerNet/sing-box",
+	"xray":     "XTLS/Xray-core",
+}
+
+// BackendAssetName returns the release asset holding a backend for a
+// platform. tag is the release, which sing-box asset names include.
+func BackendAssetName(backend, tag string, p Platform) (string, error) {
+	switch backend {
+	case "sing-box":
+		arch, err := singboxArch(p)
+		if err != nil {
+			return "", err
+		}
+		ext := "tar.gz"
+		if p.OS == "windows" {
+			ext = "zip"
+		}
+		return fmt.Sprintf("sing-box-%s-%s-%s.%s", strings.TrimPrefix(tag, "v"), p.OS, arch, ext), nil
+	case "xray":
+		arch, err := xrayArch(p)
+		if err != nil {
+			return "", err
+		}
+		goos := p.OS
+		if goos == "darwin" {
+			goos = "macos"
+		}
+		return fmt.Sprintf("Xray-%s-%s.zip", goos, arch), nil
+	}
+	return "", fmt.Errorf("unknown backend %q", backend)
+}
+
+// singboxArch names an architecture the way sing-box releases do. sing-box
+// builds big-endian 32-bit MIPS as softfloat only, which also runs on
+// CPUs with an FPU.
+func singboxArch(p Platform) (string, error) {
+	switch p.Arch {
+	case "arm":
+		if p.ARM < 5 || p.ARM > 7 {
+			return "", fmt.Errorf("unsupported ARM version %d", p.ARM)
+		}
+		return fmt.Sprintf("armv%d", p.ARM), nil
+	case "mips":
+		return "mips-softfloat", nil
+	case "mipsle":
+		return "mipsle-" + p.MIPSFloat, nil
+	}
+	return p.Arch, nil
+}
+
+// xrayArch names an architecture the way Xray releases do. Xray builds
+// 32-bit MIPS as softfloat only.
+func xrayArch(p Platform) (string, error) {
+	switch p.Arch {
+	case "amd64":
+		return "64", nil
+	case "386":
+		return "32", nil
+	case "arm64":
+		return "arm64-v8a", nil
+	case "arm":
+		switch p.ARM {
+		case 5, 6:
+			return fmt.Sprintf("arm32-v%d", p.ARM), nil
+		case 7:
+			return "arm32-v7a", nil
+		}
+		return "", fmt.Errorf("unsupported ARM version %d", p.ARM)
+	case "mips", "mipsle":
+		return strings.Replace(p.Arch, "mips", "mips32", 1), nil
+	}
+	return p.Arch, nil
+}
+
+// DownloadBackend fetches a backend release for a platform and returns the
+// backend binary from its archive. The archive is checked against its
+// .dgst digest, as Xray publishes, the release's checksums.txt, or else
+// the SHA-256 GitHub keeps for every asset; verified reports whether any
+// of them was there to check against.
+func (u *Updater) DownloadBackend(ctx context.Context, release *Release, backend string, p Platform) (binary []byte, verified bool, err error) {
+	name, err := BackendAssetName(backend, release.Tag, p)
+	if err != nil {
+		return nil, false, err
+	}
+	archiveAsset, ok := release.asset(name)
+	if !ok {
+		return nil, false, fmt.Errorf("%s %s has no build for %s (%s)", backend, release.Tag, p, name)
+	}
+
+	want, err := u.backendChecksum(ctx, release, archiveAsset)
+	if err != nil {
+		return nil, false, err
+	}
+
+	archive, err := u.get(ctx, archiveAsset.URL, maxBinarySize)
+	if err != nil {
+		return nil, false, fmt.Errorf("downloading %s: %w", name, err)
+	}
+
+	if want != "" {
+		sum := sha256.Sum256(archive)
+		if got := hex.EncodeToString(sum[:]); got != want {
+			return nil, false, fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+		}
+		verified = true
+	}
+
+	binaryName := backend
+	if p.OS == "windows" {
+		binaryName += ".exe"
+	}
+	if strings.HasSuffix(name, ".zip") {
+		binary, err = extractZip(archive, binaryName)
+	} else {
+		binary, err = extractTarGz(archive, binaryName)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", name, err)
+	}
+	return binary, verified, nil
+}
+
+// backendChecksum returns the SHA-256 a release publishes for an archive,
+// or "" if it publishes none
+func (u *Updater) backendChecksum(ctx context.Context, release *Release, archive Asset) (string, error) {
+	if digestAsset, ok := release.asset(archive.Name + ".dgst"); ok {
+		digest, err := u.get(ctx, digestAsset.URL, 4096)
+		if err != nil {
+			return "", fmt.Errorf("downloading %s.dgst: %w", archive.Name, err)
+		}
+		want, err := dgstSHA256(digest)
+		if err != nil {
+			return "", fmt.Errorf("%s.dgst: %w", archive.Name, err)
+		}
+		return want, nil
+	}
+
+	if sumsAsset, ok := release.asset(checksumsAsset); ok {
+		sums, err := u.get(ctx, sumsAsset.URL, 1<<20)
+		if err != nil {
+			return "", fmt.Errorf("downloading %s: %w", checksumsAsset, err)
+		}
+		return checksumFor(sums, archive.Name)
+	}
+
+	if sum, ok := strings.CutPrefix(archive.Digest, "sha256:"); ok {
+		sum = strings.ToLower(sum)
+		if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+			return "", fmt.Errorf("invalid digest for %s", archive.Name)
+		}
+		return sum, nil
+	}
+	return "", nil
+}
+
+// dgstSHA256 finds the SHA-256 in an Xray .dgst file, whose lines look
+// like "SHA2-256= <hex>"
+func dgstSHA256(digest []byte) (string, error) {
+	for _, line := range strings.Split(string(digest), "\n") {
+		if sum, ok := strings.CutPrefix(strings.TrimSpace(line), "SHA2-256="); ok {
+			sum = strings.ToLower(strings.TrimSpace(sum))
+			if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+				return "", fmt.Errorf("invalid SHA2-256 digest")
+			}
+			return sum, nil
+		}
+	}
+	return "", fmt.Errorf("no SHA2-256 digest")
+}
+
+// extractZip returns the file named binaryName from a zip archive, at any
+// depth
+func extractZip(archive []byte, binaryName string) ([]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range reader.File {
+		if path.Base(file.Name) != binaryName || file.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return readLimited(rc)
+	}
+	return nil, fmt.Errorf("no %s in the archive", binaryName)
+}
+
+// extractTarGz returns the file named binaryName from a .tar.gz archive,
+// at any depth
+func extractTarGz(archive []byte, binaryName string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("no %s in the archive", binaryName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binaryName {
+			return readLimited(reader)
+		}
+	}
+}
+
+// readLimited reads an extracted file, refusing ones over maxBinarySize
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBinarySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBinarySize {
+		return nil, fmt.Errorf("binary larger than %d bytes", maxBinarySize)
+	}
+	return data, nil
+}
+
+// Install writes an executable to path, replacing any binary already
+// there
+func Install(path string, binary []byte) error {
+	if _, err := os.Stat(path); err == nil {
+		return Replace(path, binary)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".protoscope-install-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s: %w", filepath.Dir(path), err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
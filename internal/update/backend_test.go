@@ -0,0 +1,126 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBackendAssetName(t *testing.T) {
+	tests := []struct {
+		backend  string
+		platform Platform
+		want     string
+	}{
+		{"sing-box", Platform{OS: "linux", Arch: "amd64"}, "sing-box-1.10.1-linux-amd64.tar.gz"},
+		{"sing-box", Platform{OS: "linux", Arch: "arm", ARM: 7}, "sing-box-1.10.1-linux-armv7.tar.gz"},
+		{"sing-box", Platform{OS: "linux", Arch: "arm", ARM: 5}, "sing-box-1.10.1-linux-armv5.tar.gz"},
+		{"sing-box", Platform{OS: "linux", Arch: "mipsle", MIPSFloat: "softfloat"}, "sing-box-1.10.1-linux-mipsle-softfloat.tar.gz"},
+		{"sing-box", Platform{OS: "linux", Arch: "mipsle", MIPSFloat: "hardfloat"}, "sing-box-1.10.1-linux-mipsle-hardfloat.tar.gz"},
+		{"sing-box", Platform{OS: "linux", Arch: "mips", MIPSFloat: "hardfloat"}, "sing-box-1.10.1-linux-mips-softfloat.tar.gz"},
+		{"sing-box", Platform{OS: "windows", Arch: "amd64"}, "sing-box-1.10.1-windows-amd64.zip"},
+		{"xray", Platform{OS: "linux", Arch: "amd64"}, "Xray-linux-64.zip"},
+		{"xray", Platform{OS: "linux", Arch: "arm", ARM: 7}, "Xray-linux-arm32-v7a.zip"},
+		{"xray", Platform{OS: "linux", Arch: "arm", ARM: 6}, "Xray-linux-arm32-v6.zip"},
+		{"xray", Platform{OS: "linux", Arch: "mipsle", MIPSFloat: "softfloat"}, "Xray-linux-mips32le.zip"},
+		{"xray", Platform{OS: "darwin", Arch: "arm64"}, "Xray-macos-arm64-v8a.zip"},
+	}
+	for _, tt := range tests {
+		got, err := BackendAssetName(tt.backend, "v1.10.1", tt.platform)
+		if err != nil || got != tt.want {
+			t.Errorf("%s for %s = %q, %v; want %q", tt.backend, tt.platform, got, err, tt.want)
+		}
+	}
+	if _, err := BackendAssetName("sing-box", "v1.10.1", Platform{OS: "linux", Arch: "arm", ARM: 4}); err == nil {
+		t.Error("accepted ARMv4")
+	}
+}
+
+func TestParseGOARM(t *testing.T) {
+	for value, want := range map[string]int{"7": 7, "6": 6, "7,softfloat": 7, "8": 0, "": 0} {
+		got, ok := parseGOARM(value)
+		if !ok {
+			got = 0
+		}
+		if got != want {
+			t.Errorf("parseGOARM(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestDownloadBackend(t *testing.T) {
+	binary := []byte("\x7fELF fake sing-box")
+	var tarball bytes.Buffer
+	gz := gzip.NewWriter(&tarball)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "sing-box-1.10.1-linux-armv7/LICENSE", Typeflag: tar.TypeReg, Size: 3, Mode: 0644})
+	tw.Write([]byte("MIT"))
+	tw.WriteHeader(&tar.Header{Name: "sing-box-1.10.1-linux-armv7/sing-box", Typeflag: tar.TypeReg, Size: int64(len(binary)), Mode: 0755})
+	tw.Write(binary)
+	tw.Close()
+	gz.Close()
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, _ := zw.Create("xray")
+	w.Write(binary)
+	zw.Close()
+	sum := sha256.Sum256(zipped.Bytes())
+	dgst := fmt.Sprintf("MD5= 00\nSHA2-256= %x\n", sum)
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	files := map[string][]byte{
+		"sing-box-1.10.1-linux-armv7.tar.gz": tarball.Bytes(),
+		"Xray-linux-arm32-v7a.zip":           zipped.Bytes(),
+		"Xray-linux-arm32-v7a.zip.dgst":      []byte(dgst),
+	}
+	release := &Release{Tag: "v1.10.1"}
+	for name := range files {
+		release.Assets = append(release.Assets, Asset{Name: name, URL: srv.URL + "/" + name})
+	}
+	mux.HandleFunc("/{name}", func(w http.ResponseWriter, r *http.Request) { w.Write(files[r.PathValue("name")]) })
+
+	updater := New("owner/name")
+	armv7 := Platform{OS: "linux", Arch: "arm", ARM: 7}
+	got, verified, err := updater.DownloadBackend(context.Background(), release, "sing-box", armv7)
+	if err != nil || verified || !bytes.Equal(got, binary) {
+		t.Errorf("sing-box: %q, verified %v, %v", got, verified, err)
+	}
+	got, verified, err = updater.DownloadBackend(context.Background(), release, "xray", armv7)
+	if err != nil || !verified || !bytes.Equal(got, binary) {
+		t.Errorf("xray: %q, verified %v, %v", got, verified, err)
+	}
+
+	// sing-box publishes no digests, but GitHub records one for each asset
+	tarSum := sha256.Sum256(tarball.Bytes())
+	for i, asset := range release.Assets {
+		if asset.Name == "sing-box-1.10.1-linux-armv7.tar.gz" {
+			release.Assets[i].Digest = fmt.Sprintf("sha256:%x", tarSum)
+		}
+	}
+	if _, verified, err := updater.DownloadBackend(context.Background(), release, "sing-box", armv7); err != nil || !verified {
+		t.Errorf("sing-box with a GitHub digest: verified %v, %v", verified, err)
+	}
+	files["checksums.txt"] = []byte(fmt.Sprintf("%064x  sing-box-1.10.1-linux-armv7.tar.gz\n", 0))
+	release.Assets = append(release.Assets, Asset{Name: "checksums.txt", URL: srv.URL + "/checksums.txt"})
+	if _, _, err := updater.DownloadBackend(context.Background(), release, "sing-box", armv7); err == nil {
+		t.Error("accepted an archive not matching the release checksums")
+	}
+
+	files["Xray-linux-arm32-v7a.zip.dgst"] = []byte("SHA2-256= " + fmt.Sprintf("%064x", 0) + "\n")
+	if _, _, err := updater.DownloadBackend(context.Background(), release, "xray", armv7); err == nil {
+		t.Error("accepted an archive not matching its digest")
+	}
+	if _, _, err := updater.DownloadBackend(context.Background(), release, "sing-box", Platform{OS: "linux", Arch: "mips64"}); err == nil {
+		t.Error("downloaded a build the release doesn't have")
+	}
+}
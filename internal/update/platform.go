@@ -0,0 +1,71 @@
+package update
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Platform is what a binary must be built for. GOOS and GOARCH are not
+// enough on the ARM and MIPS SoCs of routers and NASes: ARM binaries come
+// per architecture version, and MIPS ones per floating-point ABI, since
+// most router SoCs have no FPU.
+type Platform struct {
+	OS   string // GOOS
+	Arch string // GOARCH
+	// ARM is the ARM architecture version (GOARM: 5, 6 or 7), for arm
+	ARM int
+	// MIPSFloat is "hardfloat" or "softfloat" (GOMIPS), for mips and mipsle
+	MIPSFloat string
+}
+
+// Go's defaults when GOARM or GOMIPS is not set
+const (
+	defaultARM       = 7
+	defaultMIPSFloat = "hardfloat"
+)
+
+// CurrentPlatform returns the platform protoscope itself was built for,
+// which the device running it supports. The ARM version and float ABI are
+// read from the build settings.
+func CurrentPlatform() Platform {
+	p := Platform{OS: runtime.GOOS, Arch: runtime.GOARCH, ARM: defaultARM, MIPSFloat: defaultMIPSFloat}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return p
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "GOARM":
+			if arm, ok := parseGOARM(setting.Value); ok {
+				p.ARM = arm
+			}
+		case "GOMIPS":
+			if setting.Value == "softfloat" || setting.Value == "hardfloat" {
+				p.MIPSFloat = setting.Value
+			}
+		}
+	}
+	return p
+}
+
+// parseGOARM parses a GOARM value, which may carry a float ABI as in
+// "7,softfloat"
+func parseGOARM(value string) (int, bool) {
+	version, _, _ := strings.Cut(value, ",")
+	arm, err := strconv.Atoi(version)
+	return arm, err == nil && arm >= 5 && arm <= 7
+}
+
+// String returns e.g. linux/arm/v7 or linux/mipsle/softfloat
+func (p Platform) String() string {
+	switch p.Arch {
+	case "arm":
+		return fmt.Sprintf("%s/%s/v%d", p.OS, p.Arch, p.ARM)
+	case "mips", "mipsle":
+		return fmt.Sprintf("%s/%s/%s", p.OS, p.Arch, p.MIPSFloat)
+	}
+	return p.OS + "/" + p.Arch
+}
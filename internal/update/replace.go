@@ -0,0 +1,52 @@
+package update
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Replace swaps the binary at path for a new one. The new binary is written
+// next to it first, so a failure leaves the old binary in place. The old one
+// is moved aside rather than overwritten, which Windows requires for a
+// running executable.
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".protoscope-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write next to %s: %w", path, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0111); err != nil {
+		return err
+	}
+
+	old := path + ".old"
+	os.Remove(old)
+	if err := os.Rename(path, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Rename(old, path)
+		return err
+	}
+
+	// Fails on Windows while the old binary is still running; it is
+	// removed by the next update instead
+	os.Remove(old)
+	return nil
+}
@@ -0,0 +1,288 @@
+// Package update finds newer protoscope releases on GitHub, verifies the
+// downloaded binary against the release's checksums (and their signature
+// when a public key is configured) and replaces the running binary. It
+// also downloads the xray and sing-box backends built for this platform.
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Release channels
+const (
+	ChannelStable  = "stable"  // the newest non-prerelease
+	ChannelNightly = "nightly" // the newest release of any kind
+)
+
+// Release asset names
+const (
+	checksumsAsset = "checksums.txt"
+	signatureAsset = "checksums.txt.sig"
+)
+
+// maxBinarySize caps downloads so a bad asset can't fill the disk
+const maxBinarySize = 200 << 20
+
+// Release is a GitHub release
+type Release struct {
+	Tag        string    `json:"tag_name"`
+	Prerelease bool      `json:"prerelease"`
+	Draft      bool      `json:"draft"`
+	Published  time.Time `json:"published_at"`
+	Assets     []Asset   `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	// Digest is the "sha256:<hex>" GitHub computed on upload
+	Digest string `json:"digest"`
+}
+
+// asset returns the asset with the given name
+func (r *Release) asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Updater talks to the GitHub releases API of one repository
+type Updater struct {
+	apiURL    string // e.g. https://api.github.com/repos/owner/name
+	client    *http.Client
+	publicKey ed25519.PublicKey
+}
+
+// New creates an updater for a GitHub repository ("owner/name")
+func New(repo string) *Updater {
+	return &Updater{
+		apiURL: "https://api.github.com/repos/" + repo,
+		client: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// SetAPIURL points the updater at another releases API, e.g. a mirror
+func (u *Updater) SetAPIURL(apiURL string) {
+	u.apiURL = strings.TrimRight(apiURL, "/")
+}
+
+// SetPublicKey requires checksums.txt to carry a valid ed25519 signature by
+// this base64-encoded key
+func (u *Updater) SetPublicKey(key string) error {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key: %d bytes, want %d", len(raw), ed25519.PublicKeySize)
+	}
+	u.publicKey = raw
+	return nil
+}
+
+// Signed reports whether downloads are checked against a signature
+func (u *Updater) Signed() bool {
+	return u.publicKey != nil
+}
+
+// AssetName is the release asset holding the binary for this platform
+func AssetName() string {
+	name := fmt.Sprintf("protoscope-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Latest returns the newest published release of a channel
+func (u *Updater) Latest(ctx context.Context, channel string) (*Release, error) {
+	if channel != ChannelStable && channel != ChannelNightly {
+		return nil, fmt.Errorf("unknown channel %q (want %s or %s)", channel, ChannelStable, ChannelNightly)
+	}
+
+	body, err := u.get(ctx, u.apiURL+"/releases?per_page=30", 10<<20)
+	if err != nil {
+		return nil, fmt.Errorf("listing releases: %w", err)
+	}
+	var releases []*Release
+	if err := json.Unmarshal(body, &releases); err != nil {
+		return nil, fmt.Errorf("invalid releases response: %w", err)
+	}
+
+	var candidates []*Release
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel == ChannelStable) {
+			continue
+		}
+		candidates = append(candidates, r)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no %s releases found", channel)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Published.After(candidates[j].Published)
+	})
+	return candidates[0], nil
+}
+
+// Download fetches this platform's binary from a release and verifies it
+// against the release checksums, and their signature when a public key is
+// set
+func (u *Updater) Download(ctx context.Context, release *Release) ([]byte, error) {
+	name := AssetName()
+	binaryAsset, ok := release.asset(name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no binary for this platform (%s)", release.Tag, name)
+	}
+	sumsAsset, ok := release.asset(checksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s, refusing to install an unverified binary", release.Tag, checksumsAsset)
+	}
+
+	sums, err := u.get(ctx, sumsAsset.URL, 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", checksumsAsset, err)
+	}
+
+	if u.publicKey != nil {
+		sigAsset, ok := release.asset(signatureAsset)
+		if !ok {
+			return nil, fmt.Errorf("release %s has no %s", release.Tag, signatureAsset)
+		}
+		sig, err := u.get(ctx, sigAsset.URL, 4096)
+		if err != nil {
+			return nil, fmt.Errorf("downloading %s: %w", signatureAsset, err)
+		}
+		if err := verifySignature(u.publicKey, sums, sig); err != nil {
+			return nil, err
+		}
+	}
+
+	want, err := checksumFor(sums, name)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := u.get(ctx, binaryAsset.URL, maxBinarySize)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", name, err)
+	}
+
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+
+	return binary, nil
+}
+
+// get fetches a URL, failing on non-200 answers and bodies over limit bytes
+func (u *Updater) get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json, application/octet-stream")
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("response larger than %d bytes", limit)
+	}
+	return body, nil
+}
+
+// checksumFor finds a file's SHA-256 in sha256sum output
+func checksumFor(sums []byte, name string) (string, error) {
+	for _, line := range strings.Split(string(sums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks binary mode with a leading '*'
+		if strings.TrimPrefix(fields[1], "*") == name {
+			sum := strings.ToLower(fields[0])
+			if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+				return "", fmt.Errorf("invalid checksum for %s", name)
+			}
+			return sum, nil
+		}
+	}
+	return "", fmt.Errorf("no checksum for %s in %s", name, checksumsAsset)
+}
+
+// verifySignature checks a base64 ed25519 signature of data
+func verifySignature(key ed25519.PublicKey, data, sig []byte) error {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", signatureAsset, err)
+	}
+	if !ed25519.Verify(key, data, raw) {
+		return fmt.Errorf("%s signature does not match the release key", checksumsAsset)
+	}
+	return nil
+}
+
+// Newer reports whether tag is a newer version than current. Versions are
+// compared numerically (v1.10.0 > v1.9.2); tags that don't parse, such as
+// nightly builds, count as newer whenever they differ from current.
+func Newer(tag, current string) bool {
+	a, okA := parseVersion(tag)
+	b, okB := parseVersion(current)
+	if !okA || !okB {
+		return tag != current
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] > b[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses vMAJOR.MINOR.PATCH
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	fields := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(fields) != 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
@@ -0,0 +1,136 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		tag, current string
+		want         bool
+	}{
+		{"v0.3.0", "v0.2.0", true},
+		{"v0.10.0", "v0.9.5", true},
+		{"v0.2.0", "v0.2.0", false},
+		{"v0.1.9", "v0.2.0", false},
+		{"nightly-20261018", "v0.2.0", true},
+		{"nightly-20261018", "nightly-20261018", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.tag, tt.current); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.tag, tt.current, got, tt.want)
+		}
+	}
+}
+
+// fakeGitHub serves a releases API with a stable and a newer nightly release
+func fakeGitHub(t *testing.T, binary []byte, priv ed25519.PrivateKey) *httptest.Server {
+	t.Helper()
+	sum := sha256.Sum256(binary)
+	sums := fmt.Sprintf("%s  %s\n%s  protoscope-other-os\n", hex.EncodeToString(sum[:]), AssetName(), hex.EncodeToString(make([]byte, 32)))
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(sums)))
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	assets := func(tag string) []Asset {
+		return []Asset{
+			{Name: AssetName(), URL: srv.URL + "/dl/" + tag + "/bin"},
+			{Name: checksumsAsset, URL: srv.URL + "/dl/" + tag + "/sums"},
+			{Name: signatureAsset, URL: srv.URL + "/dl/" + tag + "/sig"},
+		}
+	}
+	now := time.Now()
+	mux.HandleFunc("/releases", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]Release{
+			{Tag: "v0.3.0", Published: now.Add(-48 * time.Hour), Assets: assets("v0.3.0")},
+			{Tag: "nightly-1", Prerelease: true, Published: now, Assets: assets("nightly-1")},
+			{Tag: "v9.0.0", Draft: true, Published: now},
+		})
+	})
+	mux.HandleFunc("/dl/{tag}/bin", func(w http.ResponseWriter, r *http.Request) { w.Write(binary) })
+	mux.HandleFunc("/dl/{tag}/sums", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(sums)) })
+	mux.HandleFunc("/dl/{tag}/sig", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(sig)) })
+	return srv
+}
+
+func TestUpdate(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	binary := []byte("#!/bin/sh\necho new\n")
+	srv := fakeGitHub(t, binary, priv)
+	defer srv.Close()
+	ctx := context.Background()
+
+	u := New("owner/repo")
+	u.SetAPIURL(srv.URL)
+	if err := u.SetPublicKey(base64.StdEncoding.EncodeToString(pub)); err != nil {
+		t.Fatal(err)
+	}
+
+	stable, err := u.Latest(ctx, ChannelStable)
+	if err != nil || stable.Tag != "v0.3.0" {
+		t.Fatalf("stable = %v, %v; want v0.3.0", stable, err)
+	}
+	nightly, err := u.Latest(ctx, ChannelNightly)
+	if err != nil || nightly.Tag != "nightly-1" {
+		t.Fatalf("nightly = %v, %v; want nightly-1", nightly, err)
+	}
+
+	got, err := u.Download(ctx, stable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(binary) {
+		t.Errorf("downloaded %q", got)
+	}
+
+	// A different release key rejects the checksums
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	u.SetPublicKey(base64.StdEncoding.EncodeToString(otherPub))
+	if _, err := u.Download(ctx, stable); err == nil {
+		t.Error("expected a signature error with the wrong key")
+	}
+
+	// A tampered binary fails the checksum
+	release := *stable
+	release.Assets = append([]Asset{{Name: AssetName(), URL: srv.URL + "/dl/x/sums"}}, stable.Assets[1:]...)
+	u.publicKey = nil
+	if _, err := u.Download(ctx, &release); err == nil {
+		t.Error("expected a checksum error for a tampered binary")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "protoscope")
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("binary = %q, want new", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("binary not executable: %v", info.Mode())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("left %d files behind, want only the binary", len(entries))
+	}
+}
@@ -0,0 +1,162 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// S3Uploader PUTs objects into an S3-compatible bucket, signing requests
+// with AWS Signature Version 4
+type S3Uploader struct {
+	config models.S3Config
+	prefix string
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3Uploader creates a new S3 uploader
+func NewS3Uploader(config models.S3Config, prefix string) *S3Uploader {
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	return &S3Uploader{
+		config: config,
+		prefix: prefix,
+		client: &http.Client{Timeout: 60 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// Name implements Uploader
+func (u *S3Uploader) Name() string {
+	return "s3"
+}
+
+// Upload implements Uploader
+func (u *S3Uploader) Upload(ctx context.Context, name string, data []byte, contentType string) error {
+	objectURL, err := u.objectURL(strings.TrimPrefix(u.prefix+name, "/"))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", objectURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	payloadHash := sha256.Sum256(data)
+	signV4(req, hex.EncodeToString(payloadHash[:]), u.config.AccessKey, u.config.SecretKey, u.config.Region, u.now())
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+// objectURL returns the URL of an object in path or virtual-hosted style
+func (u *S3Uploader) objectURL(key string) (string, error) {
+	endpoint, err := url.Parse(u.config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return "", fmt.Errorf("invalid s3 endpoint: %q", u.config.Endpoint)
+	}
+
+	path := strings.TrimSuffix(endpoint.Path, "/")
+	if u.config.PathStyle {
+		path += "/" + u.config.Bucket
+	} else {
+		endpoint.Host = u.config.Bucket + "." + endpoint.Host
+	}
+	endpoint.Path = path + "/" + key
+	endpoint.RawPath = ""
+
+	return endpoint.String(), nil
+}
+
+// signV4 adds AWS Signature Version 4 headers to req. All headers already
+// set on req are signed along with Host.
+func signV4(req *http.Request, payloadHash, accessKey, secretKey, region string, now time.Time) {
+	const service = "s3"
+
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		headers[strings.ToLower(key)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by name as SigV4 requires
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, url.QueryEscape(key)+"="+strings.ReplaceAll(url.QueryEscape(value), "+", "%20"))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,44 @@
+// Package upload publishes reports and exported subscriptions to remote
+// storage so devices can fetch them from there
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// Uploader stores a file under the given name
+type Uploader interface {
+	// Name identifies the target in log messages
+	Name() string
+	Upload(ctx context.Context, name string, data []byte, contentType string) error
+}
+
+// FromConfig returns an uploader for every configured target
+func FromConfig(config models.UploadConfig) []Uploader {
+	var uploaders []Uploader
+	if config.S3.Bucket != "" {
+		uploaders = append(uploaders, NewS3Uploader(config.S3, config.Prefix))
+	}
+	if config.WebDAV.URL != "" {
+		uploaders = append(uploaders, NewWebDAVUploader(config.WebDAV, config.Prefix))
+	}
+	return uploaders
+}
+
+// checkResponse turns a non-2xx response into an error including the start
+// of the body, where servers explain what went wrong
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if len(body) > 0 {
+		return fmt.Errorf("unexpected status: %d: %s", resp.StatusCode, body)
+	}
+	return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+}
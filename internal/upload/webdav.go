@@ -0,0 +1,54 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// WebDAVUploader PUTs files into a WebDAV directory
+type WebDAVUploader struct {
+	config models.WebDAVConfig
+	prefix string
+	client *http.Client
+}
+
+// NewWebDAVUploader creates a new WebDAV uploader
+func NewWebDAVUploader(config models.WebDAVConfig, prefix string) *WebDAVUploader {
+	return &WebDAVUploader{
+		config: config,
+		prefix: prefix,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Name implements Uploader
+func (u *WebDAVUploader) Name() string {
+	return "webdav"
+}
+
+// Upload implements Uploader. The directory must already exist.
+func (u *WebDAVUploader) Upload(ctx context.Context, name string, data []byte, contentType string) error {
+	url := strings.TrimSuffix(u.config.URL, "/") + "/" + strings.TrimPrefix(u.prefix+name, "/")
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if u.config.Username != "" {
+		req.SetBasicAuth(u.config.Username, u.config.Password)
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
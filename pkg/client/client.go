@@ -0,0 +1,260 @@
+// Package client talks to the API of a ProtoScope server, started with
+// protoscope serve or protoscope daemon -listen: it starts runs, follows
+// their progress and fetches their results
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// RunRequest asks for a run; the zero value tests all of the server's nodes
+type RunRequest struct {
+	// URL is a subscription to fetch and test instead of the server's nodes
+	URL string `json:"url,omitempty"`
+	// Nodes limits the run to the nodes with these names or fingerprint
+	// prefixes
+	Nodes []string `json:"nodes,omitempty"`
+	// Priority orders queued runs, highest first
+	Priority int `json:"priority,omitempty"`
+}
+
+// Run is the state of a run
+type Run struct {
+	ID             string     `json:"id"`
+	Trigger        string     `json:"trigger"` // schedule or api
+	State          string     `json:"state"`   // queued, running, finished or failed
+	Priority       int        `json:"priority"`
+	RequestedNodes []string   `json:"requested_nodes,omitempty"`
+	Queued         time.Time  `json:"queued"`
+	Started        *time.Time `json:"started,omitempty"`
+	Finished       *time.Time `json:"finished,omitempty"`
+	Nodes          int        `json:"nodes"` // to test, known once started
+	Done           int        `json:"done"`
+	Working        int        `json:"working"`
+	Error          string     `json:"error,omitempty"`
+}
+
+// Ended reports whether the run has finished or failed
+func (r *Run) Ended() bool {
+	return r.State == "finished" || r.State == "failed"
+}
+
+// APIError is an error answer of the server
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("protoscope api: %s (status %d)", e.Message, e.StatusCode)
+}
+
+// IsQueueFull reports whether err is the server refusing a run because
+// its queue is full; retry later
+func IsQueueFull(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// Client is a ProtoScope API client
+type Client struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// New creates a client for the server at baseURL, e.g.
+// http://127.0.0.1:8080. token is an API token from the server's
+// server.tokens, if it has any; starting runs needs an admin token.
+func New(baseURL, token string) *Client {
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		// Event streams last as long as a run, so requests are bounded
+		// by their context only
+		client: &http.Client{},
+	}
+}
+
+// SetHTTPClient replaces the HTTP client, e.g. with one that trusts the
+// server's certificate or presents a client certificate
+func (c *Client) SetHTTPClient(client *http.Client) {
+	c.client = client
+}
+
+// StartRun requests a run. The run may be queued; a request for the same
+// nodes as a queued run returns that run.
+func (c *Client) StartRun(ctx context.Context, request RunRequest) (*Run, error) {
+	var run Run
+	if err := c.do(ctx, "POST", "/runs", request, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Run returns the state of a run
+func (c *Client) Run(ctx context.Context, id string) (*Run, error) {
+	var run Run
+	if err := c.do(ctx, "GET", "/runs/"+url.PathEscape(id), nil, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Runs returns the runs the server keeps, newest first
+func (c *Client) Runs(ctx context.Context) ([]Run, error) {
+	var body struct {
+		Runs []Run `json:"runs"`
+	}
+	if err := c.do(ctx, "GET", "/runs", nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Runs, nil
+}
+
+// Results returns the results of a finished run
+func (c *Client) Results(ctx context.Context, id string) ([]*models.TestResult, error) {
+	var results []*models.TestResult
+	if err := c.do(ctx, "GET", "/runs/"+url.PathEscape(id)+"/results", nil, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Wait polls a run every interval until it has ended and returns its final
+// state
+func (c *Client) Wait(ctx context.Context, id string, interval time.Duration) (*Run, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		run, err := c.Run(ctx, id)
+		if err != nil || run.Ended() {
+			return run, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Follow reads a run's event stream, calling progress, if not nil, for
+// each update until the run ends, and returns its final state
+func (c *Client) Follow(ctx context.Context, id string, progress func(models.TestProgress)) (*Run, error) {
+	resp, err := c.send(ctx, "GET", "/runs/"+url.PathEscape(id)+"/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	// Progress updates carry the node and can be long
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var event string
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:"))...)
+		case line == "":
+			switch event {
+			case "progress":
+				var update models.TestProgress
+				if err := json.Unmarshal(data, &update); err != nil {
+					return nil, fmt.Errorf("protoscope api: invalid event: %w", err)
+				}
+				if progress != nil {
+					progress(update)
+				}
+			case "done":
+				var run Run
+				if err := json.Unmarshal(data, &run); err != nil {
+					return nil, fmt.Errorf("protoscope api: invalid event: %w", err)
+				}
+				return &run, nil
+			}
+			event, data = "", nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("protoscope api: event stream ended before the run")
+}
+
+// do sends a request and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	resp, err := c.send(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 256*1024*1024)).Decode(out); err != nil {
+		return fmt.Errorf("protoscope api: invalid response: %w", err)
+	}
+	return nil
+}
+
+// send sends a request with in as its JSON body, if given, and returns the
+// response of a successful one
+func (c *Client) send(ctx context.Context, method, path string, in interface{}) (*http.Response, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		// The server reports errors as {"message": "..."}
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		var body struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(data, &body) == nil && body.Message != "" {
+			apiErr.Message = body.Message
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
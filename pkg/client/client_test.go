@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VenoMexx/ProtoScope/internal/server"
+	"github.com/VenoMexx/ProtoScope/pkg/models"
+)
+
+// TestClient drives a run on the real server handler, so the client's
+// types stay in step with the server's JSON
+func TestClient(t *testing.T) {
+	srv := server.New()
+	srv.SetTokens([]models.APIToken{
+		{Name: "ops", Token: "adm1n", Scope: models.ScopeAdmin},
+		{Name: "grafana", Token: "r3ad", Scope: models.ScopeRead},
+	})
+	release := make(chan struct{})
+	srv.SetRunHandler(func(run *server.Run) {
+		<-release
+		run.SetNodes(1)
+		protocol := &models.Protocol{Type: models.ProtocolTrojan, Name: run.Request().URL}
+		run.Progress(models.TestProgress{Protocol: protocol, Stage: "done", Percent: 100})
+		run.Finish([]*models.TestResult{{Protocol: protocol, Success: true}}, nil)
+	}, 1, 0)
+	// The event stream flushes its headers once it has subscribed
+	subscribed := make(chan struct{})
+	handler := srv.Handler()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/events") {
+			w = &flushSignal{ResponseWriter: w, flushed: subscribed}
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := New(ts.URL, "adm1n")
+
+	if _, err := New(ts.URL, "r3ad").StartRun(ctx, RunRequest{}); err == nil {
+		t.Error("read token started a run")
+	}
+
+	run, err := c.StartRun(ctx, RunRequest{URL: "https://provider.example/sub", Priority: 3})
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	if run.ID == "" || run.State != "running" || run.Priority != 3 {
+		t.Errorf("started run: %+v", run)
+	}
+	if _, err := c.StartRun(ctx, RunRequest{Nodes: []string{"DE-01"}}); !IsQueueFull(err) {
+		t.Errorf("second run with no queue: %v, want queue full", err)
+	}
+	if _, err := c.Results(ctx, run.ID); err == nil {
+		t.Error("results of a running run")
+	}
+
+	var stages []string
+	done := make(chan *Run)
+	go func() {
+		final, err := c.Follow(ctx, run.ID, func(p models.TestProgress) {
+			stages = append(stages, p.Protocol.Name+":"+p.Stage)
+		})
+		if err != nil {
+			t.Errorf("Follow: %v", err)
+		}
+		done <- final
+	}()
+	<-subscribed
+	close(release)
+
+	final := <-done
+	if final == nil || final.State != "finished" || final.Working != 1 || !final.Ended() {
+		t.Errorf("final state: %+v", final)
+	}
+	if len(stages) != 1 || stages[0] != "https://provider.example/sub:done" {
+		t.Errorf("progress: %v", stages)
+	}
+
+	if waited, err := c.Wait(ctx, run.ID, 10*time.Millisecond); err != nil || waited.State != "finished" {
+		t.Errorf("Wait: %+v, %v", waited, err)
+	}
+	results, err := c.Results(ctx, run.ID)
+	if err != nil || len(results) != 1 || !results[0].Success {
+		t.Errorf("Results: %v, %v", results, err)
+	}
+	runs, err := c.Runs(ctx)
+	if err != nil || len(runs) != 1 || runs[0].ID != run.ID {
+		t.Errorf("Runs: %+v, %v", runs, err)
+	}
+}
+
+// flushSignal closes flushed on the first Flush
+type flushSignal struct {
+	http.ResponseWriter
+	flushed chan struct{}
+	once    sync.Once
+}
+
+func (f *flushSignal) Flush() {
+	f.ResponseWriter.(http.Flusher).Flush()
+	f.once.Do(func() { close(f.flushed) })
+}
@@ -1,5 +1,7 @@
 package domains
 
+import "strings"
+
 // GeoDomainsRU contains Russian domains for testing
 var GeoDomainsRU = []string{
 	"vk.com",
@@ -47,6 +49,14 @@ var GeoDomainsUS = []string{
 	"netflix.com",
 }
 
+// GeoRegions are the built-in geo-access regions by country code
+var GeoRegions = map[string][]string{
+	"RU": GeoDomainsRU,
+	"CN": GeoDomainsCN,
+	"IR": GeoDomainsIR,
+	"US": GeoDomainsUS,
+}
+
 // BlockedInCN contains domains typically blocked in China
 var BlockedInCN = []string{
 	"google.com",
@@ -72,18 +82,10 @@ var BlockedInIR = []string{
 
 // GetGeoDomainsForCountry returns domains for a specific country
 func GetGeoDomainsForCountry(country string) []string {
-	switch country {
-	case "RU", "ru":
-		return GeoDomainsRU
-	case "CN", "cn":
-		return GeoDomainsCN
-	case "IR", "ir":
-		return GeoDomainsIR
-	case "US", "us":
-		return GeoDomainsUS
-	default:
-		return []string{}
+	if domains, ok := GeoRegions[strings.ToUpper(country)]; ok {
+		return domains
 	}
+	return []string{}
 }
 
 // GetBlockedDomainsForCountry returns typically blocked domains for a country
@@ -1,6 +1,14 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
 
 // Config represents the application configuration
 type Config struct {
@@ -8,6 +16,19 @@ type Config struct {
 	DomainLists   DomainLists   `yaml:"domain_lists" json:"domain_lists"`
 	APIEndpoints  APIEndpoints  `yaml:"api_endpoints" json:"api_endpoints"`
 	OutputConfig  OutputConfig  `yaml:"output_config" json:"output_config"`
+	Notify        NotifyConfig  `yaml:"notify" json:"notify"`
+	Upload        UploadConfig  `yaml:"upload" json:"upload"`
+	Signing       SigningConfig `yaml:"signing" json:"signing"`
+	// Subscriptions are re-fetched before every daemon run
+	Subscriptions []SubscriptionSource `yaml:"subscriptions" json:"subscriptions"`
+	// Plugins are external checks run against every working node
+	Plugins       []PluginConfig      `yaml:"plugins" json:"plugins"`
+	// Archive keeps the report and exports of every daemon run on disk
+	Archive       ArchiveConfig       `yaml:"archive" json:"archive"`
+	// Secrets decrypts the values written as secret:<alias>
+	Secrets       SecretsConfig       `yaml:"secrets" json:"secrets"`
+	// Server secures the daemon's -listen server
+	Server        ServerConfig        `yaml:"server" json:"server"`
 }
 
 // TestConfig contains test execution settings
@@ -19,6 +40,146 @@ type TestConfig struct {
 	EnableGeoTest   bool          `yaml:"enable_geo_test" json:"enable_geo_test"`
 	EnableDNSTest   bool          `yaml:"enable_dns_test" json:"enable_dns_test"`
 	EnablePrivacyTest bool        `yaml:"enable_privacy_test" json:"enable_privacy_test"`
+	EnablePing      bool          `yaml:"enable_ping" json:"enable_ping"`
+	PingCount       int           `yaml:"ping_count" json:"ping_count"`
+	TestAllIPs      bool          `yaml:"test_all_ips" json:"test_all_ips"`
+	EnableTrace     bool          `yaml:"enable_trace" json:"enable_trace"`
+	TraceMaxHops    int           `yaml:"trace_max_hops" json:"trace_max_hops"`
+	// EnableMTUProbe probes the path MTU of UDP-based nodes (hysteria2, tuic)
+	EnableMTUProbe  bool          `yaml:"enable_mtu_probe" json:"enable_mtu_probe"`
+	// EnableEgressCheck probes which outbound ports the exit blocks
+	EnableEgressCheck bool  `yaml:"enable_egress_check" json:"enable_egress_check"`
+	EgressPorts       []int `yaml:"egress_ports" json:"egress_ports"`
+	// EnableSensitiveCheck tests which SensitiveSites, banking and
+	// government portals usually kept off proxies, the exit can reach
+	// without fraud blocks
+	EnableSensitiveCheck bool            `yaml:"enable_sensitive_check" json:"enable_sensitive_check"`
+	SensitiveSites       []SensitiveSite `yaml:"sensitive_sites" json:"sensitive_sites"`
+	// EnableGamingTest samples latency and jitter to the GamingEndpoints,
+	// regional servers of online game services, to rate the node for games
+	EnableGamingTest bool             `yaml:"enable_gaming_test" json:"enable_gaming_test"`
+	GamingEndpoints  []GamingEndpoint `yaml:"gaming_endpoints" json:"gaming_endpoints"`
+	// EnableStreamingCheck probes which streaming and AI services (Netflix,
+	// Disney+, YouTube Premium, ChatGPT) the exit unlocks, and in which region
+	EnableStreamingCheck bool `yaml:"enable_streaming_check" json:"enable_streaming_check"`
+	// EnableOokla also runs the speedtest.net TCP test against a server
+	// near the exit
+	EnableOokla bool `yaml:"enable_ookla" json:"enable_ookla"`
+	// EnableEdgeTest downloads from the CDN edge near the exit and the one
+	// near the user, to separate the node's bandwidth from the path to it
+	EnableEdgeTest bool `yaml:"enable_edge_test" json:"enable_edge_test"`
+	// EnableTLSFingerprint records the ClientHello the backend sends to
+	// TLS nodes
+	EnableTLSFingerprint bool `yaml:"enable_tls_fingerprint" json:"enable_tls_fingerprint"`
+	// EnableObfuscationCheck verifies that REALITY and salamander nodes
+	// don't send recognisable proxy traffic
+	EnableObfuscationCheck bool `yaml:"enable_obfuscation_check" json:"enable_obfuscation_check"`
+	// EnableActiveProbing connects to node servers with invalid handshakes
+	// to rate their resistance to active probing
+	EnableActiveProbing bool `yaml:"enable_active_probing" json:"enable_active_probing"`
+	// BlockingWeights weigh the categories of the DNS blocking test in its
+	// effectiveness score
+	BlockingWeights BlockingWeights `yaml:"blocking_weights" json:"blocking_weights"`
+	// BlocklistSample domains from the top of each Blocklists endpoint are
+	// tested along with the built-in ones; 0 tests only the built-in ones
+	BlocklistSample int `yaml:"blocklist_sample" json:"blocklist_sample"`
+	// GeoExpect verifies geo-access pages by domain. Block pages often
+	// come with status 200, so a page without its keyword or smaller than
+	// its minimum size is reported as a block page instead of accessible.
+	GeoExpect map[string]GeoExpectation `yaml:"geo_expect" json:"geo_expect,omitempty"`
+	// GeoRegions are the domains of geo-access regions by name. They add
+	// to the built-in RU, CN, IR and US regions, replace the built-in
+	// region of the same name, or remove it when empty.
+	GeoRegions map[string][]string `yaml:"geo_regions" json:"geo_regions,omitempty"`
+	// JitterSamples requests are sent to one latency endpoint,
+	// JitterInterval apart, to measure jitter
+	JitterSamples  int           `yaml:"jitter_samples" json:"jitter_samples"`
+	JitterInterval time.Duration `yaml:"jitter_interval" json:"jitter_interval"`
+	// SlowNodeThreshold skips expensive checks (privacy, streaming) on nodes
+	// whose connectivity latency exceeds it; 0 disables skipping
+	SlowNodeThreshold time.Duration `yaml:"slow_node_threshold" json:"slow_node_threshold"`
+	// EndpointRateLimit caps requests per second to each public test
+	// endpoint host, shared by all workers; 0 disables limiting
+	EndpointRateLimit float64 `yaml:"endpoint_rate_limit" json:"endpoint_rate_limit"`
+	EndpointRateBurst int     `yaml:"endpoint_rate_burst" json:"endpoint_rate_burst"`
+	// Backend forces a proxy backend (xray, sing-box, mock); empty selects automatically
+	Backend string `yaml:"backend" json:"backend"`
+	// Limits of each xray/sing-box process: memory in MB, CPU in percent of
+	// one core (0 leaves either unlimited) and niceness
+	BackendMemoryMB   int `yaml:"backend_memory_mb" json:"backend_memory_mb"`
+	BackendCPUPercent int `yaml:"backend_cpu_percent" json:"backend_cpu_percent"`
+	BackendNice       int `yaml:"backend_nice" json:"backend_nice"`
+	// BackendSandbox confines each backend process: it can read only its
+	// binary, its config and system files, and dangerous syscalls fail.
+	// BackendUser runs it as another user and BackendAppArmorProfile under an
+	// AppArmor profile; both need root. Linux only.
+	BackendSandbox         bool   `yaml:"backend_sandbox" json:"backend_sandbox"`
+	BackendUser            string `yaml:"backend_user" json:"backend_user"`
+	BackendAppArmorProfile string `yaml:"backend_apparmor_profile" json:"backend_apparmor_profile"`
+	// BackendConfigStdin passes node configs to xray/sing-box on stdin
+	// instead of in a file; turn it off for backends too old to read stdin
+	BackendConfigStdin bool `yaml:"backend_config_stdin" json:"backend_config_stdin"`
+	// Country is the user's ISO country code used to pick regional
+	// endpoints; empty detects it from the real IP
+	Country string `yaml:"country" json:"country"`
+	// TimeoutScale multiplies Timeout per protocol type; QUIC-based protocols
+	// need a longer handshake budget on lossy links
+	TimeoutScale map[ProtocolType]float64 `yaml:"timeout_scale" json:"timeout_scale"`
+	// Metered caps the data speed tests use, for testing over mobile data:
+	// each node downloads at most MeteredNodeBytes, the whole run at most
+	// MeteredRunBytes, and sustained tests are skipped
+	Metered          bool  `yaml:"metered" json:"metered"`
+	MeteredNodeBytes int64 `yaml:"metered_node_bytes" json:"metered_node_bytes"`
+	MeteredRunBytes  int64 `yaml:"metered_run_bytes" json:"metered_run_bytes"`
+	// LowMemory fits runs into routers with 128-256 MB of RAM: one node at
+	// a time, small connection pools and a tighter garbage collector
+	LowMemory bool `yaml:"low_memory" json:"low_memory"`
+}
+
+// ScaleTimeout scales a timeout budget for the given protocol type
+func (tc *TestConfig) ScaleTimeout(protocolType ProtocolType, timeout time.Duration) time.Duration {
+	scale, ok := tc.TimeoutScale[protocolType]
+	if !ok || scale <= 0 {
+		return timeout
+	}
+	return time.Duration(float64(timeout) * scale)
+}
+
+// TimeoutFor returns the per-node test timeout for the given protocol type
+func (tc *TestConfig) TimeoutFor(protocolType ProtocolType) time.Duration {
+	return tc.ScaleTimeout(protocolType, tc.Timeout)
+}
+
+// BlockingWeights are the relative weights of the ad, tracker and malware
+// categories in the ad-blocking effectiveness score
+type BlockingWeights struct {
+	Ads      float64 `yaml:"ads" json:"ads"`
+	Tracking float64 `yaml:"tracking" json:"tracking"`
+	Malware  float64 `yaml:"malware" json:"malware"`
+}
+
+// GeoExpectation is what the real page of a geo-access domain contains
+type GeoExpectation struct {
+	// Keyword is text the page contains, matched case-insensitively
+	Keyword string `yaml:"keyword" json:"keyword,omitempty"`
+	// MinBodyBytes is the smallest size of the page
+	MinBodyBytes int `yaml:"min_body_bytes" json:"min_body_bytes,omitempty"`
+}
+
+// SensitiveSite is a banking or government site tested by the sensitive
+// sites check
+type SensitiveSite struct {
+	Name     string `yaml:"name" json:"name"`
+	URL      string `yaml:"url" json:"url"`
+	Category string `yaml:"category" json:"category,omitempty"` // e.g. banking, government
+}
+
+// GamingEndpoint is a regional server of a game service timed by the gaming
+// latency check. It should answer HEAD requests quickly, without a body.
+type GamingEndpoint struct {
+	Service string `yaml:"service" json:"service"`
+	Region  string `yaml:"region" json:"region,omitempty"` // e.g. EU West
+	URL     string `yaml:"url" json:"url"`
 }
 
 // DomainLists contains domain lists for testing
@@ -34,10 +195,73 @@ type DomainLists struct {
 
 // APIEndpoints contains external API endpoints
 type APIEndpoints struct {
+	// Connectivity endpoints are tried as fallbacks; any success counts
+	Connectivity []string `yaml:"connectivity" json:"connectivity"`
+	// ConnectivityByCountry lists endpoints tried first when the real IP is
+	// in the given country (ISO code), for regions where the defaults are censored
+	ConnectivityByCountry map[string][]string `yaml:"connectivity_by_country" json:"connectivity_by_country"`
+	// Latency endpoints are timed with HEAD requests and should answer
+	// with little or no body, such as generate_204
+	Latency      []string `yaml:"latency" json:"latency"`
 	IPCheck      []string `yaml:"ip_check" json:"ip_check"`
 	DNSLeak      []string `yaml:"dns_leak" json:"dns_leak"`
 	SpeedTest    []string `yaml:"speed_test" json:"speed_test"`
 	GeoLocation  []string `yaml:"geo_location" json:"geo_location"`
+	// HostingRanges are the published IP range feeds used to tell which
+	// cloud an exit IP belongs to; providers without a feed are matched by ASN
+	HostingRanges []HostingRangeSource `yaml:"hosting_ranges" json:"hosting_ranges"`
+	// ProxyLists are public Tor/VPN/proxy detection lists the exit IP is
+	// checked against
+	ProxyLists []ProxyListSource `yaml:"proxy_lists" json:"proxy_lists"`
+	// Blocklists are ad, tracker and malware domain lists sampled by the
+	// DNS blocking test when TestConfig.BlocklistSample is set
+	Blocklists []BlocklistSource `yaml:"blocklists" json:"blocklists"`
+	// EgressProbe is a host accepting TCP on every port, used to test
+	// which ports the exit lets out
+	EgressProbe string `yaml:"egress_probe" json:"egress_probe"`
+	// DNSCanary is the base URL of a protoscope echo-server with a DNS
+	// canary zone; when set, DNS leak detection asks it which resolvers the
+	// exit used instead of dnsleaktest.com
+	DNSCanary string `yaml:"dns_canary" json:"dns_canary"`
+	// NTP servers (host:port) are asked for the time to check the local
+	// clock; the Date header of the TimeHTTP endpoints is used where UDP is
+	// blocked
+	NTP      []string `yaml:"ntp" json:"ntp"`
+	TimeHTTP []string `yaml:"time_http" json:"time_http"`
+	// OoklaServers lists speedtest.net servers nearest the requesting IP
+	OoklaServers string `yaml:"ookla_servers" json:"ookla_servers"`
+	// CDNEdge is a download URL on a CDN with edges worldwide
+	CDNEdge string `yaml:"cdn_edge" json:"cdn_edge"`
+	// CDNEdgeNearUser is a download URL on a unicast server near the user,
+	// for the near-user download when CDNEdge is anycast, as Cloudflare is
+	CDNEdgeNearUser string `yaml:"cdn_edge_near_user" json:"cdn_edge_near_user"`
+}
+
+// ProxyListSource is a plain-text list of IPs or CIDR prefixes, one per
+// line. Category is tor, vpn, proxy or datacenter.
+type ProxyListSource struct {
+	Name     string `yaml:"name" json:"name"`
+	URL      string `yaml:"url" json:"url"`
+	Category string `yaml:"category" json:"category"`
+}
+
+// BlocklistSource is a domain blocklist in hosts format ("0.0.0.0 domain")
+// or with one domain per line. Lists aren't ranked by popularity, so the
+// domains sampled from the top are simply the first ones listed. Category
+// is ads, tracking or malware.
+type BlocklistSource struct {
+	Name     string `yaml:"name" json:"name"`
+	URL      string `yaml:"url" json:"url"`
+	Category string `yaml:"category" json:"category"`
+}
+
+// HostingRangeSource is a provider's IP range feed. Format is aws, gcp,
+// oracle, azure (Service Tags JSON) or cidr (one prefix per line, optionally
+// followed by a region).
+type HostingRangeSource struct {
+	Provider string `yaml:"provider" json:"provider"`
+	URL      string `yaml:"url" json:"url"`
+	Format   string `yaml:"format" json:"format"`
 }
 
 // OutputConfig contains output settings
@@ -49,6 +273,245 @@ type OutputConfig struct {
 	ShowFailed  bool   `yaml:"show_failed" json:"show_failed"`
 }
 
+// SubscriptionSource is a subscription fetched before each daemon run. URL
+// is a text/template so providers with rotating tokens can be followed:
+// {{.Token}} is the trimmed output of the PreFetch command, {{.Now}} the
+// current time (e.g. {{.Now.Format "20060102"}}) and {{env "NAME"}} reads
+// an environment variable.
+type SubscriptionSource struct {
+	Name string `yaml:"name" json:"name"`
+	URL  string `yaml:"url" json:"url"`
+	// PreFetch is a shell command run before each fetch, e.g. to refresh
+	// a token; its output is available to the URL template as {{.Token}}
+	PreFetch        string        `yaml:"pre_fetch" json:"pre_fetch"`
+	PreFetchTimeout time.Duration `yaml:"pre_fetch_timeout" json:"pre_fetch_timeout"`
+}
+
+// PluginConfig is an external check. Command runs through the system shell
+// with the node's protocol as JSON on stdin and PROTOSCOPE_SOCKS set to the
+// node's local SOCKS5 proxy, and prints its result as JSON, which is stored
+// under Name in the node's plugins section. Instead of Command, WASM names
+// a WebAssembly module run in a sandbox with the same input and output.
+type PluginConfig struct {
+	Name    string        `yaml:"name" json:"name"`
+	Command string        `yaml:"command" json:"command"`
+	WASM    string        `yaml:"wasm" json:"wasm"`
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+}
+
+// NotifyConfig contains report delivery settings for daemon mode
+type NotifyConfig struct {
+	Email EmailConfig `yaml:"email" json:"email"`
+	MQTT  MQTTConfig  `yaml:"mqtt" json:"mqtt"`
+	// UptimeKuma are push monitors, each reporting one node or the run
+	UptimeKuma    []UptimeKumaMonitor `yaml:"uptime_kuma" json:"uptime_kuma"`
+	HomeAssistant HomeAssistantConfig `yaml:"home_assistant" json:"home_assistant"`
+}
+
+// EmailConfig contains SMTP settings for emailing reports
+type EmailConfig struct {
+	Host     string   `yaml:"host" json:"host"`
+	Port     int      `yaml:"port" json:"port"`
+	Username string   `yaml:"username" json:"username"`
+	Password string   `yaml:"password" json:"-"`
+	From     string   `yaml:"from" json:"from"`
+	To       []string `yaml:"to" json:"to"`
+	// TLS is "starttls" (upgrade after connecting), "tls" (implicit TLS,
+	// usually port 465) or "none"
+	TLS                string `yaml:"tls" json:"tls"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" json:"insecure_skip_verify"`
+	Subject            string `yaml:"subject" json:"subject"`
+	// Format of the report: html or markdown
+	Format string `yaml:"format" json:"format"`
+	// Attach sends the report as an attachment instead of the message body
+	Attach bool `yaml:"attach" json:"attach"`
+}
+
+// Enabled reports whether email delivery is configured
+func (e *EmailConfig) Enabled() bool {
+	return e.Host != "" && len(e.To) > 0
+}
+
+// MQTTConfig contains settings for publishing results to an MQTT broker,
+// e.g. for Home Assistant sensors
+type MQTTConfig struct {
+	// Broker is mqtt://host:1883 or, with TLS, mqtts://host:8883
+	Broker   string `yaml:"broker" json:"broker"`
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"-"`
+	ClientID string `yaml:"client_id" json:"client_id"`
+	// SummaryTopic receives the totals of each run
+	SummaryTopic string `yaml:"summary_topic" json:"summary_topic"`
+	// NodeTopic receives each node's result; {node} is replaced with the
+	// node name made safe for topics
+	NodeTopic string `yaml:"node_topic" json:"node_topic"`
+	// QoS is 0 (at most once) or 1 (at least once)
+	QoS int `yaml:"qos" json:"qos"`
+	// Retain keeps the last message on the broker for new subscribers
+	Retain             bool `yaml:"retain" json:"retain"`
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify" json:"insecure_skip_verify"`
+}
+
+// Enabled reports whether MQTT publishing is configured
+func (m *MQTTConfig) Enabled() bool {
+	return m.Broker != ""
+}
+
+// UptimeKumaMonitor is an Uptime Kuma push monitor
+type UptimeKumaMonitor struct {
+	// Node is the name of the node reported; empty reports the run as a
+	// whole, up while any node works, with the best latency
+	Node string `yaml:"node" json:"node"`
+	// URL is the monitor's push URL, https://<host>/api/push/<token>
+	URL string `yaml:"url" json:"-"`
+}
+
+// HomeAssistantConfig contains the Home Assistant webhook node results are
+// posted to
+type HomeAssistantConfig struct {
+	// Webhook is https://<host>:8123/api/webhook/<webhook_id>
+	Webhook string `yaml:"webhook" json:"-"`
+}
+
+// Enabled reports whether the Home Assistant webhook is configured
+func (h *HomeAssistantConfig) Enabled() bool {
+	return h.Webhook != ""
+}
+
+// UploadConfig contains settings for publishing reports and exported
+// subscriptions after each run
+type UploadConfig struct {
+	S3     S3Config     `yaml:"s3" json:"s3"`
+	WebDAV WebDAVConfig `yaml:"webdav" json:"webdav"`
+	// Prefix is prepended to uploaded file names, e.g. "protoscope/"
+	Prefix string `yaml:"prefix" json:"prefix"`
+	// ReportFormat of the uploaded report: html or markdown
+	ReportFormat string `yaml:"report_format" json:"report_format"`
+}
+
+// Enabled reports whether any upload target is configured
+func (u *UploadConfig) Enabled() bool {
+	return u.S3.Bucket != "" || u.WebDAV.URL != ""
+}
+
+// SigningConfig signs JSON result files with a minisign key, so published
+// reports can be checked for edits with protoscope verify or minisign -V
+type SigningConfig struct {
+	// SecretKey is the path of a minisign secret key (minisign -G)
+	SecretKey string `yaml:"secret_key" json:"secret_key"`
+	// PasswordEnv names the environment variable holding the key's
+	// password; keys generated with minisign -W have none
+	PasswordEnv string `yaml:"password_env" json:"password_env"`
+}
+
+// Enabled reports whether result files are signed
+func (s *SigningConfig) Enabled() bool {
+	return s.SecretKey != ""
+}
+
+// ArchiveConfig keeps the files of each daemon run in a timestamped
+// directory, pruned after every run
+type ArchiveConfig struct {
+	// Dir receives a directory per run; empty disables the archive
+	Dir string `yaml:"dir" json:"dir"`
+	// Keep is the number of latest runs kept
+	Keep int `yaml:"keep" json:"keep"`
+	// Daily and Weekly keep the last run of that many days and weeks
+	// beyond them; all zero keeps every run
+	Daily  int `yaml:"daily" json:"daily"`
+	Weekly int `yaml:"weekly" json:"weekly"`
+}
+
+// Enabled reports whether runs are archived
+func (a *ArchiveConfig) Enabled() bool {
+	return a.Dir != ""
+}
+
+// SecretsConfig locates the age-encrypted file that config values of the
+// form secret:<alias> are read from, and the key to it
+type SecretsConfig struct {
+	// File defaults to secrets.age in the user's config directory
+	File string `yaml:"file" json:"file"`
+	// Identity is an age identity file (age-keygen or protoscope secrets
+	// keygen); by default identity.txt next to the default File is used
+	// when it exists, and the passphrase otherwise
+	Identity string `yaml:"identity" json:"identity"`
+	// PassphraseEnv names the environment variable holding the passphrase
+	// of a file encrypted with one
+	PassphraseEnv string `yaml:"passphrase_env" json:"passphrase_env"`
+}
+
+// API token scopes: read tokens see results, admin tokens may also start
+// runs and change settings
+const (
+	ScopeRead  = "read"
+	ScopeAdmin = "admin"
+)
+
+// ServerConfig secures the daemon's -listen server for use beyond localhost
+type ServerConfig struct {
+	// Tokens, when set, are required for everything but the health probes
+	Tokens []APIToken `yaml:"tokens" json:"tokens"`
+	// TLSCert and TLSKey are PEM files; with them the server speaks HTTPS
+	TLSCert string `yaml:"tls_cert" json:"tls_cert"`
+	TLSKey  string `yaml:"tls_key" json:"tls_key"`
+	// ClientCA is a PEM bundle; with it requests other than the health
+	// probes need a client certificate it signed (mTLS)
+	ClientCA string `yaml:"client_ca" json:"client_ca"`
+	// CORSOrigins are the origins of browser dashboards allowed to call
+	// the API, e.g. https://yacd.haishan.me; "*" allows any
+	CORSOrigins []string `yaml:"cors_origins" json:"cors_origins"`
+	// Runs limits the runs admins start with POST /runs
+	Runs RunQuota `yaml:"runs" json:"runs"`
+}
+
+// RunQuota bounds the resources of runs started through the server, which
+// run alongside the scheduled ones
+type RunQuota struct {
+	// MaxConcurrent is the number of such runs in flight at once
+	MaxConcurrent int `yaml:"max_concurrent" json:"max_concurrent"`
+	// MaxQueued is the number of requests that wait for a free slot,
+	// highest priority first; more are refused
+	MaxQueued int `yaml:"max_queued" json:"max_queued"`
+	// Concurrency is the number of nodes each tests at once
+	Concurrency int `yaml:"concurrency" json:"concurrency"`
+	// MaxNodes caps the nodes tested per run; 0 tests all
+	MaxNodes int `yaml:"max_nodes" json:"max_nodes"`
+	// Timeout stops a run that takes longer; 0 never does
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+	// Ports is the range their backends listen on, e.g. 20000-20999,
+	// split evenly between the runs in flight and kept from the scheduled
+	// run; empty takes any free port
+	Ports string `yaml:"ports" json:"ports"`
+}
+
+// APIToken is a bearer token for the -listen server
+type APIToken struct {
+	Name  string `yaml:"name" json:"name"`
+	Token string `yaml:"token" json:"token"`
+	// Scope is read or admin
+	Scope string `yaml:"scope" json:"scope"`
+}
+
+// S3Config contains settings for S3-compatible storage (AWS, R2, MinIO...)
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint" json:"endpoint"` // e.g. https://s3.amazonaws.com
+	Region    string `yaml:"region" json:"region"`
+	Bucket    string `yaml:"bucket" json:"bucket"`
+	AccessKey string `yaml:"access_key" json:"access_key"`
+	SecretKey string `yaml:"secret_key" json:"-"`
+	// PathStyle addresses the bucket as endpoint/bucket instead of
+	// bucket.endpoint; most self-hosted servers need it
+	PathStyle bool `yaml:"path_style" json:"path_style"`
+}
+
+// WebDAVConfig contains settings for a WebDAV upload directory
+type WebDAVConfig struct {
+	URL      string `yaml:"url" json:"url"` // directory URL files are PUT into
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"-"`
+}
+
 // DefaultConfig returns default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -60,6 +523,55 @@ func DefaultConfig() *Config {
 			EnableGeoTest:     true,
 			EnableDNSTest:     true,
 			EnablePrivacyTest: true,
+			EnablePing:        false,
+			PingCount:         4,
+			TraceMaxHops:      30,
+			EgressPorts:       []int{25, 465, 587, 22, 3389},
+			SensitiveSites: []SensitiveSite{
+				{Name: "PayPal", URL: "https://www.paypal.com/signin", Category: "banking"},
+				{Name: "HSBC", URL: "https://www.hsbc.com", Category: "banking"},
+				{Name: "Chase", URL: "https://www.chase.com", Category: "banking"},
+				{Name: "Wise", URL: "https://wise.com/login", Category: "banking"},
+				{Name: "IRS", URL: "https://www.irs.gov", Category: "government"},
+				{Name: "Login.gov", URL: "https://secure.login.gov", Category: "government"},
+				{Name: "GOV.UK", URL: "https://www.gov.uk/personal-tax-account", Category: "government"},
+				{Name: "Gosuslugi", URL: "https://www.gosuslugi.ru", Category: "government"},
+			},
+			GamingEndpoints: []GamingEndpoint{
+				{Service: "Steam", Region: "Global", URL: "https://api.steampowered.com"},
+				{Service: "Riot", Region: "EU West", URL: "https://euw1.api.riotgames.com"},
+				{Service: "Riot", Region: "EU Nordic & East", URL: "https://eun1.api.riotgames.com"},
+				{Service: "Riot", Region: "North America", URL: "https://na1.api.riotgames.com"},
+				{Service: "Riot", Region: "Korea", URL: "https://kr.api.riotgames.com"},
+				{Service: "Battle.net", Region: "Europe", URL: "https://eu.battle.net"},
+				{Service: "Battle.net", Region: "Americas", URL: "https://us.battle.net"},
+				{Service: "Battle.net", Region: "Asia", URL: "https://kr.battle.net"},
+				{Service: "PSN", Region: "Global", URL: "https://web.np.playstation.com"},
+			},
+			JitterSamples:     10,
+			JitterInterval:    200 * time.Millisecond,
+			SlowNodeThreshold: 5 * time.Second,
+			EndpointRateLimit: 5,
+			EndpointRateBurst: 5,
+			TimeoutScale: map[ProtocolType]float64{
+				ProtocolHysteria2: 1.5,
+				ProtocolTUIC:      1.5,
+			},
+			MeteredNodeBytes:   1_000_000,
+			MeteredRunBytes:    50_000_000,
+			BackendMemoryMB:    512,
+			BackendCPUPercent:  200,
+			BackendNice:        10,
+			BackendConfigStdin: true,
+			BlockingWeights:    BlockingWeights{Ads: 1, Tracking: 1, Malware: 2},
+			GeoExpect: map[string]GeoExpectation{
+				"google.com":   {Keyword: "google"},
+				"youtube.com":  {Keyword: "youtube"},
+				"yandex.ru":    {Keyword: "yandex"},
+				"baidu.com":    {Keyword: "baidu"},
+				"bilibili.com": {Keyword: "bilibili"},
+				"bbc.com":      {Keyword: "bbc"},
+			},
 		},
 		DomainLists: DomainLists{
 			RU: []string{
@@ -98,6 +610,21 @@ func DefaultConfig() *Config {
 			},
 		},
 		APIEndpoints: APIEndpoints{
+			Connectivity: []string{
+				"http://www.gstatic.com/generate_204",
+				"http://cp.cloudflare.com/generate_204",
+				"http://captive.apple.com/hotspot-detect.html",
+			},
+			ConnectivityByCountry: map[string][]string{
+				"CN": {
+					"http://connect.rom.miui.com/generate_204",
+					"http://wifi.vivo.com.cn/generate_204",
+				},
+			},
+			Latency: []string{
+				"http://www.gstatic.com/generate_204",
+				"http://cp.cloudflare.com/generate_204",
+			},
 			IPCheck: []string{
 				"https://api.ipify.org",
 				"https://ifconfig.me/ip",
@@ -112,6 +639,33 @@ func DefaultConfig() *Config {
 			GeoLocation: []string{
 				"http://ip-api.com/json/",
 			},
+			HostingRanges: []HostingRangeSource{
+				{Provider: "AWS", URL: "https://ip-ranges.amazonaws.com/ip-ranges.json", Format: "aws"},
+				{Provider: "GCP", URL: "https://www.gstatic.com/ipranges/cloud.json", Format: "gcp"},
+				{Provider: "Oracle", URL: "https://docs.oracle.com/en-us/iaas/tools/public_ip_ranges.json", Format: "oracle"},
+			},
+			ProxyLists: []ProxyListSource{
+				{Name: "tor-exits", URL: "https://check.torproject.org/torbulkexitlist", Category: "tor"},
+				{Name: "x4bnet-vpn", URL: "https://raw.githubusercontent.com/X4BNet/lists_vpn/main/output/vpn/ipv4.txt", Category: "vpn"},
+				{Name: "x4bnet-datacenter", URL: "https://raw.githubusercontent.com/X4BNet/lists_vpn/main/output/datacenter/ipv4.txt", Category: "datacenter"},
+				{Name: "firehol-proxies", URL: "https://iplists.firehol.org/files/firehol_proxies.netset", Category: "proxy"},
+			},
+			Blocklists: []BlocklistSource{
+				{Name: "adguard-dns", URL: "https://v.firebog.net/hosts/AdguardDNS.txt", Category: "ads"},
+				{Name: "easyprivacy", URL: "https://v.firebog.net/hosts/Easyprivacy.txt", Category: "tracking"},
+				{Name: "urlhaus", URL: "https://urlhaus.abuse.ch/downloads/hostfile/", Category: "malware"},
+			},
+			EgressProbe: "portquiz.net",
+			NTP: []string{
+				"time.cloudflare.com:123",
+				"pool.ntp.org:123",
+			},
+			TimeHTTP: []string{
+				"https://www.cloudflare.com",
+				"https://www.google.com",
+			},
+			OoklaServers: "https://www.speedtest.net/api/js/servers?engine=js&limit=10",
+			CDNEdge:      "https://speed.cloudflare.com/__down?bytes=10000000",
 		},
 		OutputConfig: OutputConfig{
 			Format:      "console",
@@ -119,5 +673,106 @@ func DefaultConfig() *Config {
 			ShowSuccess: true,
 			ShowFailed:  true,
 		},
+		Notify: NotifyConfig{
+			Email: EmailConfig{
+				Port:    587,
+				TLS:     "starttls",
+				Subject: "ProtoScope report",
+				Format:  "html",
+			},
+			MQTT: MQTTConfig{
+				ClientID:     "protoscope",
+				SummaryTopic: "protoscope/summary",
+				NodeTopic:    "protoscope/nodes/{node}",
+				Retain:       true,
+			},
+		},
+		Upload: UploadConfig{
+			S3: S3Config{
+				Endpoint: "https://s3.amazonaws.com",
+				Region:   "us-east-1",
+			},
+			ReportFormat: "html",
+		},
+		Signing: SigningConfig{
+			PasswordEnv: "PROTOSCOPE_SIGNING_PASSWORD",
+		},
+		Archive: ArchiveConfig{
+			Keep:   24,
+			Daily:  7,
+			Weekly: 4,
+		},
+		Secrets: SecretsConfig{
+			PassphraseEnv: "PROTOSCOPE_SECRETS_PASSPHRASE",
+		},
+		Server: ServerConfig{
+			Runs: RunQuota{
+				MaxConcurrent: 1,
+				MaxQueued:     10,
+				Concurrency:   2,
+				Timeout:       time.Hour,
+			},
+		},
+	}
+}
+
+// ConnectivityEndpoints returns the connectivity endpoints for a user in the
+// given country: regional endpoints first, then the defaults as fallbacks
+func (e *APIEndpoints) ConnectivityEndpoints(country string) []string {
+	regional := e.ConnectivityByCountry[strings.ToUpper(country)]
+	if len(regional) == 0 {
+		return e.Connectivity
+	}
+
+	urls := make([]string, 0, len(regional)+len(e.Connectivity))
+	urls = append(urls, regional...)
+	for _, url := range e.Connectivity {
+		if !slices.Contains(urls, url) {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// LoadConfig reads a YAML config file over the defaults, so the file only
+// needs the settings it changes
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
+
+	config := DefaultConfig()
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	// Plugin names key the results, so they must be set and unique
+	seen := make(map[string]bool, len(config.Plugins))
+	for i, plugin := range config.Plugins {
+		if plugin.Name == "" || (plugin.Command == "") == (plugin.WASM == "") {
+			return nil, fmt.Errorf("config %s: plugin %d needs a name and either a command or a wasm module", path, i+1)
+		}
+		if seen[plugin.Name] {
+			return nil, fmt.Errorf("config %s: duplicate plugin %q", path, plugin.Name)
+		}
+		seen[plugin.Name] = true
+	}
+
+	for i, token := range config.Server.Tokens {
+		if token.Token == "" {
+			return nil, fmt.Errorf("config %s: server token %d is empty", path, i+1)
+		}
+		if token.Scope != ScopeRead && token.Scope != ScopeAdmin {
+			return nil, fmt.Errorf("config %s: server token %d has scope %q, want read or admin", path, i+1, token.Scope)
+		}
+	}
+	if (config.Server.TLSCert == "") != (config.Server.TLSKey == "") {
+		return nil, fmt.Errorf("config %s: server tls_cert and tls_key must be set together", path)
+	}
+	if config.Server.ClientCA != "" && config.Server.TLSCert == "" {
+		return nil, fmt.Errorf("config %s: server client_ca needs tls_cert and tls_key", path)
+	}
+
+	return config, nil
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigMergesTimeoutScale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := []byte("test_config:\n  timeout: 20s\n  timeout_scale:\n    tuic: 2\n    shadowsocks: 0.5\n")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+
+	tests := []struct {
+		protocol ProtocolType
+		want     time.Duration
+	}{
+		{ProtocolTUIC, 40 * time.Second},
+		{ProtocolHysteria2, 30 * time.Second}, // default scale kept
+		{ProtocolShadowsocks, 10 * time.Second},
+		{ProtocolTrojan, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := config.TestConfig.TimeoutFor(tt.protocol); got != tt.want {
+			t.Errorf("TimeoutFor(%s) = %v, want %v", tt.protocol, got, tt.want)
+		}
+	}
+
+	if !config.TestConfig.EnableGeoTest {
+		t.Error("expected unset keys to keep their defaults")
+	}
+}
+
+func TestConnectivityEndpointsPrefersRegional(t *testing.T) {
+	endpoints := DefaultConfig().APIEndpoints
+	endpoints.ConnectivityByCountry["IR"] = []string{"http://a.ir/204", "http://cp.cloudflare.com/generate_204"}
+
+	got := endpoints.ConnectivityEndpoints("ir")
+	want := []string{
+		"http://a.ir/204",
+		"http://cp.cloudflare.com/generate_204",
+		"http://www.gstatic.com/generate_204",
+		"http://captive.apple.com/hotspot-detect.html",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("ConnectivityEndpoints(ir) = %v, want %v", got, want)
+	}
+
+	if got := endpoints.ConnectivityEndpoints("DE"); len(got) != len(endpoints.Connectivity) {
+		t.Fatalf("expected defaults for a country without regional endpoints, got %v", got)
+	}
+}
@@ -8,6 +8,7 @@ type ErrorType string
 const (
 	ErrorTypeBackendNotFound   ErrorType = "backend_not_found"
 	ErrorTypeConfigGeneration  ErrorType = "config_generation"
+	ErrorTypeInvalidConfig     ErrorType = "invalid_config"
 	ErrorTypeProxyStartFailed  ErrorType = "proxy_start_failed"
 	ErrorTypeProxyTimeout      ErrorType = "proxy_timeout"
 	ErrorTypeConnectivity      ErrorType = "connectivity"
@@ -42,6 +43,9 @@ func (e *DetailedError) GetTroubleshootingSuggestion() string {
 	case ErrorTypeConfigGeneration:
 		return "Check if the protocol configuration is valid. The protocol URL may be malformed."
 
+	case ErrorTypeInvalidConfig:
+		return "The node's settings are malformed, so it was not tested. Ask the provider for a corrected link."
+
 	case ErrorTypeProxyStartFailed:
 		return "Check if the port is already in use. Try running with different port or stop other proxies."
 
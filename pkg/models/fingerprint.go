@@ -0,0 +1,36 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fingerprint returns a stable hash of the node's connection settings.
+// The display name and original URL are left out, so renaming a node or
+// re-encoding its link does not change the fingerprint.
+func (p *Protocol) Fingerprint() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "type=%s\nserver=%s\nport=%d\n", p.Type, strings.ToLower(p.Server), p.Port)
+	fmt.Fprintf(&b, "uuid=%s\npassword=%s\nnetwork=%s\ntls=%t\nsni=%s\n", p.UUID, p.Password, p.Network, p.TLS, p.SNI)
+
+	keys := make([]string, 0, len(p.Extra))
+	for k := range p.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		// JSON keeps nested values stable (maps are encoded with sorted keys)
+		value, err := json.Marshal(p.Extra[k])
+		if err != nil {
+			value = []byte(fmt.Sprint(p.Extra[k]))
+		}
+		fmt.Fprintf(&b, "extra.%s=%s\n", k, value)
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
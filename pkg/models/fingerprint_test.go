@@ -0,0 +1,23 @@
+package models
+
+import "testing"
+
+func TestFingerprintIgnoresNameAndRaw(t *testing.T) {
+	a := &Protocol{
+		Type: ProtocolVLESS, Name: "NL-01", Server: "nl.example.com", Port: 443, UUID: "id",
+		Raw:   "vless://id@nl.example.com:443#NL-01",
+		Extra: map[string]interface{}{"flow": "xtls-rprx-vision", "fp": "chrome"},
+	}
+	b := a.Clone()
+	b.Name = "Netherlands 1"
+	b.Raw = "vless://id@NL.example.com:443?fp=chrome#Netherlands%201"
+
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Fatal("expected rename to keep the fingerprint")
+	}
+
+	b.Extra["fp"] = "firefox"
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Fatal("expected changed settings to change the fingerprint")
+	}
+}
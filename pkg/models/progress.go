@@ -0,0 +1,15 @@
+package models
+
+// TestProgress reports how far testing of one node has got, for progress
+// bars in TUIs and dashboards. Connectivity is the first stage, followed
+// by the enabled check stages in the order they run.
+type TestProgress struct {
+	Protocol   *Protocol `json:"protocol"`
+	Stage      string    `json:"stage"`       // e.g. "connectivity", "geo"; "done" at the end
+	StageIndex int       `json:"stage_index"` // 1-based
+	StageCount int       `json:"stage_count"`
+	// Detail names the step within a long stage, e.g. the geo domain
+	// just tested
+	Detail  string  `json:"detail,omitempty"`
+	Percent float64 `json:"percent"` // of the node's stages, 0-100
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ProtocolType represents the type of proxy protocol
 type ProtocolType string
@@ -12,6 +15,8 @@ const (
 	ProtocolShadowsocks ProtocolType = "shadowsocks"
 	ProtocolHysteria2  ProtocolType = "hysteria2"
 	ProtocolTUIC       ProtocolType = "tuic"
+	ProtocolWireGuard  ProtocolType = "wireguard"
+	ProtocolSSH        ProtocolType = "ssh"
 	ProtocolSingBox    ProtocolType = "singbox"
 )
 
@@ -28,6 +33,30 @@ type Protocol struct {
 	SNI      string             `json:"sni,omitempty"`
 	Raw      string             `json:"raw"` // Original URL
 	Extra    map[string]interface{} `json:"extra,omitempty"`
+	// AdvertisedCountry is the ISO country code the node's name claims,
+	// from a flag emoji, country name or code; empty if it claims none
+	AdvertisedCountry string        `json:"advertised_country,omitempty"`
+	// Warnings lists problems found at parse time, such as a malformed
+	// UUID, that make the node unusable; such nodes are not run
+	Warnings []string           `json:"warnings,omitempty"`
+	// Subscription names the subscription the node came from when a run
+	// tests several
+	Subscription string `json:"subscription,omitempty"`
+}
+
+// Clone returns a copy of the protocol that can be modified independently
+func (p *Protocol) Clone() *Protocol {
+	clone := *p
+	if p.Extra != nil {
+		clone.Extra = make(map[string]interface{}, len(p.Extra))
+		for k, v := range p.Extra {
+			clone.Extra[k] = v
+		}
+	}
+	if p.Warnings != nil {
+		clone.Warnings = append([]string(nil), p.Warnings...)
+	}
+	return &clone
 }
 
 // TestResult contains all test results for a protocol
@@ -35,17 +64,297 @@ type TestResult struct {
 	Protocol      *Protocol           `json:"protocol"`
 	Timestamp     time.Time           `json:"timestamp"`
 	Success       bool                `json:"success"`
+	// PartialSuccess marks a working node whose later checks hit the node
+	// deadline; the results that did complete are kept
+	PartialSuccess bool               `json:"partial_success,omitempty"`
+	// Cached marks a result carried forward from an earlier run because the
+	// node's settings did not change
+	Cached        bool                `json:"cached,omitempty"`
+	// Note is the user's note on the node, see protoscope notes
+	Note          *NodeNote           `json:"note,omitempty"`
 	Error         string              `json:"error,omitempty"`
 	ErrorDetails  *DetailedError      `json:"error_details,omitempty"`
 	Connectivity  *ConnectivityResult `json:"connectivity,omitempty"`
+	Ping          *PingResult         `json:"ping,omitempty"`
+	Trace         *TraceResult        `json:"trace,omitempty"`
+	MTU           *MTUResult          `json:"mtu,omitempty"`
+	ResolvedIPs   []string            `json:"resolved_ips,omitempty"`
+	// DialedIP is the one of ResolvedIPs the node was tested through
+	DialedIP      string              `json:"dialed_ip,omitempty"`
+	IPResults     []IPResult          `json:"ip_results,omitempty"`
 	Performance   *PerformanceResult  `json:"performance,omitempty"`
 	GeoAccess     *GeoAccessResult    `json:"geo_access,omitempty"`
 	DNS           *DNSResult          `json:"dns,omitempty"`
 	Privacy       *PrivacyResult      `json:"privacy,omitempty"`
+	Egress        *EgressResult       `json:"egress,omitempty"`
+	SensitiveSites *SensitiveSitesResult `json:"sensitive_sites,omitempty"`
+	Gaming        *GamingResult       `json:"gaming,omitempty"`
+	Streaming     *StreamingResult    `json:"streaming,omitempty"`
+	Ookla         *OoklaResult        `json:"ookla,omitempty"`
+	EdgeSpeed     *EdgeSpeedResult    `json:"edge_speed,omitempty"`
+	TLSFingerprint *TLSFingerprintResult `json:"tls_fingerprint,omitempty"`
+	Obfuscation   *ObfuscationResult  `json:"obfuscation,omitempty"`
+	ActiveProbing *ActiveProbingResult `json:"active_probing,omitempty"`
+	// Plugins holds the output of external checks by plugin name
+	Plugins       map[string]*PluginResult `json:"plugins,omitempty"`
+	SkippedChecks []SkippedCheck      `json:"skipped_checks,omitempty"`
+}
+
+// Status returns "working", "partial" or "failed"
+func (r *TestResult) Status() string {
+	switch {
+	case !r.Success:
+		return "failed"
+	case r.PartialSuccess:
+		return "partial"
+	default:
+		return "working"
+	}
+}
+
+// NodeNote is a user note and labels attached to a node, e.g. "paid plan"
+type NodeNote struct {
+	Text    string    `json:"text,omitempty"`
+	Labels  []string  `json:"labels,omitempty"`
+	Name    string    `json:"name"` // node name when the note was set
+	Updated time.Time `json:"updated"`
+}
+
+// SkippedCheck records a check that was not run and why
+type SkippedCheck struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// PluginResult is the outcome of an external check
+type PluginResult struct {
+	Output   json.RawMessage `json:"output,omitempty"` // the JSON the plugin printed
+	Duration time.Duration   `json:"duration"`
+	Error    string          `json:"error,omitempty"`
 }
 
 // ConnectivityResult represents basic connectivity test
 type ConnectivityResult struct {
+	Connected    bool          `json:"connected"`
+	ResponseTime time.Duration `json:"response_time"`
+	Endpoint     string        `json:"endpoint,omitempty"` // URL that answered
+	Error        string        `json:"error,omitempty"`
+}
+
+// PingResult represents a direct ICMP echo measurement to the node server.
+// It is taken outside the tunnel, so comparing it with the proxied latency
+// separates path RTT from protocol overhead.
+type PingResult struct {
+	Method     string        `json:"method"` // icmp, udp
+	Address    string        `json:"address"`
+	Sent       int           `json:"sent"`
+	Received   int           `json:"received"`
+	PacketLoss float64       `json:"packet_loss"`
+	MinRTT     time.Duration `json:"min_rtt"`
+	AvgRTT     time.Duration `json:"avg_rtt"`
+	MaxRTT     time.Duration `json:"max_rtt"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// TraceResult represents a traceroute to the node server, taken outside the
+// tunnel
+type TraceResult struct {
+	Address     string        `json:"address"`
+	Reached     bool          `json:"reached"`
+	HopCount    int           `json:"hop_count"`
+	WorstHop    int           `json:"worst_hop,omitempty"` // TTL of the slowest hop
+	WorstHopRTT time.Duration `json:"worst_hop_rtt,omitempty"`
+	Hops        []TraceHop    `json:"hops,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// TraceHop is one TTL step of a traceroute
+type TraceHop struct {
+	TTL      int           `json:"ttl"`
+	Address  string        `json:"address,omitempty"` // empty when no hop answered
+	Sent     int           `json:"sent"`
+	Received int           `json:"received"`
+	Loss     float64       `json:"loss"`
+	AvgRTT   time.Duration `json:"avg_rtt"`
+}
+
+// MTUResult represents a path MTU probe to a UDP-based node server
+type MTUResult struct {
+	Address     string `json:"address"`
+	PathMTU     int    `json:"path_mtu"` // largest unfragmented IP packet that got through
+	ReportedMTU int    `json:"reported_mtu,omitempty"` // smallest MTU advertised by a router
+	// Blackhole is set when larger packets vanished without any
+	// "fragmentation needed" reply
+	Blackhole bool `json:"blackhole"`
+	// FragmentationIssue flags paths likely to stall QUIC: a black hole or an
+	// MTU below a full-size QUIC initial packet
+	FragmentationIssue bool   `json:"fragmentation_issue"`
+	Error              string `json:"error,omitempty"`
+}
+
+// EgressResult is the exit's outbound port policy, probed with TCP
+// connections through the proxy to a host that answers on every port
+type EgressResult struct {
+	Probe   string       `json:"probe"`
+	Ports   []EgressPort `json:"ports"`
+	Blocked []int        `json:"blocked,omitempty"`
+}
+
+// EgressPort is the outcome of one probed port
+type EgressPort struct {
+	Port    int    `json:"port"`
+	Service string `json:"service,omitempty"` // e.g. smtp, ssh
+	Open    bool   `json:"open"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SensitiveSitesResult tells which banking and government sites can be
+// reached through the node. These sites often turn proxy and datacenter
+// exits away with challenges or access denied pages (fraud blocks).
+type SensitiveSitesResult struct {
+	Sites        []SensitiveSiteAccess `json:"sites"`
+	Reachable    int                   `json:"reachable"`
+	FraudBlocked int                   `json:"fraud_blocked"`
+}
+
+// SensitiveSiteAccess is the outcome of one sensitive site
+type SensitiveSiteAccess struct {
+	Name       string        `json:"name"`
+	Category   string        `json:"category,omitempty"` // e.g. banking, government
+	URL        string        `json:"url"`
+	Reachable  bool          `json:"reachable"` // answered without a fraud block
+	StatusCode int           `json:"status_code,omitempty"`
+	FraudBlock string        `json:"fraud_block,omitempty"` // challenge, captcha, denied or e.g. "http 403"
+	Latency    time.Duration `json:"latency"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// StreamingResult tells which streaming and AI services serve the exit,
+// and the region each detected. Services pick their catalogue, or turn
+// the exit away, by that region.
+type StreamingResult struct {
+	Services []StreamingService `json:"services"`
+	Unlocked int                `json:"unlocked"`
+}
+
+// StreamingService is the outcome of one service
+type StreamingService struct {
+	Name     string `json:"name"`     // Netflix, Disney+, YouTube Premium, ChatGPT
+	Unlocked bool   `json:"unlocked"`
+	Region   string `json:"region,omitempty"` // country code the service detected
+	// Detail tells why a service is locked: originals only, blocked,
+	// not available in region or VPN detected
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// GamingResult rates the node for online games by latency and jitter to
+// the game services' regional servers. Download speed matters little for
+// games; a steady, short round trip to the nearest region does.
+type GamingResult struct {
+	Endpoints []GamingLatency `json:"endpoints"`
+	Best      *GamingLatency  `json:"best,omitempty"`   // lowest latency that answered
+	Rating    string          `json:"rating,omitempty"` // good, playable or poor, of the best
+}
+
+// GamingLatency is the latency of one game service region
+type GamingLatency struct {
+	Service    string        `json:"service"`
+	Region     string        `json:"region,omitempty"`
+	URL        string        `json:"url"`
+	Latency    time.Duration `json:"latency"` // median of the samples
+	MinLatency time.Duration `json:"min_latency"`
+	Jitter     time.Duration `json:"jitter"`
+	Samples    int           `json:"samples"`
+	Loss       float64       `json:"loss"` // fraction of failed samples
+	Rating     string        `json:"rating,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	// Times are the latencies of the samples, only written to raw
+	// measurement files
+	Times []time.Duration `json:"-"`
+}
+
+// OoklaResult is a speedtest.net TCP test against a server near the exit
+type OoklaResult struct {
+	Server        OoklaServer   `json:"server"`
+	Latency       time.Duration `json:"latency"`
+	DownloadSpeed float64       `json:"download_speed_mbps"`
+	UploadSpeed   float64       `json:"upload_speed_mbps"`
+}
+
+// OoklaServer identifies the speedtest.net server a test ran against
+type OoklaServer struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"` // city
+	Sponsor  string  `json:"sponsor"`
+	Country  string  `json:"country"` // ISO code
+	Host     string  `json:"host"`
+	Distance float64 `json:"distance_km"` // from the exit IP's location
+}
+
+// EdgeSpeedResult compares downloads from the CDN edge near the exit and
+// the one near the user
+type EdgeSpeedResult struct {
+	URL      string    `json:"url"`
+	NearExit EdgeSpeed `json:"near_exit"`           // resolved by the exit
+	NearUser EdgeSpeed `json:"near_user"`           // resolved locally
+	UserEdge string    `json:"user_edge,omitempty"` // reached without the proxy
+}
+
+// EdgeSpeed is a download from one CDN edge
+type EdgeSpeed struct {
+	Edge          string  `json:"edge,omitempty"` // point of presence, e.g. AMS
+	IP            string  `json:"ip,omitempty"`   // near_user only
+	DownloadSpeed float64 `json:"download_speed_mbps"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// TLSFingerprintResult is the ClientHello the backend sends to the node,
+// so users can check that their uTLS fingerprint setting takes effect
+type TLSFingerprintResult struct {
+	Requested  string   `json:"requested,omitempty"` // fp setting of the node link
+	Profile    string   `json:"profile"`             // browser, firefox or go-stdlib
+	JA3        string   `json:"ja3"`
+	JA3Hash    string   `json:"ja3_hash"`
+	JA3NHash   string   `json:"ja3n_hash"` // extensions sorted; stable across shuffling
+	ServerName string   `json:"server_name,omitempty"`
+	ALPN       []string `json:"alpn,omitempty"`
+	// Mismatch is set when a browser fingerprint was requested but the
+	// backend sent a plain Go TLS hello
+	Mismatch bool   `json:"mismatch"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ObfuscationResult tells whether an obfuscated node's traffic (REALITY,
+// salamander) really hides the proxy protocol from an observer
+type ObfuscationResult struct {
+	Method    string   `json:"method"`   // reality, salamander
+	Observed  string   `json:"observed"` // wire form of the first flight: tls, quic, http, trojan, vless, opaque
+	Effective bool     `json:"effective"`
+	Issues    []string `json:"issues,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// ActiveProbingResult rates how well a node server resists active probing:
+// how it reacts to connections that aren't valid client handshakes
+type ActiveProbingResult struct {
+	Score   int           `json:"score"`   // 0-100, higher reveals less
+	Verdict string        `json:"verdict"` // resistant, partial, fingerprintable
+	Probes  []ActiveProbe `json:"probes"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// ActiveProbe is the server's reaction to one probe
+type ActiveProbe struct {
+	Name     string        `json:"name"`     // empty, http, random-50, random-221, tls-http, wrong-credentials
+	Response string        `json:"response"` // timeout, reset, close, http, tls, data, refused, tls-fail, accepted, rejected
+	Detail   string        `json:"detail,omitempty"`
+	Elapsed  time.Duration `json:"elapsed"`
+}
+
+// IPResult represents a connectivity test pinned to one resolved server IP
+type IPResult struct {
+	IP           string        `json:"ip"`
 	Connected    bool          `json:"connected"`
 	ResponseTime time.Duration `json:"response_time"`
 	Error        string        `json:"error,omitempty"`
@@ -56,17 +365,48 @@ type PerformanceResult struct {
 	Latency       time.Duration `json:"latency"`
 	DownloadSpeed float64       `json:"download_speed_mbps"`
 	UploadSpeed   float64       `json:"upload_speed_mbps"`
-	Jitter        time.Duration `json:"jitter,omitempty"`
+	// SpeedTestServer is the speed test endpoint the download ran against,
+	// and ServerPing the latency to it where the server supports pings
+	SpeedTestServer string        `json:"speed_test_server,omitempty"`
+	ServerPing      time.Duration `json:"server_ping,omitempty"`
+	// Jitter is the standard deviation of JitterSamples, repeated request
+	// times to one latency endpoint
+	Jitter        time.Duration   `json:"jitter,omitempty"`
+	JitterSamples []time.Duration `json:"jitter_samples,omitempty"`
+	// DownloadThroughput and UploadThroughput are the Mbps of every second
+	// of the speed tests, only written to raw measurement files
+	DownloadThroughput []float64 `json:"-"`
+	UploadThroughput   []float64 `json:"-"`
+	// BackendTraffic is what the proxy backend counted during the download
+	// test, to cross-check the measured speed (xray backend only)
+	BackendTraffic *BackendTraffic `json:"backend_traffic,omitempty"`
+	// Metered is set when metered mode capped the download test
+	Metered *MeteredSpeed `json:"metered,omitempty"`
+}
+
+// MeteredSpeed describes a download test shortened by metered mode
+type MeteredSpeed struct {
+	CapBytes int64 `json:"cap_bytes"` // 0 when the run's data cap was used up
+	Bytes    int64 `json:"bytes"`     // actually downloaded
+	// AccuracyLoss estimates how much lower the speed reads than with the
+	// full-size download, as a fraction of it
+	AccuracyLoss float64 `json:"accuracy_loss"`
+}
+
+// BackendTraffic holds the backend's per-outbound traffic counters over the
+// download test
+type BackendTraffic struct {
+	UplinkBytes   int64   `json:"uplink_bytes"`
+	DownlinkBytes int64   `json:"downlink_bytes"`
+	DownloadSpeed float64 `json:"download_speed_mbps"` // DownlinkBytes over the test duration
 }
 
 // GeoAccessResult represents geo-blocking tests
 type GeoAccessResult struct {
-	RU      map[string]AccessStatus `json:"ru"`
-	CN      map[string]AccessStatus `json:"cn"`
-	IR      map[string]AccessStatus `json:"ir"`
-	US      map[string]AccessStatus `json:"us"`
-	Custom  map[string]AccessStatus `json:"custom,omitempty"`
-	Summary GeoAccessSummary        `json:"summary"`
+	// Regions maps each tested region (RU, CN, IR, US and those defined in
+	// the config's geo_regions) to the access status of its domains
+	Regions map[string]map[string]AccessStatus `json:"regions"`
+	Summary GeoAccessSummary                   `json:"summary"`
 }
 
 // AccessStatus represents access status for a domain
@@ -75,6 +415,14 @@ type AccessStatus struct {
 	StatusCode int           `json:"status_code,omitempty"`
 	Latency    time.Duration `json:"latency"`
 	Error      string        `json:"error,omitempty"`
+	// Blockpage is set when the page answered but failed its
+	// GeoExpectation, as block pages served with status 200 do
+	Blockpage bool `json:"blockpage,omitempty"`
+	// FinalURL is where the domain redirected to, and Redirect what kind
+	// of page that is: regional (another country's domain of the site),
+	// consent, blockpage or offsite (an unrelated site)
+	FinalURL string `json:"final_url,omitempty"`
+	Redirect string `json:"redirect,omitempty"`
 }
 
 // GeoAccessSummary provides a summary of geo-access results
@@ -83,12 +431,34 @@ type GeoAccessSummary struct {
 	TotalAccessible  int `json:"total_accessible"`
 	TotalBlocked     int `json:"total_blocked"`
 	AccessPercentage float64 `json:"access_percentage"`
+	// TotalBlockpages of the blocked domains served a block page
+	TotalBlockpages int `json:"total_blockpages,omitempty"`
 }
 
 // DNSResult represents DNS leak and blocking tests
 type DNSResult struct {
 	LeakDetection *DNSLeakResult    `json:"leak_detection"`
 	Blocking      *DNSBlockingResult `json:"blocking"`
+	DNSSEC        *DNSSECResult      `json:"dnssec,omitempty"`
+}
+
+// DNSSEC validation states
+const (
+	DNSSECValidating    = "validating"
+	DNSSECNotValidating = "not_validating"
+	DNSSECInconclusive  = "inconclusive"
+)
+
+// DNSSECResult tells whether the node's resolver validates DNSSEC: a
+// validating resolver answers a correctly signed name but refuses one with
+// a broken signature
+type DNSSECResult struct {
+	Status          string `json:"status"` // validating, not_validating, inconclusive
+	SignedDomain    string `json:"signed_domain"`
+	BrokenDomain    string `json:"broken_domain"`
+	SignedReachable bool   `json:"signed_reachable"`
+	BrokenReachable bool   `json:"broken_reachable"`
+	Error           string `json:"error,omitempty"`
 }
 
 // DNSLeakResult represents DNS leak detection
@@ -105,15 +475,20 @@ type DNSBlockingResult struct {
 	Tracking map[string]BlockStatus `json:"tracking"`
 	Malware  map[string]BlockStatus `json:"malware,omitempty"`
 	Summary  DNSBlockingSummary     `json:"summary"`
+	// BlocklistError tells which -blocklist-sample lists failed to load;
+	// their domains were left out
+	BlocklistError string `json:"blocklist_error,omitempty"`
 }
 
 // BlockStatus represents whether a domain is blocked
 type BlockStatus struct {
 	Domain      string `json:"domain"`
 	IsBlocked   bool   `json:"is_blocked"`
-	BlockType   string `json:"block_type,omitempty"` // DNS, HTTP, HTTPS, None
+	BlockType   string `json:"block_type,omitempty"` // DNS, SNI, IP, HTTP, None
 	DNSResponse string `json:"dns_response,omitempty"`
 	HTTPStatus  int    `json:"http_status,omitempty"`
+	// TLSError is why the SNI probe's handshake failed, when it did
+	TLSError string `json:"tls_error,omitempty"`
 }
 
 // DNSBlockingSummary provides summary of DNS blocking
@@ -121,6 +496,9 @@ type DNSBlockingSummary struct {
 	TotalTested    int     `json:"total_tested"`
 	TotalBlocked   int     `json:"total_blocked"`
 	BlockPercentage float64 `json:"block_percentage"`
+	// Effectiveness is the percentage of domains blocked, averaged over
+	// the categories tested with TestConfig.BlockingWeights
+	Effectiveness float64 `json:"effectiveness"`
 }
 
 // PrivacyResult represents privacy and security tests
@@ -130,13 +508,71 @@ type PrivacyResult struct {
 	IPv6Leak   bool   `json:"ipv6_leak"`
 	RealIP     string `json:"real_ip,omitempty"`
 	ProxyIP    string `json:"proxy_ip,omitempty"`
+	// ExitCountry is the ISO country code of ProxyIP
+	ExitCountry string `json:"exit_country,omitempty"`
+	// CountryMismatch is set when ExitCountry differs from the country the
+	// node's name advertises
+	CountryMismatch bool `json:"country_mismatch,omitempty"`
+	// Hosting is set when ProxyIP belongs to a known cloud or hosting provider
+	Hosting    *HostingInfo `json:"hosting,omitempty"`
+	// ProxyDetection tells whether sites will likely see ProxyIP as a proxy
+	ProxyDetection *ProxyDetectionResult `json:"proxy_detection,omitempty"`
 	Exposed    []string `json:"exposed,omitempty"`
 	Score      int    `json:"security_score"` // 0-100
 }
 
+// HostingInfo identifies the hosting provider of an IP. Streaming services
+// often block whole providers, so this explains many geo-access failures.
+type HostingInfo struct {
+	Provider string `json:"provider"`          // e.g. AWS, GCP, Azure, Oracle, Hetzner
+	Region   string `json:"region,omitempty"`  // provider region, or city for ASN matches
+	Network  string `json:"network,omitempty"` // matched prefix or ASN
+	Source   string `json:"source"`            // ip-ranges or asn
+}
+
+// ProxyDetectionResult is the exit IP checked against public Tor, VPN,
+// proxy and datacenter lists like the ones sites use to block proxies
+type ProxyDetectionResult struct {
+	// LikelyFlagged is set when the IP is on any list or belongs to a
+	// hosting provider
+	LikelyFlagged bool     `json:"likely_flagged"`
+	Lists         []string `json:"lists,omitempty"`      // names of the lists the IP is on
+	Categories    []string `json:"categories,omitempty"` // tor, vpn, proxy, datacenter
+	ListsChecked  int      `json:"lists_checked"`
+}
+
+// String returns the provider and region, e.g. "AWS eu-central-1"
+func (h *HostingInfo) String() string {
+	if h.Region == "" {
+		return h.Provider
+	}
+	return h.Provider + " " + h.Region
+}
+
 // Subscription represents a parsed subscription
 type Subscription struct {
 	URL       string      `json:"url"`
 	Protocols []*Protocol `json:"protocols"`
 	ParsedAt  time.Time   `json:"parsed_at"`
+	Report    *ParseReport `json:"parse_report,omitempty"`
+}
+
+// ParseReport lists the subscription lines that looked like share links
+// but could not be parsed
+type ParseReport struct {
+	Lines   int          `json:"lines"`
+	Parsed  int          `json:"parsed"`
+	Skipped []ParseIssue `json:"skipped,omitempty"`
+}
+
+// ParseIssue is one link that failed to parse. Snippet is the start of the
+// link with credentials masked, safe to paste into a bug report.
+// Unsupported names the feature a well-formed node needs that ProtoScope
+// can't test, e.g. shadow-tls.
+type ParseIssue struct {
+	Line        int    `json:"line"`
+	Scheme      string `json:"scheme"`
+	Error       string `json:"error"`
+	Snippet     string `json:"snippet"`
+	Unsupported string `json:"unsupported,omitempty"`
 }
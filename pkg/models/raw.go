@@ -0,0 +1,131 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// RawMeasurements are the individual samples behind a node's results, for
+// users doing their own statistics. They are written to a file per node
+// rather than into reports.
+type RawMeasurements struct {
+	Node      string       `json:"node"`
+	Type      ProtocolType `json:"type"`
+	Server    string       `json:"server"`
+	Port      int          `json:"port"`
+	Timestamp time.Time    `json:"timestamp"`
+	// Latency holds every timed request: the connectivity check, the
+	// latency test, the jitter samples and the gaming samples
+	Latency []LatencySample `json:"latency"`
+	// Throughput holds every second of the download and upload tests
+	Throughput []ThroughputSample `json:"throughput"`
+	// Domains holds the request to every domain of the geo access and
+	// sensitive sites tests
+	Domains []DomainTiming `json:"domains"`
+}
+
+// LatencySample is one timed request
+type LatencySample struct {
+	Test    string        `json:"test"`             // connectivity, latency, jitter or gaming
+	Target  string        `json:"target,omitempty"` // URL, or service and region for gaming
+	Latency time.Duration `json:"latency"`
+}
+
+// ThroughputSample is one second of a speed test
+type ThroughputSample struct {
+	Test   string  `json:"test"`   // download or upload
+	Second int     `json:"second"` // from the start of the request, the last one partial
+	Mbps   float64 `json:"mbps"`
+}
+
+// DomainTiming is the request to one domain
+type DomainTiming struct {
+	Test       string        `json:"test"`            // geo or sensitive
+	Group      string        `json:"group,omitempty"` // geo region or site category
+	Domain     string        `json:"domain"`          // domain, or site name for sensitive sites
+	Accessible bool          `json:"accessible"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Latency    time.Duration `json:"latency"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// RawMeasurements collects the samples of the result. Results loaded from
+// the cache or a report have lost the per-second throughput and gaming
+// samples, which are not saved with them.
+func (r *TestResult) RawMeasurements() *RawMeasurements {
+	raw := &RawMeasurements{
+		Timestamp:  r.Timestamp,
+		Latency:    []LatencySample{},
+		Throughput: []ThroughputSample{},
+		Domains:    []DomainTiming{},
+	}
+	if r.Protocol != nil {
+		raw.Node = r.Protocol.Name
+		raw.Type = r.Protocol.Type
+		raw.Server = r.Protocol.Server
+		raw.Port = r.Protocol.Port
+	}
+
+	if c := r.Connectivity; c != nil && c.Connected {
+		raw.Latency = append(raw.Latency, LatencySample{Test: "connectivity", Target: c.Endpoint, Latency: c.ResponseTime})
+	}
+	if p := r.Performance; p != nil {
+		if p.Latency > 0 {
+			raw.Latency = append(raw.Latency, LatencySample{Test: "latency", Latency: p.Latency})
+		}
+		for _, sample := range p.JitterSamples {
+			raw.Latency = append(raw.Latency, LatencySample{Test: "jitter", Latency: sample})
+		}
+		for i, mbps := range p.DownloadThroughput {
+			raw.Throughput = append(raw.Throughput, ThroughputSample{Test: "download", Second: i, Mbps: mbps})
+		}
+		for i, mbps := range p.UploadThroughput {
+			raw.Throughput = append(raw.Throughput, ThroughputSample{Test: "upload", Second: i, Mbps: mbps})
+		}
+	}
+	if g := r.Gaming; g != nil {
+		for _, endpoint := range g.Endpoints {
+			target := endpoint.Service
+			if endpoint.Region != "" {
+				target += " " + endpoint.Region
+			}
+			for _, sample := range endpoint.Times {
+				raw.Latency = append(raw.Latency, LatencySample{Test: "gaming", Target: target, Latency: sample})
+			}
+		}
+	}
+
+	if geo := r.GeoAccess; geo != nil {
+		for _, region := range slices.Sorted(maps.Keys(geo.Regions)) {
+			domains := geo.Regions[region]
+			for _, domain := range slices.Sorted(maps.Keys(domains)) {
+				status := domains[domain]
+				raw.Domains = append(raw.Domains, DomainTiming{
+					Test:       "geo",
+					Group:      region,
+					Domain:     domain,
+					Accessible: status.Accessible,
+					StatusCode: status.StatusCode,
+					Latency:    status.Latency,
+					Error:      status.Error,
+				})
+			}
+		}
+	}
+	if sensitive := r.SensitiveSites; sensitive != nil {
+		for _, site := range sensitive.Sites {
+			raw.Domains = append(raw.Domains, DomainTiming{
+				Test:       "sensitive",
+				Group:      site.Category,
+				Domain:     site.Name,
+				Accessible: site.Reachable,
+				StatusCode: site.StatusCode,
+				Latency:    site.Latency,
+				Error:      site.Error,
+			})
+		}
+	}
+
+	return raw
+}
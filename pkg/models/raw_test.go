@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRawMeasurements(t *testing.T) {
+	result := &TestResult{
+		Success:      true,
+		Protocol:     &Protocol{Name: "de-1", Type: ProtocolVLESS, Server: "de.example.com", Port: 443},
+		Connectivity: &ConnectivityResult{Connected: true, ResponseTime: 90 * time.Millisecond},
+		Performance: &PerformanceResult{
+			Latency:            40 * time.Millisecond,
+			JitterSamples:      []time.Duration{41 * time.Millisecond, 39 * time.Millisecond},
+			DownloadThroughput: []float64{80, 95.5},
+		},
+		Gaming: &GamingResult{Endpoints: []GamingLatency{
+			{Service: "Riot", Region: "EU West", Times: []time.Duration{30 * time.Millisecond}},
+		}},
+		GeoAccess: &GeoAccessResult{Regions: map[string]map[string]AccessStatus{
+			"US": {"hulu.com": {StatusCode: 403}, "google.com": {Accessible: true}},
+		}},
+	}
+
+	raw := result.RawMeasurements()
+	if raw.Node != "de-1" || len(raw.Latency) != 5 || len(raw.Throughput) != 2 || len(raw.Domains) != 2 {
+		t.Fatalf("raw = %+v", raw)
+	}
+	if got := raw.Latency[4]; got.Test != "gaming" || got.Target != "Riot EU West" {
+		t.Errorf("gaming sample = %+v", got)
+	}
+	if got := raw.Throughput[1]; got.Second != 1 || got.Mbps != 95.5 {
+		t.Errorf("second throughput sample = %+v", got)
+	}
+	if raw.Domains[0].Domain != "google.com" || raw.Domains[1].StatusCode != 403 {
+		t.Errorf("domains = %+v, want sorted by domain", raw.Domains)
+	}
+
+	// The samples stay out of the results JSON
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "95.5") {
+		t.Error("per-second throughput written to the results JSON")
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+// RunInfo records how and from where a run was made, so a shared report
+// can be interpreted and the run repeated
+type RunInfo struct {
+	Version string `json:"version"`
+	// Args is the command line, with subscription URLs reduced to their host
+	Args []string `json:"args"`
+	// ConfigHash is the SHA-256 of the effective configuration, after
+	// flags were applied
+	ConfigHash string `json:"config_hash"`
+	Backend    string `json:"backend"`
+	// BackendVersions maps each backend binary found to its version line
+	BackendVersions map[string]string `json:"backend_versions,omitempty"`
+	OS              string            `json:"os"`
+	Arch            string            `json:"arch"`
+	// VantageCountry is the country tests ran from, detected from the real
+	// IP or set with -country
+	VantageCountry string `json:"vantage_country,omitempty"`
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Score weights for each measured category. Categories that were not
+// measured (e.g. speed tests disabled) are left out of the average.
+const (
+	scoreWeightLatency = 40.0
+	scoreWeightSpeed   = 30.0
+	scoreWeightPrivacy = 20.0
+	scoreWeightGeo     = 10.0
+)
+
+// Score returns an overall 0-100 quality score for the result.
+// Failed nodes always score 0.
+func (r *TestResult) Score() int {
+	if r == nil || !r.Success {
+		return 0
+	}
+
+	total := 0.0
+	weights := 0.0
+
+	if latency := r.latency(); latency > 0 {
+		total += scoreWeightLatency * latencyRating(latency)
+		weights += scoreWeightLatency
+	}
+
+	if r.Performance != nil && r.Performance.DownloadSpeed > 0 {
+		total += scoreWeightSpeed * clampRating(r.Performance.DownloadSpeed/50.0)
+		weights += scoreWeightSpeed
+	}
+
+	if r.Privacy != nil {
+		total += scoreWeightPrivacy * clampRating(float64(r.Privacy.Score)/100.0)
+		weights += scoreWeightPrivacy
+	}
+
+	if r.GeoAccess != nil && r.GeoAccess.Summary.TotalTested > 0 {
+		total += scoreWeightGeo * clampRating(r.GeoAccess.Summary.AccessPercentage/100.0)
+		weights += scoreWeightGeo
+	}
+
+	if weights == 0 {
+		return 0
+	}
+
+	return int(total/weights*100.0 + 0.5)
+}
+
+// Grade returns a letter grade for the score: A (80+), B (60+), C (40+),
+// D for the rest of the working nodes and F for failed ones
+func (r *TestResult) Grade() string {
+	if r == nil || !r.Success {
+		return "F"
+	}
+	return GradeOf(r.Score())
+}
+
+// GradeOf returns the letter grade of a 0-100 score: A (80+), B (60+),
+// C (40+) or D
+func GradeOf(score int) string {
+	switch {
+	case score >= 80:
+		return "A"
+	case score >= 60:
+		return "B"
+	case score >= 40:
+		return "C"
+	default:
+		return "D"
+	}
+}
+
+// LatencyScore rates a latency 0-100 as the overall score does
+func LatencyScore(latency time.Duration) int {
+	return int(latencyRating(latency)*100 + 0.5)
+}
+
+// SpeedScore rates a download speed in Mbps 0-100 as the overall score does
+func SpeedScore(mbps float64) int {
+	return int(clampRating(mbps/50.0)*100 + 0.5)
+}
+
+// latency returns the best available latency measurement
+func (r *TestResult) latency() time.Duration {
+	if r.Performance != nil && r.Performance.Latency > 0 {
+		return r.Performance.Latency
+	}
+	if r.Connectivity != nil {
+		return r.Connectivity.ResponseTime
+	}
+	return 0
+}
+
+// latencyRating maps latency to 0..1: 100ms or less is perfect, 2s or more is 0
+func latencyRating(latency time.Duration) float64 {
+	const best, worst = 100 * time.Millisecond, 2 * time.Second
+	if latency <= best {
+		return 1
+	}
+	if latency >= worst {
+		return 0
+	}
+	return 1 - float64(latency-best)/float64(worst-best)
+}
+
+func clampRating(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// RankResults returns the successful results ordered from best to worst.
+// Ties on score are broken by lower latency.
+func RankResults(results []*TestResult) []*TestResult {
+	ranked := make([]*TestResult, 0, len(results))
+	for _, result := range results {
+		if result != nil && result.Success {
+			ranked = append(ranked, result)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		si, sj := ranked[i].Score(), ranked[j].Score()
+		if si != sj {
+			return si > sj
+		}
+		return ranked[i].latency() < ranked[j].latency()
+	})
+
+	return ranked
+}
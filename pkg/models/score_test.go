@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScoreFailedIsZero(t *testing.T) {
+	result := &TestResult{Success: false}
+	if score := result.Score(); score != 0 {
+		t.Fatalf("expected score 0 for failed result, got %d", score)
+	}
+}
+
+func TestRankResultsOrdersByScore(t *testing.T) {
+	slow := &TestResult{
+		Success:      true,
+		Protocol:     &Protocol{Name: "slow"},
+		Connectivity: &ConnectivityResult{Connected: true, ResponseTime: 1500 * time.Millisecond},
+	}
+	fast := &TestResult{
+		Success:      true,
+		Protocol:     &Protocol{Name: "fast"},
+		Connectivity: &ConnectivityResult{Connected: true, ResponseTime: 80 * time.Millisecond},
+	}
+	failed := &TestResult{Success: false, Protocol: &Protocol{Name: "failed"}}
+
+	ranked := RankResults([]*TestResult{slow, failed, fast})
+	if len(ranked) != 2 {
+		t.Fatalf("expected 2 ranked results, got %d", len(ranked))
+	}
+	if ranked[0].Protocol.Name != "fast" {
+		t.Fatalf("expected fast node first, got %s", ranked[0].Protocol.Name)
+	}
+}
+
+func TestGrade(t *testing.T) {
+	tests := []struct {
+		latency time.Duration
+		grade   string
+	}{
+		{80 * time.Millisecond, "A"},
+		{700 * time.Millisecond, "B"},
+		{1100 * time.Millisecond, "C"},
+		{1900 * time.Millisecond, "D"},
+	}
+	for _, tt := range tests {
+		result := &TestResult{
+			Success:      true,
+			Connectivity: &ConnectivityResult{Connected: true, ResponseTime: tt.latency},
+		}
+		if grade := result.Grade(); grade != tt.grade {
+			t.Errorf("latency %v: grade %s (score %d), want %s", tt.latency, grade, result.Score(), tt.grade)
+		}
+	}
+
+	if grade := (&TestResult{Success: false}).Grade(); grade != "F" {
+		t.Errorf("failed result graded %s, want F", grade)
+	}
+}